@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/logger"
+	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/stemsi/exstem-backend/internal/service"
+	"github.com/stemsi/exstem-backend/internal/storage"
+)
+
+func main() {
+	// ─── CLI Flags ──────────────────────────────────────────────────────
+	yes := flag.Bool("yes", false, "Restore without asking for confirmation")
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 1 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	// ─── Load Configuration ────────────────────────────────────────────
+	cfg := config.Load()
+
+	// ─── Initialize Logger ─────────────────────────────────────────────
+	log := logger.Setup(cfg.LogLevel, cfg.LogFormat)
+
+	ctx := context.Background()
+
+	// ─── Connect to PostgreSQL ─────────────────────────────────────────
+	pool, err := database.NewPostgresPool(ctx, cfg, log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to PostgreSQL")
+	}
+	defer pool.Close()
+
+	// ─── Connect to Redis ──────────────────────────────────────────────
+	// A restore clears the caches the running servers built from the old contents.
+	rdb, err := database.NewRedisClient(ctx, cfg, log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to Redis")
+	}
+	defer rdb.Close()
+	examCache := repository.NewExamCache(rdb, cfg.ExamCacheTTL, log)
+
+	backupService := service.NewBackupService(pool, rdb, examCache, storage.New(cfg), cfg, log)
+
+	// ─── Execute Command ───────────────────────────────────────────────
+	switch args[0] {
+	case "create":
+		backup, err := backupService.Create(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Backup failed")
+		}
+		fmt.Printf("Created %s (%d bytes)\n", backup.Name, backup.Size)
+
+	case "list":
+		backups, err := backupService.List(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to list backups")
+		}
+		if len(backups) == 0 {
+			fmt.Println("No backups found.")
+		}
+		for _, b := range backups {
+			fmt.Printf("%s\t%d bytes\tschema v%d\n", b.Name, b.Size, b.SchemaVersion)
+		}
+
+	case "restore":
+		if len(args) < 2 {
+			log.Fatal().Msg("restore requires a backup name")
+		}
+		name := args[1]
+		if !*yes {
+			fmt.Printf("This replaces ALL data in the database with %s. Type the backup name to continue: ", name)
+			var confirm string
+			fmt.Scanln(&confirm)
+			if confirm != name {
+				fmt.Println("Aborted.")
+				os.Exit(1)
+			}
+		}
+		if err := backupService.Restore(ctx, name); err != nil {
+			log.Fatal().Err(err).Msg("Restore failed")
+		}
+		fmt.Printf("Restored %s\n", name)
+
+	default:
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: backup [flags] <command>")
+	fmt.Println("Commands: create, list, restore <name>")
+	fmt.Println("Flags:")
+	flag.PrintDefaults()
+}
@@ -85,8 +85,8 @@ func main() {
 	password = strings.TrimRight(password, "\r")
 
 	fmt.Println() // Newline after password input
-	if len(password) < 6 {
-		fmt.Println("Error: Password must be at least 6 characters")
+	if err := service.NewAuthService(cfg, nil).CheckPasswordPolicy(password, username, email); err != nil {
+		fmt.Printf("Error: %v\n", err)
 		return
 	}
 
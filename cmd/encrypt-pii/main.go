@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/logger"
+	"github.com/stemsi/exstem-backend/internal/pii"
+)
+
+// piiColumns are the columns stored encrypted when a PII key is configured.
+var piiColumns = []struct{ table, column string }{
+	{"students", "religion"},
+	{"exam_target_rules", "religion"},
+}
+
+func main() {
+	// ─── CLI Flags ──────────────────────────────────────────────────────
+	decrypt := flag.Bool("decrypt", false, "Write encrypted values back as plaintext, e.g. before changing the key")
+	flag.Parse()
+
+	// ─── Load Configuration ────────────────────────────────────────────
+	cfg := config.Load()
+
+	// ─── Initialize Logger ─────────────────────────────────────────────
+	log := logger.Setup(cfg.LogLevel, cfg.LogFormat)
+
+	ctx := context.Background()
+
+	// ─── Configure PII Encryption ──────────────────────────────────────
+	if err := pii.Configure(cfg.PIIEncryptionKey); err != nil {
+		log.Fatal().Err(err).Msg("Invalid PII_ENCRYPTION_KEY")
+	}
+
+	// ─── Connect to PostgreSQL ─────────────────────────────────────────
+	pool, err := database.NewPostgresPool(ctx, cfg, log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to PostgreSQL")
+	}
+	defer pool.Close()
+
+	if !pii.Enabled() {
+		log.Fatal().Msg("PII_ENCRYPTION_KEY is not set")
+	}
+
+	if *decrypt {
+		fmt.Println("=== Decrypt PII Columns ===")
+	} else {
+		fmt.Println("=== Encrypt PII Columns ===")
+	}
+
+	// The columns hold few distinct values, so each is converted with one update.
+	for _, col := range piiColumns {
+		n, err := convertColumn(ctx, pool, col.table, col.column, *decrypt)
+		if err != nil {
+			log.Fatal().Err(err).Str("table", col.table).Str("column", col.column).Msg("Failed to convert column")
+		}
+		fmt.Printf("%s.%s: %d rows converted\n", col.table, col.column, n)
+	}
+}
+
+// convertColumn encrypts the plaintext values of a column, or decrypts its
+// encrypted ones, in a single transaction.
+func convertColumn(ctx context.Context, pool *pgxpool.Pool, table, column string, decrypt bool) (int64, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	op := "NOT LIKE"
+	if decrypt {
+		op = "LIKE"
+	}
+	rows, err := tx.Query(ctx, fmt.Sprintf(
+		`SELECT DISTINCT %[1]s FROM %[2]s WHERE %[1]s IS NOT NULL AND %[1]s %[3]s $1`, column, table, op),
+		pii.Prefix+"%")
+	if err != nil {
+		return 0, err
+	}
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		values = append(values, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, v := range values {
+		converted := pii.Encrypt(v)
+		if decrypt {
+			if converted, err = pii.Decrypt(v); err != nil {
+				return 0, err
+			}
+		}
+		tag, err := tx.Exec(ctx, fmt.Sprintf(`UPDATE %[2]s SET %[1]s = $1 WHERE %[1]s = $2`, column, table), converted, v)
+		if err != nil {
+			return 0, err
+		}
+		total += tag.RowsAffected()
+	}
+	return total, tx.Commit(ctx)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/logger"
 	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/pii"
 	"github.com/stemsi/exstem-backend/internal/repository"
 	"github.com/stemsi/exstem-backend/internal/service"
 )
@@ -20,6 +21,10 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
 
+	if err := pii.Configure(cfg.PIIEncryptionKey); err != nil {
+		log.Fatal().Err(err).Msg("Invalid PII_ENCRYPTION_KEY")
+	}
+
 	pool, err := database.NewPostgresPool(ctx, cfg, log)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to PostgreSQL")
@@ -28,9 +33,10 @@ func main() {
 
 	classRepo := repository.NewClassRepository(pool)
 	studentRepo := repository.NewStudentRepository(pool)
+	majorRepo := repository.NewMajorRepository(pool)
 
-	classService := service.NewClassService(classRepo)
-	studentService := service.NewStudentService(studentRepo)
+	classService := service.NewClassService(classRepo, majorRepo)
+	studentService := service.NewStudentService(studentRepo, service.NewAuthService(cfg, nil))
 
 	const totalStudents = 3000
 
@@ -53,6 +59,18 @@ func main() {
 		{"XII", "TSM", 2},
 	}
 
+	// Classes must name an existing major.
+	for _, c := range classesToCreate {
+		if _, err := majorRepo.GetByCode(ctx, c.Major); err == nil {
+			continue
+		} else if err != pgx.ErrNoRows {
+			log.Fatal().Err(err).Msg("Failed to check existing major")
+		}
+		if err := majorRepo.Create(ctx, &model.Major{Code: c.Major, LongName: c.Major}); err != nil {
+			log.Fatal().Err(err).Msg("Failed to create major")
+		}
+	}
+
 	var classIDs []int
 
 	for _, c := range classesToCreate {
@@ -2,20 +2,28 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/chaos"
 	"github.com/stemsi/exstem-backend/internal/config"
 	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/handler"
 	"github.com/stemsi/exstem-backend/internal/logger"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/pii"
+	"github.com/stemsi/exstem-backend/internal/queue"
 	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/stemsi/exstem-backend/internal/resilience"
 	"github.com/stemsi/exstem-backend/internal/router"
 	"github.com/stemsi/exstem-backend/internal/service"
+	"github.com/stemsi/exstem-backend/internal/storage"
 	"github.com/stemsi/exstem-backend/internal/validator"
 	"github.com/stemsi/exstem-backend/internal/worker"
 )
@@ -25,19 +33,60 @@ func main() {
 	cfg := config.Load()
 
 	// ─── Initialize Logger ─────────────────────────────────────────────
-	log := logger.Setup(cfg.LogLevel, cfg.LogFormat)
+	hostname, _ := os.Hostname()
+	log, closeLogs := logger.New(logger.Options{
+		Level:           cfg.LogLevel,
+		Format:          cfg.LogFormat,
+		ComponentLevels: cfg.LogComponentLevels,
+		File:            cfg.LogFile,
+		FileMaxSizeMB:   cfg.LogFileMaxSizeMB,
+		FileMaxBackups:  cfg.LogFileMaxBackups,
+		Syslog:          cfg.LogSyslog,
+		SyslogTag:       cfg.LogSyslogTag,
+		HTTPURL:         cfg.LogHTTPURL,
+		HTTPFormat:      cfg.LogHTTPFormat,
+		HTTPAuth:        cfg.LogHTTPAuth,
+		Instance:        hostname,
+	})
 	log.Info().
 		Str("port", cfg.ServerPort).
 		Str("mode", cfg.GinMode).
 		Str("log_level", cfg.LogLevel).
 		Msg("Starting ExStem Backend")
 
+	// ─── Fault Injection (staging only) ───────────────────────────────
+	if cfg.ChaosMode {
+		if cfg.GinMode == gin.ReleaseMode {
+			log.Fatal().Msg("CHAOS_MODE is set with GIN_MODE=release, refusing to start")
+		}
+		chaos.Configure(&chaos.Settings{
+			RedisLatencyPercent:  cfg.ChaosRedisLatencyPercent,
+			RedisMaxLatency:      cfg.ChaosRedisMaxLatency,
+			WSDropPercent:        cfg.ChaosWSDropPercent,
+			DBCommitDelayPercent: cfg.ChaosDBCommitDelayPercent,
+			DBMaxCommitDelay:     cfg.ChaosDBMaxCommitDelay,
+		})
+		log.Warn().
+			Int("redis_latency_percent", cfg.ChaosRedisLatencyPercent).
+			Dur("redis_max_latency", cfg.ChaosRedisMaxLatency).
+			Int("ws_drop_percent", cfg.ChaosWSDropPercent).
+			Int("db_commit_delay_percent", cfg.ChaosDBCommitDelayPercent).
+			Dur("db_max_commit_delay", cfg.ChaosDBMaxCommitDelay).
+			Msg("CHAOS MODE: injecting faults, do not use in production")
+	}
+
 	// ─── Initialize Validator ──────────────────────────────────────────
 	validator.Setup()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// ─── Configure PII Encryption ──────────────────────────────────────
+	if err := pii.Configure(cfg.PIIEncryptionKey); err != nil {
+		log.Fatal().Err(err).Msg("Invalid PII_ENCRYPTION_KEY")
+	}
+	log.Info().Bool("pii_encryption", pii.Enabled()).Msg("PII encryption configured")
+
 	// ─── Connect to PostgreSQL ─────────────────────────────────────────
 	pool, err := database.NewPostgresPool(ctx, cfg, log)
 	if err != nil {
@@ -52,6 +101,15 @@ func main() {
 	}
 	defer rdb.Close()
 
+	// ─── Worker Queue ─────────────────────────────────────────────────
+	// The consumer name identifies this instance within the stream consumer group and
+	// outlives restarts, so the jobs a previous run left pending are replayed.
+	jobs, err := queue.New(cfg.QueueBackend, rdb, cfg.InstanceID)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up worker queue")
+	}
+	log.Info().Str("backend", cfg.QueueBackend).Msg("Worker queue ready")
+
 	// ─── Initialize Repositories ───────────────────────────────────────
 	classRepo := repository.NewClassRepository(pool)
 	_ = classRepo // Available for future use
@@ -59,47 +117,74 @@ func main() {
 	adminRepo := repository.NewAdminRepository(pool)
 	roleRepo := repository.NewRoleRepository(pool)
 	roomRepo := repository.NewRoomRepository(pool)
-	examRepo := repository.NewExamRepository(pool)
+	examCache := repository.NewExamCache(rdb, cfg.ExamCacheTTL, log)
+	examRepo := repository.NewExamRepository(pool, examCache)
 	questionRepo := repository.NewQuestionRepository(pool)
 	sessionRepo := repository.NewExamSessionRepository(pool)
-	targetRepo := repository.NewExamTargetRuleRepository(pool)
+	targetRepo := repository.NewExamTargetRuleRepository(pool, examCache)
 	roomAssignmentRepo := repository.NewRoomAssignmentRepository(pool)
 	settingRepo := repository.NewSettingRepository(pool)
 	subjectRepo := repository.NewSubjectRepository(pool)
 	majorRepo := repository.NewMajorRepository(pool)
 	dashboardRepo := repository.NewDashboardRepository(pool)
 	monitorRepo := repository.NewMonitorRepository(pool, rdb)
+	systemMetricsRepo := repository.NewSystemMetricsRepository(pool)
+	mediaRepo := repository.NewMediaRepository(pool)
+	auditLogRepo := repository.NewAuditLogRepository(pool)
+	eraporRepo := repository.NewEraporRepository(pool)
+	notificationRepo := repository.NewNotificationRepository(pool)
+	guardianRepo := repository.NewGuardianRepository(pool)
+	retentionRepo := repository.NewRetentionRepository(pool)
+	assessmentRepo := repository.NewAssessmentRepository(pool, examCache)
+
+	// ─── Redis Health ─────────────────────────────────────────────────
+	// Flips autosave/submit to direct PostgreSQL writes while Redis is down.
+	redisHealth := resilience.NewHealthMonitor("redis", func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	}, 2*time.Second, 3, 3, log)
 
 	// ─── Initialize Services ──────────────────────────────────────────
 	authService := service.NewAuthService(cfg, rdb)
-	studentService := service.NewStudentService(studentRepo)
+	studentService := service.NewStudentService(studentRepo, authService)
 	adminService := service.NewAdminService(adminRepo, roleRepo)
-	examService := service.NewExamService(examRepo, questionRepo, targetRepo, rdb, log)
-	questionService := service.NewQuestionService(questionRepo)
-	sessionService := service.NewExamSessionService(sessionRepo, examRepo, targetRepo, rdb)
-	mediaService := service.NewMediaService(cfg)
-	adminUserService := service.NewAdminUserService(pool)
+	examService := service.NewExamService(examRepo, questionRepo, targetRepo, settingRepo, subjectRepo, mediaRepo, rdb, jobs, log)
+	questionService := service.NewQuestionService(questionRepo, mediaRepo)
+	sessionService := service.NewExamSessionService(sessionRepo, examRepo, targetRepo, examService, rdb, jobs, redisHealth, log)
+	mediaService := service.NewMediaService(cfg, mediaRepo)
+	adminUserService := service.NewAdminUserService(pool, authService)
 	adminRoleService := service.NewAdminRoleService(roleRepo)
-	classService := service.NewClassService(classRepo)
-	settingService := service.NewSettingService(settingRepo, log)
+	classService := service.NewClassService(classRepo, majorRepo)
+	settingService := service.NewSettingService(settingRepo, rdb, log)
 	subjectService := service.NewSubjectService(subjectRepo, log)
 	majorService := service.NewMajorService(majorRepo)
 	roomService := service.NewRoomService(roomRepo)
 	roomAssignmentService := service.NewRoomAssignmentService(roomAssignmentRepo, roomRepo, settingService)
-	dashboardService := service.NewDashboardService(dashboardRepo)
-	monitorService := service.NewMonitorService(monitorRepo)
+	dashboardService := service.NewDashboardService(dashboardRepo, jobs, redisHealth)
+	systemMetricsService := service.NewSystemMetricsService(systemMetricsRepo, cfg.MetricsHistoryInterval)
+	monitorService := service.NewMonitorService(monitorRepo, settingRepo, systemMetricsService, jobs, redisHealth)
+	auditService := service.NewAuditService(auditLogRepo, log)
+	oidcService := service.NewOIDCService(cfg, rdb, adminRepo, roleRepo, authService)
+	directorySyncService := service.NewDirectorySyncService(pool, cfg, log)
+	dapodikImportService := service.NewDapodikImportService(pool)
+	eraporService := service.NewEraporService(eraporRepo, cfg)
+	notificationService := service.NewNotificationService(notificationRepo, rdb, cfg, log)
+	loginMonitor := service.NewLoginMonitor(rdb, notificationService, cfg, log)
+	guardianService := service.NewGuardianService(guardianRepo, authService)
+	backupService := service.NewBackupService(pool, rdb, examCache, storage.New(cfg), cfg, log)
+	retentionService := service.NewRetentionService(retentionRepo, auditService, log)
+	assessmentService := service.NewAssessmentService(assessmentRepo, examRepo, targetRepo, sessionRepo, examService)
 
 	// ─── Initialize Handlers ──────────────────────────────────────────
 	handlers := &router.Handlers{
-		Auth:           handler.NewAuthHandler(authService, studentService, adminService),
-		StudentPortal:  handler.NewStudentPortalHandler(sessionService, examService, studentService, rdb),
-		StudentMgmt:    handler.NewStudentManagementHandler(studentService, authService, settingService),
+		Auth:           handler.NewAuthHandler(authService, studentService, adminService, oidcService, guardianService, loginMonitor),
+		StudentPortal:  handler.NewStudentPortalHandler(sessionService, examService, studentService, assessmentService, settingService, rdb),
+		StudentMgmt:    handler.NewStudentManagementHandler(studentService, authService, settingService, auditService, directorySyncService, dapodikImportService, log),
 		Admin:          handler.NewAdminHandler(authService),
 		Exam:           handler.NewExamHandler(examService, sessionService),
 		Question:       handler.NewQuestionHandler(questionService),
 		Media:          handler.NewMediaHandler(mediaService),
-		WS:             handler.NewWSHandler(rdb, examService, sessionService, studentService, log, cfg.AllowedOrigins),
-		AdminUser:      handler.NewAdminUserHandler(adminUserService),
+		WS:             handler.NewWSHandler(rdb, jobs, examService, sessionService, studentService, log, cfg.AllowedOrigins, cfg.AutosaveBackpressureThreshold, cfg.WSCompressionLevel, cfg.WSMaxConnections, cfg.WSDuplicatePolicy),
+		AdminUser:      handler.NewAdminUserHandler(adminUserService, authService),
 		AdminRole:      handler.NewAdminRoleHandler(adminRoleService),
 		Class:          handler.NewClassHandler(classService),
 		Setting:        handler.NewSettingHandler(settingService),
@@ -109,21 +194,71 @@ func main() {
 		RoomAssignment: handler.NewRoomAssignmentHandler(roomAssignmentService),
 		Dashboard:      handler.NewDashboardHandler(dashboardService),
 		Monitor:        handler.NewMonitorHandler(rdb, examService, sessionService, monitorService, log),
-		System:         handler.NewSystemHandler(rdb, log),
+		System:         handler.NewSystemHandler(pool, rdb, jobs, redisHealth, systemMetricsService, log),
+		Erapor:         handler.NewEraporHandler(eraporService, log),
+		Notification:   handler.NewNotificationHandler(notificationService, log),
+		PublicResult:   handler.NewPublicResultHandler(examService, sessionService),
+		Guardian:       handler.NewGuardianHandler(guardianService),
+		GuardianPortal: handler.NewGuardianPortalHandler(guardianService, sessionService),
+		Backup:         handler.NewBackupHandler(backupService, auditService),
+		Retention:      handler.NewRetentionHandler(retentionService),
+		Assessment:     handler.NewAssessmentHandler(assessmentService),
 	}
 
 	// ─── Start Background Workers ─────────────────────────────────────
 	workerCtx, workerCancel := context.WithCancel(context.Background())
 
-	autosaveWorker := worker.NewAutosaveWorker(pool, rdb, log)
-	scoringWorker := worker.NewScoringWorker(pool, rdb, log)
-	cheatWorker := worker.NewCheatWorker(pool, rdb, log)
-	questionOrderWorker := worker.NewQuestionOrderWorker(pool, rdb, log)
+	autosaveWorker := worker.NewAutosaveWorker(pool, rdb, jobs, cfg.AutosaveFlushSLO, log)
+	scoringWorker := worker.NewScoringWorker(pool, rdb, jobs, log)
+	cheatWorker := worker.NewCheatWorker(pool, jobs, notificationService, log)
+	questionOrderWorker := worker.NewQuestionOrderWorker(pool, jobs, log)
+	mediaCleanupWorker := worker.NewMediaCleanupWorker(mediaService, log)
+	examStatusWorker := worker.NewExamStatusWorker(examService, notificationService, log)
+	examStatsWorker := worker.NewExamStatsWorker(pool, jobs, log)
+	monitorRecordWorker := worker.NewMonitorRecordWorker(pool, rdb, jobs, monitorService, log)
+	requestStatsWorker := worker.NewRequestStatsWorker(rdb, log)
+	examPaperWorker := worker.NewExamPaperWorker(examService, jobs, log)
 
 	go autosaveWorker.Start(workerCtx)
 	go scoringWorker.Start(workerCtx)
 	go cheatWorker.Start(workerCtx)
 	go questionOrderWorker.Start(workerCtx)
+	go mediaCleanupWorker.Start(workerCtx)
+	go examStatusWorker.Start(workerCtx)
+	go examStatsWorker.Start(workerCtx)
+	go monitorRecordWorker.Start(workerCtx)
+	go requestStatsWorker.Start(workerCtx)
+	go examPaperWorker.Start(workerCtx)
+	go examCache.Listen(workerCtx)
+	go settingService.ListenRemoteConfig(workerCtx)
+
+	if notificationService.Enabled() {
+		queueBacklogWorker := worker.NewQueueBacklogWorker(jobs, notificationService, cfg.QueueBacklogThreshold, log)
+		go queueBacklogWorker.Start(workerCtx)
+	}
+
+	if cfg.MetricsHistoryInterval > 0 {
+		sampleMetrics := func() model.MetricsSample {
+			s := handlers.System.Sample()
+			s.WSConnections = int(handlers.WS.ActiveConnections())
+			return s
+		}
+		metricsHistoryWorker := worker.NewMetricsHistoryWorker(systemMetricsService, sampleMetrics, fmt.Sprintf("%s-%d", hostname, os.Getpid()), cfg.MetricsHistoryInterval, cfg.MetricsHistoryRetention, log)
+		go metricsHistoryWorker.Start(workerCtx)
+	}
+
+	if cfg.RetentionRunHour >= 0 && cfg.RetentionRunHour <= 23 {
+		retentionWorker := worker.NewRetentionWorker(retentionService, rdb, cfg.RetentionRunHour, log)
+		go retentionWorker.Start(workerCtx)
+	}
+
+	if directorySyncService.Enabled() && cfg.StudentDirectorySyncInterval > 0 {
+		directorySyncWorker := worker.NewDirectorySyncWorker(directorySyncService, cfg.StudentDirectorySyncInterval, log)
+		go directorySyncWorker.Start(workerCtx)
+	}
+
+	redisHealth.OnRecover(sessionService.ResyncRedis)
+	go redisHealth.Start(workerCtx)
 
 	// ─── Prewarm Redis Caches ─────────────────────────────────────────
 	// Load all published exams into Redis BEFORE accepting traffic.
@@ -132,8 +267,22 @@ func main() {
 		log.Warn().Err(err).Msg("Cache prewarm failed")
 	}
 
+	// Restore the Redis state of sessions still open in PostgreSQL, in case Redis
+	// lost it in a crash.
+	if report, err := sessionService.ReconcileRedis(ctx); err != nil {
+		log.Warn().Err(err).Msg("Session reconciliation failed")
+	} else {
+		log.Info().
+			Int("sessions", report.Sessions).
+			Int("start_times", report.StartTimes).
+			Int("active_exams", report.ActiveExams).
+			Int("question_orders", report.QuestionOrders).
+			Int("answers", report.Answers).
+			Msg("Session reconciliation complete")
+	}
+
 	// ─── Setup Router ──────────────────────────────────────────────────
-	r := router.SetupRouter(authService, handlers, cfg)
+	r := router.SetupRouter(authService, auditService, handlers, cfg, log)
 
 	// ─── Create HTTP Server ────────────────────────────────────────────
 	srv := &http.Server{
@@ -169,6 +318,7 @@ func main() {
 	time.Sleep(2 * time.Second) // Allow workers to drain.
 
 	log.Info().Msg("Shutdown complete")
+	closeLogs()
 }
 
 // init sets zerolog global defaults before main runs.
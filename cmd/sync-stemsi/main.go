@@ -9,17 +9,18 @@ import (
 	"github.com/stemsi/exstem-backend/internal/config"
 	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/logger"
+	"github.com/stemsi/exstem-backend/internal/pii"
 	"github.com/stemsi/exstem-backend/internal/service"
 )
 
 func main() {
 	// ─── CLI Flags ──────────────────────────────────────────────────────
-	syncType := flag.String("type", "", "Specify the type of synchronization: 'students', 'teachers', or 'all'")
+	syncType := flag.String("type", "", "Specify the type of synchronization: 'students', 'teachers', 'directory', or 'all'")
 	flag.Parse()
 
 	if *syncType == "" {
 		fmt.Println("Error: The -type flag is required.")
-		fmt.Println("Usage: sync-stemsi -type=[students|teachers|all]")
+		fmt.Println("Usage: sync-stemsi -type=[students|teachers|directory|all]")
 		os.Exit(1)
 	}
 
@@ -31,6 +32,11 @@ func main() {
 
 	ctx := context.Background()
 
+	// ─── Configure PII Encryption ──────────────────────────────────────
+	if err := pii.Configure(cfg.PIIEncryptionKey); err != nil {
+		log.Fatal().Err(err).Msg("Invalid PII_ENCRYPTION_KEY")
+	}
+
 	// ─── Connect to PostgreSQL ─────────────────────────────────────────
 	pool, err := database.NewPostgresPool(ctx, cfg, log)
 	if err != nil {
@@ -42,6 +48,7 @@ func main() {
 	// We need AuthService to inject into SyncService to handle default password hashing
 	authService := service.NewAuthService(cfg, nil) // Redis is not strictly necessary for simple hashing
 	syncService := service.NewSyncService(pool, authService, log)
+	directorySyncService := service.NewDirectorySyncService(pool, cfg, log)
 
 	fmt.Printf("=== Starting Data Synchronization (Type: %s) ===\n", *syncType)
 
@@ -64,8 +71,19 @@ func main() {
 		}
 	}
 
-	if *syncType != "students" && *syncType != "teachers" && *syncType != "all" {
-		fmt.Printf("Error: Unsupported sync type '%s'. Use 'students', 'teachers', or 'all'.\n", *syncType)
+	// The directory source is optional, so "all" only includes it when configured.
+	if *syncType == "directory" || (*syncType == "all" && directorySyncService.Enabled()) {
+		result, err := directorySyncService.Sync(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to synchronize student directory")
+		} else {
+			fmt.Printf("Directory sync: %d created, %d updated, %d deactivated, %d skipped.\n",
+				result.Created, result.Updated, result.Deactivated, len(result.Skipped))
+		}
+	}
+
+	if *syncType != "students" && *syncType != "teachers" && *syncType != "directory" && *syncType != "all" {
+		fmt.Printf("Error: Unsupported sync type '%s'. Use 'students', 'teachers', 'directory', or 'all'.\n", *syncType)
 		os.Exit(1)
 	}
 
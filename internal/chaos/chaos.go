@@ -0,0 +1,114 @@
+// Package chaos injects faults for rehearsing exam-day failures on staging: random
+// Redis latency, dropped exam socket frames and delayed database commits. It is off
+// unless configured, and then every injection point is a no-op.
+package chaos
+
+import (
+	"context"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Settings are the faults to inject. Each fault strikes the given percent of
+// operations; a zero percent disables it.
+type Settings struct {
+	// RedisLatencyPercent of Redis commands and pipelines wait up to RedisMaxLatency.
+	RedisLatencyPercent int
+	RedisMaxLatency     time.Duration
+	// WSDropPercent of exam socket frames, in both directions, are dropped.
+	WSDropPercent int
+	// DBCommitDelayPercent of PostgreSQL commits wait up to DBMaxCommitDelay.
+	DBCommitDelayPercent int
+	DBMaxCommitDelay     time.Duration
+}
+
+var (
+	mu       sync.RWMutex
+	settings *Settings
+)
+
+// Configure enables fault injection with s, or disables it when s is nil.
+func Configure(s *Settings) {
+	mu.Lock()
+	defer mu.Unlock()
+	settings = s
+}
+
+// Enabled reports whether faults are injected.
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings != nil
+}
+
+func current() *Settings {
+	mu.RLock()
+	defer mu.RUnlock()
+	return settings
+}
+
+// strikes reports whether a fault of percent strikes this time.
+func strikes(percent int) bool {
+	return percent > 0 && rand.IntN(100) < percent
+}
+
+// sleep waits a random time up to max, or until ctx is done.
+func sleep(ctx context.Context, max time.Duration) {
+	if max <= 0 {
+		return
+	}
+	t := time.NewTimer(rand.N(max))
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// DropFrame reports whether an exam socket frame is to be dropped.
+func DropFrame() bool {
+	s := current()
+	return s != nil && strikes(s.WSDropPercent)
+}
+
+// DelayCommit holds up a PostgreSQL commit.
+func DelayCommit(ctx context.Context) {
+	if s := current(); s != nil && strikes(s.DBCommitDelayPercent) {
+		sleep(ctx, s.DBMaxCommitDelay)
+	}
+}
+
+// RedisHook delays Redis commands and pipelines while fault injection is enabled.
+type RedisHook struct{}
+
+var _ redis.Hook = RedisHook{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		delayRedis(ctx)
+		return next(ctx, cmd)
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		delayRedis(ctx)
+		return next(ctx, cmds)
+	}
+}
+
+func delayRedis(ctx context.Context) {
+	if s := current(); s != nil && strikes(s.RedisLatencyPercent) {
+		sleep(ctx, s.RedisMaxLatency)
+	}
+}
@@ -30,11 +30,37 @@ func (r *CacheKeyStruct) StudentAnswersKey(examID string, studentID int) string
 	return fmt.Sprintf("student:%d:exam:%s:answers", studentID, examID)
 }
 
+// StudentAnswersQueuedKey returns the cache key mapping each question to the save
+// timestamp of the latest answer queued for persistence
+func (r *CacheKeyStruct) StudentAnswersQueuedKey(examID string, studentID int) string {
+	return fmt.Sprintf("student:%d:exam:%s:answers_queued", studentID, examID)
+}
+
+// StudentWSConnKey returns the cache key holding the ID of a student's open exam socket
+func (r *CacheKeyStruct) StudentWSConnKey(examID string, studentID int) string {
+	return fmt.Sprintf("student:%d:exam:%s:ws_conn", studentID, examID)
+}
+
 // ExamPayloadKey returns the cache key for an exam's payload
 func (r *CacheKeyStruct) ExamPayloadKey(examID string) string {
 	return fmt.Sprintf("exam:%s:payload", examID)
 }
 
+// ExamCacheLockKey returns the cache key serializing rebuilds of an exam's cache
+func (r *CacheKeyStruct) ExamCacheLockKey(examID string) string {
+	return fmt.Sprintf("exam:%s:cache_lock", examID)
+}
+
+// ExamPaperJobKey returns the cache key holding a question paper export job
+func (r *CacheKeyStruct) ExamPaperJobKey(jobID string) string {
+	return fmt.Sprintf("exam_paper:%s", jobID)
+}
+
+// ExamPaperFileKey returns the cache key holding a finished question paper PDF
+func (r *CacheKeyStruct) ExamPaperFileKey(jobID string) string {
+	return fmt.Sprintf("exam_paper:%s:pdf", jobID)
+}
+
 // ExamDurationKey returns the cache key for an exam's duration
 func (r *CacheKeyStruct) ExamDurationKey(examID string) string {
 	return fmt.Sprintf("exam:%s:duration", examID)
@@ -55,14 +81,160 @@ func (r *CacheKeyStruct) ExamRandomOrderKey(examID string) string {
 	return fmt.Sprintf("exam:%s:random_order", examID)
 }
 
+// ExamPlayLimitsKey returns the cache key for an exam's per-question media play limits
+func (r *CacheKeyStruct) ExamPlayLimitsKey(examID string) string {
+	return fmt.Sprintf("exam:%s:play_limits", examID)
+}
+
+// ExamNavigationKey returns the cache key for an exam's question navigation policy
+func (r *CacheKeyStruct) ExamNavigationKey(examID string) string {
+	return fmt.Sprintf("exam:%s:navigation", examID)
+}
+
+// ExamCurrentQuestionsKey returns the cache key for the hash of each student's current question position
+func (r *CacheKeyStruct) ExamCurrentQuestionsKey(examID string) string {
+	return fmt.Sprintf("exam:%s:current_questions", examID)
+}
+
+// StudentNavPositionKey returns the cache key for the furthest question position a student has reached
+func (r *CacheKeyStruct) StudentNavPositionKey(examID string, studentID int) string {
+	return fmt.Sprintf("student:%d:exam:%s:nav_position", studentID, examID)
+}
+
+// ExamLastSeenKey returns the cache key for the hash of when each student's exam socket
+// last answered a ping or sent a message (unix seconds)
+func (r *CacheKeyStruct) ExamLastSeenKey(examID string) string {
+	return fmt.Sprintf("exam:%s:last_seen", examID)
+}
+
+// ExamDisconnectPausedKey returns the cache key for the hash of the seconds each
+// student's timer was paused for prolonged disconnects
+func (r *CacheKeyStruct) ExamDisconnectPausedKey(examID string) string {
+	return fmt.Sprintf("exam:%s:disconnect_paused", examID)
+}
+
+// ExamDisconnectPauseKey returns the cache key for the disconnect length (minutes) after
+// which an exam pauses a student's timer
+func (r *CacheKeyStruct) ExamDisconnectPauseKey(examID string) string {
+	return fmt.Sprintf("exam:%s:disconnect_pause", examID)
+}
+
+// ExamBreakPolicyKey returns the cache key for an exam's break allowance
+func (r *CacheKeyStruct) ExamBreakPolicyKey(examID string) string {
+	return fmt.Sprintf("exam:%s:break_policy", examID)
+}
+
+// StudentBreaksKey returns the cache key for a student's break usage (count, paused seconds, running break)
+func (r *CacheKeyStruct) StudentBreaksKey(examID string, studentID int) string {
+	return fmt.Sprintf("student:%d:exam:%s:breaks", studentID, examID)
+}
+
+// StudentMediaPlaysKey returns the cache key for a student's media play counters
+func (r *CacheKeyStruct) StudentMediaPlaysKey(examID string, studentID int) string {
+	return fmt.Sprintf("student:%d:exam:%s:media_plays", studentID, examID)
+}
+
 // StudentActiveExamKey returns the cache key for a student's currently active exam
 func (r *CacheKeyStruct) StudentActiveExamKey(studentID int) string {
 	return fmt.Sprintf("student:%d:active_exam", studentID)
 }
 
+// StudentSubmittedKey returns the cache key holding a student's computed score once submitted
+func (r *CacheKeyStruct) StudentSubmittedKey(examID string, studentID int) string {
+	return fmt.Sprintf("student:%d:exam:%s:submitted", studentID, examID)
+}
+
+// StudentSubmitLockKey returns the cache key locking a student's submit while it is graded
+func (r *CacheKeyStruct) StudentSubmitLockKey(examID string, studentID int) string {
+	return fmt.Sprintf("student:%d:exam:%s:submit_lock", studentID, examID)
+}
+
+// AdminSessionsKey returns the cache key for an admin's active sessions (JTI -> session)
+func (r *CacheKeyStruct) AdminSessionsKey(adminID int) string {
+	return fmt.Sprintf("admin:%d:sessions", adminID)
+}
+
+// GuardianSessionsKey returns the cache key for a guardian's set of active session JTIs
+func (r *CacheKeyStruct) GuardianSessionsKey(guardianID int) string {
+	return fmt.Sprintf("guardian:%d:sessions", guardianID)
+}
+
+// ImpersonationSessionKey returns the cache key registering an impersonation token (JTI -> "adminID:adminJTI")
+func (r *CacheKeyStruct) ImpersonationSessionKey(jti string) string {
+	return fmt.Sprintf("impersonation:%s", jti)
+}
+
+// OIDCStateKey returns the cache key holding a pending OIDC login's state
+func (r *CacheKeyStruct) OIDCStateKey(state string) string {
+	return fmt.Sprintf("oidc:state:%s", state)
+}
+
+// CheatAlertKey returns the cache key marking that a student's cheat alert was sent
+func (r *CacheKeyStruct) CheatAlertKey(examID string, studentID int) string {
+	return fmt.Sprintf("notify:cheat:%s:%d", examID, studentID)
+}
+
+// QueueBacklogAlertKey returns the cache key throttling worker queue backlog alerts
+func (r *CacheKeyStruct) QueueBacklogAlertKey() string {
+	return "notify:queue_backlog"
+}
+
+// LoginIPKey returns the cache key counting an IP's login successes and failures in
+// a window
+func (r *CacheKeyStruct) LoginIPKey(ip string, window int64) string {
+	return fmt.Sprintf("login:ip:%s:%d", ip, window)
+}
+
+// LoginIPAccountsKey returns the cache key estimating (HyperLogLog) the distinct
+// accounts an IP failed to log in to in a window
+func (r *CacheKeyStruct) LoginIPAccountsKey(ip string, window int64) string {
+	return fmt.Sprintf("login:ip:%s:%d:accounts", ip, window)
+}
+
+// LoginAccountKey returns the cache key counting an account's failed logins in a window
+func (r *CacheKeyStruct) LoginAccountKey(kind, account string, window int64) string {
+	return fmt.Sprintf("login:account:%s:%s:%d", kind, account, window)
+}
+
+// LoginAnomalyAlertKey returns the cache key marking that a login anomaly of a
+// subject (an IP or an account) was alerted in a window
+func (r *CacheKeyStruct) LoginAnomalyAlertKey(subject string, window int64) string {
+	return fmt.Sprintf("notify:login_anomaly:%s:%d", subject, window)
+}
+
+// MonitorProgressSnapshotLockKey returns the cache key that lets one instance record
+// the progress snapshots of running exams each interval
+func (r *CacheKeyStruct) MonitorProgressSnapshotLockKey() string {
+	return "monitor:progress_snapshot"
+}
+
 // ExamMonitorChannel returns the Redis PubSub channel name for an exam monitor
 func (r *CacheKeyStruct) ExamMonitorChannel(examID string) string {
 	return fmt.Sprintf("exam:%s:monitor", examID)
 }
 
+// ExamCacheChannel returns the Redis PubSub channel on which instances announce the
+// exams whose in-process cache entries are stale
+func (r *CacheKeyStruct) ExamCacheChannel() string {
+	return "exam_cache:invalidate"
+}
+
+// RemoteConfigChannel returns the Redis PubSub channel on which instances announce
+// that the student app's remote config changed
+func (r *CacheKeyStruct) RemoteConfigChannel() string {
+	return "remote_config:changed"
+}
+
 var CacheKey = NewCacheKeyStruct()
+
+// RetentionRunLockKey returns the cache key that lets one instance apply the retention
+// rules on a given day (YYYY-MM-DD)
+func (r *CacheKeyStruct) RetentionRunLockKey(day string) string {
+	return "retention:run:" + day
+}
+
+// RequestStatsKey returns the cache key for the hash of the API requests all instances
+// answered in a unix minute ("total") and how many failed with a 5xx status ("failed")
+func (r *CacheKeyStruct) RequestStatsKey(minute int64) string {
+	return fmt.Sprintf("metrics:requests:%d", minute)
+}
@@ -5,6 +5,9 @@ type WorkerKeyStruct struct {
 	PersistAnswersQueue       string
 	PersistScoresQueue        string
 	PersistQuestionOrderQueue string
+	RefreshExamStatsQueue     string
+	RecordMonitorEventsQueue  string
+	ExportExamPaperQueue      string
 }
 
 var WorkerKey = &WorkerKeyStruct{
@@ -12,4 +15,7 @@ var WorkerKey = &WorkerKeyStruct{
 	PersistAnswersQueue:       "persist_answers_queue",
 	PersistScoresQueue:        "persist_scores_queue",
 	PersistQuestionOrderQueue: "persist_question_order_queue",
+	RefreshExamStatsQueue:     "refresh_exam_stats_queue",
+	RecordMonitorEventsQueue:  "record_monitor_events_queue",
+	ExportExamPaperQueue:      "export_exam_paper_queue",
 }
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Bounds of the repositories' queries. DefaultQueryTimeout applies to calls whose
+// context has no deadline of its own, e.g. from workers, and also covers waiting for
+// a pooled connection, which statement_timeout does not. A transient failure is
+// retried up to queryAttempts times in all, backing off from queryRetryBackoff.
+const (
+	DefaultQueryTimeout = time.Minute
+	queryAttempts       = 3
+	queryRetryBackoff   = 50 * time.Millisecond
+)
+
+// DB is the pool as the repositories use it. Each call runs under a deadline, and a
+// statement that failed transiently — a serialization failure, a deadlock, or a
+// connection lost before it was sent — is retried with backoff. Statements inside a
+// transaction are never retried; the transaction is the caller's to repeat. Calls are
+// counted by operation, the repository method that made them.
+type DB struct {
+	pool *pgxpool.Pool
+}
+
+// Wrap returns the DB of pool.
+func Wrap(pool *pgxpool.Pool) *DB {
+	return &DB{pool: pool}
+}
+
+// Exec runs a statement that returns no rows.
+func (db *DB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	op, start := callerOperation(), time.Now()
+	ctx, cancel := withQueryDeadline(ctx)
+	defer cancel()
+
+	var tag pgconn.CommandTag
+	err := retry(ctx, op, func() error {
+		var err error
+		tag, err = db.pool.Exec(ctx, sql, args...)
+		return err
+	})
+	observeCall(op, time.Since(start))
+	return tag, err
+}
+
+// Query runs a query. Only sending it is retried; the deadline lasts until the rows
+// are closed, and so does the call as counted.
+func (db *DB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	op, start := callerOperation(), time.Now()
+	ctx, cancel := withQueryDeadline(ctx)
+
+	var rows pgx.Rows
+	err := retry(ctx, op, func() error {
+		var err error
+		rows, err = db.pool.Query(ctx, sql, args...)
+		return err
+	})
+	if err != nil {
+		cancel()
+		observeCall(op, time.Since(start))
+		return nil, err
+	}
+	return &deadlineRows{Rows: rows, cancel: cancel, op: op, start: start}, nil
+}
+
+// QueryRow runs a query returning at most one row once the row is scanned, retrying
+// the query and scan together.
+func (db *DB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return &retryRow{db: db, ctx: ctx, op: callerOperation(), sql: sql, args: args}
+}
+
+// Begin starts a transaction. Its statements run under the contexts passed to them.
+func (db *DB) Begin(ctx context.Context) (pgx.Tx, error) {
+	op, start := callerOperation(), time.Now()
+	ctx, cancel := withQueryDeadline(ctx)
+	defer cancel()
+
+	var tx pgx.Tx
+	err := retry(ctx, op, func() error {
+		var err error
+		tx, err = db.pool.Begin(ctx)
+		return err
+	})
+	observeCall(op, time.Since(start))
+	return tx, err
+}
+
+// CopyFrom bulk-inserts rows with the COPY protocol. It is not retried, as rows
+// already read from src cannot be read again.
+func (db *DB) CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error) {
+	op, start := callerOperation(), time.Now()
+	ctx, cancel := withQueryDeadline(ctx)
+	defer cancel()
+	n, err := db.pool.CopyFrom(ctx, table, columns, src)
+	countTimeout(ctx, op, err)
+	observeCall(op, time.Since(start))
+	return n, err
+}
+
+// Ping checks that the database answers.
+func (db *DB) Ping(ctx context.Context) error {
+	ctx, cancel := withQueryDeadline(ctx)
+	defer cancel()
+	return db.pool.Ping(ctx)
+}
+
+// retryRow defers its query to Scan, so that the query can be retried with it.
+type retryRow struct {
+	db   *DB
+	ctx  context.Context
+	op   string
+	sql  string
+	args []any
+}
+
+func (r *retryRow) Scan(dest ...any) error {
+	start := time.Now()
+	ctx, cancel := withQueryDeadline(r.ctx)
+	defer cancel()
+	err := retry(ctx, r.op, func() error {
+		return r.db.pool.QueryRow(ctx, r.sql, r.args...).Scan(dest...)
+	})
+	observeCall(r.op, time.Since(start))
+	return err
+}
+
+// deadlineRows releases the deadline of a query when its rows are closed.
+type deadlineRows struct {
+	pgx.Rows
+	cancel context.CancelFunc
+	op     string
+	start  time.Time
+	closed bool
+}
+
+func (r *deadlineRows) Close() {
+	r.Rows.Close()
+	r.cancel()
+	if !r.closed {
+		r.closed = true
+		observeCall(r.op, time.Since(r.start))
+	}
+}
+
+// withQueryDeadline bounds ctx by DefaultQueryTimeout unless it has a deadline.
+func withQueryDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, DefaultQueryTimeout)
+}
+
+// retry runs fn until it succeeds, fails for good, or runs out of attempts.
+func retry(ctx context.Context, op string, fn func() error) error {
+	backoff := queryRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt == queryAttempts || !retryable(err) {
+			countTimeout(ctx, op, err)
+			return err
+		}
+		countRetry(op)
+		select {
+		case <-ctx.Done():
+			countTimeout(ctx, op, err)
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// retryable reports whether a failed statement can safely run again: it was rolled
+// back as a serialization failure or deadlock victim, or never reached the server.
+func retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	return pgconn.SafeToRetry(err)
+}
+
+// countTimeout counts a call of op that failed because its deadline passed.
+func countTimeout(ctx context.Context, op string, err error) {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		queryTimeouts.Add(1)
+		countOp(op, func(c *opCounts) { c.timeouts++ })
+	}
+}
+
+// operationNames caches the operation of each call site by program counter.
+var operationNames sync.Map
+
+// callerOperation names the function that called the DB method calling it, such as
+// "ExamRepository.GetByID", dropping the package path.
+func callerOperation() string {
+	var pc [1]uintptr
+	if runtime.Callers(3, pc[:]) == 0 {
+		return "unknown"
+	}
+	if name, ok := operationNames.Load(pc[0]); ok {
+		return name.(string)
+	}
+	name := "unknown"
+	if fn := runtime.FuncForPC(pc[0] - 1); fn != nil {
+		name = fn.Name()
+		name = name[strings.LastIndexByte(name, '/')+1:]
+		if i := strings.IndexByte(name, '.'); i >= 0 {
+			name = name[i+1:]
+		}
+		name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	}
+	operationNames.Store(pc[0], name)
+	return name
+}
@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"fmt"
+	"strconv"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 	"github.com/stemsi/exstem-backend/internal/config"
@@ -17,6 +19,16 @@ func NewPostgresPool(ctx context.Context, cfg *config.Config, log zerolog.Logger
 	}
 
 	poolCfg.MaxConns = cfg.MaxDBConns
+	poolCfg.MinConns = cfg.MinDBConns
+	if cfg.DBStatementCacheSize > 0 {
+		poolCfg.ConnConfig.StatementCacheCapacity = cfg.DBStatementCacheSize
+	} else {
+		poolCfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
+	}
+	poolCfg.ConnConfig.Tracer = queryTracer{}
+	if cfg.DBStatementTimeout > 0 {
+		poolCfg.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.DBStatementTimeout.Milliseconds(), 10)
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
@@ -30,6 +42,9 @@ func NewPostgresPool(ctx context.Context, cfg *config.Config, log zerolog.Logger
 
 	log.Info().
 		Int32("max_conns", cfg.MaxDBConns).
+		Int32("min_conns", cfg.MinDBConns).
+		Int("statement_cache", cfg.DBStatementCacheSize).
+		Dur("statement_timeout", cfg.DBStatementTimeout).
 		Msg("PostgreSQL connected")
 
 	return pool, nil
@@ -6,6 +6,7 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/chaos"
 	"github.com/stemsi/exstem-backend/internal/config"
 )
 
@@ -17,6 +18,9 @@ func NewRedisClient(ctx context.Context, cfg *config.Config, log zerolog.Logger)
 	}
 
 	rdb := redis.NewClient(opt)
+	if chaos.Enabled() {
+		rdb.AddHook(chaos.RedisHook{})
+	}
 
 	if err := rdb.Ping(ctx).Err(); err != nil {
 		return nil, fmt.Errorf("ping redis: %w", err)
@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/chaos"
+)
+
+// Query counters of every pool created by NewPostgresPool since startup.
+var (
+	queryCount    atomic.Int64
+	queryErrors   atomic.Int64
+	queryDuration atomic.Int64 // nanoseconds
+	// queryRetries and queryTimeouts count the repositories' calls through DB.
+	queryRetries  atomic.Int64
+	queryTimeouts atomic.Int64
+)
+
+// CallBuckets are the upper bounds of the call duration histogram of each operation.
+var CallBuckets = []time.Duration{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// operations holds the calls through DB by operation, the repository method that
+// made them.
+var operations = struct {
+	mu    sync.Mutex
+	stats map[string]*opCounts
+}{stats: make(map[string]*opCounts)}
+
+type opCounts struct {
+	calls    int64
+	duration time.Duration
+	buckets  []int64 // calls per bucket of CallBuckets, not cumulative
+	retries  int64
+	timeouts int64
+}
+
+// countOp updates the counts of op under the lock.
+func countOp(op string, update func(c *opCounts)) {
+	operations.mu.Lock()
+	defer operations.mu.Unlock()
+	c, ok := operations.stats[op]
+	if !ok {
+		c = &opCounts{buckets: make([]int64, len(CallBuckets))}
+		operations.stats[op] = c
+	}
+	update(c)
+}
+
+// observeCall records a call of op that took d, retries included.
+func observeCall(op string, d time.Duration) {
+	countOp(op, func(c *opCounts) {
+		c.calls++
+		c.duration += d
+		if i := sort.Search(len(CallBuckets), func(i int) bool { return d <= CallBuckets[i] }); i < len(CallBuckets) {
+			c.buckets[i]++
+		}
+	})
+}
+
+func countRetry(op string) {
+	queryRetries.Add(1)
+	countOp(op, func(c *opCounts) { c.retries++ })
+}
+
+// OperationStats are the calls made through DB for one operation.
+type OperationStats struct {
+	Operation string
+	Calls     int64
+	Duration  time.Duration
+	// Buckets counts the calls that took at most the matching CallBuckets bound.
+	Buckets  []int64
+	Retries  int64
+	Timeouts int64
+}
+
+// Operations returns the statistics of every operation seen since startup, by name.
+func Operations() []OperationStats {
+	operations.mu.Lock()
+	stats := make([]OperationStats, 0, len(operations.stats))
+	for op, c := range operations.stats {
+		buckets := make([]int64, len(c.buckets))
+		var n int64
+		for i, b := range c.buckets {
+			n += b
+			buckets[i] = n
+		}
+		stats = append(stats, OperationStats{
+			Operation: op,
+			Calls:     c.calls,
+			Duration:  c.duration,
+			Buckets:   buckets,
+			Retries:   c.retries,
+			Timeouts:  c.timeouts,
+		})
+	}
+	operations.mu.Unlock()
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Operation < stats[j].Operation })
+	return stats
+}
+
+type queryStartKey struct{}
+
+// queryTracer counts the queries run on pooled connections and their total duration.
+// In chaos mode it also holds up commits.
+type queryTracer struct{}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if data.SQL == "commit" {
+		chaos.DelayCommit(ctx)
+	}
+	return context.WithValue(ctx, queryStartKey{}, time.Now())
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	queryCount.Add(1)
+	if data.Err != nil {
+		queryErrors.Add(1)
+	}
+	if start, ok := ctx.Value(queryStartKey{}).(time.Time); ok {
+		queryDuration.Add(int64(time.Since(start)))
+	}
+}
+
+// PoolStats is a snapshot of a connection pool and of the queries run on it.
+type PoolStats struct {
+	MaxConns      int32 `json:"max_conns"`
+	TotalConns    int32 `json:"total_conns"`
+	AcquiredConns int32 `json:"acquired_conns"`
+	IdleConns     int32 `json:"idle_conns"`
+	// AcquireCount counts connection acquisitions; EmptyAcquireCount those that had
+	// to wait for a free connection, which took AcquireWait altogether.
+	AcquireCount      int64         `json:"acquire_count"`
+	EmptyAcquireCount int64         `json:"empty_acquire_count"`
+	AcquireWait       time.Duration `json:"acquire_wait_ns"`
+	CanceledAcquires  int64         `json:"canceled_acquire_count"`
+
+	Queries       int64         `json:"queries"`
+	QueryErrors   int64         `json:"query_errors"`
+	QueryDuration time.Duration `json:"query_duration_ns"`
+	// QueryRetries counts statements DB ran again after a transient failure;
+	// QueryTimeouts the calls that failed at their deadline.
+	QueryRetries  int64 `json:"query_retries"`
+	QueryTimeouts int64 `json:"query_timeouts"`
+}
+
+// Stats returns the current statistics of pool.
+func Stats(pool *pgxpool.Pool) PoolStats {
+	s := pool.Stat()
+	return PoolStats{
+		MaxConns:          s.MaxConns(),
+		TotalConns:        s.TotalConns(),
+		AcquiredConns:     s.AcquiredConns(),
+		IdleConns:         s.IdleConns(),
+		AcquireCount:      s.AcquireCount(),
+		EmptyAcquireCount: s.EmptyAcquireCount(),
+		AcquireWait:       s.EmptyAcquireWaitTime(),
+		CanceledAcquires:  s.CanceledAcquireCount(),
+		Queries:           queryCount.Load(),
+		QueryErrors:       queryErrors.Load(),
+		QueryDuration:     time.Duration(queryDuration.Load()),
+		QueryRetries:      queryRetries.Load(),
+		QueryTimeouts:     queryTimeouts.Load(),
+	}
+}
@@ -3,7 +3,6 @@ package handler
 import (
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stemsi/exstem-backend/internal/response"
@@ -25,7 +24,7 @@ func (h *AdminRoleHandler) ListRoles(c *gin.Context) {
 		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
 		return
 	}
-	response.Success(c, http.StatusOK, roles)
+	response.SuccessList(c, http.StatusOK, roles)
 }
 
 // GetRole gets a role and its permissions by ID.
@@ -38,11 +37,7 @@ func (h *AdminRoleHandler) GetRole(c *gin.Context) {
 
 	role, err := h.service.GetRoleByID(c.Request.Context(), id)
 	if err != nil {
-		if err.Error() == "no rows in result set" {
-			response.Fail(c, http.StatusNotFound, response.ErrNotFound)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -51,8 +46,9 @@ func (h *AdminRoleHandler) GetRole(c *gin.Context) {
 
 // CreateUpdateRoleRequest payload for role operations.
 type CreateUpdateRoleRequest struct {
-	Name        string   `json:"name" binding:"required,min=2"`
-	Permissions []string `json:"permissions"`
+	Name          string           `json:"name" binding:"required,min=2"`
+	Permissions   []string         `json:"permissions"`
+	SubjectScopes map[string][]int `json:"subject_scopes"`
 }
 
 // CreateRole creates a new role with given permissions.
@@ -63,13 +59,9 @@ func (h *AdminRoleHandler) CreateRole(c *gin.Context) {
 		return
 	}
 
-	role, err := h.service.CreateRole(c.Request.Context(), req.Name, req.Permissions)
+	role, err := h.service.CreateRole(c.Request.Context(), req.Name, req.Permissions, req.SubjectScopes)
 	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key value") {
-			response.Fail(c, http.StatusConflict, response.ErrConflict)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -90,17 +82,9 @@ func (h *AdminRoleHandler) UpdateRole(c *gin.Context) {
 		return
 	}
 
-	role, err := h.service.UpdateRole(c.Request.Context(), id, req.Name, req.Permissions)
+	role, err := h.service.UpdateRole(c.Request.Context(), id, req.Name, req.Permissions, req.SubjectScopes)
 	if err != nil {
-		if strings.Contains(err.Error(), "cannot update") {
-			response.Fail(c, http.StatusForbidden, response.ErrActionForbidden)
-			return
-		}
-		if err.Error() == "no rows in result set" {
-			response.Fail(c, http.StatusNotFound, response.ErrNotFound)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -117,15 +101,7 @@ func (h *AdminRoleHandler) DeleteRole(c *gin.Context) {
 
 	err = h.service.DeleteRole(c.Request.Context(), id)
 	if err != nil {
-		if strings.Contains(err.Error(), "cannot delete") {
-			response.Fail(c, http.StatusForbidden, response.ErrActionForbidden)
-			return
-		}
-		if strings.Contains(err.Error(), "violates foreign key constraint") {
-			response.Fail(c, http.StatusConflict, response.ErrDependencyExists)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -135,5 +111,5 @@ func (h *AdminRoleHandler) DeleteRole(c *gin.Context) {
 // GetPermissions lists all available permissions.
 func (h *AdminRoleHandler) GetPermissions(c *gin.Context) {
 	perms := h.service.GetAllPermissions()
-	response.Success(c, http.StatusOK, perms)
+	response.SuccessList(c, http.StatusOK, perms)
 }
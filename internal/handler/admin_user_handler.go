@@ -12,11 +12,12 @@ import (
 )
 
 type AdminUserHandler struct {
-	service *service.AdminUserService
+	service     *service.AdminUserService
+	authService *service.AuthService
 }
 
-func NewAdminUserHandler(service *service.AdminUserService) *AdminUserHandler {
-	return &AdminUserHandler{service: service}
+func NewAdminUserHandler(service *service.AdminUserService, authService *service.AuthService) *AdminUserHandler {
+	return &AdminUserHandler{service: service, authService: authService}
 }
 
 // ... (ListAdmins, CreateAdmin, UpdateAdmin remain unchanged)
@@ -28,16 +29,11 @@ func (h *AdminUserHandler) ListAdmins(c *gin.Context) {
 
 	admins, total, err := h.service.ListAdmins(c.Request.Context(), roleID, page, perPage)
 	if err != nil {
-		response.Fail(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
 		return
 	}
 
-	response.SuccessWithPagination(c, http.StatusOK, admins, &response.Pagination{
-		Page:       page,
-		PerPage:    perPage,
-		TotalItems: total,
-		TotalPages: (total + perPage - 1) / perPage,
-	})
+	response.SuccessPage(c, http.StatusOK, admins, response.NewPagination(page, perPage, total))
 }
 
 // CreateAdminRequest payload
@@ -63,11 +59,7 @@ func (h *AdminUserHandler) CreateAdmin(c *gin.Context) {
 
 	admin, err := h.service.CreateAdmin(c.Request.Context(), req.Username, req.Email, req.Name, req.Password, req.RoleID)
 	if err != nil {
-		if err.Error() == "email already registered" {
-			response.Fail(c, http.StatusConflict, "EMAIL_EXISTS")
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		c.Error(err)
 		return
 	}
 
@@ -103,15 +95,7 @@ func (h *AdminUserHandler) UpdateAdmin(c *gin.Context) {
 
 	admin, err := h.service.UpdateAdmin(c.Request.Context(), id, req.Username, req.Email, req.Name, req.Password, req.RoleID)
 	if err != nil {
-		if err.Error() == "admin not found" {
-			response.Fail(c, http.StatusNotFound, "NOT_FOUND")
-			return
-		}
-		if err.Error() == "email already registered" {
-			response.Fail(c, http.StatusConflict, "EMAIL_EXISTS")
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		c.Error(err)
 		return
 	}
 
@@ -141,28 +125,65 @@ func (h *AdminUserHandler) DeleteAdmin(c *gin.Context) {
 
 	err = h.service.DeleteAdmin(c.Request.Context(), id)
 	if err != nil {
-		if err.Error() == "admin not found" {
-			response.Fail(c, http.StatusNotFound, "NOT_FOUND")
-			return
-		}
-		// PGX error message for FK violation
-		if strings.Contains(err.Error(), "violates foreign key constraint") {
-			response.Fail(c, http.StatusConflict, response.ErrDependencyExists)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		c.Error(err)
+		return
+	}
+
+	// A deleted admin must not keep working with tokens issued earlier.
+	if _, err := h.authService.RevokeAdminSessions(c.Request.Context(), id); err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
 		return
 	}
 
 	response.Success(c, http.StatusOK, gin.H{"message": "Admin deleted successfully"})
 }
 
+// ListSessions lists an admin's active sessions, marking the caller's own session.
+func (h *AdminUserHandler) ListSessions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	sessions, err := h.authService.ListAdminSessions(c.Request.Context(), id)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	if claims := middleware.GetClaims(c); claims != nil && claims.UserID == id {
+		for i := range sessions {
+			sessions[i].Current = sessions[i].JTI == claims.ID
+		}
+	}
+
+	response.SuccessList(c, http.StatusOK, sessions)
+}
+
+// RevokeSessions logs an admin out everywhere, e.g. when they leave the school or a laptop is stolen.
+func (h *AdminUserHandler) RevokeSessions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	revoked, err := h.authService.RevokeAdminSessions(c.Request.Context(), id)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"revoked": revoked})
+}
+
 // GetRoles handles listing roles for selection.
 func (h *AdminUserHandler) GetRoles(c *gin.Context) {
 	roles, err := h.service.GetRoles(c.Request.Context())
 	if err != nil {
-		response.Fail(c, http.StatusInternalServerError, "INTERNAL_ERROR")
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
 		return
 	}
-	response.Success(c, http.StatusOK, roles)
+	response.SuccessList(c, http.StatusOK, roles)
 }
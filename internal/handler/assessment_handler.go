@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/middleware"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/response"
+	"github.com/stemsi/exstem-backend/internal/service"
+	"github.com/stemsi/exstem-backend/internal/validator"
+)
+
+// AssessmentHandler handles assessments, the groups of exams given together.
+type AssessmentHandler struct {
+	assessmentService *service.AssessmentService
+}
+
+// NewAssessmentHandler creates a new AssessmentHandler.
+func NewAssessmentHandler(assessmentService *service.AssessmentService) *AssessmentHandler {
+	return &AssessmentHandler{assessmentService: assessmentService}
+}
+
+// ListAssessments godoc
+// GET /api/v1/admin/assessments
+func (h *AssessmentHandler) ListAssessments(c *gin.Context) {
+	assessments, err := h.assessmentService.List(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.SuccessList(c, http.StatusOK, assessments)
+}
+
+// CreateAssessment godoc
+// POST /api/v1/admin/assessments
+func (h *AssessmentHandler) CreateAssessment(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	var req model.CreateAssessmentRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	assessment := &model.Assessment{
+		Title:       req.Title,
+		Description: req.Description,
+		AuthorID:    &claims.UserID,
+	}
+	if err := h.assessmentService.Create(c.Request.Context(), assessment); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, assessment)
+}
+
+// GetAssessment godoc
+// GET /api/v1/admin/assessments/:id
+// Returns the assessment with its exams.
+func (h *AssessmentHandler) GetAssessment(c *gin.Context) {
+	id, ok := assessmentID(c)
+	if !ok {
+		return
+	}
+
+	assessment, err := h.assessmentService.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, assessment)
+}
+
+// UpdateAssessment godoc
+// PUT /api/v1/admin/assessments/:id
+func (h *AssessmentHandler) UpdateAssessment(c *gin.Context) {
+	id, ok := assessmentID(c)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateAssessmentRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	assessment := &model.Assessment{ID: id, Title: req.Title, Description: req.Description}
+	if err := h.assessmentService.Update(c.Request.Context(), assessment); err != nil {
+		c.Error(err)
+		return
+	}
+
+	updated, err := h.assessmentService.Get(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, updated)
+}
+
+// DeleteAssessment godoc
+// DELETE /api/v1/admin/assessments/:id
+// Deletes the assessment. Its exams are kept, with their target rules.
+func (h *AssessmentHandler) DeleteAssessment(c *gin.Context) {
+	id, ok := assessmentID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.assessmentService.Delete(c.Request.Context(), id); err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, gin.H{"message": "assessment deleted"})
+}
+
+// SetAssessmentExams godoc
+// PUT /api/v1/admin/assessments/:id/exams
+// Replaces the exams of the assessment. Exams joining get its target rules; schedule
+// conflicts that introduces are returned as warnings, or refuse the change under the
+// "block" policy.
+func (h *AssessmentHandler) SetAssessmentExams(c *gin.Context) {
+	id, ok := assessmentID(c)
+	if !ok {
+		return
+	}
+
+	var req model.SetAssessmentExamsRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	assessment, conflicts, err := h.assessmentService.SetExams(c.Request.Context(), id, req.ExamIDs)
+	if err != nil {
+		if errors.Is(err, service.ErrScheduleConflict) {
+			failScheduleConflict(c, conflicts)
+			return
+		}
+		c.Error(err)
+		return
+	}
+	response.SuccessWithWarnings(c, http.StatusOK, assessment, conflictWarnings(conflicts))
+}
+
+// SetAssessmentTargetRules godoc
+// PUT /api/v1/admin/assessments/:id/target-rules
+// Replaces the target rules of the assessment and of each of its exams, checking
+// schedule conflicts like the target rules of a single exam.
+func (h *AssessmentHandler) SetAssessmentTargetRules(c *gin.Context) {
+	id, ok := assessmentID(c)
+	if !ok {
+		return
+	}
+
+	var req model.SetAssessmentTargetRulesRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	assessment, conflicts, err := h.assessmentService.SetTargetRules(c.Request.Context(), id, req.Rules)
+	if err != nil {
+		if errors.Is(err, service.ErrScheduleConflict) {
+			failScheduleConflict(c, conflicts)
+			return
+		}
+		c.Error(err)
+		return
+	}
+	response.SuccessWithWarnings(c, http.StatusOK, assessment, conflictWarnings(conflicts))
+}
+
+// GetAssessmentReport godoc
+// GET /api/v1/admin/assessments/:id/report
+// Returns the combined results of the assessment's exams, one row per student with a
+// score per exam, optionally of one class (?class_id=).
+func (h *AssessmentHandler) GetAssessmentReport(c *gin.Context) {
+	report, ok := h.report(c)
+	if !ok {
+		return
+	}
+	redactAssessmentReport(c, report)
+	response.Success(c, http.StatusOK, report)
+}
+
+// ExportAssessmentReportCSV godoc
+// GET /api/v1/admin/assessments/:id/report.csv
+// Returns the combined results as CSV, with a score column per exam.
+func (h *AssessmentHandler) ExportAssessmentReportCSV(c *gin.Context) {
+	report, ok := h.report(c)
+	if !ok {
+		return
+	}
+	redactAssessmentReport(c, report)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{"nisn", "name", "class"}
+	for _, e := range report.Exams {
+		header = append(header, e.Title)
+	}
+	_ = w.Write(append(header, "completed", "average"))
+	for _, row := range report.Students {
+		record := []string{row.NISN, row.Name, row.ClassName}
+		for _, score := range row.Scores {
+			record = append(record, formatScore(score))
+		}
+		_ = w.Write(append(record, strconv.Itoa(row.Completed), formatScore(row.Average)))
+	}
+	w.Flush()
+
+	c.Header("Content-Disposition", `attachment; filename="rekap-asesmen.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// report loads the report requested by c, or writes the error response.
+func (h *AssessmentHandler) report(c *gin.Context) (*model.AssessmentReport, bool) {
+	id, ok := assessmentID(c)
+	if !ok {
+		return nil, false
+	}
+
+	var classID *int
+	if cidStr := c.Query("class_id"); cidStr != "" {
+		cid, err := strconv.Atoi(cidStr)
+		if err != nil {
+			response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+			return nil, false
+		}
+		classID = &cid
+	}
+
+	report, err := h.assessmentService.Report(c.Request.Context(), id, classID)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	return report, true
+}
+
+// assessmentID parses the assessment ID of the path, or writes the error response.
+func assessmentID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func formatScore(score *float64) string {
+	if score == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*score, 'f', -1, 64)
+}
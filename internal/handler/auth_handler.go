@@ -14,9 +14,12 @@ import (
 
 // AuthHandler handles authentication endpoints.
 type AuthHandler struct {
-	authService    *service.AuthService
-	studentService *service.StudentService
-	adminService   *service.AdminService
+	authService     *service.AuthService
+	studentService  *service.StudentService
+	adminService    *service.AdminService
+	oidcService     *service.OIDCService
+	guardianService *service.GuardianService
+	loginMonitor    *service.LoginMonitor
 }
 
 // NewAuthHandler creates a new AuthHandler.
@@ -24,11 +27,17 @@ func NewAuthHandler(
 	authService *service.AuthService,
 	studentService *service.StudentService,
 	adminService *service.AdminService,
+	oidcService *service.OIDCService,
+	guardianService *service.GuardianService,
+	loginMonitor *service.LoginMonitor,
 ) *AuthHandler {
 	return &AuthHandler{
-		authService:    authService,
-		studentService: studentService,
-		adminService:   adminService,
+		authService:     authService,
+		studentService:  studentService,
+		adminService:    adminService,
+		oidcService:     oidcService,
+		guardianService: guardianService,
+		loginMonitor:    loginMonitor,
 	}
 }
 
@@ -77,6 +86,24 @@ func (h *AuthHandler) StudentLogout(c *gin.Context) {
 	response.Success(c, http.StatusOK, gin.H{})
 }
 
+// AdminLogout godoc
+// POST /api/v1/auth/admin/logout
+// Ends the current admin session.
+func (h *AuthHandler) AdminLogout(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	if err := h.authService.RevokeAdminSession(c.Request.Context(), claims.UserID, claims.ID); err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{})
+}
+
 // GetAdminProfile godoc
 // GET /api/v1/auth/admin/me
 // Returns the profile of the currently authenticated admin.
@@ -99,6 +126,12 @@ func (h *AuthHandler) GetAdminProfile(c *gin.Context) {
 		return
 	}
 
+	scopes, err := h.adminService.GetSubjectScopes(c.Request.Context(), admin.RoleID)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
 	response.Success(c, http.StatusOK, gin.H{
 		"admin": gin.H{
 			"id":        admin.ID,
@@ -107,7 +140,8 @@ func (h *AuthHandler) GetAdminProfile(c *gin.Context) {
 			"role_id":   admin.RoleID,
 			"role_name": admin.RoleName,
 		},
-		"permissions": permissions,
+		"permissions":    permissions,
+		"subject_scopes": scopes,
 	})
 }
 
@@ -122,23 +156,23 @@ func (h *AuthHandler) StudentLogin(c *gin.Context) {
 	}
 
 	student, err := h.studentService.GetByNISN(c.Request.Context(), req.NISN)
+	if err == nil {
+		err = h.authService.CheckStudentPassword(student.Password, req.Password)
+	}
+	h.loginMonitor.Record(c.Request.Context(), service.TokenTypeStudent, c.ClientIP(), req.NISN, err == nil)
 	if err != nil {
 		response.Fail(c, http.StatusUnauthorized, response.ErrInvalidCredentials)
 		return
 	}
 
-	if err := h.authService.CheckStudentPassword(student.Password, req.Password); err != nil {
-		response.Fail(c, http.StatusUnauthorized, response.ErrInvalidCredentials)
+	if !student.IsActive {
+		response.Fail(c, http.StatusForbidden, response.ErrAccountInactive)
 		return
 	}
 
 	token, err := h.authService.GenerateStudentToken(c.Request.Context(), student.ID, student.ClassID)
 	if err != nil {
-		if errors.Is(err, service.ErrSessionAlreadyActive) {
-			response.Fail(c, http.StatusConflict, response.ErrSessionActive)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -150,9 +184,43 @@ func (h *AuthHandler) StudentLogin(c *gin.Context) {
 			"name":     student.Name,
 			"class_id": student.ClassID,
 		},
+		"must_change_password": student.MustChangePassword,
 	})
 }
 
+// ChangeStudentPassword godoc
+// PUT /api/v1/student/password
+// Replaces the student's password after checking the current one. Students who were
+// issued credentials must do this before they can join an exam.
+func (h *AuthHandler) ChangeStudentPassword(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	var req model.ChangeStudentPasswordRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	if err := h.studentService.ChangeOwnPassword(c.Request.Context(), claims.UserID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			response.Fail(c, http.StatusUnauthorized, response.ErrInvalidCredentials)
+			return
+		}
+		if errors.Is(err, service.ErrWeakPassword) {
+			response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"new_password": err.Error()})
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "password changed successfully"})
+}
+
 // AdminLogin godoc
 // POST /api/v1/auth/admin/login
 // Validates email + password, returns JWT with permissions.
@@ -164,23 +232,160 @@ func (h *AuthHandler) AdminLogin(c *gin.Context) {
 	}
 
 	admin, err := h.adminService.GetByIdentifier(c.Request.Context(), req.Identifier)
+	if err == nil {
+		err = h.authService.CheckPassword(admin.PasswordHash, req.Password)
+	}
+	h.loginMonitor.Record(c.Request.Context(), service.TokenTypeAdmin, c.ClientIP(), req.Identifier, err == nil)
 	if err != nil {
 		response.Fail(c, http.StatusUnauthorized, response.ErrInvalidCredentials)
 		return
 	}
 
-	if err := h.authService.CheckPassword(admin.PasswordHash, req.Password); err != nil {
+	h.issueAdminToken(c, admin)
+}
+
+// oidcStateCookie keeps the state of the browser's pending SSO login.
+const oidcStateCookie = "oidc_state"
+
+// AdminOIDCLogin godoc
+// GET /api/v1/auth/admin/oidc/login
+// Redirects the admin to the identity provider to sign in, keeping the login's state
+// in an HttpOnly cookie so only this browser can complete it.
+func (h *AuthHandler) AdminOIDCLogin(c *gin.Context) {
+	authURL, state, err := h.oidcService.AuthCodeURL(c.Request.Context())
+	if err != nil {
+		c.Error(err).SetMeta(middleware.ErrorFallback{Status: http.StatusBadGateway, Code: response.ErrSSOFailed})
+		return
+	}
+
+	h.setOIDCStateCookie(c, state, int(service.OIDCStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// AdminOIDCCallback godoc
+// GET /api/v1/auth/admin/oidc/callback?code=...&state=...
+// Completes the identity provider sign-in and returns a JWT, like AdminLogin. The
+// request must carry the state cookie set by AdminOIDCLogin.
+func (h *AuthHandler) AdminOIDCCallback(c *gin.Context) {
+	code, state := c.Query("code"), c.Query("state")
+	browserState, _ := c.Cookie(oidcStateCookie)
+	if code == "" || state == "" || browserState == "" {
+		response.Fail(c, http.StatusBadRequest, response.ErrSSOStateInvalid)
+		return
+	}
+	// The state is single-use whatever the outcome.
+	h.setOIDCStateCookie(c, "", -1)
+
+	admin, err := h.oidcService.Authenticate(c.Request.Context(), code, state, browserState)
+	if err != nil {
+		c.Error(err).SetMeta(middleware.ErrorFallback{Status: http.StatusBadGateway, Code: response.ErrSSOFailed})
+		return
+	}
+
+	h.issueAdminToken(c, admin)
+}
+
+// setOIDCStateCookie sets, or with maxAge < 0 clears, the state cookie. Lax lets the
+// browser send it on the way back from the provider but not on cross-site requests.
+func (h *AuthHandler) setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, "/api/v1/auth/admin/oidc", "", secure, true)
+}
+
+// GuardianLogin godoc
+// POST /api/v1/auth/guardian/login
+// Validates email/phone + password, returns a guardian JWT.
+func (h *AuthHandler) GuardianLogin(c *gin.Context) {
+	var req model.GuardianLoginRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	guardian, err := h.guardianService.GetByIdentifier(c.Request.Context(), req.Identifier)
+	if err == nil {
+		err = h.authService.CheckPassword(guardian.PasswordHash, req.Password)
+	}
+	h.loginMonitor.Record(c.Request.Context(), service.TokenTypeGuardian, c.ClientIP(), req.Identifier, err == nil)
+	if err != nil {
 		response.Fail(c, http.StatusUnauthorized, response.ErrInvalidCredentials)
 		return
 	}
 
+	token, err := h.authService.GenerateGuardianToken(c.Request.Context(), guardian.ID)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"token":    token,
+		"guardian": guardian,
+	})
+}
+
+// GuardianLogout godoc
+// POST /api/v1/auth/guardian/logout
+// Ends the current guardian session.
+func (h *AuthHandler) GuardianLogout(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	if err := h.authService.RevokeGuardianSession(c.Request.Context(), claims.UserID, claims.ID); err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{})
+}
+
+// GetGuardianProfile godoc
+// GET /api/v1/auth/guardian/me
+// Returns the profile of the currently authenticated guardian and their children.
+func (h *AuthHandler) GetGuardianProfile(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	guardian, err := h.guardianService.GetByID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		response.Fail(c, http.StatusNotFound, response.ErrNotFound)
+		return
+	}
+
+	children, err := h.guardianService.ListChildren(c.Request.Context(), guardian.ID)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"guardian": guardian,
+		"children": children,
+	})
+}
+
+// issueAdminToken starts an admin session and writes the login response.
+func (h *AuthHandler) issueAdminToken(c *gin.Context, admin *model.Admin) {
 	permissions, err := h.adminService.GetPermissions(c.Request.Context(), admin.RoleID)
 	if err != nil {
 		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
 		return
 	}
 
-	token, err := h.authService.GenerateAdminToken(admin.ID, admin.RoleID, permissions)
+	scopes, err := h.adminService.GetSubjectScopes(c.Request.Context(), admin.RoleID)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	token, err := h.authService.GenerateAdminToken(c.Request.Context(), admin.ID, admin.RoleID, permissions, scopes, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
 		return
@@ -195,6 +400,7 @@ func (h *AuthHandler) AdminLogin(c *gin.Context) {
 			"role_id":   admin.RoleID,
 			"role_name": admin.RoleName,
 		},
-		"permissions": permissions,
+		"permissions":    permissions,
+		"subject_scopes": scopes,
 	})
 }
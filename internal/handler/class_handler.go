@@ -1,12 +1,10 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stemsi/exstem-backend/internal/model"
 	"github.com/stemsi/exstem-backend/internal/response"
 	"github.com/stemsi/exstem-backend/internal/service"
@@ -33,7 +31,7 @@ func (h *ClassHandler) ListClasses(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, http.StatusOK, gin.H{"classes": classes})
+	response.Success(c, http.StatusOK, gin.H{"classes": response.NonNil(classes)})
 }
 
 // CreateClassRequest is the payload for creating or updating a class.
@@ -60,19 +58,54 @@ func (h *ClassHandler) CreateClass(c *gin.Context) {
 	}
 
 	if err := h.classService.Create(c.Request.Context(), class); err != nil {
-		// Detect unique constraint violations (e.g., if a constraint exists on grade+major+group)
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			response.Fail(c, http.StatusConflict, response.ErrConflict)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
 	response.Success(c, http.StatusCreated, gin.H{"class": class})
 }
 
+// BulkCreateClassesRequest is the payload for generating classes from a matrix of
+// grade levels and majors, each major with its number of groups.
+type BulkCreateClassesRequest struct {
+	GradeLevels []string           `json:"grade_levels" binding:"required,min=1,max=10,unique,dive,min=1,max=10"`
+	Majors      []BulkClassesMajor `json:"majors" binding:"required,min=1,max=50,unique=MajorCode,dive"`
+}
+
+// BulkClassesMajor is one major of a bulk class generation and how many groups each
+// grade level gets.
+type BulkClassesMajor struct {
+	MajorCode string `json:"major_code" binding:"required,min=1,max=10"`
+	Groups    int    `json:"groups" binding:"required,min=1,max=20"`
+}
+
+// BulkCreateClasses godoc
+// POST /api/v1/admin/classes/bulk
+// Generates the classes of every grade level and major, numbered 1 to the major's
+// group count. Classes that already exist are skipped and listed as such.
+func (h *ClassHandler) BulkCreateClasses(c *gin.Context) {
+	var req BulkCreateClassesRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	majors := make([]string, 0, len(req.Majors))
+	groups := make(map[string]int, len(req.Majors))
+	for _, m := range req.Majors {
+		majors = append(majors, m.MajorCode)
+		groups[m.MajorCode] = m.Groups
+	}
+
+	result, err := h.classService.BulkCreate(c.Request.Context(), req.GradeLevels, majors, groups)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, result)
+}
+
 // UpdateClass godoc
 // PUT /api/v1/admin/classes/:id
 // Updates an existing class.
@@ -97,12 +130,7 @@ func (h *ClassHandler) UpdateClass(c *gin.Context) {
 	}
 
 	if err := h.classService.Update(c.Request.Context(), class); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			response.Fail(c, http.StatusConflict, response.ErrConflict)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -112,6 +140,31 @@ func (h *ClassHandler) UpdateClass(c *gin.Context) {
 	response.Success(c, http.StatusOK, gin.H{"class": updatedClass})
 }
 
+// SetHomeroom godoc
+// PUT /api/v1/admin/classes/:id/homeroom
+// Assigns the class's homeroom teacher, or removes the assignment with a null admin_id.
+func (h *ClassHandler) SetHomeroom(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.SetHomeroomRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	class, err := h.classService.SetHomeroom(c.Request.Context(), id, req.AdminID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"class": class})
+}
+
 // DeleteClass godoc
 // DELETE /api/v1/admin/classes/:id
 // Deletes a class by ID. Will fail if students are attached.
@@ -123,12 +176,7 @@ func (h *ClassHandler) DeleteClass(c *gin.Context) {
 	}
 
 	if err := h.classService.Delete(c.Request.Context(), id); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // Foreign key constraint violation
-			response.Fail(c, http.StatusConflict, response.ErrDependencyExists)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -2,10 +2,14 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/stemsi/exstem-backend/internal/middleware"
+	"github.com/stemsi/exstem-backend/internal/model"
 	"github.com/stemsi/exstem-backend/internal/response"
 	"github.com/stemsi/exstem-backend/internal/service"
+	"github.com/stemsi/exstem-backend/internal/validator"
 )
 
 // DashboardHandler handles admin dashboard endpoints.
@@ -30,3 +34,84 @@ func (h *DashboardHandler) GetDashboardData(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, data)
 }
+
+// ListWidgets godoc
+// GET /api/v1/admin/dashboard/widgets
+// Returns the widgets admins can place on their dashboard.
+func (h *DashboardHandler) ListWidgets(c *gin.Context) {
+	response.SuccessList(c, http.StatusOK, h.dashboardService.ListWidgets())
+}
+
+// GetLayout godoc
+// GET /api/v1/admin/dashboard/layout
+// Returns the admin's dashboard layout, or the default one.
+func (h *DashboardHandler) GetLayout(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	layout, err := h.dashboardService.GetLayout(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, layout)
+}
+
+// UpdateLayout godoc
+// PUT /api/v1/admin/dashboard/layout
+// Saves which widgets the admin's dashboard shows and where.
+func (h *DashboardHandler) UpdateLayout(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	var req model.UpdateDashboardLayoutRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	layout, err := h.dashboardService.UpdateLayout(c.Request.Context(), claims.UserID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, layout)
+}
+
+// GetWidgetData godoc
+// GET /api/v1/admin/dashboard/widgets/:widget?from=&to=
+// Returns a widget's data. Widgets that support a range take from and to (RFC 3339,
+// both optional) and otherwise use their default range.
+func (h *DashboardHandler) GetWidgetData(c *gin.Context) {
+	var from, to time.Time
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"from": "from must be an RFC 3339 time"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"to": "to must be an RFC 3339 time"})
+			return
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"to": "to must not be before from"})
+		return
+	}
+
+	data, err := h.dashboardService.GetWidgetData(c.Request.Context(), c.Param("widget"), from, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, gin.H{"widget": c.Param("widget"), "data": data})
+}
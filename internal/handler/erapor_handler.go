@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/middleware"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/response"
+	"github.com/stemsi/exstem-backend/internal/service"
+	"github.com/stemsi/exstem-backend/internal/validator"
+)
+
+// EraporHandler handles the e-Rapor result export.
+type EraporHandler struct {
+	eraporService *service.EraporService
+	log           zerolog.Logger
+}
+
+// NewEraporHandler creates a new EraporHandler.
+func NewEraporHandler(eraporService *service.EraporService, log zerolog.Logger) *EraporHandler {
+	return &EraporHandler{
+		eraporService: eraporService,
+		log:           log.With().Str("component", "erapor_handler").Logger(),
+	}
+}
+
+// GetMapping godoc
+// GET /api/v1/admin/exams/:id/erapor-mapping
+// Returns the e-Rapor subject and competency an exam is mapped to.
+func (h *EraporHandler) GetMapping(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	mapping, err := h.eraporService.GetMapping(c.Request.Context(), examID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, mapping)
+}
+
+// SetMapping godoc
+// PUT /api/v1/admin/exams/:id/erapor-mapping
+// Maps an exam to an e-Rapor subject and competency (KD).
+func (h *EraporHandler) SetMapping(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.UpsertEraporMappingRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	mapping, err := h.eraporService.SetMapping(c.Request.Context(), examID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, mapping)
+}
+
+// DeleteMapping godoc
+// DELETE /api/v1/admin/exams/:id/erapor-mapping
+// Removes an exam from the e-Rapor export.
+func (h *EraporHandler) DeleteMapping(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	if err := h.eraporService.DeleteMapping(c.Request.Context(), examID); err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "e-rapor mapping removed"})
+}
+
+// Export godoc
+// GET /api/v1/admin/erapor/export?subject_code=...&class_id=...
+// Downloads the e-Rapor score import workbook for a subject.
+func (h *EraporHandler) Export(c *gin.Context) {
+	subjectCode, classID, ok := eraporFilter(c)
+	if !ok {
+		return
+	}
+
+	b, err := h.eraporService.ExportXLSX(c.Request.Context(), subjectCode, classID)
+	if err != nil {
+		if !errors.Is(err, service.ErrEraporNoScores) {
+			h.log.Error().Err(err).Str("subject_code", subjectCode).Msg("e-Rapor export failed")
+		}
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=Nilai_eRapor_%s.xlsx", subjectCode))
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", b)
+}
+
+// Push godoc
+// POST /api/v1/admin/erapor/push?subject_code=...&class_id=...
+// Sends a subject's competency scores to the configured e-Rapor API.
+func (h *EraporHandler) Push(c *gin.Context) {
+	subjectCode, classID, ok := eraporFilter(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.eraporService.Push(c.Request.Context(), subjectCode, classID)
+	if err != nil {
+		h.log.Error().Err(err).Str("subject_code", subjectCode).Msg("e-Rapor push failed")
+		c.Error(err).SetMeta(middleware.ErrorFallback{Status: http.StatusBadGateway, Code: response.ErrEraporPushFailed})
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// eraporFilter reads the required subject_code and optional class_id query parameters.
+func eraporFilter(c *gin.Context) (string, *int, bool) {
+	subjectCode := c.Query("subject_code")
+	if subjectCode == "" {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"subject_code": "subject_code is required"})
+		return "", nil, false
+	}
+
+	var classID *int
+	if cidStr := c.Query("class_id"); cidStr != "" {
+		cid, err := strconv.Atoi(cidStr)
+		if err != nil {
+			response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+			return "", nil, false
+		}
+		classID = &cid
+	}
+	return subjectCode, classID, true
+}
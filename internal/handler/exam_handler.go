@@ -1,19 +1,20 @@
 package handler
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
 	"github.com/stemsi/exstem-backend/internal/middleware"
 	"github.com/stemsi/exstem-backend/internal/model"
 	"github.com/stemsi/exstem-backend/internal/response"
@@ -46,6 +47,7 @@ func generateToken() string {
 // ListExams godoc
 // GET /api/v1/admin/exams
 // Lists exams with pagination. Superadmins see all; teachers see only their own.
+// Query parameters metadata.<key>=<value> list only exams with that metadata.
 func (h *ExamHandler) ListExams(c *gin.Context) {
 	claims := middleware.GetClaims(c)
 	if claims == nil {
@@ -56,13 +58,23 @@ func (h *ExamHandler) ListExams(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
 
-	exams, pagination, err := h.examService.ListByAuthor(c.Request.Context(), page, perPage)
+	metadata := make(map[string]string)
+	for param, values := range c.Request.URL.Query() {
+		if key, ok := strings.CutPrefix(param, "metadata."); ok && len(values) > 0 {
+			metadata[key] = values[0]
+		}
+	}
+
+	exams, pagination, err := h.examService.ListByAuthor(c.Request.Context(), page, perPage, metadata)
 	if err != nil {
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
+	for i := range exams {
+		redactExams(c, &exams[i])
+	}
 
-	response.SuccessWithPagination(c, http.StatusOK, exams, pagination)
+	response.SuccessPage(c, http.StatusOK, exams, pagination)
 }
 
 // CreateExam godoc
@@ -89,14 +101,18 @@ func (h *ExamHandler) CreateExam(c *gin.Context) {
 		DurationMinutes: req.DurationMinutes,
 		CheatRules:      json.RawMessage(`{}`),
 		EntryToken:      generateToken(),
+		QBankID:         req.QBankID,
+		Metadata:        req.Metadata,
 	}
 
 	if err := h.examService.Create(c.Request.Context(), exam); err != nil {
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
-	response.Success(c, http.StatusCreated, exam)
+	// The exam exists either way; a failed check only drops the warning.
+	subjectWarning, _ := h.examService.SubjectTeacherWarning(c.Request.Context(), exam, claims.UserID)
+	response.SuccessWithWarnings(c, http.StatusCreated, exam, examWarnings(nil, subjectWarning))
 }
 
 // PublishExam godoc
@@ -115,20 +131,35 @@ func (h *ExamHandler) PublishExam(c *gin.Context) {
 		return
 	}
 
-	if err := h.examService.Publish(c.Request.Context(), examID); err != nil {
-		switch {
-		case errors.Is(err, service.ErrNoQuestions):
-			response.Fail(c, http.StatusBadRequest, response.ErrNoQuestions)
-		case errors.Is(err, service.ErrExamNotDraft):
-			response.Fail(c, http.StatusBadRequest, response.ErrExamNotAvailable)
-		default:
-			fmt.Printf("[ExamHandler] Failed to publish exam %s: %v\n", examID.String(), err)
-			response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+	conflicts, err := h.examService.Publish(c.Request.Context(), examID)
+	if err != nil {
+		if errors.Is(err, service.ErrScheduleConflict) {
+			failScheduleConflict(c, conflicts)
+			return
 		}
+		c.Error(err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, gin.H{"message": "exam published successfully"})
+	response.SuccessWithWarnings(c, http.StatusOK, gin.H{"message": "exam published successfully"}, conflictWarnings(conflicts))
+}
+
+// UnpublishExam godoc
+// POST /api/v1/admin/exams/:id/unpublish
+// Reverts a published exam to DRAFT. Only allowed while no student sessions exist.
+func (h *ExamHandler) UnpublishExam(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	if err := h.examService.Unpublish(c.Request.Context(), examID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "exam reverted to draft"})
 }
 
 // AddTargetRule godoc
@@ -155,17 +186,17 @@ func (h *ExamHandler) AddTargetRule(c *gin.Context) {
 		Religion:   req.Religion,
 	}
 
-	if err := h.examService.AddTargetRule(c.Request.Context(), rule); err != nil {
-		switch {
-		case errors.Is(err, service.ErrDuplicateTarget):
-			response.Fail(c, http.StatusConflict, response.ErrDuplicateTarget)
-		default:
-			response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+	conflicts, err := h.examService.AddTargetRule(c.Request.Context(), rule)
+	if err != nil {
+		if errors.Is(err, service.ErrScheduleConflict) {
+			failScheduleConflict(c, conflicts)
+			return
 		}
+		c.Error(err)
 		return
 	}
 
-	response.Success(c, http.StatusCreated, rule)
+	response.SuccessWithWarnings(c, http.StatusCreated, rule, conflictWarnings(conflicts))
 }
 
 // UpdateTargetRule godoc
@@ -200,19 +231,17 @@ func (h *ExamHandler) UpdateTargetRule(c *gin.Context) {
 		Religion:   req.Religion,
 	}
 
-	if err := h.examService.UpdateTargetRule(c.Request.Context(), rule); err != nil {
-		switch {
-		case errors.Is(err, pgx.ErrNoRows):
-			response.Fail(c, http.StatusNotFound, response.ErrNotFound)
-		case errors.Is(err, service.ErrDuplicateTarget):
-			response.Fail(c, http.StatusConflict, response.ErrDuplicateTarget)
-		default:
-			response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+	conflicts, err := h.examService.UpdateTargetRule(c.Request.Context(), rule)
+	if err != nil {
+		if errors.Is(err, service.ErrScheduleConflict) {
+			failScheduleConflict(c, conflicts)
+			return
 		}
+		c.Error(err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, rule)
+	response.SuccessWithWarnings(c, http.StatusOK, rule, conflictWarnings(conflicts))
 }
 
 // DeleteTargetRule godoc
@@ -233,11 +262,7 @@ func (h *ExamHandler) DeleteTargetRule(c *gin.Context) {
 	}
 
 	if err := h.examService.DeleteTargetRule(c.Request.Context(), ruleID, examID); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			response.Fail(c, http.StatusNotFound, response.ErrNotFound)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -260,11 +285,7 @@ func (h *ExamHandler) GetTargetRules(c *gin.Context) {
 		return
 	}
 
-	if rules == nil {
-		rules = []model.ExamTargetRule{}
-	}
-
-	response.Success(c, http.StatusOK, rules)
+	response.SuccessList(c, http.StatusOK, rules)
 }
 
 // RefreshExamCache godoc
@@ -284,20 +305,384 @@ func (h *ExamHandler) RefreshExamCache(c *gin.Context) {
 	}
 
 	if err := h.examService.RefreshCache(c.Request.Context(), examID); err != nil {
-		switch {
-		case errors.Is(err, service.ErrExamNotPublished):
-			response.Fail(c, http.StatusBadRequest, response.ErrExamNotPublished)
-		case errors.Is(err, service.ErrNoQuestions):
-			response.Fail(c, http.StatusBadRequest, response.ErrNoQuestions)
-		default:
-			response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
-		}
+		c.Error(err)
 		return
 	}
 
 	response.Success(c, http.StatusOK, gin.H{"message": "exam cache refreshed successfully"})
 }
 
+// ReconcileSessions godoc
+// POST /api/v1/admin/exams/reconcile-sessions
+// Restores the Redis state of open sessions that Redis lost.
+func (h *ExamHandler) ReconcileSessions(c *gin.Context) {
+	report, err := h.sessionService.ReconcileRedis(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, report)
+}
+
+// GetExamCalendar godoc
+// GET /api/v1/admin/exams/calendar?from=YYYY-MM-DD&to=YYYY-MM-DD
+// Returns scheduled exams grouped by day with class scheduling conflicts.
+// Both dates are inclusive; defaults to the next 30 days, max 92 days.
+func (h *ExamHandler) GetExamCalendar(c *gin.Context) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 30)
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"from": "from must be a date (YYYY-MM-DD)"})
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"to": "to must be a date (YYYY-MM-DD)"})
+			return
+		}
+		to = t
+	} else if c.Query("from") != "" {
+		to = from.AddDate(0, 0, 30)
+	}
+
+	if to.Before(from) {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"to": "to must not be before from"})
+		return
+	}
+	if to.Sub(from) > 92*24*time.Hour {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"to": "range must not exceed 92 days"})
+		return
+	}
+
+	calendar, err := h.examService.GetCalendar(c.Request.Context(), from, to.AddDate(0, 0, 1))
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, calendar)
+}
+
+// PreviewExam godoc
+// GET /api/v1/admin/exams/:id/preview
+// Returns the exam exactly as a student would see it, without creating a session.
+// ?seed= reproduces a specific shuffle; ?answers=true adds the answer-key overlay.
+func (h *ExamHandler) PreviewExam(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	seed, err := strconv.ParseInt(c.Query("seed"), 10, 64)
+	if err != nil {
+		seed = time.Now().UnixNano()
+	}
+	withAnswers := c.Query("answers") == "true"
+
+	preview, err := h.examService.Preview(c.Request.Context(), examID, seed, withAnswers)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, preview)
+}
+
+// ValidateExam godoc
+// GET /api/v1/admin/exams/:id/validate
+// Returns the publish preflight checklist for an exam.
+func (h *ExamHandler) ValidateExam(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	result, err := h.examService.Validate(c.Request.Context(), examID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// SimulateExam godoc
+// POST /api/v1/admin/exams/:id/simulate
+// Dry-runs the exam with virtual students answering at random and reports the score
+// distribution and configuration problems. Nothing is stored.
+func (h *ExamHandler) SimulateExam(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.SimulateExamRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+	seed := time.Now().UnixNano()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+
+	result, err := h.examService.Simulate(c.Request.Context(), examID, req.Students, seed)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// GetQuestionReuse godoc
+// GET /api/v1/admin/exams/:id/question-reuse
+// Lists questions whose text also appears in other exams for the same classes this term.
+func (h *ExamHandler) GetQuestionReuse(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	result, err := h.examService.QuestionReuse(c.Request.Context(), examID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// ExportAttendancePDF godoc
+// GET /api/v1/admin/exams/:id/attendance.pdf
+// Prints the exam's attendance sheets, one per class or room (?group_by=class|room),
+// with the students who joined already marked present and a column for signatures.
+func (h *ExamHandler) ExportAttendancePDF(c *gin.Context) {
+	attendance, ok := h.attendance(c)
+	if !ok {
+		return
+	}
+
+	pdfBytes, err := h.examService.AttendancePDF(c.Request.Context(), attendance)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="daftar-hadir.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// ExportAttendanceCSV godoc
+// GET /api/v1/admin/exams/:id/attendance.csv
+// Returns the exam's attendance sheet as CSV, with the same rows and grouping as the PDF.
+func (h *ExamHandler) ExportAttendanceCSV(c *gin.Context) {
+	attendance, ok := h.attendance(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	// The exam's metadata follows as one column per field, the same on every row.
+	header := []string{"group", "no", "nisn", "nis", "name", "class", "room", "seat", "status", "started_at"}
+	metadata := make([]string, len(attendance.Metadata))
+	for i, m := range attendance.Metadata {
+		header = append(header, m.Key)
+		metadata[i] = m.Value
+	}
+	_ = w.Write(header)
+	for _, group := range attendance.Groups {
+		for i, row := range group.Rows {
+			status, startedAt := "", ""
+			if row.SessionStatus != nil {
+				status = string(*row.SessionStatus)
+			}
+			if row.StartedAt != nil {
+				startedAt = row.StartedAt.Format(time.RFC3339)
+			}
+			record := []string{group.Name, strconv.Itoa(i + 1), row.NISN, row.NIS, row.Name, row.ClassName,
+				row.RoomName, strconv.Itoa(row.SeatNumber), status, startedAt}
+			_ = w.Write(append(record, metadata...))
+		}
+	}
+	w.Flush()
+
+	c.Header("Content-Disposition", `attachment; filename="daftar-hadir.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// attendance loads the attendance sheet requested by c, or writes the error response.
+func (h *ExamHandler) attendance(c *gin.Context) (*model.ExamAttendance, bool) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return nil, false
+	}
+
+	groupBy := c.DefaultQuery("group_by", model.AttendanceByClass)
+	if groupBy != model.AttendanceByClass && groupBy != model.AttendanceByRoom {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation,
+			map[string]string{"group_by": "must be class or room"})
+		return nil, false
+	}
+
+	attendance, err := h.examService.Attendance(c.Request.Context(), examID, groupBy)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	if len(attendance.Groups) == 0 {
+		response.Fail(c, http.StatusNotFound, response.ErrNotFound)
+		return nil, false
+	}
+	return attendance, true
+}
+
+// StartPaperExport godoc
+// POST /api/v1/admin/exams/:id/paper
+// Queues a printable question paper of the exam, with an answer sheet and optionally
+// the answer key, as an offline fallback. Returns the job to poll.
+func (h *ExamHandler) StartPaperExport(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.ExamPaperRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+	seed := time.Now().UnixNano()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+
+	job, err := h.examService.StartPaperExport(c.Request.Context(), examID, req.IncludeAnswerKey, seed, claims.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, job)
+}
+
+// GetPaperExport godoc
+// GET /api/v1/admin/exams/:id/paper/:job_id
+// Returns the status of a question paper export.
+func (h *ExamHandler) GetPaperExport(c *gin.Context) {
+	examID, jobID, ok := paperJobIDs(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.examService.PaperJob(c.Request.Context(), examID, jobID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, job)
+}
+
+// DownloadPaperExport godoc
+// GET /api/v1/admin/exams/:id/paper/:job_id/download
+// Downloads the PDF of a finished question paper export.
+func (h *ExamHandler) DownloadPaperExport(c *gin.Context) {
+	examID, jobID, ok := paperJobIDs(c)
+	if !ok {
+		return
+	}
+
+	job, pdfBytes, err := h.examService.PaperPDF(c.Request.Context(), examID, jobID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filename := "naskah-soal.pdf"
+	if job.IncludeAnswerKey {
+		filename = "naskah-soal-kunci.pdf"
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// paperJobIDs parses the exam and export job IDs of c, or writes the error response.
+func paperJobIDs(c *gin.Context) (examID, jobID uuid.UUID, ok bool) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return examID, jobID, false
+	}
+	jobID, err = uuid.Parse(c.Param("job_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return examID, jobID, false
+	}
+	return examID, jobID, true
+}
+
+// GetExamReadiness godoc
+// GET /api/v1/admin/exams/:id/readiness
+// Returns a go/no-go report before a big exam: cache warmth, eligible students,
+// expected peak connections, worker queue backlog and DB/Redis latency.
+func (h *ExamHandler) GetExamReadiness(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	result, err := h.examService.Readiness(c.Request.Context(), examID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// GetExamPayloadSize godoc
+// GET /api/v1/admin/exams/:id/payload-size
+// Returns what each student downloads when the exam starts, its text and referenced
+// media, against the configured payload limits.
+func (h *ExamHandler) GetExamPayloadSize(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	size, err := h.examService.PayloadSize(c.Request.Context(), examID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, size)
+}
+
 // GetExamResults godoc
 // GET /api/v1/admin/exams/:exam_id/results
 // Returns paginated student results for an exam, optionally filtered by class_id.
@@ -347,9 +732,15 @@ func (h *ExamHandler) GetExamResults(c *gin.Context) {
 		groupNumber = &gn
 	}
 
-	var religion *string
+	var religion *model.Religion
 	if relStr := c.Query("religion"); relStr != "" {
-		religion = &relStr
+		// Filtering by religion would reveal it row by row to callers who may not see it.
+		if !middleware.HasPermission(c, string(model.PermissionStudentsWrite)) {
+			response.Fail(c, http.StatusForbidden, response.ErrForbidden)
+			return
+		}
+		rel := model.Religion(relStr)
+		religion = &rel
 	}
 
 	results, total, err := h.sessionService.GetExamResults(c.Request.Context(), examID, page, perPage, classID, gradeLevel, majorCode, groupNumber, religion)
@@ -358,14 +749,124 @@ func (h *ExamHandler) GetExamResults(c *gin.Context) {
 		return
 	}
 
-	pagination := &response.Pagination{
-		Page:       page,
-		PerPage:    perPage,
-		TotalItems: int(total),
-		TotalPages: int(math.Ceil(float64(total) / float64(perPage))),
+	redactResults(c, results)
+
+	response.SuccessPage(c, http.StatusOK, results, response.NewPagination(page, perPage, int(total)))
+}
+
+// RecordOfflineScores godoc
+// POST /api/v1/admin/exams/:id/offline-scores
+// Enters the scores of a paper exam held when the online exam could not run, as JSON
+// entries or an uploaded CSV (file) with nisn and score columns. Returns the report
+// as a dry run unless ?confirm=true, in which case the scores are saved.
+func (h *ExamHandler) RecordOfflineScores(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+	confirm := c.Query("confirm") == "true"
+
+	var report *model.OfflineScoreReport
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			response.Fail(c, http.StatusBadRequest, response.ErrFileRequired)
+			return
+		}
+		defer file.Close()
+		report, err = h.sessionService.RecordOfflineScoresCSV(c.Request.Context(), examID, header.Filename, file, confirm)
+	} else {
+		var req model.OfflineScoresRequest
+		if fields := validator.Bind(c, &req); fields != nil {
+			response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+			return
+		}
+		report, err = h.sessionService.RecordOfflineScores(c.Request.Context(), examID, req.Entries, confirm)
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, report)
+}
+
+// ListStaleSessions godoc
+// GET /api/v1/admin/exams/:id/sessions/stale
+// Lists IN_PROGRESS sessions whose time ran out more than ?grace_minutes= (default
+// 15) ago without the student submitting.
+func (h *ExamHandler) ListStaleSessions(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+	grace := service.DefaultStaleGraceMinutes
+	if raw := c.Query("grace_minutes"); raw != "" {
+		grace, err = strconv.Atoi(raw)
+		if err != nil || grace < 0 || grace > 1440 {
+			response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation,
+				map[string]string{"grace_minutes": "must be between 0 and 1440"})
+			return
+		}
+	}
+
+	sessions, err := h.sessionService.StaleSessions(c.Request.Context(), examID, time.Duration(grace)*time.Minute)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.SuccessList(c, http.StatusOK, sessions)
+}
+
+// SubmitStaleSessions godoc
+// POST /api/v1/admin/exams/:id/sessions/stale/submit
+// Submits the exam's stale sessions, or the listed students' among them, grading
+// their saved answers as their own submit would.
+func (h *ExamHandler) SubmitStaleSessions(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.SubmitStaleSessionsRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+	grace := service.DefaultStaleGraceMinutes
+	if req.GraceMinutes != nil {
+		grace = *req.GraceMinutes
+	}
+
+	result, err := h.sessionService.SubmitStaleSessions(c.Request.Context(), examID, time.Duration(grace)*time.Minute, req.StudentIDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, result)
+}
+
+// GetExamStats godoc
+// GET /api/v1/admin/exams/:id/stats
+// Returns an exam's participant count, score average, median and standard deviation,
+// and completion rate, as last refreshed by the stats worker.
+func (h *ExamHandler) GetExamStats(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	stats, err := h.sessionService.GetExamStats(c.Request.Context(), examID)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
 	}
 
-	response.SuccessWithPagination(c, http.StatusOK, results, pagination)
+	response.Success(c, http.StatusOK, stats)
 }
 
 // GetExam godoc
@@ -383,6 +884,7 @@ func (h *ExamHandler) GetExam(c *gin.Context) {
 		response.Fail(c, http.StatusNotFound, response.ErrInvalidID) // Or a specific NotFound error
 		return
 	}
+	redactExams(c, exam)
 
 	response.Success(c, http.StatusOK, exam)
 }
@@ -440,18 +942,80 @@ func (h *ExamHandler) UpdateExam(c *gin.Context) {
 	if req.QBankID != nil {
 		existing.QBankID = req.QBankID
 	}
+	if req.ShowClassAverage != nil {
+		existing.ShowClassAverage = *req.ShowClassAverage
+	}
+	if req.AllowReview != nil {
+		existing.AllowReview = *req.AllowReview
+	}
+	if req.Language != "" {
+		existing.Language = req.Language
+	}
+	if req.Languages != nil {
+		existing.Languages = req.Languages
+	}
+	if req.ForcedLanguage != nil {
+		existing.ForcedLanguage = *req.ForcedLanguage
+	}
+	if req.ResultsAccessCode != "" {
+		existing.ResultsAccessCode = strings.ToUpper(req.ResultsAccessCode)
+	}
+	if req.PublicResults != nil {
+		existing.PublicResults = *req.PublicResults
+		// Publishing without a code would make results guessable by NISN alone.
+		if existing.PublicResults && existing.ResultsAccessCode == "" {
+			existing.ResultsAccessCode = generateToken()
+		}
+	}
+	if req.NavigationPolicy != "" {
+		existing.NavigationPolicy = req.NavigationPolicy
+	}
+	if req.SectionSize != nil {
+		existing.SectionSize = *req.SectionSize
+	}
+	if existing.NavigationPolicy == model.NavigationSectionLocked && existing.SectionSize < 1 {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"section_size": "section_size is required for SECTION_LOCKED"})
+		return
+	}
+	if req.MaxBreaks != nil {
+		existing.MaxBreaks = *req.MaxBreaks
+	}
+	if req.BreakMinutes != nil {
+		existing.BreakMinutes = *req.BreakMinutes
+	}
+	if existing.MaxBreaks > 0 && existing.BreakMinutes < 1 {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"break_minutes": "break_minutes is required when max_breaks is set"})
+		return
+	}
+	if req.DisconnectPauseMinutes != nil {
+		existing.DisconnectPauseMinutes = *req.DisconnectPauseMinutes
+	}
+	if req.Instructions != nil {
+		existing.Instructions = strings.TrimSpace(*req.Instructions)
+	}
+	if req.HonorCode != nil {
+		existing.HonorCode = strings.TrimSpace(*req.HonorCode)
+	}
+	if req.Metadata != nil {
+		existing.Metadata = req.Metadata
+	}
 
-	if err := h.examService.Update(c.Request.Context(), existing); err != nil {
-		switch {
-		case errors.Is(err, service.ErrExamNotDraft):
-			response.Fail(c, http.StatusBadRequest, response.ErrExamNotDraft)
-		default:
-			response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+	conflicts, err := h.examService.Update(c.Request.Context(), existing)
+	if err != nil {
+		if errors.Is(err, service.ErrScheduleConflict) {
+			failScheduleConflict(c, conflicts)
+			return
 		}
+		c.Error(err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, gin.H{"exam": existing})
+	// Only a change of question bank can move the exam to another subject.
+	var subjectWarning *model.SubjectTeacherWarning
+	if req.QBankID != nil {
+		subjectWarning, _ = h.examService.SubjectTeacherWarning(c.Request.Context(), existing, claims.UserID)
+	}
+	response.SuccessWithWarnings(c, http.StatusOK, gin.H{"exam": existing}, examWarnings(conflicts, subjectWarning))
 }
 
 // DeleteExam godoc
@@ -471,14 +1035,87 @@ func (h *ExamHandler) DeleteExam(c *gin.Context) {
 	}
 
 	if err := h.examService.Delete(c.Request.Context(), id); err != nil {
-		switch {
-		case errors.Is(err, service.ErrExamNotDraft):
-			response.Fail(c, http.StatusBadRequest, response.ErrExamNotDraft)
-		default:
-			response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
-		}
+		c.Error(err)
 		return
 	}
 
 	response.Success(c, http.StatusOK, gin.H{"message": "exam deleted"})
 }
+
+// BulkExams godoc
+// POST /api/v1/admin/exams/bulk
+// Publishes, archives or deletes many exams at once, reporting each exam's outcome
+// with the error code its single-exam endpoint would have answered. Publishing and
+// archiving also require exams:publish.
+func (h *ExamHandler) BulkExams(c *gin.Context) {
+	var req model.BulkExamRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+	if req.Action != model.BulkExamDelete && !middleware.HasPermission(c, string(model.PermissionExamsPublish)) {
+		response.Fail(c, http.StatusForbidden, response.ErrPermissionDenied)
+		return
+	}
+
+	method := http.MethodPost
+	if req.Action == model.BulkExamDelete {
+		method = http.MethodDelete
+	}
+	result := h.examService.Bulk(c.Request.Context(), req.Action, req.ExamIDs)
+	for i := range result.Items {
+		item := &result.Items[i]
+		if item.Err == nil {
+			continue
+		}
+		code := response.ErrScheduleConflict
+		if !errors.Is(item.Err, service.ErrScheduleConflict) {
+			_, code = middleware.ErrorCode(item.Err, method)
+		}
+		item.Code = string(code)
+		item.Error = response.GetMessage(code)
+		if code == response.ErrValidation {
+			item.Error = item.Err.Error()
+		}
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// conflictWarnings converts schedule conflicts into the response warnings field,
+// returning nil when there are none so the field is omitted.
+func conflictWarnings(conflicts []model.ExamConflict) interface{} {
+	if len(conflicts) == 0 {
+		return nil
+	}
+	return conflicts
+}
+
+// examWarnings combines schedule conflicts and a subject teacher warning into the
+// response warnings field, returning nil when there are none.
+func examWarnings(conflicts []model.ExamConflict, subjectWarning *model.SubjectTeacherWarning) interface{} {
+	if subjectWarning == nil {
+		return conflictWarnings(conflicts)
+	}
+	warnings := make([]interface{}, 0, len(conflicts)+1)
+	for _, cf := range conflicts {
+		warnings = append(warnings, cf)
+	}
+	return append(warnings, subjectWarning)
+}
+
+// failScheduleConflict rejects a change under the "block" conflict policy,
+// listing each conflicting exam with the shared classes and overlap window.
+func failScheduleConflict(c *gin.Context, conflicts []model.ExamConflict) {
+	fields := make(map[string]string, len(conflicts))
+	for _, cf := range conflicts {
+		classes := make([]string, len(cf.ClassIDs))
+		for i, id := range cf.ClassIDs {
+			classes[i] = strconv.Itoa(id)
+		}
+		fields[cf.OtherExamID.String()] = fmt.Sprintf("%s (classes %s, %s – %s)",
+			cf.OtherTitle, strings.Join(classes, ","),
+			cf.OverlapStart.Time().Format("2006-01-02 15:04"), cf.OverlapEnd.Time().Format("2006-01-02 15:04"))
+	}
+	response.FailWithFields(c, http.StatusConflict, response.ErrScheduleConflict, fields)
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/response"
+	"github.com/stemsi/exstem-backend/internal/service"
+	"github.com/stemsi/exstem-backend/internal/validator"
+)
+
+// GuardianHandler handles admin-facing guardian account management.
+type GuardianHandler struct {
+	guardianService *service.GuardianService
+}
+
+// NewGuardianHandler creates a new GuardianHandler.
+func NewGuardianHandler(guardianService *service.GuardianService) *GuardianHandler {
+	return &GuardianHandler{guardianService: guardianService}
+}
+
+// ListGuardians godoc
+// GET /api/v1/admin/guardians?search=&page=&per_page=
+func (h *GuardianHandler) ListGuardians(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	var search *string
+	if q := c.Query("search"); q != "" {
+		search = &q
+	}
+
+	guardians, pagination, err := h.guardianService.List(c.Request.Context(), search, page, perPage)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, gin.H{"guardians": response.NonNil(guardians)}, pagination)
+}
+
+// GetGuardian godoc
+// GET /api/v1/admin/guardians/:id
+// Returns a guardian with their linked students.
+func (h *GuardianHandler) GetGuardian(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	guardian, err := h.guardianService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	children, err := h.guardianService.ListChildren(c.Request.Context(), id)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"guardian": guardian, "children": response.NonNil(children)})
+}
+
+// CreateGuardian godoc
+// POST /api/v1/admin/guardians
+func (h *GuardianHandler) CreateGuardian(c *gin.Context) {
+	var req model.CreateGuardianRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	guardian, err := h.guardianService.Create(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, gin.H{"guardian": guardian})
+}
+
+// UpdateGuardian godoc
+// PUT /api/v1/admin/guardians/:id
+func (h *GuardianHandler) UpdateGuardian(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.UpdateGuardianRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	guardian, err := h.guardianService.Update(c.Request.Context(), id, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"guardian": guardian})
+}
+
+// DeleteGuardian godoc
+// DELETE /api/v1/admin/guardians/:id
+func (h *GuardianHandler) DeleteGuardian(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	if err := h.guardianService.Delete(c.Request.Context(), id); err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "guardian deleted successfully"})
+}
+
+// LinkStudent godoc
+// POST /api/v1/admin/guardians/:id/students
+// Links a student to the guardian.
+func (h *GuardianHandler) LinkStudent(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.LinkGuardianStudentRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	if err := h.guardianService.LinkStudent(c.Request.Context(), id, req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	children, err := h.guardianService.ListChildren(c.Request.Context(), id)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"children": response.NonNil(children)})
+}
+
+// UnlinkStudent godoc
+// DELETE /api/v1/admin/guardians/:id/students/:student_id
+func (h *GuardianHandler) UnlinkStudent(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+	studentID, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	if err := h.guardianService.UnlinkStudent(c.Request.Context(), id, studentID); err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "student unlinked successfully"})
+}
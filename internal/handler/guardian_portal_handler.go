@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/middleware"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/response"
+	"github.com/stemsi/exstem-backend/internal/service"
+	"github.com/stemsi/exstem-backend/internal/validator"
+)
+
+// GuardianPortalHandler serves the read-only guardian portal.
+type GuardianPortalHandler struct {
+	guardianService *service.GuardianService
+	sessionService  *service.ExamSessionService
+}
+
+// NewGuardianPortalHandler creates a new GuardianPortalHandler.
+func NewGuardianPortalHandler(guardianService *service.GuardianService, sessionService *service.ExamSessionService) *GuardianPortalHandler {
+	return &GuardianPortalHandler{
+		guardianService: guardianService,
+		sessionService:  sessionService,
+	}
+}
+
+// ListChildren godoc
+// GET /api/v1/guardian/children
+// Lists the students linked to the guardian.
+func (h *GuardianPortalHandler) ListChildren(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	children, err := h.guardianService.ListChildren(c.Request.Context(), claims.UserID)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.SuccessList(c, http.StatusOK, children)
+}
+
+// GetChildSchedule godoc
+// GET /api/v1/guardian/children/:student_id/schedule?days=7
+// Returns a child's upcoming exam schedule, as the student sees it.
+func (h *GuardianPortalHandler) GetChildSchedule(c *gin.Context) {
+	child, ok := h.child(c)
+	if !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 1 || days > 31 {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"days": "days must be between 1 and 31"})
+		return
+	}
+
+	schedule, err := h.sessionService.GetSchedule(c.Request.Context(), child.StudentID, child.ClassID, days)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.SuccessList(c, http.StatusOK, schedule)
+}
+
+// GetChildHistory godoc
+// GET /api/v1/guardian/children/:student_id/exams
+// Lists a child's exam sessions; scores appear once results are available.
+func (h *GuardianPortalHandler) GetChildHistory(c *gin.Context) {
+	child, ok := h.child(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.sessionService.GetStudentHistory(c.Request.Context(), child.StudentID)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.SuccessList(c, http.StatusOK, history)
+}
+
+// GetChildResult godoc
+// GET /api/v1/guardian/children/:student_id/exams/:exam_id/result
+// Returns a child's result for an exam under the same rules as the student result page.
+func (h *GuardianPortalHandler) GetChildResult(c *gin.Context) {
+	child, ok := h.child(c)
+	if !ok {
+		return
+	}
+
+	examID, err := uuid.Parse(c.Param("exam_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	result, err := h.sessionService.GetStudentResult(c.Request.Context(), examID, child.StudentID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// GetPreferences godoc
+// GET /api/v1/guardian/preferences
+// Returns the guardian's notification preferences.
+func (h *GuardianPortalHandler) GetPreferences(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	guardian, err := h.guardianService.GetByID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		response.Fail(c, http.StatusNotFound, response.ErrNotFound)
+		return
+	}
+
+	response.Success(c, http.StatusOK, guardianPreferences(guardian))
+}
+
+// UpdatePreferences godoc
+// PUT /api/v1/guardian/preferences
+// Sets how (none, telegram, whatsapp) and about what the guardian is notified.
+func (h *GuardianPortalHandler) UpdatePreferences(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	var req model.UpdateGuardianPreferencesRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+	if req.NotifyChannel == model.NotifyChannelTelegram && req.TelegramChatID == "" {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"telegram_chat_id": "telegram_chat_id is required for telegram"})
+		return
+	}
+
+	guardian, err := h.guardianService.UpdatePreferences(c.Request.Context(), claims.UserID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, guardianPreferences(guardian))
+}
+
+// child resolves :student_id to one of the guardian's children. Students that are
+// not theirs are reported as not found.
+func (h *GuardianPortalHandler) child(c *gin.Context) (*model.GuardianChild, bool) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return nil, false
+	}
+
+	studentID, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return nil, false
+	}
+
+	child, err := h.guardianService.GetChild(c.Request.Context(), claims.UserID, studentID)
+	if err != nil {
+		c.Error(err)
+		return nil, false
+	}
+	return child, true
+}
+
+func guardianPreferences(g *model.Guardian) gin.H {
+	return gin.H{
+		"notify_channel":   g.NotifyChannel,
+		"telegram_chat_id": g.TelegramChatID,
+		"notify_results":   g.NotifyResults,
+	}
+}
@@ -29,7 +29,7 @@ func (h *MajorHandler) GetAll(c *gin.Context) {
 		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
 		return
 	}
-	response.Success(c, http.StatusOK, gin.H{"majors": majors})
+	response.Success(c, http.StatusOK, gin.H{"majors": response.NonNil(majors)})
 }
 
 func (h *MajorHandler) Create(c *gin.Context) {
@@ -76,7 +76,7 @@ func (h *MajorHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.majorService.DeleteMajor(c.Request.Context(), id); err != nil {
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 	response.Success(c, http.StatusOK, gin.H{"message": "major deleted successfully"})
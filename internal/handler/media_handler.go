@@ -1,15 +1,17 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/middleware"
 	"github.com/stemsi/exstem-backend/internal/response"
 	"github.com/stemsi/exstem-backend/internal/service"
 )
 
-// MediaHandler handles media upload endpoints.
+// MediaHandler handles media upload and library endpoints.
 type MediaHandler struct {
 	mediaService *service.MediaService
 }
@@ -21,8 +23,15 @@ func NewMediaHandler(mediaService *service.MediaService) *MediaHandler {
 
 // UploadMedia godoc
 // POST /api/v1/admin/media/upload
-// Uploads an image file and returns its URL.
+// Uploads an image, audio or video file and returns its URL.
+// Audio/video uploads may include a duration_seconds form field.
 func (h *MediaHandler) UploadMedia(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		response.Fail(c, http.StatusBadRequest, response.ErrFileRequired)
@@ -30,18 +39,77 @@ func (h *MediaHandler) UploadMedia(c *gin.Context) {
 	}
 	defer file.Close()
 
-	url, err := h.mediaService.SaveUpload(file, header)
-	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrUnsupportedFileType):
-			response.Fail(c, http.StatusBadRequest, response.ErrUnsupportedFile)
-		case errors.Is(err, service.ErrFileTooLarge):
-			response.Fail(c, http.StatusBadRequest, response.ErrFileTooLarge)
-		default:
-			response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+	var duration *int
+	if durStr := c.PostForm("duration_seconds"); durStr != "" {
+		d, err := strconv.Atoi(durStr)
+		if err != nil {
+			response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"duration_seconds": "duration_seconds must be an integer"})
+			return
 		}
+		duration = &d
+	}
+
+	media, err := h.mediaService.SaveUpload(c.Request.Context(), file, header, claims.UserID, duration)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"url": media.URL, "media": media})
+}
+
+// ListMedia godoc
+// GET /api/v1/admin/media
+// Lists uploaded media files with pagination and usage counts.
+func (h *MediaHandler) ListMedia(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	search := c.Query("search")
+
+	files, pagination, err := h.mediaService.List(c.Request.Context(), page, perPage, search)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.SuccessPage(c, http.StatusOK, files, pagination)
+}
+
+// GetMediaUsage godoc
+// GET /api/v1/admin/media/:id/usage
+// Lists the questions, reading passages and settings that reference a media file.
+func (h *MediaHandler) GetMediaUsage(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	usages, err := h.mediaService.GetUsage(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.SuccessList(c, http.StatusOK, usages)
+}
+
+// DeleteMedia godoc
+// DELETE /api/v1/admin/media/:id
+// Deletes a media file. Files still in use require ?force=true.
+func (h *MediaHandler) DeleteMedia(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	force := c.Query("force") == "true"
+
+	if err := h.mediaService.Delete(c.Request.Context(), id, force); err != nil {
+		c.Error(err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, gin.H{"url": url})
+	response.Success(c, http.StatusOK, gin.H{"message": "media deleted successfully"})
 }
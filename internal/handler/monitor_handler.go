@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -21,6 +24,7 @@ const (
 	refreshInterval   = 15 * time.Second
 	keepAliveInterval = 30 * time.Second
 	refreshTimeout    = 5 * time.Second // prevent slow queries from blocking the SSE loop
+	overviewInterval  = 10 * time.Second
 )
 
 type MonitorHandler struct {
@@ -57,14 +61,7 @@ func (h *MonitorHandler) MonitorExamSSE(c *gin.Context) {
 		return
 	}
 
-	hasPerm := false
-	for _, p := range claims.Permissions {
-		if p == "exams:write" {
-			hasPerm = true
-			break
-		}
-	}
-	if !hasPerm {
+	if !middleware.HasPermission(c, string(model.PermissionMonitorRead)) {
 		response.Fail(c, http.StatusForbidden, response.ErrForbidden)
 		return
 	}
@@ -90,6 +87,7 @@ func (h *MonitorHandler) MonitorExamSSE(c *gin.Context) {
 	c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 
 	totalQuestions := exam.QuestionCount
+	itemStats := h.monitorService.ItemStatsEnabled(reqCtx)
 
 	// 3. Build & send initial snapshot
 	h.sendInitialSnapshot(c, reqCtx, examID, exam, totalQuestions)
@@ -135,7 +133,7 @@ func (h *MonitorHandler) MonitorExamSSE(c *gin.Context) {
 			if !hasStudents {
 				continue // no point querying if nobody has joined
 			}
-			h.sendRefresh(c, reqCtx, examID, totalQuestions)
+			h.sendRefresh(c, reqCtx, examID, totalQuestions, itemStats)
 
 		case <-keepAliveTicker.C:
 			c.Writer.Write([]byte("data: "))
@@ -186,6 +184,30 @@ func (h *MonitorHandler) sendInitialSnapshot(
 		})
 	}
 
+	// Current question positions are 0-based; the monitor shows question numbers.
+	if current, err := h.sessionService.CurrentQuestions(ctx, examID); err == nil {
+		for i, s := range studentsSnapshot {
+			if sid, ok := s["student_id"].(int); ok {
+				if pos, found := current[sid]; found {
+					studentsSnapshot[i]["current_question"] = pos + 1
+				}
+			}
+		}
+	}
+
+	// Students in progress whose socket went silent are reported with how long for.
+	if idle, err := h.sessionService.IdleStudents(ctx, examID, wsIdleAfter); err == nil {
+		for i, s := range studentsSnapshot {
+			sid, ok := s["student_id"].(int)
+			if !ok || s["status"] != model.SessionStatusInProgress {
+				continue
+			}
+			if secs, found := idle[sid]; found {
+				studentsSnapshot[i]["idle_seconds"] = secs
+			}
+		}
+	}
+
 	// Fetch counts with a timeout so a slow query doesn't block the connection
 	var initialTotalCheats int64
 	fetchCtx, cancel := context.WithTimeout(ctx, refreshTimeout)
@@ -229,7 +251,8 @@ func (h *MonitorHandler) sendInitialSnapshot(
 }
 
 // sendRefresh polls DB+Redis for current progress and sends a compact refresh event.
-func (h *MonitorHandler) sendRefresh(c *gin.Context, parentCtx context.Context, examID uuid.UUID, totalQuestions int) {
+// With itemStats it adds each question's answer distribution so far.
+func (h *MonitorHandler) sendRefresh(c *gin.Context, parentCtx context.Context, examID uuid.UUID, totalQuestions int, itemStats bool) {
 	// Scoped timeout prevents a slow query from stalling the SSE loop
 	ctx, cancel := context.WithTimeout(parentCtx, refreshTimeout)
 	defer cancel()
@@ -240,15 +263,33 @@ func (h *MonitorHandler) sendRefresh(c *gin.Context, parentCtx context.Context,
 		return
 	}
 
+	// Best-effort: a Redis failure only leaves out the current questions.
+	current, err := h.sessionService.CurrentQuestions(ctx, examID)
+	if err != nil {
+		h.log.Warn().Err(err).Msg("Failed to fetch current questions for refresh")
+	}
+
+	idle, err := h.sessionService.IdleStudents(ctx, examID, wsIdleAfter)
+	if err != nil {
+		h.log.Warn().Err(err).Msg("Failed to fetch idle students for refresh")
+	}
+
 	// Single-pass merge: iterate answered counts, decorate with cheat counts
 	progressData := make([]map[string]interface{}, 0, len(progress.AnsweredCounts)+len(progress.CheatCounts))
 
 	for sid, answered := range progress.AnsweredCounts {
-		progressData = append(progressData, map[string]interface{}{
+		entry := map[string]interface{}{
 			"student_id":     sid,
 			"answered_count": answered,
 			"cheat_count":    progress.CheatCounts[sid], // 0 if missing
-		})
+		}
+		if pos, found := current[sid]; found {
+			entry["current_question"] = pos + 1
+		}
+		if secs, found := idle[sid]; found {
+			entry["idle_seconds"] = secs
+		}
+		progressData = append(progressData, entry)
 		delete(progress.CheatCounts, sid) // mark as handled
 	}
 
@@ -261,11 +302,306 @@ func (h *MonitorHandler) sendRefresh(c *gin.Context, parentCtx context.Context,
 		})
 	}
 
-	c.SSEvent("message", map[string]interface{}{
+	event := map[string]interface{}{
 		"type":            "refresh",
 		"total_questions": totalQuestions,
 		"total_cheats":    progress.TotalCheats,
 		"students":        progressData,
+	}
+	if itemStats {
+		// Best-effort: a failure only leaves out the item stats.
+		if stats, err := h.itemStats(ctx, examID); err == nil {
+			event["item_stats"] = stats
+		} else {
+			h.log.Warn().Err(err).Msg("Failed to fetch item stats for refresh")
+		}
+	}
+
+	c.SSEvent("message", event)
+	c.Writer.Flush()
+}
+
+// itemStats computes the live answer distribution of an exam's questions from its
+// cached payload and answer key.
+func (h *MonitorHandler) itemStats(ctx context.Context, examID uuid.UUID) ([]model.LiveItemStat, error) {
+	payload, err := h.examService.GetExamPayload(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	answerKey, err := h.examService.GetAnswerKey(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	return h.monitorService.GetItemStats(ctx, examID, payload.Questions, answerKey)
+}
+
+// MonitorOverviewSSE godoc
+// GET /api/v1/admin/monitor/overview
+// Streams aggregate figures across every running exam every overviewInterval, for
+// watching the whole exam day on one screen.
+func (h *MonitorHandler) MonitorOverviewSSE(c *gin.Context) {
+	reqCtx := c.Request.Context()
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+
+	h.log.Info().Msg("Admin attached to monitor overview SSE")
+
+	ticker := time.NewTicker(overviewInterval)
+	defer ticker.Stop()
+
+	// Send immediately on connect, then every tick
+	h.sendOverview(c, reqCtx)
+
+	for {
+		select {
+		case <-reqCtx.Done():
+			h.log.Info().Msg("Admin disconnected from monitor overview SSE")
+			return
+		case <-ticker.C:
+			h.sendOverview(c, reqCtx)
+		}
+	}
+}
+
+// sendOverview writes one overview event. A failed refresh is skipped; the next tick
+// tries again.
+func (h *MonitorHandler) sendOverview(c *gin.Context, parentCtx context.Context) {
+	ctx, cancel := context.WithTimeout(parentCtx, refreshTimeout)
+	defer cancel()
+
+	overview, err := h.monitorService.GetOverview(ctx)
+	if err != nil {
+		h.log.Warn().Err(err).Msg("Failed to build monitor overview")
+		return
+	}
+	c.SSEvent("message", map[string]interface{}{
+		"type":     "overview",
+		"overview": overview,
 	})
 	c.Writer.Flush()
 }
+
+// GetMonitorReplay godoc
+// GET /api/v1/admin/exams/:id/monitor/replay?from=&to=&after=&limit=
+// Returns the exam's recorded monitor events between from and to (RFC 3339, both
+// optional), oldest first. Pages hold up to limit events (default 1000, max 5000);
+// pass next_after back as after for the next page.
+func (h *MonitorHandler) GetMonitorReplay(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var from time.Time
+	to := time.Now()
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"from": "from must be an RFC 3339 time"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"to": "to must be an RFC 3339 time"})
+			return
+		}
+	}
+	if to.Before(from) {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"to": "to must not be before from"})
+		return
+	}
+	after, err := strconv.ParseInt(c.DefaultQuery("after", "0"), 10, 64)
+	if err != nil || after < 0 {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"after": "after must be an event ID"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	if _, err := h.examService.GetByID(c.Request.Context(), examID); err != nil {
+		response.Fail(c, http.StatusNotFound, response.ErrNotFound)
+		return
+	}
+
+	replay, err := h.monitorService.GetReplay(c.Request.Context(), examID, from, to, after, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, replay)
+}
+
+// GetSessionTimeline godoc
+// GET /api/v1/admin/exams/:id/sessions/:student_id/timeline
+// Returns a student's session as one timeline for dispute resolution: join,
+// connects and disconnects, autosaves sampled per minute, cheat events and submit,
+// with the answers as persisted.
+func (h *MonitorHandler) GetSessionTimeline(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+	studentID, err := strconv.Atoi(c.Param("student_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	if _, err := h.examService.GetByID(c.Request.Context(), examID); err != nil {
+		response.Fail(c, http.StatusNotFound, response.ErrNotFound)
+		return
+	}
+
+	timeline, err := h.monitorService.SessionTimeline(c.Request.Context(), examID, studentID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, timeline)
+}
+
+// GetCheatAnalytics godoc
+// GET /api/v1/admin/analytics/cheats?from=YYYY-MM-DD&to=YYYY-MM-DD
+// Returns cheat event rates per class, repeat offenders and counts per event type for
+// the sessions started in the period. Both dates are inclusive; defaults to the
+// current semester, max 366 days.
+func (h *MonitorHandler) GetCheatAnalytics(c *gin.Context) {
+	from, to, ok := cheatAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	analytics, err := h.monitorService.GetCheatAnalytics(c.Request.Context(), from, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, analytics)
+}
+
+// ExportCheatAnalytics godoc
+// GET /api/v1/admin/analytics/cheats/export?from=YYYY-MM-DD&to=YYYY-MM-DD
+// Downloads the cheat analytics as a workbook.
+func (h *MonitorHandler) ExportCheatAnalytics(c *gin.Context) {
+	from, to, ok := cheatAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	b, err := h.monitorService.ExportCheatAnalyticsXLSX(c.Request.Context(), from, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=Analisis_Kecurangan.xlsx")
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", b)
+}
+
+// GetActivityReport godoc
+// GET /api/v1/admin/analytics/activity?from=YYYY-MM-DD&to=YYYY-MM-DD
+// Returns the exams held in the period with their participants and integrity
+// incidents, as accreditation evidence. Defaults to the current semester.
+func (h *MonitorHandler) GetActivityReport(c *gin.Context) {
+	from, to, ok := cheatAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.monitorService.ActivityReport(c.Request.Context(), from, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, report)
+}
+
+// ExportActivityReportCSV godoc
+// GET /api/v1/admin/analytics/activity.csv?from=YYYY-MM-DD&to=YYYY-MM-DD
+// Returns the activity report as CSV, one row per exam held.
+func (h *MonitorHandler) ExportActivityReportCSV(c *gin.Context) {
+	from, to, ok := cheatAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.monitorService.ActivityReport(c.Request.Context(), from, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"exam_id", "title", "held_at", "participants", "completed", "incidents", "average_score"})
+	for _, e := range report.Exams {
+		_ = w.Write([]string{e.ExamID.String(), e.Title, e.HeldAt.Format(time.RFC3339), strconv.Itoa(e.Participants),
+			strconv.Itoa(e.Completed), strconv.Itoa(e.Incidents), formatScore(e.AverageScore)})
+	}
+	w.Flush()
+
+	c.Header("Content-Disposition", `attachment; filename="laporan-kegiatan-ujian.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// ExportActivityReportPDF godoc
+// GET /api/v1/admin/analytics/activity.pdf?from=YYYY-MM-DD&to=YYYY-MM-DD
+// Prints the activity report with the period's figures, the exams held and a space
+// for the principal's signature.
+func (h *MonitorHandler) ExportActivityReportPDF(c *gin.Context) {
+	from, to, ok := cheatAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.monitorService.ActivityReport(c.Request.Context(), from, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	pdfBytes, err := h.monitorService.ActivityReportPDF(c.Request.Context(), report)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="laporan-kegiatan-ujian.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// cheatAnalyticsPeriod reads the optional from and to dates, returning the period as
+// [from, to). Both are zero when neither is given.
+func cheatAnalyticsPeriod(c *gin.Context) (time.Time, time.Time, bool) {
+	fromStr, toStr := c.Query("from"), c.Query("to")
+	if fromStr == "" && toStr == "" {
+		return time.Time{}, time.Time{}, true
+	}
+	if fromStr == "" || toStr == "" {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"from": "from and to must be given together"})
+		return time.Time{}, time.Time{}, false
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", fromStr, time.Local)
+	if err != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"from": "from must be a date (YYYY-MM-DD)"})
+		return time.Time{}, time.Time{}, false
+	}
+	to, err := time.ParseInLocation("2006-01-02", toStr, time.Local)
+	if err != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"to": "to must be a date (YYYY-MM-DD)"})
+		return time.Time{}, time.Time{}, false
+	}
+	if to.Before(from) {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"to": "to must not be before from"})
+		return time.Time{}, time.Time{}, false
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"to": "range must not exceed 366 days"})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to.AddDate(0, 0, 1), true
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/middleware"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/response"
+	"github.com/stemsi/exstem-backend/internal/service"
+	"github.com/stemsi/exstem-backend/internal/validator"
+)
+
+// NotificationHandler handles per-exam proctor notification settings.
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	log                 zerolog.Logger
+}
+
+// NewNotificationHandler creates a new NotificationHandler.
+func NewNotificationHandler(notificationService *service.NotificationService, log zerolog.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		log:                 log.With().Str("component", "notification_handler").Logger(),
+	}
+}
+
+// GetSettings godoc
+// GET /api/v1/admin/exams/:id/notifications
+// Returns who is notified about an exam and on which events.
+func (h *NotificationHandler) GetSettings(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	settings, err := h.notificationService.GetSettings(c.Request.Context(), examID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, settings)
+}
+
+// SetSettings godoc
+// PUT /api/v1/admin/exams/:id/notifications
+// Sets an exam's Telegram chats / WhatsApp numbers and the events they are told about.
+func (h *NotificationHandler) SetSettings(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.UpsertExamNotificationSettingsRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	settings, err := h.notificationService.SetSettings(c.Request.Context(), examID, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, settings)
+}
+
+// DeleteSettings godoc
+// DELETE /api/v1/admin/exams/:id/notifications
+// Turns off all notifications for an exam.
+func (h *NotificationHandler) DeleteSettings(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	if err := h.notificationService.DeleteSettings(c.Request.Context(), examID); err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "notifications disabled"})
+}
+
+// SendTest godoc
+// POST /api/v1/admin/exams/:id/notifications/test
+// Sends a test message to every recipient of an exam.
+func (h *NotificationHandler) SendTest(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	if err := h.notificationService.SendTest(c.Request.Context(), examID); err != nil {
+		h.log.Error().Err(err).Str("exam_id", examID.String()).Msg("Test notification failed")
+		c.Error(err).SetMeta(middleware.ErrorFallback{Status: http.StatusBadGateway, Code: response.ErrNotificationFailed})
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "test notification sent"})
+}
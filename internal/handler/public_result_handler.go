@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/response"
+	"github.com/stemsi/exstem-backend/internal/service"
+	"github.com/stemsi/exstem-backend/internal/validator"
+)
+
+// PublicResultHandler serves the public results lookup page.
+type PublicResultHandler struct {
+	examService    *service.ExamService
+	sessionService *service.ExamSessionService
+}
+
+// NewPublicResultHandler creates a new PublicResultHandler.
+func NewPublicResultHandler(examService *service.ExamService, sessionService *service.ExamSessionService) *PublicResultHandler {
+	return &PublicResultHandler{
+		examService:    examService,
+		sessionService: sessionService,
+	}
+}
+
+// ListExams godoc
+// GET /api/v1/public/results/exams
+// Lists the exams whose results can be looked up publicly.
+func (h *PublicResultHandler) ListExams(c *gin.Context) {
+	exams, err := h.examService.ListPublicResults(c.Request.Context())
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+	response.SuccessList(c, http.StatusOK, exams)
+}
+
+// Lookup godoc
+// POST /api/v1/public/results/exams/:id
+// Returns a student's score and grade given their NISN and the exam's access code.
+func (h *PublicResultHandler) Lookup(c *gin.Context) {
+	examID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.PublicResultLookupRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	result, err := h.sessionService.LookupPublicResult(c.Request.Context(), examID, req.NISN, req.AccessCode)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
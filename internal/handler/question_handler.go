@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -57,7 +59,7 @@ func (h *QuestionHandler) ListQBanks(c *gin.Context) {
 		return
 	}
 
-	response.SuccessWithPagination(c, http.StatusOK, qbanks, pagination)
+	response.SuccessPage(c, http.StatusOK, qbanks, pagination)
 }
 
 // GetQBanks godoc
@@ -107,7 +109,7 @@ func (h *QuestionHandler) CreateQBanks(c *gin.Context) {
 	}
 
 	if err := h.questionService.CreateQBanks(c.Request.Context(), qbank); err != nil {
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -145,7 +147,7 @@ func (h *QuestionHandler) UpdateQBanks(c *gin.Context) {
 	}
 
 	if err := h.questionService.UpdateQBanks(c.Request.Context(), qbank); err != nil {
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -163,7 +165,7 @@ func (h *QuestionHandler) DeleteQBanks(c *gin.Context) {
 	}
 
 	if err := h.questionService.DeleteQBanks(c.Request.Context(), qbankID); err != nil {
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -186,16 +188,13 @@ func (h *QuestionHandler) ListQuestions(c *gin.Context) {
 		return
 	}
 
-	if questions == nil {
-		questions = []model.Question{}
-	}
-
-	response.Success(c, http.StatusOK, questions)
+	response.SuccessList(c, http.StatusOK, questions)
 }
 
 // AddQuestion godoc
 // POST /api/v1/admin/qbanks/:qbank_id/questions
-// Adds a question to a qbank.
+// Adds a question to a qbank. A near-duplicate of an existing question is rejected
+// unless ?allow_duplicates=true, in which case it is added with a warning.
 func (h *QuestionHandler) AddQuestion(c *gin.Context) {
 	qbankID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -216,14 +215,24 @@ func (h *QuestionHandler) AddQuestion(c *gin.Context) {
 		Options:       req.Options,
 		CorrectOption: req.CorrectOption,
 		OrderNum:      req.OrderNum,
+		MaxPlays:      req.MaxPlays,
+		MathLatex:     req.MathLatex,
+		PassageID:     req.PassageID,
+		Explanation:   req.Explanation,
+		Translations:  req.Translations,
 	}
 
-	if err := h.questionService.Create(c.Request.Context(), question); err != nil {
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+	dups, err := h.questionService.Create(c.Request.Context(), question, c.Query("allow_duplicates") == "true")
+	if err != nil {
+		if errors.Is(err, service.ErrDuplicateQuestion) {
+			failDuplicateQuestions(c, dups)
+			return
+		}
+		c.Error(err)
 		return
 	}
 
-	response.Success(c, http.StatusCreated, question)
+	response.SuccessWithWarnings(c, http.StatusCreated, question, duplicateWarnings(dups))
 }
 
 // ReplaceQuestions godoc
@@ -251,13 +260,253 @@ func (h *QuestionHandler) ReplaceQuestions(c *gin.Context) {
 			Options:       q.Options,
 			CorrectOption: q.CorrectOption,
 			OrderNum:      q.OrderNum,
+			MaxPlays:      q.MaxPlays,
+			MathLatex:     q.MathLatex,
+			PassageID:     q.PassageID,
+			Explanation:   q.Explanation,
+			Translations:  q.Translations,
 		}
 	}
 
 	if err := h.questionService.ReplaceAll(c.Request.Context(), qbankID, questions); err != nil {
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
 	response.Success(c, http.StatusOK, gin.H{"message": "questions replaced successfully"})
 }
+
+// ReorderQuestions godoc
+// PUT /api/v1/admin/qbanks/:id/questions/order
+// Renumbers a qbank's questions in the order given. Every question must be listed once.
+func (h *QuestionHandler) ReorderQuestions(c *gin.Context) {
+	qbankID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.QuestionIDsRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	if err := h.questionService.Reorder(c.Request.Context(), qbankID, req.QuestionIDs); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "questions reordered successfully"})
+}
+
+// DeleteQuestions godoc
+// POST /api/v1/admin/qbanks/:id/questions/bulk-delete
+// Deletes the given questions of a qbank, all or none.
+func (h *QuestionHandler) DeleteQuestions(c *gin.Context) {
+	qbankID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.QuestionIDsRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	if err := h.questionService.DeleteMany(c.Request.Context(), qbankID, req.QuestionIDs); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "questions deleted successfully"})
+}
+
+// TransferQuestions godoc
+// POST /api/v1/admin/qbanks/:id/questions/transfer
+// Moves or copies the given questions to the end of another qbank, all or none.
+func (h *QuestionHandler) TransferQuestions(c *gin.Context) {
+	qbankID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.TransferQuestionsRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	if err := h.questionService.Transfer(c.Request.Context(), qbankID, req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "questions transferred successfully"})
+}
+
+// ListPassages godoc
+// GET /api/v1/admin/qbanks/:id/passages
+// Lists the reading passages of a qbank.
+func (h *QuestionHandler) ListPassages(c *gin.Context) {
+	qbankID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	passages, err := h.questionService.ListPassages(c.Request.Context(), qbankID)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.SuccessList(c, http.StatusOK, passages)
+}
+
+// CreatePassage godoc
+// POST /api/v1/admin/qbanks/:id/passages
+// Adds a reading passage that questions of the qbank can refer to.
+func (h *QuestionHandler) CreatePassage(c *gin.Context) {
+	qbankID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.PassageRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	passage := &model.Passage{QBankID: qbankID, Title: req.Title, Content: req.Content}
+	if err := h.questionService.CreatePassage(c.Request.Context(), passage); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, passage)
+}
+
+// UpdatePassage godoc
+// PUT /api/v1/admin/qbanks/:id/passages/:passage_id
+// Updates a reading passage.
+func (h *QuestionHandler) UpdatePassage(c *gin.Context) {
+	qbankID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+	passageID, err := uuid.Parse(c.Param("passage_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.PassageRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	passage := &model.Passage{ID: passageID, QBankID: qbankID, Title: req.Title, Content: req.Content}
+	if err := h.questionService.UpdatePassage(c.Request.Context(), passage); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, passage)
+}
+
+// DeletePassage godoc
+// DELETE /api/v1/admin/qbanks/:id/passages/:passage_id
+// Deletes a reading passage. Its questions stay in the qbank without a passage.
+func (h *QuestionHandler) DeletePassage(c *gin.Context) {
+	qbankID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+	passageID, err := uuid.Parse(c.Param("passage_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	if err := h.questionService.DeletePassage(c.Request.Context(), qbankID, passageID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "passage deleted successfully"})
+}
+
+// ImportQuestionsDoc godoc
+// POST /api/v1/admin/qbanks/:id/import-doc
+// Parses a .docx/.md/.txt question document. Returns a preview unless ?confirm=true,
+// in which case the parsed questions are appended to the qbank. Near-duplicates of
+// existing questions block the import unless ?allow_duplicates=true.
+func (h *QuestionHandler) ImportQuestionsDoc(c *gin.Context) {
+	qbankID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrFileRequired)
+		return
+	}
+	defer file.Close()
+
+	confirm := c.Query("confirm") == "true"
+	allowDuplicates := c.Query("allow_duplicates") == "true"
+
+	result, err := h.questionService.ImportDocument(c.Request.Context(), qbankID, header.Filename, file, confirm, allowDuplicates)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDuplicateQuestion):
+			failDuplicateQuestions(c, result.Duplicates)
+		case errors.Is(err, service.ErrImportHasIssues):
+			fields := make(map[string]string, len(result.Issues))
+			for _, issue := range result.Issues {
+				fields[fmt.Sprintf("question_%d_line_%d", issue.Question, issue.Line)] = issue.Message
+			}
+			if len(fields) == 0 {
+				fields["file"] = "document contains no questions"
+			}
+			response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		default:
+			c.Error(err)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if result.Imported {
+		status = http.StatusCreated
+	}
+	response.Success(c, status, result)
+}
+
+func duplicateWarnings(dups []model.QuestionDuplicate) interface{} {
+	if len(dups) == 0 {
+		return nil
+	}
+	return dups
+}
+
+// failDuplicateQuestions rejects new questions that resemble existing ones, naming the
+// existing question each one resembles.
+func failDuplicateQuestions(c *gin.Context, dups []model.QuestionDuplicate) {
+	fields := make(map[string]string, len(dups))
+	for _, d := range dups {
+		fields[fmt.Sprintf("question_%d", d.Question)] = fmt.Sprintf("similar to question %d (%.0f%% match)", d.ExistingOrderNum, d.Similarity*100)
+	}
+	response.FailWithFields(c, http.StatusConflict, response.ErrDuplicateQuestion, fields)
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stemsi/exstem-backend/internal/middleware"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+)
+
+// Response redaction: the exam and result serializers clear the fields the caller's
+// permissions do not cover, so read-only roles can use the same endpoints.
+
+// redactExams clears the entry token and results access code of exams unless the
+// caller can edit exams: the token lets students in and the code opens the public
+// results lookup, which read-only roles must not be able to do.
+func redactExams(c *gin.Context, exams ...*model.Exam) {
+	if middleware.HasPermission(c, string(model.PermissionExamsWrite)) {
+		return
+	}
+	for _, e := range exams {
+		e.EntryToken = ""
+		e.ResultsAccessCode = ""
+	}
+}
+
+// redactResults clears the student identifiers of result rows unless the caller can
+// manage students; teachers see results by name and class.
+func redactResults(c *gin.Context, results []repository.ExamResult) {
+	if middleware.HasPermission(c, string(model.PermissionStudentsWrite)) {
+		return
+	}
+	for i := range results {
+		results[i].NISN = ""
+	}
+}
+
+// redactAssessmentReport clears the student identifiers of an assessment report, as
+// redactResults does for an exam's results.
+func redactAssessmentReport(c *gin.Context, report *model.AssessmentReport) {
+	if middleware.HasPermission(c, string(model.PermissionStudentsWrite)) {
+		return
+	}
+	for i := range report.Students {
+		report.Students[i].NISN = ""
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/response"
+	"github.com/stemsi/exstem-backend/internal/service"
+	"github.com/stemsi/exstem-backend/internal/validator"
+)
+
+// RetentionHandler handles the data retention rules.
+type RetentionHandler struct {
+	retentionService *service.RetentionService
+}
+
+// NewRetentionHandler creates a new RetentionHandler.
+func NewRetentionHandler(retentionService *service.RetentionService) *RetentionHandler {
+	return &RetentionHandler{retentionService: retentionService}
+}
+
+// ListTargets godoc
+// GET /api/v1/admin/retention/targets
+// Returns the data retention rules can apply to and the actions each supports.
+func (h *RetentionHandler) ListTargets(c *gin.Context) {
+	response.SuccessList(c, http.StatusOK, h.retentionService.ListTargets())
+}
+
+// ListRules godoc
+// GET /api/v1/admin/retention/rules
+func (h *RetentionHandler) ListRules(c *gin.Context) {
+	rules, err := h.retentionService.ListRules(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.SuccessList(c, http.StatusOK, rules)
+}
+
+// CreateRule godoc
+// POST /api/v1/admin/retention/rules
+func (h *RetentionHandler) CreateRule(c *gin.Context) {
+	var req model.CreateRetentionRuleRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	rule, err := h.retentionService.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusCreated, rule)
+}
+
+// UpdateRule godoc
+// PUT /api/v1/admin/retention/rules/:id
+func (h *RetentionHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.UpdateRetentionRuleRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	rule, err := h.retentionService.UpdateRule(c.Request.Context(), id, req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, rule)
+}
+
+// DeleteRule godoc
+// DELETE /api/v1/admin/retention/rules/:id
+func (h *RetentionHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	if err := h.retentionService.DeleteRule(c.Request.Context(), id); err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, gin.H{"message": "retention rule deleted successfully"})
+}
+
+// GetReport godoc
+// GET /api/v1/admin/retention/report
+// Dry run: how many rows each retention rule would delete or anonymize if it ran now.
+func (h *RetentionHandler) GetReport(c *gin.Context) {
+	report, err := h.retentionService.Report(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, report)
+}
@@ -110,16 +110,7 @@ func (h *RoomAssignmentHandler) ExportPresenceXLSX(c *gin.Context) {
 
 	b, err := h.assignmentService.ExportPresenceXLSX(c.Request.Context(), sessionOk, sessionFilter, roomOk, roomFilter)
 	if err != nil {
-		if err.Error() == "no distribution data available to export" {
-			c.JSON(http.StatusBadRequest, response.Response{
-				Error: &response.ErrorBody{
-					Code:    response.ErrValidation,
-					Message: "No distribution data available to export",
-				},
-			})
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
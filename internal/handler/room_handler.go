@@ -1,12 +1,10 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stemsi/exstem-backend/internal/model"
 	"github.com/stemsi/exstem-backend/internal/response"
 	"github.com/stemsi/exstem-backend/internal/service"
@@ -31,7 +29,7 @@ func (h *RoomHandler) ListRooms(c *gin.Context) {
 		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
 		return
 	}
-	response.Success(c, http.StatusOK, gin.H{"rooms": rooms})
+	response.Success(c, http.StatusOK, gin.H{"rooms": response.NonNil(rooms)})
 }
 
 // CreateRoom godoc
@@ -49,12 +47,7 @@ func (h *RoomHandler) CreateRoom(c *gin.Context) {
 	}
 
 	if err := h.roomService.Create(c.Request.Context(), room); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique violation
-			response.Fail(c, http.StatusConflict, response.ErrConflict)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -83,12 +76,7 @@ func (h *RoomHandler) UpdateRoom(c *gin.Context) {
 	}
 
 	if err := h.roomService.Update(c.Request.Context(), room); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique violation
-			response.Fail(c, http.StatusConflict, response.ErrConflict)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -106,12 +94,7 @@ func (h *RoomHandler) DeleteRoom(c *gin.Context) {
 	}
 
 	if err := h.roomService.Delete(c.Request.Context(), id); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign key violation
-			response.Fail(c, http.StatusConflict, response.ErrDependencyExists)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -39,7 +39,7 @@ func (h *SettingHandler) UpdateSettings(c *gin.Context) {
 	}
 
 	if err := h.settingService.UpdateSettings(c.Request.Context(), req.Settings); err != nil {
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -56,3 +56,24 @@ func (h *SettingHandler) GetPublicSettings(c *gin.Context) {
 	}
 	response.Success(c, http.StatusOK, settings)
 }
+
+// GetRemoteConfig godoc
+// GET /api/v1/public/settings/remote
+// Returns the student app's remote config. The ETag is its version, so a client
+// revalidating with If-None-Match gets 304 until an admin changes it.
+func (h *SettingHandler) GetRemoteConfig(c *gin.Context) {
+	remote, err := h.settingService.RemoteConfig(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	etag := `"` + remote.Version + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=60")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	response.Success(c, http.StatusOK, remote)
+}
@@ -1,14 +1,16 @@
 package handler
 
 import (
-	"errors"
-	"log"
+	"bytes"
+	"encoding/csv"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/middleware"
 	"github.com/stemsi/exstem-backend/internal/model"
-	"github.com/stemsi/exstem-backend/internal/repository"
 	"github.com/stemsi/exstem-backend/internal/response"
 	"github.com/stemsi/exstem-backend/internal/service"
 	"github.com/stemsi/exstem-backend/internal/validator"
@@ -19,6 +21,10 @@ type StudentManagementHandler struct {
 	studentService *service.StudentService
 	authService    *service.AuthService
 	settingService *service.SettingService
+	auditService   *service.AuditService
+	directorySync  *service.DirectorySyncService
+	dapodikImport  *service.DapodikImportService
+	log            zerolog.Logger
 }
 
 // NewStudentManagementHandler creates a new StudentManagementHandler.
@@ -26,11 +32,19 @@ func NewStudentManagementHandler(
 	studentService *service.StudentService,
 	authService *service.AuthService,
 	settingService *service.SettingService,
+	auditService *service.AuditService,
+	directorySync *service.DirectorySyncService,
+	dapodikImport *service.DapodikImportService,
+	log zerolog.Logger,
 ) *StudentManagementHandler {
 	return &StudentManagementHandler{
 		studentService: studentService,
 		authService:    authService,
 		settingService: settingService,
+		auditService:   auditService,
+		directorySync:  directorySync,
+		dapodikImport:  dapodikImport,
+		log:            log.With().Str("component", "student_management_handler").Logger(),
 	}
 }
 
@@ -56,7 +70,8 @@ func (h *StudentManagementHandler) ListStudents(c *gin.Context) {
 		filter.Search = &search
 	}
 	if rel := c.Query("religion"); rel != "" {
-		filter.Religion = &rel
+		religion := model.Religion(rel)
+		filter.Religion = &religion
 	}
 	if gl := c.Query("grade_level"); gl != "" {
 		filter.GradeLevel = &gl
@@ -67,6 +82,9 @@ func (h *StudentManagementHandler) ListStudents(c *gin.Context) {
 	if gn := c.Query("group_number"); gn != "" {
 		filter.GroupNumber = &gn
 	}
+	if st := c.Query("status"); st != "" {
+		filter.Status = &st
+	}
 
 	students, pagination, err := h.studentService.ListStudents(c.Request.Context(), filter, page, perPage)
 	if err != nil {
@@ -74,7 +92,7 @@ func (h *StudentManagementHandler) ListStudents(c *gin.Context) {
 		return
 	}
 
-	response.SuccessWithPagination(c, http.StatusOK, gin.H{"students": students}, pagination)
+	response.SuccessWithPagination(c, http.StatusOK, gin.H{"students": response.NonNil(students)}, pagination)
 }
 
 // ResetStudentSession godoc
@@ -95,6 +113,182 @@ func (h *StudentManagementHandler) ResetStudentSession(c *gin.Context) {
 	response.Success(c, http.StatusOK, gin.H{"message": "student session reset successfully"})
 }
 
+// UpdateStudentStatus godoc
+// PUT /api/v1/admin/students/:id/status
+// Moves a student to another lifecycle status. Students who are no longer active are
+// logged out and can no longer log in or see exams; their results stay linked.
+func (h *StudentManagementHandler) UpdateStudentStatus(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	studentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.UpdateStudentStatusRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	if _, err := h.studentService.GetByID(c.Request.Context(), studentID); err != nil {
+		c.Error(err)
+		return
+	}
+	changed, err := h.studentService.UpdateStatus(c.Request.Context(), []int{studentID}, req.Status)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.afterStatusChange(c, claims.UserID, changed, req.Status, req.Reason)
+
+	student, err := h.studentService.GetByID(c.Request.Context(), studentID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, gin.H{"student": student})
+}
+
+// BulkUpdateStudentStatus godoc
+// POST /api/v1/admin/students/status
+// Moves many students, e.g. a graduating grade, to another lifecycle status at once.
+// Unknown IDs and students already in the status are left out of the changed list.
+func (h *StudentManagementHandler) BulkUpdateStudentStatus(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	var req model.BulkStudentStatusRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	changed, err := h.studentService.UpdateStatus(c.Request.Context(), req.StudentIDs, req.Status)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	h.afterStatusChange(c, claims.UserID, changed, req.Status, req.Reason)
+
+	response.Success(c, http.StatusOK, gin.H{"status": req.Status, "changed": changed})
+}
+
+// afterStatusChange logs out the students who stopped being active and records each
+// change in the audit log. The statuses are already changed, so failures here are
+// only logged.
+func (h *StudentManagementHandler) afterStatusChange(c *gin.Context, adminID int, changed []int, status model.StudentStatus, reason string) {
+	ctx := c.Request.Context()
+	for _, id := range changed {
+		if status != model.StudentStatusActive {
+			if err := h.authService.ResetStudentSession(ctx, id); err != nil {
+				h.log.Error().Err(err).Int("student_id", id).Msg("Failed to reset student session")
+			}
+		}
+		_ = h.auditService.Record(ctx, adminID, model.AuditActionStudentStatus, "student", strconv.Itoa(id), c.ClientIP(),
+			gin.H{"status": status, "reason": reason},
+		)
+	}
+}
+
+// ImpersonateStudent godoc
+// POST /api/v1/admin/students/:id/impersonate
+// Issues a short-lived, read-only student token so support staff can see the student's
+// lobby and exam view. Issuing the token and every request made with it are audited.
+func (h *StudentManagementHandler) ImpersonateStudent(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	studentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	student, err := h.studentService.GetByID(c.Request.Context(), studentID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	token, expiresAt, err := h.authService.GenerateImpersonationToken(c.Request.Context(), claims.UserID, claims.ID, student.ID, student.ClassID)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	// No token is handed out unless its issuance is on record.
+	if err := h.auditService.Record(c.Request.Context(), claims.UserID,
+		model.AuditActionImpersonationStart, "student", strconv.Itoa(student.ID), c.ClientIP(),
+		gin.H{"expires_at": expiresAt},
+	); err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, gin.H{
+		"token":         token,
+		"expires_at":    expiresAt,
+		"impersonation": true,
+		"student": gin.H{
+			"id":       student.ID,
+			"nisn":     student.NISN,
+			"name":     student.Name,
+			"class_id": student.ClassID,
+		},
+	})
+}
+
+// SyncDirectory godoc
+// POST /api/v1/admin/students/directory-sync
+// Pulls students from the configured school directory, creating, updating and
+// deactivating accounts to match it.
+func (h *StudentManagementHandler) SyncDirectory(c *gin.Context) {
+	result, err := h.directorySync.Sync(c.Request.Context())
+	if err != nil {
+		h.log.Error().Err(err).Msg("Directory sync failed")
+		c.Error(err).SetMeta(middleware.ErrorFallback{Status: http.StatusBadGateway, Code: response.ErrDirectorySyncFailed})
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// ImportDapodik godoc
+// POST /api/v1/admin/students/import-dapodik
+// Reconciles a Dapodik student export (.xlsx) against existing students. Returns the
+// diff report as a dry run unless ?confirm=true, in which case the changes are applied.
+func (h *StudentManagementHandler) ImportDapodik(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrFileRequired)
+		return
+	}
+	defer file.Close()
+
+	confirm := c.Query("confirm") == "true"
+
+	report, err := h.dapodikImport.Import(c.Request.Context(), header.Filename, file, confirm)
+	if err != nil {
+		h.log.Error().Err(err).Str("filename", header.Filename).Bool("confirm", confirm).Msg("Dapodik import failed")
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, report)
+}
+
 // CreateStudent godoc
 // POST /api/v1/admin/students
 // Creates a new student.
@@ -116,11 +310,7 @@ func (h *StudentManagementHandler) CreateStudent(c *gin.Context) {
 	}
 
 	if err := h.studentService.Create(c.Request.Context(), student); err != nil {
-		if errors.Is(err, repository.ErrDuplicateNISN) {
-			response.Fail(c, http.StatusConflict, response.ErrConflict)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -157,11 +347,7 @@ func (h *StudentManagementHandler) UpdateStudent(c *gin.Context) {
 	updatePassword := req.Password != ""
 
 	if err := h.studentService.Update(c.Request.Context(), student, updatePassword); err != nil {
-		if errors.Is(err, repository.ErrDuplicateNISN) {
-			response.Fail(c, http.StatusConflict, response.ErrConflict)
-			return
-		}
-		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		c.Error(err)
 		return
 	}
 
@@ -219,7 +405,7 @@ func (h *StudentManagementHandler) ListStudentCards(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, http.StatusOK, gin.H{"cards": cards})
+	response.Success(c, http.StatusOK, gin.H{"cards": response.NonNil(cards)})
 }
 
 // ExportStudentCardsPDF godoc
@@ -269,7 +455,7 @@ func (h *StudentManagementHandler) ExportStudentCardsPDF(c *gin.Context) {
 
 	pdfBytes, err := service.GenerateStudentCardsPDF(cards, school)
 	if err != nil {
-		log.Printf("[ERROR] GenerateStudentCardsPDF failed: %v", err)
+		h.log.Error().Err(err).Msg("Failed to generate student cards PDF")
 		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
 		return
 	}
@@ -278,3 +464,66 @@ func (h *StudentManagementHandler) ExportStudentCardsPDF(c *gin.Context) {
 	c.Header("Content-Disposition", `attachment; filename="kartu-siswa.pdf"`)
 	c.Data(http.StatusOK, "application/pdf", pdfBytes)
 }
+
+// IssueCredentials godoc
+// POST /api/v1/admin/students/credentials
+// Gives the students of a class fresh random passwords, which they must change at
+// their next login, and returns them once as a CSV (default) or a PDF of login cards.
+// The passwords are stored only as hashes and cannot be exported again.
+func (h *StudentManagementHandler) IssueCredentials(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	var req model.IssueCredentialsRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	ctx := c.Request.Context()
+	cards, err := h.studentService.IssueCredentials(ctx, req.ClassID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if len(cards) == 0 {
+		response.Fail(c, http.StatusNotFound, response.ErrNotFound)
+		return
+	}
+
+	// The passwords are already replaced, so a failed entry is only logged.
+	_ = h.auditService.Record(ctx, claims.UserID, model.AuditActionCredentialsIssue, "class", strconv.Itoa(req.ClassID), c.ClientIP(),
+		gin.H{"students": len(cards), "format": req.Format},
+	)
+
+	var body []byte
+	var contentType, filename string
+	if req.Format == "pdf" {
+		schoolName, _ := h.settingService.GetSettingByKey(ctx, "school_name")
+		schoolLogoURL, _ := h.settingService.GetSettingByKey(ctx, "school_logo_url")
+		body, err = service.GenerateStudentCardsPDF(cards, service.SchoolInfo{Name: schoolName, LogoURL: schoolLogoURL})
+		if err != nil {
+			h.log.Error().Err(err).Msg("Failed to generate student cards PDF")
+			response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+			return
+		}
+		contentType, filename = "application/pdf", "akun-siswa.pdf"
+	} else {
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		_ = w.Write([]string{"nisn", "nis", "name", "class", "password"})
+		for _, card := range cards {
+			_ = w.Write([]string{card.NISN, card.NIS, card.Name, card.ClassName, card.Password})
+		}
+		w.Flush()
+		body = buf.Bytes()
+		contentType, filename = "text/csv; charset=utf-8", "akun-siswa.csv"
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, contentType, body)
+}
@@ -1,14 +1,14 @@
 package handler
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
-	"github.com/stemsi/exstem-backend/internal/config"
 	"github.com/stemsi/exstem-backend/internal/middleware"
 	"github.com/stemsi/exstem-backend/internal/model"
 	"github.com/stemsi/exstem-backend/internal/response"
@@ -18,10 +18,12 @@ import (
 
 // StudentPortalHandler handles student-facing endpoints (exam taking, lobby).
 type StudentPortalHandler struct {
-	sessionService *service.ExamSessionService
-	examService    *service.ExamService
-	studentService *service.StudentService
-	rdb            *redis.Client
+	sessionService    *service.ExamSessionService
+	examService       *service.ExamService
+	studentService    *service.StudentService
+	assessmentService *service.AssessmentService
+	settingService    *service.SettingService
+	rdb               *redis.Client
 }
 
 // NewStudentPortalHandler creates a new StudentPortalHandler.
@@ -29,13 +31,17 @@ func NewStudentPortalHandler(
 	sessionService *service.ExamSessionService,
 	examService *service.ExamService,
 	studentService *service.StudentService,
+	assessmentService *service.AssessmentService,
+	settingService *service.SettingService,
 	rdb *redis.Client,
 ) *StudentPortalHandler {
 	return &StudentPortalHandler{
-		sessionService: sessionService,
-		examService:    examService,
-		studentService: studentService,
-		rdb:            rdb,
+		sessionService:    sessionService,
+		examService:       examService,
+		studentService:    studentService,
+		assessmentService: assessmentService,
+		settingService:    settingService,
+		rdb:               rdb,
 	}
 }
 
@@ -55,11 +61,96 @@ func (h *StudentPortalHandler) GetLobby(c *gin.Context) {
 		return
 	}
 
-	if lobby == nil {
-		lobby = []service.LobbyExam{}
+	response.SuccessList(c, http.StatusOK, lobby)
+}
+
+// LobbyStream godoc
+// GET /api/v1/student/lobby/stream
+// Streams the remote config to the lobby: once on connect, then each time an admin
+// changes it, so the app applies a new theme or announcement without reloading.
+func (h *StudentPortalHandler) LobbyStream(c *gin.Context) {
+	reqCtx := c.Request.Context()
+
+	remote, err := h.settingService.RemoteConfig(reqCtx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	updates, stop := h.settingService.WatchRemoteConfig()
+	defer stop()
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+
+	sendRemoteConfig := func(remote *model.RemoteConfig) {
+		c.SSEvent("message", map[string]interface{}{
+			"type":   "remote_config",
+			"config": remote,
+		})
+		c.Writer.Flush()
+	}
+	sendRemoteConfig(remote)
+
+	keepAliveTicker := time.NewTicker(keepAliveInterval)
+	defer keepAliveTicker.Stop()
+
+	for {
+		select {
+		case <-reqCtx.Done():
+			return
+		case remote := <-updates:
+			sendRemoteConfig(remote)
+		case <-keepAliveTicker.C:
+			c.SSEvent("message", map[string]interface{}{"type": "ping"})
+			c.Writer.Flush()
+		}
+	}
+}
+
+// GetLobbyAssessments godoc
+// GET /api/v1/student/lobby/assessments
+// Returns the assessments the student takes part in, each with all of the student's
+// live exams of it, as a grouped section of the lobby.
+func (h *StudentPortalHandler) GetLobbyAssessments(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
 	}
 
-	response.Success(c, http.StatusOK, lobby)
+	assessments, err := h.assessmentService.StudentLobby(c.Request.Context(), claims.UserID, claims.ClassID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.SuccessList(c, http.StatusOK, assessments)
+}
+
+// GetSchedule godoc
+// GET /api/v1/student/schedule
+// Returns the student's scheduled exams for the coming week, grouped by day.
+// ?days= widens or narrows the window (1–31, default 7).
+func (h *StudentPortalHandler) GetSchedule(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 1 || days > 31 {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, map[string]string{"days": "days must be between 1 and 31"})
+		return
+	}
+
+	schedule, err := h.sessionService.GetSchedule(c.Request.Context(), claims.UserID, claims.ClassID, days)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.SuccessList(c, http.StatusOK, schedule)
 }
 
 // GetActiveSession godoc
@@ -108,18 +199,14 @@ func (h *StudentPortalHandler) JoinExam(c *gin.Context) {
 		return
 	}
 
-	session, err := h.sessionService.JoinExam(c.Request.Context(), examID, claims.UserID, claims.ClassID, req.EntryToken)
+	if student, err := h.studentService.GetByID(c.Request.Context(), claims.UserID); err == nil && student.MustChangePassword {
+		c.Error(service.ErrPasswordChangeRequired)
+		return
+	}
+
+	session, err := h.sessionService.JoinExam(c.Request.Context(), examID, claims.UserID, claims.ClassID, req.EntryToken, req.AcknowledgeConsent)
 	if err != nil {
-		// Distinguish error types for specific codes.
-		errMsg := err.Error()
-		switch errMsg {
-		case "invalid entry token":
-			response.Fail(c, http.StatusBadRequest, response.ErrInvalidEntryToken)
-		case "exam is not available for joining":
-			response.Fail(c, http.StatusBadRequest, response.ErrExamNotAvailable)
-		default:
-			response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
-		}
+		c.Error(err)
 		return
 	}
 
@@ -139,8 +226,7 @@ func (h *StudentPortalHandler) JoinExam(c *gin.Context) {
 			"class_name":   className,
 			"message":      fmt.Sprintf("%s joined the exam", studentName),
 		}
-		data, _ := json.Marshal(event)
-		h.rdb.Publish(ctx, config.CacheKey.ExamMonitorChannel(examID.String()), data)
+		h.sessionService.PublishMonitorEvent(ctx, examID, event)
 	}()
 
 	response.Success(c, http.StatusOK, gin.H{"session": session})
@@ -148,7 +234,8 @@ func (h *StudentPortalHandler) JoinExam(c *gin.Context) {
 
 // GetExamPaper godoc
 // GET /api/v1/student/exams/:exam_id/paper
-// Returns the exam payload from Redis (bypasses PostgreSQL).
+// Returns the exam payload from Redis (bypasses PostgreSQL), in the language asked for
+// with ?lang= when the exam offers it.
 // SECURITY: Requires an active session for this exam — prevents IDOR.
 func (h *StudentPortalHandler) GetExamPaper(c *gin.Context) {
 	claims := middleware.GetClaims(c)
@@ -170,6 +257,12 @@ func (h *StudentPortalHandler) GetExamPaper(c *gin.Context) {
 		return
 	}
 
+	// The paper is locked while the student is on a break.
+	if onBreak, err := h.sessionService.OnBreak(c.Request.Context(), examID, claims.UserID); err == nil && onBreak {
+		response.Fail(c, http.StatusForbidden, response.ErrExamOnBreak)
+		return
+	}
+
 	payload, err := h.examService.GetExamPayload(c.Request.Context(), examID)
 	if err != nil {
 		response.Fail(c, http.StatusNotFound, response.ErrExamNotPublished)
@@ -198,6 +291,8 @@ func (h *StudentPortalHandler) GetExamPaper(c *gin.Context) {
 	}
 
 	payload.Questions = orderedQuestions
+	payload.Passages = model.PassagesFor(orderedQuestions, payload.Passages)
+	service.LocalizeExamPayload(payload, service.ServedLanguage(payload, c.Query("lang")))
 
 	response.Success(c, http.StatusOK, payload)
 }
@@ -233,3 +328,119 @@ func (h *StudentPortalHandler) GetExamState(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, state)
 }
+
+// GetExamHistory godoc
+// GET /api/v1/student/exams/history
+// Lists the student's exam sessions, with scores once results are available.
+func (h *StudentPortalHandler) GetExamHistory(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	history, err := h.sessionService.GetStudentHistory(c.Request.Context(), claims.UserID)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.SuccessList(c, http.StatusOK, history)
+}
+
+// GetExamResult godoc
+// GET /api/v1/student/exams/:exam_id/result
+// Returns the student's final score, answer counts, time taken and (optionally) class average.
+// Only available after the exam window closes.
+func (h *StudentPortalHandler) GetExamResult(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	examID, err := uuid.Parse(c.Param("exam_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	result, err := h.sessionService.GetStudentResult(c.Request.Context(), examID, claims.UserID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+// GetExamReview godoc
+// GET /api/v1/student/exams/:exam_id/review
+// Returns each question of the student's exam with their answer, the correct answer and
+// its explanation, in the language asked for with ?lang= when the exam offers it. Only
+// available once the result is, for exams that allow review.
+func (h *StudentPortalHandler) GetExamReview(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	examID, err := uuid.Parse(c.Param("exam_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	review, err := h.sessionService.GetStudentReview(c.Request.Context(), examID, claims.UserID, c.Query("lang"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, review)
+}
+
+// GetAccessibilitySettings godoc
+// GET /api/v1/student/settings/accessibility
+// Returns the student's accessibility preferences for the exam UI.
+func (h *StudentPortalHandler) GetAccessibilitySettings(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	settings, err := h.studentService.GetAccessibilitySettings(c.Request.Context(), claims.UserID)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, settings)
+}
+
+// UpdateAccessibilitySettings godoc
+// PUT /api/v1/student/settings/accessibility
+// Saves the student's font size, high contrast and screen-reader preferences.
+func (h *StudentPortalHandler) UpdateAccessibilitySettings(c *gin.Context) {
+	claims := middleware.GetClaims(c)
+	if claims == nil {
+		response.Fail(c, http.StatusUnauthorized, response.ErrTokenRequired)
+		return
+	}
+
+	var req model.UpdateAccessibilitySettingsRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	settings, err := h.studentService.UpdateAccessibilitySettings(c.Request.Context(), claims.UserID, req)
+	if err != nil {
+		response.Fail(c, http.StatusInternalServerError, response.ErrInternal)
+		return
+	}
+
+	response.Success(c, http.StatusOK, settings)
+}
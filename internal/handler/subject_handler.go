@@ -28,11 +28,7 @@ func (h *SubjectHandler) GetAll(c *gin.Context) {
 		return
 	}
 
-	if subjects == nil {
-		subjects = []model.Subject{}
-	}
-
-	response.Success(c, http.StatusOK, subjects)
+	response.SuccessList(c, http.StatusOK, subjects)
 }
 
 // Create godoc
@@ -90,3 +86,67 @@ func (h *SubjectHandler) Delete(c *gin.Context) {
 	}
 	response.Success(c, http.StatusOK, gin.H{"message": "subject deleted successfully"})
 }
+
+// ListTeachers godoc
+// GET /api/v1/admin/subjects/:id/teachers
+// Lists the teachers assigned to a subject.
+func (h *SubjectHandler) ListTeachers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	teachers, err := h.subjectService.ListTeachers(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.SuccessList(c, http.StatusOK, teachers)
+}
+
+// AssignTeachers godoc
+// POST /api/v1/admin/subjects/:id/teachers
+// Assigns admins to teach a subject and returns its teachers.
+func (h *SubjectHandler) AssignTeachers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	var req model.AssignSubjectTeachersRequest
+	if fields := validator.Bind(c, &req); fields != nil {
+		response.FailWithFields(c, http.StatusBadRequest, response.ErrValidation, fields)
+		return
+	}
+
+	teachers, err := h.subjectService.AssignTeachers(c.Request.Context(), id, req.AdminIDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.SuccessList(c, http.StatusOK, teachers)
+}
+
+// UnassignTeacher godoc
+// DELETE /api/v1/admin/subjects/:id/teachers/:admin_id
+// Removes an admin from a subject's teachers.
+func (h *SubjectHandler) UnassignTeacher(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+	adminID, err := strconv.Atoi(c.Param("admin_id"))
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, response.ErrInvalidID)
+		return
+	}
+
+	if err := h.subjectService.UnassignTeacher(c.Request.Context(), id, adminID); err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, gin.H{"message": "teacher unassigned successfully"})
+}
@@ -9,39 +9,56 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/metrics"
 	"github.com/stemsi/exstem-backend/internal/middleware"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/queue"
+	"github.com/stemsi/exstem-backend/internal/resilience"
 	"github.com/stemsi/exstem-backend/internal/response"
+	"github.com/stemsi/exstem-backend/internal/service"
 )
 
 const metricsInterval = 7 * time.Second
 
-// SystemHandler streams OS and Go runtime metrics via SSE.
+// SystemHandler streams OS and Go runtime metrics via SSE and serves their history.
 type SystemHandler struct {
-	rdb       *redis.Client
-	startTime time.Time
-	cpuModel  string
-	log       zerolog.Logger
-
-	// CPU delta state
+	pool           *pgxpool.Pool
+	rdb            *redis.Client
+	queue          queue.Queue
+	health         *resilience.HealthMonitor
+	metricsService *service.SystemMetricsService
+	startTime      time.Time
+	cpuModel       string
+	log            zerolog.Logger
+
+	// CPU delta state, shared by the SSE streams and the history sampler
+	cpuMu     sync.Mutex
 	prevIdle  uint64
 	prevTotal uint64
 }
 
-func NewSystemHandler(rdb *redis.Client, log zerolog.Logger) *SystemHandler {
+func NewSystemHandler(pool *pgxpool.Pool, rdb *redis.Client, q queue.Queue, health *resilience.HealthMonitor, metricsService *service.SystemMetricsService, log zerolog.Logger) *SystemHandler {
 	h := &SystemHandler{
-		rdb:       rdb,
-		startTime: time.Now(),
-		cpuModel:  readCPUModel(),
-		log:       log.With().Str("component", "system_handler").Logger(),
+		pool:           pool,
+		rdb:            rdb,
+		queue:          q,
+		health:         health,
+		metricsService: metricsService,
+		startTime:      time.Now(),
+		cpuModel:       readCPUModel(),
+		log:            log.With().Str("component", "system_handler").Logger(),
 	}
 	// Seed initial CPU reading so the first tick gets a real delta
 	h.prevIdle, h.prevTotal, _ = readCPUStat()
@@ -82,6 +99,27 @@ type systemMetrics struct {
 	QueueCheats        int64 `json:"queue_cheats"`
 	QueueScores        int64 `json:"queue_scores"`
 	QueueQuestionOrder int64 `json:"queue_question_order"`
+	QueueExamStats     int64 `json:"queue_exam_stats"`
+	QueueMonitorEvents int64 `json:"queue_monitor_events"`
+
+	// PostgreSQL pool and queries
+	DB database.PoolStats `json:"db"`
+
+	// Autosave latency: socket receive to Redis ack, and save to PostgreSQL flush
+	AutosaveAck         metrics.Percentiles `json:"autosave_ack"`
+	AutosaveFlush       metrics.Percentiles `json:"autosave_flush"`
+	AutosaveSLOBreached bool                `json:"autosave_slo_breached"`
+
+	// Handler panics recovered since startup
+	Panics int64 `json:"panics"`
+
+	// Failed logins and login anomalies since startup
+	LoginFailures  int64 `json:"login_failures"`
+	LoginAnomalies int64 `json:"login_anomalies"`
+
+	// Redis degradation (autosave/submit writing directly to PostgreSQL)
+	RedisDegraded      bool       `json:"redis_degraded"`
+	RedisDegradedSince *time.Time `json:"redis_degraded_since,omitempty"`
 }
 
 // SystemMetricsSSE godoc
@@ -141,6 +179,7 @@ func (h *SystemHandler) collect() systemMetrics {
 	}
 
 	// ── CPU ──
+	h.cpuMu.Lock()
 	idle, total, err := readCPUStat()
 	if err == nil && total > h.prevTotal {
 		idleDelta := float64(idle - h.prevIdle)
@@ -149,6 +188,7 @@ func (h *SystemHandler) collect() systemMetrics {
 		h.prevIdle = idle
 		h.prevTotal = total
 	}
+	h.cpuMu.Unlock()
 
 	// ── Memory ──
 	memTotal, memAvail, err := readMemInfo()
@@ -181,23 +221,146 @@ func (h *SystemHandler) collect() systemMetrics {
 	// ── App RSS ──
 	m.AppRSSBytes, _ = readProcessRSS()
 
-	// ── Worker Queues (pipelined LLEN) ──
-	ctx := context.Background()
-	pipe := h.rdb.Pipeline()
-	answersCmd := pipe.LLen(ctx, config.WorkerKey.PersistAnswersQueue)
-	cheatsCmd := pipe.LLen(ctx, config.WorkerKey.PersistCheatsQueue)
-	scoresCmd := pipe.LLen(ctx, config.WorkerKey.PersistScoresQueue)
-	orderCmd := pipe.LLen(ctx, config.WorkerKey.PersistQuestionOrderQueue)
-	if _, err := pipe.Exec(ctx); err == nil {
-		m.QueueAnswers, _ = answersCmd.Result()
-		m.QueueCheats, _ = cheatsCmd.Result()
-		m.QueueScores, _ = scoresCmd.Result()
-		m.QueueQuestionOrder, _ = orderCmd.Result()
+	// ── Database ──
+	m.DB = database.Stats(h.pool)
+
+	// ── Autosave Latency ──
+	m.AutosaveAck = metrics.AutosaveAck.Percentiles()
+	m.AutosaveFlush = metrics.AutosaveFlush.Percentiles()
+	m.AutosaveSLOBreached = metrics.AutosaveSLOBreached()
+
+	// ── Panics ──
+	m.Panics = middleware.PanicCount()
+
+	// ── Logins ──
+	for _, l := range metrics.Logins.Attempts() {
+		if !l.Success {
+			m.LoginFailures += l.Count
+		}
+	}
+	m.LoginAnomalies = metrics.Logins.Anomalies()
+
+	// ── Redis Degradation ──
+	m.RedisDegraded = h.health.Degraded()
+	m.RedisDegradedSince = h.health.DegradedSince()
+	if m.RedisDegraded {
+		return m // Queues live in Redis
 	}
 
+	// ── Worker Queues ──
+	ctx := context.Background()
+	m.QueueAnswers, _ = h.queue.Len(ctx, config.WorkerKey.PersistAnswersQueue)
+	m.QueueCheats, _ = h.queue.Len(ctx, config.WorkerKey.PersistCheatsQueue)
+	m.QueueScores, _ = h.queue.Len(ctx, config.WorkerKey.PersistScoresQueue)
+	m.QueueQuestionOrder, _ = h.queue.Len(ctx, config.WorkerKey.PersistQuestionOrderQueue)
+	m.QueueExamStats, _ = h.queue.Len(ctx, config.WorkerKey.RefreshExamStatsQueue)
+	m.QueueMonitorEvents, _ = h.queue.Len(ctx, config.WorkerKey.RecordMonitorEventsQueue)
+
 	return m
 }
 
+// Sample collects the metrics kept in the metrics history. The caller fills in the
+// instance, the time and the exam socket count.
+func (h *SystemHandler) Sample() model.MetricsSample {
+	m := h.collect()
+	return model.MetricsSample{
+		CPUPercent:         m.CPUPercent,
+		MemPercent:         m.MemPercent,
+		MemUsedBytes:       int64(m.MemUsedBytes),
+		DiskPercent:        m.DiskPercent,
+		LoadAvg1:           m.LoadAvg1,
+		Goroutines:         m.Goroutines,
+		HeapAlloc:          int64(m.HeapAlloc),
+		DBAcquiredConns:    int(m.DB.AcquiredConns),
+		QueueAnswers:       m.QueueAnswers,
+		QueueCheats:        m.QueueCheats,
+		QueueScores:        m.QueueScores,
+		QueueMonitorEvents: m.QueueMonitorEvents,
+		RedisDegraded:      m.RedisDegraded,
+	}
+}
+
+// GetMetricsHistory godoc
+// GET /api/v1/admin/system/metrics/history?range=1h|6h|24h|7d|30d
+// Returns the sampled metrics of every instance over the range (default 24h),
+// downsampled to a step fitting the range.
+func (h *SystemHandler) GetMetricsHistory(c *gin.Context) {
+	history, err := h.metricsService.History(c.Request.Context(), c.Query("range"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	response.Success(c, http.StatusOK, history)
+}
+
+// ---------- Prometheus Endpoint ----------
+
+// PrometheusMetrics godoc
+// GET /metrics
+func (h *SystemHandler) PrometheusMetrics(c *gin.Context) {
+	db := database.Stats(h.pool)
+
+	var b strings.Builder
+	writeMetric := func(name, kind, help string, value any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+	}
+	writeMetric("exstem_db_pool_max_conns", "gauge", "Maximum size of the PostgreSQL pool.", db.MaxConns)
+	writeMetric("exstem_db_pool_total_conns", "gauge", "Open PostgreSQL connections.", db.TotalConns)
+	writeMetric("exstem_db_pool_acquired_conns", "gauge", "PostgreSQL connections in use.", db.AcquiredConns)
+	writeMetric("exstem_db_pool_idle_conns", "gauge", "Idle PostgreSQL connections.", db.IdleConns)
+	writeMetric("exstem_db_pool_acquires_total", "counter", "Connections acquired from the pool.", db.AcquireCount)
+	writeMetric("exstem_db_pool_empty_acquires_total", "counter", "Acquires that waited for a free connection.", db.EmptyAcquireCount)
+	writeMetric("exstem_db_pool_acquire_wait_seconds_total", "counter", "Time spent waiting for a free connection.", db.AcquireWait.Seconds())
+	writeMetric("exstem_db_pool_canceled_acquires_total", "counter", "Acquires canceled before getting a connection.", db.CanceledAcquires)
+	writeMetric("exstem_db_queries_total", "counter", "Queries run on the pool.", db.Queries)
+	writeMetric("exstem_db_query_errors_total", "counter", "Queries that failed.", db.QueryErrors)
+	writeMetric("exstem_db_query_seconds_total", "counter", "Time spent running queries.", db.QueryDuration.Seconds())
+	ops := database.Operations()
+	b.WriteString("# HELP exstem_db_query_retries_total Statements retried after a transient failure, by repository operation.\n# TYPE exstem_db_query_retries_total counter\n")
+	for _, op := range ops {
+		fmt.Fprintf(&b, "exstem_db_query_retries_total{operation=%q} %d\n", op.Operation, op.Retries)
+	}
+	b.WriteString("# HELP exstem_db_query_timeouts_total Queries that failed at their deadline, by repository operation.\n# TYPE exstem_db_query_timeouts_total counter\n")
+	for _, op := range ops {
+		fmt.Fprintf(&b, "exstem_db_query_timeouts_total{operation=%q} %d\n", op.Operation, op.Timeouts)
+	}
+	b.WriteString("# HELP exstem_db_call_duration_seconds Duration of the repositories' calls, retries included, by operation.\n# TYPE exstem_db_call_duration_seconds histogram\n")
+	for _, op := range ops {
+		for i, bound := range database.CallBuckets {
+			fmt.Fprintf(&b, "exstem_db_call_duration_seconds_bucket{operation=%q,le=\"%v\"} %d\n", op.Operation, bound.Seconds(), op.Buckets[i])
+		}
+		fmt.Fprintf(&b, "exstem_db_call_duration_seconds_bucket{operation=%q,le=\"+Inf\"} %d\n", op.Operation, op.Calls)
+		fmt.Fprintf(&b, "exstem_db_call_duration_seconds_sum{operation=%q} %v\n", op.Operation, op.Duration.Seconds())
+		fmt.Fprintf(&b, "exstem_db_call_duration_seconds_count{operation=%q} %d\n", op.Operation, op.Calls)
+	}
+	writeSummary := func(name, help string, p metrics.Percentiles) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s summary\n", name, help, name)
+		fmt.Fprintf(&b, "%s{quantile=\"0.5\"} %v\n", name, p.P50.Seconds())
+		fmt.Fprintf(&b, "%s{quantile=\"0.95\"} %v\n", name, p.P95.Seconds())
+		fmt.Fprintf(&b, "%s{quantile=\"0.99\"} %v\n", name, p.P99.Seconds())
+	}
+	writeSummary("exstem_autosave_ack_seconds", "Autosave latency from socket receive to Redis ack (sampled).", metrics.AutosaveAck.Percentiles())
+	writeSummary("exstem_autosave_flush_seconds", "Autosave lag from save to PostgreSQL flush (sampled).", metrics.AutosaveFlush.Percentiles())
+	sloBreached := 0
+	if metrics.AutosaveSLOBreached() {
+		sloBreached = 1
+	}
+	writeMetric("exstem_autosave_slo_breached", "gauge", "Whether the autosave flush lag p95 is above its SLO.", sloBreached)
+	writeMetric("exstem_panics_total", "counter", "Handler panics recovered.", middleware.PanicCount())
+	b.WriteString("# HELP exstem_login_attempts_total Login attempts by account kind and result.\n# TYPE exstem_login_attempts_total counter\n")
+	for _, l := range metrics.Logins.Attempts() {
+		result := "failure"
+		if l.Success {
+			result = "success"
+		}
+		fmt.Fprintf(&b, "exstem_login_attempts_total{kind=%q,result=%q} %d\n", l.Kind, result, l.Count)
+	}
+	writeMetric("exstem_login_anomalies_total", "counter", "Login anomalies raised, such as one IP failing logins to many accounts.", metrics.Logins.Anomalies())
+	writeMetric("exstem_goroutines", "gauge", "Running goroutines.", runtime.NumGoroutine())
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
+
 // ---------- /proc Readers ----------
 
 // readCPUStat parses /proc/stat for aggregate CPU times.
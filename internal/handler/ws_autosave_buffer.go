@@ -0,0 +1,55 @@
+package handler
+
+import "errors"
+
+// maxBufferedAutosaves bounds how many answers a single connection holds in memory
+// while Redis is unavailable. Entries are keyed by question, so this is a question count.
+const maxBufferedAutosaves = 200
+
+// errAutosaveBacklog marks an answer that was buffered behind older unflushed answers.
+var errAutosaveBacklog = errors.New("earlier autosaves are still buffered")
+
+// autosaveBuffer keeps answers that could not be written to Redis, in arrival order,
+// until they can be flushed. Only the latest answer per question is kept.
+// It is owned by a single connection's read loop and is not safe for concurrent use.
+type autosaveBuffer struct {
+	order   []string
+	answers map[string]string
+}
+
+func newAutosaveBuffer() *autosaveBuffer {
+	return &autosaveBuffer{answers: make(map[string]string)}
+}
+
+// put stores an answer, replacing any buffered answer for the same question.
+// It returns false when the buffer is full.
+func (b *autosaveBuffer) put(qid, answer string) bool {
+	if _, exists := b.answers[qid]; exists {
+		b.answers[qid] = answer
+		return true
+	}
+	if len(b.order) >= maxBufferedAutosaves {
+		return false
+	}
+	b.order = append(b.order, qid)
+	b.answers[qid] = answer
+	return true
+}
+
+// flush writes buffered answers oldest first through save, stopping at the first error.
+// Answers written successfully are removed from the buffer.
+func (b *autosaveBuffer) flush(save func(qid, answer string) error) error {
+	for len(b.order) > 0 {
+		qid := b.order[0]
+		if err := save(qid, b.answers[qid]); err != nil {
+			return err
+		}
+		delete(b.answers, qid)
+		b.order = b.order[1:]
+	}
+	return nil
+}
+
+func (b *autosaveBuffer) len() int {
+	return len(b.order)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/queue"
+)
+
+const (
+	// wsQueueLoadInterval is how often the answers queue length is re-read.
+	wsQueueLoadInterval = 5 * time.Second
+	// wsCoalesceWindow is how long a connection holds coalesced persistence jobs.
+	wsCoalesceWindow = 10 * time.Second
+	// wsBackpressureDebounce is the autosave debounce clients are asked to use while
+	// the answers queue is overloaded.
+	wsBackpressureDebounce = 5 * time.Second
+)
+
+// queueLoad reports whether a worker queue is longer than a threshold, re-reading its
+// length at most once per wsQueueLoadInterval. It is shared by all connections.
+type queueLoad struct {
+	queue     queue.Queue
+	name      string
+	threshold int64
+	log       zerolog.Logger
+
+	mu         sync.Mutex
+	checked    time.Time
+	overloaded bool
+}
+
+// Overloaded reports whether the queue was past the threshold at the last check. A zero
+// threshold disables the check; a failed read keeps the previous answer.
+func (l *queueLoad) Overloaded(ctx context.Context) bool {
+	if l.threshold <= 0 {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.checked) < wsQueueLoadInterval {
+		return l.overloaded
+	}
+	l.checked = time.Now()
+
+	n, err := l.queue.Len(ctx, l.name)
+	if err != nil {
+		return l.overloaded
+	}
+	if overloaded := n > l.threshold; overloaded != l.overloaded {
+		l.overloaded = overloaded
+		if overloaded {
+			l.log.Warn().Str("queue", l.name).Int64("length", n).Int64("threshold", l.threshold).
+				Msg("Answers queue overloaded, coalescing autosaves")
+		} else {
+			l.log.Info().Str("queue", l.name).Int64("length", n).Msg("Answers queue recovered")
+		}
+	}
+	return l.overloaded
+}
+
+// autosaveCoalescer holds the persistence jobs of answers already written to Redis
+// while the answers queue is overloaded, keeping only the latest job per question,
+// and tracks whether the client was asked to back off.
+// It is owned by a single connection's read loop and is not safe for concurrent use.
+type autosaveCoalescer struct {
+	jobs  map[string][]byte
+	since time.Time // when the oldest held job was added
+	// active is whether the client was last told to back off.
+	active bool
+}
+
+func newAutosaveCoalescer() *autosaveCoalescer {
+	return &autosaveCoalescer{jobs: make(map[string][]byte)}
+}
+
+// put holds a question's persistence job, replacing any held job for it.
+func (c *autosaveCoalescer) put(qid string, job []byte) {
+	if len(c.jobs) == 0 {
+		c.since = time.Now()
+	}
+	c.jobs[qid] = job
+}
+
+// due reports whether the held jobs should be queued: once they are wsCoalesceWindow
+// old, or as soon as the backpressure is over.
+func (c *autosaveCoalescer) due() bool {
+	return len(c.jobs) > 0 && (!c.active || time.Since(c.since) >= wsCoalesceWindow)
+}
+
+// flush queues the held jobs through push, keeping them if it fails.
+func (c *autosaveCoalescer) flush(push func(jobs ...[]byte) error) error {
+	if len(c.jobs) == 0 {
+		return nil
+	}
+	jobs := make([][]byte, 0, len(c.jobs))
+	for _, job := range c.jobs {
+		jobs = append(jobs, job)
+	}
+	if err := push(jobs...); err != nil {
+		return err
+	}
+	clear(c.jobs)
+	return nil
+}
+
+func (c *autosaveCoalescer) len() int {
+	return len(c.jobs)
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/metrics"
+	ws "github.com/stemsi/exstem-backend/internal/websocket"
+)
+
+// maxBatchAutosave bounds the answers of one batch_autosave frame.
+const maxBatchAutosave = maxBufferedAutosaves
+
+// handleBatchAutosave saves several answers sent in one frame, typically the changes a
+// client queued while disconnected. Each answer is checked like a single autosave and
+// refused ones are reported back; the rest are written to Redis in one transaction,
+// or buffered together when Redis is unavailable.
+func (h *WSHandler) handleBatchAutosave(ctx context.Context, conn *websocket.Conn, wsLog zerolog.Logger, pending *autosaveBuffer, coalesce *autosaveCoalescer, scope *questionScope, answersKey string, studentID int, studentName string, examID uuid.UUID, msg *ws.BatchAutosaveRequest) {
+	received := time.Now()
+
+	if len(msg.Answers) == 0 {
+		ws.WriteError(conn, "answers is required")
+		return
+	}
+	if len(msg.Answers) > maxBatchAutosave {
+		ws.WriteError(conn, fmt.Sprintf("at most %d answers per batch", maxBatchAutosave))
+		return
+	}
+
+	if err := h.loadQuestionScope(ctx, scope, examID, studentID); err != nil {
+		wsLog.Warn().Err(err).Msg("Question scope not loaded, accepting batch autosave unchecked")
+	}
+	if h.onBreak(ctx, wsLog, scope, studentID, examID) {
+		ws.WriteAnswerError(conn, ws.ErrCodeOnBreak, "", "paper is locked during a break")
+		return
+	}
+
+	// Check every answer in order, keeping the latest one per question.
+	refused := make([]ws.ErrorResponse, 0)
+	refuse := func(code ws.ErrorCode, qid, errMsg string) {
+		refused = append(refused, ws.ErrorResponse{Event: ws.EventError, Code: code, QID: qid, Error: errMsg})
+	}
+	index := make(map[string]int, len(msg.Answers))
+	answers := make([]ws.AutosaveItem, 0, len(msg.Answers))
+	for _, a := range msg.Answers {
+		if _, err := uuid.Parse(a.QID); err != nil {
+			refuse("", a.QID, "invalid q_id format")
+			continue
+		}
+		if !scope.allows(a.QID) {
+			h.flagOutOfScopeAnswer(ctx, wsLog, studentID, studentName, examID, a.QID)
+			refuse(ws.ErrCodeQuestionOutOfScope, a.QID, "q_id is not part of this exam")
+			continue
+		}
+		if err := scope.validate(a.QID, a.Answer); err != nil {
+			refuse(ws.ErrCodeInvalidAnswer, a.QID, err.Error())
+			continue
+		}
+		if _, ok := h.moveToQuestion(ctx, wsLog, scope, studentID, examID, a.QID); !ok {
+			refuse(ws.ErrCodeQuestionLocked, a.QID, "question is locked")
+			continue
+		}
+		if i, ok := index[a.QID]; ok {
+			answers[i] = a
+			continue
+		}
+		index[a.QID] = len(answers)
+		answers = append(answers, a)
+	}
+
+	if len(answers) == 0 {
+		ws.WriteTyped(conn, ws.BatchAutosaveResponse{Event: ws.EventError, Status: "refused", Errors: refused})
+		return
+	}
+
+	// Older buffered answers must land first, otherwise a late flush would
+	// overwrite these newer answers.
+	if pending.len() > 0 {
+		h.flushPending(ctx, wsLog, pending, answersKey, studentID, examID)
+	}
+
+	h.signalBackpressure(conn, coalesce, h.answersLoad.Overloaded(ctx))
+
+	var err error
+	switch {
+	case pending.len() > 0:
+		err = errAutosaveBacklog
+	case coalesce.active && !h.sessionService.RedisDegraded():
+		err = h.saveAnswersCoalesced(ctx, coalesce, answersKey, studentID, examID, answers)
+	default:
+		err = h.persistAnswers(ctx, answersKey, studentID, examID, answers)
+	}
+	if coalesce.due() {
+		h.flushCoalesced(wsLog, coalesce)
+	}
+	if err != nil {
+		for _, a := range answers {
+			if !pending.put(a.QID, a.Answer) {
+				wsLog.Error().Err(err).Msg("Batch autosave Redis error, buffer full")
+				ws.WriteError(conn, "save failed")
+				return
+			}
+		}
+		wsLog.Warn().Err(err).Int("buffered", pending.len()).Msg("Batch autosave buffered while Redis is unavailable")
+		ws.WriteTyped(conn, ws.BatchAutosaveResponse{
+			Event:  ws.EventSuccess,
+			Status: "buffered",
+			Saved:  len(answers),
+			Errors: refused,
+		})
+		return
+	}
+
+	if !h.sessionService.RedisDegraded() {
+		metrics.AutosaveAck.Observe(time.Since(received))
+	}
+
+	h.publishMonitorEvent(examID, map[string]interface{}{
+		"type":         "autosave",
+		"student_id":   studentID,
+		"student_name": studentName,
+		"message":      fmt.Sprintf("%s updated %d answers", studentName, len(answers)),
+	})
+
+	ws.WriteTyped(conn, ws.BatchAutosaveResponse{
+		Event:  ws.EventSuccess,
+		Status: "saved",
+		Saved:  len(answers),
+		Errors: refused,
+	})
+}
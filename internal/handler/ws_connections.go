@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+)
+
+// Duplicate connection policies: replace closes a student's older connection to the
+// exam when a new one opens, reject refuses the new one.
+const (
+	WSDuplicateReplace = "replace"
+	WSDuplicateReject  = "reject"
+)
+
+const (
+	// wsConnTTL is how long a connection's claim on its session outlives its last heartbeat.
+	wsConnTTL = 60 * time.Second
+	// wsConnHeartbeat is how often a connection renews its claim and checks it still holds it.
+	wsConnHeartbeat = 20 * time.Second
+	// wsCloseReplaced is the close code sent to a connection superseded by a newer one.
+	wsCloseReplaced = 4001
+)
+
+// errConnectionActive is returned when the reject policy refuses a second connection.
+var errConnectionActive = errors.New("another connection to this exam is open")
+
+// renewWSConn extends the claim while it is held by this connection and takes it back
+// if it was lost (e.g. Redis restarted). It returns 0 once another connection holds it.
+var renewWSConn = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if holder == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if not holder then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// releaseWSConn deletes the claim only while it is still held by this connection.
+var releaseWSConn = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// wsConnections tracks this instance's exam sockets: how many are open, and which
+// connection ID belongs to which socket so a superseded one can be closed at once.
+// Connections on other instances notice they were superseded at their next heartbeat.
+type wsConnections struct {
+	rdb    *redis.Client
+	limit  int64
+	policy string
+
+	active atomic.Int64
+	mu     sync.Mutex
+	conns  map[string]*websocket.Conn
+}
+
+func newWSConnections(rdb *redis.Client, limit int64, policy string) *wsConnections {
+	if policy != WSDuplicateReject {
+		policy = WSDuplicateReplace
+	}
+	return &wsConnections{rdb: rdb, limit: limit, policy: policy, conns: make(map[string]*websocket.Conn)}
+}
+
+// acquire reserves a connection slot, reporting false when the instance is full.
+// A zero limit admits every connection.
+func (w *wsConnections) acquire() bool {
+	if n := w.active.Add(1); w.limit > 0 && n > w.limit {
+		w.active.Add(-1)
+		return false
+	}
+	return true
+}
+
+func (w *wsConnections) release() {
+	w.active.Add(-1)
+}
+
+// claim makes conn the student's connection to the exam and returns its ID. Under the
+// replace policy an older connection is closed; under the reject policy
+// errConnectionActive is returned while one is open. Redis errors are returned too;
+// callers let the connection through unclaimed then.
+func (w *wsConnections) claim(ctx context.Context, conn *websocket.Conn, examID uuid.UUID, studentID int) (string, error) {
+	key := config.CacheKey.StudentWSConnKey(examID.String(), studentID)
+	connID := uuid.NewString()
+
+	if w.policy == WSDuplicateReject {
+		ok, err := w.rdb.SetNX(ctx, key, connID, wsConnTTL).Result()
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", errConnectionActive
+		}
+	} else {
+		old, err := w.rdb.SetArgs(ctx, key, connID, redis.SetArgs{TTL: wsConnTTL, Get: true}).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return "", err
+		}
+		if old != "" {
+			w.kick(old)
+		}
+	}
+
+	w.mu.Lock()
+	w.conns[connID] = conn
+	w.mu.Unlock()
+	return connID, nil
+}
+
+// heartbeat renews the claim and reports whether connID still holds it. It is false
+// once a newer connection replaced this one; Redis errors keep the connection open.
+func (w *wsConnections) heartbeat(ctx context.Context, examID uuid.UUID, studentID int, connID string) bool {
+	key := config.CacheKey.StudentWSConnKey(examID.String(), studentID)
+	held, err := renewWSConn.Run(ctx, w.rdb, []string{key}, connID, wsConnTTL.Milliseconds()).Int()
+	return err != nil || held == 1
+}
+
+// unclaim gives up the claim when the connection closes.
+func (w *wsConnections) unclaim(ctx context.Context, examID uuid.UUID, studentID int, connID string) {
+	w.mu.Lock()
+	delete(w.conns, connID)
+	w.mu.Unlock()
+
+	key := config.CacheKey.StudentWSConnKey(examID.String(), studentID)
+	releaseWSConn.Run(ctx, w.rdb, []string{key}, connID)
+}
+
+// watch renews the claim every wsConnHeartbeat until ctx ends, closing the connection
+// once a newer one replaced it.
+func (w *wsConnections) watch(ctx context.Context, wsLog zerolog.Logger, conn *websocket.Conn, examID uuid.UUID, studentID int, connID string) {
+	ticker := time.NewTicker(wsConnHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !w.heartbeat(ctx, examID, studentID, connID) {
+				wsLog.Info().Msg("Connection replaced by a newer one, closing")
+				closeReplaced(conn)
+				return
+			}
+		}
+	}
+}
+
+// kick closes the connection with connID if it is open on this instance. Closing makes
+// its read loop exit, which flushes its buffered answers.
+func (w *wsConnections) kick(connID string) {
+	w.mu.Lock()
+	conn := w.conns[connID]
+	w.mu.Unlock()
+	if conn == nil {
+		return
+	}
+	closeReplaced(conn)
+}
+
+// closeReplaced tells a superseded connection why it is closed, then closes it.
+// WriteControl and Close are safe to call alongside the connection's own writes.
+func closeReplaced(conn *websocket.Conn) {
+	msg := websocket.FormatCloseMessage(wsCloseReplaced, "replaced by a newer connection")
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	conn.Close()
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -13,17 +14,25 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/chaos"
 	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/logger"
+	"github.com/stemsi/exstem-backend/internal/metrics"
 	"github.com/stemsi/exstem-backend/internal/middleware"
+	"github.com/stemsi/exstem-backend/internal/queue"
+	"github.com/stemsi/exstem-backend/internal/resilience"
+	"github.com/stemsi/exstem-backend/internal/response"
 	"github.com/stemsi/exstem-backend/internal/service"
 	ws "github.com/stemsi/exstem-backend/internal/websocket"
 )
 
-// buildUpgrader creates a WebSocket upgrader with origin validation.
-func buildUpgrader(allowedOrigins []string) websocket.Upgrader {
+// buildUpgrader creates a WebSocket upgrader with origin validation, offering
+// permessage-deflate when compress is set.
+func buildUpgrader(allowedOrigins []string, compress bool) websocket.Upgrader {
 	return websocket.Upgrader{
-		ReadBufferSize:  1024,
-		WriteBufferSize: 1024,
+		ReadBufferSize:    1024,
+		WriteBufferSize:   1024,
+		EnableCompression: compress,
 		CheckOrigin: func(r *http.Request) bool {
 			if len(allowedOrigins) == 0 {
 				return true
@@ -39,26 +48,70 @@ func buildUpgrader(allowedOrigins []string) websocket.Upgrader {
 	}
 }
 
+// Redis resilience settings for the exam hot path. Retries absorb brief blips;
+// the breaker stops hammering Redis once it is clearly down.
+const (
+	wsRedisFailureThreshold = 5
+	wsRedisBreakerCooldown  = 5 * time.Second
+	wsFinalFlushTimeout     = 5 * time.Second
+	// wsAnswersQueuedTTL outlives any reasonable answers queue backlog.
+	wsAnswersQueuedTTL = 10 * time.Minute
+)
+
+var wsRedisRetry = resilience.RetryPolicy{
+	Attempts:  3,
+	BaseDelay: 50 * time.Millisecond,
+	MaxDelay:  500 * time.Millisecond,
+}
+
 type WSHandler struct {
 	rdb            *redis.Client
+	queue          queue.Queue
+	redisBreaker   *resilience.Breaker
 	examService    *service.ExamService
 	sessionService *service.ExamSessionService
 	studentService *service.StudentService
+	answersLoad    *queueLoad
 	log            zerolog.Logger
 	upgrader       websocket.Upgrader
+	// compressionLevel is the deflate level of compressed frames; 0 disables compression.
+	compressionLevel int
+	conns            *wsConnections
 }
 
-func NewWSHandler(rdb *redis.Client, examService *service.ExamService, sessionService *service.ExamSessionService, studentService *service.StudentService, log zerolog.Logger, allowedOrigins []string) *WSHandler {
+// NewWSHandler creates the exam socket handler. Autosaves are coalesced while the
+// answers queue is longer than backpressureThreshold; zero disables backpressure.
+// Frames are compressed at compressionLevel (1-9) with clients that negotiate
+// permessage-deflate; zero disables compression. At most maxConns sockets are open at
+// once (zero means no limit), and a student's second socket to an exam is handled by
+// duplicatePolicy (WSDuplicateReplace or WSDuplicateReject).
+func NewWSHandler(rdb *redis.Client, q queue.Queue, examService *service.ExamService, sessionService *service.ExamSessionService, studentService *service.StudentService, log zerolog.Logger, allowedOrigins []string, backpressureThreshold int64, compressionLevel int, maxConns int64, duplicatePolicy string) *WSHandler {
+	log = log.With().Str("component", "ws_handler").Logger()
 	return &WSHandler{
 		rdb:            rdb,
+		queue:          q,
+		redisBreaker:   resilience.NewBreaker(wsRedisFailureThreshold, wsRedisBreakerCooldown),
 		examService:    examService,
 		sessionService: sessionService,
 		studentService: studentService,
-		log:            log.With().Str("component", "ws_handler").Logger(),
-		upgrader:       buildUpgrader(allowedOrigins),
+		answersLoad: &queueLoad{
+			queue:     q,
+			name:      config.WorkerKey.PersistAnswersQueue,
+			threshold: backpressureThreshold,
+			log:       log,
+		},
+		log:              log,
+		upgrader:         buildUpgrader(allowedOrigins, compressionLevel > 0),
+		compressionLevel: compressionLevel,
+		conns:            newWSConnections(rdb, maxConns, duplicatePolicy),
 	}
 }
 
+// ActiveConnections returns how many exam sockets this instance has open.
+func (h *WSHandler) ActiveConnections() int64 {
+	return h.conns.active.Load()
+}
+
 // ExamWebSocketStream godoc
 // WS /ws/v1/student/exams/:exam_id/stream
 func (h *WSHandler) ExamWebSocketStream(c *gin.Context) {
@@ -74,12 +127,24 @@ func (h *WSHandler) ExamWebSocketStream(c *gin.Context) {
 		return
 	}
 
+	if !h.conns.acquire() {
+		h.log.Warn().Msg("WebSocket connection limit reached, refusing connection")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "too many connections, please retry"})
+		return
+	}
+	defer h.conns.release()
+
 	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		h.log.Error().Err(err).Msg("WebSocket upgrade failed")
 		return
 	}
 	defer conn.Close()
+	if h.compressionLevel > 0 {
+		if err := conn.SetCompressionLevel(h.compressionLevel); err != nil {
+			h.log.Warn().Err(err).Int("level", h.compressionLevel).Msg("Invalid WebSocket compression level, using default")
+		}
+	}
 
 	studentID := claims.UserID
 
@@ -95,23 +160,83 @@ func (h *WSHandler) ExamWebSocketStream(c *gin.Context) {
 		studentName = student.Name
 	}
 
+	// One socket per student and exam: a second one replaces or is refused.
+	connID, err := h.conns.claim(c.Request.Context(), conn, examID, studentID)
+
+	// The upgrade's request ID follows every frame of this connection into the logs
+	// and the jobs it queues, so an answer can be traced from client to database.
+	requestID := c.GetString(response.ContextKeyRequestID)
+	ctx := logger.WithRequestID(context.Background(), requestID)
 	wsLog := h.log.With().
 		Int("student_id", studentID).
 		Str("exam_id", examID.String()).
+		Str("request_id", requestID).
+		Str("conn_id", connID).
 		Logger()
 
+	switch {
+	case errors.Is(err, errConnectionActive):
+		ws.WriteTyped(conn, ws.ErrorResponse{Event: ws.EventError, Code: ws.ErrCodeAlreadyConnected, Error: err.Error()})
+		return
+	case err != nil:
+		wsLog.Warn().Err(err).Msg("Connection not registered, duplicates not enforced")
+	default:
+		defer h.conns.unclaim(context.Background(), examID, studentID, connID)
+		watchCtx, stopWatch := context.WithCancel(context.Background())
+		defer stopWatch()
+		go h.conns.watch(watchCtx, wsLog, conn, examID, studentID, connID)
+	}
+
 	wsLog.Info().Msg("Student connected")
+	h.publishMonitorEvent(examID, map[string]interface{}{
+		"type":         "connect",
+		"student_id":   studentID,
+		"student_name": studentName,
+		"message":      fmt.Sprintf("%s connected", studentName),
+	})
+
+	// Server pings keep the student's last-seen time fresh; a silent socket is dropped.
+	pingCtx, stopPing := context.WithCancel(context.Background())
+	defer stopPing()
+	live := h.startLiveness(pingCtx, wsLog, conn, examID, studentID)
+
+	// Answers that could not reach Redis are held here and flushed once it recovers.
+	pending := newAutosaveBuffer()
+	defer h.finalFlush(ctx, wsLog, pending, answersKey, studentID, examID)
+
+	// Persistence jobs held back while the answers queue is overloaded.
+	coalesce := newAutosaveCoalescer()
+	defer h.finalFlushCoalesced(wsLog, coalesce)
+
+	// Autosaves are only accepted for questions in the student's own subset.
+	scope := &questionScope{}
+	if err := h.loadQuestionScope(c.Request.Context(), scope, examID, studentID); err != nil {
+		wsLog.Warn().Err(err).Msg("Question scope not loaded, retrying on next autosave")
+	}
 
 	for {
 		// 1. READ RAW BYTES (Critical Step)
 		// We do not unmarshal into a specific struct yet.
 		_, messageBytes, err := conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+			if isReadTimeout(err) {
+				wsLog.Info().Msg("Student missed pings, dropping idle connection")
+				h.publishMonitorEvent(examID, map[string]interface{}{
+					"type":         "idle",
+					"student_id":   studentID,
+					"student_name": studentName,
+					"idle_seconds": int(wsPongWait.Seconds()),
+					"message":      fmt.Sprintf("%s stopped responding", studentName),
+				})
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
 				wsLog.Warn().Err(err).Msg("Unexpected close")
 			}
 			break
 		}
+		live.alive()
+		if chaos.DropFrame() {
+			continue // Chaos mode: the frame is lost on the way in.
+		}
 
 		// 2. PEEK AT THE ACTION
 		var envelope ws.RequestEnvelope
@@ -131,7 +256,15 @@ func (h *WSHandler) ExamWebSocketStream(c *gin.Context) {
 				ws.WriteError(conn, "invalid autosave format")
 				continue
 			}
-			h.handleAutosave(conn, answersKey, studentID, studentName, examID, &req)
+			h.handleAutosave(ctx, conn, wsLog, pending, coalesce, scope, answersKey, studentID, studentName, examID, &req)
+
+		case ws.ActionBatchAutosave:
+			var req ws.BatchAutosaveRequest
+			if err := json.Unmarshal(messageBytes, &req); err != nil {
+				ws.WriteError(conn, "invalid batch_autosave format")
+				continue
+			}
+			h.handleBatchAutosave(ctx, conn, wsLog, pending, coalesce, scope, answersKey, studentID, studentName, examID, &req)
 
 		case ws.ActionCheat:
 			var req ws.CheatRequest
@@ -140,12 +273,40 @@ func (h *WSHandler) ExamWebSocketStream(c *gin.Context) {
 				wsLog.Error().Err(err).Msg("Cheat payload unmarshal failed")
 				continue
 			}
-			h.handleCheat(wsLog, studentID, studentName, examID, &req)
+			h.handleCheat(ctx, wsLog, studentID, studentName, examID, &req)
+
+		case ws.ActionMedia:
+			var req ws.MediaPlayRequest
+			if err := json.Unmarshal(messageBytes, &req); err != nil {
+				ws.WriteError(conn, "invalid media_play format")
+				continue
+			}
+			h.handleMediaPlay(conn, wsLog, scope, studentID, examID, &req)
+
+		case ws.ActionNavigate:
+			var req ws.NavigateRequest
+			if err := json.Unmarshal(messageBytes, &req); err != nil {
+				ws.WriteError(conn, "invalid navigate format")
+				continue
+			}
+			h.handleNavigate(conn, wsLog, scope, studentID, studentName, examID, &req)
+
+		case ws.ActionBreakStart:
+			h.handleBreakStart(conn, wsLog, scope, studentID, studentName, examID)
+
+		case ws.ActionBreakEnd:
+			h.handleBreakEnd(conn, wsLog, scope, studentID, studentName, examID)
 
 		case ws.ActionSubmit:
-			h.handleSubmit(conn, wsLog, answersKey, studentID, studentName, examID)
+			h.handleSubmit(ctx, conn, wsLog, pending, coalesce, answersKey, studentID, studentName, examID)
 
 		case ws.ActionPing:
+			if pending.len() > 0 {
+				h.flushPending(ctx, wsLog, pending, answersKey, studentID, examID)
+			}
+			if coalesce.due() {
+				h.flushCoalesced(wsLog, coalesce)
+			}
 			ws.WriteTyped(conn, ws.PongResponse{Event: ws.EventPong})
 
 		default:
@@ -153,12 +314,52 @@ func (h *WSHandler) ExamWebSocketStream(c *gin.Context) {
 			ws.WriteError(conn, "unknown action: "+string(envelope.Action))
 		}
 	}
+
+	h.publishMonitorEvent(examID, map[string]interface{}{
+		"type":         "disconnect",
+		"student_id":   studentID,
+		"student_name": studentName,
+		"message":      fmt.Sprintf("%s disconnected", studentName),
+	})
 }
 
 // handleCheat queues the cheat event for persistence.
-func (h *WSHandler) handleCheat(wsLog zerolog.Logger, studentID int, studentName string, examID uuid.UUID, msg *ws.CheatRequest) {
-	ctx := context.Background()
+func (h *WSHandler) handleCheat(ctx context.Context, wsLog zerolog.Logger, studentID int, studentName string, examID uuid.UUID, msg *ws.CheatRequest) {
+	h.queueCheat(ctx, wsLog, studentID, examID, msg.Payload)
+
+	h.publishMonitorEvent(examID, map[string]interface{}{
+		"type":         "cheat",
+		"student_id":   studentID,
+		"student_name": studentName,
+		"message":      fmt.Sprintf("%s is cheating", studentName),
+	})
+
+	// Security Best Practice: Do NOT acknowledge cheat events to the client.
+	// Silent logging prevents hackers from probing the detection system.
+}
+
+// flagOutOfScopeAnswer records an autosave for a question outside the student's
+// subset as a cheat event, so it shows up alongside other suspicious activity.
+func (h *WSHandler) flagOutOfScopeAnswer(ctx context.Context, wsLog zerolog.Logger, studentID int, studentName string, examID uuid.UUID, qid string) {
+	wsLog.Warn().Str("q_id", qid).Msg("Rejected autosave for question outside the student's subset")
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type": "out_of_scope_answer",
+		"q_id": qid,
+	})
+	h.queueCheat(ctx, wsLog, studentID, examID, string(payload))
 
+	h.publishMonitorEvent(examID, map[string]interface{}{
+		"type":         "invalid_answer",
+		"student_id":   studentID,
+		"student_name": studentName,
+		"q_id":         qid,
+		"message":      fmt.Sprintf("%s answered a question outside their exam", studentName),
+	})
+}
+
+// queueCheat queues a cheat event payload for persistence.
+func (h *WSHandler) queueCheat(ctx context.Context, wsLog zerolog.Logger, studentID int, examID uuid.UUID, payload string) {
 	// We store the payload as json.RawMessage (bytes) so that when it goes
 	// into Postgres JSONB, it is treated as a nested object, not a string.
 	// However, since msg.Payload is a string (double encoded), we just pass it through.
@@ -166,29 +367,25 @@ func (h *WSHandler) handleCheat(wsLog zerolog.Logger, studentID int, studentName
 		"student_id": studentID,
 		"exam_id":    examID.String(),
 		"timestamp":  time.Now().Unix(),
-		"payload":    msg.Payload, // The raw string from client
+		"payload":    payload, // The raw string from client
+		"request_id": logger.RequestID(ctx),
 	}
 
 	data, _ := json.Marshal(cheatEvent)
 
-	if err := h.rdb.RPush(ctx, config.WorkerKey.PersistCheatsQueue, data).Err(); err != nil {
+	if err := h.redisDo(ctx, func(ctx context.Context) error {
+		return h.queue.Push(ctx, config.WorkerKey.PersistCheatsQueue, data)
+	}); err != nil {
 		wsLog.Error().Err(err).Msg("Failed to queue cheat report")
 	}
-
-	h.publishMonitorEvent(examID, map[string]interface{}{
-		"type":         "cheat",
-		"student_id":   studentID,
-		"student_name": studentName,
-		"message":      fmt.Sprintf("%s is cheating", studentName),
-	})
-
-	// Security Best Practice: Do NOT acknowledge cheat events to the client.
-	// Silent logging prevents hackers from probing the detection system.
 }
 
-// handleAutosave saves a single answer to Redis.
-func (h *WSHandler) handleAutosave(conn *websocket.Conn, answersKey string, studentID int, studentName string, examID uuid.UUID, msg *ws.AutosaveRequest) {
-	ctx := context.Background()
+// handleAutosave saves a single answer to Redis. When Redis is unavailable the answer
+// is buffered in memory and acknowledged as "buffered" instead of failing. While the
+// answers queue is overloaded only the latest answer per question is queued for
+// persistence, and the client is asked to debounce autosaves longer.
+func (h *WSHandler) handleAutosave(ctx context.Context, conn *websocket.Conn, wsLog zerolog.Logger, pending *autosaveBuffer, coalesce *autosaveCoalescer, scope *questionScope, answersKey string, studentID int, studentName string, examID uuid.UUID, msg *ws.AutosaveRequest) {
+	received := time.Now()
 
 	if msg.QID == "" {
 		ws.WriteError(conn, "q_id is required")
@@ -201,67 +398,515 @@ func (h *WSHandler) handleAutosave(conn *websocket.Conn, answersKey string, stud
 		return
 	}
 
-	// Prepare persistence payload
-	payload, _ := json.Marshal(map[string]interface{}{
-		"student_id": studentID,
-		"exam_id":    examID.String(),
-		"q_id":       msg.QID,
-		"answer":     msg.Answer,
-	})
+	// Reject answers to questions outside the student's subset (another exam, or a
+	// question not drawn for them) and flag them as a suspicious event. Answers must
+	// also fit the question: a listed option, true/false, or a bounded essay.
+	if err := h.loadQuestionScope(ctx, scope, examID, studentID); err != nil {
+		wsLog.Warn().Err(err).Msg("Question scope not loaded, accepting autosave unchecked")
+	}
+	if h.onBreak(ctx, wsLog, scope, studentID, examID) {
+		ws.WriteAnswerError(conn, ws.ErrCodeOnBreak, msg.QID, "paper is locked during a break")
+		return
+	}
+	if !scope.allows(msg.QID) {
+		h.flagOutOfScopeAnswer(ctx, wsLog, studentID, studentName, examID, msg.QID)
+		ws.WriteAnswerError(conn, ws.ErrCodeQuestionOutOfScope, msg.QID, "q_id is not part of this exam")
+		return
+	}
+	if err := scope.validate(msg.QID, msg.Answer); err != nil {
+		ws.WriteAnswerError(conn, ws.ErrCodeInvalidAnswer, msg.QID, err.Error())
+		return
+	}
+	if _, ok := h.moveToQuestion(ctx, wsLog, scope, studentID, examID, msg.QID); !ok {
+		ws.WriteAnswerError(conn, ws.ErrCodeQuestionLocked, msg.QID, "question is locked")
+		return
+	}
 
-	// Handle Unanswer (Empty string)
-	if msg.Answer == "" {
-		if err := h.rdb.HDel(ctx, answersKey, msg.QID).Err(); err != nil {
-			h.log.Error().Err(err).Int("student_id", studentID).Msg("Autosave Redis error")
+	// Older buffered answers must land first, otherwise a late flush would
+	// overwrite this newer answer.
+	if pending.len() > 0 {
+		h.flushPending(ctx, wsLog, pending, answersKey, studentID, examID)
+	}
+
+	h.signalBackpressure(conn, coalesce, h.answersLoad.Overloaded(ctx))
+
+	var err error
+	switch {
+	case pending.len() > 0:
+		err = errAutosaveBacklog
+	case coalesce.active && !h.sessionService.RedisDegraded():
+		err = h.saveAnswersCoalesced(ctx, coalesce, answersKey, studentID, examID, []ws.AutosaveItem{{QID: msg.QID, Answer: msg.Answer}})
+	default:
+		err = h.persistAnswer(ctx, answersKey, studentID, examID, msg.QID, msg.Answer)
+	}
+	if coalesce.due() {
+		h.flushCoalesced(wsLog, coalesce)
+	}
+	if err != nil {
+		if !pending.put(msg.QID, msg.Answer) {
+			wsLog.Error().Err(err).Msg("Autosave Redis error, buffer full")
 			ws.WriteError(conn, "save failed")
 			return
 		}
-		h.rdb.RPush(ctx, config.WorkerKey.PersistAnswersQueue, payload)
-
-		h.publishMonitorEvent(examID, map[string]interface{}{
-			"type":         "autosave",
-			"student_id":   studentID,
-			"student_name": studentName,
-			"q_id":         msg.QID,
-			"message":      fmt.Sprintf("%s removed an answer", studentName),
-		})
-
+		wsLog.Warn().Err(err).Int("buffered", pending.len()).Msg("Autosave buffered while Redis is unavailable")
 		ws.WriteTyped(conn, ws.AutosaveResponse{
 			Event:  ws.EventSuccess,
-			Status: "removed",
+			Status: "buffered",
 		})
 		return
 	}
 
-	// Handle Save
-	if err := h.rdb.HSet(ctx, answersKey, msg.QID, msg.Answer).Err(); err != nil {
-		h.log.Error().Err(err).Int("student_id", studentID).Msg("Autosave Redis error")
-		ws.WriteError(conn, "save failed")
-		return
+	if !h.sessionService.RedisDegraded() {
+		metrics.AutosaveAck.Observe(time.Since(received))
 	}
 
-	h.rdb.RPush(ctx, config.WorkerKey.PersistAnswersQueue, payload)
+	status, verb := "saved", "updated"
+	if msg.Answer == "" {
+		status, verb = "removed", "removed"
+	}
 
 	h.publishMonitorEvent(examID, map[string]interface{}{
 		"type":         "autosave",
 		"student_id":   studentID,
 		"student_name": studentName,
 		"q_id":         msg.QID,
-		"message":      fmt.Sprintf("%s updated an answer", studentName),
+		"message":      fmt.Sprintf("%s %s an answer", studentName, verb),
 	})
 
 	ws.WriteTyped(conn, ws.AutosaveResponse{
 		Event:  ws.EventSuccess,
-		Status: "saved",
+		Status: status,
+	})
+}
+
+// handleNavigate moves the student to a question under the exam's navigation policy,
+// locking the questions it leaves behind, and tells the live monitor where they are.
+func (h *WSHandler) handleNavigate(conn *websocket.Conn, wsLog zerolog.Logger, scope *questionScope, studentID int, studentName string, examID uuid.UUID, msg *ws.NavigateRequest) {
+	ctx := context.Background()
+
+	if _, err := uuid.Parse(msg.QID); err != nil {
+		ws.WriteError(conn, "invalid q_id format")
+		return
+	}
+	if err := h.loadQuestionScope(ctx, scope, examID, studentID); err != nil {
+		wsLog.Warn().Err(err).Msg("Question scope not loaded, navigation unchecked")
+	}
+	if h.onBreak(ctx, wsLog, scope, studentID, examID) {
+		ws.WriteAnswerError(conn, ws.ErrCodeOnBreak, msg.QID, "paper is locked during a break")
+		return
+	}
+	if !scope.allows(msg.QID) {
+		ws.WriteAnswerError(conn, ws.ErrCodeQuestionOutOfScope, msg.QID, "q_id is not part of this exam")
+		return
+	}
+
+	unlockedFrom, ok := h.moveToQuestion(ctx, wsLog, scope, studentID, examID, msg.QID)
+	if !ok {
+		ws.WriteAnswerError(conn, ws.ErrCodeQuestionLocked, msg.QID, "question is locked")
+		return
+	}
+	if pos, ok := scope.position(msg.QID); ok {
+		h.publishMonitorEvent(examID, map[string]interface{}{
+			"type":             "navigate",
+			"student_id":       studentID,
+			"student_name":     studentName,
+			"q_id":             msg.QID,
+			"current_question": pos + 1,
+		})
+	}
+	ws.WriteTyped(conn, ws.NavigateResponse{
+		Event:        ws.EventNavigated,
+		QID:          msg.QID,
+		UnlockedFrom: unlockedFrom,
+	})
+}
+
+// moveToQuestion records the student's move to qid as their current question and
+// reports whether the navigation policy allows it, along with the first position still
+// unlocked. An unloaded scope and Redis failures let the move through, so an outage
+// never drops an answer.
+func (h *WSHandler) moveToQuestion(ctx context.Context, wsLog zerolog.Logger, scope *questionScope, studentID int, examID uuid.UUID, qid string) (int, bool) {
+	pos, ok := scope.position(qid)
+	if !ok || h.sessionService.RedisDegraded() {
+		return 0, true
+	}
+
+	unlockedFrom, err := h.sessionService.MoveToQuestion(ctx, examID, studentID, scope.nav, pos)
+	switch {
+	case errors.Is(err, service.ErrQuestionLocked):
+		return unlockedFrom, false
+	case err != nil:
+		wsLog.Warn().Err(err).Msg("Navigation position not updated, accepting move unchecked")
+	}
+	return unlockedFrom, true
+}
+
+// handleBreakStart pauses the student's timer and locks their paper, and tells the
+// proctor through the live monitor.
+func (h *WSHandler) handleBreakStart(conn *websocket.Conn, wsLog zerolog.Logger, scope *questionScope, studentID int, studentName string, examID uuid.UUID) {
+	ctx := context.Background()
+
+	if err := h.loadQuestionScope(ctx, scope, examID, studentID); err != nil {
+		wsLog.Error().Err(err).Msg("Break policy not loaded")
+		ws.WriteError(conn, "break failed")
+		return
+	}
+
+	status, err := h.sessionService.StartBreak(ctx, examID, studentID, scope.breaks)
+	switch {
+	case errors.Is(err, service.ErrBreaksDisabled), errors.Is(err, service.ErrBreakLimitReached), errors.Is(err, service.ErrBreakActive):
+		ws.WriteTyped(conn, ws.ErrorResponse{Event: ws.EventError, Code: ws.ErrCodeBreakNotAllowed, Error: err.Error()})
+		return
+	case errors.Is(err, service.ErrBreakNotRecorded):
+		wsLog.Warn().Err(err).Msg("Break started but not recorded")
+	case err != nil:
+		wsLog.Error().Err(err).Msg("Failed to start break")
+		ws.WriteError(conn, "break failed")
+		return
+	}
+
+	h.publishMonitorEvent(examID, map[string]interface{}{
+		"type":         "break_start",
+		"student_id":   studentID,
+		"student_name": studentName,
+		"breaks_used":  status.BreaksUsed,
+		"ends_at":      status.EndsAt,
+		"message":      fmt.Sprintf("%s started break %d of %d", studentName, status.BreaksUsed, status.BreaksAllowed),
+	})
+	ws.WriteTyped(conn, ws.BreakResponse{
+		Event:         ws.EventBreakStarted,
+		BreaksUsed:    status.BreaksUsed,
+		BreaksAllowed: status.BreaksAllowed,
+		EndsAt:        status.EndsAt,
+	})
+}
+
+// handleBreakEnd resumes the student's timer and unlocks their paper.
+func (h *WSHandler) handleBreakEnd(conn *websocket.Conn, wsLog zerolog.Logger, scope *questionScope, studentID int, studentName string, examID uuid.UUID) {
+	ctx := context.Background()
+
+	if err := h.loadQuestionScope(ctx, scope, examID, studentID); err != nil {
+		wsLog.Error().Err(err).Msg("Break policy not loaded")
+		ws.WriteError(conn, "break failed")
+		return
+	}
+
+	status, err := h.sessionService.EndBreak(ctx, examID, studentID, scope.breaks)
+	switch {
+	case errors.Is(err, service.ErrNoActiveBreak):
+		ws.WriteTyped(conn, ws.ErrorResponse{Event: ws.EventError, Code: ws.ErrCodeBreakNotAllowed, Error: err.Error()})
+		return
+	case errors.Is(err, service.ErrBreakNotRecorded):
+		wsLog.Warn().Err(err).Msg("Break ended but not recorded")
+	case err != nil:
+		wsLog.Error().Err(err).Msg("Failed to end break")
+		ws.WriteError(conn, "break failed")
+		return
+	}
+
+	h.publishMonitorEvent(examID, map[string]interface{}{
+		"type":         "break_end",
+		"student_id":   studentID,
+		"student_name": studentName,
+		"breaks_used":  status.BreaksUsed,
+		"message":      fmt.Sprintf("%s is back from a break", studentName),
+	})
+	ws.WriteTyped(conn, ws.BreakResponse{
+		Event:         ws.EventBreakEnded,
+		BreaksUsed:    status.BreaksUsed,
+		BreaksAllowed: status.BreaksAllowed,
+	})
+}
+
+// onBreak reports whether the student is on a break, which locks the paper. Lookup
+// failures count as not on a break, so an outage never drops an answer.
+func (h *WSHandler) onBreak(ctx context.Context, wsLog zerolog.Logger, scope *questionScope, studentID int, examID uuid.UUID) bool {
+	if !scope.breaks.Enabled() || h.sessionService.RedisDegraded() {
+		return false
+	}
+	status, err := h.sessionService.GetBreakStatus(ctx, examID, studentID, scope.breaks)
+	if err != nil {
+		wsLog.Warn().Err(err).Msg("Break status not loaded, treating paper as unlocked")
+		return false
+	}
+	return status.OnBreak
+}
+
+// persistAnswer saves an answer through Redis, or straight to PostgreSQL while
+// Redis is degraded.
+func (h *WSHandler) persistAnswer(ctx context.Context, answersKey string, studentID int, examID uuid.UUID, qid, answer string) error {
+	return h.persistAnswers(ctx, answersKey, studentID, examID, []ws.AutosaveItem{{QID: qid, Answer: answer}})
+}
+
+// persistAnswers saves several answers like persistAnswer. Through Redis they are
+// written in a single transaction; directly to PostgreSQL one by one.
+func (h *WSHandler) persistAnswers(ctx context.Context, answersKey string, studentID int, examID uuid.UUID, answers []ws.AutosaveItem) error {
+	if h.sessionService.RedisDegraded() {
+		for _, a := range answers {
+			if err := h.sessionService.SaveAnswerDirect(ctx, examID, studentID, a.QID, a.Answer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return h.saveAnswers(ctx, answersKey, studentID, examID, answers)
+}
+
+// saveAnswers writes (or, for an empty answer, removes) answers in Redis and queues them
+// for persistence in a single transaction, retrying through the Redis breaker.
+func (h *WSHandler) saveAnswers(ctx context.Context, answersKey string, studentID int, examID uuid.UUID, answers []ws.AutosaveItem) error {
+	savedAt := time.Now().UnixMilli()
+	queuedKey := config.CacheKey.StudentAnswersQueuedKey(examID.String(), studentID)
+
+	return h.redisDo(ctx, func(ctx context.Context) error {
+		_, err := h.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, a := range answers {
+				if a.Answer == "" {
+					pipe.HDel(ctx, answersKey, a.QID)
+				} else {
+					pipe.HSet(ctx, answersKey, a.QID, a.Answer)
+				}
+				h.queue.PushPipe(ctx, pipe, config.WorkerKey.PersistAnswersQueue, answerJob(ctx, studentID, examID, a.QID, a.Answer, savedAt))
+				// Lets the AutosaveWorker skip older jobs for the question still in the queue.
+				pipe.HSet(ctx, queuedKey, a.QID, savedAt)
+			}
+			pipe.Expire(ctx, queuedKey, wsAnswersQueuedTTL)
+			return nil
+		})
+		return err
+	})
+}
+
+// saveAnswersCoalesced writes (or removes) answers in Redis like saveAnswers, but holds
+// their persistence jobs in coalesce instead of queueing them.
+func (h *WSHandler) saveAnswersCoalesced(ctx context.Context, coalesce *autosaveCoalescer, answersKey string, studentID int, examID uuid.UUID, answers []ws.AutosaveItem) error {
+	savedAt := time.Now().UnixMilli()
+
+	err := h.redisDo(ctx, func(ctx context.Context) error {
+		_, err := h.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, a := range answers {
+				if a.Answer == "" {
+					pipe.HDel(ctx, answersKey, a.QID)
+				} else {
+					pipe.HSet(ctx, answersKey, a.QID, a.Answer)
+				}
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, a := range answers {
+		coalesce.put(a.QID, answerJob(ctx, studentID, examID, a.QID, a.Answer, savedAt))
+	}
+	return nil
+}
+
+// answerJob builds the AutosaveWorker job persisting an answer saved at savedAt (unix ms),
+// tagged with the request ID carried by ctx.
+func answerJob(ctx context.Context, studentID int, examID uuid.UUID, qid, answer string, savedAt int64) []byte {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"student_id": studentID,
+		"exam_id":    examID.String(),
+		"q_id":       qid,
+		"answer":     answer,
+		"ts":         savedAt,
+		"request_id": logger.RequestID(ctx),
+	})
+	return payload
+}
+
+// signalBackpressure tells the client to lengthen or restore its autosave debounce
+// when the answers queue becomes overloaded or recovers.
+func (h *WSHandler) signalBackpressure(conn *websocket.Conn, coalesce *autosaveCoalescer, overloaded bool) {
+	if coalesce.active == overloaded {
+		return
+	}
+	coalesce.active = overloaded
+
+	msg := ws.BackpressureResponse{Event: ws.EventBackpressure, Active: overloaded}
+	if overloaded {
+		msg.DebounceMs = wsBackpressureDebounce.Milliseconds()
+	}
+	ws.WriteTyped(conn, msg)
+}
+
+// flushCoalesced queues the held persistence jobs, keeping them if Redis fails.
+func (h *WSHandler) flushCoalesced(wsLog zerolog.Logger, coalesce *autosaveCoalescer) error {
+	ctx := context.Background()
+	err := coalesce.flush(func(jobs ...[]byte) error {
+		return h.redisDo(ctx, func(ctx context.Context) error {
+			return h.queue.Push(ctx, config.WorkerKey.PersistAnswersQueue, jobs...)
+		})
+	})
+	if err != nil {
+		wsLog.Warn().Err(err).Int("held", coalesce.len()).Msg("Coalesced autosaves not queued yet")
+	}
+	return err
+}
+
+// finalFlushCoalesced queues the held persistence jobs when the connection closes.
+func (h *WSHandler) finalFlushCoalesced(wsLog zerolog.Logger, coalesce *autosaveCoalescer) {
+	if coalesce.len() == 0 {
+		return
+	}
+	if err := h.flushCoalesced(wsLog, coalesce); err != nil {
+		wsLog.Error().Err(err).Int("lost", coalesce.len()).Msg("Coalesced autosaves dropped on disconnect")
+	}
+}
+
+// flushPending tries to write buffered answers to Redis, keeping whatever still fails.
+func (h *WSHandler) flushPending(ctx context.Context, wsLog zerolog.Logger, pending *autosaveBuffer, answersKey string, studentID int, examID uuid.UUID) {
+	err := pending.flush(func(qid, answer string) error {
+		return h.persistAnswer(ctx, answersKey, studentID, examID, qid, answer)
+	})
+	if err != nil {
+		wsLog.Warn().Err(err).Int("buffered", pending.len()).Msg("Buffered autosaves not flushed yet")
+		return
+	}
+	wsLog.Info().Msg("Buffered autosaves flushed")
+}
+
+// finalFlush makes a last attempt to save buffered answers when the connection closes.
+func (h *WSHandler) finalFlush(ctx context.Context, wsLog zerolog.Logger, pending *autosaveBuffer, answersKey string, studentID int, examID uuid.UUID) {
+	if pending.len() == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, wsFinalFlushTimeout)
+	defer cancel()
+
+	err := pending.flush(func(qid, answer string) error {
+		return h.persistAnswer(ctx, answersKey, studentID, examID, qid, answer)
 	})
+	if err != nil {
+		wsLog.Error().Err(err).Int("lost", pending.len()).Msg("Buffered autosaves dropped on disconnect")
+	}
 }
 
-// handleSubmit grades the exam in RAM.
-func (h *WSHandler) handleSubmit(conn *websocket.Conn, wsLog zerolog.Logger, answersKey string, studentID int, studentName string, examID uuid.UUID) {
+// redisDo runs a Redis call with retries and jitter, guarded by the circuit breaker.
+func (h *WSHandler) redisDo(ctx context.Context, fn func(ctx context.Context) error) error {
+	return wsRedisRetry.Do(ctx, func(ctx context.Context) error {
+		return h.redisBreaker.Do(func() error { return fn(ctx) })
+	})
+}
+
+// handleMediaPlay counts a playback of a question's audio/video against its max_plays limit.
+// The counter lives in Redis so the limit survives page reloads and reconnects.
+func (h *WSHandler) handleMediaPlay(conn *websocket.Conn, wsLog zerolog.Logger, scope *questionScope, studentID int, examID uuid.UUID, msg *ws.MediaPlayRequest) {
 	ctx := context.Background()
 
+	if _, err := uuid.Parse(msg.QID); err != nil {
+		ws.WriteError(conn, "invalid q_id format")
+		return
+	}
+	if h.onBreak(ctx, wsLog, scope, studentID, examID) {
+		ws.WriteAnswerError(conn, ws.ErrCodeOnBreak, msg.QID, "paper is locked during a break")
+		return
+	}
+
+	maxPlays, err := h.rdb.HGet(ctx, config.CacheKey.ExamPlayLimitsKey(examID.String()), msg.QID).Int()
+	if err != nil && err != redis.Nil {
+		wsLog.Error().Err(err).Msg("Failed to read play limit")
+		ws.WriteError(conn, "play check failed")
+		return
+	}
+
+	playsKey := config.CacheKey.StudentMediaPlaysKey(examID.String(), studentID)
+	used, err := h.rdb.HIncrBy(ctx, playsKey, msg.QID, 1).Result()
+	if err != nil {
+		wsLog.Error().Err(err).Msg("Failed to count media play")
+		ws.WriteError(conn, "play check failed")
+		return
+	}
+
+	if maxPlays > 0 && int(used) > maxPlays {
+		// Roll back so the stored count never exceeds the limit.
+		h.rdb.HIncrBy(ctx, playsKey, msg.QID, -1)
+		ws.WriteTyped(conn, ws.MediaPlayResponse{
+			Event:     ws.EventPlayDenied,
+			QID:       msg.QID,
+			PlaysUsed: maxPlays,
+			MaxPlays:  maxPlays,
+		})
+		return
+	}
+
+	ws.WriteTyped(conn, ws.MediaPlayResponse{
+		Event:     ws.EventPlayGranted,
+		QID:       msg.QID,
+		PlaysUsed: int(used),
+		MaxPlays:  maxPlays,
+	})
+}
+
+// handleSubmit grades the exam in RAM. Buffered autosaves must be flushed first
+// so the grade includes every answer.
+func (h *WSHandler) handleSubmit(ctx context.Context, conn *websocket.Conn, wsLog zerolog.Logger, pending *autosaveBuffer, coalesce *autosaveCoalescer, answersKey string, studentID int, studentName string, examID uuid.UUID) {
+
+	if pending.len() > 0 {
+		h.flushPending(ctx, wsLog, pending, answersKey, studentID, examID)
+		if pending.len() > 0 {
+			ws.WriteError(conn, "answers not saved yet, please retry")
+			return
+		}
+	}
+	// Scoring clears the Redis answers, so every answer must be queued for persistence first.
+	if err := h.flushCoalesced(wsLog, coalesce); err != nil {
+		ws.WriteError(conn, "answers not saved yet, please retry")
+		return
+	}
+
+	// While Redis is degraded, grade from PostgreSQL and complete the session directly.
+	degraded := h.sessionService.RedisDegraded()
+
+	// Grade each session at most once. Without Redis the conditional completion
+	// in PostgreSQL keeps the first score.
+	if !degraded {
+		var release func()
+		var lockErr error
+		err := h.redisDo(ctx, func(ctx context.Context) error {
+			release, lockErr = h.sessionService.LockSubmit(ctx, examID, studentID)
+			if errors.Is(lockErr, service.ErrSubmitInProgress) {
+				return nil // Redis answered; do not retry or trip the breaker
+			}
+			return lockErr
+		})
+		if errors.Is(lockErr, service.ErrSubmitInProgress) {
+			ws.WriteError(conn, "submit already in progress")
+			return
+		}
+		if err != nil {
+			wsLog.Error().Err(err).Msg("Submit lock error")
+			ws.WriteError(conn, "submit failed, please retry")
+			return
+		}
+		defer release()
+	}
+
+	if score, done, err := h.sessionService.CompletedScore(ctx, examID, studentID); err != nil {
+		wsLog.Error().Err(err).Msg("Check submitted score error")
+		ws.WriteError(conn, "submit failed, please retry")
+		return
+	} else if done {
+		wsLog.Info().Float64("score", score).Msg("Duplicate submit, returning existing score")
+		ws.WriteTyped(conn, ws.GradedResponse{
+			Event:  ws.EventGraded,
+			Status: "completed",
+			Score:  score,
+		})
+		return
+	}
+
 	// 1. Get correct answers (Cached in service layer usually)
-	answerKey, err := h.examService.GetAnswerKey(ctx, examID)
+	var answerKey map[string]string
+	var err error
+	if degraded {
+		answerKey, err = h.examService.GetAnswerKeyDirect(ctx, examID)
+	} else {
+		answerKey, err = h.examService.GetAnswerKey(ctx, examID)
+	}
 	if err != nil {
 		wsLog.Error().Err(err).Msg("Get answer key error")
 		ws.WriteError(conn, "grading failed")
@@ -269,7 +914,16 @@ func (h *WSHandler) handleSubmit(conn *websocket.Conn, wsLog zerolog.Logger, ans
 	}
 
 	// 2. Get student answers from Redis
-	studentAnswers, err := h.rdb.HGetAll(ctx, answersKey).Result()
+	var studentAnswers map[string]string
+	if degraded {
+		studentAnswers, err = h.sessionService.ListAnswersDirect(ctx, examID, studentID)
+	} else {
+		err = h.redisDo(ctx, func(ctx context.Context) error {
+			var err error
+			studentAnswers, err = h.rdb.HGetAll(ctx, answersKey).Result()
+			return err
+		})
+	}
 	if err != nil {
 		wsLog.Error().Err(err).Msg("Get student answers error")
 		ws.WriteError(conn, "failed to get answers")
@@ -283,31 +937,35 @@ func (h *WSHandler) handleSubmit(conn *websocket.Conn, wsLog zerolog.Logger, ans
 		ws.WriteError(conn, "failed to get question subset")
 		return
 	}
-
-	// 4. Grade it against their specific subset
-	correct := 0
-	total := len(orderedIDs)
-	for _, qID := range orderedIDs {
-		// Verify this question actually exists in the global answer key
-		if correctAns, exists := answerKey[qID]; exists {
-			if studentAns, answered := studentAnswers[qID]; answered && studentAns == correctAns {
-				correct++
-			}
+	if len(orderedIDs) == 0 && degraded {
+		// The question order may still be queued in Redis; grade against the full key.
+		for qID := range answerKey {
+			orderedIDs = append(orderedIDs, qID)
 		}
 	}
 
-	var score float64
-	if total > 0 {
-		score = (float64(correct) / float64(total)) * 100
-	}
+	// 4. Grade it against their specific subset
+	score := service.GradeAnswers(answerKey, studentAnswers, orderedIDs)
 
 	// 4. Queue Score for Persistence
 	scorePayload, _ := json.Marshal(map[string]interface{}{
 		"student_id": studentID,
 		"exam_id":    examID.String(),
 		"score":      score,
+		"request_id": logger.RequestID(ctx),
 	})
-	h.rdb.RPush(ctx, config.WorkerKey.PersistScoresQueue, scorePayload)
+	if degraded {
+		score, err = h.sessionService.CompleteDirect(ctx, examID, studentID, score)
+	} else {
+		err = h.redisDo(ctx, func(ctx context.Context) error {
+			return h.sessionService.QueueScore(ctx, examID, studentID, scorePayload, score)
+		})
+	}
+	if err != nil {
+		wsLog.Error().Err(err).Msg("Queue score error")
+		ws.WriteError(conn, "submit failed, please retry")
+		return
+	}
 
 	h.publishMonitorEvent(examID, map[string]interface{}{
 		"type":         "submit",
@@ -328,7 +986,9 @@ func (h *WSHandler) handleSubmit(conn *websocket.Conn, wsLog zerolog.Logger, ans
 
 // publishMonitorEvent sends real-time updates to connected admin dashboards.
 func (h *WSHandler) publishMonitorEvent(examID uuid.UUID, event map[string]interface{}) {
-	data, _ := json.Marshal(event)
+	if h.sessionService.RedisDegraded() {
+		return // Monitor events go through Redis PubSub; drop them during an outage.
+	}
 	// Fire-and-forget, don't block the WS handler
-	go h.rdb.Publish(context.Background(), config.CacheKey.ExamMonitorChannel(examID.String()), data)
+	go h.sessionService.PublishMonitorEvent(context.Background(), examID, event)
 }
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// wsPingPeriod is how often the server pings an exam socket.
+	wsPingPeriod = 25 * time.Second
+	// wsPongWait is how long a socket may stay silent before it is dropped as idle,
+	// enough for two pings to go unanswered.
+	wsPongWait = 60 * time.Second
+	// wsPingWriteWait bounds the write of a single ping.
+	wsPingWriteWait = 10 * time.Second
+	// wsTouchInterval bounds how often a socket refreshes its student's last-seen time.
+	wsTouchInterval = 15 * time.Second
+	// wsIdleAfter is the silence after which the monitor reports a student idle.
+	wsIdleAfter = wsPongWait
+)
+
+// wsLiveness keeps one exam socket's read deadline and its student's last-seen time
+// fresh. alive runs on the socket's read goroutine, from the read loop and the pong
+// handler, so it needs no locking.
+type wsLiveness struct {
+	h         *WSHandler
+	wsLog     zerolog.Logger
+	conn      *websocket.Conn
+	examID    uuid.UUID
+	studentID int
+	touched   time.Time
+}
+
+// startLiveness settles the student's disconnect pause, then pings conn every
+// wsPingPeriod until ctx ends and drops it once it stays silent for wsPongWait.
+func (h *WSHandler) startLiveness(ctx context.Context, wsLog zerolog.Logger, conn *websocket.Conn, examID uuid.UUID, studentID int) *wsLiveness {
+	l := &wsLiveness{h: h, wsLog: wsLog, conn: conn, examID: examID, studentID: studentID}
+
+	if !h.sessionService.RedisDegraded() {
+		credited, err := h.sessionService.SettleDisconnect(ctx, examID, studentID)
+		if err != nil {
+			wsLog.Warn().Err(err).Msg("Disconnect pause not settled")
+		}
+		if credited > 0 {
+			wsLog.Info().Int("paused_seconds", credited).Msg("Timer paused for prolonged disconnect")
+		}
+		l.touched = time.Now()
+	}
+
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		l.alive()
+		return nil
+	})
+	go l.ping(ctx)
+	return l
+}
+
+// alive extends the read deadline and, at most once per wsTouchInterval, the student's
+// last-seen time.
+func (l *wsLiveness) alive() {
+	l.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	if time.Since(l.touched) < wsTouchInterval || l.h.sessionService.RedisDegraded() {
+		return
+	}
+	l.touched = time.Now()
+	if err := l.h.sessionService.TouchSession(context.Background(), l.examID, l.studentID); err != nil {
+		l.wsLog.Debug().Err(err).Msg("Last-seen time not updated")
+	}
+}
+
+// ping sends a ping every wsPingPeriod until ctx ends or a ping cannot be written.
+// WriteControl is safe to call alongside the connection's own writes.
+func (l *wsLiveness) ping(ctx context.Context) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsPingWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// isReadTimeout reports whether a read failed because the socket missed its deadline.
+func isReadTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
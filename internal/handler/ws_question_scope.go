@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+// questionScope is the set of questions a student may answer, with the answers each
+// accepts and the exam's navigation and break policies. It is read once per connection
+// from the Redis-cached question order and exam payload, and is owned by a single
+// connection's read loop.
+type questionScope struct {
+	ids    map[string]int // question ID -> position in the student's order
+	rules  map[string]service.AnswerRule
+	nav    model.NavigationRules
+	breaks model.BreakPolicy
+}
+
+// allows reports whether qid belongs to the student's questions. Before the scope is
+// loaded every question is allowed, so a lookup failure never drops an answer.
+func (s *questionScope) allows(qid string) bool {
+	if s.ids == nil {
+		return true
+	}
+	_, ok := s.ids[qid]
+	return ok
+}
+
+// position returns qid's position in the student's question order. ok is false when
+// the scope is not loaded.
+func (s *questionScope) position(qid string) (int, bool) {
+	if s.ids == nil {
+		return 0, false
+	}
+	pos, ok := s.ids[qid]
+	return pos, ok
+}
+
+// validate checks an answer against its question's rule. Unknown questions and an
+// unloaded scope pass.
+func (s *questionScope) validate(qid, answer string) error {
+	rule, ok := s.rules[qid]
+	if !ok {
+		return nil
+	}
+	return rule.Validate(answer)
+}
+
+// loadQuestionScope fills scope from the student's question order and the exam payload.
+// When the order is not known yet (e.g. still queued during a Redis outage) every
+// question of the exam is allowed, which still rejects questions from other exams.
+func (h *WSHandler) loadQuestionScope(ctx context.Context, scope *questionScope, examID uuid.UUID, studentID int) error {
+	if scope.ids != nil {
+		return nil
+	}
+
+	payload, err := h.examService.GetExamPayload(ctx, examID)
+	if err != nil {
+		return err
+	}
+	qIDs, err := h.sessionService.GetShuffledQuestionIDs(ctx, examID, studentID)
+	if err != nil {
+		return err
+	}
+	if len(qIDs) == 0 {
+		for _, q := range payload.Questions {
+			qIDs = append(qIDs, q.ID.String())
+		}
+	}
+
+	nav, err := h.sessionService.GetNavigationRules(ctx, examID)
+	if err != nil {
+		return err
+	}
+	breaks, err := h.sessionService.GetBreakPolicy(ctx, examID)
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]int, len(qIDs))
+	for i, qID := range qIDs {
+		ids[qID] = i
+	}
+	scope.ids = ids
+	scope.nav = nav
+	scope.breaks = breaks
+	scope.rules = service.AnswerRulesFor(payload.Questions)
+	return nil
+}
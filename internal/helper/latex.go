@@ -0,0 +1,61 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidLatex is returned when a LaTeX math source fails validation.
+var ErrInvalidLatex = errors.New("invalid latex")
+
+// MaxLatexLength caps the size of a question's LaTeX source.
+const MaxLatexLength = 5000
+
+// forbiddenLatexCommands can read files, define macros or emit links —
+// none of which a math renderer (KaTeX/MathJax) should ever see from exam content.
+var forbiddenLatexCommands = map[string]bool{
+	"input": true, "include": true, "write": true, "immediate": true,
+	"openout": true, "openin": true, "read": true, "def": true, "edef": true,
+	"gdef": true, "xdef": true, "let": true, "newcommand": true,
+	"renewcommand": true, "providecommand": true, "catcode": true,
+	"href": true, "url": true, "htmlclass": true, "htmlid": true,
+	"htmlstyle": true, "htmldata": true, "csname": true, "expandafter": true,
+}
+
+var latexCommandRe = regexp.MustCompile(`\\([a-zA-Z]+)`)
+
+// ValidateLatex checks a math-mode LaTeX source for length, balanced braces
+// and forbidden commands. An empty source is valid.
+func ValidateLatex(src string) error {
+	if len(src) > MaxLatexLength {
+		return fmt.Errorf("%w: source exceeds %d characters", ErrInvalidLatex, MaxLatexLength)
+	}
+
+	depth := 0
+	for i := 0; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++ // skip escaped character, e.g. \{ or \}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("%w: unbalanced braces", ErrInvalidLatex)
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("%w: unbalanced braces", ErrInvalidLatex)
+	}
+
+	for _, m := range latexCommandRe.FindAllStringSubmatch(src, -1) {
+		if forbiddenLatexCommands[strings.ToLower(m[1])] {
+			return fmt.Errorf("%w: forbidden command \\%s", ErrInvalidLatex, m[1])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package helper
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// MediaRef is an image, audio or video embedded in question content. Label is the
+// image's alt text, or the aria-label of audio and video.
+type MediaRef struct {
+	Kind  string
+	Src   string
+	Label string
+}
+
+// mediaKinds maps media elements to the kind reported for them.
+var mediaKinds = map[atom.Atom]string{
+	atom.Img:   "image",
+	atom.Audio: "audio",
+	atom.Video: "video",
+}
+
+// FindMedia lists the media embedded in an HTML fragment, in document order. Audio and
+// video without a src take it from their first <source>.
+func FindMedia(input string) []MediaRef {
+	z := html.NewTokenizer(strings.NewReader(input))
+	var refs []MediaRef
+	open := -1 // index of the audio/video waiting for a <source>
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return refs
+		}
+		tok := z.Token()
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if tok.DataAtom == atom.Source {
+				if open >= 0 && refs[open].Src == "" {
+					refs[open].Src = attr(tok, "src")
+				}
+				continue
+			}
+			kind, ok := mediaKinds[tok.DataAtom]
+			if !ok {
+				continue
+			}
+			label := attr(tok, "aria-label")
+			if tok.DataAtom == atom.Img {
+				label = attr(tok, "alt")
+			}
+			refs = append(refs, MediaRef{Kind: kind, Src: attr(tok, "src"), Label: strings.TrimSpace(label)})
+			if tok.DataAtom != atom.Img && tt == html.StartTagToken {
+				open = len(refs) - 1
+			}
+		case html.EndTagToken:
+			if tok.DataAtom == atom.Audio || tok.DataAtom == atom.Video {
+				open = -1
+			}
+		}
+	}
+}
+
+// FindMediaInJSON lists the media embedded in every string value of a JSON document,
+// such as question options.
+func FindMediaInJSON(raw json.RawMessage) []MediaRef {
+	if len(raw) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil
+	}
+	var refs []MediaRef
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch t := v.(type) {
+		case string:
+			refs = append(refs, FindMedia(t)...)
+		case []interface{}:
+			for _, e := range t {
+				walk(e)
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(t[k])
+			}
+		}
+	}
+	walk(v)
+	return refs
+}
+
+func attr(tok html.Token, key string) string {
+	for _, a := range tok.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
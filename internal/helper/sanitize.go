@@ -0,0 +1,130 @@
+package helper
+
+import (
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedTags lists the HTML elements permitted in question content,
+// mapped to the attributes each may keep. Everything else is stripped.
+var allowedTags = map[atom.Atom]map[string]bool{
+	atom.P: nil, atom.Br: nil, atom.Div: nil, atom.Span: {"class": true},
+	atom.B: nil, atom.Strong: nil, atom.I: nil, atom.Em: nil, atom.U: nil, atom.S: nil,
+	atom.Sub: nil, atom.Sup: nil, atom.Code: nil, atom.Pre: nil, atom.Blockquote: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil,
+	atom.Ul: nil, atom.Ol: nil, atom.Li: nil,
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tr: nil,
+	atom.Th:     {"colspan": true, "rowspan": true},
+	atom.Td:     {"colspan": true, "rowspan": true},
+	atom.Img:    {"src": true, "alt": true, "width": true, "height": true},
+	atom.Audio:  {"src": true, "controls": true, "aria-label": true},
+	atom.Video:  {"src": true, "controls": true, "width": true, "height": true, "aria-label": true},
+	atom.Source: {"src": true, "type": true},
+}
+
+// droppedContentTags are removed together with everything inside them.
+var droppedContentTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true,
+	atom.Embed: true, atom.Noscript: true, atom.Template: true,
+}
+
+// SanitizeHTML strips every tag and attribute outside the question-content
+// allowlist. Text is re-escaped and src attributes must be http(s) or /uploads/.
+func SanitizeHTML(input string) string {
+	z := html.NewTokenizer(strings.NewReader(input))
+	var b strings.Builder
+	skipDepth := 0
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return b.String()
+		}
+		tok := z.Token()
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedContentTags[tok.DataAtom] {
+				if tt == html.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			attrs, ok := allowedTags[tok.DataAtom]
+			if !ok {
+				continue
+			}
+			kept := tok.Attr[:0]
+			for _, a := range tok.Attr {
+				key := strings.ToLower(a.Key)
+				if !attrs[key] || (key == "src" && !isSafeURL(a.Val)) {
+					continue
+				}
+				kept = append(kept, html.Attribute{Key: key, Val: a.Val})
+			}
+			tok.Attr = kept
+			b.WriteString(tok.String())
+
+		case html.EndTagToken:
+			if droppedContentTags[tok.DataAtom] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			if _, ok := allowedTags[tok.DataAtom]; ok {
+				b.WriteString(tok.String())
+			}
+
+		case html.TextToken:
+			if skipDepth == 0 {
+				b.WriteString(html.EscapeString(tok.Data))
+			}
+		}
+	}
+}
+
+// SanitizeJSONStrings applies SanitizeHTML to every string value in a JSON document,
+// leaving its structure untouched. Used for question options, whose shape is free-form.
+func SanitizeJSONStrings(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return raw, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(sanitizeValue(v))
+}
+
+func sanitizeValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return SanitizeHTML(t)
+	case []interface{}:
+		for i := range t {
+			t[i] = sanitizeValue(t[i])
+		}
+	case map[string]interface{}:
+		for k := range t {
+			t[k] = sanitizeValue(t[k])
+		}
+	}
+	return v
+}
+
+func isSafeURL(u string) bool {
+	u = strings.TrimSpace(strings.ToLower(u))
+	return strings.HasPrefix(u, "/uploads/") ||
+		strings.HasPrefix(u, "https://") ||
+		strings.HasPrefix(u, "http://")
+}
@@ -0,0 +1,189 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// httpShipBuffer is how many events wait to be shipped; more are dropped, so a
+	// slow or unreachable log store never blocks the server.
+	httpShipBuffer = 10000
+	// httpShipBatch and httpShipInterval bound a batch by events and by age.
+	httpShipBatch    = 500
+	httpShipInterval = 2 * time.Second
+	// httpShipCloseTimeout bounds the final flush on Close.
+	httpShipCloseTimeout = 5 * time.Second
+)
+
+// shippedEvent is an encoded event and when it was written.
+type shippedEvent struct {
+	at   time.Time
+	line []byte
+}
+
+// httpShipper ships events in batches to Loki's push API or Elasticsearch's bulk API
+// from a goroutine of its own. Events arriving while the buffer is full are dropped
+// and counted; failures are reported on stderr, once until a batch goes through.
+type httpShipper struct {
+	url      string
+	format   string
+	auth     string
+	instance string
+	client   *http.Client
+
+	mu      sync.RWMutex // guards events against writes after Close
+	closed  bool
+	events  chan shippedEvent
+	done    chan struct{}
+	dropped atomic.Int64
+	failing bool
+}
+
+func newHTTPShipper(url, format, auth, instance string) (*httpShipper, error) {
+	if format != "loki" && format != "elasticsearch" {
+		return nil, fmt.Errorf("log shipping: format %q is not loki or elasticsearch", format)
+	}
+	s := &httpShipper{
+		url:      url,
+		format:   format,
+		auth:     auth,
+		instance: instance,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		events:   make(chan shippedEvent, httpShipBuffer),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *httpShipper) Write(p []byte) (int, error) {
+	// zerolog reuses p once Write returns.
+	line := bytes.TrimRight(append([]byte(nil), p...), "\n")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return len(p), nil
+	}
+	select {
+	case s.events <- shippedEvent{at: time.Now(), line: line}:
+	default:
+		s.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Close ships the events still buffered, waiting at most httpShipCloseTimeout.
+func (s *httpShipper) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.done:
+	case <-time.After(httpShipCloseTimeout):
+	}
+	return nil
+}
+
+func (s *httpShipper) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(httpShipInterval)
+	defer ticker.Stop()
+
+	batch := make([]shippedEvent, 0, httpShipBatch)
+	for {
+		select {
+		case e, ok := <-s.events:
+			if !ok {
+				s.ship(batch)
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= httpShipBatch {
+				s.ship(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			s.ship(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// ship sends a batch, reporting the events dropped since the last one along with it.
+func (s *httpShipper) ship(batch []shippedEvent) {
+	if n := s.dropped.Swap(0); n > 0 {
+		line := fmt.Sprintf(`{"level":"warn","component":"log_shipper","dropped":%d,"time":%q,"message":"Log events dropped while the shipping buffer was full"}`,
+			n, time.Now().Format(time.RFC3339))
+		batch = append(batch, shippedEvent{at: time.Now(), line: []byte(line)})
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	body, contentType := s.encode(batch)
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		s.report(err)
+		return
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.auth != "" {
+		req.Header.Set("Authorization", s.auth)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.report(err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		s.report(fmt.Errorf("%s answered %s", s.format, resp.Status))
+		return
+	}
+	s.failing = false
+}
+
+// encode builds the request body of a batch in the log store's format.
+func (s *httpShipper) encode(batch []shippedEvent) ([]byte, string) {
+	var buf bytes.Buffer
+	if s.format == "elasticsearch" {
+		for _, e := range batch {
+			buf.WriteString(`{"create":{}}` + "\n")
+			buf.Write(e.line)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), "application/x-ndjson"
+	}
+
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	st := stream{Stream: map[string]string{"app": "exstem", "instance": s.instance}, Values: make([][2]string, len(batch))}
+	for i, e := range batch {
+		st.Values[i] = [2]string{strconv.FormatInt(e.at.UnixNano(), 10), string(e.line)}
+	}
+	_ = json.NewEncoder(&buf).Encode(map[string][]stream{"streams": {st}})
+	return buf.Bytes(), "application/json"
+}
+
+// report writes a shipping failure to stderr, as the logger cannot log its own.
+func (s *httpShipper) report(err error) {
+	if s.failing {
+		return
+	}
+	s.failing = true
+	fmt.Fprintf(os.Stderr, "log shipping failed, events are being dropped: %v\n", err)
+}
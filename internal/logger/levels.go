@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// componentRule sets the level of the components whose name matches pattern.
+type componentRule struct {
+	pattern string
+	level   zerolog.Level
+}
+
+// ParseComponentLevels parses comma-separated component=level overrides. A component
+// may be a glob pattern (path.Match syntax), e.g. "*_worker=debug,ws_handler=warn";
+// the first matching rule wins.
+func ParseComponentLevels(s string) ([]componentRule, error) {
+	var rules []componentRule
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		pattern, levelStr, ok := strings.Cut(part, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("%q is not component=level", part)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%q: %w", pattern, err)
+		}
+		level, err := zerolog.ParseLevel(strings.TrimSpace(levelStr))
+		if err != nil || level == zerolog.NoLevel {
+			return nil, fmt.Errorf("%q is not a log level", levelStr)
+		}
+		rules = append(rules, componentRule{pattern: pattern, level: level})
+	}
+	return rules, nil
+}
+
+// minLevel returns the most verbose of base and the rules' levels.
+func minLevel(base zerolog.Level, rules []componentRule) zerolog.Level {
+	for _, r := range rules {
+		base = min(base, r.level)
+	}
+	return base
+}
+
+// componentMarker precedes the component name in an encoded event.
+var componentMarker = []byte(`"component":"`)
+
+// componentFilter drops the events below the level of their component: the level of
+// the first matching rule, or base. The component is read from the encoded event,
+// as loggers carry it as a field rather than as state of their own.
+type componentFilter struct {
+	out   zerolog.LevelWriter
+	base  zerolog.Level
+	rules []componentRule
+}
+
+func (f *componentFilter) Write(p []byte) (int, error) {
+	return f.out.Write(p)
+}
+
+func (f *componentFilter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	if l != zerolog.NoLevel && l < f.levelOf(p) {
+		return len(p), nil
+	}
+	return f.out.WriteLevel(l, p)
+}
+
+func (f *componentFilter) levelOf(p []byte) zerolog.Level {
+	if len(f.rules) == 0 {
+		return f.base
+	}
+	i := bytes.Index(p, componentMarker)
+	if i < 0 {
+		return f.base
+	}
+	name := p[i+len(componentMarker):]
+	if j := bytes.IndexByte(name, '"'); j >= 0 {
+		name = name[:j]
+	}
+	for _, r := range f.rules {
+		if ok, _ := path.Match(r.pattern, string(name)); ok {
+			return r.level
+		}
+	}
+	return f.base
+}
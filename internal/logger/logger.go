@@ -8,29 +8,99 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// Options configures the logger: its level, the stdout format, optional sinks the
+// same events are written to as JSON, and level overrides per component.
+type Options struct {
+	Level  string // trace, debug, info, warn, error, fatal, panic
+	Format string // "json" for production, "pretty" for human-readable dev output
+
+	// ComponentLevels overrides Level for the loggers of matching components, e.g.
+	// "*_worker=debug,*_handler=info". See ParseComponentLevels.
+	ComponentLevels string
+
+	// File, when set, also writes to a file rotated at FileMaxSizeMB, keeping
+	// FileMaxBackups rotated files.
+	File           string
+	FileMaxSizeMB  int
+	FileMaxBackups int
+
+	// Syslog, when set, also writes to syslog: "local" for the local daemon, or
+	// "udp://host:514" / "tcp://host:514" for a remote one.
+	Syslog    string
+	SyslogTag string
+
+	// HTTPURL, when set, also ships events in batches to a log store. HTTPFormat is
+	// "loki" (URL of the push API) or "elasticsearch" (URL of an index's _bulk API);
+	// HTTPAuth is sent as the Authorization header. Instance labels the events.
+	HTTPURL    string
+	HTTPFormat string
+	HTTPAuth   string
+	Instance   string
+}
+
 // Setup initializes the global zerolog logger based on environment configuration.
 //   - level: log level string (trace, debug, info, warn, error, fatal, panic)
 //   - format: "json" for production, "pretty" for human-readable dev output
 //
 // Returns the configured logger instance.
 func Setup(level, format string) zerolog.Logger {
-	var writer io.Writer
+	log, _ := New(Options{Level: level, Format: format})
+	return log
+}
 
-	if format == "pretty" {
-		writer = zerolog.ConsoleWriter{
+// New initializes the global zerolog logger with the sinks of opts. Sinks that cannot
+// be opened are left out with a warning. The returned function flushes and closes
+// the sinks; call it before exiting.
+func New(opts Options) (zerolog.Logger, func()) {
+	var stdout io.Writer = os.Stdout
+	if opts.Format == "pretty" {
+		stdout = zerolog.ConsoleWriter{
 			Out:        os.Stdout,
 			TimeFormat: time.RFC3339,
 		}
-	} else {
-		writer = os.Stdout
 	}
 
-	lvl, err := zerolog.ParseLevel(level)
-	if err != nil {
+	lvl, err := zerolog.ParseLevel(opts.Level)
+	if err != nil || lvl == zerolog.NoLevel {
 		lvl = zerolog.InfoLevel
 	}
+	rules, rulesErr := ParseComponentLevels(opts.ComponentLevels)
 
-	zerolog.SetGlobalLevel(lvl)
+	writers := []io.Writer{stdout}
+	var closers []io.Closer
+	var sinkErrs []error
+	if opts.File != "" {
+		f, err := newRotatingFile(opts.File, int64(opts.FileMaxSizeMB)<<20, opts.FileMaxBackups)
+		if err != nil {
+			sinkErrs = append(sinkErrs, err)
+		} else {
+			writers = append(writers, f)
+			closers = append(closers, f)
+		}
+	}
+	if opts.Syslog != "" {
+		w, err := newSyslogWriter(opts.Syslog, opts.SyslogTag)
+		if err != nil {
+			sinkErrs = append(sinkErrs, err)
+		} else {
+			writers = append(writers, w)
+			closers = append(closers, w)
+		}
+	}
+	if opts.HTTPURL != "" {
+		s, err := newHTTPShipper(opts.HTTPURL, opts.HTTPFormat, opts.HTTPAuth, opts.Instance)
+		if err != nil {
+			sinkErrs = append(sinkErrs, err)
+		} else {
+			writers = append(writers, s)
+			closers = append(closers, s)
+		}
+	}
+
+	// Events are built down to the most verbose level any component asks for; the
+	// filter then drops those below their component's level.
+	zerolog.SetGlobalLevel(minLevel(lvl, rules))
+	writer := &componentFilter{out: zerolog.MultiLevelWriter(writers...), base: lvl, rules: rules}
 
 	log := zerolog.New(writer).
 		With().
@@ -38,5 +108,16 @@ func Setup(level, format string) zerolog.Logger {
 		Caller().
 		Logger()
 
-	return log
+	if rulesErr != nil {
+		log.Warn().Err(rulesErr).Msg("Component log levels ignored")
+	}
+	for _, err := range sinkErrs {
+		log.Warn().Err(err).Msg("Log sink not opened")
+	}
+
+	return log, func() {
+		for _, c := range closers {
+			_ = c.Close()
+		}
+	}
 }
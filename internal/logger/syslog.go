@@ -0,0 +1,41 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// syslogWriter writes events to syslog at the priority matching their level.
+type syslogWriter struct {
+	zerolog.LevelWriter
+	w *syslog.Writer
+}
+
+// newSyslogWriter connects to the local syslog daemon ("local") or to a remote one
+// ("udp://host:514", "tcp://host:514").
+func newSyslogWriter(addr, tag string) (io.WriteCloser, error) {
+	if tag == "" {
+		tag = "exstem"
+	}
+	network, raddr := "", ""
+	if addr != "local" {
+		var ok bool
+		network, raddr, ok = strings.Cut(addr, "://")
+		if !ok || (network != "udp" && network != "tcp") || raddr == "" {
+			return nil, fmt.Errorf("syslog: %q is not local, udp://host:port or tcp://host:port", addr)
+		}
+	}
+	w, err := syslog.Dial(network, raddr, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, fmt.Errorf("syslog: %w", err)
+	}
+	return &syslogWriter{LevelWriter: zerolog.SyslogLevelWriter(w), w: w}, nil
+}
+
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
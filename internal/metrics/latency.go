@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	latencyWindow      = 1000 // samples kept per tracker
+	latencySampleEvery = 5    // one observation in this many is sampled
+)
+
+// Autosave latencies: AutosaveAck runs from receiving an answer on the exam socket to
+// Redis acknowledging it, AutosaveFlush from saving it to the worker writing it to
+// PostgreSQL.
+var (
+	AutosaveAck   = NewLatency()
+	AutosaveFlush = NewLatency()
+)
+
+var autosaveSLOBreached atomic.Bool
+
+// SetAutosaveSLOBreached records whether the autosave flush lag is above its SLO and
+// reports whether that changed.
+func SetAutosaveSLOBreached(breached bool) bool {
+	return autosaveSLOBreached.Swap(breached) != breached
+}
+
+// AutosaveSLOBreached reports whether the autosave flush lag was above its SLO at the
+// last flush.
+func AutosaveSLOBreached() bool {
+	return autosaveSLOBreached.Load()
+}
+
+// Latency keeps a sample of recent durations and reports their percentiles.
+type Latency struct {
+	mu      sync.Mutex
+	seen    uint64
+	samples []time.Duration
+	next    int
+}
+
+// Percentiles summarises the samples of a Latency.
+type Percentiles struct {
+	Samples int           `json:"samples"`
+	P50     time.Duration `json:"p50_ns"`
+	P95     time.Duration `json:"p95_ns"`
+	P99     time.Duration `json:"p99_ns"`
+}
+
+func NewLatency() *Latency {
+	return &Latency{samples: make([]time.Duration, 0, latencyWindow)}
+}
+
+// Observe samples d, replacing the oldest sample once the window is full.
+func (l *Latency) Observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seen++
+	if l.seen%latencySampleEvery != 1 {
+		return
+	}
+	if len(l.samples) < latencyWindow {
+		l.samples = append(l.samples, d)
+		return
+	}
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % latencyWindow
+}
+
+// Percentiles returns the p50, p95 and p99 of the current samples.
+func (l *Latency) Percentiles() Percentiles {
+	l.mu.Lock()
+	sorted := slices.Clone(l.samples)
+	l.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return Percentiles{}
+	}
+	slices.Sort(sorted)
+	at := func(q float64) time.Duration {
+		return sorted[int(q*float64(len(sorted)-1))]
+	}
+	return Percentiles{Samples: len(sorted), P50: at(0.50), P95: at(0.95), P99: at(0.99)}
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Logins counts the login attempts this instance checked since startup, by account
+// kind and outcome, and the login anomalies it raised.
+var Logins = &LoginCounter{attempts: make(map[LoginOutcome]int64)}
+
+// LoginOutcome is the account kind and result of a login attempt.
+type LoginOutcome struct {
+	Kind    string
+	Success bool
+}
+
+// LoginCounter counts login attempts and anomalies.
+type LoginCounter struct {
+	mu        sync.Mutex
+	attempts  map[LoginOutcome]int64
+	anomalies atomic.Int64
+}
+
+// Observe counts a login attempt to an account of kind.
+func (l *LoginCounter) Observe(kind string, success bool) {
+	l.mu.Lock()
+	l.attempts[LoginOutcome{Kind: kind, Success: success}]++
+	l.mu.Unlock()
+}
+
+// ObserveAnomaly counts a login anomaly.
+func (l *LoginCounter) ObserveAnomaly() {
+	l.anomalies.Add(1)
+}
+
+// LoginCount is the number of login attempts with an outcome.
+type LoginCount struct {
+	LoginOutcome
+	Count int64
+}
+
+// Attempts returns the attempt counts, ordered by kind with failures first.
+func (l *LoginCounter) Attempts() []LoginCount {
+	l.mu.Lock()
+	counts := make([]LoginCount, 0, len(l.attempts))
+	for outcome, n := range l.attempts {
+		counts = append(counts, LoginCount{LoginOutcome: outcome, Count: n})
+	}
+	l.mu.Unlock()
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Kind != counts[j].Kind {
+			return counts[i].Kind < counts[j].Kind
+		}
+		return !counts[i].Success && counts[j].Success
+	})
+	return counts
+}
+
+// Anomalies returns the number of login anomalies raised.
+func (l *LoginCounter) Anomalies() int64 {
+	return l.anomalies.Load()
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import "sync/atomic"
+
+// Requests counts the API requests this instance answered since they were last taken,
+// and how many of them failed with a 5xx status.
+var Requests = &RequestCounter{}
+
+// RequestCounter counts requests and server errors until they are taken.
+type RequestCounter struct {
+	total  atomic.Int64
+	failed atomic.Int64
+}
+
+// Observe counts a request answered with status.
+func (r *RequestCounter) Observe(status int) {
+	r.total.Add(1)
+	if status >= 500 {
+		r.failed.Add(1)
+	}
+}
+
+// Take returns the counts and resets them.
+func (r *RequestCounter) Take() (total, failed int64) {
+	return r.total.Swap(0), r.failed.Swap(0)
+}
+
+// Restore adds back counts that were taken but could not be reported.
+func (r *RequestCounter) Restore(total, failed int64) {
+	r.total.Add(total)
+	r.failed.Add(failed)
+}
@@ -135,6 +135,16 @@ func shouldSkip(c *gin.Context) bool {
 	if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
 		return true
 	}
+	// Range requests (audio/video seeking) must return the exact byte span
+	// with a valid Content-Range — compressing the 206 body corrupts it
+	if c.GetHeader("Range") != "" {
+		return true
+	}
+	// Uploaded media is already compressed; re-encoding it only burns CPU
+	// and strips Content-Length, which media players rely on
+	if strings.HasPrefix(c.Request.URL.Path, "/uploads/") {
+		return true
+	}
 	return false
 }
 
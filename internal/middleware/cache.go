@@ -7,9 +7,22 @@ import (
 )
 
 // CacheControl sets the Cache-Control header for responses, usually static assets.
-func CacheControl(maxAgeSeconds int) gin.HandlerFunc {
+// Immutable assets, whose URL changes with their content, are never revalidated.
+func CacheControl(maxAgeSeconds int, immutable bool) gin.HandlerFunc {
+	value := fmt.Sprintf("public, max-age=%d", maxAgeSeconds)
+	if immutable {
+		value += ", immutable"
+	}
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", value)
+		c.Next()
+	}
+}
+
+// AcceptRanges advertises byte-range support so media players request partial content.
+func AcceptRanges() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAgeSeconds))
+		c.Header("Accept-Ranges", "bytes")
 		c.Next()
 	}
 }
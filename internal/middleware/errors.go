@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stemsi/exstem-backend/internal/helper"
+	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/stemsi/exstem-backend/internal/response"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+// domainError is how a service or repository error is reported to clients. With a
+// field set it is reported as a validation failure of that request field, described
+// by message or, without one, by the error itself.
+type domainError struct {
+	err     error
+	status  int
+	code    response.ErrCode
+	field   string
+	message string
+}
+
+// domainErrors translates the sentinel errors of the services and repositories. The
+// first entry matching with errors.Is wins.
+var domainErrors = []domainError{
+	// ─── Resources ─────────────────────────────────────────────────────
+	{err: pgx.ErrNoRows, status: http.StatusNotFound, code: response.ErrNotFound},
+	{err: repository.ErrDuplicateNISN, status: http.StatusConflict, code: response.ErrConflict},
+
+	// ─── Authentication ────────────────────────────────────────────────
+	{err: service.ErrSessionAlreadyActive, status: http.StatusConflict, code: response.ErrSessionActive},
+	{err: service.ErrPasswordChangeRequired, status: http.StatusForbidden, code: response.ErrPasswordChange},
+	{err: service.ErrWeakPassword, field: "password"},
+	{err: service.ErrOIDCNotConfigured, status: http.StatusNotFound, code: response.ErrSSONotConfigured},
+	{err: service.ErrOIDCStateInvalid, status: http.StatusBadRequest, code: response.ErrSSOStateInvalid},
+	{err: service.ErrOIDCTokenInvalid, status: http.StatusUnauthorized, code: response.ErrSSOFailed},
+	{err: service.ErrOIDCUnverified, status: http.StatusForbidden, code: response.ErrSSOAccountUnlinked},
+	{err: service.ErrOIDCNotLinked, status: http.StatusForbidden, code: response.ErrSSOAccountUnlinked},
+
+	// ─── Admins & Roles ────────────────────────────────────────────────
+	{err: service.ErrAdminNotFound, status: http.StatusNotFound, code: response.ErrNotFound},
+	{err: service.ErrAdminExists, status: http.StatusConflict, code: response.ErrEmailExists},
+	{err: service.ErrSystemRole, status: http.StatusForbidden, code: response.ErrActionForbidden},
+	{err: service.ErrInvalidScope, status: http.StatusBadRequest, code: response.ErrInvalidPayload},
+	{err: service.ErrSubjectOutOfScope, status: http.StatusForbidden, code: response.ErrSubjectOutOfScope},
+
+	// ─── Exams ─────────────────────────────────────────────────────────
+	{err: service.ErrNoQuestions, status: http.StatusBadRequest, code: response.ErrNoQuestions},
+	{err: service.ErrMediaAltMissing, status: http.StatusBadRequest, code: response.ErrMediaAltMissing},
+	{err: service.ErrQuestionCountTooHigh, field: "question_count"},
+	{err: service.ErrNoGradableQuestions, field: "qbank_id"},
+	{err: service.ErrInvalidExamMetadata, field: "metadata"},
+	{err: service.ErrInvalidLanguage, field: "languages"},
+	{err: service.ErrTranslationMissing, field: "languages"},
+	{err: service.ErrExamPayloadTooLarge, field: "qbank_id"},
+	{err: service.ErrInvalidPayloadLimit, field: "settings"},
+	{err: service.ErrInvalidMetadataSchema, field: "settings"},
+	{err: service.ErrInvalidRemoteConfig, field: "settings"},
+	{err: service.ErrExamNotDraft, status: http.StatusBadRequest, code: response.ErrExamNotDraft},
+	{err: service.ErrExamCacheBusy, status: http.StatusConflict, code: response.ErrConflict},
+	{err: service.ErrExamNotPublished, status: http.StatusBadRequest, code: response.ErrExamNotPublished},
+	{err: service.ErrExamNotCompleted, status: http.StatusBadRequest, code: response.ErrExamNotCompleted},
+	{err: service.ErrExamHasSessions, status: http.StatusConflict, code: response.ErrExamHasSessions},
+	{err: service.ErrDuplicateTarget, status: http.StatusConflict, code: response.ErrDuplicateTarget},
+	{err: service.ErrExamInOtherAssessment, status: http.StatusConflict, code: response.ErrConflict},
+	{err: service.ErrExamNotAvailable, status: http.StatusBadRequest, code: response.ErrExamNotAvailable},
+	{err: service.ErrInvalidEntryToken, status: http.StatusBadRequest, code: response.ErrInvalidEntryToken},
+	{err: service.ErrConsentRequired, status: http.StatusBadRequest, code: response.ErrConsentRequired},
+	{err: service.ErrResultNotAvailable, status: http.StatusForbidden, code: response.ErrResultNotAvailable},
+	{err: service.ErrPublicResultNotFound, status: http.StatusNotFound, code: response.ErrResultLookupFailed},
+	{err: service.ErrExamPaperNotFound, status: http.StatusNotFound, code: response.ErrNotFound},
+	{err: service.ErrExamPaperNotReady, status: http.StatusConflict, code: response.ErrExamPaperNotReady},
+	{err: service.ErrOfflineScoresFormat, status: http.StatusBadRequest, code: response.ErrUnsupportedFile},
+
+	// ─── Question Banks ────────────────────────────────────────────────
+	{err: service.ErrPassageNotFound, status: http.StatusNotFound, code: response.ErrNotFound},
+	{err: helper.ErrInvalidLatex, field: "math_latex"},
+	{err: service.ErrInvalidOptions, field: "options", message: "options must be valid JSON"},
+	{err: service.ErrInvalidOption, field: "options"},
+	{err: service.ErrOptionMediaNotFound, field: "options"},
+	{err: service.ErrInvalidTranslation, field: "translations"},
+	{err: service.ErrPassageNotInQBank, field: "passage_id", message: "passage must belong to this question bank"},
+	{err: service.ErrQuestionsNotInQBank, field: "question_ids", message: "every question must belong to this question bank and be listed once"},
+	{err: service.ErrIncompleteOrder, field: "question_ids", message: "order must list every question of the question bank once"},
+	{err: service.ErrSameQBank, field: "target_qbank_id", message: "target must be another question bank"},
+
+	// ─── Students & Guardians ──────────────────────────────────────────
+	{err: service.ErrDirectoryNotConfigured, status: http.StatusNotFound, code: response.ErrDirectoryNotConfigured},
+	{err: service.ErrDapodikFormat, status: http.StatusBadRequest, code: response.ErrUnsupportedFile},
+	{err: service.ErrGuardianContactRequired, field: "email", message: "email or phone is required"},
+	{err: service.ErrGuardianPhoneRequired, field: "notify_channel", message: "a phone number is required for whatsapp"},
+	{err: service.ErrNoDistribution, field: "distribution", message: "no distribution data available to export"},
+	{err: service.ErrUnknownMajor, field: "major_code", message: "major_code must be an existing major"},
+
+	// ─── Media ─────────────────────────────────────────────────────────
+	{err: service.ErrUnsupportedFileType, status: http.StatusBadRequest, code: response.ErrUnsupportedFile},
+	{err: service.ErrFileTooLarge, status: http.StatusBadRequest, code: response.ErrFileTooLarge},
+	{err: service.ErrInvalidDuration, field: "duration_seconds", message: "duration_seconds must not be negative"},
+	{err: service.ErrMediaInUse, status: http.StatusConflict, code: response.ErrDependencyExists},
+
+	// ─── Dashboard ─────────────────────────────────────────────────────
+	{err: service.ErrUnknownWidget, status: http.StatusNotFound, code: response.ErrNotFound},
+	{err: service.ErrInvalidLayout, field: "widgets"},
+
+	// ─── System ────────────────────────────────────────────────────────
+	{err: service.ErrInvalidMetricsRange, field: "range"},
+
+	// ─── Backups ───────────────────────────────────────────────────────
+	{err: service.ErrBackupNotFound, status: http.StatusNotFound, code: response.ErrNotFound},
+	{err: service.ErrBackupInProgress, status: http.StatusConflict, code: response.ErrBackupInProgress},
+	{err: service.ErrBackupSchemaMismatch, status: http.StatusConflict, code: response.ErrBackupSchemaMismatch},
+	{err: service.ErrRestoreExamsRunning, status: http.StatusConflict, code: response.ErrBackupExamsRunning},
+
+	// ─── Retention ─────────────────────────────────────────────────────
+	{err: service.ErrRetentionUnsupported, field: "action"},
+
+	// ─── Integrations ──────────────────────────────────────────────────
+	{err: service.ErrEraporNotConfigured, status: http.StatusNotFound, code: response.ErrEraporNotConfigured},
+	{err: service.ErrEraporNoScores, status: http.StatusNotFound, code: response.ErrEraporNoScores},
+	{err: service.ErrNotificationNotConfigured, status: http.StatusNotFound, code: response.ErrNotificationNotConfigured},
+	{err: service.ErrNotificationNoRecipients, status: http.StatusUnprocessableEntity, code: response.ErrNotificationNoRecipients},
+}
+
+// ErrorFallback, attached with SetMeta to an error recorded with c.Error, is the
+// response for the error when domainErrors does not map it, instead of 500. Handlers
+// of endpoints wrapping an upstream service use it to report upstream failures.
+type ErrorFallback struct {
+	Status int
+	Code   response.ErrCode
+}
+
+// ErrorMapper answers requests whose handler recorded an error with c.Error instead
+// of writing a response, translating the last error through domainErrors. Unmapped
+// errors are answered with their ErrorFallback or 500 INTERNAL_ERROR.
+func ErrorMapper() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		last := c.Errors.Last()
+		fallback, ok := last.Meta.(ErrorFallback)
+		if !ok {
+			fallback = ErrorFallback{Status: http.StatusInternalServerError, Code: response.ErrInternal}
+		}
+		failDomainError(c, last.Err, fallback)
+	}
+}
+
+func failDomainError(c *gin.Context, err error, fallback ErrorFallback) {
+	status, code, fields := resolveError(err, c.Request.Method, fallback)
+	if fields != nil {
+		response.FailWithFields(c, status, code, fields)
+		return
+	}
+	response.Fail(c, status, code)
+}
+
+// ErrorCode returns the status and code err is answered with when recorded with
+// c.Error, for endpoints that report errors per item instead of failing the request.
+// method is the HTTP method the failed operation stands for, as deletes report foreign
+// key violations differently. Validation failures are reported as 400 VALIDATION_ERROR.
+func ErrorCode(err error, method string) (int, response.ErrCode) {
+	status, code, _ := resolveError(err, method, ErrorFallback{Status: http.StatusInternalServerError, Code: response.ErrInternal})
+	return status, code
+}
+
+// resolveError translates err through domainErrors and the PostgreSQL error codes,
+// returning the fields in error for validation failures.
+func resolveError(err error, method string, fallback ErrorFallback) (int, response.ErrCode, map[string]string) {
+	for _, d := range domainErrors {
+		if !errors.Is(err, d.err) {
+			continue
+		}
+		if d.field != "" {
+			message := d.message
+			if message == "" {
+				message = err.Error()
+			}
+			return http.StatusBadRequest, response.ErrValidation, map[string]string{d.field: message}
+		}
+		return d.status, d.code, nil
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique violation
+			return http.StatusConflict, response.ErrConflict, nil
+		case "23503": // foreign key violation
+			// A delete hits rows still referencing the target; anything else references
+			// a row that does not exist.
+			if method == http.MethodDelete {
+				return http.StatusConflict, response.ErrDependencyExists, nil
+			}
+			return http.StatusNotFound, response.ErrNotFound, nil
+		case "57014": // query canceled, e.g. by statement_timeout
+			return http.StatusGatewayTimeout, response.ErrRequestTimeout, nil
+		}
+	}
+
+	return fallback.Status, fallback.Code, nil
+}
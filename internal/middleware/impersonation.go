@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/response"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+// AuditImpersonation records every request made with an impersonation token in the
+// audit log and keeps such tokens read-only, so support staff cannot join exams or
+// end the student's session on their behalf. Other tokens pass through untouched.
+func AuditImpersonation(auditService *service.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := GetClaims(c)
+		if claims == nil || !claims.IsImpersonation() {
+			c.Next()
+			return
+		}
+
+		readOnly := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+		if !readOnly {
+			response.AbortFail(c, http.StatusForbidden, response.ErrImpersonationReadOnly)
+		} else {
+			c.Next()
+		}
+
+		_ = auditService.Record(c.Request.Context(), claims.ImpersonatorID,
+			model.AuditActionImpersonationRequest, "student", claims.Subject, c.ClientIP(),
+			gin.H{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+				"status": c.Writer.Status(),
+				"jti":    claims.ID,
+			})
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -48,6 +50,48 @@ func RequireAdminJWT(authService *service.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		// A Redis outage is answered 503, so clients retry instead of logging the admin out.
+		if err := authService.ValidateAdminSession(c.Request.Context(), claims.UserID, claims.ID); err != nil {
+			if errors.Is(err, service.ErrSessionCheckUnavailable) {
+				response.AbortFail(c, http.StatusServiceUnavailable, response.ErrSessionCheckUnavailable)
+				return
+			}
+			response.AbortFail(c, http.StatusUnauthorized, response.ErrSessionInvalidated)
+			return
+		}
+
+		c.Set(ContextKeyClaims, claims)
+		if len(claims.SubjectScopes) > 0 {
+			c.Request = c.Request.WithContext(service.WithSubjectScopes(c.Request.Context(), claims.SubjectScopes))
+		}
+		c.Next()
+	}
+}
+
+// RequireGuardianJWT validates a guardian JWT from the Authorization header.
+func RequireGuardianJWT(authService *service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := extractAndValidateClaims(c, authService)
+		if err != nil {
+			response.AbortFail(c, http.StatusUnauthorized, response.ErrTokenInvalid)
+			return
+		}
+
+		if claims.TokenType != service.TokenTypeGuardian {
+			response.AbortFail(c, http.StatusForbidden, response.ErrGuardianAccessOnly)
+			return
+		}
+
+		// As for admins, a Redis outage is answered 503 rather than logging the guardian out.
+		if err := authService.ValidateGuardianSession(c.Request.Context(), claims.UserID, claims.ID); err != nil {
+			if errors.Is(err, service.ErrSessionCheckUnavailable) {
+				response.AbortFail(c, http.StatusServiceUnavailable, response.ErrSessionCheckUnavailable)
+				return
+			}
+			response.AbortFail(c, http.StatusUnauthorized, response.ErrSessionInvalidated)
+			return
+		}
+
 		c.Set(ContextKeyClaims, claims)
 		c.Next()
 	}
@@ -74,11 +118,31 @@ func RequireStudentWSAuth(authService *service.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		// The exam stream saves answers and submits, so impersonation stops at the paper view.
+		if claims.IsImpersonation() {
+			response.AbortFail(c, http.StatusForbidden, response.ErrImpersonationReadOnly)
+			return
+		}
+
 		c.Set(ContextKeyClaims, claims)
 		c.Next()
 	}
 }
 
+// RequireStaticToken admits requests bearing token in the Authorization header.
+// Used for machine clients such as the Prometheus scraper.
+func RequireStaticToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, tokenStr, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "bearer") ||
+			subtle.ConstantTimeCompare([]byte(tokenStr), []byte(token)) != 1 {
+			response.AbortFail(c, http.StatusUnauthorized, response.ErrTokenInvalid)
+			return
+		}
+		c.Next()
+	}
+}
+
 // GetClaims retrieves the JWT claims from the Gin context.
 func GetClaims(c *gin.Context) *service.Claims {
 	val, exists := c.Get(ContextKeyClaims)
@@ -48,3 +48,18 @@ func RequireAnyPermission(codes ...string) gin.HandlerFunc {
 		response.AbortFail(c, http.StatusForbidden, response.ErrPermissionDenied)
 	}
 }
+
+// HasPermission reports whether the admin JWT of the request contains the permission
+// code, for handlers that show more or less rather than refusing.
+func HasPermission(c *gin.Context, permissionCode string) bool {
+	claims := GetClaims(c)
+	if claims == nil {
+		return false
+	}
+	for _, p := range claims.Permissions {
+		if p == permissionCode {
+			return true
+		}
+	}
+	return false
+}
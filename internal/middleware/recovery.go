@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/response"
+)
+
+// panics counts the handler panics recovered since the server started.
+var panics atomic.Int64
+
+// PanicCount returns the number of handler panics recovered since the server started.
+func PanicCount() int64 {
+	return panics.Load()
+}
+
+// Recovery turns a panicking request into a 500 INTERNAL_ERROR in the standard error
+// envelope and logs the panic value and stack trace with the request ID, replacing
+// gin's plain-text recovery.
+func Recovery(log zerolog.Logger) gin.HandlerFunc {
+	log = log.With().Str("component", "recovery").Logger()
+
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// http.ErrAbortHandler deliberately aborts the response; let net/http handle it.
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			reqID, _ := c.Get(response.ContextKeyRequestID)
+			err, ok := rec.(error)
+			if !ok {
+				err = fmt.Errorf("%v", rec)
+			}
+
+			// A client that hung up cannot be answered.
+			if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+				log.Warn().Err(err).Interface("request_id", reqID).
+					Str("method", c.Request.Method).Str("path", c.Request.URL.Path).
+					Msg("Connection lost while writing response")
+				c.Abort()
+				return
+			}
+
+			panics.Add(1)
+			log.Error().Err(err).Interface("request_id", reqID).
+				Str("method", c.Request.Method).Str("path", c.Request.URL.Path).
+				Bytes("stack", debug.Stack()).
+				Msg("Recovered from panic")
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			response.AbortFail(c, http.StatusInternalServerError, response.ErrInternal)
+		}()
+
+		c.Next()
+	}
+}
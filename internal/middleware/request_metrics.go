@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stemsi/exstem-backend/internal/metrics"
+)
+
+// CountRequests counts every answered request and its status in metrics.Requests. It
+// must run outside Recovery to see the 500 of a recovered panic.
+func CountRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		metrics.Requests.Observe(c.Writer.Status())
+	}
+}
@@ -24,6 +24,16 @@ func CheckSingleDeviceSession(authService *service.AuthService) gin.HandlerFunc
 			return
 		}
 
+		// Impersonation tokens run alongside the student's own session.
+		if claims.IsImpersonation() {
+			if err := authService.ValidateImpersonationSession(c.Request.Context(), claims.ID); err != nil {
+				response.AbortFail(c, http.StatusUnauthorized, response.ErrSessionInvalidated)
+				return
+			}
+			c.Next()
+			return
+		}
+
 		if err := authService.ValidateStudentSession(c.Request.Context(), claims.UserID, claims.ID); err != nil {
 			response.AbortFail(c, http.StatusUnauthorized, response.ErrSessionInvalidated)
 			return
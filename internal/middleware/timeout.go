@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stemsi/exstem-backend/internal/response"
+)
+
+// contextKeyUntimedCtx holds the request context as it was before any Timeout.
+const contextKeyUntimedCtx = "untimed_ctx"
+
+// Timeout bounds the request context to d, so queries and calls made with it are
+// cancelled once the request runs too long. A Timeout on a route replaces the one of
+// its group rather than nesting in it, keeping the values set since, such as the
+// admin's subject scopes; d <= 0 lifts the deadline. A handler that fails because of
+// the deadline is answered with 504 REQUEST_TIMEOUT.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		untimed := c.Request.Context()
+		if v, ok := c.Get(contextKeyUntimedCtx); ok {
+			untimed = v.(context.Context)
+		} else {
+			c.Set(contextKeyUntimedCtx, untimed)
+		}
+
+		// Drop the group's deadline but not what was stored in the context since; the
+		// request still ends when the client goes away.
+		parent := context.WithoutCancel(c.Request.Context())
+		var ctx context.Context
+		var cancel context.CancelFunc
+		if d <= 0 {
+			ctx, cancel = context.WithCancel(parent)
+		} else {
+			ctx, cancel = context.WithTimeout(parent, d)
+		}
+		defer cancel()
+		stop := context.AfterFunc(untimed, cancel)
+		defer stop()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if d > 0 && !c.Writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			response.Fail(c, http.StatusGatewayTimeout, response.ErrRequestTimeout)
+		}
+	}
+}
+
+// NoTimeout lifts the group's Timeout for long-lived routes such as SSE streams.
+func NoTimeout() gin.HandlerFunc {
+	return Timeout(0)
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// FontSize is a student's preferred text size in the exam UI.
+type FontSize string
+
+const (
+	FontSizeNormal FontSize = "NORMAL"
+	FontSizeLarge  FontSize = "LARGE"
+	FontSizeXLarge FontSize = "XLARGE"
+)
+
+// AccessibilitySettings are a student's accessibility preferences. ScreenReader asks
+// the exam UI to favour assistive-technology friendly rendering, such as announcing
+// the media hints of each question.
+type AccessibilitySettings struct {
+	StudentID    int        `json:"student_id"`
+	FontSize     FontSize   `json:"font_size"`
+	HighContrast bool       `json:"high_contrast"`
+	ScreenReader bool       `json:"screen_reader"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+// UpdateAccessibilitySettingsRequest is the payload for a student's accessibility preferences.
+type UpdateAccessibilitySettingsRequest struct {
+	FontSize     FontSize `json:"font_size" binding:"required,oneof=NORMAL LARGE XLARGE"`
+	HighContrast bool     `json:"high_contrast"`
+	ScreenReader bool     `json:"screen_reader"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActivityReport summarises the exams held in a period, as accreditation evidence of
+// the school's computer-based testing: how many exams and participants, how often
+// integrity incidents (cheat events) were recorded, and the server's uptime.
+type ActivityReport struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	ExamsHeld           int     `json:"exams_held"`
+	Participants        int     `json:"participants"` // distinct students
+	Sessions            int     `json:"sessions"`
+	CompletedSessions   int     `json:"completed_sessions"`
+	Incidents           int     `json:"incidents"`
+	FlaggedSessions     int     `json:"flagged_sessions"`
+	IncidentsPerExam    float64 `json:"incidents_per_exam"`
+	IncidentsPerSession float64 `json:"incidents_per_session"`
+
+	// UptimePercent is the share of the period the server was up, from the metrics
+	// history; null when the history has no samples in the period.
+	UptimePercent *float64 `json:"uptime_percent"`
+
+	Exams []ActivityReportExam `json:"exams"`
+}
+
+// ActivityReportExam is an exam held in the period of an activity report.
+type ActivityReportExam struct {
+	ExamID       uuid.UUID `json:"exam_id"`
+	Title        string    `json:"title"`
+	HeldAt       time.Time `json:"held_at"` // first session start
+	Participants int       `json:"participants"`
+	Completed    int       `json:"completed"`
+	Incidents    int       `json:"incidents"`
+	AverageScore *float64  `json:"average_score"`
+}
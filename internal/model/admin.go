@@ -15,6 +15,16 @@ type Admin struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// AdminSession is one active admin login, tracked by its token ID.
+type AdminSession struct {
+	JTI       string    `json:"jti"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Current   bool      `json:"current"`
+}
+
 // AdminLoginRequest is the payload for admin authentication.
 type AdminLoginRequest struct {
 	Identifier string `json:"identifier" binding:"required,max=255"`
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Assessment groups exams given together, such as one exam per subject in final
+// exams week. Its target rules are applied to each of its exams, its results are
+// reported together, and students see its exams as one section of their lobby.
+type Assessment struct {
+	ID          uuid.UUID              `json:"id"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	TargetRules []AddTargetRuleRequest `json:"target_rules"`
+	AuthorID    *int                   `json:"author_id"`
+	ExamCount   int                    `json:"exam_count"`
+	Exams       []AssessmentExam       `json:"exams,omitempty"` // only on the detail
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// AssessmentExam is an exam of an assessment.
+type AssessmentExam struct {
+	ID             uuid.UUID  `json:"id"`
+	Title          string     `json:"title"`
+	ScheduledStart *LocalTime `json:"scheduled_start,omitempty"`
+	Status         ExamStatus `json:"status"`
+}
+
+// CreateAssessmentRequest is the payload for creating an assessment.
+type CreateAssessmentRequest struct {
+	Title       string `json:"title" binding:"required,min=3,max=255"`
+	Description string `json:"description" binding:"omitempty,max=5000"`
+}
+
+// UpdateAssessmentRequest is the payload for updating an assessment.
+type UpdateAssessmentRequest struct {
+	Title       string `json:"title" binding:"required,min=3,max=255"`
+	Description string `json:"description" binding:"omitempty,max=5000"`
+}
+
+// SetAssessmentExamsRequest lists the exams of an assessment, replacing the ones it
+// had.
+type SetAssessmentExamsRequest struct {
+	ExamIDs []uuid.UUID `json:"exam_ids" binding:"max=50"`
+}
+
+// SetAssessmentTargetRulesRequest lists the target rules of an assessment, replacing
+// those of each of its exams.
+type SetAssessmentTargetRulesRequest struct {
+	Rules []AddTargetRuleRequest `json:"rules" binding:"max=50"`
+}
+
+// AssessmentReport is the combined result of an assessment's exams: one row per
+// student who joined any of them, with a score per exam.
+type AssessmentReport struct {
+	AssessmentID uuid.UUID             `json:"assessment_id"`
+	Title        string                `json:"title"`
+	Exams        []AssessmentExam      `json:"exams"`
+	Students     []AssessmentReportRow `json:"students"`
+}
+
+// AssessmentReportRow is a student's results across an assessment. Scores follow the
+// order of the report's exams; a score is null until the exam is completed.
+type AssessmentReportRow struct {
+	StudentID int        `json:"student_id"`
+	NISN      string     `json:"nisn"`
+	Name      string     `json:"name"`
+	ClassName string     `json:"class_name"`
+	Scores    []*float64 `json:"scores"`
+	Completed int        `json:"completed"`
+	Average   *float64   `json:"average"`
+}
+
+// AssessmentScore is a student's session in an exam of an assessment.
+type AssessmentScore struct {
+	StudentID  int
+	NISN       string
+	Name       string
+	ClassName  string
+	ExamID     uuid.UUID
+	Status     SessionStatus
+	FinalScore *float64
+}
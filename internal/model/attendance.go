@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attendance sheet groupings.
+const (
+	AttendanceByClass = "class"
+	AttendanceByRoom  = "room"
+)
+
+// AttendanceRow is one student on an exam's attendance sheet: a student the exam
+// targets, or one who joined it. SessionStatus is nil for students who did not join;
+// OfflineEntry marks a session entered by hand from a paper exam.
+type AttendanceRow struct {
+	StudentID     int            `json:"student_id"`
+	NISN          string         `json:"nisn"`
+	NIS           string         `json:"nis"`
+	Name          string         `json:"name"`
+	ClassName     string         `json:"class_name"`
+	RoomName      string         `json:"room_name"`
+	SeatNumber    int            `json:"seat_number"`
+	SessionStatus *SessionStatus `json:"session_status"`
+	StartedAt     *time.Time     `json:"started_at"`
+	OfflineEntry  bool           `json:"offline_entry"`
+}
+
+// AttendanceGroup is the students of one class or room, each printed on its own sheet.
+type AttendanceGroup struct {
+	Name string          `json:"name"`
+	Rows []AttendanceRow `json:"rows"`
+}
+
+// ExamAttendance is the attendance sheet of an exam.
+type ExamAttendance struct {
+	ExamID         uuid.UUID         `json:"exam_id"`
+	Title          string            `json:"title"`
+	ScheduledStart *LocalTime        `json:"scheduled_start"`
+	GroupBy        string            `json:"group_by"`
+	Groups         []AttendanceGroup `json:"groups"`
+
+	Metadata []ExamMetadataValue `json:"metadata"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit log actions.
+const (
+	AuditActionImpersonationStart   = "impersonation.start"
+	AuditActionImpersonationRequest = "impersonation.request"
+	AuditActionBackupCreate         = "backup.create"
+	AuditActionBackupRestore        = "backup.restore"
+	AuditActionRetentionPurge       = "retention.purge"
+	AuditActionStudentStatus        = "student.status"
+	AuditActionCredentialsIssue     = "student.credentials_issue"
+)
+
+// AuditLog is one entry of the audit trail of sensitive admin actions.
+type AuditLog struct {
+	ID         int64           `json:"id"`
+	AdminID    *int            `json:"admin_id,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Details    json.RawMessage `json:"details"`
+	IP         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Backup is a logical backup of the database kept in backup storage.
+type Backup struct {
+	Name string `json:"name"`
+	// SchemaVersion is the migration version of the database the backup was taken from.
+	SchemaVersion int       `json:"schema_version"`
+	Size          int64     `json:"size"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// RestoreBackupRequest confirms a restore by repeating the backup's name.
+type RestoreBackupRequest struct {
+	Confirm string `json:"confirm" binding:"required"`
+}
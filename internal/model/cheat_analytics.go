@@ -0,0 +1,43 @@
+package model
+
+// CheatAnalytics summarises the cheat events of the exam sessions started in a
+// period, by class, by repeat offender and by event type.
+type CheatAnalytics struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Sessions int    `json:"sessions"`
+	Events   int    `json:"events"`
+
+	ByClass   []ClassCheatRate    `json:"by_class"`
+	ByStudent []StudentCheatCount `json:"repeat_offenders"`
+	ByType    []CheatTypeCount    `json:"by_type"`
+}
+
+// ClassCheatRate is how often a class's sessions had cheat events. FlaggedRate is the
+// share of sessions with at least one event.
+type ClassCheatRate struct {
+	ClassID         int     `json:"class_id"`
+	ClassName       string  `json:"class_name"`
+	Sessions        int     `json:"sessions"`
+	FlaggedSessions int     `json:"flagged_sessions"`
+	Events          int     `json:"events"`
+	FlaggedRate     float64 `json:"flagged_rate"`
+}
+
+// StudentCheatCount is a student with cheat events in several exams.
+type StudentCheatCount struct {
+	StudentID    int    `json:"student_id"`
+	Name         string `json:"name"`
+	NISN         string `json:"nisn"`
+	ClassName    string `json:"class_name"`
+	Sessions     int    `json:"sessions"`
+	FlaggedExams int    `json:"flagged_exams"`
+	Events       int    `json:"events"`
+}
+
+// CheatTypeCount is how many events of a type were recorded, and for how many students.
+type CheatTypeCount struct {
+	Type     string `json:"type"`
+	Events   int    `json:"events"`
+	Students int    `json:"students"`
+}
@@ -10,4 +10,21 @@ type Class struct {
 	GroupNumber int       `json:"group_number"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// HomeroomAdminID is the class's homeroom teacher, if one is assigned.
+	HomeroomAdminID *int    `json:"homeroom_admin_id"`
+	HomeroomName    *string `json:"homeroom_name,omitempty"`
+}
+
+// BulkClassResult lists the classes a bulk generation created and the combinations
+// it skipped because the class already existed.
+type BulkClassResult struct {
+	Created []Class `json:"created"`
+	Skipped []Class `json:"skipped"`
+}
+
+// SetHomeroomRequest is the payload for assigning a class's homeroom teacher. A null
+// admin_id removes the assignment.
+type SetHomeroomRequest struct {
+	AdminID *int `json:"admin_id" binding:"omitempty,min=1"`
 }
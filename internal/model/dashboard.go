@@ -0,0 +1,81 @@
+package model
+
+import "time"
+
+// Dashboard widget IDs.
+const (
+	WidgetUpcomingExams  = "upcoming_exams"
+	WidgetRecentResults  = "recent_results"
+	WidgetQueueHealth    = "queue_health"
+	WidgetTopCheatAlerts = "top_cheat_alerts"
+)
+
+// DashboardGridColumns is the width of the dashboard grid widgets are placed on.
+const DashboardGridColumns = 12
+
+// DashboardWidget describes a widget admins can place on their dashboard.
+// SupportsRange is set when its data endpoint accepts from and to.
+type DashboardWidget struct {
+	ID            string `json:"id"`
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	DefaultWidth  int    `json:"default_width"`
+	DefaultHeight int    `json:"default_height"`
+	SupportsRange bool   `json:"supports_range"`
+}
+
+// DashboardWidgets is the catalog of available widgets, in their default order.
+var DashboardWidgets = []DashboardWidget{
+	{ID: WidgetUpcomingExams, Title: "Ujian Mendatang", Description: "Published exams scheduled in the range", DefaultWidth: 6, DefaultHeight: 4, SupportsRange: true},
+	{ID: WidgetRecentResults, Title: "Hasil Terbaru", Description: "Completed exams that ended in the range, with participants and average score", DefaultWidth: 6, DefaultHeight: 4, SupportsRange: true},
+	{ID: WidgetQueueHealth, Title: "Kesehatan Antrean", Description: "Worker queue lengths and Redis state", DefaultWidth: 4, DefaultHeight: 3},
+	{ID: WidgetTopCheatAlerts, Title: "Peringatan Kecurangan", Description: "Students with the most cheat events recorded in the range", DefaultWidth: 8, DefaultHeight: 3, SupportsRange: true},
+}
+
+// FindDashboardWidget returns the catalog entry of a widget.
+func FindDashboardWidget(id string) (DashboardWidget, bool) {
+	for _, w := range DashboardWidgets {
+		if w.ID == id {
+			return w, true
+		}
+	}
+	return DashboardWidget{}, false
+}
+
+// WidgetPlacement is where a widget sits on the dashboard grid.
+type WidgetPlacement struct {
+	ID     string `json:"id" binding:"required"`
+	X      int    `json:"x" binding:"min=0,max=11"`
+	Y      int    `json:"y" binding:"min=0"`
+	Width  int    `json:"width" binding:"required,min=1,max=12"`
+	Height int    `json:"height" binding:"required,min=1,max=12"`
+}
+
+// DashboardLayout is an admin's dashboard. Admins who never saved one get the default
+// layout, without UpdatedAt.
+type DashboardLayout struct {
+	Widgets   []WidgetPlacement `json:"widgets"`
+	UpdatedAt *time.Time        `json:"updated_at,omitempty"`
+}
+
+// UpdateDashboardLayoutRequest is the payload for saving an admin's dashboard layout.
+type UpdateDashboardLayoutRequest struct {
+	Widgets []WidgetPlacement `json:"widgets" binding:"required,max=20,dive"`
+}
+
+// QueueHealth is the state of the worker queues.
+type QueueHealth struct {
+	RedisDegraded bool             `json:"redis_degraded"`
+	Queues        map[string]int64 `json:"queues"`
+}
+
+// CheatAlert is a student's cheat events in one exam within a time range.
+type CheatAlert struct {
+	StudentID  int       `json:"student_id"`
+	Name       string    `json:"name"`
+	ClassName  string    `json:"class_name"`
+	ExamID     string    `json:"exam_id"`
+	ExamTitle  string    `json:"exam_title"`
+	Events     int       `json:"events"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EraporMapping ties an exam to the e-Rapor subject and competency (KD) it assesses.
+type EraporMapping struct {
+	ExamID         uuid.UUID `json:"exam_id"`
+	SubjectCode    string    `json:"subject_code"`
+	CompetencyCode string    `json:"competency_code"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// UpsertEraporMappingRequest is the payload for mapping an exam to e-Rapor.
+type UpsertEraporMappingRequest struct {
+	SubjectCode    string `json:"subject_code" binding:"required,max=50"`
+	CompetencyCode string `json:"competency_code" binding:"required,max=20"`
+}
+
+// EraporScore is one student's score for one competency of an e-Rapor subject.
+// When several completed exams map to the same competency their scores are averaged.
+type EraporScore struct {
+	StudentID      int     `json:"-"`
+	NIS            string  `json:"nis"`
+	NISN           string  `json:"nisn"`
+	Name           string  `json:"name"`
+	ClassName      string  `json:"class_name"`
+	CompetencyCode string  `json:"competency_code"`
+	Score          float64 `json:"score"`
+}
+
+// EraporPushResult summarizes a push of scores to the e-Rapor API.
+type EraporPushResult struct {
+	SubjectCode string `json:"subject_code"`
+	Pushed      int    `json:"pushed"`
+}
@@ -88,22 +88,96 @@ const (
 	ExamStatusArchived   ExamStatus = "ARCHIVED"
 )
 
+// IsLive reports whether students can take the exam, i.e. its caches must stay warm.
+func (s ExamStatus) IsLive() bool {
+	return s == ExamStatusPublished || s == ExamStatusInProgress
+}
+
+// NavigationPolicy controls how a student may move between an exam's questions.
+type NavigationPolicy string
+
+const (
+	// NavigationFree lets students answer questions in any order.
+	NavigationFree NavigationPolicy = "FREE"
+	// NavigationLinear forbids going back to an earlier question.
+	NavigationLinear NavigationPolicy = "LINEAR"
+	// NavigationSectionLocked splits the question order into sections of SectionSize
+	// questions; students move freely within a section but never back into an earlier one.
+	NavigationSectionLocked NavigationPolicy = "SECTION_LOCKED"
+)
+
+// NavigationRules is the Redis-cached navigation policy of an exam.
+type NavigationRules struct {
+	Policy      NavigationPolicy `json:"policy"`
+	SectionSize int              `json:"section_size,omitempty"`
+}
+
+// LockSize is the number of questions unlocked together: 1 for LINEAR, the section
+// size for SECTION_LOCKED, and 0 when navigation is not restricted.
+func (r NavigationRules) LockSize() int {
+	switch r.Policy {
+	case NavigationLinear:
+		return 1
+	case NavigationSectionLocked:
+		if r.SectionSize > 0 {
+			return r.SectionSize
+		}
+	}
+	return 0
+}
+
+// UnlockedFrom returns the first question position still answerable once a student
+// has reached furthest.
+func (r NavigationRules) UnlockedFrom(furthest int) int {
+	size := r.LockSize()
+	if size == 0 {
+		return 0
+	}
+	return furthest - furthest%size
+}
+
 // Exam represents an exam entity.
 type Exam struct {
-	ID                 uuid.UUID       `json:"id"`
-	Title              string          `json:"title"`
-	AuthorID           int             `json:"author_id"`
-	ScheduledStart     *LocalTime      `json:"scheduled_start,omitempty"`
-	ScheduledEnd       *LocalTime      `json:"scheduled_end,omitempty"`
-	DurationMinutes    int             `json:"duration_minutes"`
-	EntryToken         string          `json:"entry_token,omitempty"`
-	CheatRules         json.RawMessage `json:"cheat_rules"`
-	QuestionCount      int             `json:"question_count"`
-	RandomizeQuestions bool            `json:"randomize_questions"`
-	QBankID            *uuid.UUID      `json:"qbank_id,omitempty"`
-	Status             ExamStatus      `json:"status"`
-	CreatedAt          time.Time       `json:"created_at"`
-	UpdatedAt          time.Time       `json:"updated_at"`
+	ID                 uuid.UUID        `json:"id"`
+	Title              string           `json:"title"`
+	AuthorID           int              `json:"author_id"`
+	ScheduledStart     *LocalTime       `json:"scheduled_start,omitempty"`
+	ScheduledEnd       *LocalTime       `json:"scheduled_end,omitempty"`
+	DurationMinutes    int              `json:"duration_minutes"`
+	EntryToken         string           `json:"entry_token,omitempty"` // redacted for callers without exams:write
+	CheatRules         json.RawMessage  `json:"cheat_rules"`
+	QuestionCount      int              `json:"question_count"`
+	RandomizeQuestions bool             `json:"randomize_questions"`
+	QBankID            *uuid.UUID       `json:"qbank_id,omitempty"`
+	AssessmentID       *uuid.UUID       `json:"assessment_id,omitempty"`
+	ShowClassAverage   bool             `json:"show_class_average"`
+	AllowReview        bool             `json:"allow_review"`
+	PublicResults      bool             `json:"public_results"`
+	ResultsAccessCode  string           `json:"results_access_code,omitempty"` // redacted for callers without exams:write
+	NavigationPolicy   NavigationPolicy `json:"navigation_policy"`
+	SectionSize        int              `json:"section_size,omitempty"`
+	MaxBreaks          int              `json:"max_breaks"`
+	BreakMinutes       int              `json:"break_minutes"`
+	Instructions       string           `json:"instructions"`
+	HonorCode          string           `json:"honor_code"`
+	Status             ExamStatus       `json:"status"`
+	CreatedAt          time.Time        `json:"created_at"`
+	UpdatedAt          time.Time        `json:"updated_at"`
+
+	// DisconnectPauseMinutes pauses a student's timer once they have been disconnected
+	// this long, until they reconnect. Zero disables the pause.
+	DisconnectPauseMinutes int `json:"disconnect_pause_minutes"`
+
+	// Metadata holds the school's own fields of the exam, such as its curriculum code
+	// or semester, keyed as in the exam metadata schema.
+	Metadata map[string]any `json:"metadata"`
+
+	// Language is the language the questions are written in. Languages lists the
+	// translations students may choose from instead; every question needs them to
+	// publish. ForcedLanguage, when set, is served to every student.
+	Language       string   `json:"language"`
+	Languages      []string `json:"languages"`
+	ForcedLanguage string   `json:"forced_language,omitempty"`
 }
 
 // CreateExamRequest is the payload for creating a new exam.
@@ -113,6 +187,9 @@ type CreateExamRequest struct {
 	ScheduledEnd    *LocalTime `json:"scheduled_end" binding:"omitempty"` // gtfield handled in handler manually due to custom type
 	DurationMinutes int        `json:"duration_minutes" binding:"required,min=1,max=480"`
 	EntryToken      string     `json:"entry_token" binding:"omitempty,min=4,max=20"`
+	QBankID         *uuid.UUID `json:"qbank_id" binding:"omitempty"`
+
+	Metadata map[string]any `json:"metadata" binding:"omitempty"`
 }
 
 // ExamPayload is the Redis-cached payload sent to students (no correct answers).
@@ -121,25 +198,203 @@ type ExamPayload struct {
 	Title     string               `json:"title"`
 	Duration  int                  `json:"duration_minutes"`
 	Questions []QuestionForStudent `json:"questions"`
+	Passages  []PassageForStudent  `json:"passages,omitempty"`
+
+	// Language is the language of the questions. Languages lists those a student may
+	// ask for, the exam's own first; it is empty once the payload is localized to a
+	// forced language.
+	Language       string   `json:"language,omitempty"`
+	Languages      []string `json:"languages,omitempty"`
+	ForcedLanguage string   `json:"forced_language,omitempty"`
+}
+
+// ExamPreview is the author-facing preview of an exam as a student would see it.
+// AnswerKey is only present when the answer-key overlay is requested.
+type ExamPreview struct {
+	ExamPayload
+	Seed       int64             `json:"seed"`
+	CheatRules json.RawMessage   `json:"cheat_rules"`
+	AnswerKey  map[string]string `json:"answer_key,omitempty"`
+}
+
+// ValidationSeverity distinguishes blocking preflight failures from advisories.
+type ValidationSeverity string
+
+const (
+	ValidationError   ValidationSeverity = "error"
+	ValidationWarning ValidationSeverity = "warning"
+)
+
+// ExamValidationCheck is a single item in the publish preflight checklist.
+type ExamValidationCheck struct {
+	Key      string             `json:"key"`
+	Passed   bool               `json:"passed"`
+	Severity ValidationSeverity `json:"severity"`
+	Message  string             `json:"message"`
+}
+
+// ExamValidationResult is the publish preflight checklist for an exam.
+// Publishable is false when any error-severity check fails.
+type ExamValidationResult struct {
+	ExamID      uuid.UUID             `json:"exam_id"`
+	Publishable bool                  `json:"publishable"`
+	Checks      []ExamValidationCheck `json:"checks"`
+}
+
+// SimulateExamRequest is the payload for an exam dry run. Seed reproduces a run;
+// without it a random seed is used.
+type SimulateExamRequest struct {
+	Students int    `json:"students" binding:"required,min=1,max=5000"`
+	Seed     *int64 `json:"seed" binding:"omitempty"`
+}
+
+// ExamSimulation is the outcome of a dry run in which virtual students take an exam
+// with random answers. Checks lists the configuration problems found, failed ones only.
+type ExamSimulation struct {
+	ExamID              uuid.UUID             `json:"exam_id"`
+	Seed                int64                 `json:"seed"`
+	Students            int                   `json:"students"`
+	QuestionsPerStudent int                   `json:"questions_per_student"`
+	QuestionsAvailable  int                   `json:"questions_available"`
+	QuestionsNeverSeen  int                   `json:"questions_never_seen"`
+	AverageScore        float64               `json:"average_score"`
+	MedianScore         float64               `json:"median_score"`
+	MinScore            float64               `json:"min_score"`
+	MaxScore            float64               `json:"max_score"`
+	Distribution        []ScoreBucket         `json:"distribution"`
+	Checks              []ExamValidationCheck `json:"checks"`
+}
+
+// ScoreBucket counts the scores from From up to, but not including, To. The last
+// bucket includes 100.
+type ScoreBucket struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Count int `json:"count"`
+}
+
+// ExamReadiness is the pre-exam go/no-go report for operators.
+// Ready is false when any error-severity check fails.
+type ExamReadiness struct {
+	ExamID                  uuid.UUID             `json:"exam_id"`
+	Ready                   bool                  `json:"ready"`
+	EligibleStudents        int                   `json:"eligible_students"`
+	StartedSessions         int                   `json:"started_sessions"`
+	CompletedSessions       int                   `json:"completed_sessions"`
+	ExpectedPeakConnections int                   `json:"expected_peak_connections"`
+	QueueBacklog            map[string]int64      `json:"queue_backlog"`
+	DBLatencyMs             float64               `json:"db_latency_ms"`
+	RedisLatencyMs          float64               `json:"redis_latency_ms"`
+	Checks                  []ExamValidationCheck `json:"checks"`
+}
+
+// ExamConflict reports two exams that overlap in time while targeting the same classes.
+type ExamConflict struct {
+	ExamID       uuid.UUID `json:"exam_id"`
+	OtherExamID  uuid.UUID `json:"other_exam_id"`
+	OtherTitle   string    `json:"other_title"`
+	ClassIDs     []int     `json:"class_ids"`
+	OverlapStart LocalTime `json:"overlap_start"`
+	OverlapEnd   LocalTime `json:"overlap_end"`
+}
+
+// CalendarExam is an exam entry on the admin calendar.
+type CalendarExam struct {
+	ID              uuid.UUID   `json:"id"`
+	Title           string      `json:"title"`
+	Status          ExamStatus  `json:"status"`
+	ScheduledStart  *LocalTime  `json:"scheduled_start"`
+	ScheduledEnd    *LocalTime  `json:"scheduled_end,omitempty"`
+	DurationMinutes int         `json:"duration_minutes"`
+	ClassIDs        []int       `json:"class_ids"`
+	ConflictsWith   []uuid.UUID `json:"conflicts_with"`
+}
+
+// CalendarDay groups calendar exams by their start date (YYYY-MM-DD).
+type CalendarDay struct {
+	Date  string         `json:"date"`
+	Exams []CalendarExam `json:"exams"`
+}
+
+// ExamCalendar is the admin calendar view of scheduled exams in a date range.
+type ExamCalendar struct {
+	From      string         `json:"from"`
+	To        string         `json:"to"`
+	Days      []CalendarDay  `json:"days"`
+	Conflicts []ExamConflict `json:"conflicts"`
+}
+
+// QuestionReuse reports a question whose text also appears in another exam that
+// targets some of the same classes in the same term.
+type QuestionReuse struct {
+	QuestionID  uuid.UUID `json:"question_id"`
+	OrderNum    int       `json:"order_num"`
+	OtherExamID uuid.UUID `json:"other_exam_id"`
+	OtherTitle  string    `json:"other_title"`
+	ClassIDs    []int     `json:"class_ids"`
+}
+
+// QuestionReuseReport lists reused questions of an exam within its term (YYYY-MM-DD, end exclusive).
+type QuestionReuseReport struct {
+	ExamID    uuid.UUID       `json:"exam_id"`
+	TermStart string          `json:"term_start"`
+	TermEnd   string          `json:"term_end"`
+	Reused    []QuestionReuse `json:"reused"`
 }
 
 // QuestionForStudent is a question without the correct answer, sent to students.
 type QuestionForStudent struct {
 	ID           uuid.UUID       `json:"id"`
+	QuestionType QuestionType    `json:"question_type,omitempty"`
 	QuestionText string          `json:"question_text"`
 	Options      json.RawMessage `json:"options"`
 	OrderNum     int             `json:"order_num"`
+	MaxPlays     int             `json:"max_plays,omitempty"`
+	MathLatex    string          `json:"math_latex,omitempty"`
+	Media        []MediaHint     `json:"media,omitempty"`
+	PassageID    *uuid.UUID      `json:"passage_id,omitempty"`
+	// Translations, without explanations, travel in the cached payload and are
+	// applied to the question text and options before it is served.
+	Translations map[string]QuestionTranslation `json:"translations,omitempty"`
+}
+
+// MediaHint describes an image, audio or video embedded in a question for assistive
+// technology. Alt is the image's alt text or the audio/video's aria-label.
+type MediaHint struct {
+	Type string `json:"type"`
+	Src  string `json:"src"`
+	Alt  string `json:"alt"`
 }
 
 // UpdateExamRequest is the payload for updating an existing exam.
 type UpdateExamRequest struct {
-	Title              string          `json:"title" binding:"omitempty,min=3,max=255"`
-	ScheduledStart     *LocalTime      `json:"scheduled_start" binding:"omitempty"`
-	ScheduledEnd       *LocalTime      `json:"scheduled_end" binding:"omitempty"` // gtfield handled in handler natively
-	DurationMinutes    int             `json:"duration_minutes" binding:"omitempty,min=1,max=480"`
-	CheatRules         json.RawMessage `json:"cheat_rules" binding:"omitempty"`
-	RandomizeQuestions *bool           `json:"randomize_questions" binding:"omitempty"`
-	QuestionCount      *int            `json:"question_count" binding:"omitempty"`
-	EntryToken         string          `json:"entry_token" binding:"omitempty,min=4,max=20"`
-	QBankID            *uuid.UUID      `json:"qbank_id" binding:"omitempty"`
+	Title              string           `json:"title" binding:"omitempty,min=3,max=255"`
+	ScheduledStart     *LocalTime       `json:"scheduled_start" binding:"omitempty"`
+	ScheduledEnd       *LocalTime       `json:"scheduled_end" binding:"omitempty"` // gtfield handled in handler natively
+	DurationMinutes    int              `json:"duration_minutes" binding:"omitempty,min=1,max=480"`
+	CheatRules         json.RawMessage  `json:"cheat_rules" binding:"omitempty"`
+	RandomizeQuestions *bool            `json:"randomize_questions" binding:"omitempty"`
+	QuestionCount      *int             `json:"question_count" binding:"omitempty"`
+	EntryToken         string           `json:"entry_token" binding:"omitempty,min=4,max=20"`
+	QBankID            *uuid.UUID       `json:"qbank_id" binding:"omitempty"`
+	ShowClassAverage   *bool            `json:"show_class_average" binding:"omitempty"`
+	AllowReview        *bool            `json:"allow_review" binding:"omitempty"`
+	PublicResults      *bool            `json:"public_results" binding:"omitempty"`
+	ResultsAccessCode  string           `json:"results_access_code" binding:"omitempty,min=4,max=20"`
+	NavigationPolicy   NavigationPolicy `json:"navigation_policy" binding:"omitempty,oneof=FREE LINEAR SECTION_LOCKED"`
+	SectionSize        *int             `json:"section_size" binding:"omitempty,min=1,max=500"`
+	MaxBreaks          *int             `json:"max_breaks" binding:"omitempty,min=0,max=10"`
+	BreakMinutes       *int             `json:"break_minutes" binding:"omitempty,min=1,max=60"`
+	Instructions       *string          `json:"instructions" binding:"omitempty,max=20000"`
+	HonorCode          *string          `json:"honor_code" binding:"omitempty,max=5000"`
+
+	DisconnectPauseMinutes *int `json:"disconnect_pause_minutes" binding:"omitempty,min=0,max=60"`
+
+	// Metadata replaces the exam's metadata as a whole when present.
+	Metadata map[string]any `json:"metadata" binding:"omitempty"`
+
+	Language string `json:"language" binding:"omitempty"`
+	// Languages replaces the exam's translations when present; [] removes them all.
+	Languages      []string `json:"languages" binding:"omitempty,max=5"`
+	ForcedLanguage *string  `json:"forced_language" binding:"omitempty"`
 }
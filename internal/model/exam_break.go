@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// BreakPolicy is the Redis-cached break allowance of an exam.
+type BreakPolicy struct {
+	MaxBreaks    int `json:"max_breaks"`
+	BreakMinutes int `json:"break_minutes"`
+}
+
+// Enabled reports whether students may take breaks in the exam.
+func (p BreakPolicy) Enabled() bool {
+	return p.MaxBreaks > 0 && p.BreakMinutes > 0
+}
+
+// Limit is the longest a single break may last.
+func (p BreakPolicy) Limit() time.Duration {
+	return time.Duration(p.BreakMinutes) * time.Minute
+}
+
+// BreakStatus is a student's break usage in an exam. While OnBreak the paper is
+// locked and the student's timer stands still until EndsAt.
+type BreakStatus struct {
+	BreaksUsed    int        `json:"breaks_used"`
+	BreaksAllowed int        `json:"breaks_allowed"`
+	BreakMinutes  int        `json:"break_minutes"`
+	OnBreak       bool       `json:"on_break"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+	// PausedSeconds is the time spent on finished breaks, added to the student's timer.
+	PausedSeconds int `json:"paused_seconds"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "github.com/google/uuid"
+
+// BulkExamAction is an operation applied to many exams at once.
+type BulkExamAction string
+
+const (
+	BulkExamPublish BulkExamAction = "publish" // publish drafts
+	BulkExamArchive BulkExamAction = "archive" // archive completed exams
+	BulkExamDelete  BulkExamAction = "delete"  // delete drafts
+)
+
+// BulkExamRequest is the payload for applying an action to many exams.
+type BulkExamRequest struct {
+	Action  BulkExamAction `json:"action" binding:"required,oneof=publish archive delete"`
+	ExamIDs []uuid.UUID    `json:"exam_ids" binding:"required,min=1,max=100"`
+}
+
+// BulkExamItem is the outcome of a bulk action on one exam. A failed item carries
+// the error code the single-exam endpoint would have answered with.
+type BulkExamItem struct {
+	ExamID    uuid.UUID      `json:"exam_id"`
+	Status    string         `json:"status"` // "ok" or "failed"
+	Code      string         `json:"code,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Conflicts []ExamConflict `json:"conflicts,omitempty"`
+
+	Err error `json:"-"`
+}
+
+// BulkExamResult reports a bulk action item by item, in the order requested.
+type BulkExamResult struct {
+	Action    BulkExamAction `json:"action"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Items     []BulkExamItem `json:"items"`
+}
@@ -0,0 +1,27 @@
+package model
+
+// ExamMetadataType is the kind of value an exam metadata field holds.
+type ExamMetadataType string
+
+const (
+	ExamMetadataString ExamMetadataType = "string"
+	ExamMetadataNumber ExamMetadataType = "number"
+	ExamMetadataEnum   ExamMetadataType = "enum" // one of Options
+	ExamMetadataList   ExamMetadataType = "list" // a list of strings, such as KD codes
+)
+
+// ExamMetadataField is a field of the exam metadata schema.
+type ExamMetadataField struct {
+	Key      string           `json:"key"`
+	Label    string           `json:"label"`
+	Type     ExamMetadataType `json:"type"`
+	Options  []string         `json:"options,omitempty"`
+	Required bool             `json:"required"`
+}
+
+// ExamMetadataValue is an exam metadata field formatted for exports.
+type ExamMetadataValue struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MonitorEvent is one recorded event of an exam's live monitor stream. Payload is the
+// event as the monitor received it.
+type MonitorEvent struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	RecordedAt time.Time       `json:"recorded_at"`
+}
+
+// MonitorReplay is a page of an exam's recorded monitor events, oldest first.
+// NextAfter is set when more events follow; pass it back as after.
+type MonitorReplay struct {
+	Events    []MonitorEvent `json:"events"`
+	NextAfter *int64         `json:"next_after,omitempty"`
+}
+
+// LiveItemStat is how a question has been answered so far while its exam runs.
+// Choices counts every option, including those nobody picked; CorrectRate is the share
+// of Answered that picked CorrectOption.
+type LiveItemStat struct {
+	QuestionID    uuid.UUID        `json:"question_id"`
+	OrderNum      int              `json:"order_num"`
+	Answered      int64            `json:"answered"`
+	Choices       map[string]int64 `json:"choices"`
+	CorrectOption string           `json:"correct_option"`
+	CorrectRate   float64          `json:"correct_rate"`
+}
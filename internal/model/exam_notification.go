@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExamNotificationSettings controls which proctor notifications an exam sends and to whom.
+type ExamNotificationSettings struct {
+	ExamID             uuid.UUID `json:"exam_id"`
+	ExamTitle          string    `json:"exam_title"`
+	TelegramChatIDs    []string  `json:"telegram_chat_ids"`
+	WhatsAppNumbers    []string  `json:"whatsapp_numbers"`
+	NotifyExamStart    bool      `json:"notify_exam_start"`
+	CheatThreshold     int       `json:"cheat_threshold"`
+	NotifyQueueBacklog bool      `json:"notify_queue_backlog"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+
+	// NotifyHomeroomSummary emails each homeroom teacher their class's results once
+	// the exam completes.
+	NotifyHomeroomSummary bool `json:"notify_homeroom_summary"`
+
+	// NotifyLoginAnomaly alerts the recipients of login anomalies, such as credential
+	// stuffing, while the exam is upcoming or running.
+	NotifyLoginAnomaly bool `json:"notify_login_anomaly"`
+}
+
+// HasRecipients reports whether the settings name at least one recipient.
+func (s *ExamNotificationSettings) HasRecipients() bool {
+	return len(s.TelegramChatIDs) > 0 || len(s.WhatsAppNumbers) > 0
+}
+
+// UpsertExamNotificationSettingsRequest is the payload for configuring an exam's notifications.
+type UpsertExamNotificationSettingsRequest struct {
+	TelegramChatIDs    []string `json:"telegram_chat_ids" binding:"max=20,dive,required,max=64"`
+	WhatsAppNumbers    []string `json:"whatsapp_numbers" binding:"max=20,dive,required,max=20"`
+	NotifyExamStart    bool     `json:"notify_exam_start"`
+	CheatThreshold     int      `json:"cheat_threshold" binding:"min=0"`
+	NotifyQueueBacklog bool     `json:"notify_queue_backlog"`
+
+	NotifyHomeroomSummary bool `json:"notify_homeroom_summary"`
+	NotifyLoginAnomaly    bool `json:"notify_login_anomaly"`
+}
+
+// CheatCount is how many cheat events a student has recorded in an exam.
+type CheatCount struct {
+	StudentID int
+	Name      string
+	Count     int
+}
+
+// HomeroomSummary is one class's results in an exam, addressed to the class's
+// homeroom teacher.
+type HomeroomSummary struct {
+	ClassID      int
+	ClassName    string
+	TeacherName  string
+	TeacherEmail string
+	Results      []HomeroomResult
+}
+
+// HomeroomResult is one student's session in a homeroom summary.
+type HomeroomResult struct {
+	Name       string
+	NISN       string
+	Status     SessionStatus
+	FinalScore *float64
+}
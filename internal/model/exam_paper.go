@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExamPaperStatus is the state of a question paper export.
+type ExamPaperStatus string
+
+const (
+	ExamPaperPending ExamPaperStatus = "PENDING"
+	ExamPaperReady   ExamPaperStatus = "READY"
+	ExamPaperFailed  ExamPaperStatus = "FAILED"
+)
+
+// ExamPaperRequest is the payload for exporting an exam's question paper. Seed picks
+// the question set and order like a preview's; without it a random seed is used.
+type ExamPaperRequest struct {
+	IncludeAnswerKey bool   `json:"include_answer_key"`
+	Seed             *int64 `json:"seed" binding:"omitempty"`
+}
+
+// ExamPaperJob is an export of an exam's printable question paper, rendered in the
+// background. The PDF can be downloaded once Status is READY, until ExpiresAt.
+type ExamPaperJob struct {
+	ID               uuid.UUID       `json:"id"`
+	ExamID           uuid.UUID       `json:"exam_id"`
+	IncludeAnswerKey bool            `json:"include_answer_key"`
+	Seed             int64           `json:"seed"`
+	Status           ExamPaperStatus `json:"status"`
+	Error            string          `json:"error,omitempty"`
+	RequestedBy      int             `json:"requested_by"`
+	CreatedAt        time.Time       `json:"created_at"`
+	FinishedAt       *time.Time      `json:"finished_at,omitempty"`
+	ExpiresAt        time.Time       `json:"expires_at"`
+}
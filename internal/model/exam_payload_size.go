@@ -0,0 +1,34 @@
+package model
+
+import "github.com/google/uuid"
+
+// ExamPayloadSize is what each student downloads when an exam starts: the exam
+// payload's text and the media its questions and passages reference. Media are counted
+// once however many questions use them.
+type ExamPayloadSize struct {
+	ExamID     uuid.UUID `json:"exam_id"`
+	TextBytes  int64     `json:"text_bytes"`
+	MediaBytes int64     `json:"media_bytes"`
+	TotalBytes int64     `json:"total_bytes"`
+	// WarnBytes and MaxBytes are the configured limits; 0 means no limit.
+	WarnBytes int64 `json:"warn_bytes"`
+	MaxBytes  int64 `json:"max_bytes"`
+	// EligibleStudents times TotalBytes estimates the transfer when the exam starts.
+	EligibleStudents   int   `json:"eligible_students"`
+	StartTransferBytes int64 `json:"start_transfer_bytes"`
+	// Media lists the media from the library, largest first.
+	Media []ExamPayloadMedia `json:"media"`
+	// UntrackedMedia lists referenced URLs outside the media library, whose size is unknown.
+	UntrackedMedia []string `json:"untracked_media"`
+}
+
+// ExamPayloadMedia is a media file referenced by an exam, with the order numbers of
+// the questions using it.
+type ExamPayloadMedia struct {
+	MediaID      uuid.UUID `json:"media_id"`
+	URL          string    `json:"url"`
+	OriginalName string    `json:"original_name"`
+	MimeType     string    `json:"mime_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	OrderNums    []int     `json:"order_nums"`
+}
@@ -16,19 +16,46 @@ const (
 
 // ExamSession represents a student's exam attempt.
 type ExamSession struct {
-	ID            uuid.UUID     `json:"id"`
-	ExamID        uuid.UUID     `json:"exam_id"`
-	StudentID     int           `json:"student_id"`
-	QuestionOrder []string      `json:"question_order"`
-	StartedAt     time.Time     `json:"started_at"`
-	FinishedAt    *time.Time    `json:"finished_at,omitempty"`
-	Status        SessionStatus `json:"status"`
-	FinalScore    *float64      `json:"final_score,omitempty"`
+	ID                    uuid.UUID     `json:"id"`
+	ExamID                uuid.UUID     `json:"exam_id"`
+	StudentID             int           `json:"student_id"`
+	QuestionOrder         []string      `json:"question_order"`
+	StartedAt             time.Time     `json:"started_at"`
+	FinishedAt            *time.Time    `json:"finished_at,omitempty"`
+	Status                SessionStatus `json:"status"`
+	FinalScore            *float64      `json:"final_score,omitempty"`
+	ConsentAcknowledgedAt *time.Time    `json:"consent_acknowledged_at,omitempty"`
 }
 
-// JoinExamRequest is the payload for a student joining an exam.
+// SessionReconcileReport counts the Redis state a reconciliation restored from
+// PostgreSQL for open sessions.
+type SessionReconcileReport struct {
+	Sessions       int `json:"sessions"`
+	StartTimes     int `json:"start_times"`
+	ActiveExams    int `json:"active_exams"`
+	QuestionOrders int `json:"question_orders"`
+	AnswerSets     int `json:"answer_sets"`
+	Answers        int `json:"answers"`
+}
+
+// ExamStats are an exam's session aggregates as last computed by the stats worker.
+// Scores cover completed sessions; CompletionRate is completed / participants.
+type ExamStats struct {
+	ExamID         uuid.UUID `json:"exam_id"`
+	Participants   int       `json:"participants"`
+	Completed      int       `json:"completed"`
+	AverageScore   *float64  `json:"average_score"`
+	MedianScore    *float64  `json:"median_score"`
+	StddevScore    *float64  `json:"stddev_score"`
+	CompletionRate float64   `json:"completion_rate"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// JoinExamRequest is the payload for a student joining an exam. AcknowledgeConsent
+// accepts the exam's honor code and is required when the exam has one.
 type JoinExamRequest struct {
-	EntryToken string `json:"entry_token" binding:"required,min=4,max=20"`
+	EntryToken         string `json:"entry_token" binding:"required,min=4,max=20"`
+	AcknowledgeConsent bool   `json:"acknowledge_consent"`
 }
 
 type ExamSessionState struct {
@@ -37,5 +64,172 @@ type ExamSessionState struct {
 	IsRandomOrder    bool              `json:"is_random_order"`
 	CheatRules       map[string]bool   `json:"cheat_rules"`
 	AutosavedAnswers map[string]string `json:"autosaved_answers"`
+	MediaPlays       map[string]int    `json:"media_plays"`
 	RemainingTime    float64           `json:"remaining_time"`
+	NavigationPolicy NavigationPolicy  `json:"navigation_policy"`
+	SectionSize      int               `json:"section_size,omitempty"`
+	// CurrentPosition is the position in the student's question order they were last on.
+	CurrentPosition int `json:"current_position"`
+	// UnlockedFrom is the first position in the student's question order that may
+	// still be answered; earlier questions are locked by the navigation policy.
+	UnlockedFrom int `json:"unlocked_from"`
+	// Breaks is present when the exam allows breaks.
+	Breaks *BreakStatus `json:"breaks,omitempty"`
+	// DisconnectPausedSeconds is how long the timer was paused for prolonged disconnects;
+	// RemainingTime already includes it.
+	DisconnectPausedSeconds int `json:"disconnect_paused_seconds,omitempty"`
+}
+
+// StudentExamResult is a student's own result for a finished exam.
+// ClassAverage is only present when the exam enables show_class_average.
+type StudentExamResult struct {
+	ExamID           uuid.UUID  `json:"exam_id"`
+	Title            string     `json:"title"`
+	FinalScore       *float64   `json:"final_score"`
+	TotalQuestions   int        `json:"total_questions"`
+	CorrectCount     int        `json:"correct_count"`
+	IncorrectCount   int        `json:"incorrect_count"`
+	UnansweredCount  int        `json:"unanswered_count"`
+	StartedAt        time.Time  `json:"started_at"`
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`
+	TimeTakenSeconds int        `json:"time_taken_seconds"`
+	ClassAverage     *float64   `json:"class_average,omitempty"`
+}
+
+// StudentExamReview is a student's review of an exam after it: each question of their
+// exam, in the order they had them, with their answer, the correct one and its
+// explanation.
+type StudentExamReview struct {
+	ExamID    uuid.UUID        `json:"exam_id"`
+	Title     string           `json:"title"`
+	Language  string           `json:"language"`
+	Questions []ReviewQuestion `json:"questions"`
+}
+
+// ReviewQuestion is a question of an exam review. Answer is empty when the student
+// left the question unanswered.
+type ReviewQuestion struct {
+	QuestionForStudent
+	Answer        string `json:"answer"`
+	CorrectOption string `json:"correct_option"`
+	IsCorrect     bool   `json:"is_correct"`
+	Explanation   string `json:"explanation"`
+}
+
+// StaleSession is an IN_PROGRESS session whose time ran out, breaks and disconnect
+// pauses allowed for, without the student submitting. Deadline is the latest the
+// student's timer could have run to.
+type StaleSession struct {
+	StudentID      int       `json:"student_id"`
+	NISN           string    `json:"nisn"`
+	Name           string    `json:"name"`
+	ClassName      string    `json:"class_name"`
+	StartedAt      time.Time `json:"started_at"`
+	Deadline       time.Time `json:"deadline"`
+	OverdueMinutes int       `json:"overdue_minutes"`
+	Answered       int       `json:"answered"`
+	QuestionOrder  []string  `json:"-"`
+}
+
+// SubmitStaleSessionsRequest submits an exam's stale sessions, or only those of
+// StudentIDs when given. GraceMinutes is how long past its deadline a session is
+// stale; it defaults to the report's.
+type SubmitStaleSessionsRequest struct {
+	StudentIDs   []int `json:"student_ids" binding:"max=5000"`
+	GraceMinutes *int  `json:"grace_minutes" binding:"omitempty,min=0,max=1440"`
+}
+
+// StaleSubmitResult is the outcome of submitting stale sessions, one item per session.
+type StaleSubmitResult struct {
+	Submitted int               `json:"submitted"`
+	Failed    int               `json:"failed"`
+	Items     []StaleSubmitItem `json:"items"`
+}
+
+// StaleSubmitItem is the outcome of submitting one stale session.
+type StaleSubmitItem struct {
+	StudentID int      `json:"student_id"`
+	Name      string   `json:"name"`
+	Score     *float64 `json:"score,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// PublicResultLookupRequest is the payload of the public results lookup.
+type PublicResultLookupRequest struct {
+	NISN       string `json:"nisn" binding:"required,max=20"`
+	AccessCode string `json:"access_code" binding:"required,max=20"`
+}
+
+// PublicExamResult is what the public results lookup reveals: the score and grade only.
+type PublicExamResult struct {
+	ExamID     uuid.UUID `json:"exam_id"`
+	Title      string    `json:"title"`
+	FinalScore *float64  `json:"final_score"`
+	Grade      string    `json:"grade"`
+}
+
+// PublicExam is an exam listed on the public results page.
+type PublicExam struct {
+	ID    uuid.UUID `json:"id"`
+	Title string    `json:"title"`
+}
+
+// ScoreGrade maps a 0–100 score onto the report-card letter grade (predikat):
+// A from 90, B from 80, C from 70 and D below.
+func ScoreGrade(score float64) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	default:
+		return "D"
+	}
+}
+
+// StudentExamHistoryItem is a row in a student's exam history.
+// FinalScore is withheld until the exam's result is available.
+type StudentExamHistoryItem struct {
+	ExamID          uuid.UUID     `json:"exam_id"`
+	Title           string        `json:"title"`
+	Status          SessionStatus `json:"status"`
+	StartedAt       time.Time     `json:"started_at"`
+	FinishedAt      *time.Time    `json:"finished_at,omitempty"`
+	FinalScore      *float64      `json:"final_score,omitempty"`
+	ResultAvailable bool          `json:"result_available"`
+}
+
+// OfflineScoreEntry is one student's score from a paper exam, identified by NISN.
+type OfflineScoreEntry struct {
+	NISN  string  `json:"nisn" binding:"required,max=20"`
+	Score float64 `json:"score" binding:"min=0,max=100"`
+}
+
+// OfflineScoresRequest is the JSON payload for entering the scores of a paper exam.
+type OfflineScoresRequest struct {
+	Entries []OfflineScoreEntry `json:"entries" binding:"required,min=1,max=5000,dive"`
+}
+
+// OfflineScoreRow is the outcome of one entered score. Row is its 1-based position in
+// the upload. Action is one of the ImportAction values.
+type OfflineScoreRow struct {
+	Row       int     `json:"row"`
+	NISN      string  `json:"nisn"`
+	StudentID int     `json:"student_id,omitempty"`
+	Name      string  `json:"name,omitempty"`
+	Score     float64 `json:"score"`
+	Action    string  `json:"action"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// OfflineScoreReport is the outcome of entering a paper exam's scores. Applied is
+// false for dry runs, which change nothing.
+type OfflineScoreReport struct {
+	Rows    []OfflineScoreRow `json:"rows"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Skipped int               `json:"skipped"`
+	Applied bool              `json:"applied"`
 }
@@ -9,13 +9,13 @@ type ExamTargetRule struct {
 	ClassID    *int      `json:"class_id,omitempty"`
 	GradeLevel *string   `json:"grade_level,omitempty"`
 	MajorCode  *string   `json:"major_code,omitempty"`
-	Religion   *string   `json:"religion,omitempty"`
+	Religion   *Religion `json:"religion,omitempty"`
 }
 
 // AddTargetRuleRequest is the payload for adding a target rule.
 type AddTargetRuleRequest struct {
-	ClassID    *int    `json:"class_id,omitempty"`
-	GradeLevel *string `json:"grade_level,omitempty"`
-	MajorCode  *string `json:"major_code,omitempty"`
-	Religion   *string `json:"religion,omitempty"`
+	ClassID    *int      `json:"class_id,omitempty"`
+	GradeLevel *string   `json:"grade_level,omitempty"`
+	MajorCode  *string   `json:"major_code,omitempty"`
+	Religion   *Religion `json:"religion,omitempty"`
 }
@@ -0,0 +1,85 @@
+package model
+
+import "time"
+
+// NotifyChannel is where a guardian receives notifications.
+type NotifyChannel string
+
+const (
+	NotifyChannelNone     NotifyChannel = "none"
+	NotifyChannelTelegram NotifyChannel = "telegram"
+	NotifyChannelWhatsApp NotifyChannel = "whatsapp"
+)
+
+// Guardian represents a parent/guardian user with read-only access to their children.
+type Guardian struct {
+	ID             int           `json:"id"`
+	Name           string        `json:"name"`
+	Email          *string       `json:"email"`
+	Phone          *string       `json:"phone"`
+	PasswordHash   string        `json:"-"`
+	NotifyChannel  NotifyChannel `json:"notify_channel"`
+	TelegramChatID string        `json:"telegram_chat_id"`
+	NotifyResults  bool          `json:"notify_results"`
+	ChildrenCount  int           `json:"children_count"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// GuardianChild is a student linked to a guardian.
+type GuardianChild struct {
+	StudentID    int    `json:"student_id"`
+	NIS          string `json:"nis"`
+	NISN         string `json:"nisn"`
+	Name         string `json:"name"`
+	ClassID      int    `json:"class_id"`
+	ClassName    string `json:"class_name"`
+	Relationship string `json:"relationship"`
+}
+
+// GuardianLoginRequest is the payload for guardian authentication by email or phone.
+type GuardianLoginRequest struct {
+	Identifier string `json:"identifier" binding:"required,max=255"`
+	Password   string `json:"password" binding:"required,min=6,max=128"`
+}
+
+// CreateGuardianRequest is the payload for creating a guardian account.
+// At least one of email and phone is required.
+type CreateGuardianRequest struct {
+	Name     string  `json:"name" binding:"required,min=2,max=255"`
+	Email    *string `json:"email" binding:"omitempty,email,max=255"`
+	Phone    *string `json:"phone" binding:"omitempty,min=8,max=20"`
+	Password string  `json:"password" binding:"required,min=6,max=128"`
+}
+
+// UpdateGuardianRequest is the payload for updating a guardian account.
+// An empty password keeps the current one.
+type UpdateGuardianRequest struct {
+	Name     string  `json:"name" binding:"required,min=2,max=255"`
+	Email    *string `json:"email" binding:"omitempty,email,max=255"`
+	Phone    *string `json:"phone" binding:"omitempty,min=8,max=20"`
+	Password string  `json:"password" binding:"omitempty,min=6,max=128"`
+}
+
+// LinkGuardianStudentRequest links a student to a guardian.
+type LinkGuardianStudentRequest struct {
+	StudentID    int    `json:"student_id" binding:"required,min=1"`
+	Relationship string `json:"relationship" binding:"omitempty,max=30"`
+}
+
+// UpdateGuardianPreferencesRequest is the payload for a guardian's notification preferences.
+type UpdateGuardianPreferencesRequest struct {
+	NotifyChannel  NotifyChannel `json:"notify_channel" binding:"required,oneof=none telegram whatsapp"`
+	TelegramChatID string        `json:"telegram_chat_id" binding:"omitempty,max=64"`
+	NotifyResults  bool          `json:"notify_results"`
+}
+
+// GuardianRecipient is a guardian to notify about one of their children.
+type GuardianRecipient struct {
+	GuardianID     int
+	Channel        NotifyChannel
+	TelegramChatID string
+	Phone          *string
+	StudentName    string
+	ExamTitle      string
+}
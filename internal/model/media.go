@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MediaFile represents an uploaded file tracked in the media library.
+type MediaFile struct {
+	ID           uuid.UUID `json:"id"`
+	Filename     string    `json:"filename"`
+	OriginalName string    `json:"original_name"`
+	URL          string    `json:"url"`
+	MimeType     string    `json:"mime_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	Duration     *int      `json:"duration_seconds,omitempty"`
+	UploadedBy   *int      `json:"uploaded_by,omitempty"`
+	UsageCount   *int      `json:"usage_count,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	// ReusedAt and ReusedBy are set when an upload of the same content reused the file.
+	ReusedAt *time.Time `json:"reused_at,omitempty"`
+	ReusedBy *int       `json:"reused_by,omitempty"`
+}
+
+// Kinds of media usage.
+const (
+	MediaUsageQuestion = "question"
+	MediaUsagePassage  = "passage"
+	MediaUsageSetting  = "setting"
+)
+
+// MediaUsage describes a question, reading passage or setting that references a media
+// file. Questions and passages carry their question bank; settings only their key.
+type MediaUsage struct {
+	Kind         string     `json:"kind"`
+	QuestionID   *uuid.UUID `json:"question_id,omitempty"`
+	PassageID    *uuid.UUID `json:"passage_id,omitempty"`
+	PassageTitle string     `json:"passage_title,omitempty"`
+	QBankID      *uuid.UUID `json:"qbank_id,omitempty"`
+	QBankName    string     `json:"qbank_name,omitempty"`
+	OrderNum     int        `json:"order_num,omitempty"`
+	SettingKey   string     `json:"setting_key,omitempty"`
+}
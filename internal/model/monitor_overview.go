@@ -0,0 +1,36 @@
+package model
+
+import "github.com/google/uuid"
+
+// ExamOverview is one running exam in the monitor overview.
+type ExamOverview struct {
+	ExamID     uuid.UUID `json:"exam_id"`
+	Title      string    `json:"title"`
+	InProgress int       `json:"in_progress"`
+	Completed  int       `json:"completed"`
+	// Connected counts the students whose exam socket was seen recently.
+	Connected        int     `json:"connected"`
+	SubmitsPerMinute float64 `json:"submits_per_minute"`
+}
+
+// MonitorOverview aggregates every running exam for the exam day overview. Rates are
+// averaged over the last few minutes.
+type MonitorOverview struct {
+	Timestamp   int64 `json:"timestamp"`
+	ActiveExams int   `json:"active_exams"`
+	InProgress  int   `json:"in_progress"`
+	Completed   int   `json:"completed"`
+	Connected   int   `json:"connected"`
+	// Disconnected counts in-progress students whose socket was not seen recently.
+	Disconnected     int     `json:"disconnected"`
+	SubmitsPerMinute float64 `json:"submits_per_minute"`
+
+	// API requests of all instances and the share answered with a 5xx status.
+	RequestsPerMinute float64 `json:"requests_per_minute"`
+	ErrorRate         float64 `json:"error_rate"`
+
+	Queues        map[string]int64 `json:"queues"`
+	RedisDegraded bool             `json:"redis_degraded"`
+
+	Exams []ExamOverview `json:"exams"`
+}
@@ -7,6 +7,12 @@ const (
 	// PermissionMediaUpload allows uploading media files.
 	PermissionMediaUpload Permission = "media:upload"
 
+	// PermissionMediaRead allows browsing the media library and its usage.
+	PermissionMediaRead Permission = "media:read"
+
+	// PermissionMediaDelete allows deleting media files.
+	PermissionMediaDelete Permission = "media:delete"
+
 	// PermissionStudentsRead allows viewing student lists and details.
 	PermissionStudentsRead Permission = "students:read"
 
@@ -16,6 +22,9 @@ const (
 	// PermissionStudentsResetSession allows resetting a student's active session.
 	PermissionStudentsResetSession Permission = "students:reset_session"
 
+	// PermissionStudentsImpersonate allows viewing the student portal as a student.
+	PermissionStudentsImpersonate Permission = "students:impersonate"
+
 	// PermissionExamsRead allows viewing exam lists and details.
 	PermissionExamsRead Permission = "exams:read"
 
@@ -69,14 +78,33 @@ const (
 
 	// PermissionRoomsWrite allows creating, updating, and deleting rooms.
 	PermissionRoomsWrite Permission = "rooms:write"
+
+	// PermissionEraporExport allows exporting and pushing exam results to e-Rapor.
+	PermissionEraporExport Permission = "erapor:export"
+
+	// PermissionGuardiansRead allows viewing guardian accounts.
+	PermissionGuardiansRead Permission = "guardians:read"
+
+	// PermissionGuardiansWrite allows managing guardian accounts and their student links.
+	PermissionGuardiansWrite Permission = "guardians:write"
+
+	// PermissionOpsBackup allows creating, listing and restoring database backups.
+	PermissionOpsBackup Permission = "ops:backup"
+
+	// PermissionMonitorRead allows watching the live exam monitor and the room
+	// assignments, for proctors who must not edit or publish exams.
+	PermissionMonitorRead Permission = "monitor:read"
 )
 
 // AllPermissions is a slice of all available permissions.
 var AllPermissions = []Permission{
 	PermissionMediaUpload,
+	PermissionMediaRead,
+	PermissionMediaDelete,
 	PermissionStudentsRead,
 	PermissionStudentsWrite,
 	PermissionStudentsResetSession,
+	PermissionStudentsImpersonate,
 	PermissionExamsRead,
 	PermissionExamsWrite,
 	PermissionQBanksWriteOwn,
@@ -95,4 +123,28 @@ var AllPermissions = []Permission{
 	PermissionMajorDelete,
 	PermissionRoomsRead,
 	PermissionRoomsWrite,
+	PermissionEraporExport,
+	PermissionGuardiansRead,
+	PermissionGuardiansWrite,
+	PermissionOpsBackup,
+	PermissionMonitorRead,
+}
+
+// ScopablePermissions can be limited to subjects per role. Exams are scoped by the
+// subject of their question bank.
+var ScopablePermissions = []Permission{
+	PermissionExamsWrite,
+	PermissionExamsPublish,
+	PermissionQBanksWriteOwn,
+	PermissionQBanksWriteAll,
+}
+
+// IsScopable reports whether a permission code can be limited to subjects.
+func IsScopable(code string) bool {
+	for _, p := range ScopablePermissions {
+		if string(p) == code {
+			return true
+		}
+	}
+	return false
 }
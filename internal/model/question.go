@@ -15,6 +15,14 @@ type Question struct {
 	Options       json.RawMessage `json:"options"`
 	CorrectOption string          `json:"correct_option"`
 	OrderNum      int             `json:"order_num"`
+	MaxPlays      int             `json:"max_plays"`  // Playback limit for attached audio/video (0 = unlimited)
+	MathLatex     string          `json:"math_latex"` // LaTeX math source rendered alongside question_text
+	PassageID     *uuid.UUID      `json:"passage_id,omitempty"`
+	// Explanation is the rich-text reasoning behind the correct answer. It is left out
+	// of the live exam payload and shown only when the student reviews the exam.
+	Explanation string `json:"explanation"`
+	// Translations holds the question in other languages, keyed by language code.
+	Translations map[string]QuestionTranslation `json:"translations"`
 }
 
 type QuestionType string
@@ -22,18 +30,84 @@ type QuestionType string
 const (
 	QuestionTypeMultipleChoice QuestionType = "MULTIPLE_CHOICE"
 	QuestionTypeEssay          QuestionType = "ESSAY"
+	QuestionTypeTrueFalse      QuestionType = "TRUE_FALSE"
 )
 
 // AddQuestionRequest is the payload for adding a question to an exam.
 type AddQuestionRequest struct {
 	QuestionText  string          `json:"question_text" binding:"required,min=1,max=2000"`
-	QuestionType  string          `json:"question_type" binding:"required,oneof=MULTIPLE_CHOICE ESSAY"`
+	QuestionType  string          `json:"question_type" binding:"required,oneof=MULTIPLE_CHOICE ESSAY TRUE_FALSE"`
 	Options       json.RawMessage `json:"options" binding:"required"`
 	CorrectOption string          `json:"correct_option" binding:"required,max=10"`
 	OrderNum      int             `json:"order_num" binding:"min=0"`
+	MaxPlays      int             `json:"max_plays" binding:"min=0,max=20"`
+	MathLatex     string          `json:"math_latex" binding:"max=5000"`
+	PassageID     *uuid.UUID      `json:"passage_id" binding:"omitempty"`
+	Explanation   string          `json:"explanation" binding:"max=10000"`
+
+	Translations map[string]QuestionTranslation `json:"translations" binding:"omitempty,max=5"`
 }
 
 // ReplaceQuestionsRequest is the payload for bulk replacing questions.
 type ReplaceQuestionsRequest struct {
 	Questions []AddQuestionRequest `json:"questions" binding:"dive"`
 }
+
+// QuestionIDsRequest selects questions of a qbank for a bulk operation.
+type QuestionIDsRequest struct {
+	QuestionIDs []uuid.UUID `json:"question_ids" binding:"required,min=1,max=1000"`
+}
+
+// TransferMode selects whether transferred questions leave their qbank.
+type TransferMode string
+
+const (
+	TransferMove TransferMode = "MOVE"
+	TransferCopy TransferMode = "COPY"
+)
+
+// TransferQuestionsRequest moves or copies questions to another qbank.
+type TransferQuestionsRequest struct {
+	QuestionIDs   []uuid.UUID  `json:"question_ids" binding:"required,min=1,max=1000"`
+	TargetQBankID uuid.UUID    `json:"target_qbank_id" binding:"required"`
+	Mode          TransferMode `json:"mode" binding:"required,oneof=MOVE COPY"`
+}
+
+// QuestionOption is a single choice of a multiple-choice question. Key identifies the
+// option for grading, whatever order it is shown in. An option has text, an attached
+// media file, or both; MediaAlt is the image's alt text or the audio/video's label.
+type QuestionOption struct {
+	Key      string     `json:"key"`
+	Text     string     `json:"text"`
+	MediaID  *uuid.UUID `json:"media_id,omitempty"`
+	MediaAlt string     `json:"media_alt,omitempty"`
+	// MediaURL and MediaType are filled in from MediaID for the student payload.
+	MediaURL  string `json:"media_url,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+}
+
+// ImportIssue describes a problem found while parsing an imported document.
+type ImportIssue struct {
+	Question int    `json:"question"` // 1-based question number in the document
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+}
+
+// ImportQuestionsResult is returned by the question document import.
+// Imported is false for previews and for documents that contain issues.
+type ImportQuestionsResult struct {
+	Questions  []Question          `json:"questions"`
+	Issues     []ImportIssue       `json:"issues"`
+	Duplicates []QuestionDuplicate `json:"duplicates,omitempty"`
+	Imported   bool                `json:"imported"`
+}
+
+// QuestionDuplicate pairs a new question with the most similar question already in
+// its qbank. Similarity is the trigram similarity (0–1) of the normalized texts.
+type QuestionDuplicate struct {
+	Question         int       `json:"question"` // 1-based position among the new questions
+	ExistingID       uuid.UUID `json:"existing_id"`
+	ExistingOrderNum int       `json:"existing_order_num"`
+	Similarity       float64   `json:"similarity"`
+	Exact            bool      `json:"exact"`
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Passage is a reading passage (stimulus) in a question bank that several questions
+// refer to, e.g. the text of a reading-comprehension block.
+type Passage struct {
+	ID        uuid.UUID `json:"id"`
+	QBankID   uuid.UUID `json:"qbank_id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PassageRequest is the payload for creating or updating a passage.
+type PassageRequest struct {
+	Title   string `json:"title" binding:"max=255"`
+	Content string `json:"content" binding:"required,min=1,max=50000"`
+}
+
+// PassageForStudent is a passage as sent to students, once per exam payload.
+type PassageForStudent struct {
+	ID      uuid.UUID `json:"id"`
+	Title   string    `json:"title,omitempty"`
+	Content string    `json:"content"`
+}
+
+// PassagesFor returns the passages that questions refer to, in the order they are
+// first referred to.
+func PassagesFor(questions []QuestionForStudent, passages []PassageForStudent) []PassageForStudent {
+	byID := make(map[uuid.UUID]PassageForStudent, len(passages))
+	for _, p := range passages {
+		byID[p.ID] = p
+	}
+
+	var used []PassageForStudent
+	seen := make(map[uuid.UUID]bool)
+	for _, q := range questions {
+		if q.PassageID == nil || seen[*q.PassageID] {
+			continue
+		}
+		seen[*q.PassageID] = true
+		if p, ok := byID[*q.PassageID]; ok {
+			used = append(used, p)
+		}
+	}
+	return used
+}
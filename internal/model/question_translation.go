@@ -0,0 +1,16 @@
+package model
+
+// DefaultLanguage is the language exams' questions are written in unless set
+// otherwise.
+const DefaultLanguage = "id"
+
+// QuestionTranslation is a question in another language. Options maps option keys,
+// or the letters A, B, … of options without keys, to their text in that language;
+// options keep their keys, media and order. Fields left empty fall back to the
+// question's own.
+type QuestionTranslation struct {
+	QuestionText string            `json:"question_text"`
+	Options      map[string]string `json:"options,omitempty"`
+	MathLatex    string            `json:"math_latex,omitempty"`
+	Explanation  string            `json:"explanation,omitempty"`
+}
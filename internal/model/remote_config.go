@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// Settings the student app reads as remote config, each holding a JSON value. An
+// empty value clears the setting.
+const (
+	SettingUITheme           = "ui_theme"
+	SettingUIAnnouncement    = "ui_announcement"
+	SettingUISupportContact  = "ui_support_contact"
+	SettingUIAllowedBrowsers = "ui_allowed_browsers"
+)
+
+// RemoteConfigKeys lists the settings making up the remote config.
+var RemoteConfigKeys = []string{
+	SettingUITheme,
+	SettingUIAnnouncement,
+	SettingUISupportContact,
+	SettingUIAllowedBrowsers,
+}
+
+// Announcement levels, from least to most prominent.
+const (
+	AnnouncementInfo     = "info"
+	AnnouncementWarning  = "warning"
+	AnnouncementCritical = "critical"
+)
+
+// UITheme customizes the exam UI. Colors are #rrggbb; Mode is "light", "dark" or
+// "system".
+type UITheme struct {
+	PrimaryColor string `json:"primary_color,omitempty"`
+	AccentColor  string `json:"accent_color,omitempty"`
+	LogoURL      string `json:"logo_url,omitempty"`
+	Mode         string `json:"mode,omitempty"`
+}
+
+// UIAnnouncement is a banner shown to students between StartsAt and EndsAt, or
+// always when they are unset. The app hides it outside that window.
+type UIAnnouncement struct {
+	Message  string     `json:"message"`
+	Level    string     `json:"level"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// UISupportContact is who students contact when something goes wrong.
+type UISupportContact struct {
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+	WhatsApp string `json:"whatsapp,omitempty"`
+	Hours    string `json:"hours,omitempty"`
+}
+
+// UIAllowedBrowser is a browser students may take exams in, e.g. "chrome" or "seb"
+// (Safe Exam Browser), from MinVersion on when it is set.
+type UIAllowedBrowser struct {
+	Name       string `json:"name"`
+	MinVersion int    `json:"min_version,omitempty"`
+}
+
+// RemoteConfig is the student app's remote config. Version changes whenever any part
+// does; unset parts are null, and an empty AllowedBrowsers allows every browser.
+type RemoteConfig struct {
+	Version         string             `json:"version"`
+	Theme           *UITheme           `json:"theme"`
+	Announcement    *UIAnnouncement    `json:"announcement"`
+	SupportContact  *UISupportContact  `json:"support_contact"`
+	AllowedBrowsers []UIAllowedBrowser `json:"allowed_browsers"`
+}
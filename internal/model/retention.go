@@ -0,0 +1,101 @@
+package model
+
+import "time"
+
+// Retention targets: the kinds of data retention rules apply to.
+const (
+	RetentionTargetStudentAnswers = "student_answers"
+	RetentionTargetCheatEvents    = "cheat_events"
+	RetentionTargetMonitorEvents  = "monitor_events"
+	RetentionTargetAuditLogs      = "audit_logs"
+)
+
+// Retention actions.
+const (
+	RetentionActionDelete    = "delete"
+	RetentionActionAnonymize = "anonymize"
+)
+
+// RetentionTarget describes data retention rules can apply to and what they can do with it.
+type RetentionTarget struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	Actions     []string `json:"actions"`
+}
+
+// RetentionTargets is the catalog of retention targets.
+var RetentionTargets = []RetentionTarget{
+	{
+		ID:          RetentionTargetStudentAnswers,
+		Description: "Students' answers, aged by their last change. Anonymizing detaches them from the student and keeps them for question statistics.",
+		Actions:     []string{RetentionActionDelete, RetentionActionAnonymize},
+	},
+	{
+		ID:          RetentionTargetCheatEvents,
+		Description: "Cheat events reported during exams.",
+		Actions:     []string{RetentionActionDelete},
+	},
+	{
+		ID:          RetentionTargetMonitorEvents,
+		Description: "Recorded monitor events used for exam replays.",
+		Actions:     []string{RetentionActionDelete},
+	},
+	{
+		ID:          RetentionTargetAuditLogs,
+		Description: "Audit log entries. Anonymizing clears their IP addresses.",
+		Actions:     []string{RetentionActionDelete, RetentionActionAnonymize},
+	},
+}
+
+// RetentionTargetSupports reports whether target is in the catalog and supports action.
+func RetentionTargetSupports(target, action string) bool {
+	for _, t := range RetentionTargets {
+		if t.ID != target {
+			continue
+		}
+		for _, a := range t.Actions {
+			if a == action {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RetentionRule deletes or anonymizes a target's data once it is older than AfterDays.
+type RetentionRule struct {
+	ID        int       `json:"id"`
+	Target    string    `json:"target"`
+	Action    string    `json:"action"`
+	AfterDays int       `json:"after_days"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateRetentionRuleRequest is the payload for creating a retention rule.
+type CreateRetentionRuleRequest struct {
+	Target    string `json:"target" binding:"required"`
+	Action    string `json:"action" binding:"required,oneof=delete anonymize"`
+	AfterDays int    `json:"after_days" binding:"required,min=30,max=3650"`
+	Enabled   *bool  `json:"enabled"`
+}
+
+// UpdateRetentionRuleRequest is the payload for updating a retention rule.
+type UpdateRetentionRuleRequest struct {
+	AfterDays int  `json:"after_days" binding:"required,min=30,max=3650"`
+	Enabled   bool `json:"enabled"`
+}
+
+// RetentionResult is what a retention rule covers, or covered, at a cutoff.
+type RetentionResult struct {
+	Rule   RetentionRule `json:"rule"`
+	Cutoff time.Time     `json:"cutoff"`
+	Rows   int64         `json:"rows"`
+}
+
+// RetentionReport lists what the retention rules would purge if they ran now.
+type RetentionReport struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Results     []RetentionResult `json:"results"`
+}
@@ -10,7 +10,10 @@ type Role struct {
 }
 
 // RoleWithPermissions extends Role to include its associated permissions.
+// SubjectScopes limits scopable permissions to the listed subject IDs;
+// a permission missing from it applies to every subject.
 type RoleWithPermissions struct {
 	*Role
-	Permissions []string `json:"permissions"`
+	Permissions   []string         `json:"permissions"`
+	SubjectScopes map[string][]int `json:"subject_scopes"`
 }
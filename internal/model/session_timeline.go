@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session timeline event types. Other recorded monitor events of the student, such
+// as idle, navigate or break_start, keep their own type.
+const (
+	TimelineJoin       = "join"
+	TimelineConnect    = "connect"
+	TimelineDisconnect = "disconnect"
+	TimelineAutosave   = "autosave"
+	TimelineCheat      = "cheat"
+	TimelineSubmit     = "submit"
+)
+
+// SessionTimeline is everything recorded of a student's exam session, oldest first,
+// for settling disputes such as a student saying their answers were lost. Answers are
+// the answers PostgreSQL holds for the student.
+type SessionTimeline struct {
+	ExamID     uuid.UUID               `json:"exam_id"`
+	StudentID  int                     `json:"student_id"`
+	NISN       string                  `json:"nisn"`
+	Name       string                  `json:"name"`
+	Status     SessionStatus           `json:"status"`
+	StartedAt  time.Time               `json:"started_at"`
+	FinishedAt *time.Time              `json:"finished_at,omitempty"`
+	FinalScore *float64                `json:"final_score,omitempty"`
+	Events     []SessionTimelineEvent  `json:"events"`
+	Answers    []SessionTimelineAnswer `json:"answers"`
+}
+
+// SessionTimelineEvent is an entry of a session timeline. Autosaves are sampled:
+// one entry stands for Count autosaves within a minute, Data listing their questions.
+type SessionTimelineEvent struct {
+	At      time.Time       `json:"at"`
+	Type    string          `json:"type"`
+	Message string          `json:"message,omitempty"`
+	Count   int             `json:"count,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// SessionTimelineAnswer is an answer as persisted, with when it last changed.
+type SessionTimelineAnswer struct {
+	QuestionID uuid.UUID `json:"question_id"`
+	OrderNum   int       `json:"order_num"`
+	Answer     string    `json:"answer"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
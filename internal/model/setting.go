@@ -13,3 +13,28 @@ type AppSetting struct {
 type UpdateSettingsRequest struct {
 	Settings map[string]string `json:"settings" binding:"required"`
 }
+
+// SettingExamConflictPolicy controls how overlapping exams for the same class are handled.
+// "warn" (default) saves the change and reports the conflicts; "block" rejects it.
+const (
+	SettingExamConflictPolicy = "exam_conflict_policy"
+	ExamConflictPolicyWarn    = "warn"
+	ExamConflictPolicyBlock   = "block"
+)
+
+// SettingMonitorItemStats, when "true", adds each question's live answer distribution
+// to the exam monitor's refresh events.
+const SettingMonitorItemStats = "monitor_item_stats"
+
+// SettingExamMetadataSchema holds the fields exams may carry in their metadata, as a
+// JSON list of ExamMetadataField. Without it, exam metadata is free-form.
+const SettingExamMetadataSchema = "exam_metadata_schema"
+
+// SettingExamPayloadWarnMB and SettingExamPayloadMaxMB bound the size of an exam's
+// payload, its text plus the media it references, in megabytes. Above the warning
+// size the publish checklist warns; above the maximum publishing is refused. "0"
+// turns a limit off.
+const (
+	SettingExamPayloadWarnMB = "exam_payload_warn_mb"
+	SettingExamPayloadMaxMB  = "exam_payload_max_mb"
+)
@@ -1,6 +1,12 @@
 package model
 
-import "time"
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/stemsi/exstem-backend/internal/pii"
+)
 
 // Gender represents the student's gender.
 type Gender string
@@ -22,28 +28,77 @@ const (
 	ReligionKonghucu Religion = "Konghucu"
 )
 
+// Value stores the religion encrypted when a PII key is configured. Encryption is
+// deterministic, so religion columns can still be compared in SQL.
+func (r Religion) Value() (driver.Value, error) {
+	return pii.Encrypt(string(r)), nil
+}
+
+// Scan reads a religion column, decrypting it when it is encrypted.
+func (r *Religion) Scan(src any) error {
+	var v string
+	switch src := src.(type) {
+	case nil:
+		*r = ""
+		return nil
+	case string:
+		v = src
+	case []byte:
+		v = string(src)
+	default:
+		return fmt.Errorf("cannot scan %T into Religion", src)
+	}
+	plain, err := pii.Decrypt(v)
+	if err != nil {
+		return err
+	}
+	*r = Religion(plain)
+	return nil
+}
+
+// StudentStatus is where a student is in their lifecycle at the school.
+type StudentStatus string
+
+const (
+	StudentStatusActive      StudentStatus = "active"
+	StudentStatusTransferred StudentStatus = "transferred"
+	StudentStatusGraduated   StudentStatus = "graduated"
+	StudentStatusSuspended   StudentStatus = "suspended"
+)
+
 // Student represents a student user.
 type Student struct {
-	ID        int       `json:"id"`
-	NIS       string    `json:"nis"`
-	NISN      string    `json:"nisn"`
-	Name      string    `json:"name"`
-	Gender    Gender    `json:"gender"`
-	Religion  Religion  `json:"religion"`
-	Password  string    `json:"password"`
-	ClassID   int       `json:"class_id"`
+	ID       int      `json:"id"`
+	NIS      string   `json:"nis"`
+	NISN     string   `json:"nisn"`
+	Name     string   `json:"name"`
+	Gender   Gender   `json:"gender"`
+	Religion Religion `json:"religion"`
+	Password string   `json:"password"`
+	ClassID  int      `json:"class_id"`
+	// IsActive is true while Status is active. Only active students can log in and
+	// see exams; the results of the others stay linked to them.
+	IsActive  bool      `json:"is_active"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	Status          StudentStatus `json:"status"`
+	StatusChangedAt *time.Time    `json:"status_changed_at,omitempty"`
+
+	// MustChangePassword is set when credentials were issued to the student, until
+	// they choose their own password.
+	MustChangePassword bool `json:"must_change_password"`
 }
 
 // StudentFilter holds optional filtering parameters for listing students.
 type StudentFilter struct {
 	Search      *string
-	Religion    *string
+	Religion    *Religion
 	GradeLevel  *string
 	MajorCode   *string
 	GroupNumber *string
 	ClassID     *int
+	Status      *string
 }
 
 // StudentCardInfo holds generic student data for printing ID cards.
@@ -60,6 +115,19 @@ type StudentCardInfo struct {
 	SeatNumber int    `json:"seat_number"`
 }
 
+// IssueCredentialsRequest is the payload for issuing fresh login credentials to the
+// active students of a class.
+type IssueCredentialsRequest struct {
+	ClassID int    `json:"class_id" binding:"required"`
+	Format  string `json:"format" binding:"omitempty,oneof=csv pdf"`
+}
+
+// ChangeStudentPasswordRequest is the payload for a student changing their password.
+type ChangeStudentPasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required,max=128"`
+	NewPassword     string `json:"new_password" binding:"required,min=6,max=72"`
+}
+
 // StudentLoginRequest is the payload for student authentication.
 type StudentLoginRequest struct {
 	NISN     string `json:"nisn" binding:"required,min=4,max=20"`
@@ -93,3 +161,66 @@ type UpdateStudentRequest struct {
 	Password string   `json:"password" binding:"omitempty,min=6,max=128"`
 	ClassID  int      `json:"class_id" binding:"required"`
 }
+
+// DirectorySyncResult summarizes one student directory sync run.
+type DirectorySyncResult struct {
+	Created     int      `json:"created"`
+	Updated     int      `json:"updated"`
+	Deactivated int      `json:"deactivated"`
+	Skipped     []string `json:"skipped"`
+}
+
+// Dapodik import row actions.
+const (
+	ImportActionCreate    = "create"
+	ImportActionUpdate    = "update"
+	ImportActionUnchanged = "unchanged"
+	ImportActionSkip      = "skip"
+)
+
+// FieldChange is one field that an import would change on an existing record.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// DapodikImportRow reports how one row of a Dapodik export reconciles against the database.
+type DapodikImportRow struct {
+	Row     int           `json:"row"`
+	NIS     string        `json:"nis"`
+	NISN    string        `json:"nisn"`
+	Name    string        `json:"name"`
+	Class   string        `json:"class"`
+	Action  string        `json:"action"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// DapodikImportReport is the diff of a Dapodik export against existing students.
+// Applied is false for dry runs, which change nothing.
+type DapodikImportReport struct {
+	Rows       []DapodikImportRow `json:"rows"`
+	Created    int                `json:"created"`
+	Updated    int                `json:"updated"`
+	Unchanged  int                `json:"unchanged"`
+	Skipped    int                `json:"skipped"`
+	NewClasses []string           `json:"new_classes"`
+	// Missing lists active students that the export does not contain. They are not touched.
+	Missing []Student `json:"missing"`
+	Applied bool      `json:"applied"`
+}
+
+// UpdateStudentStatusRequest is the payload for moving one student to another status.
+type UpdateStudentStatusRequest struct {
+	Status StudentStatus `json:"status" binding:"required,oneof=active transferred graduated suspended"`
+	Reason string        `json:"reason" binding:"omitempty,max=500"`
+}
+
+// BulkStudentStatusRequest is the payload for moving many students, e.g. a graduating
+// grade, to another status.
+type BulkStudentStatusRequest struct {
+	StudentIDs []int         `json:"student_ids" binding:"required,min=1,max=2000,dive,min=1"`
+	Status     StudentStatus `json:"status" binding:"required,oneof=active transferred graduated suspended"`
+	Reason     string        `json:"reason" binding:"omitempty,max=500"`
+}
@@ -4,10 +4,19 @@ import "time"
 
 // Subject represents an academic course or subject.
 type Subject struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        int              `json:"id"`
+	Name      string           `json:"name"`
+	Teachers  []SubjectTeacher `json:"teachers"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// SubjectTeacher is an admin assigned to teach a subject.
+type SubjectTeacher struct {
+	AdminID    int       `json:"admin_id"`
+	Username   string    `json:"username"`
+	Name       string    `json:"name"`
+	AssignedAt time.Time `json:"assigned_at"`
 }
 
 // CreateSubjectRequest is the payload for creating a subject.
@@ -19,3 +28,16 @@ type CreateSubjectRequest struct {
 type UpdateSubjectRequest struct {
 	Name string `json:"name" binding:"required,min=2,max=100"`
 }
+
+// AssignSubjectTeachersRequest is the payload for assigning teachers to a subject.
+type AssignSubjectTeachersRequest struct {
+	AdminIDs []int `json:"admin_ids" binding:"required,min=1,max=100,dive,min=1"`
+}
+
+// SubjectTeacherWarning warns that an exam was created or moved to a subject its
+// admin is not assigned to teach.
+type SubjectTeacherWarning struct {
+	SubjectID   int    `json:"subject_id"`
+	SubjectName string `json:"subject_name"`
+	Message     string `json:"message"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// MetricsSample is one instance's system metrics at a point in time, as kept in the
+// metrics history.
+type MetricsSample struct {
+	Instance           string    `json:"instance"`
+	RecordedAt         time.Time `json:"recorded_at"`
+	CPUPercent         float64   `json:"cpu_percent"`
+	MemPercent         float64   `json:"mem_percent"`
+	MemUsedBytes       int64     `json:"mem_used_bytes"`
+	DiskPercent        float64   `json:"disk_percent"`
+	LoadAvg1           float64   `json:"load_avg_1"`
+	Goroutines         int       `json:"goroutines"`
+	HeapAlloc          int64     `json:"heap_alloc"`
+	DBAcquiredConns    int       `json:"db_acquired_conns"`
+	WSConnections      int       `json:"ws_connections"`
+	QueueAnswers       int64     `json:"queue_answers"`
+	QueueCheats        int64     `json:"queue_cheats"`
+	QueueScores        int64     `json:"queue_scores"`
+	QueueMonitorEvents int64     `json:"queue_monitor_events"`
+	RedisDegraded      bool      `json:"redis_degraded"`
+}
+
+// MetricsHistory is the metrics history over a range, downsampled to one sample per
+// instance per step: gauges averaged, queue depths and connections at their peak, and
+// Redis degraded when it was at any point of the step.
+type MetricsHistory struct {
+	Range   string          `json:"range"`
+	Step    string          `json:"step"`
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Samples []MetricsSample `json:"samples"`
+}
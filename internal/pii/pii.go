@@ -0,0 +1,123 @@
+// Package pii encrypts personal data columns at rest.
+//
+// Values are sealed with AES-256-GCM under a nonce derived from the plaintext
+// (HMAC-SHA256), so equal values give equal ciphertexts. That keeps equality
+// filters, joins and unique constraints working in SQL, at the cost of revealing
+// which rows share a value. Ciphertexts are stored as text with a version prefix;
+// values without it are read back as they are, so rows written before the key was
+// set keep working until they are backfilled.
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Prefix marks an encrypted value.
+const Prefix = "enc:v1:"
+
+// ErrNoKey is returned when decrypting a value while no key is configured.
+var ErrNoKey = errors.New("pii: encrypted value but no encryption key configured")
+
+var (
+	mu     sync.RWMutex
+	aead   cipher.AEAD
+	macKey []byte
+)
+
+// Configure sets the key values are encrypted with: 32 bytes, base64 encoded. An
+// empty key disables encryption, so values are written as plaintext.
+func Configure(key string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if key == "" {
+		aead, macKey = nil, nil
+		return nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("pii: decode key: %w", err)
+	}
+	if len(raw) != 32 {
+		return fmt.Errorf("pii: key must be 32 bytes, got %d", len(raw))
+	}
+
+	// Separate subkeys for sealing and for deriving nonces.
+	block, err := aes.NewCipher(derive(raw, "encrypt"))
+	if err != nil {
+		return fmt.Errorf("pii: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("pii: %w", err)
+	}
+	aead, macKey = gcm, derive(raw, "nonce")
+	return nil
+}
+
+// Enabled reports whether a key is configured.
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return aead != nil
+}
+
+// IsEncrypted reports whether a stored value is a ciphertext.
+func IsEncrypted(v string) bool {
+	return strings.HasPrefix(v, Prefix)
+}
+
+// Encrypt returns the stored form of a value: its ciphertext when a key is
+// configured, the value itself otherwise. Empty and already encrypted values are
+// returned unchanged.
+func Encrypt(v string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if aead == nil || v == "" || IsEncrypted(v) {
+		return v
+	}
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write([]byte(v))
+	nonce := mac.Sum(nil)[:aead.NonceSize()]
+	sealed := aead.Seal(nonce, nonce, []byte(v), nil)
+	return Prefix + base64.RawStdEncoding.EncodeToString(sealed)
+}
+
+// Decrypt returns the value of a stored ciphertext. Values without the prefix are
+// plaintext and returned as they are.
+func Decrypt(v string) (string, error) {
+	if !IsEncrypted(v) {
+		return v, nil
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if aead == nil {
+		return "", ErrNoKey
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(v, Prefix))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.New("pii: malformed ciphertext")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("pii: decrypt: %w", err)
+	}
+	return string(plain), nil
+}
+
+func derive(key []byte, label string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,56 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ListQueue is the original RPUSH/BLPOP queue. Ack is a no-op.
+type ListQueue struct {
+	rdb *redis.Client
+}
+
+// NewListQueue creates a ListQueue.
+func NewListQueue(rdb *redis.Client) *ListQueue {
+	return &ListQueue{rdb: rdb}
+}
+
+func (q *ListQueue) Push(ctx context.Context, name string, bodies ...[]byte) error {
+	if len(bodies) == 0 {
+		return nil
+	}
+	values := make([]interface{}, len(bodies))
+	for i, b := range bodies {
+		values[i] = b
+	}
+	return q.rdb.RPush(ctx, name, values...).Err()
+}
+
+func (q *ListQueue) PushPipe(ctx context.Context, pipe redis.Pipeliner, name string, body []byte) {
+	pipe.RPush(ctx, name, body)
+}
+
+func (q *ListQueue) Pop(ctx context.Context, name string, timeout time.Duration) (*Message, error) {
+	item, err := q.rdb.BLPop(ctx, timeout, name).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrEmpty
+		}
+		return nil, err
+	}
+	if len(item) < 2 {
+		return nil, ErrEmpty
+	}
+	return &Message{Body: []byte(item[1])}, nil
+}
+
+func (q *ListQueue) Ack(ctx context.Context, name string, ids ...string) error {
+	return nil
+}
+
+func (q *ListQueue) Len(ctx context.Context, name string) (int64, error) {
+	return q.rdb.LLen(ctx, name).Result()
+}
@@ -0,0 +1,69 @@
+// Package queue abstracts the job queues feeding the background workers.
+//
+// Two Redis backends are available:
+//   - list (default): RPUSH/BLPOP. Simple, but a job is gone once popped, so a
+//     worker crash between pop and flush loses it.
+//   - stream: Redis Streams with a consumer group. Jobs stay pending until
+//     acknowledged and are redelivered after a crash.
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrEmpty is returned by Pop when no job arrived before the timeout.
+var ErrEmpty = errors.New("queue is empty")
+
+// Backend names accepted by New.
+const (
+	BackendList   = "list"
+	BackendStream = "stream"
+)
+
+// Message is a job taken from a queue. ID is empty for backends without acknowledgments.
+type Message struct {
+	ID   string
+	Body []byte
+}
+
+// Queue is a named-queue job transport.
+type Queue interface {
+	// Push appends jobs to the named queue.
+	Push(ctx context.Context, name string, bodies ...[]byte) error
+	// PushPipe queues a push on a Redis pipeline so it commits together with other writes.
+	PushPipe(ctx context.Context, pipe redis.Pipeliner, name string, body []byte)
+	// Pop blocks up to timeout for the next job and returns ErrEmpty when none arrived.
+	Pop(ctx context.Context, name string, timeout time.Duration) (*Message, error)
+	// Ack marks jobs as processed so they are not delivered again.
+	Ack(ctx context.Context, name string, ids ...string) error
+	// Len returns the number of jobs not yet processed.
+	Len(ctx context.Context, name string) (int64, error)
+}
+
+// New returns the Queue for the configured backend.
+func New(backend string, rdb *redis.Client, consumer string) (Queue, error) {
+	switch backend {
+	case "", BackendList:
+		return NewListQueue(rdb), nil
+	case BackendStream:
+		return NewStreamQueue(rdb, consumer), nil
+	default:
+		return nil, fmt.Errorf("unknown queue backend %q (allowed: %s, %s)", backend, BackendList, BackendStream)
+	}
+}
+
+// IDs returns the acknowledgment IDs of messages, skipping empty ones.
+func IDs(msgs []*Message) []string {
+	ids := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		if m.ID != "" {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,241 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// streamPrefix keeps stream keys apart from the list keys of the same queue,
+	// so switching backends never hits a WRONGTYPE error.
+	streamPrefix = "stream:"
+	streamGroup  = "exstem-workers"
+	streamField  = "body"
+	// streamReadCount is how many entries one XREADGROUP fetches into the local buffer.
+	streamReadCount = 50
+	// streamClaimIdle is how long an entry may stay unacknowledged by another consumer
+	// (e.g. a crashed instance) before this consumer takes it over.
+	streamClaimIdle = time.Minute
+	// streamConsumerIdle is how long a consumer may go without reading before it is
+	// taken for gone and removed from the group, once it has nothing pending.
+	streamConsumerIdle = time.Hour
+)
+
+// StreamQueue stores jobs in Redis Streams read through a consumer group.
+// Entries stay pending until acknowledged; on startup a consumer first replays its own
+// pending entries, and it periodically claims entries abandoned by other consumers,
+// then removes those consumers from the group. Acknowledged entries are deleted so the
+// stream length equals the backlog.
+type StreamQueue struct {
+	rdb      *redis.Client
+	consumer string
+
+	mu      sync.Mutex
+	streams map[string]*streamState
+}
+
+// streamState is the per-stream read state. Each worker reads its own stream, so
+// locking per stream keeps one blocking read from stalling the other workers.
+type streamState struct {
+	mu         sync.Mutex
+	ready      bool   // consumer group exists
+	replayed   bool   // own pending entries were re-read after startup
+	pendingID  string // cursor into own pending entries while replaying
+	lastClaim  time.Time
+	claimStart string
+	buffered   []*Message
+}
+
+// NewStreamQueue creates a StreamQueue. consumer must be unique per running instance
+// and stable across its restarts.
+func NewStreamQueue(rdb *redis.Client, consumer string) *StreamQueue {
+	return &StreamQueue{
+		rdb:      rdb,
+		consumer: consumer,
+		streams:  make(map[string]*streamState),
+	}
+}
+
+func (q *StreamQueue) Push(ctx context.Context, name string, bodies ...[]byte) error {
+	if len(bodies) == 0 {
+		return nil
+	}
+	pipe := q.rdb.Pipeline()
+	for _, b := range bodies {
+		q.PushPipe(ctx, pipe, name, b)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (q *StreamQueue) PushPipe(ctx context.Context, pipe redis.Pipeliner, name string, body []byte) {
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamPrefix + name,
+		Values: map[string]interface{}{streamField: body},
+	})
+}
+
+func (q *StreamQueue) Pop(ctx context.Context, name string, timeout time.Duration) (*Message, error) {
+	st := q.state(name)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if len(st.buffered) == 0 {
+		if err := q.fill(ctx, name, st, timeout); err != nil {
+			return nil, err
+		}
+	}
+	if len(st.buffered) == 0 {
+		return nil, ErrEmpty
+	}
+	msg := st.buffered[0]
+	st.buffered = st.buffered[1:]
+	return msg, nil
+}
+
+func (q *StreamQueue) Ack(ctx context.Context, name string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	stream := streamPrefix + name
+	pipe := q.rdb.Pipeline()
+	pipe.XAck(ctx, stream, streamGroup, ids...)
+	pipe.XDel(ctx, stream, ids...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (q *StreamQueue) Len(ctx context.Context, name string) (int64, error) {
+	return q.rdb.XLen(ctx, streamPrefix+name).Result()
+}
+
+func (q *StreamQueue) state(name string) *streamState {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	st, ok := q.streams[name]
+	if !ok {
+		st = &streamState{pendingID: "0", claimStart: "0-0"}
+		q.streams[name] = st
+	}
+	return st
+}
+
+// fill loads the next entries into the local buffer: own pending entries first, then
+// entries abandoned by other consumers, then new entries (blocking up to timeout).
+func (q *StreamQueue) fill(ctx context.Context, name string, st *streamState, timeout time.Duration) error {
+	stream := streamPrefix + name
+
+	if !st.ready {
+		err := q.rdb.XGroupCreateMkStream(ctx, stream, streamGroup, "0").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return err
+		}
+		st.ready = true
+	}
+
+	if !st.replayed {
+		msgs, err := q.read(ctx, stream, st.pendingID, 0)
+		if err != nil {
+			return err
+		}
+		if len(msgs) > 0 {
+			// Pending entries stay pending until acked, so continue after the last one.
+			st.pendingID = msgs[len(msgs)-1].ID
+			st.buffered = msgs
+			return nil
+		}
+		st.replayed = true
+	}
+
+	if time.Since(st.lastClaim) >= streamClaimIdle {
+		st.lastClaim = time.Now()
+		entries, next, err := q.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   stream,
+			Group:    streamGroup,
+			Consumer: q.consumer,
+			MinIdle:  streamClaimIdle,
+			Start:    st.claimStart,
+			Count:    streamReadCount,
+		}).Result()
+		if err != nil {
+			return err
+		}
+		st.claimStart = next
+		if next != "0-0" {
+			st.lastClaim = time.Time{} // more to claim on the next fill
+		} else if err := q.pruneConsumers(ctx, stream); err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			st.buffered = toMessages(entries)
+			return nil
+		}
+	}
+
+	msgs, err := q.read(ctx, stream, ">", timeout)
+	if err != nil {
+		return err
+	}
+	st.buffered = msgs
+	return nil
+}
+
+// pruneConsumers removes consumers that stopped reading long ago, such as instances
+// since renamed or shut down. Consumers with pending entries are kept until those are
+// claimed, since removing a consumer drops its pending entries.
+func (q *StreamQueue) pruneConsumers(ctx context.Context, stream string) error {
+	consumers, err := q.rdb.XInfoConsumers(ctx, stream, streamGroup).Result()
+	if err != nil {
+		return err
+	}
+	for _, c := range consumers {
+		if c.Name == q.consumer || c.Pending > 0 || c.Idle < streamConsumerIdle {
+			continue
+		}
+		if err := q.rdb.XGroupDelConsumer(ctx, stream, streamGroup, c.Name).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read runs XREADGROUP from id (an ID for own pending entries, ">" for new ones).
+// A zero block means do not block.
+func (q *StreamQueue) read(ctx context.Context, stream, id string, block time.Duration) ([]*Message, error) {
+	args := &redis.XReadGroupArgs{
+		Group:    streamGroup,
+		Consumer: q.consumer,
+		Streams:  []string{stream, id},
+		Count:    streamReadCount,
+		Block:    block,
+	}
+	if block == 0 {
+		args.Block = -1 // omit BLOCK
+	}
+	res, err := q.rdb.XReadGroup(ctx, args).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var msgs []*Message
+	for _, s := range res {
+		msgs = append(msgs, toMessages(s.Messages)...)
+	}
+	return msgs, nil
+}
+
+func toMessages(entries []redis.XMessage) []*Message {
+	msgs := make([]*Message, 0, len(entries))
+	for _, e := range entries {
+		body, _ := e.Values[streamField].(string)
+		msgs = append(msgs, &Message{ID: e.ID, Body: []byte(body)})
+	}
+	return msgs
+}
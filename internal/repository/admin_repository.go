@@ -4,17 +4,18 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
 // AdminRepository handles admin data access.
 type AdminRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 }
 
 // NewAdminRepository creates a new AdminRepository.
 func NewAdminRepository(pool *pgxpool.Pool) *AdminRepository {
-	return &AdminRepository{pool: pool}
+	return &AdminRepository{pool: database.Wrap(pool)}
 }
 
 // GetByID retrieves an admin by ID.
@@ -59,6 +60,13 @@ func (r *AdminRepository) GetByIdentifier(ctx context.Context, identifier string
 	return a, nil
 }
 
+// UpdateRole changes an admin's role.
+func (r *AdminRepository) UpdateRole(ctx context.Context, id, roleID int) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE admins SET role_id = $1, updated_at = NOW() WHERE id = $2`, roleID, id)
+	return err
+}
+
 // Create inserts a new admin.
 func (r *AdminRepository) Create(ctx context.Context, a *model.Admin) error {
 	return r.pool.QueryRow(ctx,
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// AssessmentRepository handles assessment data access. Exams carry their assessment,
+// so writes changing an exam's assessment or target rules invalidate its cache.
+type AssessmentRepository struct {
+	pool  *database.DB
+	cache *ExamCache
+}
+
+// NewAssessmentRepository creates a new AssessmentRepository. cache may be nil.
+func NewAssessmentRepository(pool *pgxpool.Pool, cache *ExamCache) *AssessmentRepository {
+	return &AssessmentRepository{pool: database.Wrap(pool), cache: cache}
+}
+
+const assessmentColumns = `a.id, a.title, a.description, a.target_rules, a.author_id, a.created_at, a.updated_at,
+	(SELECT COUNT(*) FROM exams e WHERE e.assessment_id = a.id)`
+
+func scanAssessment(row pgx.Row, a *model.Assessment) error {
+	return row.Scan(&a.ID, &a.Title, &a.Description, &a.TargetRules, &a.AuthorID, &a.CreatedAt, &a.UpdatedAt, &a.ExamCount)
+}
+
+// Create inserts a new assessment.
+func (r *AssessmentRepository) Create(ctx context.Context, a *model.Assessment) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO assessments (title, description, author_id)
+		 VALUES ($1, $2, $3)
+		 RETURNING id, created_at, updated_at`,
+		a.Title, a.Description, a.AuthorID,
+	).Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+}
+
+// GetByID retrieves an assessment by its UUID, without its exams.
+func (r *AssessmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Assessment, error) {
+	var a model.Assessment
+	if err := scanAssessment(r.pool.QueryRow(ctx,
+		`SELECT `+assessmentColumns+` FROM assessments a WHERE a.id = $1`, id), &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// List retrieves every assessment, newest first.
+func (r *AssessmentRepository) List(ctx context.Context) ([]model.Assessment, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+assessmentColumns+` FROM assessments a ORDER BY a.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assessments []model.Assessment
+	for rows.Next() {
+		var a model.Assessment
+		if err := scanAssessment(rows, &a); err != nil {
+			return nil, err
+		}
+		assessments = append(assessments, a)
+	}
+	return assessments, rows.Err()
+}
+
+// Update modifies an assessment's title and description.
+func (r *AssessmentRepository) Update(ctx context.Context, a *model.Assessment) error {
+	cmdTag, err := r.pool.Exec(ctx,
+		`UPDATE assessments SET title = $1, description = $2, updated_at = NOW() WHERE id = $3`,
+		a.Title, a.Description, a.ID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete removes an assessment. Its exams remain, outside any assessment.
+func (r *AssessmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	examIDs, err := r.listExamIDs(ctx, id)
+	if err != nil {
+		return err
+	}
+	cmdTag, err := r.pool.Exec(ctx, `DELETE FROM assessments WHERE id = $1`, id)
+	r.cache.Invalidate(ctx, examIDs...)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ListExams retrieves the exams of an assessment in schedule order.
+func (r *AssessmentRepository) ListExams(ctx context.Context, id uuid.UUID) ([]model.AssessmentExam, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, title, scheduled_start, status
+		 FROM exams
+		 WHERE assessment_id = $1
+		 ORDER BY scheduled_start NULLS LAST, title`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exams := []model.AssessmentExam{}
+	for rows.Next() {
+		var e model.AssessmentExam
+		if err := rows.Scan(&e.ID, &e.Title, &e.ScheduledStart, &e.Status); err != nil {
+			return nil, err
+		}
+		exams = append(exams, e)
+	}
+	return exams, rows.Err()
+}
+
+func (r *AssessmentRepository) listExamIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id FROM exams WHERE assessment_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// SetExams makes examIDs the exams of an assessment. Exams leaving it keep their
+// target rules; exams joining it get the assessment's rules when it has any.
+func (r *AssessmentRepository) SetExams(ctx context.Context, id uuid.UUID, examIDs []uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var rules []model.AddTargetRuleRequest
+	if err := tx.QueryRow(ctx, `SELECT target_rules FROM assessments WHERE id = $1 FOR UPDATE`, id).Scan(&rules); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx,
+		`UPDATE exams SET assessment_id = NULL, updated_at = NOW()
+		 WHERE assessment_id = $1 AND NOT (id = ANY($2))
+		 RETURNING id`, id, examIDs)
+	if err != nil {
+		return err
+	}
+	changed, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return err
+	}
+
+	rows, err = tx.Query(ctx,
+		`UPDATE exams SET assessment_id = $1, updated_at = NOW()
+		 WHERE id = ANY($2) AND assessment_id IS DISTINCT FROM $1
+		 RETURNING id`, id, examIDs)
+	if err != nil {
+		return err
+	}
+	joined, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return err
+	}
+	if len(rules) > 0 {
+		if err := replaceTargetRules(ctx, tx, joined, rules); err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	r.cache.Invalidate(ctx, append(changed, joined...)...)
+	return err
+}
+
+// SetTargetRules stores the target rules of an assessment and makes them the rules
+// of each of its exams.
+func (r *AssessmentRepository) SetTargetRules(ctx context.Context, id uuid.UUID, rules []model.AddTargetRuleRequest) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	cmdTag, err := tx.Exec(ctx,
+		`UPDATE assessments SET target_rules = $1::jsonb, updated_at = NOW() WHERE id = $2`, rules, id)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	rows, err := tx.Query(ctx, `SELECT id FROM exams WHERE assessment_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	examIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return err
+	}
+	if err := replaceTargetRules(ctx, tx, examIDs, rules); err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	r.cache.Invalidate(ctx, examIDs...)
+	return err
+}
+
+// replaceTargetRules replaces the target rules of each of examIDs with rules.
+func replaceTargetRules(ctx context.Context, tx pgx.Tx, examIDs []uuid.UUID, rules []model.AddTargetRuleRequest) error {
+	if len(examIDs) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM exam_target_rules WHERE exam_id = ANY($1)`, examIDs); err != nil {
+		return err
+	}
+	for _, examID := range examIDs {
+		for _, rule := range rules {
+			if _, err := tx.Exec(ctx,
+				`INSERT INTO exam_target_rules (exam_id, class_id, grade_level, major_code, religion)
+				 VALUES ($1, $2, $3, $4, $5)`,
+				examID, rule.ClassID, rule.GradeLevel, rule.MajorCode, rule.Religion,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ListScores retrieves the sessions of every student in the assessment's exams,
+// optionally of one class, ordered by class and name.
+func (r *AssessmentRepository) ListScores(ctx context.Context, id uuid.UUID, classID *int) ([]model.AssessmentScore, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT s.id, s.nisn, s.name,
+		        COALESCE(c.grade_level || ' ' || c.major_code || ' ' || c.group_number::text, ''),
+		        es.exam_id, es.status, es.final_score
+		 FROM exam_sessions es
+		 JOIN exams e ON e.id = es.exam_id
+		 JOIN students s ON s.id = es.student_id
+		 LEFT JOIN classes c ON c.id = s.class_id
+		 WHERE e.assessment_id = $1
+		   AND ($2::int IS NULL OR s.class_id = $2)
+		 ORDER BY c.grade_level, c.major_code, c.group_number, s.name, s.id`,
+		id, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scores []model.AssessmentScore
+	for rows.Next() {
+		var sc model.AssessmentScore
+		if err := rows.Scan(&sc.StudentID, &sc.NISN, &sc.Name, &sc.ClassName, &sc.ExamID, &sc.Status, &sc.FinalScore); err != nil {
+			return nil, err
+		}
+		scores = append(scores, sc)
+	}
+	return scores, rows.Err()
+}
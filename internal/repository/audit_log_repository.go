@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// AuditLogRepository handles database operations for the audit log.
+type AuditLogRepository struct {
+	pool *database.DB
+}
+
+// NewAuditLogRepository creates a new AuditLogRepository.
+func NewAuditLogRepository(pool *pgxpool.Pool) *AuditLogRepository {
+	return &AuditLogRepository{pool: database.Wrap(pool)}
+}
+
+// Create inserts an audit log entry.
+func (r *AuditLogRepository) Create(ctx context.Context, entry *model.AuditLog) error {
+	details := entry.Details
+	if len(details) == 0 {
+		details = []byte(`{}`)
+	}
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO audit_logs (admin_id, action, target_type, target_id, details, ip)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, created_at`,
+		entry.AdminID, entry.Action, entry.TargetType, entry.TargetID, details, entry.IP,
+	).Scan(&entry.ID, &entry.CreatedAt)
+}
@@ -2,28 +2,33 @@ package repository
 
 import (
 	"context"
+	"errors"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
 // ClassRepository handles class data access.
 type ClassRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 }
 
 // NewClassRepository creates a new ClassRepository.
 func NewClassRepository(pool *pgxpool.Pool) *ClassRepository {
-	return &ClassRepository{pool: pool}
+	return &ClassRepository{pool: database.Wrap(pool)}
 }
 
 // GetByID retrieves a class by its ID.
 func (r *ClassRepository) GetByID(ctx context.Context, id int) (*model.Class, error) {
 	c := &model.Class{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, grade_level, major_code, group_number, created_at, updated_at
-		 FROM classes WHERE id = $1`, id,
-	).Scan(&c.ID, &c.GradeLevel, &c.MajorCode, &c.GroupNumber, &c.CreatedAt, &c.UpdatedAt)
+		`SELECT c.id, c.grade_level, c.major_code, c.group_number, c.created_at, c.updated_at, c.homeroom_admin_id, a.name
+		 FROM classes c
+		 LEFT JOIN admins a ON a.id = c.homeroom_admin_id
+		 WHERE c.id = $1`, id,
+	).Scan(&c.ID, &c.GradeLevel, &c.MajorCode, &c.GroupNumber, &c.CreatedAt, &c.UpdatedAt, &c.HomeroomAdminID, &c.HomeroomName)
 	if err != nil {
 		return nil, err
 	}
@@ -33,8 +38,10 @@ func (r *ClassRepository) GetByID(ctx context.Context, id int) (*model.Class, er
 // List retrieves all classes.
 func (r *ClassRepository) List(ctx context.Context) ([]model.Class, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, grade_level, major_code, group_number, created_at, updated_at
-		 FROM classes ORDER BY grade_level, major_code, group_number`)
+		`SELECT c.id, c.grade_level, c.major_code, c.group_number, c.created_at, c.updated_at, c.homeroom_admin_id, a.name
+		 FROM classes c
+		 LEFT JOIN admins a ON a.id = c.homeroom_admin_id
+		 ORDER BY c.grade_level, c.major_code, c.group_number`)
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +50,7 @@ func (r *ClassRepository) List(ctx context.Context) ([]model.Class, error) {
 	var classes []model.Class
 	for rows.Next() {
 		var c model.Class
-		if err := rows.Scan(&c.ID, &c.GradeLevel, &c.MajorCode, &c.GroupNumber, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.GradeLevel, &c.MajorCode, &c.GroupNumber, &c.CreatedAt, &c.UpdatedAt, &c.HomeroomAdminID, &c.HomeroomName); err != nil {
 			return nil, err
 		}
 		classes = append(classes, c)
@@ -61,6 +68,44 @@ func (r *ClassRepository) Create(ctx context.Context, c *model.Class) error {
 	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
 }
 
+// CreateMissing inserts the classes that do not exist yet in one transaction. Each
+// class is filled in with its ID and timestamps, whether new or existing; the
+// returned flags report which ones were created.
+func (r *ClassRepository) CreateMissing(ctx context.Context, classes []model.Class) ([]bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	created := make([]bool, len(classes))
+	for i := range classes {
+		c := &classes[i]
+		err := tx.QueryRow(ctx,
+			`INSERT INTO classes (grade_level, major_code, group_number)
+			 VALUES ($1, $2, $3)
+			 ON CONFLICT (grade_level, major_code, group_number) DO NOTHING
+			 RETURNING id, created_at, updated_at`,
+			c.GradeLevel, c.MajorCode, c.GroupNumber,
+		).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+		if err == nil {
+			created[i] = true
+			continue
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		if err := tx.QueryRow(ctx,
+			`SELECT id, created_at, updated_at FROM classes
+			 WHERE grade_level = $1 AND major_code = $2 AND group_number = $3`,
+			c.GradeLevel, c.MajorCode, c.GroupNumber,
+		).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+	}
+	return created, tx.Commit(ctx)
+}
+
 // Update modifies an existing class.
 func (r *ClassRepository) Update(ctx context.Context, c *model.Class) error {
 	_, err := r.pool.Exec(ctx,
@@ -71,6 +116,21 @@ func (r *ClassRepository) Update(ctx context.Context, c *model.Class) error {
 	return err
 }
 
+// SetHomeroom assigns a class's homeroom teacher, or removes it when adminID is nil.
+func (r *ClassRepository) SetHomeroom(ctx context.Context, id int, adminID *int) error {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE classes SET homeroom_admin_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		adminID, id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
 // Delete removes a class by its ID.
 func (r *ClassRepository) Delete(ctx context.Context, id int) error {
 	_, err := r.pool.Exec(ctx, `DELETE FROM classes WHERE id = $1`, id)
@@ -2,20 +2,24 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
 // DashboardRepository handles admin dashboard data access.
 type DashboardRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 }
 
 // NewDashboardRepository creates a new DashboardRepository.
 func NewDashboardRepository(pool *pgxpool.Pool) *DashboardRepository {
-	return &DashboardRepository{pool: pool}
+	return &DashboardRepository{pool: database.Wrap(pool)}
 }
 
 // GetSummaryCounts retrieves the high-level metrics for the dashboard.
@@ -58,14 +62,15 @@ type DashboardUpcomingExam struct {
 	Duration       int              `json:"duration_minutes"`
 }
 
-// GetUpcomingExams retrieves the next N scheduled exams that are PUBLISHED.
+// GetUpcomingExams retrieves the next N scheduled exams that are live (PUBLISHED or
+// IN_PROGRESS, as an exam opened early is already in progress).
 func (r *DashboardRepository) GetUpcomingExams(ctx context.Context, limit int) ([]DashboardUpcomingExam, error) {
 	rows, err := r.pool.Query(ctx,
 		`SELECT id, title, scheduled_start, duration_minutes 
 		 FROM exams 
-		 WHERE status = $1 AND scheduled_start > NOW() 
-		 ORDER BY scheduled_start ASC LIMIT $2`,
-		model.ExamStatusPublished, limit,
+		 WHERE status IN ($1, $2) AND scheduled_start > NOW() 
+		 ORDER BY scheduled_start ASC LIMIT $3`,
+		model.ExamStatusPublished, model.ExamStatusInProgress, limit,
 	)
 	if err != nil {
 		return nil, err
@@ -95,19 +100,19 @@ type DashboardRecentExamResult struct {
 	AverageScore     *float64         `json:"average_score"`
 }
 
-// GetRecentExamResults retrieves the last N completed or archived exams with session completion stats.
+// GetRecentExamResults retrieves the last N completed or archived exams with their
+// session stats, as last computed by the stats worker.
 func (r *DashboardRepository) GetRecentExamResults(ctx context.Context, limit int) ([]DashboardRecentExamResult, error) {
 	query := `
 		SELECT 
 			e.id, 
 			e.title, 
 			COALESCE(e.scheduled_end, e.updated_at::timestamp) as end_time,
-			COUNT(s.id) as participant_count,
-			AVG(s.final_score) as average_score
+			COALESCE(st.participants, 0) as participant_count,
+			st.average_score
 		FROM exams e
-		LEFT JOIN exam_sessions s ON e.id = s.exam_id
+		LEFT JOIN exam_stats st ON st.exam_id = e.id
 		WHERE e.status IN ($1, $2)
-		GROUP BY e.id, e.title, end_time
 		ORDER BY end_time DESC
 		LIMIT $3
 	`
@@ -130,3 +135,118 @@ func (r *DashboardRepository) GetRecentExamResults(ctx context.Context, limit in
 	}
 	return results, rows.Err()
 }
+
+// GetUpcomingExamsBetween retrieves up to limit live exams scheduled to start within [from, to).
+func (r *DashboardRepository) GetUpcomingExamsBetween(ctx context.Context, from, to model.LocalTime, limit int) ([]DashboardUpcomingExam, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, title, scheduled_start, duration_minutes
+		 FROM exams
+		 WHERE status IN ($1, $2) AND scheduled_start >= $3 AND scheduled_start < $4
+		 ORDER BY scheduled_start ASC LIMIT $5`,
+		model.ExamStatusPublished, model.ExamStatusInProgress, &from, &to, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exams := make([]DashboardUpcomingExam, 0)
+	for rows.Next() {
+		var e DashboardUpcomingExam
+		if err := rows.Scan(&e.ID, &e.Title, &e.ScheduledStart, &e.Duration); err != nil {
+			return nil, err
+		}
+		exams = append(exams, e)
+	}
+	return exams, rows.Err()
+}
+
+// GetRecentExamResultsBetween retrieves up to limit completed or archived exams that
+// ended within [from, to), most recent first, with their session stats.
+func (r *DashboardRepository) GetRecentExamResultsBetween(ctx context.Context, from, to model.LocalTime, limit int) ([]DashboardRecentExamResult, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT e.id, e.title, e.end_time, COALESCE(st.participants, 0), st.average_score
+		 FROM (
+		     SELECT id, title, COALESCE(scheduled_end, updated_at::timestamp) AS end_time
+		     FROM exams
+		     WHERE status IN ($1, $2)
+		 ) e
+		 LEFT JOIN exam_stats st ON st.exam_id = e.id
+		 WHERE e.end_time >= $3 AND e.end_time < $4
+		 ORDER BY e.end_time DESC
+		 LIMIT $5`,
+		model.ExamStatusCompleted, model.ExamStatusArchived, &from, &to, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]DashboardRecentExamResult, 0)
+	for rows.Next() {
+		var res DashboardRecentExamResult
+		if err := rows.Scan(&res.ID, &res.Title, &res.EndDateTime, &res.ParticipantCount, &res.AverageScore); err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
+
+// GetTopCheatAlerts retrieves the up to limit students with the most cheat events in
+// one exam recorded within [from, to).
+func (r *DashboardRepository) GetTopCheatAlerts(ctx context.Context, from, to time.Time, limit int) ([]model.CheatAlert, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT s.id, s.name, CONCAT(cl.grade_level, ' ', cl.major_code, ' ', cl.group_number),
+		        e.id::text, e.title, COUNT(*), MAX(c.recorded_at)
+		 FROM exam_cheats c
+		 JOIN students s ON s.id = c.student_id
+		 JOIN classes cl ON cl.id = s.class_id
+		 JOIN exams e ON e.id = c.exam_id
+		 WHERE c.recorded_at >= $1 AND c.recorded_at < $2
+		 GROUP BY s.id, cl.id, e.id
+		 ORDER BY COUNT(*) DESC, MAX(c.recorded_at) DESC
+		 LIMIT $3`,
+		from, to, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]model.CheatAlert, 0)
+	for rows.Next() {
+		var a model.CheatAlert
+		if err := rows.Scan(&a.StudentID, &a.Name, &a.ClassName, &a.ExamID, &a.ExamTitle, &a.Events, &a.LastSeenAt); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// GetLayout retrieves an admin's saved dashboard layout, or nil when they never saved one.
+func (r *DashboardRepository) GetLayout(ctx context.Context, adminID int) (*model.DashboardLayout, error) {
+	l := &model.DashboardLayout{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT widgets, updated_at FROM admin_dashboard_layouts WHERE admin_id = $1`, adminID,
+	).Scan(&l.Widgets, &l.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// UpsertLayout saves an admin's dashboard layout.
+func (r *DashboardRepository) UpsertLayout(ctx context.Context, adminID int, l *model.DashboardLayout) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO admin_dashboard_layouts (admin_id, widgets)
+		 VALUES ($1, $2)
+		 ON CONFLICT (admin_id) DO UPDATE SET widgets = EXCLUDED.widgets, updated_at = NOW()
+		 RETURNING updated_at`,
+		adminID, l.Widgets,
+	).Scan(&l.UpdatedAt)
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// EraporRepository handles e-Rapor mapping and score data access.
+type EraporRepository struct {
+	pool *database.DB
+}
+
+// NewEraporRepository creates a new EraporRepository.
+func NewEraporRepository(pool *pgxpool.Pool) *EraporRepository {
+	return &EraporRepository{pool: database.Wrap(pool)}
+}
+
+// GetMapping retrieves an exam's e-Rapor mapping.
+func (r *EraporRepository) GetMapping(ctx context.Context, examID uuid.UUID) (*model.EraporMapping, error) {
+	m := &model.EraporMapping{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT exam_id, subject_code, competency_code, created_at, updated_at
+		 FROM exam_erapor_mappings WHERE exam_id = $1`, examID,
+	).Scan(&m.ExamID, &m.SubjectCode, &m.CompetencyCode, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// UpsertMapping creates or replaces an exam's e-Rapor mapping.
+func (r *EraporRepository) UpsertMapping(ctx context.Context, m *model.EraporMapping) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO exam_erapor_mappings (exam_id, subject_code, competency_code)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (exam_id) DO UPDATE SET
+			subject_code = EXCLUDED.subject_code,
+			competency_code = EXCLUDED.competency_code,
+			updated_at = NOW()
+		 RETURNING created_at, updated_at`,
+		m.ExamID, m.SubjectCode, m.CompetencyCode,
+	).Scan(&m.CreatedAt, &m.UpdatedAt)
+}
+
+// DeleteMapping removes an exam's e-Rapor mapping.
+func (r *EraporRepository) DeleteMapping(ctx context.Context, examID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM exam_erapor_mappings WHERE exam_id = $1`, examID)
+	return err
+}
+
+// ListScores returns per-student competency scores of an e-Rapor subject, taken from
+// completed sessions of completed exams mapped to it. classID optionally narrows to one class.
+func (r *EraporRepository) ListScores(ctx context.Context, subjectCode string, classID *int) ([]model.EraporScore, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT s.id, s.nis, s.nisn, s.name,
+			CONCAT(c.grade_level, ' ', c.major_code, ' ', c.group_number) AS class_name,
+			m.competency_code, ROUND(AVG(es.final_score), 2)::float8
+		 FROM exam_erapor_mappings m
+		 JOIN exams e ON e.id = m.exam_id AND e.status = 'COMPLETED'
+		 JOIN exam_sessions es ON es.exam_id = e.id AND es.status = 'COMPLETED' AND es.final_score IS NOT NULL
+		 JOIN students s ON s.id = es.student_id
+		 JOIN classes c ON c.id = s.class_id
+		 WHERE m.subject_code = $1 AND ($2::int IS NULL OR s.class_id = $2)
+		 GROUP BY s.id, c.grade_level, c.major_code, c.group_number, m.competency_code
+		 ORDER BY class_name, s.name, m.competency_code`,
+		subjectCode, classID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scores []model.EraporScore
+	for rows.Next() {
+		var sc model.EraporScore
+		if err := rows.Scan(&sc.StudentID, &sc.NIS, &sc.NISN, &sc.Name, &sc.ClassName, &sc.CompetencyCode, &sc.Score); err != nil {
+			return nil, err
+		}
+		scores = append(scores, sc)
+	}
+	return scores, rows.Err()
+}
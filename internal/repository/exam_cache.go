@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"golang.org/x/sync/singleflight"
+)
+
+// ExamCache keeps exam rows and target rules in process memory for a short TTL, as the
+// lobby, join, session and monitor paths read them on nearly every request. Concurrent
+// misses for one exam share a single query. Writes through ExamRepository and
+// ExamTargetRuleRepository drop the exam from this instance right away and from every
+// other instance over Redis pub/sub; the TTL bounds staleness when a message is lost.
+// A nil *ExamCache caches nothing.
+type ExamCache struct {
+	rdb *redis.Client
+	ttl time.Duration
+	log zerolog.Logger
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]*examCacheEntry
+	// versions counts the invalidations of each exam, and epoch those of every exam at
+	// once, so that a load racing one is neither shared with later readers nor stored.
+	versions map[uuid.UUID]uint64
+	epoch    uint64
+	loads    singleflight.Group
+}
+
+type examCacheEntry struct {
+	exam  cacheSlot[model.Exam]
+	rules cacheSlot[[]model.ExamTargetRule]
+}
+
+type cacheSlot[T any] struct {
+	value T
+	until time.Time
+}
+
+// examCacheDropAll is announced in place of an exam ID to drop every exam.
+const examCacheDropAll = "*"
+
+// NewExamCache creates an ExamCache, or returns nil when ttl disables it.
+func NewExamCache(rdb *redis.Client, ttl time.Duration, log zerolog.Logger) *ExamCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &ExamCache{
+		rdb:      rdb,
+		ttl:      ttl,
+		log:      log.With().Str("component", "exam_cache").Logger(),
+		entries:  make(map[uuid.UUID]*examCacheEntry),
+		versions: make(map[uuid.UUID]uint64),
+	}
+}
+
+// Listen drops the exams other instances announce as changed until ctx is done.
+func (c *ExamCache) Listen(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	sub := c.rdb.Subscribe(ctx, config.CacheKey.ExamCacheChannel())
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-sub.Channel():
+			if !ok {
+				return
+			}
+			if msg.Payload == examCacheDropAll {
+				c.dropAll()
+			} else if id, err := uuid.Parse(msg.Payload); err == nil {
+				c.drop(id)
+			}
+		}
+	}
+}
+
+// Invalidate drops an exam's row and target rules here and on every other instance.
+func (c *ExamCache) Invalidate(ctx context.Context, ids ...uuid.UUID) {
+	if c == nil {
+		return
+	}
+	for _, id := range ids {
+		c.drop(id)
+		if err := c.rdb.Publish(ctx, config.CacheKey.ExamCacheChannel(), id.String()).Err(); err != nil {
+			c.log.Warn().Err(err).Str("exam_id", id.String()).Msg("Failed to announce exam cache invalidation")
+		}
+	}
+}
+
+// InvalidateAll drops every exam here and on every other instance, as after the
+// database is restored.
+func (c *ExamCache) InvalidateAll(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	c.dropAll()
+	if err := c.rdb.Publish(ctx, config.CacheKey.ExamCacheChannel(), examCacheDropAll).Err(); err != nil {
+		c.log.Warn().Err(err).Msg("Failed to announce exam cache invalidation")
+	}
+}
+
+func (c *ExamCache) dropAll() {
+	c.mu.Lock()
+	clear(c.entries)
+	c.epoch++
+	c.mu.Unlock()
+}
+
+func (c *ExamCache) drop(id uuid.UUID) {
+	c.mu.Lock()
+	delete(c.entries, id)
+	c.versions[id]++
+	c.mu.Unlock()
+}
+
+// exam returns a copy of the cached row of an exam, loading it on a miss.
+func (c *ExamCache) exam(ctx context.Context, id uuid.UUID, load func(context.Context) (*model.Exam, error)) (*model.Exam, error) {
+	if c == nil {
+		return load(ctx)
+	}
+	e, err := cached(ctx, c, "exam", id,
+		func(entry *examCacheEntry) *cacheSlot[model.Exam] { return &entry.exam },
+		func(ctx context.Context) (model.Exam, error) {
+			e, err := load(ctx)
+			if err != nil {
+				return model.Exam{}, err
+			}
+			return *e, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// rules returns a copy of the cached target rules of an exam, loading them on a miss.
+func (c *ExamCache) rules(ctx context.Context, examID uuid.UUID, load func(context.Context) ([]model.ExamTargetRule, error)) ([]model.ExamTargetRule, error) {
+	if c == nil {
+		return load(ctx)
+	}
+	rules, err := cached(ctx, c, "rules", examID,
+		func(entry *examCacheEntry) *cacheSlot[[]model.ExamTargetRule] { return &entry.rules },
+		load)
+	return slices.Clone(rules), err
+}
+
+// cached serves one slot of an exam's entry while it is fresh and otherwise loads it.
+// The load runs detached from ctx, as other readers may be waiting on it.
+func cached[T any](
+	ctx context.Context,
+	c *ExamCache,
+	kind string,
+	id uuid.UUID,
+	slot func(*examCacheEntry) *cacheSlot[T],
+	load func(context.Context) (T, error),
+) (T, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[id]; ok {
+		if s := slot(entry); time.Now().Before(s.until) {
+			c.mu.Unlock()
+			return s.value, nil
+		}
+	}
+	version, epoch := c.versions[id], c.epoch
+	c.mu.Unlock()
+
+	key := fmt.Sprintf("%s:%s:%d:%d", kind, id, epoch, version)
+	v, err, _ := c.loads.Do(key, func() (interface{}, error) {
+		return load(context.WithoutCancel(ctx))
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	value := v.(T)
+
+	c.mu.Lock()
+	if c.versions[id] == version && c.epoch == epoch {
+		entry, ok := c.entries[id]
+		if !ok {
+			entry = &examCacheEntry{}
+			c.entries[id] = entry
+		}
+		*slot(entry) = cacheSlot[T]{value: value, until: time.Now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+	return value, nil
+}
@@ -2,32 +2,42 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
-// ExamRepository handles exam data access.
+// ExamRepository handles exam data access. Exams read by ID are served from cache,
+// which every write here invalidates.
 type ExamRepository struct {
-	pool *pgxpool.Pool
+	pool  *database.DB
+	cache *ExamCache
 }
 
-// NewExamRepository creates a new ExamRepository.
-func NewExamRepository(pool *pgxpool.Pool) *ExamRepository {
-	return &ExamRepository{pool: pool}
+// NewExamRepository creates a new ExamRepository. cache may be nil.
+func NewExamRepository(pool *pgxpool.Pool, cache *ExamCache) *ExamRepository {
+	return &ExamRepository{pool: database.Wrap(pool), cache: cache}
 }
 
 // GetByID retrieves an exam by its UUID.
 func (r *ExamRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Exam, error) {
+	return r.cache.exam(ctx, id, func(ctx context.Context) (*model.Exam, error) {
+		return r.getByID(ctx, id)
+	})
+}
+
+func (r *ExamRepository) getByID(ctx context.Context, id uuid.UUID) (*model.Exam, error) {
 	e := &model.Exam{}
 	err := r.pool.QueryRow(ctx,
 		`SELECT e.id, e.title, e.author_id, e.scheduled_start, e.scheduled_end,
-		        e.duration_minutes, e.entry_token, e.cheat_rules, e.randomize_questions, e.question_count, e.qbank_id, e.status, e.created_at, e.updated_at
+		        e.duration_minutes, e.entry_token, e.cheat_rules, e.randomize_questions, e.question_count, e.qbank_id, e.assessment_id, e.show_class_average, e.allow_review, e.public_results, e.results_access_code, e.navigation_policy, e.section_size, e.max_breaks, e.break_minutes, e.disconnect_pause_minutes, e.instructions, e.honor_code, e.metadata, e.language, e.languages, e.forced_language, e.status, e.created_at, e.updated_at
 		 FROM exams e
 		 WHERE e.id = $1`, id,
 	).Scan(&e.ID, &e.Title, &e.AuthorID, &e.ScheduledStart, &e.ScheduledEnd,
-		&e.DurationMinutes, &e.EntryToken, &e.CheatRules, &e.RandomizeQuestions, &e.QuestionCount, &e.QBankID, &e.Status, &e.CreatedAt, &e.UpdatedAt)
+		&e.DurationMinutes, &e.EntryToken, &e.CheatRules, &e.RandomizeQuestions, &e.QuestionCount, &e.QBankID, &e.AssessmentID, &e.ShowClassAverage, &e.AllowReview, &e.PublicResults, &e.ResultsAccessCode, &e.NavigationPolicy, &e.SectionSize, &e.MaxBreaks, &e.BreakMinutes, &e.DisconnectPauseMinutes, &e.Instructions, &e.HonorCode, &e.Metadata, &e.Language, &e.Languages, &e.ForcedLanguage, &e.Status, &e.CreatedAt, &e.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -35,23 +45,30 @@ func (r *ExamRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Exam
 }
 
 // ListByAuthorPaginated retrieves exams filtered by author with pagination.
-// Pass authorID=0 to list all exams (superadmin).
-func (r *ExamRepository) ListByAuthorPaginated(ctx context.Context, limit, offset int) ([]model.Exam, int, error) {
+// Pass authorID=0 to list all exams (superadmin). With metadata set, only exams whose
+// metadata contains it are listed.
+func (r *ExamRepository) ListByAuthorPaginated(ctx context.Context, metadata map[string]any, limit, offset int) ([]model.Exam, int, error) {
+	where := ""
+	var filterArgs []interface{}
+	if len(metadata) > 0 {
+		where = ` WHERE e.metadata @> $1::jsonb`
+		filterArgs = append(filterArgs, metadata)
+	}
+
 	// 1. Get total count
-	countQuery := `SELECT COUNT(*) FROM exams`
-	var countArgs []interface{}
+	countQuery := `SELECT COUNT(*) FROM exams e` + where
 
 	var total int
-	if err := r.pool.QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+	if err := r.pool.QueryRow(ctx, countQuery, filterArgs...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
 	// 2. Get paginated data
 	query := `SELECT e.id, e.title, e.author_id, e.scheduled_start, e.scheduled_end,
-	                  e.duration_minutes, e.entry_token, e.status, e.created_at, e.updated_at
-	           FROM exams e`
-	var args []interface{}
-	argIdx := 1
+	                  e.duration_minutes, e.entry_token, e.metadata, e.status, e.created_at, e.updated_at
+	           FROM exams e` + where
+	args := filterArgs
+	argIdx := len(args) + 1
 
 	query += ` ORDER BY e.created_at DESC LIMIT $` + formatInt(argIdx) + ` OFFSET $` + formatInt(argIdx+1)
 	args = append(args, limit, offset)
@@ -66,7 +83,7 @@ func (r *ExamRepository) ListByAuthorPaginated(ctx context.Context, limit, offse
 	for rows.Next() {
 		var e model.Exam
 		if err := rows.Scan(&e.ID, &e.Title, &e.AuthorID, &e.ScheduledStart, &e.ScheduledEnd,
-			&e.DurationMinutes, &e.EntryToken, &e.Status, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			&e.DurationMinutes, &e.EntryToken, &e.Metadata, &e.Status, &e.CreatedAt, &e.UpdatedAt); err != nil {
 			return nil, 0, err
 		}
 		exams = append(exams, e)
@@ -91,11 +108,11 @@ func formatInt(n int) string {
 // Create inserts a new exam.
 func (r *ExamRepository) Create(ctx context.Context, e *model.Exam) error {
 	return r.pool.QueryRow(ctx,
-		`INSERT INTO exams (title, author_id, scheduled_start, scheduled_end, duration_minutes, entry_token, status)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`INSERT INTO exams (title, author_id, scheduled_start, scheduled_end, duration_minutes, entry_token, status, qbank_id, metadata)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		 RETURNING id, created_at, updated_at`,
 		e.Title, e.AuthorID, e.ScheduledStart, e.ScheduledEnd,
-		e.DurationMinutes, e.EntryToken, e.Status,
+		e.DurationMinutes, e.EntryToken, e.Status, e.QBankID, e.Metadata,
 	).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
 }
 
@@ -104,18 +121,131 @@ func (r *ExamRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status
 	_, err := r.pool.Exec(ctx,
 		`UPDATE exams SET status = $1, updated_at = NOW() WHERE id = $2`,
 		status, id)
+	r.cache.Invalidate(ctx, id)
 	return err
 }
 
-// ListPublished returns all exams with PUBLISHED status.
+// RevertToDraft atomically sets a PUBLISHED exam back to DRAFT, but only while
+// no exam session exists for it. Returns false when nothing was updated.
+func (r *ExamRepository) RevertToDraft(ctx context.Context, id uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE exams SET status = $1, updated_at = NOW()
+		 WHERE id = $2 AND status = $3
+		   AND NOT EXISTS (SELECT 1 FROM exam_sessions WHERE exam_id = $2)`,
+		model.ExamStatusDraft, id, model.ExamStatusPublished)
+	if err != nil {
+		return false, err
+	}
+	r.cache.Invalidate(ctx, id)
+	return tag.RowsAffected() > 0, nil
+}
+
+// CountSessions returns how many sessions an exam has and how many of them are completed.
+func (r *ExamRepository) CountSessions(ctx context.Context, id uuid.UUID) (total, completed int, err error) {
+	err = r.pool.QueryRow(ctx,
+		`SELECT COUNT(*), COUNT(*) FILTER (WHERE status = $2)
+		 FROM exam_sessions WHERE exam_id = $1`,
+		id, model.SessionStatusCompleted,
+	).Scan(&total, &completed)
+	return total, completed, err
+}
+
+// Ping checks database connectivity.
+func (r *ExamRepository) Ping(ctx context.Context) error {
+	return r.pool.Ping(ctx)
+}
+
+// MarkStartedInProgress moves PUBLISHED exams that have at least one session to IN_PROGRESS.
+// Returns the IDs of the exams that changed.
+func (r *ExamRepository) MarkStartedInProgress(ctx context.Context) ([]uuid.UUID, error) {
+	return r.collectIDs(ctx,
+		`UPDATE exams e SET status = $1, updated_at = NOW()
+		 WHERE e.status = $2
+		   AND EXISTS (SELECT 1 FROM exam_sessions s WHERE s.exam_id = e.id)
+		 RETURNING e.id`,
+		model.ExamStatusInProgress, model.ExamStatusPublished)
+}
+
+// MarkFinishedCompleted moves live exams whose scheduled window has ended and that
+// have no IN_PROGRESS sessions left to COMPLETED. Once the window end plus the exam's
+// duration and grace has passed, even a student who started last has run out of time,
+// so sessions still IN_PROGRESS are stale and no longer hold the exam back. Returns
+// the IDs of the exams that changed.
+func (r *ExamRepository) MarkFinishedCompleted(ctx context.Context, now model.LocalTime, grace time.Duration) ([]uuid.UUID, error) {
+	return r.collectIDs(ctx,
+		`UPDATE exams e SET status = $1, updated_at = NOW()
+		 WHERE e.status IN ($2, $3)
+		   AND e.scheduled_end IS NOT NULL AND e.scheduled_end < $4
+		   AND (
+		       e.scheduled_end + make_interval(mins => e.duration_minutes + $6) < $4
+		       OR NOT EXISTS (
+		           SELECT 1 FROM exam_sessions s
+		           WHERE s.exam_id = e.id AND s.status = $5
+		       )
+		   )
+		 RETURNING e.id`,
+		model.ExamStatusCompleted, model.ExamStatusPublished, model.ExamStatusInProgress, &now, model.SessionStatusInProgress,
+		int(grace/time.Minute))
+}
+
+// collectIDs runs a status update returning the IDs of the exams it changed.
+func (r *ExamRepository) collectIDs(ctx context.Context, query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	r.cache.Invalidate(ctx, ids...)
+	return ids, rows.Err()
+}
+
+// ListScheduledBetween returns non-archived exams whose scheduled window overlaps [from, to).
+// Exams without a scheduled_end are treated as ending scheduled_start + duration.
+func (r *ExamRepository) ListScheduledBetween(ctx context.Context, from, to model.LocalTime) ([]model.Exam, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT e.id, e.title, e.author_id, e.scheduled_start, e.scheduled_end,
+		        e.duration_minutes, e.entry_token, e.status, e.cheat_rules, e.randomize_questions, e.question_count, e.created_at, e.updated_at
+		 FROM exams e
+		 WHERE e.status <> $1
+		   AND e.scheduled_start IS NOT NULL
+		   AND e.scheduled_start < $3
+		   AND COALESCE(e.scheduled_end, e.scheduled_start + make_interval(mins => e.duration_minutes)) > $2
+		 ORDER BY e.scheduled_start`, model.ExamStatusArchived, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exams []model.Exam
+	for rows.Next() {
+		var e model.Exam
+		if err := rows.Scan(&e.ID, &e.Title, &e.AuthorID, &e.ScheduledStart, &e.ScheduledEnd,
+			&e.DurationMinutes, &e.EntryToken, &e.Status, &e.CheatRules, &e.RandomizeQuestions, &e.QuestionCount, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		exams = append(exams, e)
+	}
+	return exams, rows.Err()
+}
+
+// ListPublished returns all live exams (PUBLISHED or IN_PROGRESS).
 // Used for cache prewarming on application startup.
 func (r *ExamRepository) ListPublished(ctx context.Context) ([]model.Exam, error) {
 	rows, err := r.pool.Query(ctx,
 		`SELECT e.id, e.title, e.author_id, e.scheduled_start, e.scheduled_end,
 		        e.duration_minutes, e.entry_token, e.status, e.cheat_rules, e.randomize_questions, e.question_count, e.created_at, e.updated_at
 		 FROM exams e
-		 WHERE e.status = $1
-		 ORDER BY e.created_at DESC`, model.ExamStatusPublished)
+		 WHERE e.status IN ($1, $2)
+		 ORDER BY e.created_at DESC`, model.ExamStatusPublished, model.ExamStatusInProgress)
 	if err != nil {
 		return nil, err
 	}
@@ -133,18 +263,47 @@ func (r *ExamRepository) ListPublished(ctx context.Context) ([]model.Exam, error
 	return exams, rows.Err()
 }
 
+// ListPublicResults returns the exams whose results may be looked up publicly.
+func (r *ExamRepository) ListPublicResults(ctx context.Context) ([]model.PublicExam, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, title FROM exams
+		 WHERE public_results AND status <> $1
+		 ORDER BY created_at DESC`, model.ExamStatusDraft)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exams []model.PublicExam
+	for rows.Next() {
+		var e model.PublicExam
+		if err := rows.Scan(&e.ID, &e.Title); err != nil {
+			return nil, err
+		}
+		exams = append(exams, e)
+	}
+	return exams, rows.Err()
+}
+
 // Update modifies an existing exam's metadata.
 func (r *ExamRepository) Update(ctx context.Context, e *model.Exam) error {
 	_, err := r.pool.Exec(ctx,
 		`UPDATE exams SET title = $1, scheduled_start = $2, scheduled_end = $3,
-        duration_minutes = $4, entry_token = $5, cheat_rules = $6, randomize_questions = $7, question_count = $8, qbank_id = $9, updated_at = NOW()
- WHERE id = $10`,
-		e.Title, e.ScheduledStart, e.ScheduledEnd, e.DurationMinutes, e.EntryToken, e.CheatRules, e.RandomizeQuestions, e.QuestionCount, e.QBankID, e.ID)
+        duration_minutes = $4, entry_token = $5, cheat_rules = $6, randomize_questions = $7, question_count = $8, qbank_id = $9, show_class_average = $10,
+        public_results = $11, results_access_code = $12, navigation_policy = $13, section_size = $14,
+        max_breaks = $15, break_minutes = $16, instructions = $17, honor_code = $18, disconnect_pause_minutes = $19, metadata = $20, allow_review = $21,
+        language = $22, languages = COALESCE($23, '{}'), forced_language = $24, updated_at = NOW()
+ WHERE id = $25`,
+		e.Title, e.ScheduledStart, e.ScheduledEnd, e.DurationMinutes, e.EntryToken, e.CheatRules, e.RandomizeQuestions, e.QuestionCount, e.QBankID, e.ShowClassAverage,
+		e.PublicResults, e.ResultsAccessCode, e.NavigationPolicy, e.SectionSize, e.MaxBreaks, e.BreakMinutes, e.Instructions, e.HonorCode, e.DisconnectPauseMinutes, e.Metadata, e.AllowReview,
+		e.Language, e.Languages, e.ForcedLanguage, e.ID)
+	r.cache.Invalidate(ctx, e.ID)
 	return err
 }
 
 // Delete removes an exam.
 func (r *ExamRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	_, err := r.pool.Exec(ctx, `DELETE FROM exams WHERE id = $1`, id)
+	r.cache.Invalidate(ctx, id)
 	return err
 }
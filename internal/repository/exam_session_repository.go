@@ -2,11 +2,14 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
@@ -14,32 +17,49 @@ import (
 type ExamResult struct {
 	StudentID  int                 `json:"student_id"`
 	Name       string              `json:"name"`
-	NISN       string              `json:"nisn"`
+	NISN       string              `json:"nisn,omitempty"` // redacted for callers without students:write
 	ClassName  string              `json:"class_name"`
 	FinalScore *float64            `json:"score"`
 	Status     model.SessionStatus `json:"status"`
 	StartedAt  *time.Time          `json:"started_at"`
 	FinishedAt *time.Time          `json:"finished_at"`
+	// OfflineEntry marks a score entered by hand from a paper exam.
+	OfflineEntry bool `json:"offline_entry"`
 }
 
 // ExamSessionRepository handles exam session data access.
 type ExamSessionRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 }
 
 // NewExamSessionRepository creates a new ExamSessionRepository.
 func NewExamSessionRepository(pool *pgxpool.Pool) *ExamSessionRepository {
-	return &ExamSessionRepository{pool: pool}
+	return &ExamSessionRepository{pool: database.Wrap(pool)}
 }
 
 // GetByExamAndStudent retrieves a session for a specific exam-student combination.
 func (r *ExamSessionRepository) GetByExamAndStudent(ctx context.Context, examID uuid.UUID, studentID int) (*model.ExamSession, error) {
 	s := &model.ExamSession{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, exam_id, student_id, question_order, started_at, finished_at, status, final_score
+		`SELECT id, exam_id, student_id, question_order, started_at, finished_at, status, final_score, consent_acknowledged_at
 		 FROM exam_sessions
 		 WHERE exam_id = $1 AND student_id = $2`, examID, studentID,
-	).Scan(&s.ID, &s.ExamID, &s.StudentID, &s.QuestionOrder, &s.StartedAt, &s.FinishedAt, &s.Status, &s.FinalScore)
+	).Scan(&s.ID, &s.ExamID, &s.StudentID, &s.QuestionOrder, &s.StartedAt, &s.FinishedAt, &s.Status, &s.FinalScore, &s.ConsentAcknowledgedAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetByExamAndNISN retrieves the session of the student with the given NISN in an exam.
+func (r *ExamSessionRepository) GetByExamAndNISN(ctx context.Context, examID uuid.UUID, nisn string) (*model.ExamSession, error) {
+	s := &model.ExamSession{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT es.id, es.exam_id, es.student_id, es.question_order, es.started_at, es.finished_at, es.status, es.final_score, es.consent_acknowledged_at
+		 FROM exam_sessions es
+		 JOIN students st ON st.id = es.student_id
+		 WHERE es.exam_id = $1 AND st.nisn = $2`, examID, nisn,
+	).Scan(&s.ID, &s.ExamID, &s.StudentID, &s.QuestionOrder, &s.StartedAt, &s.FinishedAt, &s.Status, &s.FinalScore, &s.ConsentAcknowledgedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -49,29 +69,51 @@ func (r *ExamSessionRepository) GetByExamAndStudent(ctx context.Context, examID
 // Create inserts a new exam session (student joins the exam).
 func (r *ExamSessionRepository) Create(ctx context.Context, s *model.ExamSession) error {
 	return r.pool.QueryRow(ctx,
-		`INSERT INTO exam_sessions (exam_id, student_id, status, started_at)
-		 VALUES ($1, $2, $3, $4)
+		`INSERT INTO exam_sessions (exam_id, student_id, status, started_at, consent_acknowledged_at)
+		 VALUES ($1, $2, $3, $4, $5)
 		 ON CONFLICT (exam_id, student_id) DO NOTHING
 		 RETURNING id, started_at`,
-		s.ExamID, s.StudentID, model.SessionStatusInProgress, s.StartedAt,
+		s.ExamID, s.StudentID, model.SessionStatusInProgress, s.StartedAt, s.ConsentAcknowledgedAt,
 	).Scan(&s.ID, &s.StartedAt)
 }
 
-// Complete marks a session as completed with a final score.
-func (r *ExamSessionRepository) Complete(ctx context.Context, examID uuid.UUID, studentID int, score float64) error {
+// Complete marks a session as completed with a final score. A session that is already
+// completed keeps its score; the returned bool reports whether this call completed it.
+func (r *ExamSessionRepository) Complete(ctx context.Context, examID uuid.UUID, studentID int, score float64) (bool, error) {
 	now := time.Now()
-	_, err := r.pool.Exec(ctx,
+	tag, err := r.pool.Exec(ctx,
 		`UPDATE exam_sessions
 		 SET status = $1, final_score = $2, finished_at = $3
-		 WHERE exam_id = $4 AND student_id = $5`,
+		 WHERE exam_id = $4 AND student_id = $5 AND status <> $1`,
 		model.SessionStatusCompleted, score, now, examID, studentID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// SaveOfflineScores records the scores of a paper exam as COMPLETED sessions flagged
+// as offline entries, one per student. Earlier offline entries get the new score and
+// sessions still in progress are completed with it; sessions submitted online are
+// left untouched.
+func (r *ExamSessionRepository) SaveOfflineScores(ctx context.Context, examID uuid.UUID, studentIDs []int, scores []float64) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO exam_sessions (exam_id, student_id, status, started_at, finished_at, final_score, offline_entry)
+		 SELECT $1, u.student_id, $2, NOW(), NOW(), u.score, TRUE
+		 FROM UNNEST($3::int[], $4::float8[]) AS u (student_id, score)
+		 ON CONFLICT (exam_id, student_id) DO UPDATE
+		 SET status = EXCLUDED.status, final_score = EXCLUDED.final_score,
+		     finished_at = EXCLUDED.finished_at, offline_entry = TRUE
+		 WHERE exam_sessions.offline_entry OR exam_sessions.status <> $2`,
+		examID, model.SessionStatusCompleted, studentIDs, scores,
+	)
 	return err
 }
 
 // ListByStudent retrieves all sessions for a given student.
 func (r *ExamSessionRepository) ListByStudent(ctx context.Context, studentID int) ([]model.ExamSession, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, exam_id, student_id, question_order, started_at, finished_at, status, final_score
+		`SELECT id, exam_id, student_id, question_order, started_at, finished_at, status, final_score, consent_acknowledged_at
 		 FROM exam_sessions
 		 WHERE student_id = $1
 		 ORDER BY started_at DESC`, studentID,
@@ -84,7 +126,7 @@ func (r *ExamSessionRepository) ListByStudent(ctx context.Context, studentID int
 	var sessions []model.ExamSession
 	for rows.Next() {
 		var s model.ExamSession
-		if err := rows.Scan(&s.ID, &s.ExamID, &s.StudentID, &s.QuestionOrder, &s.StartedAt, &s.FinishedAt, &s.Status, &s.FinalScore); err != nil {
+		if err := rows.Scan(&s.ID, &s.ExamID, &s.StudentID, &s.QuestionOrder, &s.StartedAt, &s.FinishedAt, &s.Status, &s.FinalScore, &s.ConsentAcknowledgedAt); err != nil {
 			return nil, err
 		}
 		sessions = append(sessions, s)
@@ -93,7 +135,7 @@ func (r *ExamSessionRepository) ListByStudent(ctx context.Context, studentID int
 }
 
 // ListByExam retrieves all student results for a specific exam, with optional filters and pagination.
-func (r *ExamSessionRepository) ListByExam(ctx context.Context, examID uuid.UUID, page, perPage int, classID *int, gradeLevel *string, majorCode *string, groupNumber *int, religion *string) ([]ExamResult, int64, error) {
+func (r *ExamSessionRepository) ListByExam(ctx context.Context, examID uuid.UUID, page, perPage int, classID *int, gradeLevel *string, majorCode *string, groupNumber *int, religion *model.Religion) ([]ExamResult, int64, error) {
 	offset := (page - 1) * perPage
 
 	// Base query parts
@@ -140,7 +182,7 @@ func (r *ExamSessionRepository) ListByExam(ctx context.Context, examID uuid.UUID
 	query := `
 		SELECT 
 			s.id, s.name, s.nisn, CONCAT(c.grade_level, ' ', c.major_code, ' ', c.group_number) as class_name,
-			es.final_score, es.status, es.started_at, es.finished_at
+			es.final_score, es.status, es.started_at, es.finished_at, es.offline_entry
 		` + baseQuery + `
 		ORDER BY class_name ASC, s.name ASC
 		LIMIT $` + fmt.Sprintf("%d", len(args)+1) + ` OFFSET $` + fmt.Sprintf("%d", len(args)+2) + `
@@ -158,7 +200,7 @@ func (r *ExamSessionRepository) ListByExam(ctx context.Context, examID uuid.UUID
 		var r ExamResult
 		if err := rows.Scan(
 			&r.StudentID, &r.Name, &r.NISN, &r.ClassName,
-			&r.FinalScore, &r.Status, &r.StartedAt, &r.FinishedAt,
+			&r.FinalScore, &r.Status, &r.StartedAt, &r.FinishedAt, &r.OfflineEntry,
 		); err != nil {
 			return nil, 0, err
 		}
@@ -206,3 +248,254 @@ func (r *ExamSessionRepository) GetActiveExamID(ctx context.Context, studentID i
 	}
 	return &examID, nil
 }
+
+// ListOpenSessions returns the IN_PROGRESS sessions of published and running exams.
+func (r *ExamSessionRepository) ListOpenSessions(ctx context.Context) ([]model.ExamSession, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT es.id, es.exam_id, es.student_id, es.question_order, es.started_at, es.finished_at, es.status, es.final_score, es.consent_acknowledged_at
+		 FROM exam_sessions es
+		 JOIN exams e ON e.id = es.exam_id
+		 WHERE es.status = $1 AND e.status IN ($2, $3)`,
+		model.SessionStatusInProgress, model.ExamStatusPublished, model.ExamStatusInProgress,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []model.ExamSession
+	for rows.Next() {
+		var s model.ExamSession
+		if err := rows.Scan(&s.ID, &s.ExamID, &s.StudentID, &s.QuestionOrder, &s.StartedAt, &s.FinishedAt, &s.Status, &s.FinalScore, &s.ConsentAcknowledgedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// ListStale returns the IN_PROGRESS sessions of an exam whose deadline passed more
+// than grace ago, oldest first. The deadline is the start plus the exam's duration,
+// every break the exam allows at full length, and the session's disconnect pauses.
+func (r *ExamSessionRepository) ListStale(ctx context.Context, examID uuid.UUID, grace time.Duration) ([]model.StaleSession, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT es.student_id, s.nisn, s.name,
+		        COALESCE(c.grade_level || ' ' || c.major_code || ' ' || c.group_number::text, ''),
+		        es.started_at, d.deadline, es.question_order,
+		        (SELECT COUNT(*) FROM student_answers sa
+		          WHERE sa.exam_id = es.exam_id AND sa.student_id = es.student_id AND sa.answer <> '')
+		 FROM exam_sessions es
+		 JOIN exams e ON e.id = es.exam_id
+		 JOIN students s ON s.id = es.student_id
+		 LEFT JOIN classes c ON c.id = s.class_id
+		 CROSS JOIN LATERAL (
+		     SELECT es.started_at
+		            + make_interval(mins => e.duration_minutes + e.max_breaks * e.break_minutes)
+		            + make_interval(secs => es.disconnect_paused_seconds) AS deadline
+		 ) d
+		 WHERE es.exam_id = $1 AND es.status = $2
+		   AND d.deadline + make_interval(secs => $3) < NOW()
+		 ORDER BY d.deadline, s.name`,
+		examID, model.SessionStatusInProgress, grace.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var sessions []model.StaleSession
+	for rows.Next() {
+		var ss model.StaleSession
+		if err := rows.Scan(&ss.StudentID, &ss.NISN, &ss.Name, &ss.ClassName, &ss.StartedAt, &ss.Deadline, &ss.QuestionOrder, &ss.Answered); err != nil {
+			return nil, err
+		}
+		ss.OverdueMinutes = int(now.Sub(ss.Deadline).Minutes())
+		sessions = append(sessions, ss)
+	}
+	return sessions, rows.Err()
+}
+
+// StudentHistoryRow is a student's session joined with the exam fields needed
+// to decide whether its result may be shown.
+type StudentHistoryRow struct {
+	model.ExamSession
+	Title        string
+	ExamStatus   model.ExamStatus
+	ScheduledEnd *model.LocalTime
+}
+
+// ListHistoryByStudent retrieves all sessions of a student together with their exam details.
+func (r *ExamSessionRepository) ListHistoryByStudent(ctx context.Context, studentID int) ([]StudentHistoryRow, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT s.id, s.exam_id, s.student_id, s.started_at, s.finished_at, s.status, s.final_score,
+		        e.title, e.status, e.scheduled_end
+		 FROM exam_sessions s
+		 JOIN exams e ON e.id = s.exam_id
+		 WHERE s.student_id = $1
+		 ORDER BY s.started_at DESC`, studentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []StudentHistoryRow
+	for rows.Next() {
+		var h StudentHistoryRow
+		if err := rows.Scan(&h.ID, &h.ExamID, &h.StudentID, &h.StartedAt, &h.FinishedAt, &h.Status, &h.FinalScore,
+			&h.Title, &h.ExamStatus, &h.ScheduledEnd); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// GetAnswerStats counts a student's persisted answers for an exam and how many match the answer key.
+func (r *ExamSessionRepository) GetAnswerStats(ctx context.Context, examID uuid.UUID, studentID int) (correct, answered int, err error) {
+	err = r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FILTER (WHERE upper(sa.answer) = upper(q.correct_option)), COUNT(*)
+		 FROM student_answers sa
+		 JOIN questions q ON q.id = sa.question_id
+		 WHERE sa.exam_id = $1 AND sa.student_id = $2`,
+		examID, studentID,
+	).Scan(&correct, &answered)
+	return correct, answered, err
+}
+
+// GetStats retrieves an exam's cached session aggregates. An exam the stats worker has
+// not seen yet has empty stats.
+func (r *ExamSessionRepository) GetStats(ctx context.Context, examID uuid.UUID) (*model.ExamStats, error) {
+	st := &model.ExamStats{ExamID: examID}
+	err := r.pool.QueryRow(ctx,
+		`SELECT participants, completed, average_score, median_score, stddev_score, completion_rate, updated_at
+		 FROM exam_stats WHERE exam_id = $1`, examID,
+	).Scan(&st.Participants, &st.Completed, &st.AverageScore, &st.MedianScore, &st.StddevScore, &st.CompletionRate, &st.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// GetClassAverage returns the average final score of completed sessions for an exam
+// among students in the same class as studentID. Returns nil when nobody has a score.
+func (r *ExamSessionRepository) GetClassAverage(ctx context.Context, examID uuid.UUID, studentID int) (*float64, error) {
+	var avg *float64
+	err := r.pool.QueryRow(ctx,
+		`SELECT AVG(s.final_score)::float8
+		 FROM exam_sessions s
+		 JOIN students st ON st.id = s.student_id
+		 WHERE s.exam_id = $1 AND s.status = $2
+		   AND st.class_id = (SELECT class_id FROM students WHERE id = $3)`,
+		examID, model.SessionStatusCompleted, studentID,
+	).Scan(&avg)
+	return avg, err
+}
+
+// SaveAnswer writes a student's answer directly, bypassing the Redis queue.
+// An empty answer removes the row. Used when Redis is unavailable.
+func (r *ExamSessionRepository) SaveAnswer(ctx context.Context, examID uuid.UUID, studentID int, questionID uuid.UUID, answer string) error {
+	if answer == "" {
+		_, err := r.pool.Exec(ctx,
+			`DELETE FROM student_answers
+			 WHERE exam_id = $1 AND student_id = $2 AND question_id = $3`,
+			examID, studentID, questionID,
+		)
+		return err
+	}
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO student_answers (exam_id, student_id, question_id, answer, updated_at)
+		 VALUES ($1, $2, $3, $4, NOW())
+		 ON CONFLICT (exam_id, student_id, question_id)
+		 DO UPDATE SET answer = EXCLUDED.answer, updated_at = NOW()`,
+		examID, studentID, questionID, answer,
+	)
+	return err
+}
+
+// ListAnswers returns a student's persisted answers for an exam keyed by question ID.
+func (r *ExamSessionRepository) ListAnswers(ctx context.Context, examID uuid.UUID, studentID int) (map[string]string, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT question_id, answer FROM student_answers
+		 WHERE exam_id = $1 AND student_id = $2`,
+		examID, studentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers := make(map[string]string)
+	for rows.Next() {
+		var qID uuid.UUID
+		var answer string
+		if err := rows.Scan(&qID, &answer); err != nil {
+			return nil, err
+		}
+		answers[qID.String()] = answer
+	}
+	return answers, rows.Err()
+}
+
+// StartBreak records a new break for a student. A break still open from before (one
+// that ran out without the student resuming) is closed at its limit first.
+func (r *ExamSessionRepository) StartBreak(ctx context.Context, examID uuid.UUID, studentID, limitMinutes int) error {
+	_, err := r.pool.Exec(ctx,
+		`WITH closed AS (
+		     UPDATE exam_breaks SET ended_at = LEAST(NOW(), started_at + make_interval(mins => $3))
+		      WHERE exam_id = $1 AND student_id = $2 AND ended_at IS NULL
+		 )
+		 INSERT INTO exam_breaks (exam_id, student_id) VALUES ($1, $2)`,
+		examID, studentID, limitMinutes,
+	)
+	return err
+}
+
+// EndBreak closes a student's open break, capped at its limit.
+func (r *ExamSessionRepository) EndBreak(ctx context.Context, examID uuid.UUID, studentID, limitMinutes int) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE exam_breaks SET ended_at = LEAST(NOW(), started_at + make_interval(mins => $3))
+		  WHERE exam_id = $1 AND student_id = $2 AND ended_at IS NULL`,
+		examID, studentID, limitMinutes,
+	)
+	return err
+}
+
+// GetBreakUsage returns how many breaks a student took, the seconds spent on finished
+// breaks (each capped at its limit) and the start of a break still running, if any.
+func (r *ExamSessionRepository) GetBreakUsage(ctx context.Context, examID uuid.UUID, studentID, limitMinutes int) (count, pausedSeconds int, runningSince *time.Time, err error) {
+	err = r.pool.QueryRow(ctx,
+		`SELECT COUNT(*),
+		        COALESCE(SUM(EXTRACT(EPOCH FROM LEAST(COALESCE(ended_at, NOW()), started_at + make_interval(mins => $3)) - started_at))
+		                 FILTER (WHERE ended_at IS NOT NULL OR started_at + make_interval(mins => $3) <= NOW()), 0)::int,
+		        MAX(started_at) FILTER (WHERE ended_at IS NULL AND started_at + make_interval(mins => $3) > NOW())
+		 FROM exam_breaks
+		 WHERE exam_id = $1 AND student_id = $2`,
+		examID, studentID, limitMinutes,
+	).Scan(&count, &pausedSeconds, &runningSince)
+	return count, pausedSeconds, runningSince, err
+}
+
+// AddDisconnectPause extends a session's timer by seconds paused for a prolonged disconnect.
+func (r *ExamSessionRepository) AddDisconnectPause(ctx context.Context, examID uuid.UUID, studentID, seconds int) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE exam_sessions SET disconnect_paused_seconds = disconnect_paused_seconds + $3
+		  WHERE exam_id = $1 AND student_id = $2`,
+		examID, studentID, seconds,
+	)
+	return err
+}
+
+// GetDisconnectPause returns the seconds a session's timer was paused for prolonged disconnects.
+func (r *ExamSessionRepository) GetDisconnectPause(ctx context.Context, examID uuid.UUID, studentID int) (int, error) {
+	var seconds int
+	err := r.pool.QueryRow(ctx,
+		`SELECT disconnect_paused_seconds FROM exam_sessions WHERE exam_id = $1 AND student_id = $2`,
+		examID, studentID,
+	).Scan(&seconds)
+	return seconds, err
+}
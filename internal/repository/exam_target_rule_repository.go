@@ -6,21 +6,30 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
-// ExamTargetRuleRepository handles exam target rule data access.
+// ExamTargetRuleRepository handles exam target rule data access. An exam's rules are
+// served from cache, which every write here invalidates.
 type ExamTargetRuleRepository struct {
-	pool *pgxpool.Pool
+	pool  *database.DB
+	cache *ExamCache
 }
 
-// NewExamTargetRuleRepository creates a new ExamTargetRuleRepository.
-func NewExamTargetRuleRepository(pool *pgxpool.Pool) *ExamTargetRuleRepository {
-	return &ExamTargetRuleRepository{pool: pool}
+// NewExamTargetRuleRepository creates a new ExamTargetRuleRepository. cache may be nil.
+func NewExamTargetRuleRepository(pool *pgxpool.Pool, cache *ExamCache) *ExamTargetRuleRepository {
+	return &ExamTargetRuleRepository{pool: database.Wrap(pool), cache: cache}
 }
 
 // ListByExam retrieves all target rules for a given exam.
 func (r *ExamTargetRuleRepository) ListByExam(ctx context.Context, examID uuid.UUID) ([]model.ExamTargetRule, error) {
+	return r.cache.rules(ctx, examID, func(ctx context.Context) ([]model.ExamTargetRule, error) {
+		return r.listByExam(ctx, examID)
+	})
+}
+
+func (r *ExamTargetRuleRepository) listByExam(ctx context.Context, examID uuid.UUID) ([]model.ExamTargetRule, error) {
 	rows, err := r.pool.Query(ctx,
 		`SELECT id, exam_id, class_id, grade_level, major_code, religion
 		 FROM exam_target_rules
@@ -44,12 +53,14 @@ func (r *ExamTargetRuleRepository) ListByExam(ctx context.Context, examID uuid.U
 
 // Create inserts a new target rule.
 func (r *ExamTargetRuleRepository) Create(ctx context.Context, rule *model.ExamTargetRule) error {
-	return r.pool.QueryRow(ctx,
+	err := r.pool.QueryRow(ctx,
 		`INSERT INTO exam_target_rules (exam_id, class_id, grade_level, major_code, religion)
 		 VALUES ($1, $2, $3, $4, $5)
 		 RETURNING id`,
 		rule.ExamID, rule.ClassID, rule.GradeLevel, rule.MajorCode, rule.Religion,
 	).Scan(&rule.ID)
+	r.cache.Invalidate(ctx, rule.ExamID)
+	return err
 }
 
 // Delete removes a target rule by its ID, ensuring it belongs to the given exam.
@@ -58,6 +69,7 @@ func (r *ExamTargetRuleRepository) Delete(ctx context.Context, ruleID int, examI
 		`DELETE FROM exam_target_rules WHERE id = $1 AND exam_id = $2`,
 		ruleID, examID,
 	)
+	r.cache.Invalidate(ctx, examID)
 	if err != nil {
 		return err
 	}
@@ -75,6 +87,7 @@ func (r *ExamTargetRuleRepository) Update(ctx context.Context, rule *model.ExamT
 		 WHERE id = $5 AND exam_id = $6`,
 		rule.ClassID, rule.GradeLevel, rule.MajorCode, rule.Religion, rule.ID, rule.ExamID,
 	)
+	r.cache.Invalidate(ctx, rule.ExamID)
 	if err != nil {
 		return err
 	}
@@ -85,12 +98,13 @@ func (r *ExamTargetRuleRepository) Update(ctx context.Context, rule *model.ExamT
 }
 
 // FindExamsForStudent retrieves exam IDs that target a student's class/grade/major/religion.
-func (r *ExamTargetRuleRepository) FindExamsForStudent(ctx context.Context, classID int) ([]uuid.UUID, error) {
+// Students who are no longer active are not eligible for any exam.
+func (r *ExamTargetRuleRepository) FindExamsForStudent(ctx context.Context, studentID, classID int) ([]uuid.UUID, error) {
 	rows, err := r.pool.Query(ctx,
 		`SELECT DISTINCT etr.exam_id
 		 FROM exam_target_rules etr
-		 JOIN classes c ON c.id = $1
-		 JOIN students s ON s.class_id = c.id
+		 JOIN classes c ON c.id = $2
+		 JOIN students s ON s.id = $1 AND s.status = 'active'
 		 WHERE
 		   etr.class_id = c.id
 		   OR (
@@ -98,7 +112,7 @@ func (r *ExamTargetRuleRepository) FindExamsForStudent(ctx context.Context, clas
 			   AND (etr.major_code IS NULL OR etr.major_code = c.major_code)
 			   AND (etr.religion IS NULL OR etr.religion = s.religion)
 		   )`,
-		classID,
+		studentID, classID,
 	)
 	if err != nil {
 		return nil, err
@@ -115,3 +129,133 @@ func (r *ExamTargetRuleRepository) FindExamsForStudent(ctx context.Context, clas
 	}
 	return examIDs, rows.Err()
 }
+
+// CountEligibleStudents counts the students matched by any of an exam's target rules,
+// including religion narrowing.
+func (r *ExamTargetRuleRepository) CountEligibleStudents(ctx context.Context, examID uuid.UUID) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*)
+		 FROM students s
+		 JOIN classes c ON c.id = s.class_id
+		 WHERE EXISTS (
+			 SELECT 1 FROM exam_target_rules etr
+			 WHERE etr.exam_id = $1
+			   AND (etr.class_id IS NULL OR etr.class_id = c.id)
+			   AND (etr.grade_level IS NULL OR etr.grade_level = CAST(c.grade_level AS VARCHAR))
+			   AND (etr.major_code IS NULL OR etr.major_code = c.major_code)
+			   AND (etr.religion IS NULL OR etr.religion = s.religion)
+		 )`,
+		examID,
+	).Scan(&count)
+	return count, err
+}
+
+// ListAttendance lists the active students matched by an exam's target rules, as
+// CountEligibleStudents matches them, together with every student who joined it,
+// with their class, room assignment and session.
+func (r *ExamTargetRuleRepository) ListAttendance(ctx context.Context, examID uuid.UUID) ([]model.AttendanceRow, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT s.id, s.nisn, s.nis, s.name,
+		        c.grade_level || ' ' || c.major_code || ' ' || c.group_number::text,
+		        COALESCE(rm.name, ''), COALESCE(sra.seat_number, 0),
+		        es.status, es.started_at, COALESCE(es.offline_entry, FALSE)
+		 FROM students s
+		 JOIN classes c ON c.id = s.class_id
+		 LEFT JOIN exam_sessions es ON es.exam_id = $1 AND es.student_id = s.id
+		 LEFT JOIN student_room_assignments sra ON sra.student_id = s.id
+		 LEFT JOIN room_sessions rs ON rs.id = sra.room_session_id
+		 LEFT JOIN rooms rm ON rm.id = rs.room_id
+		 WHERE es.id IS NOT NULL
+		    OR (s.status = 'active' AND EXISTS (
+			   SELECT 1 FROM exam_target_rules etr
+			   WHERE etr.exam_id = $1
+			     AND (etr.class_id IS NULL OR etr.class_id = c.id)
+			     AND (etr.grade_level IS NULL OR etr.grade_level = CAST(c.grade_level AS VARCHAR))
+			     AND (etr.major_code IS NULL OR etr.major_code = c.major_code)
+			     AND (etr.religion IS NULL OR etr.religion = s.religion)
+		    ))
+		 ORDER BY c.grade_level, c.major_code, c.group_number, s.name`,
+		examID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attendance []model.AttendanceRow
+	for rows.Next() {
+		var a model.AttendanceRow
+		if err := rows.Scan(&a.StudentID, &a.NISN, &a.NIS, &a.Name, &a.ClassName, &a.RoomName, &a.SeatNumber, &a.SessionStatus, &a.StartedAt, &a.OfflineEntry); err != nil {
+			return nil, err
+		}
+		attendance = append(attendance, a)
+	}
+	return attendance, rows.Err()
+}
+
+// ListTargetedClasses resolves the target rules of the given exams to concrete class IDs.
+// A rule matches a class by class_id, or — when class_id is empty — by grade level and major.
+// Religion-only narrowing is ignored, so the result is a superset of the affected classes.
+func (r *ExamTargetRuleRepository) ListTargetedClasses(ctx context.Context, examIDs []uuid.UUID) (map[uuid.UUID][]int, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT DISTINCT etr.exam_id, c.id
+		 FROM exam_target_rules etr
+		 JOIN classes c ON
+		   etr.class_id = c.id
+		   OR (
+			   etr.class_id IS NULL
+			   AND (etr.grade_level IS NULL OR etr.grade_level = CAST(c.grade_level AS VARCHAR))
+			   AND (etr.major_code IS NULL OR etr.major_code = c.major_code)
+		   )
+		 WHERE etr.exam_id = ANY($1)
+		 ORDER BY etr.exam_id, c.id`,
+		examIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	classes := make(map[uuid.UUID][]int)
+	for rows.Next() {
+		var examID uuid.UUID
+		var classID int
+		if err := rows.Scan(&examID, &classID); err != nil {
+			return nil, err
+		}
+		classes[examID] = append(classes[examID], classID)
+	}
+	return classes, rows.Err()
+}
+
+// ListClassesForRule resolves a single, possibly unsaved, target rule to class IDs
+// using the same matching as ListTargetedClasses.
+func (r *ExamTargetRuleRepository) ListClassesForRule(ctx context.Context, rule *model.ExamTargetRule) ([]int, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT c.id
+		 FROM classes c
+		 WHERE ($1::int IS NOT NULL AND c.id = $1)
+		    OR (
+			   $1::int IS NULL
+			   AND ($2::varchar IS NULL OR $2 = CAST(c.grade_level AS VARCHAR))
+			   AND ($3::varchar IS NULL OR $3 = c.major_code)
+		    )
+		 ORDER BY c.id`,
+		rule.ClassID, rule.GradeLevel, rule.MajorCode,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		classIDs = append(classIDs, id)
+	}
+	return classIDs, rows.Err()
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// GuardianRepository handles database operations for guardians and their student links.
+type GuardianRepository struct {
+	pool *database.DB
+}
+
+// NewGuardianRepository creates a new GuardianRepository.
+func NewGuardianRepository(pool *pgxpool.Pool) *GuardianRepository {
+	return &GuardianRepository{pool: database.Wrap(pool)}
+}
+
+const guardianColumns = `g.id, g.name, g.email, g.phone, g.password_hash, g.notify_channel, g.telegram_chat_id, g.notify_results,
+	(SELECT COUNT(*) FROM guardian_students gs WHERE gs.guardian_id = g.id), g.created_at, g.updated_at`
+
+func scanGuardian(row interface{ Scan(...interface{}) error }, g *model.Guardian) error {
+	return row.Scan(&g.ID, &g.Name, &g.Email, &g.Phone, &g.PasswordHash, &g.NotifyChannel, &g.TelegramChatID, &g.NotifyResults,
+		&g.ChildrenCount, &g.CreatedAt, &g.UpdatedAt)
+}
+
+// Create inserts a new guardian.
+func (r *GuardianRepository) Create(ctx context.Context, g *model.Guardian) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO guardians (name, email, phone, password_hash)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, notify_channel, telegram_chat_id, notify_results, created_at, updated_at`,
+		g.Name, g.Email, g.Phone, g.PasswordHash,
+	).Scan(&g.ID, &g.NotifyChannel, &g.TelegramChatID, &g.NotifyResults, &g.CreatedAt, &g.UpdatedAt)
+}
+
+// GetByID retrieves a guardian by ID.
+func (r *GuardianRepository) GetByID(ctx context.Context, id int) (*model.Guardian, error) {
+	g := &model.Guardian{}
+	err := scanGuardian(r.pool.QueryRow(ctx,
+		`SELECT `+guardianColumns+` FROM guardians g WHERE g.id = $1`, id), g)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// GetByIdentifier retrieves a guardian by their email or phone number.
+func (r *GuardianRepository) GetByIdentifier(ctx context.Context, identifier string) (*model.Guardian, error) {
+	g := &model.Guardian{}
+	err := scanGuardian(r.pool.QueryRow(ctx,
+		`SELECT `+guardianColumns+` FROM guardians g WHERE g.email = $1 OR g.phone = $1`, identifier), g)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// ListPaginated retrieves guardians, optionally filtered by name, email or phone.
+func (r *GuardianRepository) ListPaginated(ctx context.Context, search *string, limit, offset int) ([]model.Guardian, int, error) {
+	var pattern *string
+	if search != nil {
+		p := "%" + *search + "%"
+		pattern = &p
+	}
+	const where = `WHERE ($1::text IS NULL OR g.name ILIKE $1 OR g.email ILIKE $1 OR g.phone ILIKE $1)`
+
+	var total int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM guardians g `+where, pattern).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+guardianColumns+` FROM guardians g `+where+`
+		 ORDER BY g.name ASC
+		 LIMIT $2 OFFSET $3`, pattern, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var guardians []model.Guardian
+	for rows.Next() {
+		var g model.Guardian
+		if err := scanGuardian(rows, &g); err != nil {
+			return nil, 0, err
+		}
+		guardians = append(guardians, g)
+	}
+	return guardians, total, rows.Err()
+}
+
+// Update modifies a guardian's profile. An empty PasswordHash keeps the current password.
+func (r *GuardianRepository) Update(ctx context.Context, g *model.Guardian) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE guardians
+		 SET name = $1, email = $2, phone = $3,
+		     password_hash = COALESCE(NULLIF($4, ''), password_hash),
+		     updated_at = NOW()
+		 WHERE id = $5`,
+		g.Name, g.Email, g.Phone, g.PasswordHash, g.ID,
+	)
+	return err
+}
+
+// UpdatePreferences saves a guardian's notification preferences.
+func (r *GuardianRepository) UpdatePreferences(ctx context.Context, g *model.Guardian) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE guardians
+		 SET notify_channel = $1, telegram_chat_id = $2, notify_results = $3, updated_at = NOW()
+		 WHERE id = $4`,
+		g.NotifyChannel, g.TelegramChatID, g.NotifyResults, g.ID,
+	)
+	return err
+}
+
+// Delete removes a guardian and their student links.
+func (r *GuardianRepository) Delete(ctx context.Context, id int) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM guardians WHERE id = $1`, id)
+	return err
+}
+
+// LinkStudent links a student to a guardian, updating the relationship if already linked.
+func (r *GuardianRepository) LinkStudent(ctx context.Context, guardianID, studentID int, relationship string) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO guardian_students (guardian_id, student_id, relationship)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (guardian_id, student_id) DO UPDATE SET relationship = EXCLUDED.relationship`,
+		guardianID, studentID, relationship,
+	)
+	return err
+}
+
+// UnlinkStudent removes a student from a guardian.
+func (r *GuardianRepository) UnlinkStudent(ctx context.Context, guardianID, studentID int) error {
+	_, err := r.pool.Exec(ctx,
+		`DELETE FROM guardian_students WHERE guardian_id = $1 AND student_id = $2`, guardianID, studentID)
+	return err
+}
+
+const guardianChildQuery = `SELECT s.id, s.nis, s.nisn, s.name, s.class_id,
+		CONCAT(c.grade_level, ' ', c.major_code, ' ', c.group_number),
+		gs.relationship
+	 FROM guardian_students gs
+	 JOIN students s ON s.id = gs.student_id
+	 JOIN classes c ON c.id = s.class_id
+	 WHERE gs.guardian_id = $1`
+
+// ListChildren returns the students linked to a guardian.
+func (r *GuardianRepository) ListChildren(ctx context.Context, guardianID int) ([]model.GuardianChild, error) {
+	rows, err := r.pool.Query(ctx, guardianChildQuery+` ORDER BY s.name ASC`, guardianID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var children []model.GuardianChild
+	for rows.Next() {
+		var ch model.GuardianChild
+		if err := rows.Scan(&ch.StudentID, &ch.NIS, &ch.NISN, &ch.Name, &ch.ClassID, &ch.ClassName, &ch.Relationship); err != nil {
+			return nil, err
+		}
+		children = append(children, ch)
+	}
+	return children, rows.Err()
+}
+
+// GetChild returns one of a guardian's children. It returns pgx.ErrNoRows when
+// the student is not linked to the guardian.
+func (r *GuardianRepository) GetChild(ctx context.Context, guardianID, studentID int) (*model.GuardianChild, error) {
+	ch := &model.GuardianChild{}
+	err := r.pool.QueryRow(ctx, guardianChildQuery+` AND s.id = $2`, guardianID, studentID).
+		Scan(&ch.StudentID, &ch.NIS, &ch.NISN, &ch.Name, &ch.ClassID, &ch.ClassName, &ch.Relationship)
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
@@ -17,11 +18,11 @@ type MajorRepository interface {
 }
 
 type majorRepository struct {
-	db *pgxpool.Pool
+	db *database.DB
 }
 
 func NewMajorRepository(db *pgxpool.Pool) MajorRepository {
-	return &majorRepository{db: db}
+	return &majorRepository{db: database.Wrap(db)}
 }
 
 func (r *majorRepository) GetAll(ctx context.Context) ([]*model.Major, error) {
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// mediaReferenceClause matches questions whose text, explanation, translations or
+// options embed the media URL, or whose options attach the media by ID.
+const mediaReferenceClause = `(q.question_text LIKE '%' || m.url || '%' OR q.options::text LIKE '%' || m.url || '%'
+	OR q.explanation LIKE '%' || m.url || '%' OR q.translations::text LIKE '%' || m.url || '%'
+	OR q.options::text LIKE '%' || m.id::text || '%')`
+
+// mediaPassageClause matches reading passages whose content embeds the media URL.
+const mediaPassageClause = `p.content LIKE '%' || m.url || '%'`
+
+// mediaSettingClause matches the settings that may point at a library image: the
+// school logo and the remote config theme, whose logo_url sits in its JSON value.
+const mediaSettingClause = `(s.key IN ('school_logo_url', 'ui_theme') AND s.value LIKE '%' || m.url || '%')`
+
+// mediaInUseClause matches media referenced by any question, passage or setting.
+const mediaInUseClause = `(EXISTS (SELECT 1 FROM questions q WHERE ` + mediaReferenceClause + `)
+	OR EXISTS (SELECT 1 FROM question_passages p WHERE ` + mediaPassageClause + `)
+	OR EXISTS (SELECT 1 FROM app_settings s WHERE ` + mediaSettingClause + `))`
+
+// MediaRepository handles media library data access.
+type MediaRepository struct {
+	pool *database.DB
+}
+
+// NewMediaRepository creates a new MediaRepository.
+func NewMediaRepository(pool *pgxpool.Pool) *MediaRepository {
+	return &MediaRepository{pool: database.Wrap(pool)}
+}
+
+// Create inserts a new media file record.
+func (r *MediaRepository) Create(ctx context.Context, m *model.MediaFile) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO media_files (filename, original_name, url, mime_type, size_bytes, duration_seconds, uploaded_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, created_at`,
+		m.Filename, m.OriginalName, m.URL, m.MimeType, m.SizeBytes, m.Duration, m.UploadedBy,
+	).Scan(&m.ID, &m.CreatedAt)
+}
+
+// GetByID retrieves a media file by its UUID.
+func (r *MediaRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.MediaFile, error) {
+	m := &model.MediaFile{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, filename, original_name, url, mime_type, size_bytes, duration_seconds, uploaded_by, created_at
+		 FROM media_files WHERE id = $1`, id,
+	).Scan(&m.ID, &m.Filename, &m.OriginalName, &m.URL, &m.MimeType, &m.SizeBytes, &m.Duration, &m.UploadedBy, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// GetByFilename retrieves a media file by its stored filename.
+func (r *MediaRepository) GetByFilename(ctx context.Context, filename string) (*model.MediaFile, error) {
+	m := &model.MediaFile{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, filename, original_name, url, mime_type, size_bytes, duration_seconds, uploaded_by, created_at
+		 FROM media_files WHERE filename = $1`, filename,
+	).Scan(&m.ID, &m.Filename, &m.OriginalName, &m.URL, &m.MimeType, &m.SizeBytes, &m.Duration, &m.UploadedBy, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// GetByIDs retrieves the media files among ids, keyed by ID. Unknown IDs are left out.
+func (r *MediaRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]model.MediaFile, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, filename, original_name, url, mime_type, size_bytes, duration_seconds, uploaded_by, created_at
+		 FROM media_files WHERE id = ANY($1)`, ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := make(map[uuid.UUID]model.MediaFile, len(ids))
+	for rows.Next() {
+		var m model.MediaFile
+		if err := rows.Scan(&m.ID, &m.Filename, &m.OriginalName, &m.URL, &m.MimeType, &m.SizeBytes, &m.Duration, &m.UploadedBy, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		files[m.ID] = m
+	}
+	return files, rows.Err()
+}
+
+// GetByFilenames retrieves the media files among filenames, keyed by filename. Unknown
+// filenames are left out.
+func (r *MediaRepository) GetByFilenames(ctx context.Context, filenames []string) (map[string]model.MediaFile, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, filename, original_name, url, mime_type, size_bytes, duration_seconds, uploaded_by, created_at
+		 FROM media_files WHERE filename = ANY($1)`, filenames,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := make(map[string]model.MediaFile, len(filenames))
+	for rows.Next() {
+		var m model.MediaFile
+		if err := rows.Scan(&m.ID, &m.Filename, &m.OriginalName, &m.URL, &m.MimeType, &m.SizeBytes, &m.Duration, &m.UploadedBy, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		files[m.Filename] = m
+	}
+	return files, rows.Err()
+}
+
+// MarkReused records that adminID uploaded a media file again, which restarts its
+// orphan grace period, and stores the reuse in m.
+func (r *MediaRepository) MarkReused(ctx context.Context, m *model.MediaFile, adminID int) error {
+	return r.pool.QueryRow(ctx,
+		`UPDATE media_files SET reused_at = NOW(), reused_by = $2 WHERE id = $1 RETURNING reused_at, reused_by`,
+		m.ID, adminID,
+	).Scan(&m.ReusedAt, &m.ReusedBy)
+}
+
+// ListPaginated retrieves media files with their usage counts, newest first.
+func (r *MediaRepository) ListPaginated(ctx context.Context, limit, offset int, search string) ([]model.MediaFile, int, error) {
+	searchParam := "%" + search + "%"
+
+	var total int
+	if err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM media_files WHERE original_name ILIKE $1 OR filename ILIKE $1`,
+		searchParam,
+	).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT m.id, m.filename, m.original_name, m.url, m.mime_type, m.size_bytes, m.duration_seconds, m.uploaded_by, m.created_at,
+		        (SELECT COUNT(*) FROM questions q WHERE `+mediaReferenceClause+`)
+		        + (SELECT COUNT(*) FROM question_passages p WHERE `+mediaPassageClause+`)
+		        + (SELECT COUNT(*) FROM app_settings s WHERE `+mediaSettingClause+`) AS usage_count
+		 FROM media_files m
+		 WHERE m.original_name ILIKE $1 OR m.filename ILIKE $1
+		 ORDER BY m.created_at DESC
+		 LIMIT $2 OFFSET $3`,
+		searchParam, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var files []model.MediaFile
+	for rows.Next() {
+		var m model.MediaFile
+		if err := rows.Scan(&m.ID, &m.Filename, &m.OriginalName, &m.URL, &m.MimeType, &m.SizeBytes, &m.Duration, &m.UploadedBy, &m.CreatedAt, &m.UsageCount); err != nil {
+			return nil, 0, err
+		}
+		files = append(files, m)
+	}
+	return files, total, rows.Err()
+}
+
+// ListUsage returns every question, passage and setting that references the given
+// media file: questions and passages by question bank, then settings.
+func (r *MediaRepository) ListUsage(ctx context.Context, id uuid.UUID) ([]model.MediaUsage, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT kind, question_id, passage_id, passage_title, qbank_id, qbank_name, order_num, setting_key
+		 FROM (
+		     SELECT 'question' AS kind, q.id AS question_id, NULL::uuid AS passage_id, '' AS passage_title,
+		            q.qbank_id, qb.name AS qbank_name, q.order_num, '' AS setting_key
+		     FROM media_files m
+		     JOIN questions q ON `+mediaReferenceClause+`
+		     JOIN question_banks qb ON qb.id = q.qbank_id
+		     WHERE m.id = $1
+		     UNION ALL
+		     SELECT 'passage', NULL, p.id, p.title, p.qbank_id, qb.name, 0, ''
+		     FROM media_files m
+		     JOIN question_passages p ON `+mediaPassageClause+`
+		     JOIN question_banks qb ON qb.id = p.qbank_id
+		     WHERE m.id = $1
+		     UNION ALL
+		     SELECT 'setting', NULL, NULL, '', NULL, '', 0, s.key
+		     FROM media_files m
+		     JOIN app_settings s ON `+mediaSettingClause+`
+		     WHERE m.id = $1
+		 ) usage
+		 ORDER BY qbank_id IS NULL, qbank_name, kind DESC, order_num, setting_key`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usages []model.MediaUsage
+	for rows.Next() {
+		var u model.MediaUsage
+		if err := rows.Scan(&u.Kind, &u.QuestionID, &u.PassageID, &u.PassageTitle, &u.QBankID, &u.QBankName, &u.OrderNum, &u.SettingKey); err != nil {
+			return nil, err
+		}
+		usages = append(usages, u)
+	}
+	return usages, rows.Err()
+}
+
+// ListOrphans returns media files uploaded, or last reused, before the cutoff that no
+// question, passage or setting references.
+func (r *MediaRepository) ListOrphans(ctx context.Context, olderThan time.Time) ([]model.MediaFile, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT m.id, m.filename, m.original_name, m.url, m.mime_type, m.size_bytes, m.duration_seconds, m.uploaded_by, m.created_at
+		 FROM media_files m
+		 WHERE COALESCE(m.reused_at, m.created_at) < $1
+		   AND NOT `+mediaInUseClause,
+		olderThan,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []model.MediaFile
+	for rows.Next() {
+		var m model.MediaFile
+		if err := rows.Scan(&m.ID, &m.Filename, &m.OriginalName, &m.URL, &m.MimeType, &m.SizeBytes, &m.Duration, &m.UploadedBy, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		files = append(files, m)
+	}
+	return files, rows.Err()
+}
+
+// Delete removes a media file record.
+func (r *MediaRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	cmdTag, err := r.pool.Exec(ctx, `DELETE FROM media_files WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
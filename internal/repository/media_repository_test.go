@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// testPool connects to TEST_DATABASE_URL, a migrated database, and shadows tables with
+// empty temporary copies, so tests neither need seed data nor touch real rows. The pool
+// holds a single connection, the one the temporary tables live in.
+func testPool(t *testing.T, tables ...string) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse TEST_DATABASE_URL: %v", err)
+	}
+	cfg.MaxConns = 1
+
+	ctx := context.Background()
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	// Copies keep the columns, defaults and unique constraints but not the foreign keys.
+	for _, table := range tables {
+		if _, err := pool.Exec(ctx, `CREATE TEMP TABLE `+table+` (LIKE public.`+table+` INCLUDING ALL)`); err != nil {
+			t.Fatalf("shadow %s: %v", table, err)
+		}
+	}
+	return pool
+}
+
+// An image used only by a reading passage is in use: it is listed as such and never
+// taken for an orphan.
+func TestMediaReferencedByPassageOnly(t *testing.T) {
+	pool := testPool(t, "media_files", "questions", "question_passages", "question_banks", "app_settings")
+	repo := NewMediaRepository(pool)
+	ctx := context.Background()
+
+	used := &model.MediaFile{Filename: "passage.png", URL: "/uploads/passage.png", MimeType: "image/png"}
+	unused := &model.MediaFile{Filename: "unused.png", URL: "/uploads/unused.png", MimeType: "image/png"}
+	for _, m := range []*model.MediaFile{used, unused} {
+		if err := repo.Create(ctx, m); err != nil {
+			t.Fatalf("create media: %v", err)
+		}
+	}
+
+	qbankID := uuid.New()
+	if _, err := pool.Exec(ctx, `INSERT INTO question_banks (id, name) VALUES ($1, 'Reading')`, qbankID); err != nil {
+		t.Fatalf("create question bank: %v", err)
+	}
+	var passageID uuid.UUID
+	if err := pool.QueryRow(ctx,
+		`INSERT INTO question_passages (qbank_id, title, content) VALUES ($1, 'Volcanoes', $2) RETURNING id`,
+		qbankID, `<p>Look at the map.</p><img src="/uploads/passage.png">`,
+	).Scan(&passageID); err != nil {
+		t.Fatalf("create passage: %v", err)
+	}
+
+	orphans, err := repo.ListOrphans(ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("list orphans: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].ID != unused.ID {
+		t.Errorf("orphans = %v, want only %s", orphans, unused.Filename)
+	}
+
+	usages, err := repo.ListUsage(ctx, used.ID)
+	if err != nil {
+		t.Fatalf("list usage: %v", err)
+	}
+	if len(usages) != 1 {
+		t.Fatalf("got %d usages, want 1", len(usages))
+	}
+	u := usages[0]
+	if u.Kind != model.MediaUsagePassage || u.PassageID == nil || *u.PassageID != passageID || u.QBankName != "Reading" {
+		t.Errorf("usage = %+v, want passage %s of Reading", u, passageID)
+	}
+
+	files, _, err := repo.ListPaginated(ctx, 10, 0, "")
+	if err != nil {
+		t.Fatalf("list media: %v", err)
+	}
+	for _, f := range files {
+		want := 0
+		if f.ID == used.ID {
+			want = 1
+		}
+		if f.UsageCount == nil || *f.UsageCount != want {
+			t.Errorf("%s: usage count = %v, want %d", f.Filename, f.UsageCount, want)
+		}
+	}
+}
@@ -2,22 +2,28 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/model"
 )
 
 // MonitorRepository provides data access for the live exam monitoring feature.
 // It combines PostgreSQL (session state) and Redis (live answer counts).
 type MonitorRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 	rdb  *redis.Client
 }
 
 // NewMonitorRepository creates a new MonitorRepository.
 func NewMonitorRepository(pool *pgxpool.Pool, rdb *redis.Client) *MonitorRepository {
-	return &MonitorRepository{pool: pool, rdb: rdb}
+	return &MonitorRepository{pool: database.Wrap(pool), rdb: rdb}
 }
 
 // GetInProgressStudentIDs returns all student IDs with an active session for the given exam.
@@ -50,7 +56,7 @@ func (r *MonitorRepository) GetAnsweredCounts(ctx context.Context, examID uuid.U
 	rows, err := r.pool.Query(ctx,
 		`SELECT student_id, COUNT(*)
 		 FROM student_answers
-		 WHERE exam_id = $1
+		 WHERE exam_id = $1 AND student_id IS NOT NULL
 		 GROUP BY student_id`,
 		examID,
 	)
@@ -97,3 +103,407 @@ func (r *MonitorRepository) GetCheatCounts(ctx context.Context, examID uuid.UUID
 
 	return counts, rows.Err()
 }
+
+// ListEvents returns up to limit recorded monitor events of an exam recorded within
+// [from, to], oldest first, starting after the event with ID after.
+func (r *MonitorRepository) ListEvents(ctx context.Context, examID uuid.UUID, from, to time.Time, after int64, limit int) ([]model.MonitorEvent, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, event_type, payload, recorded_at
+		 FROM exam_monitor_events
+		 WHERE exam_id = $1 AND recorded_at BETWEEN $2 AND $3
+		   AND ($4::bigint = 0 OR (recorded_at, id) > (SELECT recorded_at, id FROM exam_monitor_events WHERE id = $4))
+		 ORDER BY recorded_at, id
+		 LIMIT $5`,
+		examID, from, to, after, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]model.MonitorEvent, 0)
+	for rows.Next() {
+		var e model.MonitorEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.Payload, &e.RecordedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// GetSessionTimeline retrieves a student's session of an exam with the student's
+// NISN and name, without events or answers.
+func (r *MonitorRepository) GetSessionTimeline(ctx context.Context, examID uuid.UUID, studentID int) (*model.SessionTimeline, error) {
+	t := model.SessionTimeline{ExamID: examID, StudentID: studentID}
+	err := r.pool.QueryRow(ctx,
+		`SELECT s.nisn, s.name, es.status, es.started_at, es.finished_at, es.final_score
+		 FROM exam_sessions es
+		 JOIN students s ON s.id = es.student_id
+		 WHERE es.exam_id = $1 AND es.student_id = $2`,
+		examID, studentID,
+	).Scan(&t.NISN, &t.Name, &t.Status, &t.StartedAt, &t.FinishedAt, &t.FinalScore)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListStudentEvents returns the recorded monitor events of one student in an exam,
+// oldest first. Exam-wide events, such as progress snapshots, carry no student.
+func (r *MonitorRepository) ListStudentEvents(ctx context.Context, examID uuid.UUID, studentID int) ([]model.MonitorEvent, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, event_type, payload, recorded_at
+		 FROM exam_monitor_events
+		 WHERE exam_id = $1 AND payload->>'student_id' = $2::text
+		 ORDER BY recorded_at, id`,
+		examID, studentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.MonitorEvent
+	for rows.Next() {
+		var e model.MonitorEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.Payload, &e.RecordedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// ListStudentCheats returns the cheat events persisted for one student in an exam,
+// oldest first.
+func (r *MonitorRepository) ListStudentCheats(ctx context.Context, examID uuid.UUID, studentID int) ([]model.MonitorEvent, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, COALESCE(event_data->>'type', 'unknown'), event_data, recorded_at
+		 FROM exam_cheats
+		 WHERE exam_id = $1 AND student_id = $2
+		 ORDER BY recorded_at, id`,
+		examID, studentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cheats []model.MonitorEvent
+	for rows.Next() {
+		var e model.MonitorEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.Payload, &e.RecordedAt); err != nil {
+			return nil, err
+		}
+		cheats = append(cheats, e)
+	}
+	return cheats, rows.Err()
+}
+
+// ListStudentAnswers returns the answers persisted for one student in an exam, with
+// their question's order number, in question order.
+func (r *MonitorRepository) ListStudentAnswers(ctx context.Context, examID uuid.UUID, studentID int) ([]model.SessionTimelineAnswer, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT sa.question_id, COALESCE(q.order_num, 0), sa.answer, sa.updated_at
+		 FROM student_answers sa
+		 LEFT JOIN questions q ON q.id = sa.question_id
+		 WHERE sa.exam_id = $1 AND sa.student_id = $2
+		 ORDER BY q.order_num NULLS LAST, sa.updated_at`,
+		examID, studentID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers := []model.SessionTimelineAnswer{}
+	for rows.Next() {
+		var a model.SessionTimelineAnswer
+		if err := rows.Scan(&a.QuestionID, &a.OrderNum, &a.Answer, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		answers = append(answers, a)
+	}
+	return answers, rows.Err()
+}
+
+// ListActivityExams returns the exams with sessions started within [from, to), with
+// their participants, completions, cheat events and average score, oldest first.
+func (r *MonitorRepository) ListActivityExams(ctx context.Context, from, to time.Time) ([]model.ActivityReportExam, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT e.id, e.title, MIN(s.started_at), COUNT(*),
+		       COUNT(*) FILTER (WHERE s.status = 'COMPLETED'),
+		       COALESCE(SUM((SELECT COUNT(*) FROM exam_cheats c WHERE c.exam_id = s.exam_id AND c.student_id = s.student_id)), 0)::int,
+		       ROUND(AVG(s.final_score) FILTER (WHERE s.status = 'COMPLETED')::numeric, 2)::float8
+		FROM exam_sessions s
+		JOIN exams e ON e.id = s.exam_id
+		WHERE s.started_at >= $1 AND s.started_at < $2
+		GROUP BY e.id, e.title
+		ORDER BY MIN(s.started_at), e.title`,
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exams := make([]model.ActivityReportExam, 0)
+	for rows.Next() {
+		var e model.ActivityReportExam
+		if err := rows.Scan(&e.ExamID, &e.Title, &e.HeldAt, &e.Participants, &e.Completed, &e.Incidents, &e.AverageScore); err != nil {
+			return nil, err
+		}
+		exams = append(exams, e)
+	}
+	return exams, rows.Err()
+}
+
+// GetActivityTotals returns, over the sessions started within [from, to), the distinct
+// students and the sessions with at least one cheat event.
+func (r *MonitorRepository) GetActivityTotals(ctx context.Context, from, to time.Time) (participants, flagged int, err error) {
+	err = r.pool.QueryRow(ctx, cheatSessionsCTE+`
+		SELECT COUNT(DISTINCT student_id), COUNT(*) FILTER (WHERE events > 0)
+		FROM sess`,
+		from, to,
+	).Scan(&participants, &flagged)
+	return participants, flagged, err
+}
+
+// cheatSessionsCTE selects the exam sessions started within [$1, $2) with the
+// number of cheat events of each.
+const cheatSessionsCTE = `
+	WITH sess AS (
+		SELECT s.exam_id, s.student_id,
+		       (SELECT COUNT(*) FROM exam_cheats c WHERE c.exam_id = s.exam_id AND c.student_id = s.student_id) AS events
+		FROM exam_sessions s
+		WHERE s.started_at >= $1 AND s.started_at < $2
+	)`
+
+// GetClassCheatRates returns, per class, how many sessions started within [from, to)
+// had cheat events, classes with the highest share first.
+func (r *MonitorRepository) GetClassCheatRates(ctx context.Context, from, to time.Time) ([]model.ClassCheatRate, error) {
+	rows, err := r.pool.Query(ctx, cheatSessionsCTE+`
+		SELECT cl.id, CONCAT(cl.grade_level, ' ', cl.major_code, ' ', cl.group_number),
+		       COUNT(*), COUNT(*) FILTER (WHERE sess.events > 0), COALESCE(SUM(sess.events), 0)::int
+		FROM sess
+		JOIN students st ON st.id = sess.student_id
+		JOIN classes cl ON cl.id = st.class_id
+		GROUP BY cl.id
+		ORDER BY COUNT(*) FILTER (WHERE sess.events > 0)::float8 / COUNT(*) DESC, cl.grade_level, cl.major_code, cl.group_number`,
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make([]model.ClassCheatRate, 0)
+	for rows.Next() {
+		var c model.ClassCheatRate
+		if err := rows.Scan(&c.ClassID, &c.ClassName, &c.Sessions, &c.FlaggedSessions, &c.Events); err != nil {
+			return nil, err
+		}
+		if c.Sessions > 0 {
+			c.FlaggedRate = float64(c.FlaggedSessions) / float64(c.Sessions)
+		}
+		rates = append(rates, c)
+	}
+	return rates, rows.Err()
+}
+
+// GetRepeatCheaters returns up to limit students with cheat events in at least
+// minExams of their sessions started within [from, to), most flagged exams first.
+func (r *MonitorRepository) GetRepeatCheaters(ctx context.Context, from, to time.Time, minExams, limit int) ([]model.StudentCheatCount, error) {
+	rows, err := r.pool.Query(ctx, cheatSessionsCTE+`
+		SELECT st.id, st.name, st.nisn, CONCAT(cl.grade_level, ' ', cl.major_code, ' ', cl.group_number),
+		       COUNT(*), COUNT(*) FILTER (WHERE sess.events > 0), SUM(sess.events)::int
+		FROM sess
+		JOIN students st ON st.id = sess.student_id
+		JOIN classes cl ON cl.id = st.class_id
+		GROUP BY st.id, cl.id
+		HAVING COUNT(*) FILTER (WHERE sess.events > 0) >= $3
+		ORDER BY COUNT(*) FILTER (WHERE sess.events > 0) DESC, SUM(sess.events) DESC, st.name
+		LIMIT $4`,
+		from, to, minExams, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	students := make([]model.StudentCheatCount, 0)
+	for rows.Next() {
+		var s model.StudentCheatCount
+		if err := rows.Scan(&s.StudentID, &s.Name, &s.NISN, &s.ClassName, &s.Sessions, &s.FlaggedExams, &s.Events); err != nil {
+			return nil, err
+		}
+		students = append(students, s)
+	}
+	return students, rows.Err()
+}
+
+// GetCheatTypeCounts returns the cheat events of the sessions started within
+// [from, to) by their event type, the most frequent first. Events without a type are
+// counted as "unknown".
+func (r *MonitorRepository) GetCheatTypeCounts(ctx context.Context, from, to time.Time) ([]model.CheatTypeCount, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT COALESCE(c.event_data->>'type', 'unknown') AS event_type, COUNT(*), COUNT(DISTINCT c.student_id)
+		 FROM exam_cheats c
+		 JOIN exam_sessions s ON s.exam_id = c.exam_id AND s.student_id = c.student_id
+		 WHERE s.started_at >= $1 AND s.started_at < $2
+		 GROUP BY event_type
+		 ORDER BY COUNT(*) DESC, event_type`,
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]model.CheatTypeCount, 0)
+	for rows.Next() {
+		var t model.CheatTypeCount
+		if err := rows.Scan(&t.Type, &t.Events, &t.Students); err != nil {
+			return nil, err
+		}
+		counts = append(counts, t)
+	}
+	return counts, rows.Err()
+}
+
+// GetActiveExamOverviews returns the session counts of every IN_PROGRESS exam and, as
+// SubmitsPerMinute, the raw number of sessions finished since since.
+func (r *MonitorRepository) GetActiveExamOverviews(ctx context.Context, since time.Time) ([]model.ExamOverview, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT e.id, e.title,
+		        COUNT(s.id) FILTER (WHERE s.status = 'IN_PROGRESS'),
+		        COUNT(s.id) FILTER (WHERE s.status = 'COMPLETED'),
+		        COUNT(s.id) FILTER (WHERE s.finished_at >= $1)
+		 FROM exams e
+		 LEFT JOIN exam_sessions s ON s.exam_id = e.id
+		 WHERE e.status = 'IN_PROGRESS'
+		 GROUP BY e.id, e.title
+		 ORDER BY e.title`,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exams []model.ExamOverview
+	for rows.Next() {
+		var e model.ExamOverview
+		var submitted int
+		if err := rows.Scan(&e.ExamID, &e.Title, &e.InProgress, &e.Completed, &submitted); err != nil {
+			return nil, err
+		}
+		e.SubmitsPerMinute = float64(submitted)
+		exams = append(exams, e)
+	}
+	return exams, rows.Err()
+}
+
+// CountConnected returns, per exam, how many students' exam sockets were seen since since.
+func (r *MonitorRepository) CountConnected(ctx context.Context, examIDs []uuid.UUID, since time.Time) (map[uuid.UUID]int, error) {
+	pipe := r.rdb.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(examIDs))
+	for i, id := range examIDs {
+		cmds[i] = pipe.HVals(ctx, config.CacheKey.ExamLastSeenKey(id.String()))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	connected := make(map[uuid.UUID]int, len(examIDs))
+	for i, id := range examIDs {
+		for _, v := range cmds[i].Val() {
+			if seen, err := strconv.ParseInt(v, 10, 64); err == nil && seen >= since.Unix() {
+				connected[id]++
+			}
+		}
+	}
+	return connected, nil
+}
+
+// GetRequestStats sums the API request counters of the unix minutes from to to.
+func (r *MonitorRepository) GetRequestStats(ctx context.Context, from, to int64) (total, failed int64, err error) {
+	pipe := r.rdb.Pipeline()
+	var cmds []*redis.SliceCmd
+	for m := from; m <= to; m++ {
+		cmds = append(cmds, pipe.HMGet(ctx, config.CacheKey.RequestStatsKey(m), "total", "failed"))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return 0, 0, err
+	}
+
+	for _, cmd := range cmds {
+		vals := cmd.Val()
+		if len(vals) != 2 {
+			continue
+		}
+		if s, ok := vals[0].(string); ok {
+			n, _ := strconv.ParseInt(s, 10, 64)
+			total += n
+		}
+		if s, ok := vals[1].(string); ok {
+			n, _ := strconv.ParseInt(s, 10, 64)
+			failed += n
+		}
+	}
+	return total, failed, nil
+}
+
+// GetLiveAnswers returns the autosaved answers of each given student, question ID to
+// answer, read from their Redis answer hashes. Students without answers are left out.
+func (r *MonitorRepository) GetLiveAnswers(ctx context.Context, examID uuid.UUID, studentIDs []int) ([]map[string]string, error) {
+	pipe := r.rdb.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(studentIDs))
+	for i, sid := range studentIDs {
+		cmds[i] = pipe.HGetAll(ctx, config.CacheKey.StudentAnswersKey(examID.String(), sid))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	answers := make([]map[string]string, 0, len(studentIDs))
+	for _, cmd := range cmds {
+		if vals := cmd.Val(); len(vals) > 0 {
+			answers = append(answers, vals)
+		}
+	}
+	return answers, nil
+}
+
+// GetSubmittedAnswerCounts counts, per question and answer, the persisted answers of
+// the sessions that are no longer in progress, whose Redis answer hashes are gone.
+// Answers anonymized by retention have no student, hence no session, and are counted.
+func (r *MonitorRepository) GetSubmittedAnswerCounts(ctx context.Context, examID uuid.UUID) (map[string]map[string]int64, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT sa.question_id::text, sa.answer, COUNT(*)
+		 FROM student_answers sa
+		 LEFT JOIN exam_sessions es ON es.exam_id = sa.exam_id AND es.student_id = sa.student_id
+		 WHERE sa.exam_id = $1 AND (es.status IS NULL OR es.status <> 'IN_PROGRESS') AND sa.answer <> ''
+		 GROUP BY sa.question_id, sa.answer`,
+		examID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[string]int64)
+	for rows.Next() {
+		var qid, answer string
+		var n int64
+		if err := rows.Scan(&qid, &answer, &n); err != nil {
+			return nil, err
+		}
+		if counts[qid] == nil {
+			counts[qid] = make(map[string]int64)
+		}
+		counts[qid][answer] += n
+	}
+	return counts, rows.Err()
+}
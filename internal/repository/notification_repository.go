@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// NotificationRepository handles exam notification settings data access.
+type NotificationRepository struct {
+	pool *database.DB
+}
+
+// NewNotificationRepository creates a new NotificationRepository.
+func NewNotificationRepository(pool *pgxpool.Pool) *NotificationRepository {
+	return &NotificationRepository{pool: database.Wrap(pool)}
+}
+
+// GetSettings retrieves an exam's notification settings.
+func (r *NotificationRepository) GetSettings(ctx context.Context, examID uuid.UUID) (*model.ExamNotificationSettings, error) {
+	s := &model.ExamNotificationSettings{}
+	err := r.pool.QueryRow(ctx,
+		`SELECT n.exam_id, e.title, n.telegram_chat_ids, n.whatsapp_numbers, n.notify_exam_start,
+			n.cheat_threshold, n.notify_queue_backlog, n.created_at, n.updated_at, n.notify_homeroom_summary,
+			n.notify_login_anomaly
+		 FROM exam_notification_settings n
+		 JOIN exams e ON e.id = n.exam_id
+		 WHERE n.exam_id = $1`, examID,
+	).Scan(&s.ExamID, &s.ExamTitle, &s.TelegramChatIDs, &s.WhatsAppNumbers, &s.NotifyExamStart,
+		&s.CheatThreshold, &s.NotifyQueueBacklog, &s.CreatedAt, &s.UpdatedAt, &s.NotifyHomeroomSummary,
+		&s.NotifyLoginAnomaly)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UpsertSettings creates or replaces an exam's notification settings.
+func (r *NotificationRepository) UpsertSettings(ctx context.Context, s *model.ExamNotificationSettings) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO exam_notification_settings
+			(exam_id, telegram_chat_ids, whatsapp_numbers, notify_exam_start, cheat_threshold, notify_queue_backlog, notify_homeroom_summary,
+			notify_login_anomaly)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (exam_id) DO UPDATE SET
+			telegram_chat_ids = EXCLUDED.telegram_chat_ids,
+			whatsapp_numbers = EXCLUDED.whatsapp_numbers,
+			notify_exam_start = EXCLUDED.notify_exam_start,
+			cheat_threshold = EXCLUDED.cheat_threshold,
+			notify_queue_backlog = EXCLUDED.notify_queue_backlog,
+			notify_homeroom_summary = EXCLUDED.notify_homeroom_summary,
+			notify_login_anomaly = EXCLUDED.notify_login_anomaly,
+			updated_at = NOW()
+		 RETURNING (SELECT title FROM exams WHERE id = $1), created_at, updated_at`,
+		s.ExamID, s.TelegramChatIDs, s.WhatsAppNumbers, s.NotifyExamStart, s.CheatThreshold, s.NotifyQueueBacklog,
+		s.NotifyHomeroomSummary, s.NotifyLoginAnomaly,
+	).Scan(&s.ExamTitle, &s.CreatedAt, &s.UpdatedAt)
+}
+
+// DeleteSettings removes an exam's notification settings.
+func (r *NotificationRepository) DeleteSettings(ctx context.Context, examID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM exam_notification_settings WHERE exam_id = $1`, examID)
+	return err
+}
+
+// ListBacklogSubscribers returns the settings of IN_PROGRESS exams that want queue backlog alerts.
+func (r *NotificationRepository) ListBacklogSubscribers(ctx context.Context) ([]model.ExamNotificationSettings, error) {
+	return r.listSubscribers(ctx, `n.notify_queue_backlog AND e.status = $1`, model.ExamStatusInProgress)
+}
+
+// ListLoginAnomalySubscribers returns the settings of PUBLISHED and IN_PROGRESS exams
+// that want login anomaly alerts.
+func (r *NotificationRepository) ListLoginAnomalySubscribers(ctx context.Context) ([]model.ExamNotificationSettings, error) {
+	return r.listSubscribers(ctx, `n.notify_login_anomaly AND e.status IN ($1, $2)`, model.ExamStatusPublished, model.ExamStatusInProgress)
+}
+
+// listSubscribers returns the settings matching where, a condition on the settings n
+// and their exam e.
+func (r *NotificationRepository) listSubscribers(ctx context.Context, where string, args ...any) ([]model.ExamNotificationSettings, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT n.exam_id, e.title, n.telegram_chat_ids, n.whatsapp_numbers, n.notify_exam_start,
+			n.cheat_threshold, n.notify_queue_backlog, n.created_at, n.updated_at, n.notify_homeroom_summary,
+			n.notify_login_anomaly
+		 FROM exam_notification_settings n
+		 JOIN exams e ON e.id = n.exam_id
+		 WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []model.ExamNotificationSettings
+	for rows.Next() {
+		var s model.ExamNotificationSettings
+		if err := rows.Scan(&s.ExamID, &s.ExamTitle, &s.TelegramChatIDs, &s.WhatsAppNumbers, &s.NotifyExamStart,
+			&s.CheatThreshold, &s.NotifyQueueBacklog, &s.CreatedAt, &s.UpdatedAt, &s.NotifyHomeroomSummary,
+			&s.NotifyLoginAnomaly); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// CountCheats returns the total cheat events recorded per student in an exam,
+// limited to the given students.
+func (r *NotificationRepository) CountCheats(ctx context.Context, examID uuid.UUID, studentIDs []int) ([]model.CheatCount, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT c.student_id, s.name, COUNT(*)
+		 FROM exam_cheats c
+		 JOIN students s ON s.id = c.student_id
+		 WHERE c.exam_id = $1 AND c.student_id = ANY($2)
+		 GROUP BY c.student_id, s.name`, examID, studentIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []model.CheatCount
+	for rows.Next() {
+		var cc model.CheatCount
+		if err := rows.Scan(&cc.StudentID, &cc.Name, &cc.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, cc)
+	}
+	return counts, rows.Err()
+}
+
+// ListGuardianResultRecipients returns the guardians who want result notifications,
+// once per child with a completed session in the exam.
+func (r *NotificationRepository) ListGuardianResultRecipients(ctx context.Context, examID uuid.UUID) ([]model.GuardianRecipient, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT g.id, g.notify_channel, g.telegram_chat_id, g.phone, s.name, e.title
+		 FROM guardians g
+		 JOIN guardian_students gs ON gs.guardian_id = g.id
+		 JOIN exam_sessions es ON es.student_id = gs.student_id
+		 JOIN students s ON s.id = gs.student_id
+		 JOIN exams e ON e.id = es.exam_id
+		 WHERE es.exam_id = $1 AND es.status = $2
+		   AND g.notify_results AND g.notify_channel <> $3`,
+		examID, model.SessionStatusCompleted, model.NotifyChannelNone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []model.GuardianRecipient
+	for rows.Next() {
+		var gr model.GuardianRecipient
+		if err := rows.Scan(&gr.GuardianID, &gr.Channel, &gr.TelegramChatID, &gr.Phone, &gr.StudentName, &gr.ExamTitle); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, gr)
+	}
+	return recipients, rows.Err()
+}
+
+// ListHomeroomSummaries returns an exam's results grouped by class, for the classes
+// whose homeroom teacher has an email address.
+func (r *NotificationRepository) ListHomeroomSummaries(ctx context.Context, examID uuid.UUID) ([]model.HomeroomSummary, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT c.id, CONCAT(c.grade_level, ' ', c.major_code, ' ', c.group_number), a.name, a.email,
+			s.name, s.nisn, es.status, es.final_score
+		 FROM exam_sessions es
+		 JOIN students s ON s.id = es.student_id
+		 JOIN classes c ON c.id = s.class_id
+		 JOIN admins a ON a.id = c.homeroom_admin_id
+		 WHERE es.exam_id = $1 AND a.email <> ''
+		 ORDER BY c.grade_level, c.major_code, c.group_number, s.name`, examID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []model.HomeroomSummary
+	for rows.Next() {
+		var hs model.HomeroomSummary
+		var res model.HomeroomResult
+		if err := rows.Scan(&hs.ClassID, &hs.ClassName, &hs.TeacherName, &hs.TeacherEmail,
+			&res.Name, &res.NISN, &res.Status, &res.FinalScore); err != nil {
+			return nil, err
+		}
+		if n := len(summaries); n == 0 || summaries[n-1].ClassID != hs.ClassID {
+			summaries = append(summaries, hs)
+		}
+		last := &summaries[len(summaries)-1]
+		last.Results = append(last.Results, res)
+	}
+	return summaries, rows.Err()
+}
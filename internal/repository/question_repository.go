@@ -5,17 +5,18 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
 // QuestionRepository handles question data access.
 type QuestionRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 }
 
 // NewQuestionRepository creates a new QuestionRepository.
 func NewQuestionRepository(pool *pgxpool.Pool) *QuestionRepository {
-	return &QuestionRepository{pool: pool}
+	return &QuestionRepository{pool: database.Wrap(pool)}
 }
 
 // ListQBanks retrieves question banks with pagination and search.
@@ -155,7 +156,7 @@ func (r *QuestionRepository) DeleteQBanks(ctx context.Context, qbankID uuid.UUID
 // ListByQBank retrieves all questions for a given qbank, ordered by order_num.
 func (r *QuestionRepository) ListByQBank(ctx context.Context, qbankID uuid.UUID) ([]model.Question, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, qbank_id, question_text, question_type, options, correct_option, order_num
+		`SELECT id, qbank_id, question_text, question_type, options, correct_option, order_num, max_plays, math_latex, passage_id, explanation, translations
 		 FROM questions WHERE qbank_id = $1
 		 ORDER BY order_num`, qbankID,
 	)
@@ -167,7 +168,7 @@ func (r *QuestionRepository) ListByQBank(ctx context.Context, qbankID uuid.UUID)
 	var questions []model.Question
 	for rows.Next() {
 		var q model.Question
-		if err := rows.Scan(&q.ID, &q.QBankID, &q.QuestionText, &q.QuestionType, &q.Options, &q.CorrectOption, &q.OrderNum); err != nil {
+		if err := rows.Scan(&q.ID, &q.QBankID, &q.QuestionText, &q.QuestionType, &q.Options, &q.CorrectOption, &q.OrderNum, &q.MaxPlays, &q.MathLatex, &q.PassageID, &q.Explanation, &q.Translations); err != nil {
 			return nil, err
 		}
 		questions = append(questions, q)
@@ -178,7 +179,7 @@ func (r *QuestionRepository) ListByQBank(ctx context.Context, qbankID uuid.UUID)
 // ListByExam retrieves all questions by exam id
 func (r *QuestionRepository) ListByExam(ctx context.Context, examID uuid.UUID) ([]model.Question, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT q.id, q.qbank_id, q.question_text, q.question_type, q.options, q.correct_option, q.order_num
+		`SELECT q.id, q.qbank_id, q.question_text, q.question_type, q.options, q.correct_option, q.order_num, q.max_plays, q.math_latex, q.passage_id, q.explanation, q.translations
 		 FROM 
 		 	questions q 
 		INNER JOIN
@@ -197,7 +198,7 @@ func (r *QuestionRepository) ListByExam(ctx context.Context, examID uuid.UUID) (
 	var questions []model.Question
 	for rows.Next() {
 		var q model.Question
-		if err := rows.Scan(&q.ID, &q.QBankID, &q.QuestionText, &q.QuestionType, &q.Options, &q.CorrectOption, &q.OrderNum); err != nil {
+		if err := rows.Scan(&q.ID, &q.QBankID, &q.QuestionText, &q.QuestionType, &q.Options, &q.CorrectOption, &q.OrderNum, &q.MaxPlays, &q.MathLatex, &q.PassageID, &q.Explanation, &q.Translations); err != nil {
 			return nil, err
 		}
 		questions = append(questions, q)
@@ -209,10 +210,10 @@ func (r *QuestionRepository) ListByExam(ctx context.Context, examID uuid.UUID) (
 func (r *QuestionRepository) Create(ctx context.Context, q *model.Question) error {
 	return r.pool.QueryRow(ctx,
 		`INSERT INTO questions
-			(qbank_id, question_text, question_type, options, correct_option, order_num)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+			(qbank_id, question_text, question_type, options, correct_option, order_num, max_plays, math_latex, passage_id, explanation, translations)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11, '{}'::jsonb))
 		 RETURNING id`,
-		q.QBankID, q.QuestionText, q.QuestionType, q.Options, q.CorrectOption, q.OrderNum,
+		q.QBankID, q.QuestionText, q.QuestionType, q.Options, q.CorrectOption, q.OrderNum, q.MaxPlays, q.MathLatex, q.PassageID, q.Explanation, q.Translations,
 	).Scan(&q.ID)
 }
 
@@ -233,10 +234,10 @@ func (r *QuestionRepository) ReplaceAll(ctx context.Context, qbankID uuid.UUID,
 	for _, q := range questions {
 		err := tx.QueryRow(ctx,
 			`INSERT INTO questions
-				(qbank_id, question_text, question_type, options, correct_option, order_num)
-			 VALUES ($1, $2, $3, $4, $5, $6)
+				(qbank_id, question_text, question_type, options, correct_option, order_num, max_plays, math_latex, passage_id, explanation, translations)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11, '{}'::jsonb))
 			 RETURNING id`,
-			qbankID, q.QuestionText, q.QuestionType, q.Options, q.CorrectOption, q.OrderNum,
+			qbankID, q.QuestionText, q.QuestionType, q.Options, q.CorrectOption, q.OrderNum, q.MaxPlays, q.MathLatex, q.PassageID, q.Explanation, q.Translations,
 		).Scan(&q.ID)
 		if err != nil {
 			return err
@@ -245,3 +246,329 @@ func (r *QuestionRepository) ReplaceAll(ctx context.Context, qbankID uuid.UUID,
 
 	return tx.Commit(ctx)
 }
+
+// AppendBatch inserts questions after the existing ones in a qbank in a single transaction.
+// order_num continues from the current maximum.
+func (r *QuestionRepository) AppendBatch(ctx context.Context, qbankID uuid.UUID, questions []model.Question) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var maxOrder int
+	if err := tx.QueryRow(ctx,
+		`SELECT COALESCE(MAX(order_num), 0) FROM questions WHERE qbank_id = $1`, qbankID,
+	).Scan(&maxOrder); err != nil {
+		return err
+	}
+
+	for i := range questions {
+		q := &questions[i]
+		q.QBankID = qbankID
+		q.OrderNum = maxOrder + i + 1
+		err := tx.QueryRow(ctx,
+			`INSERT INTO questions
+				(qbank_id, question_text, question_type, options, correct_option, order_num, max_plays, math_latex, passage_id, explanation, translations)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11, '{}'::jsonb))
+			 RETURNING id`,
+			qbankID, q.QuestionText, q.QuestionType, q.Options, q.CorrectOption, q.OrderNum, q.MaxPlays, q.MathLatex, q.PassageID, q.Explanation, q.Translations,
+		).Scan(&q.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// FindSharedText returns one entry per question of examID and exam in otherExamIDs whose
+// question bank holds the same question text. Text is compared by an MD5 hash after trimming, collapsing whitespace and lower-casing.
+func (r *QuestionRepository) FindSharedText(ctx context.Context, examID uuid.UUID, otherExamIDs []uuid.UUID) ([]model.QuestionReuse, error) {
+	rows, err := r.pool.Query(ctx,
+		`WITH own AS (
+			SELECT q.id, q.order_num,
+			       md5(lower(regexp_replace(btrim(q.question_text), '\s+', ' ', 'g'))) AS text_hash
+			FROM questions q
+			JOIN exams e ON e.qbank_id = q.qbank_id
+			WHERE e.id = $1
+		 )
+		 SELECT DISTINCT own.id, own.order_num, o.id, o.title
+		 FROM own
+		 JOIN exams o ON o.id = ANY($2) AND o.id <> $1
+		 JOIN questions oq ON oq.qbank_id = o.qbank_id
+		 WHERE md5(lower(regexp_replace(btrim(oq.question_text), '\s+', ' ', 'g'))) = own.text_hash
+		 ORDER BY own.order_num, o.title`,
+		examID, otherExamIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reuses []model.QuestionReuse
+	for rows.Next() {
+		var ru model.QuestionReuse
+		if err := rows.Scan(&ru.QuestionID, &ru.OrderNum, &ru.OtherExamID, &ru.OtherTitle); err != nil {
+			return nil, err
+		}
+		reuses = append(reuses, ru)
+	}
+	return reuses, rows.Err()
+}
+
+// ListPassages retrieves the passages of a qbank.
+func (r *QuestionRepository) ListPassages(ctx context.Context, qbankID uuid.UUID) ([]model.Passage, error) {
+	return r.queryPassages(ctx,
+		`SELECT id, qbank_id, title, content, created_at, updated_at
+		 FROM question_passages WHERE qbank_id = $1
+		 ORDER BY created_at`, qbankID)
+}
+
+// ListPassagesByExam retrieves the passages of an exam's qbank that questions refer to.
+func (r *QuestionRepository) ListPassagesByExam(ctx context.Context, examID uuid.UUID) ([]model.Passage, error) {
+	return r.queryPassages(ctx,
+		`SELECT p.id, p.qbank_id, p.title, p.content, p.created_at, p.updated_at
+		 FROM question_passages p
+		 JOIN exams e ON e.qbank_id = p.qbank_id
+		 WHERE e.id = $1 AND EXISTS (SELECT 1 FROM questions q WHERE q.passage_id = p.id)
+		 ORDER BY p.created_at`, examID)
+}
+
+func (r *QuestionRepository) queryPassages(ctx context.Context, query string, args ...interface{}) ([]model.Passage, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var passages []model.Passage
+	for rows.Next() {
+		var p model.Passage
+		if err := rows.Scan(&p.ID, &p.QBankID, &p.Title, &p.Content, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		passages = append(passages, p)
+	}
+	return passages, rows.Err()
+}
+
+// GetPassage retrieves a passage by ID.
+func (r *QuestionRepository) GetPassage(ctx context.Context, id uuid.UUID) (*model.Passage, error) {
+	var p model.Passage
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, qbank_id, title, content, created_at, updated_at
+		 FROM question_passages WHERE id = $1`, id,
+	).Scan(&p.ID, &p.QBankID, &p.Title, &p.Content, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreatePassage inserts a new passage.
+func (r *QuestionRepository) CreatePassage(ctx context.Context, p *model.Passage) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO question_passages (qbank_id, title, content)
+		 VALUES ($1, $2, $3)
+		 RETURNING id, created_at, updated_at`,
+		p.QBankID, p.Title, p.Content,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// UpdatePassage updates a passage's title and content. Returns pgx.ErrNoRows when the
+// passage does not belong to the qbank.
+func (r *QuestionRepository) UpdatePassage(ctx context.Context, p *model.Passage) error {
+	return r.pool.QueryRow(ctx,
+		`UPDATE question_passages SET title = $3, content = $4, updated_at = NOW()
+		 WHERE id = $1 AND qbank_id = $2
+		 RETURNING created_at, updated_at`,
+		p.ID, p.QBankID, p.Title, p.Content,
+	).Scan(&p.CreatedAt, &p.UpdatedAt)
+}
+
+// DeletePassage removes a passage from a qbank. Its questions remain, unlinked.
+// Returns false when the passage does not belong to the qbank.
+func (r *QuestionRepository) DeletePassage(ctx context.Context, qbankID, id uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM question_passages WHERE id = $1 AND qbank_id = $2`, id, qbankID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Reorder renumbers a qbank's questions in the order of ids, in a single transaction.
+// Nothing changes, and false is returned, unless ids lists every question of the qbank once.
+func (r *QuestionRepository) Reorder(ctx context.Context, qbankID uuid.UUID, ids []uuid.UUID) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var total int
+	if err := tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM questions WHERE qbank_id = $1`, qbankID,
+	).Scan(&total); err != nil {
+		return false, err
+	}
+	if total != len(ids) {
+		return false, nil
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE questions q SET order_num = o.ord
+		 FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, ord)
+		 WHERE q.id = o.id AND q.qbank_id = $1`,
+		qbankID, ids)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() != int64(len(ids)) {
+		return false, nil
+	}
+	return true, tx.Commit(ctx)
+}
+
+// DeleteMany removes questions from a qbank in a single transaction. Nothing is
+// deleted, and false is returned, unless every ID belongs to the qbank.
+func (r *QuestionRepository) DeleteMany(ctx context.Context, qbankID uuid.UUID, ids []uuid.UUID) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM questions WHERE qbank_id = $1 AND id = ANY($2)`, qbankID, ids)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() != int64(len(ids)) {
+		return false, nil
+	}
+	return true, tx.Commit(ctx)
+}
+
+// Transfer moves, or with duplicate set copies, questions of qbankID to the end of
+// targetID in a single transaction, keeping their relative order. Passages the
+// questions refer to are copied to the target qbank along with them. Nothing
+// changes, and false is returned, unless every ID belongs to qbankID.
+func (r *QuestionRepository) Transfer(ctx context.Context, qbankID, targetID uuid.UUID, ids []uuid.UUID, duplicate bool) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`SELECT id, passage_id FROM questions
+		 WHERE qbank_id = $1 AND id = ANY($2)
+		 ORDER BY order_num`, qbankID, ids)
+	if err != nil {
+		return false, err
+	}
+	type source struct {
+		id        uuid.UUID
+		passageID *uuid.UUID
+	}
+	var sources []source
+	for rows.Next() {
+		var src source
+		if err := rows.Scan(&src.id, &src.passageID); err != nil {
+			rows.Close()
+			return false, err
+		}
+		sources = append(sources, src)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	if len(sources) != len(ids) {
+		return false, nil
+	}
+
+	var maxOrder int
+	if err := tx.QueryRow(ctx,
+		`SELECT COALESCE(MAX(order_num), 0) FROM questions WHERE qbank_id = $1`, targetID,
+	).Scan(&maxOrder); err != nil {
+		return false, err
+	}
+
+	copiedPassages := make(map[uuid.UUID]uuid.UUID)
+	for i, src := range sources {
+		var passageID *uuid.UUID
+		if src.passageID != nil {
+			newID, ok := copiedPassages[*src.passageID]
+			if !ok {
+				if err := tx.QueryRow(ctx,
+					`INSERT INTO question_passages (qbank_id, title, content)
+					 SELECT $1, title, content FROM question_passages WHERE id = $2
+					 RETURNING id`, targetID, *src.passageID,
+				).Scan(&newID); err != nil {
+					return false, err
+				}
+				copiedPassages[*src.passageID] = newID
+			}
+			passageID = &newID
+		}
+
+		orderNum := maxOrder + i + 1
+		if duplicate {
+			_, err = tx.Exec(ctx,
+				`INSERT INTO questions
+					(qbank_id, question_text, question_type, options, correct_option, order_num, max_plays, math_latex, passage_id, explanation, translations)
+				 SELECT $1, question_text, question_type, options, correct_option, $2, max_plays, math_latex, $3, explanation, translations
+				 FROM questions WHERE id = $4`,
+				targetID, orderNum, passageID, src.id)
+		} else {
+			_, err = tx.Exec(ctx,
+				`UPDATE questions SET qbank_id = $1, order_num = $2, passage_id = $3 WHERE id = $4`,
+				targetID, orderNum, passageID, src.id)
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// FindDuplicates compares texts with the questions of a qbank and returns, for each text
+// matching a question, the most similar one. Texts are normalized by trimming, collapsing
+// whitespace and lower-casing; a text matches when its MD5 hash is equal or its trigram
+// similarity reaches threshold. Question is the 1-based position in texts.
+func (r *QuestionRepository) FindDuplicates(ctx context.Context, qbankID uuid.UUID, texts []string, threshold float64) ([]model.QuestionDuplicate, error) {
+	rows, err := r.pool.Query(ctx,
+		`WITH candidate AS (
+			SELECT c.idx, lower(regexp_replace(btrim(c.text), '\s+', ' ', 'g')) AS norm
+			FROM unnest($2::text[]) WITH ORDINALITY AS c(text, idx)
+		 ), existing AS (
+			SELECT id, order_num, lower(regexp_replace(btrim(question_text), '\s+', ' ', 'g')) AS norm
+			FROM questions
+			WHERE qbank_id = $1
+		 )
+		 SELECT DISTINCT ON (c.idx) c.idx, e.id, e.order_num,
+		        similarity(c.norm, e.norm) AS score, md5(c.norm) = md5(e.norm) AS exact
+		 FROM candidate c
+		 JOIN existing e ON md5(c.norm) = md5(e.norm) OR similarity(c.norm, e.norm) >= $3
+		 ORDER BY c.idx, exact DESC, score DESC, e.order_num`,
+		qbankID, texts, threshold,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dups []model.QuestionDuplicate
+	for rows.Next() {
+		var d model.QuestionDuplicate
+		if err := rows.Scan(&d.Question, &d.ExistingID, &d.ExistingOrderNum, &d.Similarity, &d.Exact); err != nil {
+			return nil, err
+		}
+		dups = append(dups, d)
+	}
+	return dups, rows.Err()
+}
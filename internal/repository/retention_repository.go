@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// retentionQuery selects the rows of a retention target and action: count counts the
+// rows older than $1 still to be handled, apply handles at most $2 of them.
+type retentionQuery struct {
+	count string
+	apply string
+}
+
+var retentionQueries = map[string]retentionQuery{
+	model.RetentionTargetStudentAnswers + "/" + model.RetentionActionDelete: {
+		count: `SELECT COUNT(*) FROM student_answers WHERE updated_at < $1`,
+		apply: `DELETE FROM student_answers WHERE id IN (
+			SELECT id FROM student_answers WHERE updated_at < $1 LIMIT $2)`,
+	},
+	model.RetentionTargetStudentAnswers + "/" + model.RetentionActionAnonymize: {
+		count: `SELECT COUNT(*) FROM student_answers WHERE updated_at < $1 AND student_id IS NOT NULL`,
+		apply: `UPDATE student_answers SET student_id = NULL WHERE id IN (
+			SELECT id FROM student_answers WHERE updated_at < $1 AND student_id IS NOT NULL LIMIT $2)`,
+	},
+	model.RetentionTargetCheatEvents + "/" + model.RetentionActionDelete: {
+		count: `SELECT COUNT(*) FROM exam_cheats WHERE recorded_at < $1`,
+		apply: `DELETE FROM exam_cheats WHERE id IN (
+			SELECT id FROM exam_cheats WHERE recorded_at < $1 LIMIT $2)`,
+	},
+	model.RetentionTargetMonitorEvents + "/" + model.RetentionActionDelete: {
+		count: `SELECT COUNT(*) FROM exam_monitor_events WHERE recorded_at < $1`,
+		apply: `DELETE FROM exam_monitor_events WHERE id IN (
+			SELECT id FROM exam_monitor_events WHERE recorded_at < $1 LIMIT $2)`,
+	},
+	model.RetentionTargetAuditLogs + "/" + model.RetentionActionDelete: {
+		count: `SELECT COUNT(*) FROM audit_logs WHERE created_at < $1`,
+		apply: `DELETE FROM audit_logs WHERE id IN (
+			SELECT id FROM audit_logs WHERE created_at < $1 LIMIT $2)`,
+	},
+	model.RetentionTargetAuditLogs + "/" + model.RetentionActionAnonymize: {
+		count: `SELECT COUNT(*) FROM audit_logs WHERE created_at < $1 AND ip <> ''`,
+		apply: `UPDATE audit_logs SET ip = '' WHERE id IN (
+			SELECT id FROM audit_logs WHERE created_at < $1 AND ip <> '' LIMIT $2)`,
+	},
+}
+
+// RetentionRepository handles database operations for retention rules and the data
+// they purge.
+type RetentionRepository struct {
+	pool *database.DB
+}
+
+// NewRetentionRepository creates a new RetentionRepository.
+func NewRetentionRepository(pool *pgxpool.Pool) *RetentionRepository {
+	return &RetentionRepository{pool: database.Wrap(pool)}
+}
+
+// ListRules retrieves all retention rules.
+func (r *RetentionRepository) ListRules(ctx context.Context) ([]model.RetentionRule, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, target, action, after_days, enabled, created_at, updated_at
+		 FROM retention_rules
+		 ORDER BY target, action`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []model.RetentionRule
+	for rows.Next() {
+		var rule model.RetentionRule
+		if err := rows.Scan(
+			&rule.ID, &rule.Target, &rule.Action, &rule.AfterDays, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// CreateRule inserts a retention rule.
+func (r *RetentionRepository) CreateRule(ctx context.Context, rule *model.RetentionRule) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO retention_rules (target, action, after_days, enabled)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at, updated_at`,
+		rule.Target, rule.Action, rule.AfterDays, rule.Enabled,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+}
+
+// UpdateRule changes a retention rule's age and whether it is enabled.
+func (r *RetentionRepository) UpdateRule(ctx context.Context, rule *model.RetentionRule) error {
+	return r.pool.QueryRow(ctx,
+		`UPDATE retention_rules
+		 SET after_days = $1, enabled = $2, updated_at = NOW()
+		 WHERE id = $3
+		 RETURNING target, action, created_at, updated_at`,
+		rule.AfterDays, rule.Enabled, rule.ID,
+	).Scan(&rule.Target, &rule.Action, &rule.CreatedAt, &rule.UpdatedAt)
+}
+
+// DeleteRule removes a retention rule.
+func (r *RetentionRepository) DeleteRule(ctx context.Context, id int) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM retention_rules WHERE id = $1`, id)
+	return err
+}
+
+// CountExpired counts the rows a rule would handle at cutoff.
+func (r *RetentionRepository) CountExpired(ctx context.Context, rule model.RetentionRule, cutoff time.Time) (int64, error) {
+	q, ok := retentionQueries[rule.Target+"/"+rule.Action]
+	if !ok {
+		return 0, fmt.Errorf("no retention query for %s/%s", rule.Target, rule.Action)
+	}
+	var n int64
+	err := r.pool.QueryRow(ctx, q.count, cutoff).Scan(&n)
+	return n, err
+}
+
+// ApplyExpired deletes or anonymizes at most limit of the rows a rule handles at
+// cutoff and returns how many it changed. Small batches keep locks short.
+func (r *RetentionRepository) ApplyExpired(ctx context.Context, rule model.RetentionRule, cutoff time.Time, limit int) (int64, error) {
+	q, ok := retentionQueries[rule.Target+"/"+rule.Action]
+	if !ok {
+		return 0, fmt.Errorf("no retention query for %s/%s", rule.Target, rule.Action)
+	}
+	tag, err := r.pool.Exec(ctx, q.apply, cutoff, limit)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
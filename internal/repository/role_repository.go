@@ -5,17 +5,18 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
 // RoleRepository handles role and permission data access.
 type RoleRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 }
 
 // NewRoleRepository creates a new RoleRepository.
 func NewRoleRepository(pool *pgxpool.Pool) *RoleRepository {
-	return &RoleRepository{pool: pool}
+	return &RoleRepository{pool: database.Wrap(pool)}
 }
 
 // GetPermissionsByRoleID retrieves all permission codes for a given role.
@@ -43,6 +44,58 @@ func (r *RoleRepository) GetPermissionsByRoleID(ctx context.Context, roleID int)
 	return permissions, rows.Err()
 }
 
+// GetSubjectScopesByRoleID retrieves the subject IDs each scoped permission of a role is limited to.
+func (r *RoleRepository) GetSubjectScopesByRoleID(ctx context.Context, roleID int) (map[string][]int, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT permission_code, subject_id
+		 FROM role_permission_scopes
+		 WHERE role_id = $1
+		 ORDER BY permission_code, subject_id`, roleID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scopes := make(map[string][]int)
+	for rows.Next() {
+		var code string
+		var subjectID int
+		if err := rows.Scan(&code, &subjectID); err != nil {
+			return nil, err
+		}
+		scopes[code] = append(scopes[code], subjectID)
+	}
+	return scopes, rows.Err()
+}
+
+// ReplaceSubjectScopes replaces all subject scopes of a role.
+func (r *RoleRepository) ReplaceSubjectScopes(ctx context.Context, roleID int, scopes map[string][]int) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM role_permission_scopes WHERE role_id = $1", roleID); err != nil {
+		return err
+	}
+	for code, subjectIDs := range scopes {
+		if len(subjectIDs) == 0 {
+			continue
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO role_permission_scopes (role_id, permission_code, subject_id)
+			 SELECT $1, $2, UNNEST($3::int[])
+			 ON CONFLICT DO NOTHING`,
+			roleID, code, subjectIDs,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
 // GetRoleByID retrieves a role and its permissions by ID.
 func (r *RoleRepository) GetRoleByID(ctx context.Context, id int) (*model.RoleWithPermissions, error) {
 	role := &model.Role{ID: id}
@@ -55,13 +108,25 @@ func (r *RoleRepository) GetRoleByID(ctx context.Context, id int) (*model.RoleWi
 	if err != nil {
 		return nil, err
 	}
+	scopes, err := r.GetSubjectScopesByRoleID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
 	return &model.RoleWithPermissions{
-		Role:        role,
-		Permissions: permissions,
+		Role:          role,
+		Permissions:   permissions,
+		SubjectScopes: scopes,
 	}, nil
 }
 
+// GetRoleIDByName retrieves the ID of the role with the given name.
+func (r *RoleRepository) GetRoleIDByName(ctx context.Context, name string) (int, error) {
+	var id int
+	err := r.pool.QueryRow(ctx, "SELECT id FROM roles WHERE name = $1", name).Scan(&id)
+	return id, err
+}
+
 // ListRolesWithPermissions retrieves all roles with their associated permissions.
 func (r *RoleRepository) ListRolesWithPermissions(ctx context.Context) ([]model.RoleWithPermissions, error) {
 	rows, err := r.pool.Query(ctx, "SELECT id, name, created_at FROM roles ORDER BY id")
@@ -83,10 +148,15 @@ func (r *RoleRepository) ListRolesWithPermissions(ctx context.Context) ([]model.
 		if err != nil {
 			return nil, err
 		}
+		scopes, err := r.GetSubjectScopesByRoleID(ctx, role.ID)
+		if err != nil {
+			return nil, err
+		}
 
 		roles = append(roles, model.RoleWithPermissions{
-			Role:        &role,
-			Permissions: permissions,
+			Role:          &role,
+			Permissions:   permissions,
+			SubjectScopes: scopes,
 		})
 	}
 
@@ -7,17 +7,18 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
 // RoomAssignmentRepository handles DB ops for room_sessions and student_room_assignments.
 type RoomAssignmentRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 }
 
 // NewRoomAssignmentRepository creates a new RoomAssignmentRepository.
 func NewRoomAssignmentRepository(pool *pgxpool.Pool) *RoomAssignmentRepository {
-	return &RoomAssignmentRepository{pool: pool}
+	return &RoomAssignmentRepository{pool: database.Wrap(pool)}
 }
 
 // ListSessions retrieves all room sessions with room details.
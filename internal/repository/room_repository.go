@@ -4,17 +4,18 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
 // RoomRepository handles database operations for rooms.
 type RoomRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 }
 
 // NewRoomRepository creates a new RoomRepository.
 func NewRoomRepository(pool *pgxpool.Pool) *RoomRepository {
-	return &RoomRepository{pool: pool}
+	return &RoomRepository{pool: database.Wrap(pool)}
 }
 
 // Create inserts a new room into the database.
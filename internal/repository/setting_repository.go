@@ -4,15 +4,16 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
 type SettingRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 }
 
 func NewSettingRepository(pool *pgxpool.Pool) *SettingRepository {
-	return &SettingRepository{pool: pool}
+	return &SettingRepository{pool: database.Wrap(pool)}
 }
 
 func (r *SettingRepository) GetAll(ctx context.Context) ([]model.AppSetting, error) {
@@ -8,8 +8,10 @@ import (
 
 	"errors"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
@@ -17,21 +19,21 @@ var ErrDuplicateNISN = errors.New("student with this NISN already exists")
 
 // StudentRepository handles student data access.
 type StudentRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 }
 
 // NewStudentRepository creates a new StudentRepository.
 func NewStudentRepository(pool *pgxpool.Pool) *StudentRepository {
-	return &StudentRepository{pool: pool}
+	return &StudentRepository{pool: database.Wrap(pool)}
 }
 
 // GetByID retrieves a student by ID.
 func (r *StudentRepository) GetByID(ctx context.Context, id int) (*model.Student, error) {
 	s := &model.Student{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, nis, nisn, name, gender, religion, password, class_id, created_at, updated_at
+		`SELECT id, nis, nisn, name, gender, religion, password, class_id, is_active, created_at, updated_at, status, status_changed_at, must_change_password
 		 FROM students WHERE id = $1`, id,
-	).Scan(&s.ID, &s.NIS, &s.NISN, &s.Name, &s.Gender, &s.Religion, &s.Password, &s.ClassID, &s.CreatedAt, &s.UpdatedAt)
+	).Scan(&s.ID, &s.NIS, &s.NISN, &s.Name, &s.Gender, &s.Religion, &s.Password, &s.ClassID, &s.IsActive, &s.CreatedAt, &s.UpdatedAt, &s.Status, &s.StatusChangedAt, &s.MustChangePassword)
 	if err != nil {
 		return nil, err
 	}
@@ -42,9 +44,9 @@ func (r *StudentRepository) GetByID(ctx context.Context, id int) (*model.Student
 func (r *StudentRepository) GetByNISN(ctx context.Context, nisn string) (*model.Student, error) {
 	s := &model.Student{}
 	err := r.pool.QueryRow(ctx,
-		`SELECT id, nis, nisn, name, gender, religion, password, class_id, created_at, updated_at
+		`SELECT id, nis, nisn, name, gender, religion, password, class_id, is_active, created_at, updated_at, status, status_changed_at, must_change_password
 		 FROM students WHERE nisn = $1`, nisn,
-	).Scan(&s.ID, &s.NIS, &s.NISN, &s.Name, &s.Gender, &s.Religion, &s.Password, &s.ClassID, &s.CreatedAt, &s.UpdatedAt)
+	).Scan(&s.ID, &s.NIS, &s.NISN, &s.Name, &s.Gender, &s.Religion, &s.Password, &s.ClassID, &s.IsActive, &s.CreatedAt, &s.UpdatedAt, &s.Status, &s.StatusChangedAt, &s.MustChangePassword)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +56,7 @@ func (r *StudentRepository) GetByNISN(ctx context.Context, nisn string) (*model.
 // ListPaginated retrieves students with pagination and advanced filtering.
 func (r *StudentRepository) ListPaginated(ctx context.Context, filter model.StudentFilter, limit, offset int) ([]model.Student, int, error) {
 	// Base query components
-	baseSelect := `SELECT s.id, s.nis, s.nisn, s.name, s.gender, s.religion, s.password, s.class_id, s.created_at, s.updated_at FROM students s`
+	baseSelect := `SELECT s.id, s.nis, s.nisn, s.name, s.gender, s.religion, s.password, s.class_id, s.is_active, s.created_at, s.updated_at, s.status, s.status_changed_at, s.must_change_password FROM students s`
 	baseCount := `SELECT COUNT(s.id) FROM students s`
 	baseJoins := ` LEFT JOIN classes c ON s.class_id = c.id`
 
@@ -93,6 +95,11 @@ func (r *StudentRepository) ListPaginated(ctx context.Context, filter model.Stud
 		args = append(args, *filter.GroupNumber)
 		argIdx++
 	}
+	if filter.Status != nil && *filter.Status != "" {
+		whereClauses = append(whereClauses, `s.status = $`+strconv.Itoa(argIdx))
+		args = append(args, *filter.Status)
+		argIdx++
+	}
 
 	whereStmt := " WHERE " + strings.Join(whereClauses, " AND ")
 
@@ -116,7 +123,7 @@ func (r *StudentRepository) ListPaginated(ctx context.Context, filter model.Stud
 	var students []model.Student
 	for rows.Next() {
 		var s model.Student
-		if err := rows.Scan(&s.ID, &s.NIS, &s.NISN, &s.Name, &s.Gender, &s.Religion, &s.Password, &s.ClassID, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		if err := rows.Scan(&s.ID, &s.NIS, &s.NISN, &s.Name, &s.Gender, &s.Religion, &s.Password, &s.ClassID, &s.IsActive, &s.CreatedAt, &s.UpdatedAt, &s.Status, &s.StatusChangedAt, &s.MustChangePassword); err != nil {
 			return nil, 0, err
 		}
 		students = append(students, s)
@@ -129,9 +136,9 @@ func (r *StudentRepository) Create(ctx context.Context, s *model.Student) error
 	err := r.pool.QueryRow(ctx,
 		`INSERT INTO students (nis, nisn, name, gender, religion, password, class_id)
 		 VALUES ($1, $2, $3, $4, $5, $6, $7)
-		 RETURNING id, created_at, updated_at`,
+		 RETURNING id, is_active, status, created_at, updated_at`,
 		s.NIS, s.NISN, s.Name, s.Gender, s.Religion, s.Password, s.ClassID,
-	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	).Scan(&s.ID, &s.IsActive, &s.Status, &s.CreatedAt, &s.UpdatedAt)
 
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -160,6 +167,35 @@ func (r *StudentRepository) Update(ctx context.Context, s *model.Student) error
 	return nil
 }
 
+// IssuePasswords stores issued password hashes, keyed by student ID, and makes the
+// students change them at their next login.
+func (r *StudentRepository) IssuePasswords(ctx context.Context, hashes map[int]string) error {
+	ids := make([]int, 0, len(hashes))
+	values := make([]string, 0, len(hashes))
+	for id, hash := range hashes {
+		ids = append(ids, id)
+		values = append(values, hash)
+	}
+	_, err := r.pool.Exec(ctx,
+		`UPDATE students s
+		 SET password = v.hash, must_change_password = TRUE, updated_at = CURRENT_TIMESTAMP
+		 FROM unnest($1::int[], $2::text[]) AS v(id, hash)
+		 WHERE s.id = v.id`,
+		ids, values,
+	)
+	return err
+}
+
+// ChangeOwnPassword stores the password hash a student chose, which ends the
+// requirement to change it.
+func (r *StudentRepository) ChangeOwnPassword(ctx context.Context, id int, hash string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE students SET password = $1, must_change_password = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $2`,
+		hash, id,
+	)
+	return err
+}
+
 // UpdatePassword updates a student's password.
 func (r *StudentRepository) UpdatePassword(ctx context.Context, id int, password string) error {
 	_, err := r.pool.Exec(ctx,
@@ -169,6 +205,29 @@ func (r *StudentRepository) UpdatePassword(ctx context.Context, id int, password
 	return err
 }
 
+// UpdateStatus moves the given students to status and returns the IDs of those whose
+// status changed.
+func (r *StudentRepository) UpdateStatus(ctx context.Context, ids []int, status model.StudentStatus) ([]int, error) {
+	rows, err := r.pool.Query(ctx,
+		`UPDATE students SET status = $1, status_changed_at = NOW(), updated_at = NOW()
+		 WHERE id = ANY($2) AND status <> $1
+		 RETURNING id`, status, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changed := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		changed = append(changed, id)
+	}
+	return changed, rows.Err()
+}
+
 // Delete removes a student by ID.
 func (r *StudentRepository) Delete(ctx context.Context, id int) error {
 	_, err := r.pool.Exec(ctx, `DELETE FROM students WHERE id = $1`, id)
@@ -229,3 +288,33 @@ func (r *StudentRepository) ListStudentCards(ctx context.Context, classID *int,
 	}
 	return cards, rows.Err()
 }
+
+// GetAccessibilitySettings retrieves a student's accessibility preferences, or the
+// defaults when they never saved any.
+func (r *StudentRepository) GetAccessibilitySettings(ctx context.Context, studentID int) (*model.AccessibilitySettings, error) {
+	a := &model.AccessibilitySettings{StudentID: studentID, FontSize: model.FontSizeNormal}
+	err := r.pool.QueryRow(ctx,
+		`SELECT font_size, high_contrast, screen_reader, updated_at
+		 FROM student_accessibility_settings WHERE student_id = $1`, studentID,
+	).Scan(&a.FontSize, &a.HighContrast, &a.ScreenReader, &a.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// UpsertAccessibilitySettings saves a student's accessibility preferences.
+func (r *StudentRepository) UpsertAccessibilitySettings(ctx context.Context, a *model.AccessibilitySettings) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO student_accessibility_settings (student_id, font_size, high_contrast, screen_reader)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (student_id) DO UPDATE
+		    SET font_size = EXCLUDED.font_size, high_contrast = EXCLUDED.high_contrast,
+		        screen_reader = EXCLUDED.screen_reader, updated_at = NOW()
+		 RETURNING updated_at`,
+		a.StudentID, a.FontSize, a.HighContrast, a.ScreenReader,
+	).Scan(&a.UpdatedAt)
+}
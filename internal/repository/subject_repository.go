@@ -3,16 +3,18 @@ package repository
 import (
 	"context"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
 	"github.com/stemsi/exstem-backend/internal/model"
 )
 
 type SubjectRepository struct {
-	pool *pgxpool.Pool
+	pool *database.DB
 }
 
 func NewSubjectRepository(pool *pgxpool.Pool) *SubjectRepository {
-	return &SubjectRepository{pool: pool}
+	return &SubjectRepository{pool: database.Wrap(pool)}
 }
 
 func (r *SubjectRepository) Create(ctx context.Context, s *model.Subject) error {
@@ -39,6 +41,17 @@ func (r *SubjectRepository) GetAll(ctx context.Context) ([]model.Subject, error)
 	return subjects, rows.Err()
 }
 
+// GetByID retrieves a subject by its ID.
+func (r *SubjectRepository) GetByID(ctx context.Context, id int) (*model.Subject, error) {
+	var s model.Subject
+	err := r.pool.QueryRow(ctx, `SELECT id, name, created_at, updated_at FROM subjects WHERE id = $1`, id).
+		Scan(&s.ID, &s.Name, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
 func (r *SubjectRepository) Update(ctx context.Context, s *model.Subject) error {
 	_, err := r.pool.Exec(ctx, `UPDATE subjects SET name = $1, updated_at = NOW() WHERE id = $2`, s.Name, s.ID)
 	return err
@@ -48,3 +61,84 @@ func (r *SubjectRepository) Delete(ctx context.Context, id int) error {
 	_, err := r.pool.Exec(ctx, `DELETE FROM subjects WHERE id = $1`, id)
 	return err
 }
+
+// ListTeachers retrieves the teachers assigned to a subject.
+func (r *SubjectRepository) ListTeachers(ctx context.Context, subjectID int) ([]model.SubjectTeacher, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT a.id, a.username, a.name, st.created_at
+		 FROM subject_teachers st
+		 JOIN admins a ON a.id = st.admin_id
+		 WHERE st.subject_id = $1
+		 ORDER BY a.name, a.username`, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teachers := []model.SubjectTeacher{}
+	for rows.Next() {
+		var t model.SubjectTeacher
+		if err := rows.Scan(&t.AdminID, &t.Username, &t.Name, &t.AssignedAt); err != nil {
+			return nil, err
+		}
+		teachers = append(teachers, t)
+	}
+	return teachers, rows.Err()
+}
+
+// ListAllTeachers retrieves the teachers of every subject, keyed by subject ID.
+func (r *SubjectRepository) ListAllTeachers(ctx context.Context) (map[int][]model.SubjectTeacher, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT st.subject_id, a.id, a.username, a.name, st.created_at
+		 FROM subject_teachers st
+		 JOIN admins a ON a.id = st.admin_id
+		 ORDER BY a.name, a.username`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teachers := make(map[int][]model.SubjectTeacher)
+	for rows.Next() {
+		var subjectID int
+		var t model.SubjectTeacher
+		if err := rows.Scan(&subjectID, &t.AdminID, &t.Username, &t.Name, &t.AssignedAt); err != nil {
+			return nil, err
+		}
+		teachers[subjectID] = append(teachers[subjectID], t)
+	}
+	return teachers, rows.Err()
+}
+
+// AssignTeachers assigns admins to a subject. Admins already assigned are left as they are.
+func (r *SubjectRepository) AssignTeachers(ctx context.Context, subjectID int, adminIDs []int) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO subject_teachers (subject_id, admin_id)
+		 SELECT $1, unnest($2::int[])
+		 ON CONFLICT DO NOTHING`, subjectID, adminIDs)
+	return err
+}
+
+// UnassignTeacher removes an admin from a subject's teachers.
+func (r *SubjectRepository) UnassignTeacher(ctx context.Context, subjectID, adminID int) error {
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM subject_teachers WHERE subject_id = $1 AND admin_id = $2`, subjectID, adminID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// TeacherStatus reports whether a subject has any assigned teachers and whether
+// adminID is one of them.
+func (r *SubjectRepository) TeacherStatus(ctx context.Context, subjectID, adminID int) (hasTeachers, assigned bool, err error) {
+	err = r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) > 0, COALESCE(BOOL_OR(admin_id = $2), FALSE)
+		 FROM subject_teachers
+		 WHERE subject_id = $1`, subjectID, adminID,
+	).Scan(&hasTeachers, &assigned)
+	return hasTeachers, assigned, err
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/database"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// SystemMetricsRepository handles the sampled system metrics history.
+type SystemMetricsRepository struct {
+	pool *database.DB
+}
+
+// NewSystemMetricsRepository creates a new SystemMetricsRepository.
+func NewSystemMetricsRepository(pool *pgxpool.Pool) *SystemMetricsRepository {
+	return &SystemMetricsRepository{pool: database.Wrap(pool)}
+}
+
+// Insert stores a sample.
+func (r *SystemMetricsRepository) Insert(ctx context.Context, s model.MetricsSample) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO system_metrics_samples (instance, recorded_at, cpu_percent, mem_percent, mem_used_bytes,
+		     disk_percent, load_avg_1, goroutines, heap_alloc, db_acquired_conns, ws_connections,
+		     queue_answers, queue_cheats, queue_scores, queue_monitor_events, redis_degraded)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+		s.Instance, s.RecordedAt, s.CPUPercent, s.MemPercent, s.MemUsedBytes,
+		s.DiskPercent, s.LoadAvg1, s.Goroutines, s.HeapAlloc, s.DBAcquiredConns, s.WSConnections,
+		s.QueueAnswers, s.QueueCheats, s.QueueScores, s.QueueMonitorEvents, s.RedisDegraded,
+	)
+	return err
+}
+
+// Prune deletes the samples recorded before before and returns how many.
+func (r *SystemMetricsRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	cmdTag, err := r.pool.Exec(ctx, `DELETE FROM system_metrics_samples WHERE recorded_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
+// List returns the samples recorded within [from, to), downsampled to one per
+// instance per step, oldest first.
+func (r *SystemMetricsRepository) List(ctx context.Context, from, to time.Time, step time.Duration) ([]model.MetricsSample, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT instance,
+		        to_timestamp(floor(extract(epoch FROM recorded_at) / $3) * $3) AS bucket,
+		        AVG(cpu_percent), AVG(mem_percent), AVG(mem_used_bytes)::bigint,
+		        AVG(disk_percent), AVG(load_avg_1), AVG(goroutines)::int, AVG(heap_alloc)::bigint,
+		        MAX(db_acquired_conns), MAX(ws_connections),
+		        MAX(queue_answers), MAX(queue_cheats), MAX(queue_scores), MAX(queue_monitor_events),
+		        BOOL_OR(redis_degraded)
+		 FROM system_metrics_samples
+		 WHERE recorded_at >= $1 AND recorded_at < $2
+		 GROUP BY instance, bucket
+		 ORDER BY bucket, instance`,
+		from, to, step.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := make([]model.MetricsSample, 0)
+	for rows.Next() {
+		var s model.MetricsSample
+		if err := rows.Scan(&s.Instance, &s.RecordedAt, &s.CPUPercent, &s.MemPercent, &s.MemUsedBytes,
+			&s.DiskPercent, &s.LoadAvg1, &s.Goroutines, &s.HeapAlloc, &s.DBAcquiredConns, &s.WSConnections,
+			&s.QueueAnswers, &s.QueueCheats, &s.QueueScores, &s.QueueMonitorEvents, &s.RedisDegraded,
+		); err != nil {
+			return nil, err
+		}
+		samples = append(samples, s)
+	}
+	return samples, rows.Err()
+}
+
+// Coverage returns the first sample recorded within [from, to) and in how many
+// distinct intervals of the given length any instance recorded a sample. first is
+// nil when there are none.
+func (r *SystemMetricsRepository) Coverage(ctx context.Context, from, to time.Time, interval time.Duration) (first *time.Time, intervals int, err error) {
+	err = r.pool.QueryRow(ctx,
+		`SELECT MIN(recorded_at), COUNT(DISTINCT floor(extract(epoch FROM recorded_at) / $3))
+		 FROM system_metrics_samples
+		 WHERE recorded_at >= $1 AND recorded_at < $2`,
+		from, to, interval.Seconds(),
+	).Scan(&first, &intervals)
+	return first, intervals, err
+}
@@ -0,0 +1,121 @@
+package resilience
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// HealthMonitor periodically runs a health check and flips into degraded mode after
+// failThreshold consecutive failures. It recovers after recoverThreshold consecutive
+// successes, then runs the registered recovery hooks.
+type HealthMonitor struct {
+	name             string
+	check            func(ctx context.Context) error
+	interval         time.Duration
+	failThreshold    int
+	recoverThreshold int
+	log              zerolog.Logger
+
+	mu            sync.RWMutex
+	degraded      bool
+	degradedSince time.Time
+	failures      int
+	successes     int
+	onRecover     []func(ctx context.Context)
+}
+
+// NewHealthMonitor creates a HealthMonitor that starts in the healthy state.
+func NewHealthMonitor(name string, check func(ctx context.Context) error, interval time.Duration, failThreshold, recoverThreshold int, log zerolog.Logger) *HealthMonitor {
+	return &HealthMonitor{
+		name:             name,
+		check:            check,
+		interval:         interval,
+		failThreshold:    failThreshold,
+		recoverThreshold: recoverThreshold,
+		log:              log.With().Str("component", "health_monitor").Str("target", name).Logger(),
+	}
+}
+
+// OnRecover registers a hook that runs each time the monitor leaves degraded mode.
+func (m *HealthMonitor) OnRecover(fn func(ctx context.Context)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onRecover = append(m.onRecover, fn)
+}
+
+// Degraded reports whether the checked dependency is currently considered down.
+// A nil monitor is never degraded.
+func (m *HealthMonitor) Degraded() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.degraded
+}
+
+// DegradedSince returns when degraded mode was entered, or nil when healthy.
+func (m *HealthMonitor) DegradedSince() *time.Time {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.degraded {
+		return nil
+	}
+	since := m.degradedSince
+	return &since
+}
+
+// Start runs the check every interval until ctx is cancelled.
+func (m *HealthMonitor) Start(ctx context.Context) {
+	m.log.Info().Dur("interval", m.interval).Msg("HealthMonitor started")
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.log.Info().Msg("HealthMonitor stopped")
+			return
+		case <-ticker.C:
+			m.probe(ctx)
+		}
+	}
+}
+
+func (m *HealthMonitor) probe(ctx context.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, m.interval)
+	err := m.check(checkCtx)
+	cancel()
+
+	m.mu.Lock()
+	var recovered []func(ctx context.Context)
+	if err != nil {
+		m.successes = 0
+		m.failures++
+		if !m.degraded && m.failures >= m.failThreshold {
+			m.degraded = true
+			m.degradedSince = time.Now()
+			m.log.Error().Err(err).Msg("Dependency unhealthy, entering degraded mode")
+		}
+	} else {
+		m.failures = 0
+		m.successes++
+		if m.degraded && m.successes >= m.recoverThreshold {
+			m.degraded = false
+			m.log.Info().Dur("downtime", time.Since(m.degradedSince)).Msg("Dependency recovered, leaving degraded mode")
+			recovered = append(recovered, m.onRecover...)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, fn := range recovered {
+		fn(ctx)
+	}
+}
@@ -0,0 +1,53 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy retries a call with exponential backoff and full jitter.
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// Do calls fn until it succeeds, the attempts are used up or ctx is done.
+// ErrCircuitOpen is returned immediately since retrying cannot help.
+func (p RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	attempts := p.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = fn(ctx); err == nil || errors.Is(err, ErrCircuitOpen) {
+			return err
+		}
+		if i == attempts-1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(p.backoff(i)):
+		}
+	}
+	return err
+}
+
+// backoff returns a random delay in [0, min(MaxDelay, BaseDelay*2^attempt)).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << attempt
+	if p.MaxDelay > 0 && (d > p.MaxDelay || d <= 0) {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
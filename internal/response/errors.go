@@ -5,18 +5,28 @@ type ErrCode string
 
 const (
 	// ─── Authentication ────────────────────────────────────────────────
-	ErrInvalidCredentials ErrCode = "INVALID_CREDENTIALS"
-	ErrSessionActive      ErrCode = "SESSION_ALREADY_ACTIVE"
-	ErrSessionInvalidated ErrCode = "SESSION_INVALIDATED"
-	ErrTokenRequired      ErrCode = "TOKEN_REQUIRED"
-	ErrTokenInvalid       ErrCode = "TOKEN_INVALID"
-	ErrTokenExpired       ErrCode = "TOKEN_EXPIRED"
+	ErrInvalidCredentials      ErrCode = "INVALID_CREDENTIALS"
+	ErrSessionActive           ErrCode = "SESSION_ALREADY_ACTIVE"
+	ErrSessionInvalidated      ErrCode = "SESSION_INVALIDATED"
+	ErrSessionCheckUnavailable ErrCode = "SESSION_CHECK_UNAVAILABLE"
+	ErrTokenRequired           ErrCode = "TOKEN_REQUIRED"
+	ErrTokenInvalid            ErrCode = "TOKEN_INVALID"
+	ErrTokenExpired            ErrCode = "TOKEN_EXPIRED"
+	ErrSSONotConfigured        ErrCode = "SSO_NOT_CONFIGURED"
+	ErrSSOStateInvalid         ErrCode = "SSO_STATE_INVALID"
+	ErrSSOFailed               ErrCode = "SSO_FAILED"
+	ErrSSOAccountUnlinked      ErrCode = "SSO_ACCOUNT_NOT_LINKED"
+	ErrAccountInactive         ErrCode = "ACCOUNT_INACTIVE"
+	ErrPasswordChange          ErrCode = "PASSWORD_CHANGE_REQUIRED"
 
 	// ─── Authorization ─────────────────────────────────────────────────
-	ErrForbidden         ErrCode = "FORBIDDEN"
-	ErrPermissionDenied  ErrCode = "PERMISSION_DENIED"
-	ErrStudentAccessOnly ErrCode = "STUDENT_ACCESS_ONLY"
-	ErrAdminAccessOnly   ErrCode = "ADMIN_ACCESS_ONLY"
+	ErrForbidden             ErrCode = "FORBIDDEN"
+	ErrPermissionDenied      ErrCode = "PERMISSION_DENIED"
+	ErrStudentAccessOnly     ErrCode = "STUDENT_ACCESS_ONLY"
+	ErrAdminAccessOnly       ErrCode = "ADMIN_ACCESS_ONLY"
+	ErrGuardianAccessOnly    ErrCode = "GUARDIAN_ACCESS_ONLY"
+	ErrSubjectOutOfScope     ErrCode = "SUBJECT_OUT_OF_SCOPE"
+	ErrImpersonationReadOnly ErrCode = "IMPERSONATION_READ_ONLY"
 
 	// ─── Validation ────────────────────────────────────────────────────
 	ErrValidation     ErrCode = "VALIDATION_ERROR"
@@ -26,16 +36,46 @@ const (
 	// ─── Resources ─────────────────────────────────────────────────────
 	ErrNotFound         ErrCode = "NOT_FOUND"
 	ErrConflict         ErrCode = "CONFLICT"
+	ErrEmailExists      ErrCode = "EMAIL_EXISTS"
 	ErrDependencyExists ErrCode = "DEPENDENCY_EXISTS"
 	ErrActionForbidden  ErrCode = "ACTION_FORBIDDEN"
 
+	// ─── Directory Sync ────────────────────────────────────────────────
+	ErrDirectoryNotConfigured ErrCode = "DIRECTORY_NOT_CONFIGURED"
+	ErrDirectorySyncFailed    ErrCode = "DIRECTORY_SYNC_FAILED"
+
+	// ─── e-Rapor ───────────────────────────────────────────────────────
+	ErrEraporNotConfigured ErrCode = "ERAPOR_NOT_CONFIGURED"
+	ErrEraporPushFailed    ErrCode = "ERAPOR_PUSH_FAILED"
+	ErrEraporNoScores      ErrCode = "ERAPOR_NO_SCORES"
+
+	// ─── Notifications ─────────────────────────────────────────────────
+	ErrNotificationNotConfigured ErrCode = "NOTIFICATION_NOT_CONFIGURED"
+	ErrNotificationNoRecipients  ErrCode = "NOTIFICATION_NO_RECIPIENTS"
+	ErrNotificationFailed        ErrCode = "NOTIFICATION_FAILED"
+
+	// ─── Backups ───────────────────────────────────────────────────────
+	ErrBackupInProgress     ErrCode = "BACKUP_IN_PROGRESS"
+	ErrBackupSchemaMismatch ErrCode = "BACKUP_SCHEMA_MISMATCH"
+	ErrBackupExamsRunning   ErrCode = "BACKUP_EXAMS_RUNNING"
+
 	// ─── Exam-specific ─────────────────────────────────────────────────
-	ErrExamNotAvailable  ErrCode = "EXAM_NOT_AVAILABLE"
-	ErrInvalidEntryToken ErrCode = "INVALID_ENTRY_TOKEN"
-	ErrExamNotPublished  ErrCode = "EXAM_NOT_PUBLISHED"
-	ErrNoQuestions       ErrCode = "NO_QUESTIONS"
-	ErrExamNotDraft      ErrCode = "EXAM_NOT_DRAFT"
-	ErrDuplicateTarget   ErrCode = "DUPLICATE_TARGET_RULE"
+	ErrExamNotAvailable   ErrCode = "EXAM_NOT_AVAILABLE"
+	ErrInvalidEntryToken  ErrCode = "INVALID_ENTRY_TOKEN"
+	ErrExamNotPublished   ErrCode = "EXAM_NOT_PUBLISHED"
+	ErrNoQuestions        ErrCode = "NO_QUESTIONS"
+	ErrExamNotDraft       ErrCode = "EXAM_NOT_DRAFT"
+	ErrExamNotCompleted   ErrCode = "EXAM_NOT_COMPLETED"
+	ErrDuplicateTarget    ErrCode = "DUPLICATE_TARGET_RULE"
+	ErrExamHasSessions    ErrCode = "EXAM_HAS_SESSIONS"
+	ErrResultNotAvailable ErrCode = "RESULT_NOT_AVAILABLE"
+	ErrResultLookupFailed ErrCode = "RESULT_LOOKUP_FAILED"
+	ErrScheduleConflict   ErrCode = "SCHEDULE_CONFLICT"
+	ErrExamOnBreak        ErrCode = "EXAM_ON_BREAK"
+	ErrMediaAltMissing    ErrCode = "MEDIA_ALT_TEXT_MISSING"
+	ErrConsentRequired    ErrCode = "CONSENT_REQUIRED"
+	ErrDuplicateQuestion  ErrCode = "DUPLICATE_QUESTION"
+	ErrExamPaperNotReady  ErrCode = "EXAM_PAPER_NOT_READY"
 
 	// ─── Media ─────────────────────────────────────────────────────────
 	ErrFileRequired    ErrCode = "FILE_REQUIRED"
@@ -46,7 +86,8 @@ const (
 	ErrRateLimitExceeded ErrCode = "RATE_LIMIT_EXCEEDED"
 
 	// ─── Server ────────────────────────────────────────────────────────
-	ErrInternal ErrCode = "INTERNAL_ERROR"
+	ErrInternal       ErrCode = "INTERNAL_ERROR"
+	ErrRequestTimeout ErrCode = "REQUEST_TIMEOUT"
 )
 
 // GetMessage returns a human-readable message for a given error code.
@@ -59,12 +100,26 @@ func GetMessage(code ErrCode) string {
 		return "Anda sudah login di perangkat lain."
 	case ErrSessionInvalidated:
 		return "Sesi Anda telah berakhir. Silakan login kembali."
+	case ErrSessionCheckUnavailable:
+		return "Sesi Anda tidak dapat diperiksa saat ini. Silakan coba lagi."
 	case ErrTokenRequired:
 		return "Token autentikasi diperlukan."
 	case ErrTokenInvalid:
 		return "Token autentikasi tidak valid."
 	case ErrTokenExpired:
 		return "Token autentikasi telah kedaluwarsa."
+	case ErrSSONotConfigured:
+		return "Login SSO belum dikonfigurasi."
+	case ErrSSOStateInvalid:
+		return "Permintaan login SSO tidak valid atau telah kedaluwarsa."
+	case ErrSSOFailed:
+		return "Login SSO gagal. Silakan coba lagi."
+	case ErrSSOAccountUnlinked:
+		return "Akun SSO ini tidak terhubung ke akun administrator mana pun."
+	case ErrAccountInactive:
+		return "Akun Anda sudah tidak aktif. Silakan hubungi admin."
+	case ErrPasswordChange:
+		return "Silakan ganti kata sandi Anda terlebih dahulu."
 
 	// ─── Authorization ─────────────────────────────────────────────────
 	case ErrForbidden:
@@ -75,6 +130,12 @@ func GetMessage(code ErrCode) string {
 		return "Sumber daya ini terbatas untuk siswa."
 	case ErrAdminAccessOnly:
 		return "Sumber daya ini terbatas untuk administrator."
+	case ErrGuardianAccessOnly:
+		return "Sumber daya ini terbatas untuk orang tua/wali."
+	case ErrSubjectOutOfScope:
+		return "Anda tidak memiliki akses ke mata pelajaran ini."
+	case ErrImpersonationReadOnly:
+		return "Mode impersonasi hanya dapat digunakan untuk melihat."
 
 	// ─── Validation ────────────────────────────────────────────────────
 	case ErrValidation:
@@ -89,11 +150,43 @@ func GetMessage(code ErrCode) string {
 		return "Sumber daya tidak ditemukan."
 	case ErrConflict:
 		return "Sumber daya sudah ada."
+	case ErrEmailExists:
+		return "Email atau username sudah terdaftar."
 	case ErrDependencyExists:
 		return "Data tidak dapat dihapus karena masih digunakan oleh data lain."
 	case ErrActionForbidden:
 		return "Tindakan ini tidak diperbolehkan."
 
+	// ─── Directory Sync ────────────────────────────────────────────────
+	case ErrDirectoryNotConfigured:
+		return "Sinkronisasi direktori siswa belum dikonfigurasi."
+	case ErrDirectorySyncFailed:
+		return "Sinkronisasi direktori siswa gagal."
+
+	// ─── e-Rapor ───────────────────────────────────────────────────────
+	case ErrEraporNotConfigured:
+		return "Integrasi API e-Rapor belum dikonfigurasi."
+	case ErrEraporPushFailed:
+		return "Gagal mengirim nilai ke e-Rapor."
+	case ErrEraporNoScores:
+		return "Tidak ada nilai ujian selesai untuk mata pelajaran ini."
+
+	// ─── Notifications ─────────────────────────────────────────────────
+	case ErrNotificationNotConfigured:
+		return "Kanal notifikasi (Telegram/WhatsApp) belum dikonfigurasi."
+	case ErrNotificationNoRecipients:
+		return "Ujian ini belum memiliki penerima notifikasi."
+	case ErrNotificationFailed:
+		return "Gagal mengirim notifikasi."
+
+	// ─── Backups ───────────────────────────────────────────────────────
+	case ErrBackupInProgress:
+		return "Pencadangan atau pemulihan lain sedang berjalan."
+	case ErrBackupSchemaMismatch:
+		return "Cadangan dibuat dari versi skema basis data yang berbeda."
+	case ErrBackupExamsRunning:
+		return "Pemulihan tidak dapat dilakukan selama ada ujian yang berlangsung."
+
 	// ─── Exam-specific ─────────────────────────────────────────────────
 	case ErrExamNotAvailable:
 		return "Ujian ini saat ini tidak tersedia."
@@ -105,8 +198,28 @@ func GetMessage(code ErrCode) string {
 		return "Ujian ini tidak memiliki pertanyaan."
 	case ErrExamNotDraft:
 		return "Ujian ini tidak dalam status DRAFT."
+	case ErrExamNotCompleted:
+		return "Ujian ini belum selesai."
 	case ErrDuplicateTarget:
 		return "Aturan target serupa sudah ada untuk ujian ini."
+	case ErrExamHasSessions:
+		return "Ujian ini sudah dikerjakan oleh siswa."
+	case ErrResultNotAvailable:
+		return "Hasil ujian belum tersedia."
+	case ErrResultLookupFailed:
+		return "Hasil tidak ditemukan. Periksa kembali NISN dan kode akses."
+	case ErrScheduleConflict:
+		return "Jadwal ujian bentrok dengan ujian lain untuk kelas yang sama."
+	case ErrExamOnBreak:
+		return "Soal dikunci selama istirahat."
+	case ErrMediaAltMissing:
+		return "Gambar, audio, atau video pada soal belum memiliki teks alternatif."
+	case ErrConsentRequired:
+		return "Anda harus menyetujui pakta integritas sebelum memulai ujian."
+	case ErrDuplicateQuestion:
+		return "Bank soal sudah memiliki soal yang mirip."
+	case ErrExamPaperNotReady:
+		return "Naskah soal belum selesai dibuat."
 
 	// ─── Media ─────────────────────────────────────────────────────────
 	case ErrFileRequired:
@@ -123,6 +236,8 @@ func GetMessage(code ErrCode) string {
 	// ─── Server ────────────────────────────────────────────────────────
 	case ErrInternal:
 		return "Terjadi kesalahan server internal."
+	case ErrRequestTimeout:
+		return "Permintaan memakan waktu terlalu lama. Silakan coba lagi."
 	default:
 		return "Terjadi kesalahan yang tidak terduga."
 	}
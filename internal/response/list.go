@@ -0,0 +1,33 @@
+package response
+
+import "github.com/gin-gonic/gin"
+
+// NonNil returns items, or an empty slice when items is nil, so that collections
+// always serialize as [] rather than null. Use it for lists nested in larger payloads,
+// including WebSocket and SSE events.
+func NonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// NewPagination builds the pagination metadata of one page of totalItems.
+func NewPagination(page, perPage, totalItems int) *Pagination {
+	p := &Pagination{Page: page, PerPage: perPage, TotalItems: totalItems}
+	if perPage > 0 {
+		p.TotalPages = (totalItems + perPage - 1) / perPage
+	}
+	return p
+}
+
+// SuccessList sends a list as the response data. A nil list is sent as [].
+func SuccessList[T any](c *gin.Context, statusCode int, items []T) {
+	Success(c, statusCode, NonNil(items))
+}
+
+// SuccessPage sends one page of a list with its pagination metadata. A nil list is
+// sent as [].
+func SuccessPage[T any](c *gin.Context, statusCode int, items []T, pagination *Pagination) {
+	SuccessWithPagination(c, statusCode, NonNil(items), pagination)
+}
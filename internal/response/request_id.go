@@ -3,12 +3,14 @@ package response
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/logger"
 )
 
 // ContextKeyRequestID is the Gin context key for the request ID.
 const ContextKeyRequestID = "request_id"
 
-// RequestIDMiddleware generates a unique request ID for every request.
+// RequestIDMiddleware generates a unique request ID for every request. The ID is also
+// carried by the request context so services can attach it to the jobs they queue.
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		reqID := c.GetHeader("X-Request-ID")
@@ -17,6 +19,7 @@ func RequestIDMiddleware() gin.HandlerFunc {
 		}
 		c.Set(ContextKeyRequestID, reqID)
 		c.Header("X-Request-ID", reqID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), reqID))
 		c.Next()
 	}
 }
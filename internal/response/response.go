@@ -1,6 +1,9 @@
 package response
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +15,7 @@ type Response struct {
 	Data       interface{} `json:"data"`
 	Error      *ErrorBody  `json:"error,omitempty"`
 	Pagination *Pagination `json:"pagination,omitempty"`
+	Warnings   interface{} `json:"warnings,omitempty"`
 	Metadata   Metadata    `json:"metadata"`
 }
 
@@ -57,8 +61,19 @@ func SuccessWithPagination(c *gin.Context, statusCode int, data interface{}, pag
 	})
 }
 
+// SuccessWithWarnings sends a successful response carrying non-fatal warnings.
+// The warnings field is omitted when warnings is nil.
+func SuccessWithWarnings(c *gin.Context, statusCode int, data interface{}, warnings interface{}) {
+	c.JSON(statusCode, Response{
+		Data:     data,
+		Warnings: warnings,
+		Metadata: buildMetadata(c),
+	})
+}
+
 // Fail sends an error response with an error code and no field-level details.
 func Fail(c *gin.Context, statusCode int, code ErrCode) {
+	statusCode, code = timeoutAware(c, statusCode, code)
 	c.JSON(statusCode, Response{
 		Data:     nil,
 		Error:    &ErrorBody{Code: code, Message: GetMessage(code)},
@@ -68,6 +83,10 @@ func Fail(c *gin.Context, statusCode int, code ErrCode) {
 
 // FailWithFields sends an error response with field-level validation details.
 func FailWithFields(c *gin.Context, statusCode int, code ErrCode, fields map[string]string) {
+	if status, timeoutCode := timeoutAware(c, statusCode, code); status != statusCode {
+		Fail(c, status, timeoutCode)
+		return
+	}
 	c.JSON(statusCode, Response{
 		Data:     nil,
 		Error:    &ErrorBody{Code: code, Message: GetMessage(code), Fields: fields},
@@ -88,6 +107,15 @@ func AbortFail(c *gin.Context, statusCode int, code ErrCode) {
 // Internal helpers
 // ────────────────────────────────────────────────────────────────────────────
 
+// timeoutAware reports a 500 caused by the request running past its deadline (see
+// middleware.Timeout) as 504 REQUEST_TIMEOUT.
+func timeoutAware(c *gin.Context, statusCode int, code ErrCode) (int, ErrCode) {
+	if statusCode == http.StatusInternalServerError && errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout, ErrRequestTimeout
+	}
+	return statusCode, code
+}
+
 func buildMetadata(c *gin.Context) Metadata {
 	reqID, _ := c.Get(ContextKeyRequestID)
 	id, ok := reqID.(string)
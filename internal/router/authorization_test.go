@@ -0,0 +1,285 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+// adminRoutePermissions lists every admin route with the permissions that admit it,
+// any one of them. nil marks a route open to every signed-in admin. A new admin route
+// must be added here, so it cannot ship without a decision on who may call it.
+var adminRoutePermissions = map[string][]model.Permission{
+	"POST /api/v1/admin/media/upload":                           {model.PermissionMediaUpload},
+	"GET /api/v1/admin/media":                                   {model.PermissionMediaRead},
+	"GET /api/v1/admin/media/:id/usage":                         {model.PermissionMediaRead},
+	"DELETE /api/v1/admin/media/:id":                            {model.PermissionMediaDelete},
+	"GET /api/v1/admin/classes":                                 {model.PermissionStudentsRead},
+	"POST /api/v1/admin/classes":                                {model.PermissionStudentsWrite},
+	"POST /api/v1/admin/classes/bulk":                           {model.PermissionStudentsWrite},
+	"PUT /api/v1/admin/classes/:id":                             {model.PermissionStudentsWrite},
+	"PUT /api/v1/admin/classes/:id/homeroom":                    {model.PermissionStudentsWrite},
+	"DELETE /api/v1/admin/classes/:id":                          {model.PermissionStudentsWrite},
+	"GET /api/v1/admin/students-cards":                          {model.PermissionStudentsRead},
+	"GET /api/v1/admin/students-cards/pdf":                      {model.PermissionStudentsRead},
+	"GET /api/v1/admin/students":                                {model.PermissionStudentsRead},
+	"POST /api/v1/admin/students":                               {model.PermissionStudentsWrite},
+	"PUT /api/v1/admin/students/:id":                            {model.PermissionStudentsWrite},
+	"DELETE /api/v1/admin/students/:id":                         {model.PermissionStudentsWrite},
+	"POST /api/v1/admin/students/:id/reset-session":             {model.PermissionStudentsResetSession},
+	"PUT /api/v1/admin/students/:id/status":                     {model.PermissionStudentsWrite},
+	"POST /api/v1/admin/students/status":                        {model.PermissionStudentsWrite},
+	"POST /api/v1/admin/students/directory-sync":                {model.PermissionStudentsWrite},
+	"POST /api/v1/admin/students/import-dapodik":                {model.PermissionStudentsWrite},
+	"POST /api/v1/admin/students/credentials":                   {model.PermissionStudentsWrite},
+	"POST /api/v1/admin/students/:id/impersonate":               {model.PermissionStudentsImpersonate},
+	"GET /api/v1/admin/users":                                   {model.PermissionAdminsRead},
+	"POST /api/v1/admin/users":                                  {model.PermissionAdminsWrite},
+	"PUT /api/v1/admin/users/:id":                               {model.PermissionAdminsWrite},
+	"DELETE /api/v1/admin/users/:id":                            {model.PermissionAdminsWrite},
+	"GET /api/v1/admin/users/:id/sessions":                      {model.PermissionAdminsRead},
+	"DELETE /api/v1/admin/users/:id/sessions":                   {model.PermissionAdminsWrite},
+	"GET /api/v1/admin/roles":                                   {model.PermissionAdminsRead},
+	"GET /api/v1/admin/roles/all":                               {model.PermissionRolesRead},
+	"GET /api/v1/admin/roles/permissions":                       {model.PermissionRolesRead},
+	"GET /api/v1/admin/roles/:id":                               {model.PermissionRolesRead},
+	"POST /api/v1/admin/roles":                                  {model.PermissionRolesWrite},
+	"PUT /api/v1/admin/roles/:id":                               {model.PermissionRolesWrite},
+	"DELETE /api/v1/admin/roles/:id":                            {model.PermissionRolesWrite},
+	"GET /api/v1/admin/exams":                                   {model.PermissionExamsRead},
+	"GET /api/v1/admin/exams/calendar":                          {model.PermissionExamsRead},
+	"GET /api/v1/admin/exams/:id/results":                       {model.PermissionExamsRead},
+	"POST /api/v1/admin/exams/:id/offline-scores":               {model.PermissionExamsWrite},
+	"GET /api/v1/admin/exams/:id/sessions/stale":                {model.PermissionExamsRead},
+	"POST /api/v1/admin/exams/:id/sessions/stale/submit":        {model.PermissionExamsWrite},
+	"GET /api/v1/admin/exams/:id/stats":                         {model.PermissionExamsRead},
+	"GET /api/v1/admin/exams/:id/erapor-mapping":                {model.PermissionExamsRead},
+	"PUT /api/v1/admin/exams/:id/erapor-mapping":                {model.PermissionExamsWrite},
+	"DELETE /api/v1/admin/exams/:id/erapor-mapping":             {model.PermissionExamsWrite},
+	"GET /api/v1/admin/exams/:id/notifications":                 {model.PermissionExamsRead},
+	"PUT /api/v1/admin/exams/:id/notifications":                 {model.PermissionExamsWrite},
+	"DELETE /api/v1/admin/exams/:id/notifications":              {model.PermissionExamsWrite},
+	"POST /api/v1/admin/exams/:id/notifications/test":           {model.PermissionExamsWrite},
+	"GET /api/v1/admin/erapor/export":                           {model.PermissionEraporExport},
+	"POST /api/v1/admin/erapor/push":                            {model.PermissionEraporExport},
+	"POST /api/v1/admin/exams":                                  {model.PermissionExamsWrite},
+	"GET /api/v1/admin/exams/:id":                               {model.PermissionExamsRead},
+	"GET /api/v1/admin/exams/:id/preview":                       {model.PermissionExamsRead},
+	"GET /api/v1/admin/exams/:id/validate":                      {model.PermissionExamsRead},
+	"POST /api/v1/admin/exams/:id/simulate":                     {model.PermissionExamsWrite},
+	"GET /api/v1/admin/exams/:id/question-reuse":                {model.PermissionExamsRead},
+	"GET /api/v1/admin/exams/:id/attendance.pdf":                {model.PermissionExamsRead},
+	"GET /api/v1/admin/exams/:id/attendance.csv":                {model.PermissionExamsRead},
+	"POST /api/v1/admin/exams/:id/paper":                        {model.PermissionExamsRead},
+	"GET /api/v1/admin/exams/:id/paper/:job_id":                 {model.PermissionExamsRead},
+	"GET /api/v1/admin/exams/:id/paper/:job_id/download":        {model.PermissionExamsRead},
+	"GET /api/v1/admin/exams/:id/readiness":                     {model.PermissionExamsRead},
+	"GET /api/v1/admin/exams/:id/payload-size":                  {model.PermissionExamsRead},
+	"PUT /api/v1/admin/exams/:id":                               {model.PermissionExamsWrite},
+	"DELETE /api/v1/admin/exams/:id":                            {model.PermissionExamsWrite},
+	"POST /api/v1/admin/exams/:id/publish":                      {model.PermissionExamsPublish},
+	"POST /api/v1/admin/exams/:id/unpublish":                    {model.PermissionExamsPublish},
+	"GET /api/v1/admin/exams/:id/target-rules":                  {model.PermissionExamsRead},
+	"POST /api/v1/admin/exams/:id/target-rules":                 {model.PermissionExamsWrite},
+	"PUT /api/v1/admin/exams/:id/target-rules/:rule_id":         {model.PermissionExamsWrite},
+	"DELETE /api/v1/admin/exams/:id/target-rules/:rule_id":      {model.PermissionExamsWrite},
+	"POST /api/v1/admin/exams/:id/refresh-cache":                {model.PermissionExamsPublish},
+	"POST /api/v1/admin/exams/bulk":                             {model.PermissionExamsWrite},
+	"POST /api/v1/admin/exams/reconcile-sessions":               {model.PermissionExamsPublish},
+	"GET /api/v1/admin/exams/:id/monitor":                       {model.PermissionMonitorRead},
+	"GET /api/v1/admin/monitor/overview":                        {model.PermissionMonitorRead},
+	"GET /api/v1/admin/exams/:id/monitor/replay":                {model.PermissionMonitorRead},
+	"GET /api/v1/admin/exams/:id/sessions/:student_id/timeline": {model.PermissionMonitorRead},
+	"GET /api/v1/admin/room-assignments":                        {model.PermissionRoomsRead, model.PermissionMonitorRead},
+	"POST /api/v1/admin/room-assignments/distribute":            {model.PermissionRoomsWrite},
+	"PUT /api/v1/admin/room-assignments/sessions":               {model.PermissionRoomsWrite},
+	"DELETE /api/v1/admin/room-assignments":                     {model.PermissionRoomsWrite},
+	"GET /api/v1/admin/room-assignments/export":                 {model.PermissionRoomsRead},
+	"GET /api/v1/admin/analytics/cheats":                        {model.PermissionExamsRead},
+	"GET /api/v1/admin/analytics/cheats/export":                 {model.PermissionExamsRead},
+	"GET /api/v1/admin/analytics/activity":                      {model.PermissionExamsRead},
+	"GET /api/v1/admin/analytics/activity.csv":                  {model.PermissionExamsRead},
+	"GET /api/v1/admin/analytics/activity.pdf":                  {model.PermissionExamsRead},
+	"GET /api/v1/admin/dashboard":                               nil,
+	"GET /api/v1/admin/dashboard/widgets":                       nil,
+	"GET /api/v1/admin/dashboard/widgets/:widget":               nil,
+	"GET /api/v1/admin/dashboard/layout":                        nil,
+	"PUT /api/v1/admin/dashboard/layout":                        nil,
+	"GET /api/v1/admin/system/metrics":                          nil,
+	"GET /api/v1/admin/system/metrics/history":                  nil,
+	"GET /api/v1/admin/qbanks":                                  {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"GET /api/v1/admin/qbanks/:id":                              {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"POST /api/v1/admin/qbanks":                                 {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"PUT /api/v1/admin/qbanks/:id":                              {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"DELETE /api/v1/admin/qbanks/:id":                           {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"GET /api/v1/admin/qbanks/:id/questions":                    {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"POST /api/v1/admin/qbanks/:id/questions":                   {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"PUT /api/v1/admin/qbanks/:id/questions":                    {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"PUT /api/v1/admin/qbanks/:id/questions/order":              {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"POST /api/v1/admin/qbanks/:id/questions/bulk-delete":       {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"POST /api/v1/admin/qbanks/:id/questions/transfer":          {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"POST /api/v1/admin/qbanks/:id/import-doc":                  {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"GET /api/v1/admin/qbanks/:id/passages":                     {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"POST /api/v1/admin/qbanks/:id/passages":                    {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"PUT /api/v1/admin/qbanks/:id/passages/:passage_id":         {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"DELETE /api/v1/admin/qbanks/:id/passages/:passage_id":      {model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll},
+	"GET /api/v1/admin/settings":                                {model.PermissionSettingsRead},
+	"PUT /api/v1/admin/settings":                                {model.PermissionSettingsWrite},
+	"GET /api/v1/admin/assessments":                             {model.PermissionExamsRead},
+	"POST /api/v1/admin/assessments":                            {model.PermissionExamsWrite},
+	"GET /api/v1/admin/assessments/:id":                         {model.PermissionExamsRead},
+	"PUT /api/v1/admin/assessments/:id":                         {model.PermissionExamsWrite},
+	"DELETE /api/v1/admin/assessments/:id":                      {model.PermissionExamsWrite},
+	"PUT /api/v1/admin/assessments/:id/exams":                   {model.PermissionExamsWrite},
+	"PUT /api/v1/admin/assessments/:id/target-rules":            {model.PermissionExamsWrite},
+	"GET /api/v1/admin/assessments/:id/report":                  {model.PermissionExamsRead},
+	"GET /api/v1/admin/assessments/:id/report.csv":              {model.PermissionExamsRead},
+	"GET /api/v1/admin/subjects":                                {model.PermissionSubjectsRead},
+	"POST /api/v1/admin/subjects":                               {model.PermissionSubjectsWrite},
+	"PUT /api/v1/admin/subjects/:id":                            {model.PermissionSubjectsWrite},
+	"DELETE /api/v1/admin/subjects/:id":                         {model.PermissionSubjectsWrite},
+	"GET /api/v1/admin/subjects/:id/teachers":                   {model.PermissionSubjectsRead},
+	"POST /api/v1/admin/subjects/:id/teachers":                  {model.PermissionSubjectsWrite},
+	"DELETE /api/v1/admin/subjects/:id/teachers/:admin_id":      {model.PermissionSubjectsWrite},
+	"GET /api/v1/admin/majors":                                  {model.PermissionMajorRead},
+	"POST /api/v1/admin/majors":                                 {model.PermissionMajorWrite},
+	"PUT /api/v1/admin/majors/:id":                              {model.PermissionMajorWrite},
+	"DELETE /api/v1/admin/majors/:id":                           {model.PermissionMajorDelete},
+	"GET /api/v1/admin/rooms":                                   {model.PermissionRoomsRead},
+	"POST /api/v1/admin/rooms":                                  {model.PermissionRoomsWrite},
+	"PUT /api/v1/admin/rooms/:id":                               {model.PermissionRoomsWrite},
+	"DELETE /api/v1/admin/rooms/:id":                            {model.PermissionRoomsWrite},
+	"GET /api/v1/admin/guardians":                               {model.PermissionGuardiansRead},
+	"GET /api/v1/admin/guardians/:id":                           {model.PermissionGuardiansRead},
+	"POST /api/v1/admin/guardians":                              {model.PermissionGuardiansWrite},
+	"PUT /api/v1/admin/guardians/:id":                           {model.PermissionGuardiansWrite},
+	"DELETE /api/v1/admin/guardians/:id":                        {model.PermissionGuardiansWrite},
+	"POST /api/v1/admin/guardians/:id/students":                 {model.PermissionGuardiansWrite},
+	"DELETE /api/v1/admin/guardians/:id/students/:student_id":   {model.PermissionGuardiansWrite},
+	"GET /api/v1/admin/backups":                                 {model.PermissionOpsBackup},
+	"POST /api/v1/admin/backups":                                {model.PermissionOpsBackup},
+	"POST /api/v1/admin/backups/:name/restore":                  {model.PermissionOpsBackup},
+	"GET /api/v1/admin/retention/targets":                       {model.PermissionSettingsRead},
+	"GET /api/v1/admin/retention/rules":                         {model.PermissionSettingsRead},
+	"POST /api/v1/admin/retention/rules":                        {model.PermissionSettingsWrite},
+	"PUT /api/v1/admin/retention/rules/:id":                     {model.PermissionSettingsWrite},
+	"DELETE /api/v1/admin/retention/rules/:id":                  {model.PermissionSettingsWrite},
+	"GET /api/v1/admin/retention/report":                        {model.PermissionSettingsRead},
+}
+
+func TestAdminRoutesAreListed(t *testing.T) {
+	r := newTestRouter(t)
+
+	registered := make(map[string]bool)
+	for _, route := range r.engine.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/v1/admin/") {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if _, ok := adminRoutePermissions[key]; !ok {
+			t.Errorf("%s is not listed in adminRoutePermissions", key)
+		}
+	}
+	for key := range adminRoutePermissions {
+		if !registered[key] {
+			t.Errorf("%s is listed in adminRoutePermissions but not registered", key)
+		}
+	}
+}
+
+func TestAdminRouteAuthorization(t *testing.T) {
+	r := newTestRouter(t)
+	student := studentToken(t)
+	bare := adminToken(t)
+
+	keys := make([]string, 0, len(adminRoutePermissions))
+	for key := range adminRoutePermissions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		permissions := adminRoutePermissions[key]
+		method, pattern, _ := strings.Cut(key, " ")
+		path := samplePath(pattern)
+
+		t.Run(key, func(t *testing.T) {
+			expectStatus(t, "no token", r.do(method, path, ""), http.StatusUnauthorized)
+			expectStatus(t, "student token", r.do(method, path, student), http.StatusForbidden)
+
+			if permissions == nil {
+				expectAdmitted(t, "admin without permissions", r.do(method, path, bare))
+				return
+			}
+			expectStatus(t, "admin without permissions", r.do(method, path, bare), http.StatusForbidden)
+
+			// Every other permission together must not be enough.
+			var others []string
+			for _, p := range model.AllPermissions {
+				if !slices.Contains(permissions, p) {
+					others = append(others, string(p))
+				}
+			}
+			expectStatus(t, "admin with every other permission", r.do(method, path, adminToken(t, others...)), http.StatusForbidden)
+
+			for _, p := range permissions {
+				expectAdmitted(t, "admin with "+string(p), r.do(method, path, adminToken(t, string(p))))
+			}
+		})
+	}
+}
+
+// An admin or guardian whose session cannot be checked because Redis is down is asked
+// to retry, not logged out.
+func TestSessionCheckUnavailable(t *testing.T) {
+	rdb := redis.NewClient(&redis.Options{
+		MaxRetries: -1,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, errors.New("redis is down")
+		},
+	})
+	t.Cleanup(func() { rdb.Close() })
+	r := newTestRouterOn(t, &Handlers{}, rdb)
+
+	token := adminToken(t, string(model.PermissionExamsRead))
+	expectStatus(t, "admin during a Redis outage", r.do(http.MethodGet, "/api/v1/admin/exams", token), http.StatusServiceUnavailable)
+
+	guardian := signToken(t, service.Claims{TokenType: service.TokenTypeGuardian, UserID: 1})
+	expectStatus(t, "guardian during a Redis outage", r.do(http.MethodGet, "/api/v1/guardian/children", guardian), http.StatusServiceUnavailable)
+}
+
+// samplePath fills the parameters of a route pattern.
+func samplePath(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			parts[i] = "1"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func expectStatus(t *testing.T, caller string, got, want int) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s: got %s, want %s", caller, statusName(got), statusName(want))
+	}
+}
+
+// expectAdmitted checks the middleware let the request through to the handler.
+func expectAdmitted(t *testing.T, caller string, got int) {
+	t.Helper()
+	if got == http.StatusUnauthorized || got == http.StatusForbidden {
+		t.Errorf("%s: got %s, want the request admitted", caller, statusName(got))
+	}
+}
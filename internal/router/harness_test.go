@@ -0,0 +1,178 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+const testJWTSecret = "router-test-secret"
+
+// testRouter is the full router wired to zero-value handlers. Requests that get past
+// the middleware reach a nil handler and are answered 500 by Recovery, so a test can
+// tell what the middleware decided without any database.
+type testRouter struct {
+	engine *gin.Engine
+}
+
+// newTestRouter builds the router with a config fit for tests and an AuthService whose
+// Redis is a fake that knows every admin session.
+func newTestRouter(t *testing.T) *testRouter {
+	t.Helper()
+	return newTestRouterWith(t, &Handlers{})
+}
+
+// newTestRouterWith is newTestRouter wired to handlers, for tests that need a request
+// to get further than the middleware.
+func newTestRouterWith(t *testing.T, handlers *Handlers) *testRouter {
+	t.Helper()
+
+	rdb := newFakeRedis()
+	t.Cleanup(func() { rdb.Close() })
+	return newTestRouterOn(t, handlers, rdb)
+}
+
+// newTestRouterOn is newTestRouterWith with the AuthService on rdb.
+func newTestRouterOn(t *testing.T, handlers *Handlers, rdb *redis.Client) *testRouter {
+	t.Helper()
+
+	gin.DefaultWriter = io.Discard
+	cfg := &config.Config{
+		GinMode:            gin.TestMode,
+		RequestTimeout:     5 * time.Second,
+		LongRequestTimeout: 5 * time.Second,
+		JWTSecret:          testJWTSecret,
+		JWTExpiry:          time.Hour,
+	}
+
+	authService := service.NewAuthService(cfg, rdb)
+	return &testRouter{engine: SetupRouter(authService, nil, handlers, cfg, zerolog.Nop())}
+}
+
+// do sends a request with token as bearer, if any, and returns the status code.
+func (r *testRouter) do(method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.engine.ServeHTTP(w, req)
+	return w.Code
+}
+
+// adminToken signs an admin token holding permissions.
+func adminToken(t *testing.T, permissions ...string) string {
+	t.Helper()
+	return signToken(t, service.Claims{TokenType: service.TokenTypeAdmin, UserID: 1, RoleID: 1, Permissions: permissions})
+}
+
+// studentToken signs a student token.
+func studentToken(t *testing.T) string {
+	t.Helper()
+	return signToken(t, service.Claims{TokenType: service.TokenTypeStudent, UserID: 1, ClassID: 1})
+}
+
+func signToken(t *testing.T, claims service.Claims) string {
+	t.Helper()
+
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        "test-session",
+		Subject:   strconv.Itoa(claims.UserID),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+// newFakeRedis returns a client talking to an in-process server that answers the
+// session checks of the auth middleware as if every session were live, and refuses
+// every other command.
+func newFakeRedis() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Protocol:        2,
+		DisableIdentity: true,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go serveFakeRedis(server)
+			return client, nil
+		},
+	})
+}
+
+func serveFakeRedis(conn net.Conn) {
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "HEXISTS", "SISMEMBER":
+			reply = ":1\r\n"
+		default:
+			reply = fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads one command, an array of bulk strings.
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("fake redis: bad command header %q", line)
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("fake redis: bad argument header %q", line)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// statusName renders a status code for failure messages.
+func statusName(code int) string {
+	return fmt.Sprintf("%d %s", code, http.StatusText(code))
+}
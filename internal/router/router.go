@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
 	"github.com/stemsi/exstem-backend/internal/config"
 	"github.com/stemsi/exstem-backend/internal/handler"
 	"github.com/stemsi/exstem-backend/internal/middleware"
@@ -35,16 +36,30 @@ type Handlers struct {
 	System         *handler.SystemHandler
 	Room           *handler.RoomHandler
 	RoomAssignment *handler.RoomAssignmentHandler
+	Erapor         *handler.EraporHandler
+	Notification   *handler.NotificationHandler
+	PublicResult   *handler.PublicResultHandler
+	Guardian       *handler.GuardianHandler
+	GuardianPortal *handler.GuardianPortalHandler
+	Backup         *handler.BackupHandler
+	Retention      *handler.RetentionHandler
+	Assessment     *handler.AssessmentHandler
 }
 
 // SetupRouter configures all Gin route groups with appropriate middlewares.
 func SetupRouter(
 	authService *service.AuthService,
+	auditService *service.AuditService,
 	handlers *Handlers,
 	cfg *config.Config,
+	log zerolog.Logger,
 ) *gin.Engine {
 	gin.SetMode(cfg.GinMode)
-	router := gin.Default()
+	router := gin.New()
+
+	// Panics are answered in the standard error envelope and logged with their stack,
+	// and counted as server errors by CountRequests.
+	router.Use(gin.Logger(), middleware.CountRequests(), middleware.Recovery(log))
 
 	// ─── CORS ──────────────────────────────────────────────────────────
 	// If AllowedOrigins is set in config, restrict to that list;
@@ -52,6 +67,8 @@ func SetupRouter(
 	corsConfig := cors.DefaultConfig()
 	if len(cfg.AllowedOrigins) > 0 {
 		corsConfig.AllowOrigins = cfg.AllowedOrigins
+		// The SSO callback needs the state cookie of the login it completes.
+		corsConfig.AllowCredentials = true
 	} else {
 		corsConfig.AllowAllOrigins = true
 	}
@@ -67,9 +84,14 @@ func SetupRouter(
 	// Apply brotli middleware globally.
 	router.Use(middleware.Brotli())
 
-	// Serve uploaded media files statically with aggressive caching (1 year).
+	// Translate the domain errors handlers record with c.Error into error responses.
+	router.Use(middleware.ErrorMapper())
+
+	// Serve uploaded media files statically with aggressive caching (1 year). Uploads
+	// are named after their content, so a URL never changes content.
+	// http.FileServer answers Range requests, so audio/video can be streamed and seeked.
 	uploadsGroup := router.Group("/uploads")
-	uploadsGroup.Use(middleware.CacheControl(31536000))
+	uploadsGroup.Use(middleware.CacheControl(31536000, true), middleware.AcceptRanges())
 	{
 		uploadsGroup.Static("/", "./uploads")
 	}
@@ -79,10 +101,26 @@ func SetupRouter(
 		response.Success(c, http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Prometheus scrape endpoint, enabled by METRICS_TOKEN.
+	if cfg.MetricsToken != "" {
+		router.GET("/metrics", middleware.RequireStaticToken(cfg.MetricsToken), handlers.System.PrometheusMetrics)
+	}
+
 	// ─── 0. Public Group (No Auth) ─────────────────────────────────────
 	publicAPI := router.Group("/api/v1/public")
+	publicAPI.Use(middleware.Timeout(cfg.RequestTimeout))
 	{
 		publicAPI.GET("/settings", handlers.Setting.GetPublicSettings)
+		publicAPI.GET("/settings/remote", handlers.Setting.GetRemoteConfig)
+	}
+
+	// Results lookup (10 attempts per minute per IP against NISN/code guessing).
+	resultLookupLimiter := middleware.NewRateLimiter(10, time.Minute)
+	publicResults := publicAPI.Group("/results")
+	publicResults.Use(resultLookupLimiter.Middleware())
+	{
+		publicResults.GET("/exams", handlers.PublicResult.ListExams)
+		publicResults.POST("/exams/:id", handlers.PublicResult.Lookup)
 	}
 
 	// Rate limiter for auth routes (30 requests per minute per IP).
@@ -90,29 +128,48 @@ func SetupRouter(
 
 	// ─── 1. Auth Group (Public, Rate Limited) ──────────────────────────
 	auth := router.Group("/api/v1/auth")
+	auth.Use(middleware.Timeout(cfg.RequestTimeout))
 	// auth.Use(authLimiter.Middleware())
 	{
 		auth.POST("/student/login", handlers.Auth.StudentLogin)
 		auth.POST("/admin/login", handlers.Auth.AdminLogin)
+		auth.GET("/admin/oidc/login", handlers.Auth.AdminOIDCLogin)
+		auth.GET("/admin/oidc/callback", handlers.Auth.AdminOIDCCallback)
 
 		// Authenticated profile routes
-		auth.POST("/student/logout", middleware.RequireStudentJWT(authService), handlers.Auth.StudentLogout)
-		auth.GET("/student/me", middleware.RequireStudentJWT(authService), handlers.Auth.GetStudentProfile)
+		auth.POST("/student/logout", middleware.RequireStudentJWT(authService), middleware.AuditImpersonation(auditService), handlers.Auth.StudentLogout)
+		auth.GET("/student/me", middleware.RequireStudentJWT(authService), middleware.AuditImpersonation(auditService), handlers.Auth.GetStudentProfile)
+		auth.POST("/admin/logout", middleware.RequireAdminJWT(authService), handlers.Auth.AdminLogout)
 		auth.GET("/admin/me", middleware.RequireAdminJWT(authService), handlers.Auth.GetAdminProfile)
+
+		auth.POST("/guardian/login", handlers.Auth.GuardianLogin)
+		auth.POST("/guardian/logout", middleware.RequireGuardianJWT(authService), handlers.Auth.GuardianLogout)
+		auth.GET("/guardian/me", middleware.RequireGuardianJWT(authService), handlers.Auth.GetGuardianProfile)
 	}
 
 	// ─── 2. Student Group (JWT + Single Device) ────────────────────────
 	studentAPI := router.Group("/api/v1/student")
 	studentAPI.Use(
+		middleware.Timeout(cfg.RequestTimeout),
 		middleware.RequireStudentJWT(authService),
 		middleware.CheckSingleDeviceSession(authService),
+		middleware.AuditImpersonation(auditService),
 	)
 	{
 		studentAPI.GET("/lobby", handlers.StudentPortal.GetLobby)
+		studentAPI.GET("/lobby/assessments", handlers.StudentPortal.GetLobbyAssessments)
+		studentAPI.GET("/lobby/stream", middleware.NoTimeout(), handlers.StudentPortal.LobbyStream)
+		studentAPI.GET("/schedule", handlers.StudentPortal.GetSchedule)
 		studentAPI.GET("/active-session", handlers.StudentPortal.GetActiveSession)
 		studentAPI.POST("/exams/:exam_id/join", handlers.StudentPortal.JoinExam)
 		studentAPI.GET("/exams/:exam_id/paper", handlers.StudentPortal.GetExamPaper)
 		studentAPI.GET("/exams/:exam_id/state", handlers.StudentPortal.GetExamState)
+		studentAPI.GET("/exams/:exam_id/result", handlers.StudentPortal.GetExamResult)
+		studentAPI.GET("/exams/:exam_id/review", handlers.StudentPortal.GetExamReview)
+		studentAPI.GET("/exams/history", handlers.StudentPortal.GetExamHistory)
+		studentAPI.GET("/settings/accessibility", handlers.StudentPortal.GetAccessibilitySettings)
+		studentAPI.PUT("/settings/accessibility", handlers.StudentPortal.UpdateAccessibilitySettings)
+		studentAPI.PUT("/password", handlers.Auth.ChangeStudentPassword)
 	}
 
 	// ─── 3. WebSocket Group (Student WS Auth) ──────────────────────────
@@ -124,14 +181,29 @@ func SetupRouter(
 
 	// ─── 4. Admin Group (JWT + RBAC) ───────────────────────────────────
 	adminAPI := router.Group("/api/v1/admin")
-	adminAPI.Use(middleware.RequireAdminJWT(authService))
+	adminAPI.Use(middleware.Timeout(cfg.RequestTimeout), middleware.RequireAdminJWT(authService))
 	{
 		// Media upload
 		adminAPI.POST("/media/upload",
+			middleware.Timeout(cfg.LongRequestTimeout),
 			middleware.RequirePermission(string(model.PermissionMediaUpload)),
 			handlers.Media.UploadMedia,
 		)
 
+		// Media library
+		adminAPI.GET("/media",
+			middleware.RequirePermission(string(model.PermissionMediaRead)),
+			handlers.Media.ListMedia,
+		)
+		adminAPI.GET("/media/:id/usage",
+			middleware.RequirePermission(string(model.PermissionMediaRead)),
+			handlers.Media.GetMediaUsage,
+		)
+		adminAPI.DELETE("/media/:id",
+			middleware.RequirePermission(string(model.PermissionMediaDelete)),
+			handlers.Media.DeleteMedia,
+		)
+
 		// Class management
 		adminAPI.GET("/classes",
 			middleware.RequirePermission(string(model.PermissionStudentsRead)),
@@ -141,10 +213,18 @@ func SetupRouter(
 			middleware.RequirePermission(string(model.PermissionStudentsWrite)),
 			handlers.Class.CreateClass,
 		)
+		adminAPI.POST("/classes/bulk",
+			middleware.RequirePermission(string(model.PermissionStudentsWrite)),
+			handlers.Class.BulkCreateClasses,
+		)
 		adminAPI.PUT("/classes/:id",
 			middleware.RequirePermission(string(model.PermissionStudentsWrite)),
 			handlers.Class.UpdateClass,
 		)
+		adminAPI.PUT("/classes/:id/homeroom",
+			middleware.RequirePermission(string(model.PermissionStudentsWrite)),
+			handlers.Class.SetHomeroom,
+		)
 		adminAPI.DELETE("/classes/:id",
 			middleware.RequirePermission(string(model.PermissionStudentsWrite)),
 			handlers.Class.DeleteClass,
@@ -156,6 +236,7 @@ func SetupRouter(
 			handlers.StudentMgmt.ListStudentCards,
 		)
 		adminAPI.GET("/students-cards/pdf",
+			middleware.Timeout(cfg.LongRequestTimeout),
 			middleware.RequirePermission(string(model.PermissionStudentsRead)),
 			handlers.StudentMgmt.ExportStudentCardsPDF,
 		)
@@ -179,6 +260,33 @@ func SetupRouter(
 			middleware.RequirePermission(string(model.PermissionStudentsResetSession)),
 			handlers.StudentMgmt.ResetStudentSession,
 		)
+		adminAPI.PUT("/students/:id/status",
+			middleware.RequirePermission(string(model.PermissionStudentsWrite)),
+			handlers.StudentMgmt.UpdateStudentStatus,
+		)
+		adminAPI.POST("/students/status",
+			middleware.RequirePermission(string(model.PermissionStudentsWrite)),
+			handlers.StudentMgmt.BulkUpdateStudentStatus,
+		)
+		adminAPI.POST("/students/directory-sync",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequirePermission(string(model.PermissionStudentsWrite)),
+			handlers.StudentMgmt.SyncDirectory,
+		)
+		adminAPI.POST("/students/import-dapodik",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequirePermission(string(model.PermissionStudentsWrite)),
+			handlers.StudentMgmt.ImportDapodik,
+		)
+		adminAPI.POST("/students/credentials",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequirePermission(string(model.PermissionStudentsWrite)),
+			handlers.StudentMgmt.IssueCredentials,
+		)
+		adminAPI.POST("/students/:id/impersonate",
+			middleware.RequirePermission(string(model.PermissionStudentsImpersonate)),
+			handlers.StudentMgmt.ImpersonateStudent,
+		)
 
 		// Admin User Management
 		adminAPI.GET("/users",
@@ -197,6 +305,14 @@ func SetupRouter(
 			middleware.RequirePermission(string(model.PermissionAdminsWrite)),
 			handlers.AdminUser.DeleteAdmin,
 		)
+		adminAPI.GET("/users/:id/sessions",
+			middleware.RequirePermission(string(model.PermissionAdminsRead)),
+			handlers.AdminUser.ListSessions,
+		)
+		adminAPI.DELETE("/users/:id/sessions",
+			middleware.RequirePermission(string(model.PermissionAdminsWrite)),
+			handlers.AdminUser.RevokeSessions,
+		)
 		// Roles for selection (using read permission as it's needed for viewing user form)
 		adminAPI.GET("/roles",
 			middleware.RequirePermission(string(model.PermissionAdminsRead)),
@@ -234,10 +350,71 @@ func SetupRouter(
 			middleware.RequirePermission(string(model.PermissionExamsRead)),
 			handlers.Exam.ListExams,
 		)
+		adminAPI.GET("/exams/calendar",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.GetExamCalendar,
+		)
 		adminAPI.GET("/exams/:id/results",
 			middleware.RequirePermission(string(model.PermissionExamsRead)),
 			handlers.Exam.GetExamResults,
 		)
+		adminAPI.POST("/exams/:id/offline-scores",
+			middleware.RequirePermission(string(model.PermissionExamsWrite)),
+			handlers.Exam.RecordOfflineScores,
+		)
+		adminAPI.GET("/exams/:id/sessions/stale",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.ListStaleSessions,
+		)
+		adminAPI.POST("/exams/:id/sessions/stale/submit",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequirePermission(string(model.PermissionExamsWrite)),
+			handlers.Exam.SubmitStaleSessions,
+		)
+		adminAPI.GET("/exams/:id/stats",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.GetExamStats,
+		)
+		adminAPI.GET("/exams/:id/erapor-mapping",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Erapor.GetMapping,
+		)
+		adminAPI.PUT("/exams/:id/erapor-mapping",
+			middleware.RequirePermission(string(model.PermissionExamsWrite)),
+			handlers.Erapor.SetMapping,
+		)
+		adminAPI.DELETE("/exams/:id/erapor-mapping",
+			middleware.RequirePermission(string(model.PermissionExamsWrite)),
+			handlers.Erapor.DeleteMapping,
+		)
+		adminAPI.GET("/exams/:id/notifications",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Notification.GetSettings,
+		)
+		adminAPI.PUT("/exams/:id/notifications",
+			middleware.RequirePermission(string(model.PermissionExamsWrite)),
+			handlers.Notification.SetSettings,
+		)
+		adminAPI.DELETE("/exams/:id/notifications",
+			middleware.RequirePermission(string(model.PermissionExamsWrite)),
+			handlers.Notification.DeleteSettings,
+		)
+		adminAPI.POST("/exams/:id/notifications/test",
+			middleware.RequirePermission(string(model.PermissionExamsWrite)),
+			handlers.Notification.SendTest,
+		)
+
+		// e-Rapor export
+		adminAPI.GET("/erapor/export",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequirePermission(string(model.PermissionEraporExport)),
+			handlers.Erapor.Export,
+		)
+		adminAPI.POST("/erapor/push",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequirePermission(string(model.PermissionEraporExport)),
+			handlers.Erapor.Push,
+		)
 		adminAPI.POST("/exams",
 			middleware.RequirePermission(string(model.PermissionExamsWrite)),
 			handlers.Exam.CreateExam,
@@ -246,6 +423,51 @@ func SetupRouter(
 			middleware.RequirePermission(string(model.PermissionExamsRead)),
 			handlers.Exam.GetExam,
 		)
+		adminAPI.GET("/exams/:id/preview",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.PreviewExam,
+		)
+		adminAPI.GET("/exams/:id/validate",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.ValidateExam,
+		)
+		adminAPI.POST("/exams/:id/simulate",
+			middleware.RequirePermission(string(model.PermissionExamsWrite)),
+			handlers.Exam.SimulateExam,
+		)
+		adminAPI.GET("/exams/:id/question-reuse",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.GetQuestionReuse,
+		)
+		adminAPI.GET("/exams/:id/attendance.pdf",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.ExportAttendancePDF,
+		)
+		adminAPI.GET("/exams/:id/attendance.csv",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.ExportAttendanceCSV,
+		)
+		adminAPI.POST("/exams/:id/paper",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.StartPaperExport,
+		)
+		adminAPI.GET("/exams/:id/paper/:job_id",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.GetPaperExport,
+		)
+		adminAPI.GET("/exams/:id/paper/:job_id/download",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.DownloadPaperExport,
+		)
+		adminAPI.GET("/exams/:id/readiness",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.GetExamReadiness,
+		)
+		adminAPI.GET("/exams/:id/payload-size",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Exam.GetExamPayloadSize,
+		)
 		adminAPI.PUT("/exams/:id",
 			middleware.RequirePermission(string(model.PermissionExamsWrite)),
 			handlers.Exam.UpdateExam,
@@ -258,6 +480,10 @@ func SetupRouter(
 			middleware.RequirePermission(string(model.PermissionExamsPublish)),
 			handlers.Exam.PublishExam,
 		)
+		adminAPI.POST("/exams/:id/unpublish",
+			middleware.RequirePermission(string(model.PermissionExamsPublish)),
+			handlers.Exam.UnpublishExam,
+		)
 		adminAPI.GET("/exams/:id/target-rules",
 			middleware.RequirePermission(string(model.PermissionExamsRead)),
 			handlers.Exam.GetTargetRules,
@@ -278,31 +504,87 @@ func SetupRouter(
 			middleware.RequirePermission(string(model.PermissionExamsPublish)),
 			handlers.Exam.RefreshExamCache,
 		)
+		adminAPI.POST("/exams/bulk",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequirePermission(string(model.PermissionExamsWrite)),
+			handlers.Exam.BulkExams,
+		)
+		adminAPI.POST("/exams/reconcile-sessions",
+			middleware.RequirePermission(string(model.PermissionExamsPublish)),
+			handlers.Exam.ReconcileSessions,
+		)
 
 		adminAPI.GET("/exams/:id/monitor",
-			middleware.RequirePermission(string(model.PermissionExamsWrite)),
+			middleware.NoTimeout(),
+			middleware.RequirePermission(string(model.PermissionMonitorRead)),
 			handlers.Monitor.MonitorExamSSE,
 		)
+		adminAPI.GET("/monitor/overview",
+			middleware.NoTimeout(),
+			middleware.RequirePermission(string(model.PermissionMonitorRead)),
+			handlers.Monitor.MonitorOverviewSSE,
+		)
+		adminAPI.GET("/exams/:id/monitor/replay",
+			middleware.RequirePermission(string(model.PermissionMonitorRead)),
+			handlers.Monitor.GetMonitorReplay,
+		)
+		adminAPI.GET("/exams/:id/sessions/:student_id/timeline",
+			middleware.RequirePermission(string(model.PermissionMonitorRead)),
+			handlers.Monitor.GetSessionTimeline,
+		)
 
 		// Room Assignments (standalone distribution)
 		assignmentsGroup := adminAPI.Group("/room-assignments")
 		{
-			assignmentsGroup.GET("", middleware.RequirePermission(string(model.PermissionRoomsRead)), handlers.RoomAssignment.GetDistribution)
+			assignmentsGroup.GET("", middleware.RequireAnyPermission(string(model.PermissionRoomsRead), string(model.PermissionMonitorRead)), handlers.RoomAssignment.GetDistribution)
 			assignmentsGroup.POST("/distribute", middleware.RequirePermission(string(model.PermissionRoomsWrite)), handlers.RoomAssignment.AutoDistribute)
 			assignmentsGroup.PUT("/sessions", middleware.RequirePermission(string(model.PermissionRoomsWrite)), handlers.RoomAssignment.UpdateSessionTimes)
 			assignmentsGroup.DELETE("", middleware.RequirePermission(string(model.PermissionRoomsWrite)), handlers.RoomAssignment.ClearDistribution)
-			assignmentsGroup.GET("/export", middleware.RequirePermission(string(model.PermissionRoomsRead)), handlers.RoomAssignment.ExportPresenceXLSX)
+			assignmentsGroup.GET("/export", middleware.Timeout(cfg.LongRequestTimeout), middleware.RequirePermission(string(model.PermissionRoomsRead)), handlers.RoomAssignment.ExportPresenceXLSX)
 		}
 
+		// Cheat analytics across exams
+		adminAPI.GET("/analytics/cheats",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Monitor.GetCheatAnalytics,
+		)
+		adminAPI.GET("/analytics/cheats/export",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Monitor.ExportCheatAnalytics,
+		)
+		adminAPI.GET("/analytics/activity",
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Monitor.GetActivityReport,
+		)
+		adminAPI.GET("/analytics/activity.csv",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Monitor.ExportActivityReportCSV,
+		)
+		adminAPI.GET("/analytics/activity.pdf",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequirePermission(string(model.PermissionExamsRead)),
+			handlers.Monitor.ExportActivityReportPDF,
+		)
+
 		// Dashboard
 		adminAPI.GET("/dashboard",
 			handlers.Dashboard.GetDashboardData, // Open to all admins
 		)
+		adminAPI.GET("/dashboard/widgets", handlers.Dashboard.ListWidgets)
+		adminAPI.GET("/dashboard/widgets/:widget", handlers.Dashboard.GetWidgetData)
+		adminAPI.GET("/dashboard/layout", handlers.Dashboard.GetLayout)
+		adminAPI.PUT("/dashboard/layout", handlers.Dashboard.UpdateLayout)
 
 		// System Monitoring
 		adminAPI.GET("/system/metrics",
+			middleware.NoTimeout(),
 			handlers.System.SystemMetricsSSE, // Open to all admins
 		)
+		adminAPI.GET("/system/metrics/history",
+			handlers.System.GetMetricsHistory, // Open to all admins
+		)
 
 		// Question management
 		adminAPI.GET("/qbanks",
@@ -337,6 +619,39 @@ func SetupRouter(
 			middleware.RequireAnyPermission(string(model.PermissionQBanksWriteOwn), string(model.PermissionQBanksWriteAll)),
 			handlers.Question.ReplaceQuestions,
 		)
+		adminAPI.PUT("/qbanks/:id/questions/order",
+			middleware.RequireAnyPermission(string(model.PermissionQBanksWriteOwn), string(model.PermissionQBanksWriteAll)),
+			handlers.Question.ReorderQuestions,
+		)
+		adminAPI.POST("/qbanks/:id/questions/bulk-delete",
+			middleware.RequireAnyPermission(string(model.PermissionQBanksWriteOwn), string(model.PermissionQBanksWriteAll)),
+			handlers.Question.DeleteQuestions,
+		)
+		adminAPI.POST("/qbanks/:id/questions/transfer",
+			middleware.RequireAnyPermission(string(model.PermissionQBanksWriteOwn), string(model.PermissionQBanksWriteAll)),
+			handlers.Question.TransferQuestions,
+		)
+		adminAPI.POST("/qbanks/:id/import-doc",
+			middleware.Timeout(cfg.LongRequestTimeout),
+			middleware.RequireAnyPermission(string(model.PermissionQBanksWriteOwn), string(model.PermissionQBanksWriteAll)),
+			handlers.Question.ImportQuestionsDoc,
+		)
+		adminAPI.GET("/qbanks/:id/passages",
+			middleware.RequireAnyPermission(string(model.PermissionQBanksWriteOwn), string(model.PermissionQBanksWriteAll)),
+			handlers.Question.ListPassages,
+		)
+		adminAPI.POST("/qbanks/:id/passages",
+			middleware.RequireAnyPermission(string(model.PermissionQBanksWriteOwn), string(model.PermissionQBanksWriteAll)),
+			handlers.Question.CreatePassage,
+		)
+		adminAPI.PUT("/qbanks/:id/passages/:passage_id",
+			middleware.RequireAnyPermission(string(model.PermissionQBanksWriteOwn), string(model.PermissionQBanksWriteAll)),
+			handlers.Question.UpdatePassage,
+		)
+		adminAPI.DELETE("/qbanks/:id/passages/:passage_id",
+			middleware.RequireAnyPermission(string(model.PermissionQBanksWriteOwn), string(model.PermissionQBanksWriteAll)),
+			handlers.Question.DeletePassage,
+		)
 
 		// App Settings Routes
 		settingsGroup := adminAPI.Group("/settings")
@@ -345,6 +660,20 @@ func SetupRouter(
 			settingsGroup.PUT("", middleware.RequirePermission(string(model.PermissionSettingsWrite)), handlers.Setting.UpdateSettings)
 		}
 
+		// Assessments Routes
+		assessmentsGroup := adminAPI.Group("/assessments")
+		{
+			assessmentsGroup.GET("", middleware.RequirePermission(string(model.PermissionExamsRead)), handlers.Assessment.ListAssessments)
+			assessmentsGroup.POST("", middleware.RequirePermission(string(model.PermissionExamsWrite)), handlers.Assessment.CreateAssessment)
+			assessmentsGroup.GET("/:id", middleware.RequirePermission(string(model.PermissionExamsRead)), handlers.Assessment.GetAssessment)
+			assessmentsGroup.PUT("/:id", middleware.RequirePermission(string(model.PermissionExamsWrite)), handlers.Assessment.UpdateAssessment)
+			assessmentsGroup.DELETE("/:id", middleware.RequirePermission(string(model.PermissionExamsWrite)), handlers.Assessment.DeleteAssessment)
+			assessmentsGroup.PUT("/:id/exams", middleware.RequirePermission(string(model.PermissionExamsWrite)), handlers.Assessment.SetAssessmentExams)
+			assessmentsGroup.PUT("/:id/target-rules", middleware.RequirePermission(string(model.PermissionExamsWrite)), handlers.Assessment.SetAssessmentTargetRules)
+			assessmentsGroup.GET("/:id/report", middleware.RequirePermission(string(model.PermissionExamsRead)), handlers.Assessment.GetAssessmentReport)
+			assessmentsGroup.GET("/:id/report.csv", middleware.RequirePermission(string(model.PermissionExamsRead)), handlers.Assessment.ExportAssessmentReportCSV)
+		}
+
 		// Subjects Routes
 		subjectsGroup := adminAPI.Group("/subjects")
 		{
@@ -352,6 +681,9 @@ func SetupRouter(
 			subjectsGroup.POST("", middleware.RequirePermission(string(model.PermissionSubjectsWrite)), handlers.Subject.Create)
 			subjectsGroup.PUT("/:id", middleware.RequirePermission(string(model.PermissionSubjectsWrite)), handlers.Subject.Update)
 			subjectsGroup.DELETE("/:id", middleware.RequirePermission(string(model.PermissionSubjectsWrite)), handlers.Subject.Delete)
+			subjectsGroup.GET("/:id/teachers", middleware.RequirePermission(string(model.PermissionSubjectsRead)), handlers.Subject.ListTeachers)
+			subjectsGroup.POST("/:id/teachers", middleware.RequirePermission(string(model.PermissionSubjectsWrite)), handlers.Subject.AssignTeachers)
+			subjectsGroup.DELETE("/:id/teachers/:admin_id", middleware.RequirePermission(string(model.PermissionSubjectsWrite)), handlers.Subject.UnassignTeacher)
 		}
 
 		// Majors Routes
@@ -371,6 +703,48 @@ func SetupRouter(
 			roomsGroup.PUT("/:id", middleware.RequirePermission(string(model.PermissionRoomsWrite)), handlers.Room.UpdateRoom)
 			roomsGroup.DELETE("/:id", middleware.RequirePermission(string(model.PermissionRoomsWrite)), handlers.Room.DeleteRoom)
 		}
+
+		guardiansGroup := adminAPI.Group("/guardians")
+		{
+			guardiansGroup.GET("", middleware.RequirePermission(string(model.PermissionGuardiansRead)), handlers.Guardian.ListGuardians)
+			guardiansGroup.GET("/:id", middleware.RequirePermission(string(model.PermissionGuardiansRead)), handlers.Guardian.GetGuardian)
+			guardiansGroup.POST("", middleware.RequirePermission(string(model.PermissionGuardiansWrite)), handlers.Guardian.CreateGuardian)
+			guardiansGroup.PUT("/:id", middleware.RequirePermission(string(model.PermissionGuardiansWrite)), handlers.Guardian.UpdateGuardian)
+			guardiansGroup.DELETE("/:id", middleware.RequirePermission(string(model.PermissionGuardiansWrite)), handlers.Guardian.DeleteGuardian)
+			guardiansGroup.POST("/:id/students", middleware.RequirePermission(string(model.PermissionGuardiansWrite)), handlers.Guardian.LinkStudent)
+			guardiansGroup.DELETE("/:id/students/:student_id", middleware.RequirePermission(string(model.PermissionGuardiansWrite)), handlers.Guardian.UnlinkStudent)
+		}
+
+		// Backups Routes
+		backupsGroup := adminAPI.Group("/backups")
+		{
+			backupsGroup.GET("", middleware.RequirePermission(string(model.PermissionOpsBackup)), handlers.Backup.ListBackups)
+			backupsGroup.POST("", middleware.Timeout(cfg.LongRequestTimeout), middleware.RequirePermission(string(model.PermissionOpsBackup)), handlers.Backup.CreateBackup)
+			backupsGroup.POST("/:name/restore", middleware.Timeout(cfg.LongRequestTimeout), middleware.RequirePermission(string(model.PermissionOpsBackup)), handlers.Backup.RestoreBackup)
+		}
+
+		// Retention Routes
+		retentionGroup := adminAPI.Group("/retention")
+		{
+			retentionGroup.GET("/targets", middleware.RequirePermission(string(model.PermissionSettingsRead)), handlers.Retention.ListTargets)
+			retentionGroup.GET("/rules", middleware.RequirePermission(string(model.PermissionSettingsRead)), handlers.Retention.ListRules)
+			retentionGroup.POST("/rules", middleware.RequirePermission(string(model.PermissionSettingsWrite)), handlers.Retention.CreateRule)
+			retentionGroup.PUT("/rules/:id", middleware.RequirePermission(string(model.PermissionSettingsWrite)), handlers.Retention.UpdateRule)
+			retentionGroup.DELETE("/rules/:id", middleware.RequirePermission(string(model.PermissionSettingsWrite)), handlers.Retention.DeleteRule)
+			retentionGroup.GET("/report", middleware.Timeout(cfg.LongRequestTimeout), middleware.RequirePermission(string(model.PermissionSettingsRead)), handlers.Retention.GetReport)
+		}
+	}
+
+	// ─── 5. Guardian Group (JWT, Read-Only) ────────────────────────────
+	guardianAPI := router.Group("/api/v1/guardian")
+	guardianAPI.Use(middleware.Timeout(cfg.RequestTimeout), middleware.RequireGuardianJWT(authService))
+	{
+		guardianAPI.GET("/children", handlers.GuardianPortal.ListChildren)
+		guardianAPI.GET("/children/:student_id/schedule", handlers.GuardianPortal.GetChildSchedule)
+		guardianAPI.GET("/children/:student_id/exams", handlers.GuardianPortal.GetChildHistory)
+		guardianAPI.GET("/children/:student_id/exams/:exam_id/result", handlers.GuardianPortal.GetChildResult)
+		guardianAPI.GET("/preferences", handlers.GuardianPortal.GetPreferences)
+		guardianAPI.PUT("/preferences", handlers.GuardianPortal.UpdatePreferences)
 	}
 
 	return router
@@ -0,0 +1,231 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/handler"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+// scopedQBankID is the question bank the fake Postgres knows, in subject 2.
+const scopedQBankID = "6f1c1d7e-6a43-4f7e-9a55-2d0f3c1f4b10"
+
+// A route with its own, longer Timeout must keep the subject scopes RequireAdminJWT
+// put in the request context, or a scoped admin reaches other subjects through it.
+func TestLongTimeoutRouteKeepsSubjectScopes(t *testing.T) {
+	pool := newFakePostgres(t)
+	questions := service.NewQuestionService(repository.NewQuestionRepository(pool), nil)
+	r := newTestRouterWith(t, &Handlers{Question: handler.NewQuestionHandler(questions)})
+
+	path := "/api/v1/admin/qbanks/" + scopedQBankID + "/import-doc"
+	perm := string(model.PermissionQBanksWriteOwn)
+
+	outside := signToken(t, service.Claims{
+		TokenType:     service.TokenTypeAdmin,
+		UserID:        1,
+		RoleID:        1,
+		Permissions:   []string{perm},
+		SubjectScopes: map[string][]int{perm: {1}},
+	})
+	expectStatus(t, "admin scoped to another subject", r.upload(t, path, outside), http.StatusForbidden)
+
+	inside := signToken(t, service.Claims{
+		TokenType:     service.TokenTypeAdmin,
+		UserID:        1,
+		RoleID:        1,
+		Permissions:   []string{perm},
+		SubjectScopes: map[string][]int{perm: {2}},
+	})
+	expectAdmitted(t, "admin scoped to the bank's subject", r.upload(t, path, inside))
+}
+
+// upload posts a small question document to path with token as bearer and returns the
+// status code.
+func (r *testRouter) upload(t *testing.T, path, token string) int {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "questions.txt")
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	io.WriteString(part, "1. What is 1 + 1?\nA. 1\nB. 2\nAnswer: B\n")
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.engine.ServeHTTP(w, req)
+	return w.Code
+}
+
+// newFakePostgres returns a pool talking to an in-process server that answers the
+// question bank lookup with scopedQBankID, finds no exam sessions and fails every
+// other query.
+func newFakePostgres(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	cfg, err := pgxpool.ParseConfig("postgres://test@fake/test?sslmode=disable&default_query_exec_mode=simple_protocol")
+	if err != nil {
+		t.Fatalf("parse pool config: %v", err)
+	}
+	cfg.ConnConfig.LookupFunc = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	}
+	cfg.ConnConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go serveFakePostgres(server)
+		return client, nil
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func serveFakePostgres(conn net.Conn) {
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+	// The startup message has no type byte.
+	var size int32
+	if err := binary.Read(rd, binary.BigEndian, &size); err != nil {
+		return
+	}
+	if _, err := io.CopyN(io.Discard, rd, int64(size)-4); err != nil {
+		return
+	}
+	var out bytes.Buffer
+	writePGMessage(&out, 'R', pgInt32(0))
+	writePGMessage(&out, 'S', pgString("server_version"), pgString("16.0"))
+	writePGMessage(&out, 'S', pgString("client_encoding"), pgString("UTF8"))
+	writePGMessage(&out, 'S', pgString("standard_conforming_strings"), pgString("on"))
+	writePGMessage(&out, 'K', pgInt32(1), pgInt32(1))
+	writePGMessage(&out, 'Z', []byte{'I'})
+	if _, err := conn.Write(out.Bytes()); err != nil {
+		return
+	}
+
+	for {
+		kind, err := rd.ReadByte()
+		if err != nil {
+			return
+		}
+		if err := binary.Read(rd, binary.BigEndian, &size); err != nil {
+			return
+		}
+		body := make([]byte, size-4)
+		if _, err := io.ReadFull(rd, body); err != nil {
+			return
+		}
+		if kind == 'X' {
+			return
+		}
+
+		out.Reset()
+		query := string(bytes.TrimRight(body, "\x00"))
+		switch {
+		case kind != 'Q':
+			writePGError(&out, "unsupported message")
+		case strings.HasPrefix(query, "-- ping"):
+			writePGMessage(&out, 'I')
+		case strings.Contains(query, "FROM question_banks q"):
+			writeQBankRow(&out)
+		case strings.Contains(query, "FROM exam_sessions"):
+			writePGMessage(&out, 'C', pgString("SELECT 0"))
+		default:
+			writePGError(&out, "unexpected query")
+		}
+		writePGMessage(&out, 'Z', []byte{'I'})
+		if _, err := conn.Write(out.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+// writeQBankRow answers GetQBanks with scopedQBankID in subject 2.
+func writeQBankRow(out *bytes.Buffer) {
+	columns := []struct {
+		name string
+		oid  int32
+	}{
+		{"id", 2950}, {"author_id", 23}, {"subject_id", 23},
+		{"name", 25}, {"description", 25}, {"subject_name", 25},
+	}
+	desc := []byte{0, byte(len(columns))}
+	for _, col := range columns {
+		desc = append(desc, pgString(col.name)...)
+		desc = append(desc, pgInt32(0)...)
+		desc = append(desc, 0, 0)
+		desc = append(desc, pgInt32(col.oid)...)
+		desc = append(desc, 0xff, 0xff)
+		desc = append(desc, pgInt32(-1)...)
+		desc = append(desc, 0, 0)
+	}
+	writePGMessage(out, 'T', desc)
+
+	row := []byte{0, byte(len(columns))}
+	for _, v := range []*string{ptr(scopedQBankID), nil, ptr("2"), ptr("Physics"), ptr(""), ptr("Physics")} {
+		if v == nil {
+			row = append(row, pgInt32(-1)...)
+			continue
+		}
+		row = append(row, pgInt32(int32(len(*v)))...)
+		row = append(row, *v...)
+	}
+	writePGMessage(out, 'D', row)
+	writePGMessage(out, 'C', pgString("SELECT 1"))
+}
+
+func writePGError(out *bytes.Buffer, message string) {
+	var fields []byte
+	fields = append(fields, 'S')
+	fields = append(fields, pgString("ERROR")...)
+	fields = append(fields, 'C')
+	fields = append(fields, pgString("XX000")...)
+	fields = append(fields, 'M')
+	fields = append(fields, pgString("fake postgres: "+message)...)
+	fields = append(fields, 0)
+	writePGMessage(out, 'E', fields)
+}
+
+func writePGMessage(out *bytes.Buffer, kind byte, parts ...[]byte) {
+	size := 4
+	for _, p := range parts {
+		size += len(p)
+	}
+	out.WriteByte(kind)
+	out.Write(pgInt32(int32(size)))
+	for _, p := range parts {
+		out.Write(p)
+	}
+}
+
+func pgInt32(v int32) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(v))
+}
+
+func pgString(s string) []byte {
+	return append([]byte(s), 0)
+}
+
+func ptr(s string) *string {
+	return &s
+}
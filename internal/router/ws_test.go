@@ -0,0 +1,643 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/handler"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/queue"
+	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/stemsi/exstem-backend/internal/service"
+	ws "github.com/stemsi/exstem-backend/internal/websocket"
+)
+
+// releaseScriptPrefix starts the scripts that delete a key only while it holds ARGV[1].
+const releaseScriptPrefix = `if redis.call("GET", KEYS[1]) == ARGV[1] then`
+
+// wsReplyTimeout bounds the wait for a server frame.
+const wsReplyTimeout = 5 * time.Second
+
+// wsStudentID is the student the test tokens are signed for.
+const wsStudentID = 1
+
+// wsExam is the live exam the in-memory Redis knows: two multiple-choice questions
+// with answers A and B, and the student's session on it.
+type wsExam struct {
+	id        uuid.UUID
+	questions []string
+}
+
+// TestWSProtocol checks the exam socket contract against the real router and WS
+// handler, served by httptest with Redis kept in memory.
+func TestWSProtocol(t *testing.T) {
+	t.Run("NoActiveSession", func(t *testing.T) {
+		srv, _, _ := newWSTestServer(t, handler.WSDuplicateReplace)
+		c := srv.dial(t, uuid.New())
+		reply := c.expectEvent(ws.EventError)
+		if !strings.Contains(reply.Error, "no active session") {
+			t.Errorf("got error %q, want no active session", reply.Error)
+		}
+		c.expectClosed()
+	})
+
+	t.Run("Ping", func(t *testing.T) {
+		srv, exam, _ := newWSTestServer(t, handler.WSDuplicateReplace)
+		c := srv.dial(t, exam.id)
+		c.send(map[string]string{"action": string(ws.ActionPing)})
+		c.expectEvent(ws.EventPong)
+	})
+
+	t.Run("Autosave", func(t *testing.T) {
+		srv, exam, store := newWSTestServer(t, handler.WSDuplicateReplace)
+		c := srv.dial(t, exam.id)
+
+		c.send(ws.AutosaveRequest{Action: ws.ActionAutosave, Answer: "A"})
+		if reply := c.expectEvent(ws.EventError); reply.Error != "q_id is required" {
+			t.Errorf("missing q_id: got error %q", reply.Error)
+		}
+
+		c.send(ws.AutosaveRequest{Action: ws.ActionAutosave, QID: "not-a-uuid", Answer: "A"})
+		if reply := c.expectEvent(ws.EventError); reply.Error != "invalid q_id format" {
+			t.Errorf("malformed q_id: got error %q", reply.Error)
+		}
+
+		foreign := uuid.NewString()
+		c.send(ws.AutosaveRequest{Action: ws.ActionAutosave, QID: foreign, Answer: "A"})
+		if reply := c.expectEvent(ws.EventError); reply.Code != ws.ErrCodeQuestionOutOfScope || reply.QID != foreign {
+			t.Errorf("foreign q_id: got %s, want %s for %s", reply.Raw, ws.ErrCodeQuestionOutOfScope, foreign)
+		}
+
+		c.send(ws.AutosaveRequest{Action: ws.ActionAutosave, QID: exam.questions[0], Answer: "Z"})
+		if reply := c.expectEvent(ws.EventError); reply.Code != ws.ErrCodeInvalidAnswer {
+			t.Errorf("unknown option: got %s, want %s", reply.Raw, ws.ErrCodeInvalidAnswer)
+		}
+
+		answersKey := config.CacheKey.StudentAnswersKey(exam.id.String(), wsStudentID)
+		c.send(ws.AutosaveRequest{Action: ws.ActionAutosave, QID: exam.questions[0], Answer: "A"})
+		if reply := c.expectEvent(ws.EventSuccess); reply.Status != "saved" {
+			t.Errorf("save: got status %q", reply.Status)
+		}
+		if got := store.hget(answersKey, exam.questions[0]); got != "A" {
+			t.Errorf("saved answer = %q, want A", got)
+		}
+		if n := store.llen(config.WorkerKey.PersistAnswersQueue); n != 1 {
+			t.Errorf("%d answer jobs queued, want 1", n)
+		}
+
+		c.send(ws.AutosaveRequest{Action: ws.ActionAutosave, QID: exam.questions[0], Answer: ""})
+		if reply := c.expectEvent(ws.EventSuccess); reply.Status != "removed" {
+			t.Errorf("clear: got status %q", reply.Status)
+		}
+		if got := store.hget(answersKey, exam.questions[0]); got != "" {
+			t.Errorf("cleared answer = %q, want none", got)
+		}
+	})
+
+	t.Run("BatchAutosave", func(t *testing.T) {
+		srv, exam, store := newWSTestServer(t, handler.WSDuplicateReplace)
+		c := srv.dial(t, exam.id)
+
+		c.send(ws.BatchAutosaveRequest{Action: ws.ActionBatchAutosave})
+		if reply := c.expectEvent(ws.EventError); reply.Error != "answers is required" {
+			t.Errorf("empty batch: got error %q", reply.Error)
+		}
+
+		// A batch with only foreign questions saves nothing.
+		foreign := ws.AutosaveItem{QID: uuid.NewString(), Answer: "A"}
+		c.send(ws.BatchAutosaveRequest{Action: ws.ActionBatchAutosave, Answers: []ws.AutosaveItem{foreign}})
+		reply := c.expectEvent(ws.EventError)
+		if reply.Status != "refused" || len(reply.Errors) != 1 || reply.Errors[0].Code != ws.ErrCodeQuestionOutOfScope {
+			t.Errorf("foreign q_id: got %s, want the batch refused", reply.Raw)
+		}
+
+		// Otherwise the valid answers are saved and the refused ones reported.
+		items := []ws.AutosaveItem{foreign}
+		for _, id := range exam.questions {
+			items = append(items, ws.AutosaveItem{QID: id, Answer: "A"})
+		}
+		c.send(ws.BatchAutosaveRequest{Action: ws.ActionBatchAutosave, Answers: items})
+		reply = c.expectEvent(ws.EventSuccess)
+		if reply.Saved != len(exam.questions) || len(reply.Errors) != 1 || reply.Errors[0].QID != foreign.QID {
+			t.Errorf("mixed batch: got %s, want %d saved and %s refused", reply.Raw, len(exam.questions), foreign.QID)
+		}
+		answersKey := config.CacheKey.StudentAnswersKey(exam.id.String(), wsStudentID)
+		for _, id := range exam.questions {
+			if got := store.hget(answersKey, id); got != "A" {
+				t.Errorf("%s: saved answer = %q, want A", id, got)
+			}
+		}
+	})
+
+	t.Run("UnknownAction", func(t *testing.T) {
+		srv, exam, _ := newWSTestServer(t, handler.WSDuplicateReplace)
+		c := srv.dial(t, exam.id)
+		c.send(map[string]string{"action": "teleport"})
+		if reply := c.expectEvent(ws.EventError); reply.Error != "unknown action: teleport" {
+			t.Errorf("got error %q", reply.Error)
+		}
+	})
+
+	t.Run("MalformedJSON", func(t *testing.T) {
+		srv, exam, _ := newWSTestServer(t, handler.WSDuplicateReplace)
+		c := srv.dial(t, exam.id)
+
+		// Malformed frames are dropped without a reply and the socket stays open: the
+		// next reply is the pong.
+		c.sendRaw(`{"action": "autosave", "q_id": `)
+		c.sendRaw(`not json at all`)
+		c.send(map[string]interface{}{"action": string(ws.ActionAutosave), "q_id": 42})
+		if reply := c.read(); reply.Event != ws.EventError || reply.Error != "invalid autosave format" {
+			t.Errorf("mistyped autosave: got %s, want invalid autosave format", reply.Raw)
+		}
+		c.send(map[string]string{"action": string(ws.ActionPing)})
+		c.expectEvent(ws.EventPong)
+	})
+
+	t.Run("DuplicateReplaced", func(t *testing.T) {
+		srv, exam, _ := newWSTestServer(t, handler.WSDuplicateReplace)
+		first := srv.dial(t, exam.id)
+		first.send(map[string]string{"action": string(ws.ActionPing)})
+		first.expectEvent(ws.EventPong)
+
+		// The newer socket replaces the older one, which is closed with 4001.
+		second := srv.dial(t, exam.id)
+		if code := first.expectClosed(); code != 4001 {
+			t.Errorf("first socket: got close code %d, want 4001", code)
+		}
+		second.send(map[string]string{"action": string(ws.ActionPing)})
+		second.expectEvent(ws.EventPong)
+	})
+
+	t.Run("DuplicateRejected", func(t *testing.T) {
+		srv, exam, _ := newWSTestServer(t, handler.WSDuplicateReject)
+		first := srv.dial(t, exam.id)
+		first.send(map[string]string{"action": string(ws.ActionPing)})
+		first.expectEvent(ws.EventPong)
+
+		second := srv.dial(t, exam.id)
+		if reply := second.expectEvent(ws.EventError); reply.Code != ws.ErrCodeAlreadyConnected {
+			t.Errorf("second socket: got %s, want %s", reply.Raw, ws.ErrCodeAlreadyConnected)
+		}
+		second.expectClosed()
+
+		// The first socket is left open.
+		first.send(map[string]string{"action": string(ws.ActionPing)})
+		first.expectEvent(ws.EventPong)
+	})
+
+	t.Run("SubmitIdempotent", func(t *testing.T) {
+		srv, exam, store := newWSTestServer(t, handler.WSDuplicateReplace)
+		c := srv.dial(t, exam.id)
+
+		// One of the two questions answered right.
+		c.send(ws.AutosaveRequest{Action: ws.ActionAutosave, QID: exam.questions[0], Answer: "A"})
+		c.expectEvent(ws.EventSuccess)
+
+		c.send(ws.SubmitRequest{Action: ws.ActionSubmit})
+		first := c.expectEvent(ws.EventGraded)
+		if first.Status != "completed" || first.Score != 50 {
+			t.Errorf("first submit: got %s, want completed with 50", first.Raw)
+		}
+
+		// A repeated submit returns the score already recorded and queues nothing.
+		c.send(ws.SubmitRequest{Action: ws.ActionSubmit})
+		second := c.expectEvent(ws.EventGraded)
+		if second.Status != "completed" || second.Score != first.Score {
+			t.Errorf("second submit: got %s, want the first score %v", second.Raw, first.Score)
+		}
+		if n := store.llen(config.WorkerKey.PersistScoresQueue); n != 1 {
+			t.Errorf("%d score jobs queued, want 1", n)
+		}
+	})
+}
+
+// wsTestServer serves the router over a real listener, as the socket needs one.
+type wsTestServer struct {
+	url   string
+	token string
+}
+
+// newWSTestServer starts the router with a WS handler whose Redis is in memory, seeded
+// with a live exam the student has joined, and whose PostgreSQL fails every query.
+func newWSTestServer(t *testing.T, duplicatePolicy string) (*wsTestServer, wsExam, *redisStore) {
+	t.Helper()
+
+	store := newRedisStore()
+	rdb := store.client()
+	t.Cleanup(func() { rdb.Close() })
+	exam := store.seedExam(t)
+
+	pool := newFakePostgres(t)
+	examRepo := repository.NewExamRepository(pool, nil)
+	targetRepo := repository.NewExamTargetRuleRepository(pool, nil)
+	jobs := queue.NewListQueue(rdb)
+	examService := service.NewExamService(examRepo, repository.NewQuestionRepository(pool), targetRepo,
+		repository.NewSettingRepository(pool), repository.NewSubjectRepository(pool), repository.NewMediaRepository(pool),
+		rdb, jobs, zerolog.Nop())
+	sessionService := service.NewExamSessionService(repository.NewExamSessionRepository(pool), examRepo, targetRepo,
+		examService, rdb, jobs, nil, zerolog.Nop())
+	studentService := service.NewStudentService(repository.NewStudentRepository(pool), nil)
+	wsHandler := handler.NewWSHandler(rdb, jobs, examService, sessionService, studentService, zerolog.Nop(),
+		nil, 0, 0, 0, duplicatePolicy)
+
+	r := newTestRouterWith(t, &Handlers{WS: wsHandler})
+	srv := httptest.NewServer(r.engine)
+	t.Cleanup(srv.Close)
+
+	return &wsTestServer{
+		url:   "ws" + strings.TrimPrefix(srv.URL, "http"),
+		token: signToken(t, service.Claims{TokenType: service.TokenTypeStudent, UserID: wsStudentID, ClassID: 1}),
+	}, exam, store
+}
+
+// wsClient is a student exam socket that reads replies one frame at a time.
+type wsClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+// wsReply is any server frame, decoded loosely so every event fits.
+type wsReply struct {
+	Event  ws.Event        `json:"event"`
+	Status string          `json:"status"`
+	Code   ws.ErrorCode    `json:"code"`
+	QID    string          `json:"q_id"`
+	Error  string          `json:"error"`
+	Score  float64         `json:"score"`
+	Saved  int             `json:"saved"`
+	Errors []wsReply       `json:"errors"`
+	Raw    json.RawMessage `json:"-"`
+}
+
+// dial opens the exam socket of examID as the test student.
+func (s *wsTestServer) dial(t *testing.T, examID uuid.UUID) *wsClient {
+	t.Helper()
+
+	url := fmt.Sprintf("%s/ws/v1/student/exams/%s/stream?token=%s", s.url, examID, s.token)
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(resp.Body)
+			t.Fatalf("dial: %v (status %d: %s)", err, resp.StatusCode, body)
+		}
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &wsClient{t: t, conn: conn}
+}
+
+func (c *wsClient) send(v interface{}) {
+	c.t.Helper()
+	if err := c.conn.WriteJSON(v); err != nil {
+		c.t.Fatalf("send: %v", err)
+	}
+}
+
+func (c *wsClient) sendRaw(s string) {
+	c.t.Helper()
+	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(s)); err != nil {
+		c.t.Fatalf("send: %v", err)
+	}
+}
+
+// read returns the next frame, skipping backpressure signals, which may come at any time.
+func (c *wsClient) read() wsReply {
+	c.t.Helper()
+	for {
+		reply, err := c.next()
+		if err != nil {
+			c.t.Fatalf("read: %v", err)
+		}
+		if reply.Event != ws.EventBackpressure {
+			return reply
+		}
+	}
+}
+
+func (c *wsClient) next() (wsReply, error) {
+	c.conn.SetReadDeadline(time.Now().Add(wsReplyTimeout))
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		return wsReply{}, err
+	}
+	var reply wsReply
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return wsReply{}, fmt.Errorf("decode %s: %w", data, err)
+	}
+	reply.Raw = data
+	return reply, nil
+}
+
+// expectEvent reads the next frame and fails unless it is event.
+func (c *wsClient) expectEvent(event ws.Event) wsReply {
+	c.t.Helper()
+	reply := c.read()
+	if reply.Event != event {
+		c.t.Fatalf("got %s, want a %s event", reply.Raw, event)
+	}
+	return reply
+}
+
+// expectClosed reads until the server closes the socket and returns the close code.
+func (c *wsClient) expectClosed() int {
+	c.t.Helper()
+	for {
+		_, err := c.next()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) {
+			return closeErr.Code
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			c.t.Fatalf("socket still open after %s", wsReplyTimeout)
+		}
+		return websocket.CloseAbnormalClosure
+	}
+}
+
+// redisStore is an in-memory Redis holding strings, hashes and lists, enough for the
+// exam socket. Expiry is ignored. Of the scripts only the compare-and-delete releasing
+// a lock is run; the others are refused, which the socket tolerates.
+type redisStore struct {
+	mu      sync.Mutex
+	strings map[string]string
+	hashes  map[string]map[string]string
+	lists   map[string][]string
+}
+
+func newRedisStore() *redisStore {
+	return &redisStore{
+		strings: make(map[string]string),
+		hashes:  make(map[string]map[string]string),
+		lists:   make(map[string][]string),
+	}
+}
+
+// client returns a client talking to the store, one connection per dial.
+func (s *redisStore) client() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Protocol:        2,
+		DisableIdentity: true,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go s.serve(server)
+			return client, nil
+		},
+	})
+}
+
+// seedExam caches a live exam's payload and answer key and joins the test student to it.
+func (s *redisStore) seedExam(t *testing.T) wsExam {
+	t.Helper()
+
+	exam := wsExam{id: uuid.New()}
+	payload := model.ExamPayload{ExamID: exam.id, Title: "Physics", Duration: 60}
+	answers := make(map[string]string)
+	for i, correct := range []string{"A", "B"} {
+		id := uuid.New()
+		payload.Questions = append(payload.Questions, model.QuestionForStudent{
+			ID:           id,
+			QuestionType: model.QuestionTypeMultipleChoice,
+			QuestionText: fmt.Sprintf("Question %d", i+1),
+			Options:      json.RawMessage(`["1", "2", "3", "4"]`),
+			OrderNum:     i + 1,
+		})
+		exam.questions = append(exam.questions, id.String())
+		answers[id.String()] = correct
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	orderJSON, _ := json.Marshal(exam.questions)
+
+	s.strings[config.CacheKey.ExamPayloadKey(exam.id.String())] = string(payloadJSON)
+	s.hashes[config.CacheKey.ExamAnswerKey(exam.id.String())] = answers
+	s.strings[config.CacheKey.StudentActiveExamKey(wsStudentID)] = exam.id.String()
+	s.strings[config.CacheKey.StudentShuffledQuestionKey(exam.id.String(), wsStudentID)] = string(orderJSON)
+	return exam
+}
+
+func (s *redisStore) hget(key, field string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hashes[key][field]
+}
+
+func (s *redisStore) llen(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.lists[key])
+}
+
+func (s *redisStore) serve(conn net.Conn) {
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+
+		var reply string
+		switch name := strings.ToUpper(args[0]); {
+		case name == "MULTI":
+			inMulti, queued = true, nil
+			reply = "+OK\r\n"
+		case name == "EXEC":
+			var b strings.Builder
+			fmt.Fprintf(&b, "*%d\r\n", len(queued))
+			for _, cmd := range queued {
+				b.WriteString(s.exec(cmd))
+			}
+			inMulti, queued = false, nil
+			reply = b.String()
+		case inMulti:
+			queued = append(queued, args)
+			reply = "+QUEUED\r\n"
+		default:
+			reply = s.exec(args)
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// exec runs one command and returns its RESP2 reply.
+func (s *redisStore) exec(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := ""
+	if len(args) > 1 {
+		key = args[1]
+	}
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		v, ok := s.strings[key]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return respBulk(v)
+	case "SET":
+		return s.set(key, args[2], args[3:])
+	case "SETNX":
+		return s.set(key, args[2], []string{"NX"})
+	case "DEL", "UNLINK":
+		n := 0
+		for _, k := range args[1:] {
+			if s.delete(k) {
+				n++
+			}
+		}
+		return respInt(n)
+	case "EXPIRE", "PEXPIRE":
+		_, str := s.strings[key]
+		_, hash := s.hashes[key]
+		_, list := s.lists[key]
+		if str || hash || list {
+			return respInt(1)
+		}
+		return respInt(0)
+	case "HSET":
+		h := s.hashes[key]
+		if h == nil {
+			h = make(map[string]string)
+			s.hashes[key] = h
+		}
+		added := 0
+		for i := 2; i+1 < len(args); i += 2 {
+			if _, ok := h[args[i]]; !ok {
+				added++
+			}
+			h[args[i]] = args[i+1]
+		}
+		return respInt(added)
+	case "HGET":
+		v, ok := s.hashes[key][args[2]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return respBulk(v)
+	case "HGETALL":
+		h := s.hashes[key]
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%d\r\n", 2*len(h))
+		for f, v := range h {
+			b.WriteString(respBulk(f))
+			b.WriteString(respBulk(v))
+		}
+		return b.String()
+	case "HDEL":
+		n := 0
+		for _, f := range args[2:] {
+			if _, ok := s.hashes[key][f]; ok {
+				delete(s.hashes[key], f)
+				n++
+			}
+		}
+		return respInt(n)
+	case "HINCRBY":
+		h := s.hashes[key]
+		if h == nil {
+			h = make(map[string]string)
+			s.hashes[key] = h
+		}
+		cur, _ := strconv.Atoi(h[args[2]])
+		by, _ := strconv.Atoi(args[3])
+		h[args[2]] = strconv.Itoa(cur + by)
+		return respInt(cur + by)
+	case "RPUSH":
+		s.lists[key] = append(s.lists[key], args[2:]...)
+		return respInt(len(s.lists[key]))
+	case "LLEN":
+		return respInt(len(s.lists[key]))
+	case "PUBLISH":
+		return respInt(0)
+	case "EVALSHA":
+		// Makes the client send the script itself.
+		return "-NOSCRIPT No matching script\r\n"
+	case "EVAL":
+		if !strings.HasPrefix(strings.TrimSpace(args[1]), releaseScriptPrefix) || len(args) < 5 {
+			return "-ERR fake redis runs no such script\r\n"
+		}
+		if v, ok := s.strings[args[3]]; !ok || v != args[4] {
+			return respInt(0)
+		}
+		delete(s.strings, args[3])
+		return respInt(1)
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// set implements SET with the NX and GET options; expiry options are accepted and ignored.
+func (s *redisStore) set(key, value string, opts []string) string {
+	old, exists := s.strings[key]
+	nx, get := false, false
+	for _, opt := range opts {
+		switch strings.ToUpper(opt) {
+		case "NX":
+			nx = true
+		case "GET":
+			get = true
+		}
+	}
+	if nx && exists {
+		return "$-1\r\n"
+	}
+	s.delete(key)
+	s.strings[key] = value
+	switch {
+	case !get:
+		return "+OK\r\n"
+	case exists:
+		return respBulk(old)
+	default:
+		return "$-1\r\n"
+	}
+}
+
+func (s *redisStore) delete(key string) bool {
+	_, str := s.strings[key]
+	_, hash := s.hashes[key]
+	_, list := s.lists[key]
+	delete(s.strings, key)
+	delete(s.hashes, key)
+	delete(s.lists, key)
+	return str || hash || list
+}
+
+func respBulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func respInt(n int) string {
+	return fmt.Sprintf(":%d\r\n", n)
+}
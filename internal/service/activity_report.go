@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// ActivityReport summarises the exams held in [from, to): those with sessions started
+// in the period, their participants and the integrity incidents recorded, with the
+// server's uptime from the metrics history. Without a period it covers the current
+// semester.
+func (s *MonitorService) ActivityReport(ctx context.Context, from, to time.Time) (*model.ActivityReport, error) {
+	if from.IsZero() || to.IsZero() {
+		from, to = academicTerm(time.Now())
+	}
+
+	exams, err := s.monitorRepo.ListActivityExams(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("list activity exams: %w", err)
+	}
+	participants, flagged, err := s.monitorRepo.GetActivityTotals(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("get activity totals: %w", err)
+	}
+	uptime, err := s.metricsService.Uptime(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("get uptime: %w", err)
+	}
+
+	report := &model.ActivityReport{
+		From:            from.Format("2006-01-02"),
+		To:              to.AddDate(0, 0, -1).Format("2006-01-02"),
+		ExamsHeld:       len(exams),
+		Participants:    participants,
+		FlaggedSessions: flagged,
+		UptimePercent:   uptime,
+		Exams:           exams,
+	}
+	for _, e := range exams {
+		report.Sessions += e.Participants
+		report.CompletedSessions += e.Completed
+		report.Incidents += e.Incidents
+	}
+	if report.ExamsHeld > 0 {
+		report.IncidentsPerExam = math.Round(float64(report.Incidents)/float64(report.ExamsHeld)*100) / 100
+	}
+	if report.Sessions > 0 {
+		report.IncidentsPerSession = math.Round(float64(report.Incidents)/float64(report.Sessions)*100) / 100
+	}
+	return report, nil
+}
+
+// ActivityReportPDF renders an activity report with the school's branding.
+func (s *MonitorService) ActivityReportPDF(ctx context.Context, report *model.ActivityReport) ([]byte, error) {
+	var school SchoolInfo
+	if setting, err := s.settingRepo.GetByKey(ctx, "school_name"); err == nil {
+		school.Name = setting.Value
+	}
+	if setting, err := s.settingRepo.GetByKey(ctx, "school_logo_url"); err == nil {
+		school.LogoURL = setting.Value
+	}
+	return GenerateActivityReportPDF(report, school)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/signintech/gopdf"
+	"github.com/stemsi/exstem-backend/internal/assets/fonts"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// Layout constants of the activity report — millimeters. The table rows and cells
+// are those of the attendance sheet.
+const (
+	actHeaderHMM   = 30.0 // height of the report header (school, title, period)
+	actSummaryHMM  = 48.0 // height of the summary figures
+	actFooterHMM   = 36.0 // space the signature needs
+	actLogoSizeMM  = 16.0
+	actSummaryRowH = 6.0
+)
+
+// actColumn is one column of the activity report's table of exams.
+type actColumn struct {
+	title string
+	width float64 // mm
+	value func(i int, e model.ActivityReportExam) string
+}
+
+// activityColumns lists the table columns, which add up to the usable page width.
+var activityColumns = []actColumn{
+	{title: "NO", width: 10, value: func(i int, _ model.ActivityReportExam) string { return strconv.Itoa(i + 1) }},
+	{title: "TANGGAL", width: 24, value: func(_ int, e model.ActivityReportExam) string { return e.HeldAt.Local().Format("02/01/2006") }},
+	{title: "UJIAN", width: 70, value: func(_ int, e model.ActivityReportExam) string { return e.Title }},
+	{title: "PESERTA", width: 20, value: func(_ int, e model.ActivityReportExam) string { return strconv.Itoa(e.Participants) }},
+	{title: "SELESAI", width: 20, value: func(_ int, e model.ActivityReportExam) string { return strconv.Itoa(e.Completed) }},
+	{title: "INSIDEN", width: 20, value: func(_ int, e model.ActivityReportExam) string { return strconv.Itoa(e.Incidents) }},
+	{title: "RATA-RATA", width: 22, value: func(_ int, e model.ActivityReportExam) string {
+		if e.AverageScore == nil {
+			return "-"
+		}
+		return strconv.FormatFloat(*e.AverageScore, 'f', 2, 64)
+	}},
+}
+
+// GenerateActivityReportPDF builds an A4 activity report: the period's figures, a
+// table of the exams held and a space for the principal's signature. Returns the raw
+// PDF bytes.
+func GenerateActivityReportPDF(report *model.ActivityReport, school SchoolInfo) ([]byte, error) {
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+
+	for name, file := range map[string]string{
+		fontRegular: "Roboto-Regular.ttf",
+		fontBold:    "Roboto-Bold.ttf",
+	} {
+		fontBytes, err := fonts.FS.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read embedded font %s: %w", file, err)
+		}
+		if err := pdf.AddTTFFontData(name, fontBytes); err != nil {
+			return nil, fmt.Errorf("load font %s: %w", name, err)
+		}
+	}
+
+	logoBytes, _ := loadLogoAsJPEG(resolveLogoPath(school.LogoURL))
+	bottom := pdfPageHeightMM - pdfPageMarginMM
+
+	pdf.AddPage()
+	y := drawActivityHeader(pdf, report, school.Name, logoBytes)
+	y = drawActivitySummary(pdf, report, y)
+	y = drawActivityTableHeader(pdf, y)
+	for i, e := range report.Exams {
+		if y+attRowHMM > bottom {
+			pdf.AddPage()
+			y = drawActivityTableHeader(pdf, pdfPageMarginMM)
+		}
+		drawActivityRow(pdf, i, e, y)
+		y += attRowHMM
+	}
+
+	if y+actFooterHMM > bottom {
+		pdf.AddPage()
+		y = pdfPageMarginMM
+	}
+	drawActivityFooter(pdf, y+8)
+
+	var buf bytes.Buffer
+	if _, err := pdf.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("write pdf: %w", err)
+	}
+	pdf.Close()
+
+	return buf.Bytes(), nil
+}
+
+// drawActivityHeader renders the school, title and period at the top of the report
+// and returns the Y (mm) below it.
+func drawActivityHeader(pdf *gopdf.GoPdf, report *model.ActivityReport, schoolName string, logoBytes []byte) float64 {
+	x, y := pdfPageMarginMM, pdfPageMarginMM
+	textX := x
+	if len(logoBytes) > 0 {
+		if holder, err := gopdf.ImageHolderByBytes(logoBytes); err == nil {
+			size := mmToPt(actLogoSizeMM)
+			if err := pdf.ImageByHolder(holder, mmToPt(x), mmToPt(y), &gopdf.Rect{W: size, H: size}); err == nil {
+				textX += actLogoSizeMM + 4
+			}
+		}
+	}
+
+	line := func(text, font string, size, atMM float64) {
+		_ = pdf.SetFont(font, "", size)
+		pdf.SetTextColor(30, 40, 50)
+		pdf.SetXY(mmToPt(textX), mmToPt(atMM))
+		pdf.Text(text)
+	}
+	if schoolName != "" {
+		line(strings.ToUpper(schoolName), fontBold, 10, y+3)
+	}
+	line("LAPORAN KEGIATAN UJIAN BERBASIS KOMPUTER", fontBold, 13, y+9)
+	line(fmt.Sprintf("Periode: %s s.d. %s", formatReportDate(report.From), formatReportDate(report.To)), fontRegular, 10, y+15)
+
+	pdf.SetStrokeColor(60, 70, 80)
+	pdf.SetLineWidth(0.8)
+	pdf.Line(mmToPt(x), mmToPt(y+actHeaderHMM-4), mmToPt(pdfPageWidthMM-pdfPageMarginMM), mmToPt(y+actHeaderHMM-4))
+
+	return y + actHeaderHMM
+}
+
+// drawActivitySummary renders the period's figures at yMM and returns the Y (mm)
+// below them.
+func drawActivitySummary(pdf *gopdf.GoPdf, report *model.ActivityReport, yMM float64) float64 {
+	_ = pdf.SetFont(fontRegular, "", 9)
+	pdf.SetTextColor(30, 40, 50)
+	for i, text := range []string{
+		fmt.Sprintf("Ujian terlaksana                : %d", report.ExamsHeld),
+		fmt.Sprintf("Peserta (siswa)                 : %d", report.Participants),
+		fmt.Sprintf("Sesi ujian (selesai)            : %d (%d)", report.Sessions, report.CompletedSessions),
+		fmt.Sprintf("Insiden integritas              : %d pada %d sesi", report.Incidents, report.FlaggedSessions),
+		fmt.Sprintf("Rata-rata insiden per ujian     : %.2f", report.IncidentsPerExam),
+		fmt.Sprintf("Rata-rata insiden per sesi      : %.2f", report.IncidentsPerSession),
+		"Ketersediaan server (uptime)    : " + formatUptime(report.UptimePercent),
+	} {
+		pdf.SetXY(mmToPt(pdfPageMarginMM), mmToPt(yMM+float64(i)*actSummaryRowH))
+		pdf.Text(text)
+	}
+	return yMM + actSummaryHMM
+}
+
+// drawActivityTableHeader renders the shaded column titles at yMM and returns the Y
+// (mm) of the first row.
+func drawActivityTableHeader(pdf *gopdf.GoPdf, yMM float64) float64 {
+	x := pdfPageMarginMM
+	pdf.SetFillColor(235, 238, 242)
+	pdf.SetStrokeColor(120, 130, 140)
+	pdf.SetLineWidth(0.5)
+	for _, col := range activityColumns {
+		pdf.RectFromUpperLeftWithStyle(mmToPt(x), mmToPt(yMM), mmToPt(col.width), mmToPt(attRowHMM), "FD")
+		_ = pdf.SetFont(fontBold, "", attHeaderFontPt)
+		pdf.SetTextColor(30, 40, 50)
+		drawAttendanceCell(pdf, x, yMM, col.width, col.title, gopdf.Center|gopdf.Middle)
+		x += col.width
+	}
+	return yMM + attRowHMM
+}
+
+// drawActivityRow renders the i-th exam of the report at yMM.
+func drawActivityRow(pdf *gopdf.GoPdf, i int, e model.ActivityReportExam, yMM float64) {
+	x := pdfPageMarginMM
+	pdf.SetStrokeColor(120, 130, 140)
+	pdf.SetLineWidth(0.5)
+	for c, col := range activityColumns {
+		pdf.RectFromUpperLeftWithStyle(mmToPt(x), mmToPt(yMM), mmToPt(col.width), mmToPt(attRowHMM), "D")
+		_ = pdf.SetFont(fontRegular, "", attTableFontPt)
+		pdf.SetTextColor(30, 40, 50)
+		align := gopdf.Right | gopdf.Middle
+		switch {
+		case c == 0:
+			align = gopdf.Center | gopdf.Middle
+		case c <= 2:
+			align = gopdf.Left | gopdf.Middle
+		}
+		drawAttendanceCell(pdf, x, yMM, col.width, col.value(i, e), align)
+		x += col.width
+	}
+}
+
+// drawActivityFooter renders the date of the report and the principal's signature
+// space at yMM.
+func drawActivityFooter(pdf *gopdf.GoPdf, yMM float64) {
+	_ = pdf.SetFont(fontRegular, "", 9)
+	pdf.SetTextColor(30, 40, 50)
+	signX := pdfPageWidthMM - pdfPageMarginMM - 60
+	pdf.SetXY(mmToPt(signX), mmToPt(yMM))
+	pdf.Text("Dicetak " + time.Now().Format("02/01/2006"))
+	pdf.SetXY(mmToPt(signX), mmToPt(yMM+5))
+	pdf.Text("Kepala Sekolah,")
+	pdf.SetXY(mmToPt(signX), mmToPt(yMM+30))
+	pdf.Text("(..........................................)")
+}
+
+// formatUptime formats the uptime of a report, which is unknown without metrics history.
+func formatUptime(uptime *float64) string {
+	if uptime == nil {
+		return "tidak tercatat"
+	}
+	return fmt.Sprintf("%.2f%%", *uptime)
+}
+
+// formatReportDate turns a YYYY-MM-DD date into DD/MM/YYYY.
+func formatReportDate(date string) string {
+	if t, err := time.Parse("2006-01-02", date); err == nil {
+		return t.Format("02/01/2006")
+	}
+	return date
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/stemsi/exstem-backend/internal/model"
 	"github.com/stemsi/exstem-backend/internal/repository"
@@ -18,6 +19,30 @@ func NewAdminRoleService(roleRepo *repository.RoleRepository) *AdminRoleService
 	return &AdminRoleService{roleRepo: roleRepo}
 }
 
+// ErrInvalidScope is returned when a subject scope names a permission that cannot be
+// scoped or that the role does not have.
+var ErrInvalidScope = errors.New("invalid subject scope")
+
+// ErrSystemRole is returned when changing or deleting the built-in Superadmin role.
+var ErrSystemRole = errors.New("the system Superadmin role cannot be changed")
+
+// validateScopes checks that every scoped permission is scopable and granted to the role.
+func validateScopes(permissions []string, scopes map[string][]int) error {
+	granted := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		granted[p] = true
+	}
+	for code := range scopes {
+		if !model.IsScopable(code) {
+			return fmt.Errorf("%w: %s cannot be limited to subjects", ErrInvalidScope, code)
+		}
+		if !granted[code] {
+			return fmt.Errorf("%w: role does not have %s", ErrInvalidScope, code)
+		}
+	}
+	return nil
+}
+
 // ListRoles retrieves all roles with their permissions.
 func (s *AdminRoleService) ListRoles(ctx context.Context) ([]model.RoleWithPermissions, error) {
 	return s.roleRepo.ListRolesWithPermissions(ctx)
@@ -28,11 +53,14 @@ func (s *AdminRoleService) GetRoleByID(ctx context.Context, id int) (*model.Role
 	return s.roleRepo.GetRoleByID(ctx, id)
 }
 
-// CreateRole creates a new role and assigns its permissions.
-func (s *AdminRoleService) CreateRole(ctx context.Context, name string, permissions []string) (*model.RoleWithPermissions, error) {
+// CreateRole creates a new role and assigns its permissions and subject scopes.
+func (s *AdminRoleService) CreateRole(ctx context.Context, name string, permissions []string, scopes map[string][]int) (*model.RoleWithPermissions, error) {
 	if name == "" {
 		return nil, errors.New("role name cannot be empty")
 	}
+	if err := validateScopes(permissions, scopes); err != nil {
+		return nil, err
+	}
 
 	// 1. Create Role
 	id, err := s.roleRepo.CreateRole(ctx, name)
@@ -53,17 +81,28 @@ func (s *AdminRoleService) CreateRole(ctx context.Context, name string, permissi
 		}
 	}
 
+	// 3. Assign Subject Scopes
+	if len(scopes) > 0 {
+		if err := s.roleRepo.ReplaceSubjectScopes(ctx, id, scopes); err != nil {
+			_ = s.roleRepo.DeleteRole(ctx, id)
+			return nil, err
+		}
+	}
+
 	return s.GetRoleByID(ctx, id)
 }
 
-// UpdateRole updates a role's name and permissions.
-func (s *AdminRoleService) UpdateRole(ctx context.Context, id int, name string, permissions []string) (*model.RoleWithPermissions, error) {
+// UpdateRole updates a role's name, permissions and subject scopes.
+func (s *AdminRoleService) UpdateRole(ctx context.Context, id int, name string, permissions []string, scopes map[string][]int) (*model.RoleWithPermissions, error) {
 	if id == 1 {
-		return nil, errors.New("cannot update system Superadmin role")
+		return nil, ErrSystemRole
 	}
 	if name == "" {
 		return nil, errors.New("role name cannot be empty")
 	}
+	if err := validateScopes(permissions, scopes); err != nil {
+		return nil, err
+	}
 
 	// 1. Update Role Name
 	err := s.roleRepo.UpdateRole(ctx, id, name)
@@ -86,13 +125,18 @@ func (s *AdminRoleService) UpdateRole(ctx context.Context, id int, name string,
 		}
 	}
 
+	// 3. Update Subject Scopes (Replace all)
+	if err := s.roleRepo.ReplaceSubjectScopes(ctx, id, scopes); err != nil {
+		return nil, err
+	}
+
 	return s.GetRoleByID(ctx, id)
 }
 
 // DeleteRole deletes a role.
 func (s *AdminRoleService) DeleteRole(ctx context.Context, id int) error {
 	if id == 1 {
-		return errors.New("cannot delete system Superadmin role")
+		return ErrSystemRole
 	}
 	// Note: Role usage check is handled by DB foreign key constraints.
 	// If a user has this role, deletion will fail at DB level.
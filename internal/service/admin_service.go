@@ -38,6 +38,11 @@ func (s *AdminService) GetPermissions(ctx context.Context, roleID int) ([]string
 	return s.roleRepo.GetPermissionsByRoleID(ctx, roleID)
 }
 
+// GetSubjectScopes retrieves the subject IDs each scoped permission of an admin's role is limited to.
+func (s *AdminService) GetSubjectScopes(ctx context.Context, roleID int) (map[string][]int, error) {
+	return s.roleRepo.GetSubjectScopesByRoleID(ctx, roleID)
+}
+
 // Create creates a new admin.
 func (s *AdminService) Create(ctx context.Context, admin *model.Admin) error {
 	return s.adminRepo.Create(ctx, admin)
@@ -10,12 +10,19 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Admin user errors.
+var (
+	ErrAdminNotFound = errors.New("admin not found")
+	ErrAdminExists   = errors.New("email or username already registered")
+)
+
 type AdminUserService struct {
-	pool *pgxpool.Pool
+	pool        *pgxpool.Pool
+	authService *AuthService
 }
 
-func NewAdminUserService(pool *pgxpool.Pool) *AdminUserService {
-	return &AdminUserService{pool: pool}
+func NewAdminUserService(pool *pgxpool.Pool, authService *AuthService) *AdminUserService {
+	return &AdminUserService{pool: pool, authService: authService}
 }
 
 // ListAdmins retrieves a paginated list of admins.
@@ -103,6 +110,10 @@ func (s *AdminUserService) ListAdmins(ctx context.Context, roleID, page, perPage
 
 // CreateAdmin creates a new admin user.
 func (s *AdminUserService) CreateAdmin(ctx context.Context, username, email, name, password string, roleID int) (*model.Admin, error) {
+	if err := s.authService.CheckPasswordPolicy(password, username, email); err != nil {
+		return nil, err
+	}
+
 	// Check if email or username exists
 	var exists bool
 	err := s.pool.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM admins WHERE email = $1 OR username = $2)", email, username).Scan(&exists)
@@ -110,7 +121,7 @@ func (s *AdminUserService) CreateAdmin(ctx context.Context, username, email, nam
 		return nil, err
 	}
 	if exists {
-		return nil, errors.New("email or username already registered")
+		return nil, ErrAdminExists
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -153,7 +164,7 @@ func (s *AdminUserService) UpdateAdmin(ctx context.Context, id int, username, em
 		return nil, err
 	}
 	if !exists {
-		return nil, errors.New("admin not found")
+		return nil, ErrAdminNotFound
 	}
 
 	// Check email uniqueness if changed
@@ -163,11 +174,14 @@ func (s *AdminUserService) UpdateAdmin(ctx context.Context, id int, username, em
 		return nil, err
 	}
 	if emailExists {
-		return nil, errors.New("email or username already registered")
+		return nil, ErrAdminExists
 	}
 
 	var errUpdate error
 	if password != "" {
+		if err := s.authService.CheckPasswordPolicy(password, username, email); err != nil {
+			return nil, err
+		}
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 		if err != nil {
 			return nil, err
@@ -208,7 +222,7 @@ func (s *AdminUserService) DeleteAdmin(ctx context.Context, id int) error {
 		return err
 	}
 	if res.RowsAffected() == 0 {
-		return errors.New("admin not found")
+		return ErrAdminNotFound
 	}
 	return nil
 }
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// MaxAnswerLength bounds a stored answer in characters. Only essays come close.
+const MaxAnswerLength = 10000
+
+// ErrInvalidAnswer is returned when an answer does not fit its question.
+var ErrInvalidAnswer = errors.New("invalid answer")
+
+// AnswerRule describes the answers a question accepts.
+type AnswerRule struct {
+	Type model.QuestionType
+	// Choices holds the accepted multiple-choice answers, upper-cased.
+	// Nil means the options could not be read and any short answer is accepted.
+	Choices map[string]struct{}
+}
+
+// AnswerRulesFor builds the answer rule of every question in an exam payload.
+// Payloads cached before question types were included have no type; questions
+// with readable options are then treated as multiple choice.
+func AnswerRulesFor(questions []model.QuestionForStudent) map[string]AnswerRule {
+	rules := make(map[string]AnswerRule, len(questions))
+	for _, q := range questions {
+		rule := AnswerRule{Type: q.QuestionType}
+		if q.QuestionType == model.QuestionTypeMultipleChoice || q.QuestionType == "" {
+			rule.Choices = optionChoices(q.Options)
+			if rule.Type == "" && len(rule.Choices) > 0 {
+				rule.Type = model.QuestionTypeMultipleChoice
+			}
+		}
+		rules[q.ID.String()] = rule
+	}
+	return rules
+}
+
+// Validate checks an answer against the rule. An empty answer clears the
+// question and is always valid.
+func (r AnswerRule) Validate(answer string) error {
+	if answer == "" {
+		return nil
+	}
+	if n := utf8.RuneCountInString(answer); n > MaxAnswerLength {
+		return fmt.Errorf("%w: answer is %d characters, the limit is %d", ErrInvalidAnswer, n, MaxAnswerLength)
+	}
+
+	switch r.Type {
+	case model.QuestionTypeTrueFalse:
+		if !isTrueFalseAnswer(answer) {
+			return fmt.Errorf("%w: answer must be true or false", ErrInvalidAnswer)
+		}
+	case model.QuestionTypeMultipleChoice:
+		if r.Choices == nil {
+			return nil
+		}
+		if _, ok := r.Choices[strings.ToUpper(answer)]; !ok {
+			return fmt.Errorf("%w: %q is not one of the question's options", ErrInvalidAnswer, answer)
+		}
+	}
+	return nil
+}
+
+// AnswerMatches reports whether a student's answer is the correct one. Option keys
+// compare case-insensitively, and a 0-based index (sent for a payload cached before
+// options were keyed) counts as the letter at that position.
+func AnswerMatches(answer, correct string) bool {
+	return gradingKey(answer) == gradingKey(correct)
+}
+
+// GradeAnswers scores a student's answers out of 100 against the questions of their
+// subset, in orderedIDs. Questions missing from the answer key count as wrong.
+func GradeAnswers(answerKey, studentAnswers map[string]string, orderedIDs []string) float64 {
+	if len(orderedIDs) == 0 {
+		return 0
+	}
+	correct := 0
+	for _, qID := range orderedIDs {
+		if correctAns, exists := answerKey[qID]; exists {
+			if studentAns, answered := studentAnswers[qID]; answered && AnswerMatches(studentAns, correctAns) {
+				correct++
+			}
+		}
+	}
+	return (float64(correct) / float64(len(orderedIDs))) * 100
+}
+
+// gradingKey is the form answers are compared in: upper-cased, with an index
+// replaced by its option letter.
+func gradingKey(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if idx, err := strconv.Atoi(s); err == nil && idx >= 0 && idx < 26 {
+		return string(rune('A' + idx))
+	}
+	return s
+}
+
+// optionChoices lists the answers a multiple-choice question accepts, mirroring
+// hasValidCorrectOption: option keys for keyed options, and for plain string
+// options their 0-based index or letter.
+func optionChoices(options json.RawMessage) map[string]struct{} {
+	var keyed []model.QuestionOption
+	if err := json.Unmarshal(options, &keyed); err == nil && len(keyed) > 0 && keyed[0].Key != "" {
+		choices := make(map[string]struct{}, len(keyed))
+		for _, o := range keyed {
+			choices[strings.ToUpper(o.Key)] = struct{}{}
+		}
+		return choices
+	}
+
+	var plain []string
+	if err := json.Unmarshal(options, &plain); err == nil && len(plain) > 0 {
+		choices := make(map[string]struct{}, len(plain)*2)
+		for i := range plain {
+			choices[strconv.Itoa(i)] = struct{}{}
+			if i < 26 {
+				choices[string(rune('A'+i))] = struct{}{}
+			}
+		}
+		return choices
+	}
+	return nil
+}
+
+// isTrueFalseAnswer reports whether s is a true/false answer as stored: "true" or "false".
+func isTrueFalseAnswer(s string) bool {
+	return s == "true" || s == "false"
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+)
+
+// ErrExamInOtherAssessment is returned when an exam added to an assessment already
+// belongs to another one.
+var ErrExamInOtherAssessment = errors.New("exam belongs to another assessment")
+
+// AssessmentService manages assessments, the groups of exams given together, and
+// reports on them.
+type AssessmentService struct {
+	assessmentRepo *repository.AssessmentRepository
+	examRepo       *repository.ExamRepository
+	targetRepo     *repository.ExamTargetRuleRepository
+	sessionRepo    *repository.ExamSessionRepository
+	examService    *ExamService
+}
+
+// NewAssessmentService creates a new AssessmentService.
+func NewAssessmentService(
+	assessmentRepo *repository.AssessmentRepository,
+	examRepo *repository.ExamRepository,
+	targetRepo *repository.ExamTargetRuleRepository,
+	sessionRepo *repository.ExamSessionRepository,
+	examService *ExamService,
+) *AssessmentService {
+	return &AssessmentService{
+		assessmentRepo: assessmentRepo,
+		examRepo:       examRepo,
+		targetRepo:     targetRepo,
+		sessionRepo:    sessionRepo,
+		examService:    examService,
+	}
+}
+
+// Create inserts a new assessment without exams.
+func (s *AssessmentService) Create(ctx context.Context, a *model.Assessment) error {
+	if err := s.assessmentRepo.Create(ctx, a); err != nil {
+		return err
+	}
+	a.TargetRules = []model.AddTargetRuleRequest{}
+	return nil
+}
+
+// List returns every assessment with its number of exams.
+func (s *AssessmentService) List(ctx context.Context) ([]model.Assessment, error) {
+	return s.assessmentRepo.List(ctx)
+}
+
+// Get returns an assessment with its exams.
+func (s *AssessmentService) Get(ctx context.Context, id uuid.UUID) (*model.Assessment, error) {
+	a, err := s.assessmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.Exams, err = s.assessmentRepo.ListExams(ctx, id); err != nil {
+		return nil, fmt.Errorf("list assessment exams: %w", err)
+	}
+	return a, nil
+}
+
+// Update modifies an assessment's title and description.
+func (s *AssessmentService) Update(ctx context.Context, a *model.Assessment) error {
+	return s.assessmentRepo.Update(ctx, a)
+}
+
+// Delete removes an assessment. Its exams are kept, with their target rules.
+func (s *AssessmentService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.assessmentRepo.Delete(ctx, id)
+}
+
+// SetExams makes examIDs the exams of an assessment. Each exam added or removed must
+// be within the caller's subject scope, and an exam cannot belong to two assessments.
+// Exams joining get the assessment's target rules, if it has any; the schedule
+// conflicts that introduces are reported.
+func (s *AssessmentService) SetExams(ctx context.Context, id uuid.UUID, examIDs []uuid.UUID) (*model.Assessment, []model.ExamConflict, error) {
+	a, err := s.assessmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	current, err := s.assessmentRepo.ListExams(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wanted := make(map[uuid.UUID]bool, len(examIDs))
+	for _, examID := range examIDs {
+		wanted[examID] = true
+	}
+	for _, e := range current {
+		if !wanted[e.ID] {
+			if err := s.examService.checkExamIDScope(ctx, e.ID, model.PermissionExamsWrite); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	var joining []*model.Exam
+	for examID := range wanted {
+		exam, err := s.examRepo.GetByID(ctx, examID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get exam %s: %w", examID, err)
+		}
+		if exam.AssessmentID != nil && *exam.AssessmentID != id {
+			return nil, nil, ErrExamInOtherAssessment
+		}
+		if err := s.examService.checkExamScope(ctx, exam.QBankID, model.PermissionExamsWrite); err != nil {
+			return nil, nil, err
+		}
+		if exam.AssessmentID == nil {
+			joining = append(joining, exam)
+		}
+	}
+
+	// Joining exams swap their rules for the assessment's.
+	conflicts, err := s.examService.checkReplacedRulesConflicts(ctx, joining, a.TargetRules)
+	if err != nil {
+		return nil, conflicts, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(wanted))
+	for examID := range wanted {
+		ids = append(ids, examID)
+	}
+	if err := s.assessmentRepo.SetExams(ctx, id, ids); err != nil {
+		return nil, nil, err
+	}
+	a, err = s.Get(ctx, id)
+	return a, conflicts, err
+}
+
+// SetTargetRules makes rules the target rules of an assessment and of each of its
+// exams, replacing the rules the exams had, and reports the schedule conflicts that
+// introduces. Rules must not repeat.
+func (s *AssessmentService) SetTargetRules(ctx context.Context, id uuid.UUID, rules []model.AddTargetRuleRequest) (*model.Assessment, []model.ExamConflict, error) {
+	for i := range rules {
+		for j := range i {
+			if reflect.DeepEqual(rules[i], rules[j]) {
+				return nil, nil, ErrDuplicateTarget
+			}
+		}
+	}
+	if rules == nil {
+		rules = []model.AddTargetRuleRequest{}
+	}
+
+	if _, err := s.assessmentRepo.GetByID(ctx, id); err != nil {
+		return nil, nil, err
+	}
+	members, err := s.assessmentRepo.ListExams(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	exams := make([]*model.Exam, 0, len(members))
+	for _, e := range members {
+		exam, err := s.examRepo.GetByID(ctx, e.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get exam %s: %w", e.ID, err)
+		}
+		if err := s.examService.checkExamScope(ctx, exam.QBankID, model.PermissionExamsWrite); err != nil {
+			return nil, nil, err
+		}
+		exams = append(exams, exam)
+	}
+
+	conflicts, err := s.examService.checkReplacedRulesConflicts(ctx, exams, rules)
+	if err != nil {
+		return nil, conflicts, err
+	}
+
+	if err := s.assessmentRepo.SetTargetRules(ctx, id, rules); err != nil {
+		return nil, nil, err
+	}
+	a, err := s.Get(ctx, id)
+	return a, conflicts, err
+}
+
+// Report combines the results of an assessment's exams, one row per student who
+// joined any of them, optionally of one class. A student's average is over the
+// exams they completed.
+func (s *AssessmentService) Report(ctx context.Context, id uuid.UUID, classID *int) (*model.AssessmentReport, error) {
+	a, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	scores, err := s.assessmentRepo.ListScores(ctx, id, classID)
+	if err != nil {
+		return nil, fmt.Errorf("list assessment scores: %w", err)
+	}
+
+	column := make(map[uuid.UUID]int, len(a.Exams))
+	for i, e := range a.Exams {
+		column[e.ID] = i
+	}
+
+	report := &model.AssessmentReport{
+		AssessmentID: a.ID,
+		Title:        a.Title,
+		Exams:        a.Exams,
+		Students:     []model.AssessmentReportRow{},
+	}
+	rowOf := make(map[int]int)
+	for _, sc := range scores {
+		i, ok := rowOf[sc.StudentID]
+		if !ok {
+			i = len(report.Students)
+			rowOf[sc.StudentID] = i
+			report.Students = append(report.Students, model.AssessmentReportRow{
+				StudentID: sc.StudentID,
+				NISN:      sc.NISN,
+				Name:      sc.Name,
+				ClassName: sc.ClassName,
+				Scores:    make([]*float64, len(a.Exams)),
+			})
+		}
+		if sc.Status == model.SessionStatusCompleted && sc.FinalScore != nil {
+			report.Students[i].Scores[column[sc.ExamID]] = sc.FinalScore
+		}
+	}
+
+	for i := range report.Students {
+		row := &report.Students[i]
+		var sum float64
+		for _, score := range row.Scores {
+			if score != nil {
+				sum += *score
+				row.Completed++
+			}
+		}
+		if row.Completed > 0 {
+			avg := math.Round(sum/float64(row.Completed)*100) / 100
+			row.Average = &avg
+		}
+	}
+	return report, nil
+}
+
+// LobbyAssessment is an assessment as a section of the student lobby, with the
+// student's exams of it.
+type LobbyAssessment struct {
+	ID          uuid.UUID   `json:"id"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Completed   int         `json:"completed"`
+	Exams       []LobbyExam `json:"exams"`
+}
+
+// StudentLobby returns the assessments with live exams targeted at a student, each
+// with all such exams in schedule order, not only today's as in the lobby.
+func (s *AssessmentService) StudentLobby(ctx context.Context, studentID, classID int) ([]LobbyAssessment, error) {
+	examIDs, err := s.targetRepo.FindExamsForStudent(ctx, studentID, classID)
+	if err != nil {
+		return nil, fmt.Errorf("find exams for student: %w", err)
+	}
+	sessions, err := s.sessionRepo.ListByStudent(ctx, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	sessionMap := make(map[uuid.UUID]*model.ExamSession, len(sessions))
+	for i := range sessions {
+		sessionMap[sessions[i].ExamID] = &sessions[i]
+	}
+
+	now := time.Now()
+	sections := []LobbyAssessment{}
+	sectionOf := make(map[uuid.UUID]int)
+	for _, eid := range examIDs {
+		exam, err := s.examRepo.GetByID(ctx, eid)
+		if err != nil || exam.AssessmentID == nil || !exam.Status.IsLive() {
+			continue
+		}
+
+		i, ok := sectionOf[*exam.AssessmentID]
+		if !ok {
+			a, err := s.assessmentRepo.GetByID(ctx, *exam.AssessmentID)
+			if err != nil {
+				continue
+			}
+			i = len(sections)
+			sectionOf[a.ID] = i
+			sections = append(sections, LobbyAssessment{ID: a.ID, Title: a.Title, Description: a.Description})
+		}
+
+		entry := newLobbyExam(exam, sessionMap[eid], now)
+		if entry.LobbyStatus == LobbyStatusCompleted {
+			sections[i].Completed++
+		}
+		sections[i].Exams = append(sections[i].Exams, entry)
+	}
+
+	for i := range sections {
+		sortLobbyBySchedule(sections[i].Exams)
+	}
+	return sections, nil
+}
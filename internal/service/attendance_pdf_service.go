@@ -0,0 +1,275 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/signintech/gopdf"
+	"github.com/stemsi/exstem-backend/internal/assets/fonts"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// ---------------------------------------------------------------------------
+// Layout constants — millimeters unless noted otherwise. The page, margin and
+// font settings are shared with the student card PDF.
+// ---------------------------------------------------------------------------
+
+const (
+	attHeaderHMM    = 30.0 // height of the sheet header (school, title, exam, group)
+	attMetadataHMM  = 5.0  // extra header height for the exam's metadata line
+	attLogoSizeMM   = 16.0 // logo square side length in the header
+	attRowHMM       = 8.0  // height of a table row, header row included
+	attFooterHMM    = 36.0 // space the summary and proctor signature need
+	attCellPadMM    = 1.5  // horizontal padding inside a table cell
+	attTableFontPt  = 8.0
+	attHeaderFontPt = 7.5
+)
+
+// attColumn is one column of the attendance table.
+type attColumn struct {
+	title string
+	width float64 // mm
+	value func(i int, row model.AttendanceRow) string
+}
+
+// attendanceColumns lists the table columns, which add up to the usable page width.
+// A sheet grouped by class shows each student's room; one grouped by room their class.
+func attendanceColumns(groupBy string) []attColumn {
+	place := attColumn{title: "RUANG / KURSI", width: 32, value: func(_ int, r model.AttendanceRow) string {
+		if r.RoomName == "" {
+			return "-"
+		}
+		return fmt.Sprintf("%s / %d", r.RoomName, r.SeatNumber)
+	}}
+	if groupBy == model.AttendanceByRoom {
+		place = attColumn{title: "KELAS", width: 32, value: func(_ int, r model.AttendanceRow) string { return r.ClassName }}
+	}
+	return []attColumn{
+		{title: "NO", width: 10, value: func(i int, _ model.AttendanceRow) string { return strconv.Itoa(i + 1) }},
+		{title: "NISN", width: 26, value: func(_ int, r model.AttendanceRow) string { return r.NISN }},
+		{title: "NAMA SISWA", width: 60, value: func(_ int, r model.AttendanceRow) string { return r.Name }},
+		place,
+		{title: "STATUS", width: 22, value: func(_ int, r model.AttendanceRow) string { return attendanceStatus(r) }},
+		{title: "TANDA TANGAN", width: 36, value: func(int, model.AttendanceRow) string { return "" }},
+	}
+}
+
+// attendanceStatus is the pre-filled status of a row: students who joined are
+// present; the others are left blank for the proctor to fill in.
+func attendanceStatus(r model.AttendanceRow) string {
+	if r.SessionStatus == nil {
+		return ""
+	}
+	return "Hadir"
+}
+
+// ---------------------------------------------------------------------------
+// Public API
+// ---------------------------------------------------------------------------
+
+// GenerateAttendancePDF builds an A4 attendance sheet per class or room: a table of
+// the students with their status and a signature box, then a summary and a space
+// for the proctor's signature. Returns the raw PDF bytes.
+func GenerateAttendancePDF(attendance *model.ExamAttendance, school SchoolInfo) ([]byte, error) {
+	if len(attendance.Groups) == 0 {
+		return nil, fmt.Errorf("no students on the attendance sheet")
+	}
+
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+
+	for name, file := range map[string]string{
+		fontRegular: "Roboto-Regular.ttf",
+		fontBold:    "Roboto-Bold.ttf",
+	} {
+		fontBytes, err := fonts.FS.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read embedded font %s: %w", file, err)
+		}
+		if err := pdf.AddTTFFontData(name, fontBytes); err != nil {
+			return nil, fmt.Errorf("load font %s: %w", name, err)
+		}
+	}
+
+	logoBytes, _ := loadLogoAsJPEG(resolveLogoPath(school.LogoURL))
+	columns := attendanceColumns(attendance.GroupBy)
+	bottom := pdfPageHeightMM - pdfPageMarginMM
+
+	for _, group := range attendance.Groups {
+		pdf.AddPage()
+		y := drawAttendanceHeader(pdf, attendance, group.Name, school.Name, logoBytes)
+		y = drawAttendanceTableHeader(pdf, columns, y)
+
+		present := 0
+		for i, row := range group.Rows {
+			if y+attRowHMM > bottom {
+				pdf.AddPage()
+				y = drawAttendanceTableHeader(pdf, columns, pdfPageMarginMM)
+			}
+			drawAttendanceRow(pdf, columns, i, row, y)
+			y += attRowHMM
+			if row.SessionStatus != nil {
+				present++
+			}
+		}
+
+		if y+attFooterHMM > bottom {
+			pdf.AddPage()
+			y = pdfPageMarginMM
+		}
+		drawAttendanceFooter(pdf, len(group.Rows), present, y+6)
+	}
+
+	var buf bytes.Buffer
+	if _, err := pdf.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("write pdf: %w", err)
+	}
+	pdf.Close()
+
+	return buf.Bytes(), nil
+}
+
+// ---------------------------------------------------------------------------
+// Sheet drawing
+// ---------------------------------------------------------------------------
+
+// drawAttendanceHeader renders the school, title, exam and group at the top of a
+// sheet and returns the Y (mm) below it.
+func drawAttendanceHeader(pdf *gopdf.GoPdf, attendance *model.ExamAttendance, groupName, schoolName string, logoBytes []byte) float64 {
+	x, y := pdfPageMarginMM, pdfPageMarginMM
+	textX := x
+	if len(logoBytes) > 0 {
+		if holder, err := gopdf.ImageHolderByBytes(logoBytes); err == nil {
+			size := mmToPt(attLogoSizeMM)
+			if err := pdf.ImageByHolder(holder, mmToPt(x), mmToPt(y), &gopdf.Rect{W: size, H: size}); err == nil {
+				textX += attLogoSizeMM + 4
+			}
+		}
+	}
+
+	line := func(text, font string, size, atMM float64) {
+		_ = pdf.SetFont(font, "", size)
+		pdf.SetTextColor(30, 40, 50)
+		pdf.SetXY(mmToPt(textX), mmToPt(atMM))
+		pdf.Text(text)
+	}
+	if schoolName != "" {
+		line(strings.ToUpper(schoolName), fontBold, 10, y+3)
+	}
+	line("DAFTAR HADIR PESERTA UJIAN", fontBold, 13, y+9)
+	line(attendance.Title, fontRegular, 10, y+15)
+
+	schedule := "-"
+	if attendance.ScheduledStart != nil {
+		schedule = attendance.ScheduledStart.Time().Format("02/01/2006 15:04")
+	}
+	groupLabel := "Kelas"
+	if attendance.GroupBy == model.AttendanceByRoom {
+		groupLabel = "Ruangan"
+	}
+	line(fmt.Sprintf("Jadwal: %s    %s: %s", schedule, groupLabel, groupName), fontRegular, 9, y+21)
+
+	height := attHeaderHMM
+	if len(attendance.Metadata) > 0 {
+		fields := make([]string, len(attendance.Metadata))
+		for i, m := range attendance.Metadata {
+			fields[i] = m.Label + ": " + m.Value
+		}
+		line(strings.Join(fields, "    "), fontRegular, 8, y+26)
+		height += attMetadataHMM
+	}
+
+	// Rule under the header.
+	pdf.SetStrokeColor(60, 70, 80)
+	pdf.SetLineWidth(0.8)
+	pdf.Line(mmToPt(x), mmToPt(y+height-4), mmToPt(pdfPageWidthMM-pdfPageMarginMM), mmToPt(y+height-4))
+
+	return y + height
+}
+
+// drawAttendanceTableHeader renders the shaded column titles at yMM and returns the
+// Y (mm) of the first row.
+func drawAttendanceTableHeader(pdf *gopdf.GoPdf, columns []attColumn, yMM float64) float64 {
+	x := pdfPageMarginMM
+	pdf.SetFillColor(235, 238, 242)
+	pdf.SetStrokeColor(120, 130, 140)
+	pdf.SetLineWidth(0.5)
+	for _, col := range columns {
+		pdf.RectFromUpperLeftWithStyle(mmToPt(x), mmToPt(yMM), mmToPt(col.width), mmToPt(attRowHMM), "FD")
+		_ = pdf.SetFont(fontBold, "", attHeaderFontPt)
+		pdf.SetTextColor(30, 40, 50)
+		drawAttendanceCell(pdf, x, yMM, col.width, col.title, gopdf.Center|gopdf.Middle)
+		x += col.width
+	}
+	return yMM + attRowHMM
+}
+
+// drawAttendanceRow renders the i-th student of a group at yMM. The signature box
+// alternates its number between the left and the middle, as on paper sheets, so
+// neighbours do not sign over each other.
+func drawAttendanceRow(pdf *gopdf.GoPdf, columns []attColumn, i int, row model.AttendanceRow, yMM float64) {
+	x := pdfPageMarginMM
+	pdf.SetStrokeColor(120, 130, 140)
+	pdf.SetLineWidth(0.5)
+	for c, col := range columns {
+		pdf.RectFromUpperLeftWithStyle(mmToPt(x), mmToPt(yMM), mmToPt(col.width), mmToPt(attRowHMM), "D")
+		_ = pdf.SetFont(fontRegular, "", attTableFontPt)
+		pdf.SetTextColor(30, 40, 50)
+
+		align := gopdf.Left | gopdf.Middle
+		value := col.value(i, row)
+		switch {
+		case c == 0:
+			align = gopdf.Center | gopdf.Middle
+		case c == len(columns)-1:
+			_ = pdf.SetFont(fontRegular, "", 6)
+			pdf.SetTextColor(120, 130, 140)
+			value = strconv.Itoa(i+1) + "."
+			if i%2 == 1 {
+				drawAttendanceCell(pdf, x+col.width/2, yMM, col.width/2, value, gopdf.Left|gopdf.Top)
+				x += col.width
+				continue
+			}
+			align = gopdf.Left | gopdf.Top
+		}
+		drawAttendanceCell(pdf, x, yMM, col.width, value, align)
+		x += col.width
+	}
+}
+
+// drawAttendanceFooter renders the group's counts and the proctor's signature space
+// at yMM.
+func drawAttendanceFooter(pdf *gopdf.GoPdf, total, present int, yMM float64) {
+	x := pdfPageMarginMM
+	_ = pdf.SetFont(fontRegular, "", 9)
+	pdf.SetTextColor(30, 40, 50)
+	for i, text := range []string{
+		fmt.Sprintf("Jumlah peserta    : %d", total),
+		fmt.Sprintf("Hadir (tercatat)  : %d", present),
+		"Tidak hadir       : ........",
+	} {
+		pdf.SetXY(mmToPt(x), mmToPt(yMM+float64(i)*5))
+		pdf.Text(text)
+	}
+
+	signX := pdfPageWidthMM - pdfPageMarginMM - 60
+	pdf.SetXY(mmToPt(signX), mmToPt(yMM))
+	pdf.Text("Pengawas Ujian,")
+	pdf.SetXY(mmToPt(signX), mmToPt(yMM+25))
+	pdf.Text("(..........................................)")
+}
+
+// drawAttendanceCell writes text inside a cell, shortened to fit its padded width.
+func drawAttendanceCell(pdf *gopdf.GoPdf, xMM, yMM, wMM float64, text string, align int) {
+	maxW := mmToPt(wMM - 2*attCellPadMM)
+	for runes := []rune(text); len(runes) > 0; runes = runes[:len(runes)-1] {
+		text = string(runes)
+		if tw, _ := pdf.MeasureTextWidth(text); tw <= maxW {
+			break
+		}
+	}
+	pdf.SetXY(mmToPt(xMM+attCellPadMM), mmToPt(yMM))
+	_ = pdf.CellWithOption(&gopdf.Rect{W: maxW, H: mmToPt(attRowHMM)}, text, gopdf.CellOption{Align: align})
+}
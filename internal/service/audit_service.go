@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+)
+
+// AuditService records sensitive admin actions.
+type AuditService struct {
+	repo *repository.AuditLogRepository
+	log  zerolog.Logger
+}
+
+// NewAuditService creates a new AuditService.
+func NewAuditService(repo *repository.AuditLogRepository, log zerolog.Logger) *AuditService {
+	return &AuditService{repo: repo, log: log}
+}
+
+// Record writes an audit entry. details is marshalled to JSON and may be nil.
+func (s *AuditService) Record(ctx context.Context, adminID int, action, targetType, targetID, ip string, details interface{}) error {
+	return s.record(ctx, &adminID, action, targetType, targetID, ip, details)
+}
+
+// RecordSystem writes an audit entry for an action the system took on its own, such
+// as a scheduled purge.
+func (s *AuditService) RecordSystem(ctx context.Context, action, targetType, targetID string, details interface{}) error {
+	return s.record(ctx, nil, action, targetType, targetID, "", details)
+}
+
+func (s *AuditService) record(ctx context.Context, adminID *int, action, targetType, targetID, ip string, details interface{}) error {
+	entry := &model.AuditLog{
+		AdminID:    adminID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         ip,
+	}
+	if details != nil {
+		raw, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+		entry.Details = raw
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		logEvent := s.log.Error().Err(err).Str("action", action)
+		if adminID != nil {
+			logEvent = logEvent.Int("admin_id", *adminID)
+		}
+		logEvent.Msg("Failed to write audit log")
+		return err
+	}
+	return nil
+}
@@ -2,15 +2,19 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -18,24 +22,36 @@ import (
 var (
 	ErrInvalidCredentials   = errors.New("invalid credentials")
 	ErrSessionAlreadyActive = errors.New("another session is already active, please contact admin to reset")
+	// ErrSessionCheckUnavailable is returned when a session cannot be checked because
+	// Redis failed, as opposed to the session being gone.
+	ErrSessionCheckUnavailable = errors.New("session check unavailable")
 )
 
 // TokenType distinguishes student vs admin tokens.
 type TokenType string
 
 const (
-	TokenTypeStudent TokenType = "student"
-	TokenTypeAdmin   TokenType = "admin"
+	TokenTypeStudent  TokenType = "student"
+	TokenTypeAdmin    TokenType = "admin"
+	TokenTypeGuardian TokenType = "guardian"
 )
 
 // Claims extends JWT standard claims with app-specific fields.
 type Claims struct {
 	jwt.RegisteredClaims
-	TokenType   TokenType `json:"token_type"`
-	UserID      int       `json:"user_id"`
-	ClassID     int       `json:"class_id,omitempty"`    // Student only
-	RoleID      int       `json:"role_id,omitempty"`     // Admin only
-	Permissions []string  `json:"permissions,omitempty"` // Admin only
+	TokenType     TokenType        `json:"token_type"`
+	UserID        int              `json:"user_id"`
+	ClassID       int              `json:"class_id,omitempty"`       // Student only
+	RoleID        int              `json:"role_id,omitempty"`        // Admin only
+	Permissions   []string         `json:"permissions,omitempty"`    // Admin only
+	SubjectScopes map[string][]int `json:"subject_scopes,omitempty"` // Admin only
+	// ImpersonatorID marks a student token issued to a support admin; it is read-only.
+	ImpersonatorID int `json:"impersonator_id,omitempty"` // Student only
+}
+
+// IsImpersonation reports whether the token was issued to an admin impersonating a student.
+func (c *Claims) IsImpersonation() bool {
+	return c.ImpersonatorID != 0
 }
 
 // AuthService handles authentication, JWT, and session management.
@@ -64,14 +80,23 @@ func (s *AuthService) CheckPassword(hash, password string) error {
 	return nil
 }
 
-// CheckStudentPassword compares a plaintext student password against the stored plaintext password.
+// CheckStudentPassword compares a plaintext student password against the stored one,
+// which is plaintext unless the student was issued credentials or chose their own.
 func (s *AuthService) CheckStudentPassword(storedPassword, password string) error {
+	if isPasswordHash(storedPassword) {
+		return s.CheckPassword(storedPassword, password)
+	}
 	if storedPassword != password {
 		return ErrInvalidCredentials
 	}
 	return nil
 }
 
+// isPasswordHash reports whether a stored student password is a bcrypt hash.
+func isPasswordHash(password string) bool {
+	return strings.HasPrefix(password, "$2a$") || strings.HasPrefix(password, "$2b$") || strings.HasPrefix(password, "$2y$")
+}
+
 // GenerateStudentToken creates a JWT for a student and registers the session in Redis.
 // Returns an error if a session already exists (new logins are rejected).
 func (s *AuthService) GenerateStudentToken(ctx context.Context, studentID, classID int) (string, error) {
@@ -115,25 +140,87 @@ func (s *AuthService) GenerateStudentToken(ctx context.Context, studentID, class
 	return signed, nil
 }
 
-// GenerateAdminToken creates a JWT for an admin with permissions embedded.
-func (s *AuthService) GenerateAdminToken(adminID, roleID int, permissions []string) (string, error) {
+// GenerateAdminToken creates a JWT for an admin with permissions and subject scopes embedded,
+// and registers the session in Redis. An admin may hold several sessions at once.
+func (s *AuthService) GenerateAdminToken(ctx context.Context, adminID, roleID int, permissions []string, scopes map[string][]int, ip, userAgent string) (string, error) {
+	jti := uuid.New().String()
 	now := time.Now()
 
 	claims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ID:        uuid.New().String(),
+			ID:        jti,
 			Subject:   strconv.Itoa(adminID),
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(now.Add(s.cfg.JWTExpiry)),
 		},
-		TokenType:   TokenTypeAdmin,
-		UserID:      adminID,
-		RoleID:      roleID,
-		Permissions: permissions,
+		TokenType:     TokenTypeAdmin,
+		UserID:        adminID,
+		RoleID:        roleID,
+		Permissions:   permissions,
+		SubjectScopes: scopes,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(s.cfg.JWTSecret))
+	if err != nil {
+		return "", fmt.Errorf("sign token: %w", err)
+	}
+
+	session, err := json.Marshal(model.AdminSession{
+		JTI:       jti,
+		IP:        ip,
+		UserAgent: userAgent,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.cfg.JWTExpiry),
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode session: %w", err)
+	}
+
+	// The registry lives as long as the newest token; older entries are pruned on listing.
+	sessionsKey := config.CacheKey.AdminSessionsKey(adminID)
+	pipe := s.rdb.TxPipeline()
+	pipe.HSet(ctx, sessionsKey, jti, session)
+	pipe.Expire(ctx, sessionsKey, s.cfg.JWTExpiry)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("store session: %w", err)
+	}
+
+	return signed, nil
+}
+
+// GenerateGuardianToken creates a JWT for a guardian and registers the session in Redis.
+// A guardian may hold several sessions at once.
+func (s *AuthService) GenerateGuardianToken(ctx context.Context, guardianID int) (string, error) {
+	jti := uuid.New().String()
+	now := time.Now()
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.Itoa(guardianID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.cfg.JWTExpiry)),
+		},
+		TokenType: TokenTypeGuardian,
+		UserID:    guardianID,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.cfg.JWTSecret))
+	signed, err := token.SignedString([]byte(s.cfg.JWTSecret))
+	if err != nil {
+		return "", fmt.Errorf("sign token: %w", err)
+	}
+
+	sessionsKey := config.CacheKey.GuardianSessionsKey(guardianID)
+	pipe := s.rdb.TxPipeline()
+	pipe.SAdd(ctx, sessionsKey, jti)
+	pipe.Expire(ctx, sessionsKey, s.cfg.JWTExpiry)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("store session: %w", err)
+	}
+
+	return signed, nil
 }
 
 // ValidateToken parses and validates a JWT, returning the claims.
@@ -156,6 +243,59 @@ func (s *AuthService) ValidateToken(tokenStr string) (*Claims, error) {
 	return claims, nil
 }
 
+// GenerateImpersonationToken creates a short-lived student JWT for an admin viewing the
+// portal as that student. It does not replace the student's own session, and it ends
+// together with the admin session (adminJTI) it was issued from.
+func (s *AuthService) GenerateImpersonationToken(ctx context.Context, adminID int, adminJTI string, studentID, classID int) (string, time.Time, error) {
+	jti := uuid.New().String()
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.ImpersonationExpiry)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.Itoa(studentID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		TokenType:      TokenTypeStudent,
+		UserID:         studentID,
+		ClassID:        classID,
+		ImpersonatorID: adminID,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(s.cfg.JWTSecret))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("sign token: %w", err)
+	}
+
+	sessionKey := config.CacheKey.ImpersonationSessionKey(jti)
+	if err := s.rdb.Set(ctx, sessionKey, fmt.Sprintf("%d:%s", adminID, adminJTI), s.cfg.ImpersonationExpiry).Err(); err != nil {
+		return "", time.Time{}, fmt.Errorf("store session: %w", err)
+	}
+
+	return signed, expiresAt, nil
+}
+
+// ValidateImpersonationSession checks that an impersonation token is still registered
+// and that the admin session it was issued from has not been revoked.
+func (s *AuthService) ValidateImpersonationSession(ctx context.Context, jti string) error {
+	stored, err := s.rdb.Get(ctx, config.CacheKey.ImpersonationSessionKey(jti)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return errors.New("session invalidated")
+		}
+		return fmt.Errorf("check session: %w", err)
+	}
+	adminIDStr, adminJTI, ok := strings.Cut(stored, ":")
+	adminID, convErr := strconv.Atoi(adminIDStr)
+	if !ok || convErr != nil {
+		return errors.New("session invalidated")
+	}
+	return s.ValidateAdminSession(ctx, adminID, adminJTI)
+}
+
 // ValidateStudentSession checks that the token's JTI matches the active session in Redis.
 func (s *AuthService) ValidateStudentSession(ctx context.Context, studentID int, jti string) error {
 	sessionKey := config.CacheKey.StudentSessionKey(studentID)
@@ -177,3 +317,89 @@ func (s *AuthService) ResetStudentSession(ctx context.Context, studentID int) er
 	sessionKey := config.CacheKey.StudentSessionKey(studentID)
 	return s.rdb.Del(ctx, sessionKey).Err()
 }
+
+// ValidateAdminSession checks that the token's JTI is still registered for the admin.
+// A Redis failure returns ErrSessionCheckUnavailable rather than ending the session.
+func (s *AuthService) ValidateAdminSession(ctx context.Context, adminID int, jti string) error {
+	ok, err := s.rdb.HExists(ctx, config.CacheKey.AdminSessionsKey(adminID), jti).Result()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSessionCheckUnavailable, err)
+	}
+	if !ok {
+		return errors.New("session invalidated")
+	}
+	return nil
+}
+
+// ValidateGuardianSession checks that the token's JTI is still registered for the guardian.
+// A Redis failure returns ErrSessionCheckUnavailable rather than ending the session.
+func (s *AuthService) ValidateGuardianSession(ctx context.Context, guardianID int, jti string) error {
+	ok, err := s.rdb.SIsMember(ctx, config.CacheKey.GuardianSessionsKey(guardianID), jti).Result()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSessionCheckUnavailable, err)
+	}
+	if !ok {
+		return errors.New("session invalidated")
+	}
+	return nil
+}
+
+// RevokeGuardianSession ends one guardian session.
+func (s *AuthService) RevokeGuardianSession(ctx context.Context, guardianID int, jti string) error {
+	return s.rdb.SRem(ctx, config.CacheKey.GuardianSessionsKey(guardianID), jti).Err()
+}
+
+// RevokeGuardianSessions ends every session of a guardian.
+func (s *AuthService) RevokeGuardianSessions(ctx context.Context, guardianID int) error {
+	return s.rdb.Del(ctx, config.CacheKey.GuardianSessionsKey(guardianID)).Err()
+}
+
+// ListAdminSessions returns an admin's active sessions, newest first.
+// Sessions whose token has expired are removed from the registry.
+func (s *AuthService) ListAdminSessions(ctx context.Context, adminID int) ([]model.AdminSession, error) {
+	sessionsKey := config.CacheKey.AdminSessionsKey(adminID)
+	entries, err := s.rdb.HGetAll(ctx, sessionsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+
+	now := time.Now()
+	sessions := make([]model.AdminSession, 0, len(entries))
+	var expired []string
+	for jti, raw := range entries {
+		var session model.AdminSession
+		if err := json.Unmarshal([]byte(raw), &session); err != nil || now.After(session.ExpiresAt) {
+			expired = append(expired, jti)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	if len(expired) > 0 {
+		if err := s.rdb.HDel(ctx, sessionsKey, expired...).Err(); err != nil {
+			return nil, fmt.Errorf("prune sessions: %w", err)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].IssuedAt.After(sessions[j].IssuedAt)
+	})
+	return sessions, nil
+}
+
+// RevokeAdminSession ends a single admin session.
+func (s *AuthService) RevokeAdminSession(ctx context.Context, adminID int, jti string) error {
+	return s.rdb.HDel(ctx, config.CacheKey.AdminSessionsKey(adminID), jti).Err()
+}
+
+// RevokeAdminSessions ends every session of an admin, e.g. when they leave or a device is lost.
+// It returns the number of sessions revoked.
+func (s *AuthService) RevokeAdminSessions(ctx context.Context, adminID int) (int64, error) {
+	sessionsKey := config.CacheKey.AdminSessionsKey(adminID)
+	pipe := s.rdb.TxPipeline()
+	count := pipe.HLen(ctx, sessionsKey)
+	pipe.Del(ctx, sessionsKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("revoke sessions: %w", err)
+	}
+	return count.Val(), nil
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/stemsi/exstem-backend/internal/storage"
+)
+
+// Backup errors.
+var (
+	ErrBackupNotFound       = errors.New("backup not found")
+	ErrBackupInProgress     = errors.New("another backup or restore is running")
+	ErrBackupSchemaMismatch = errors.New("backup schema version differs from the database")
+	ErrRestoreExamsRunning  = errors.New("exams are in progress")
+)
+
+// backupLockKey is the PostgreSQL advisory lock that keeps backups and restores from
+// overlapping, across server instances and the backup command.
+const backupLockKey = 4_480_001
+
+// restoreStaleKeys match the Redis keys built from the database that a restore leaves
+// stale: cached exam payloads, answer keys and settings, and the students' exam state.
+var restoreStaleKeys = []string{"exam:*", "student:*:exam:*", "student:*:active_exam"}
+
+// backupNamePattern matches backup names: creation time (UTC) and schema version.
+var backupNamePattern = regexp.MustCompile(`^exstem-(\d{8}-\d{6})-v(\d+)\.dump$`)
+
+// BackupService takes logical backups of the database with pg_dump, keeps them in
+// backup storage and restores them with pg_restore.
+type BackupService struct {
+	pool      *pgxpool.Pool
+	rdb       *redis.Client
+	examCache *repository.ExamCache
+	store     storage.Store
+	cfg       *config.Config
+	log       zerolog.Logger
+}
+
+// NewBackupService creates a new BackupService.
+func NewBackupService(pool *pgxpool.Pool, rdb *redis.Client, examCache *repository.ExamCache, store storage.Store, cfg *config.Config, log zerolog.Logger) *BackupService {
+	return &BackupService{
+		pool:      pool,
+		rdb:       rdb,
+		examCache: examCache,
+		store:     store,
+		cfg:       cfg,
+		log:       log.With().Str("component", "backup").Logger(),
+	}
+}
+
+// List returns the backups in storage, newest first. Other objects are ignored.
+func (s *BackupService) List(ctx context.Context) ([]model.Backup, error) {
+	objects, err := s.store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list backups: %w", err)
+	}
+
+	backups := make([]model.Backup, 0, len(objects))
+	for _, o := range objects {
+		b, ok := parseBackupName(o.Name)
+		if !ok {
+			continue
+		}
+		b.Size = o.Size
+		backups = append(backups, b)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// Create dumps the database and uploads the dump to backup storage. The data of
+// exam_monitor_events, which only serves monitor replays, is left out.
+func (s *BackupService) Create(ctx context.Context) (*model.Backup, error) {
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	version, err := s.schemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	backup := &model.Backup{
+		Name:          fmt.Sprintf("exstem-%s-v%d.dump", now.Format("20060102-150405"), version),
+		SchemaVersion: version,
+		CreatedAt:     now,
+	}
+
+	tmp, err := os.CreateTemp("", "exstem-backup-*.dump")
+	if err != nil {
+		return nil, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := s.run(ctx, "pg_dump",
+		"--format=custom", "--no-owner", "--no-privileges",
+		"--exclude-table-data=exam_monitor_events",
+		"--file="+tmp.Name(),
+	); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, backup.Name, f, info.Size()); err != nil {
+		return nil, fmt.Errorf("upload backup: %w", err)
+	}
+	backup.Size = info.Size()
+
+	s.log.Info().Str("backup", backup.Name).Int64("bytes", backup.Size).Msg("Database backup created")
+	return backup, nil
+}
+
+// Restore replaces the database's contents with a backup in a single transaction,
+// so a failed restore changes nothing. It is refused while exams are in progress
+// and for backups of another schema version, which the running code cannot use.
+// Afterwards the caches built from the old contents are cleared on every instance.
+func (s *BackupService) Restore(ctx context.Context, name string) error {
+	backup, ok := parseBackupName(name)
+	if !ok {
+		return ErrBackupNotFound
+	}
+
+	unlock, err := s.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	version, err := s.schemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if backup.SchemaVersion != version {
+		return fmt.Errorf("%w: backup is at version %d, database at %d", ErrBackupSchemaMismatch, backup.SchemaVersion, version)
+	}
+	var running bool
+	if err := s.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM exams WHERE status = 'IN_PROGRESS')`).Scan(&running); err != nil {
+		return err
+	}
+	if running {
+		return ErrRestoreExamsRunning
+	}
+
+	path, err := s.download(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	if err := s.run(ctx, "pg_restore",
+		"--clean", "--if-exists", "--no-owner", "--no-privileges",
+		"--single-transaction", "--exit-on-error",
+		path,
+	); err != nil {
+		return err
+	}
+	s.log.Warn().Str("backup", name).Msg("Database restored from backup")
+
+	// The database is restored whatever happens here; a cache left behind expires.
+	ctx = context.WithoutCancel(ctx)
+	if err := s.clearStaleKeys(ctx); err != nil {
+		s.log.Error().Err(err).Msg("Failed to clear cached exams after restore")
+	}
+	s.examCache.InvalidateAll(ctx)
+	if err := s.rdb.Publish(ctx, config.CacheKey.RemoteConfigChannel(), "").Err(); err != nil {
+		s.log.Warn().Err(err).Msg("Failed to announce remote config change after restore")
+	}
+	return nil
+}
+
+// clearStaleKeys deletes the Redis keys matching restoreStaleKeys.
+func (s *BackupService) clearStaleKeys(ctx context.Context) error {
+	deleted := 0
+	for _, pattern := range restoreStaleKeys {
+		iter := s.rdb.Scan(ctx, 0, pattern, 500).Iterator()
+		var batch []string
+		for iter.Next(ctx) {
+			batch = append(batch, iter.Val())
+			if len(batch) == 500 {
+				if err := s.rdb.Unlink(ctx, batch...).Err(); err != nil {
+					return err
+				}
+				deleted += len(batch)
+				batch = batch[:0]
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			if err := s.rdb.Unlink(ctx, batch...).Err(); err != nil {
+				return err
+			}
+			deleted += len(batch)
+		}
+	}
+	s.log.Info().Int("keys", deleted).Msg("Cleared cached exams after restore")
+	return nil
+}
+
+// download copies a backup from storage to a temporary file, since pg_restore needs
+// to seek in a custom-format dump.
+func (s *BackupService) download(ctx context.Context, name string) (string, error) {
+	r, err := s.store.Get(ctx, name)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", ErrBackupNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("download backup: %w", err)
+	}
+	defer r.Close()
+
+	tmp, err := os.CreateTemp("", "exstem-restore-*.dump")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("download backup: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// lock takes the backup advisory lock on a dedicated connection and returns its release.
+func (s *BackupService) lock(ctx context.Context) (func(), error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, backupLockKey).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, err
+	}
+	if !locked {
+		conn.Release()
+		return nil, ErrBackupInProgress
+	}
+
+	return func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, backupLockKey); err != nil {
+			// Closing the connection drops the lock with it.
+			conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}, nil
+}
+
+// schemaVersion returns the database's migration version.
+func (s *BackupService) schemaVersion(ctx context.Context) (int, error) {
+	var version int
+	if err := s.pool.QueryRow(ctx, `SELECT version FROM schema_migrations LIMIT 1`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// run runs a PostgreSQL client tool against the database. The password is passed in
+// PGPASSWORD rather than on the command line, where other local users could read it.
+func (s *BackupService) run(ctx context.Context, tool string, args ...string) error {
+	bin := tool
+	if s.cfg.PGBinDir != "" {
+		bin = filepath.Join(s.cfg.PGBinDir, tool)
+	}
+
+	dsn := s.cfg.DatabaseURL
+	env := os.Environ()
+	if u, err := url.Parse(dsn); err == nil && u.User != nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		if password, ok := u.User.Password(); ok {
+			env = append(env, "PGPASSWORD="+password)
+			u.User = url.User(u.User.Username())
+			dsn = u.String()
+		}
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, append(args, "--dbname="+dsn)...)
+	cmd.Env = env
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w: %s", tool, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// parseBackupName reads the creation time and schema version out of a backup name.
+func parseBackupName(name string) (model.Backup, bool) {
+	m := backupNamePattern.FindStringSubmatch(name)
+	if m == nil {
+		return model.Backup{}, false
+	}
+	createdAt, err := time.Parse("20060102-150405", m[1])
+	if err != nil {
+		return model.Backup{}, false
+	}
+	version, err := strconv.Atoi(m[2])
+	if err != nil {
+		return model.Backup{}, false
+	}
+	return model.Backup{Name: name, SchemaVersion: version, CreatedAt: createdAt}, true
+}
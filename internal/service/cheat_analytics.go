@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	// repeatOffenderMinExams is how many flagged exams make a student a repeat offender.
+	repeatOffenderMinExams = 2
+	// repeatOffenderLimit caps the repeat offenders listed.
+	repeatOffenderLimit = 100
+)
+
+// GetCheatAnalytics summarises the cheat events of the exam sessions started within
+// [from, to) across all exams. Without a period it covers the current semester.
+func (s *MonitorService) GetCheatAnalytics(ctx context.Context, from, to time.Time) (*model.CheatAnalytics, error) {
+	if from.IsZero() || to.IsZero() {
+		from, to = academicTerm(time.Now())
+	}
+
+	byClass, err := s.monitorRepo.GetClassCheatRates(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("get class cheat rates: %w", err)
+	}
+	byStudent, err := s.monitorRepo.GetRepeatCheaters(ctx, from, to, repeatOffenderMinExams, repeatOffenderLimit)
+	if err != nil {
+		return nil, fmt.Errorf("get repeat cheaters: %w", err)
+	}
+	byType, err := s.monitorRepo.GetCheatTypeCounts(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("get cheat type counts: %w", err)
+	}
+
+	analytics := &model.CheatAnalytics{
+		From:      from.Format("2006-01-02"),
+		To:        to.AddDate(0, 0, -1).Format("2006-01-02"),
+		ByClass:   byClass,
+		ByStudent: byStudent,
+		ByType:    byType,
+	}
+	for _, c := range byClass {
+		analytics.Sessions += c.Sessions
+		analytics.Events += c.Events
+	}
+	return analytics, nil
+}
+
+// ExportCheatAnalyticsXLSX builds a workbook of the cheat analytics with one sheet
+// per breakdown.
+func (s *MonitorService) ExportCheatAnalyticsXLSX(ctx context.Context, from, to time.Time) ([]byte, error) {
+	analytics, err := s.GetCheatAnalytics(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+
+	sheets := []struct {
+		name   string
+		header []interface{}
+		rows   [][]interface{}
+	}{
+		{name: "Per Kelas", header: []interface{}{"Kelas", "Sesi", "Sesi Terindikasi", "Kejadian", "Persentase Terindikasi"}},
+		{name: "Siswa Berulang", header: []interface{}{"Nama", "NISN", "Kelas", "Sesi", "Ujian Terindikasi", "Kejadian"}},
+		{name: "Per Jenis", header: []interface{}{"Jenis", "Kejadian", "Siswa"}},
+	}
+	for _, c := range analytics.ByClass {
+		sheets[0].rows = append(sheets[0].rows, []interface{}{c.ClassName, c.Sessions, c.FlaggedSessions, c.Events, c.FlaggedRate * 100})
+	}
+	for _, st := range analytics.ByStudent {
+		sheets[1].rows = append(sheets[1].rows, []interface{}{st.Name, st.NISN, st.ClassName, st.Sessions, st.FlaggedExams, st.Events})
+	}
+	for _, t := range analytics.ByType {
+		sheets[2].rows = append(sheets[2].rows, []interface{}{t.Type, t.Events, t.Students})
+	}
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", sheet.name); err != nil {
+				return nil, err
+			}
+		} else if _, err := f.NewSheet(sheet.name); err != nil {
+			return nil, err
+		}
+
+		if err := f.SetSheetRow(sheet.name, "A1", &sheet.header); err != nil {
+			return nil, err
+		}
+		lastCol, _ := excelize.ColumnNumberToName(len(sheet.header))
+		_ = f.SetCellStyle(sheet.name, "A1", lastCol+"1", headerStyle)
+
+		for n, row := range sheet.rows {
+			cell, _ := excelize.CoordinatesToCellName(1, n+2)
+			if err := f.SetSheetRow(sheet.name, cell, &row); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
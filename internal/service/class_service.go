@@ -2,19 +2,26 @@ package service
 
 import (
 	"context"
+	"errors"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/stemsi/exstem-backend/internal/model"
 	"github.com/stemsi/exstem-backend/internal/repository"
 )
 
+// ErrUnknownMajor is returned when a class names a major code that is not in the
+// majors table.
+var ErrUnknownMajor = errors.New("major does not exist")
+
 // ClassService handles class business logic.
 type ClassService struct {
 	classRepo *repository.ClassRepository
+	majorRepo repository.MajorRepository
 }
 
 // NewClassService creates a new ClassService.
-func NewClassService(classRepo *repository.ClassRepository) *ClassService {
-	return &ClassService{classRepo: classRepo}
+func NewClassService(classRepo *repository.ClassRepository, majorRepo repository.MajorRepository) *ClassService {
+	return &ClassService{classRepo: classRepo, majorRepo: majorRepo}
 }
 
 // GetByID retrieves a class by its ID.
@@ -29,18 +36,75 @@ func (s *ClassService) List(ctx context.Context) ([]model.Class, error) {
 
 // Create creates a new class.
 func (s *ClassService) Create(ctx context.Context, class *model.Class) error {
-	// Add potential uniqueness validations here if required (e.g. combination of grade, major, group)
+	if err := s.checkMajor(ctx, class.MajorCode); err != nil {
+		return err
+	}
 	return s.classRepo.Create(ctx, class)
 }
 
+// BulkCreate creates every combination of grade levels, majors and group numbers
+// 1..groups[major], skipping the classes that already exist. All majors are
+// checked before any class is created.
+func (s *ClassService) BulkCreate(ctx context.Context, gradeLevels []string, majors []string, groups map[string]int) (*model.BulkClassResult, error) {
+	for _, code := range majors {
+		if err := s.checkMajor(ctx, code); err != nil {
+			return nil, err
+		}
+	}
+
+	var classes []model.Class
+	for _, grade := range gradeLevels {
+		for _, code := range majors {
+			for n := 1; n <= groups[code]; n++ {
+				classes = append(classes, model.Class{GradeLevel: grade, MajorCode: code, GroupNumber: n})
+			}
+		}
+	}
+
+	created, err := s.classRepo.CreateMissing(ctx, classes)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.BulkClassResult{Created: []model.Class{}, Skipped: []model.Class{}}
+	for i, class := range classes {
+		if created[i] {
+			result.Created = append(result.Created, class)
+		} else {
+			result.Skipped = append(result.Skipped, class)
+		}
+	}
+	return result, nil
+}
+
 // Update modifies an existing class.
 func (s *ClassService) Update(ctx context.Context, class *model.Class) error {
+	if err := s.checkMajor(ctx, class.MajorCode); err != nil {
+		return err
+	}
 	return s.classRepo.Update(ctx, class)
 }
 
+// SetHomeroom assigns a class's homeroom teacher, or removes it when adminID is nil.
+func (s *ClassService) SetHomeroom(ctx context.Context, id int, adminID *int) (*model.Class, error) {
+	if err := s.classRepo.SetHomeroom(ctx, id, adminID); err != nil {
+		return nil, err
+	}
+	return s.classRepo.GetByID(ctx, id)
+}
+
 // Delete removes a class.
 func (s *ClassService) Delete(ctx context.Context, id int) error {
 	// Note: Foreign key constraints on the `students` table will correctly prevent
 	// deletion if students are assigned to this class. The handler uses this error structure.
 	return s.classRepo.Delete(ctx, id)
 }
+
+// checkMajor verifies that code names an existing major, so a typo'd class cannot
+// silently miss the exam target rules written against the major.
+func (s *ClassService) checkMajor(ctx context.Context, code string) error {
+	_, err := s.majorRepo.GetByCode(ctx, code)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrUnknownMajor
+	}
+	return err
+}
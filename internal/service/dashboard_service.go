@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/queue"
 	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/stemsi/exstem-backend/internal/resilience"
 )
 
 // DashboardData consolidates all metrics for the admin dashboard.
@@ -20,12 +22,14 @@ type DashboardData struct {
 
 // DashboardService handles admin dashboard business logic.
 type DashboardService struct {
-	repo *repository.DashboardRepository
+	repo   *repository.DashboardRepository
+	queue  queue.Queue
+	health *resilience.HealthMonitor
 }
 
 // NewDashboardService creates a new DashboardService.
-func NewDashboardService(repo *repository.DashboardRepository) *DashboardService {
-	return &DashboardService{repo: repo}
+func NewDashboardService(repo *repository.DashboardRepository, q queue.Queue, health *resilience.HealthMonitor) *DashboardService {
+	return &DashboardService{repo: repo, queue: q, health: health}
 }
 
 // GetDashboardData orchestrates fetching all dashboard metrics concurrently or sequentially.
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// dashboardWidgetLimit caps the rows of list widgets.
+const dashboardWidgetLimit = 10
+
+// Dashboard errors.
+var (
+	ErrUnknownWidget = errors.New("unknown dashboard widget")
+	// ErrInvalidLayout wraps the reason a dashboard layout was refused.
+	ErrInvalidLayout = errors.New("invalid dashboard layout")
+)
+
+// ListWidgets returns the catalog of dashboard widgets.
+func (s *DashboardService) ListWidgets() []model.DashboardWidget {
+	return model.DashboardWidgets
+}
+
+// GetLayout returns the admin's dashboard layout, or the default layout (every widget
+// at its default size, filling the grid row by row) when they never saved one.
+func (s *DashboardService) GetLayout(ctx context.Context, adminID int) (*model.DashboardLayout, error) {
+	layout, err := s.repo.GetLayout(ctx, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("get dashboard layout: %w", err)
+	}
+	if layout != nil {
+		return layout, nil
+	}
+
+	layout = &model.DashboardLayout{Widgets: make([]model.WidgetPlacement, 0, len(model.DashboardWidgets))}
+	x, y, rowHeight := 0, 0, 0
+	for _, w := range model.DashboardWidgets {
+		if x+w.DefaultWidth > model.DashboardGridColumns {
+			x, y, rowHeight = 0, y+rowHeight, 0
+		}
+		layout.Widgets = append(layout.Widgets, model.WidgetPlacement{ID: w.ID, X: x, Y: y, Width: w.DefaultWidth, Height: w.DefaultHeight})
+		x += w.DefaultWidth
+		rowHeight = max(rowHeight, w.DefaultHeight)
+	}
+	return layout, nil
+}
+
+// UpdateLayout saves the admin's dashboard layout. Each widget must be in the catalog,
+// placed once and fit within the grid's width.
+func (s *DashboardService) UpdateLayout(ctx context.Context, adminID int, req model.UpdateDashboardLayoutRequest) (*model.DashboardLayout, error) {
+	seen := make(map[string]bool, len(req.Widgets))
+	for _, w := range req.Widgets {
+		if _, ok := model.FindDashboardWidget(w.ID); !ok {
+			return nil, fmt.Errorf("%w: unknown widget %q", ErrInvalidLayout, w.ID)
+		}
+		if seen[w.ID] {
+			return nil, fmt.Errorf("%w: widget %q is placed twice", ErrInvalidLayout, w.ID)
+		}
+		seen[w.ID] = true
+		if w.X+w.Width > model.DashboardGridColumns {
+			return nil, fmt.Errorf("%w: widget %q extends past column %d", ErrInvalidLayout, w.ID, model.DashboardGridColumns)
+		}
+	}
+
+	layout := &model.DashboardLayout{Widgets: req.Widgets}
+	if err := s.repo.UpsertLayout(ctx, adminID, layout); err != nil {
+		return nil, fmt.Errorf("save dashboard layout: %w", err)
+	}
+	return layout, nil
+}
+
+// GetWidgetData returns the data of a widget for [from, to). A zero from or to
+// defaults to the widget's usual range: the next 7 days of upcoming exams, the last
+// 30 days of results and the last 24 hours of cheat alerts. Queue health ignores
+// the range.
+func (s *DashboardService) GetWidgetData(ctx context.Context, id string, from, to time.Time) (interface{}, error) {
+	now := time.Now()
+	defaultRange := func(back, ahead time.Duration) {
+		if from.IsZero() {
+			from = now.Add(-back)
+		}
+		if to.IsZero() {
+			to = now.Add(ahead)
+		}
+	}
+
+	switch id {
+	case model.WidgetUpcomingExams:
+		defaultRange(0, 7*24*time.Hour)
+		return s.repo.GetUpcomingExamsBetween(ctx, model.LocalTime(from), model.LocalTime(to), dashboardWidgetLimit)
+
+	case model.WidgetRecentResults:
+		defaultRange(30*24*time.Hour, 0)
+		return s.repo.GetRecentExamResultsBetween(ctx, model.LocalTime(from), model.LocalTime(to), dashboardWidgetLimit)
+
+	case model.WidgetTopCheatAlerts:
+		defaultRange(24*time.Hour, 0)
+		return s.repo.GetTopCheatAlerts(ctx, from, to, dashboardWidgetLimit)
+
+	case model.WidgetQueueHealth:
+		return s.queueHealth(ctx), nil
+	}
+	return nil, ErrUnknownWidget
+}
+
+// queueHealth reads the length of every worker queue. Queues live in Redis, so none
+// are read while it is degraded.
+func (s *DashboardService) queueHealth(ctx context.Context) *model.QueueHealth {
+	health := &model.QueueHealth{RedisDegraded: s.health.Degraded(), Queues: map[string]int64{}}
+	if !health.RedisDegraded {
+		health.Queues = workerQueueLengths(ctx, s.queue)
+	}
+	return health
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/xuri/excelize/v2"
+)
+
+// e-Rapor errors.
+var (
+	ErrEraporNotConfigured = errors.New("e-rapor api is not configured")
+	ErrEraporNoScores      = errors.New("no completed exam scores for this subject")
+)
+
+// EraporService maps completed exam results onto e-Rapor's per-subject, per-competency
+// score import, either as an import workbook or pushed to the e-Rapor API.
+type EraporService struct {
+	repo   *repository.EraporRepository
+	cfg    *config.Config
+	client *http.Client
+}
+
+// NewEraporService creates a new EraporService.
+func NewEraporService(repo *repository.EraporRepository, cfg *config.Config) *EraporService {
+	return &EraporService{
+		repo:   repo,
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// GetMapping retrieves an exam's e-Rapor mapping.
+func (s *EraporService) GetMapping(ctx context.Context, examID uuid.UUID) (*model.EraporMapping, error) {
+	return s.repo.GetMapping(ctx, examID)
+}
+
+// SetMapping maps an exam to an e-Rapor subject and competency.
+func (s *EraporService) SetMapping(ctx context.Context, examID uuid.UUID, req model.UpsertEraporMappingRequest) (*model.EraporMapping, error) {
+	m := &model.EraporMapping{
+		ExamID:         examID,
+		SubjectCode:    req.SubjectCode,
+		CompetencyCode: req.CompetencyCode,
+	}
+	if err := s.repo.UpsertMapping(ctx, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DeleteMapping removes an exam's e-Rapor mapping.
+func (s *EraporService) DeleteMapping(ctx context.Context, examID uuid.UUID) error {
+	return s.repo.DeleteMapping(ctx, examID)
+}
+
+// ExportXLSX builds the e-Rapor score import workbook for a subject: one sheet per
+// class, one row per student and one column per competency.
+func (s *EraporService) ExportXLSX(ctx context.Context, subjectCode string, classID *int) ([]byte, error) {
+	scores, err := s.repo.ListScores(ctx, subjectCode, classID)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) == 0 {
+		return nil, ErrEraporNoScores
+	}
+
+	type studentRow struct {
+		score  model.EraporScore
+		byComp map[string]float64
+	}
+	var classes []string
+	students := make(map[string][]*studentRow)
+	competencies := make(map[string]map[string]bool)
+	index := make(map[int]*studentRow)
+
+	for _, sc := range scores {
+		row, ok := index[sc.StudentID]
+		if !ok {
+			row = &studentRow{score: sc, byComp: make(map[string]float64)}
+			index[sc.StudentID] = row
+			if _, seen := students[sc.ClassName]; !seen {
+				classes = append(classes, sc.ClassName)
+				competencies[sc.ClassName] = make(map[string]bool)
+			}
+			students[sc.ClassName] = append(students[sc.ClassName], row)
+		}
+		row.byComp[sc.CompetencyCode] = sc.Score
+		competencies[sc.ClassName][sc.CompetencyCode] = true
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+
+	for i, className := range classes {
+		sheet := className
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", sheet); err != nil {
+				return nil, err
+			}
+		} else if _, err := f.NewSheet(sheet); err != nil {
+			return nil, err
+		}
+
+		comps := make([]string, 0, len(competencies[className]))
+		for code := range competencies[className] {
+			comps = append(comps, code)
+		}
+		sort.Strings(comps)
+
+		header := []interface{}{"No", "NIS", "NISN", "Nama", "Mata Pelajaran"}
+		for _, code := range comps {
+			header = append(header, code)
+		}
+		if err := f.SetSheetRow(sheet, "A1", &header); err != nil {
+			return nil, err
+		}
+		lastCol, _ := excelize.ColumnNumberToName(len(header))
+		_ = f.SetCellStyle(sheet, "A1", lastCol+"1", headerStyle)
+
+		for n, row := range students[className] {
+			values := []interface{}{n + 1, row.score.NIS, row.score.NISN, row.score.Name, subjectCode}
+			for _, code := range comps {
+				if v, ok := row.byComp[code]; ok {
+					values = append(values, v)
+				} else {
+					values = append(values, "")
+				}
+			}
+			cell, _ := excelize.CoordinatesToCellName(1, n+2)
+			if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Push sends a subject's competency scores to the configured e-Rapor API.
+func (s *EraporService) Push(ctx context.Context, subjectCode string, classID *int) (*model.EraporPushResult, error) {
+	if s.cfg.EraporAPIURL == "" {
+		return nil, ErrEraporNotConfigured
+	}
+
+	scores, err := s.repo.ListScores(ctx, subjectCode, classID)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) == 0 {
+		return nil, ErrEraporNoScores
+	}
+
+	body, err := json.Marshal(struct {
+		SubjectCode string              `json:"subject_code"`
+		Scores      []model.EraporScore `json:"scores"`
+	}{subjectCode, scores})
+	if err != nil {
+		return nil, fmt.Errorf("encode scores: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.EraporAPIURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.EraporAPIToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.EraporAPIToken)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("push scores: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("e-rapor returned status: %d", resp.StatusCode)
+	}
+
+	return &model.EraporPushResult{SubjectCode: subjectCode, Pushed: len(scores)}, nil
+}
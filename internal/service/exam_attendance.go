@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// attendanceNoRoom names the group of students without a room assignment.
+const attendanceNoRoom = "Tanpa Ruangan"
+
+// Attendance builds an exam's attendance sheet: the students it targets and those who
+// joined it, marked by whether they did, grouped by class or by room. Rooms list their
+// students by seat.
+func (s *ExamService) Attendance(ctx context.Context, examID uuid.UUID, groupBy string) (*model.ExamAttendance, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.targetRepo.ListAttendance(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("list attendance: %w", err)
+	}
+	metadata, err := s.FormatMetadata(ctx, exam.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("format exam metadata: %w", err)
+	}
+
+	if groupBy == model.AttendanceByRoom {
+		sort.SliceStable(rows, func(i, j int) bool {
+			a, b := rows[i], rows[j]
+			if (a.RoomName == "") != (b.RoomName == "") {
+				return b.RoomName == ""
+			}
+			if a.RoomName != b.RoomName {
+				return a.RoomName < b.RoomName
+			}
+			return a.SeatNumber < b.SeatNumber
+		})
+	}
+
+	attendance := &model.ExamAttendance{
+		ExamID:         exam.ID,
+		Title:          exam.Title,
+		ScheduledStart: exam.ScheduledStart,
+		GroupBy:        groupBy,
+		Groups:         []model.AttendanceGroup{},
+		Metadata:       metadata,
+	}
+	for _, row := range rows {
+		name := row.ClassName
+		if groupBy == model.AttendanceByRoom {
+			name = row.RoomName
+			if name == "" {
+				name = attendanceNoRoom
+			}
+		}
+		if n := len(attendance.Groups); n == 0 || attendance.Groups[n-1].Name != name {
+			attendance.Groups = append(attendance.Groups, model.AttendanceGroup{Name: name})
+		}
+		group := &attendance.Groups[len(attendance.Groups)-1]
+		group.Rows = append(group.Rows, row)
+	}
+	return attendance, nil
+}
+
+// AttendancePDF renders an attendance sheet with the school's branding.
+func (s *ExamService) AttendancePDF(ctx context.Context, attendance *model.ExamAttendance) ([]byte, error) {
+	var school SchoolInfo
+	if setting, err := s.settingRepo.GetByKey(ctx, "school_name"); err == nil {
+		school.Name = setting.Value
+	}
+	if setting, err := s.settingRepo.GetByKey(ctx, "school_logo_url"); err == nil {
+		school.LogoURL = setting.Value
+	}
+	return GenerateAttendancePDF(attendance, school)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// A student's breaks live in a Redis hash: "count" breaks taken, "paused" seconds spent
+// on finished breaks and, while a break runs, its "since" start (unix seconds). A break
+// that outlives the exam's limit counts as ended at the limit. exam_breaks in
+// PostgreSQL keeps a record of every break for proctors and the degraded-mode timer.
+
+// Break errors.
+var (
+	ErrBreaksDisabled    = errors.New("exam does not allow breaks")
+	ErrBreakLimitReached = errors.New("no breaks left")
+	ErrBreakActive       = errors.New("a break is already running")
+	ErrNoActiveBreak     = errors.New("no break is running")
+	// ErrBreakNotRecorded wraps a failure to record a break in PostgreSQL. The break
+	// itself has taken effect.
+	ErrBreakNotRecorded = errors.New("break not recorded")
+)
+
+// startBreakScript settles an expired break (ARGV[3] = limit seconds), then starts a new
+// one at ARGV[2] if fewer than ARGV[1] were taken. It returns the new break count, -1
+// while a break runs and -2 when no breaks are left.
+var startBreakScript = redis.NewScript(`
+local since = tonumber(redis.call("HGET", KEYS[1], "since") or "-1")
+if since >= 0 then
+	if tonumber(ARGV[2]) - since < tonumber(ARGV[3]) then
+		return -1
+	end
+	redis.call("HINCRBY", KEYS[1], "paused", ARGV[3])
+	redis.call("HDEL", KEYS[1], "since")
+end
+local count = tonumber(redis.call("HGET", KEYS[1], "count") or "0")
+if count >= tonumber(ARGV[1]) then
+	return -2
+end
+redis.call("HSET", KEYS[1], "since", ARGV[2], "count", count + 1)
+return count + 1
+`)
+
+// endBreakScript ends the running break at ARGV[1], capped at ARGV[2] seconds, and
+// returns its length, or -1 when no break runs.
+var endBreakScript = redis.NewScript(`
+local since = tonumber(redis.call("HGET", KEYS[1], "since") or "-1")
+if since < 0 then
+	return -1
+end
+local elapsed = math.min(tonumber(ARGV[1]) - since, tonumber(ARGV[2]))
+redis.call("HINCRBY", KEYS[1], "paused", elapsed)
+redis.call("HDEL", KEYS[1], "since")
+return elapsed
+`)
+
+// GetBreakPolicy returns an exam's cached break allowance. Exams cached before breaks
+// existed allow none.
+func (s *ExamSessionService) GetBreakPolicy(ctx context.Context, examID uuid.UUID) (model.BreakPolicy, error) {
+	var policy model.BreakPolicy
+	raw, err := s.rdb.Get(ctx, config.CacheKey.ExamBreakPolicyKey(examID.String())).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("get break policy: %w", err)
+	}
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return policy, fmt.Errorf("unmarshal break policy: %w", err)
+	}
+	return policy, nil
+}
+
+// StartBreak pauses the student's timer and locks their paper for up to the exam's
+// break length.
+func (s *ExamSessionService) StartBreak(ctx context.Context, examID uuid.UUID, studentID int, policy model.BreakPolicy) (*model.BreakStatus, error) {
+	if !policy.Enabled() {
+		return nil, ErrBreaksDisabled
+	}
+
+	key := config.CacheKey.StudentBreaksKey(examID.String(), studentID)
+	now := time.Now()
+	res, err := startBreakScript.Run(ctx, s.rdb, []string{key}, policy.MaxBreaks, now.Unix(), int(policy.Limit().Seconds())).Int()
+	if err != nil {
+		return nil, fmt.Errorf("start break: %w", err)
+	}
+	switch res {
+	case -1:
+		return nil, ErrBreakActive
+	case -2:
+		return nil, ErrBreakLimitReached
+	}
+
+	status, err := s.GetBreakStatus(ctx, examID, studentID, policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessionRepo.StartBreak(ctx, examID, studentID, policy.BreakMinutes); err != nil {
+		return status, fmt.Errorf("%w: %v", ErrBreakNotRecorded, err)
+	}
+	return status, nil
+}
+
+// EndBreak resumes the student's timer and unlocks their paper.
+func (s *ExamSessionService) EndBreak(ctx context.Context, examID uuid.UUID, studentID int, policy model.BreakPolicy) (*model.BreakStatus, error) {
+	key := config.CacheKey.StudentBreaksKey(examID.String(), studentID)
+	res, err := endBreakScript.Run(ctx, s.rdb, []string{key}, time.Now().Unix(), int(policy.Limit().Seconds())).Int()
+	if err != nil {
+		return nil, fmt.Errorf("end break: %w", err)
+	}
+	if res < 0 {
+		return nil, ErrNoActiveBreak
+	}
+
+	status, err := s.GetBreakStatus(ctx, examID, studentID, policy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessionRepo.EndBreak(ctx, examID, studentID, policy.BreakMinutes); err != nil {
+		return status, fmt.Errorf("%w: %v", ErrBreakNotRecorded, err)
+	}
+	return status, nil
+}
+
+// GetBreakStatus returns the student's break usage from Redis.
+func (s *ExamSessionService) GetBreakStatus(ctx context.Context, examID uuid.UUID, studentID int, policy model.BreakPolicy) (*model.BreakStatus, error) {
+	raw, err := s.rdb.HGetAll(ctx, config.CacheKey.StudentBreaksKey(examID.String(), studentID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get breaks: %w", err)
+	}
+
+	count, _ := strconv.Atoi(raw["count"])
+	paused, _ := strconv.Atoi(raw["paused"])
+	var since *time.Time
+	if v, err := strconv.ParseInt(raw["since"], 10, 64); err == nil {
+		t := time.Unix(v, 0)
+		since = &t
+	}
+	return breakStatus(policy, count, paused, since, time.Now()), nil
+}
+
+// OnBreak reports whether the student is on a break in the exam. Without Redis breaks
+// cannot be checked and the paper stays unlocked.
+func (s *ExamSessionService) OnBreak(ctx context.Context, examID uuid.UUID, studentID int) (bool, error) {
+	if s.health.Degraded() {
+		return false, nil
+	}
+	policy, err := s.GetBreakPolicy(ctx, examID)
+	if err != nil || !policy.Enabled() {
+		return false, err
+	}
+	status, err := s.GetBreakStatus(ctx, examID, studentID, policy)
+	if err != nil {
+		return false, err
+	}
+	return status.OnBreak, nil
+}
+
+// breakStatus builds a student's break status at now. A break past its limit is
+// reported as finished after exactly the limit.
+func breakStatus(policy model.BreakPolicy, count, paused int, since *time.Time, now time.Time) *model.BreakStatus {
+	status := &model.BreakStatus{
+		BreaksUsed:    count,
+		BreaksAllowed: policy.MaxBreaks,
+		BreakMinutes:  policy.BreakMinutes,
+		PausedSeconds: paused,
+	}
+	if since == nil {
+		return status
+	}
+
+	endsAt := since.Add(policy.Limit())
+	if !now.Before(endsAt) {
+		status.PausedSeconds += int(policy.Limit().Seconds())
+		return status
+	}
+	status.OnBreak = true
+	status.StartedAt = since
+	status.EndsAt = &endsAt
+	return status
+}
+
+// breakStatusFromDB rebuilds a student's break status from PostgreSQL while Redis is
+// down. It returns nil when the exam allows no breaks.
+func (s *ExamSessionService) breakStatusFromDB(ctx context.Context, exam *model.Exam, studentID int) (*model.BreakStatus, error) {
+	policy := model.BreakPolicy{MaxBreaks: exam.MaxBreaks, BreakMinutes: exam.BreakMinutes}
+	if !policy.Enabled() {
+		return nil, nil
+	}
+	count, paused, since, err := s.sessionRepo.GetBreakUsage(ctx, exam.ID, studentID, policy.BreakMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("get break usage: %w", err)
+	}
+	return breakStatus(policy, count, paused, since, time.Now()), nil
+}
+
+// timerEnd returns when a session's timer runs out, given its break status. While a
+// break runs the timer stands still, so the end keeps moving with the clock.
+func timerEnd(startedAt time.Time, durationMinutes int, breaks *model.BreakStatus, now time.Time) time.Time {
+	end := startedAt.Add(time.Duration(durationMinutes) * time.Minute)
+	if breaks == nil {
+		return end
+	}
+	end = end.Add(time.Duration(breaks.PausedSeconds) * time.Second)
+	if breaks.OnBreak {
+		end = end.Add(now.Sub(*breaks.StartedAt))
+	}
+	return end
+}
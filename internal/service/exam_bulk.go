@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// Bulk applies action to each exam of examIDs in turn, as the single-exam endpoints
+// would, and reports the outcome of each. A failure fails only its item; repeated
+// IDs are handled once.
+func (s *ExamService) Bulk(ctx context.Context, action model.BulkExamAction, examIDs []uuid.UUID) *model.BulkExamResult {
+	result := &model.BulkExamResult{Action: action, Items: make([]model.BulkExamItem, 0, len(examIDs))}
+	seen := make(map[uuid.UUID]bool, len(examIDs))
+	for _, id := range examIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		item := model.BulkExamItem{ExamID: id}
+		switch action {
+		case model.BulkExamPublish:
+			item.Conflicts, item.Err = s.Publish(ctx, id)
+		case model.BulkExamArchive:
+			item.Err = s.Archive(ctx, id)
+		case model.BulkExamDelete:
+			item.Err = s.Delete(ctx, id)
+		}
+
+		if item.Err != nil {
+			item.Status = "failed"
+			result.Failed++
+		} else {
+			item.Status = "ok"
+			result.Succeeded++
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// examWindow returns the time span an exam occupies. Exams without a scheduled end
+// are assumed to run for their duration from the scheduled start.
+func examWindow(e *model.Exam) (time.Time, time.Time, bool) {
+	if e.ScheduledStart == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	start := e.ScheduledStart.Time()
+	if e.ScheduledEnd != nil {
+		return start, e.ScheduledEnd.Time(), true
+	}
+	return start, start.Add(time.Duration(e.DurationMinutes) * time.Minute), true
+}
+
+// findConflicts returns one entry per ordered pair of exams in a that overlap in time with
+// an exam in b while sharing at least one targeted class. Pairs of the same exam are skipped.
+func findConflicts(a, b []model.Exam, classes map[uuid.UUID][]int) []model.ExamConflict {
+	var conflicts []model.ExamConflict
+	for i := range a {
+		aStart, aEnd, ok := examWindow(&a[i])
+		if !ok {
+			continue
+		}
+		for j := range b {
+			if a[i].ID == b[j].ID {
+				continue
+			}
+			bStart, bEnd, ok := examWindow(&b[j])
+			if !ok || !aStart.Before(bEnd) || !bStart.Before(aEnd) {
+				continue
+			}
+
+			shared := intersectInts(classes[a[i].ID], classes[b[j].ID])
+			if len(shared) == 0 {
+				continue
+			}
+
+			overlapStart, overlapEnd := aStart, aEnd
+			if bStart.After(overlapStart) {
+				overlapStart = bStart
+			}
+			if bEnd.Before(overlapEnd) {
+				overlapEnd = bEnd
+			}
+
+			conflicts = append(conflicts, model.ExamConflict{
+				ExamID:       a[i].ID,
+				OtherExamID:  b[j].ID,
+				OtherTitle:   b[j].Title,
+				ClassIDs:     shared,
+				OverlapStart: model.LocalTime(overlapStart),
+				OverlapEnd:   model.LocalTime(overlapEnd),
+			})
+		}
+	}
+	return conflicts
+}
+
+func intersectInts(a, b []int) []int {
+	set := make(map[int]bool, len(a))
+	for _, v := range a {
+		set[v] = true
+	}
+	var out []int
+	for _, v := range b {
+		if set[v] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// checkScheduleConflicts finds live exams that overlap exam's window and share a targeted class.
+// A pending target rule (new, or replacing the saved rule with the same ID) is taken into
+// account before it is stored. Under the "block" policy a non-empty result is returned
+// together with ErrScheduleConflict.
+func (s *ExamService) checkScheduleConflicts(ctx context.Context, exam *model.Exam, pending *model.ExamTargetRule) ([]model.ExamConflict, error) {
+	if _, _, ok := examWindow(exam); !ok {
+		return nil, nil
+	}
+
+	rules, err := s.targetRepo.ListByExam(ctx, exam.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list target rules: %w", err)
+	}
+	if pending != nil {
+		replaced := false
+		for i := range rules {
+			if pending.ID != 0 && rules[i].ID == pending.ID {
+				rules[i] = *pending
+				replaced = true
+			}
+		}
+		if !replaced {
+			rules = append(rules, *pending)
+		}
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	own, err := s.classesForRules(ctx, rules)
+	if err != nil {
+		return nil, err
+	}
+	conflicts, err := s.overlapConflicts(ctx, exam, own, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 && s.conflictPolicy(ctx) == model.ExamConflictPolicyBlock {
+		return conflicts, ErrScheduleConflict
+	}
+	return conflicts, nil
+}
+
+// checkReplacedRulesConflicts is checkScheduleConflicts for exams whose target rules
+// are all about to be replaced with rules, as when an assessment hands its rules to
+// its exams. Each exam is checked with the new rules, against the others with theirs.
+func (s *ExamService) checkReplacedRulesConflicts(ctx context.Context, exams []*model.Exam, rules []model.AddTargetRuleRequest) ([]model.ExamConflict, error) {
+	if len(exams) == 0 || len(rules) == 0 {
+		return nil, nil
+	}
+	pending := make([]model.ExamTargetRule, len(rules))
+	for i, r := range rules {
+		pending[i] = model.ExamTargetRule{ClassID: r.ClassID, GradeLevel: r.GradeLevel, MajorCode: r.MajorCode, Religion: r.Religion}
+	}
+	own, err := s.classesForRules(ctx, pending)
+	if err != nil {
+		return nil, err
+	}
+	replaced := make(map[uuid.UUID][]int, len(exams))
+	for _, e := range exams {
+		replaced[e.ID] = own
+	}
+
+	var conflicts []model.ExamConflict
+	for _, e := range exams {
+		found, err := s.overlapConflicts(ctx, e, own, replaced)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, found...)
+	}
+	if len(conflicts) > 0 && s.conflictPolicy(ctx) == model.ExamConflictPolicyBlock {
+		return conflicts, ErrScheduleConflict
+	}
+	return conflicts, nil
+}
+
+// classesForRules resolves the classes any of rules targets.
+func (s *ExamService) classesForRules(ctx context.Context, rules []model.ExamTargetRule) ([]int, error) {
+	seen := make(map[int]bool)
+	var classes []int
+	for i := range rules {
+		ids, err := s.targetRepo.ListClassesForRule(ctx, &rules[i])
+		if err != nil {
+			return nil, fmt.Errorf("resolve target rule: %w", err)
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				classes = append(classes, id)
+			}
+		}
+	}
+	return classes, nil
+}
+
+// overlapConflicts finds live exams that overlap exam's window and share one of own, the
+// classes exam targets. replaced holds the classes of exams whose stored rules are
+// about to change.
+func (s *ExamService) overlapConflicts(ctx context.Context, exam *model.Exam, own []int, replaced map[uuid.UUID][]int) ([]model.ExamConflict, error) {
+	start, end, ok := examWindow(exam)
+	if !ok || len(own) == 0 {
+		return nil, nil
+	}
+
+	candidates, err := s.examRepo.ListScheduledBetween(ctx, model.LocalTime(start), model.LocalTime(end))
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled exams: %w", err)
+	}
+	var others []model.Exam
+	ids := make([]uuid.UUID, 0, len(candidates))
+	for _, e := range candidates {
+		if e.ID != exam.ID && e.Status.IsLive() {
+			others = append(others, e)
+			ids = append(ids, e.ID)
+		}
+	}
+	if len(others) == 0 {
+		return nil, nil
+	}
+
+	classes, err := s.targetRepo.ListTargetedClasses(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("list targeted classes: %w", err)
+	}
+	for id, c := range replaced {
+		classes[id] = c
+	}
+	classes[exam.ID] = own
+
+	return findConflicts([]model.Exam{*exam}, others, classes), nil
+}
+
+// conflictPolicy reads the exam_conflict_policy setting, falling back to "warn".
+func (s *ExamService) conflictPolicy(ctx context.Context) string {
+	setting, err := s.settingRepo.GetByKey(ctx, model.SettingExamConflictPolicy)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			s.log.Warn().Err(err).Msg("Failed to read exam conflict policy, defaulting to warn")
+		}
+		return model.ExamConflictPolicyWarn
+	}
+	if setting.Value == model.ExamConflictPolicyBlock {
+		return model.ExamConflictPolicyBlock
+	}
+	return model.ExamConflictPolicyWarn
+}
+
+// GetCalendar returns scheduled exams in [from, to) grouped by start day,
+// flagging exams that overlap while targeting the same class.
+func (s *ExamService) GetCalendar(ctx context.Context, from, to time.Time) (*model.ExamCalendar, error) {
+	exams, err := s.examRepo.ListScheduledBetween(ctx, model.LocalTime(from), model.LocalTime(to))
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled exams: %w", err)
+	}
+
+	ids := make([]uuid.UUID, len(exams))
+	for i := range exams {
+		ids[i] = exams[i].ID
+	}
+	classes, err := s.targetRepo.ListTargetedClasses(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("list targeted classes: %w", err)
+	}
+
+	// Each overlapping pair shows up twice (A→B and B→A); keep one for the summary
+	// but use both directions to fill each exam's conflicts_with.
+	pairs := findConflicts(exams, exams, classes)
+	conflictsWith := make(map[uuid.UUID][]uuid.UUID)
+	conflicts := []model.ExamConflict{}
+	for _, c := range pairs {
+		conflictsWith[c.ExamID] = append(conflictsWith[c.ExamID], c.OtherExamID)
+		if c.ExamID.String() < c.OtherExamID.String() {
+			conflicts = append(conflicts, c)
+		}
+	}
+
+	calendar := &model.ExamCalendar{
+		From:      from.Format("2006-01-02"),
+		To:        to.AddDate(0, 0, -1).Format("2006-01-02"), // last included day
+		Days:      []model.CalendarDay{},
+		Conflicts: conflicts,
+	}
+	for i := range exams {
+		e := &exams[i]
+		entry := model.CalendarExam{
+			ID:              e.ID,
+			Title:           e.Title,
+			Status:          e.Status,
+			ScheduledStart:  e.ScheduledStart,
+			ScheduledEnd:    e.ScheduledEnd,
+			DurationMinutes: e.DurationMinutes,
+			ClassIDs:        classes[e.ID],
+			ConflictsWith:   conflictsWith[e.ID],
+		}
+		if entry.ClassIDs == nil {
+			entry.ClassIDs = []int{}
+		}
+		if entry.ConflictsWith == nil {
+			entry.ConflictsWith = []uuid.UUID{}
+		}
+
+		// Exams are ordered by start, so days come out sorted. Exams that began
+		// before the range are listed on its first day.
+		day := e.ScheduledStart.Time()
+		if day.Before(from) {
+			day = from
+		}
+		date := day.Format("2006-01-02")
+		if n := len(calendar.Days); n == 0 || calendar.Days[n-1].Date != date {
+			calendar.Days = append(calendar.Days, model.CalendarDay{Date: date})
+		}
+		last := &calendar.Days[len(calendar.Days)-1]
+		last.Exams = append(last.Exams, entry)
+	}
+
+	return calendar, nil
+}
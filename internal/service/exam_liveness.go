@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stemsi/exstem-backend/internal/config"
+)
+
+// Each exam keeps a Redis hash of when every student's socket was last heard from
+// (unix seconds), refreshed by pongs and messages. Exams with a disconnect pause credit
+// a student the part of a silence that went past it, which extends their timer like
+// a break; exam_sessions.disconnect_paused_seconds keeps a copy for the degraded-mode
+// timer.
+
+// ErrDisconnectPauseNotRecorded wraps a failure to record a disconnect pause in
+// PostgreSQL. The pause itself has taken effect.
+var ErrDisconnectPauseNotRecorded = errors.New("disconnect pause not recorded")
+
+// settleDisconnectScript marks student ARGV[1] seen at ARGV[2] and, when they were
+// silent for longer than ARGV[3] seconds (0 disables the pause), credits the excess to
+// their paused seconds. It returns the seconds credited.
+var settleDisconnectScript = redis.NewScript(`
+local last = tonumber(redis.call("HGET", KEYS[1], ARGV[1]) or "-1")
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+local threshold = tonumber(ARGV[3])
+if last < 0 or threshold <= 0 then
+	return 0
+end
+local excess = tonumber(ARGV[2]) - last - threshold
+if excess <= 0 then
+	return 0
+end
+redis.call("HINCRBY", KEYS[2], ARGV[1], excess)
+return excess
+`)
+
+// TouchSession records that the student's exam socket is alive.
+func (s *ExamSessionService) TouchSession(ctx context.Context, examID uuid.UUID, studentID int) error {
+	return s.rdb.HSet(ctx, config.CacheKey.ExamLastSeenKey(examID.String()), studentID, time.Now().Unix()).Err()
+}
+
+// SettleDisconnect credits the student for the part of their last silence that went
+// past the exam's disconnect pause and marks them seen. It returns the seconds
+// credited.
+func (s *ExamSessionService) SettleDisconnect(ctx context.Context, examID uuid.UUID, studentID int) (int, error) {
+	pause, err := s.rdb.Get(ctx, config.CacheKey.ExamDisconnectPauseKey(examID.String())).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("get disconnect pause: %w", err)
+	}
+
+	keys := []string{
+		config.CacheKey.ExamLastSeenKey(examID.String()),
+		config.CacheKey.ExamDisconnectPausedKey(examID.String()),
+	}
+	credited, err := settleDisconnectScript.Run(ctx, s.rdb, keys, studentID, time.Now().Unix(), pause*60).Int()
+	if err != nil {
+		return 0, fmt.Errorf("settle disconnect: %w", err)
+	}
+	if credited > 0 {
+		if err := s.sessionRepo.AddDisconnectPause(ctx, examID, studentID, credited); err != nil {
+			return credited, fmt.Errorf("%w: %v", ErrDisconnectPauseNotRecorded, err)
+		}
+	}
+	return credited, nil
+}
+
+// DisconnectPaused returns the seconds the student's timer was paused for prolonged
+// disconnects.
+func (s *ExamSessionService) DisconnectPaused(ctx context.Context, examID uuid.UUID, studentID int) (int, error) {
+	paused, err := s.rdb.HGet(ctx, config.CacheKey.ExamDisconnectPausedKey(examID.String()), strconv.Itoa(studentID)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("get disconnect pause: %w", err)
+	}
+	return paused, nil
+}
+
+// IdleStudents returns, keyed by student ID, how many seconds each student of an exam
+// has been silent for, leaving out those heard from within after.
+func (s *ExamSessionService) IdleStudents(ctx context.Context, examID uuid.UUID, after time.Duration) (map[int]int, error) {
+	raw, err := s.rdb.HGetAll(ctx, config.CacheKey.ExamLastSeenKey(examID.String())).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get last seen: %w", err)
+	}
+	now := time.Now().Unix()
+	idle := make(map[int]int)
+	for sid, v := range raw {
+		id, err1 := strconv.Atoi(sid)
+		seen, err2 := strconv.ParseInt(v, 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if silent := now - seen; silent > int64(after.Seconds()) {
+			idle[id] = int(silent)
+		}
+	}
+	return idle, nil
+}
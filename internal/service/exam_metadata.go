@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+var (
+	// ErrInvalidExamMetadata is returned when an exam's metadata does not match the
+	// exam metadata schema. The wrapping error lists every problem.
+	ErrInvalidExamMetadata = errors.New("invalid exam metadata")
+	// ErrInvalidMetadataSchema is returned when the exam metadata schema setting is
+	// saved malformed.
+	ErrInvalidMetadataSchema = errors.New("invalid exam metadata schema")
+)
+
+const (
+	// maxMetadataFields bounds the fields of an exam's metadata and of the schema.
+	maxMetadataFields = 30
+	// maxMetadataText bounds a text value, or an item of a list, in characters.
+	maxMetadataText = 255
+	// maxMetadataListItems bounds the items of a list value.
+	maxMetadataListItems = 50
+)
+
+// metadataKeyPattern is the form of metadata keys, which list filters take as query
+// parameter names.
+var metadataKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// ParseExamMetadataSchema parses the exam metadata schema setting. An empty value is
+// no schema.
+func ParseExamMetadataSchema(raw string) ([]model.ExamMetadataField, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var fields []model.ExamMetadataField
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMetadataSchema, err)
+	}
+	if len(fields) > maxMetadataFields {
+		return nil, fmt.Errorf("%w: at most %d fields", ErrInvalidMetadataSchema, maxMetadataFields)
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if !metadataKeyPattern.MatchString(f.Key) {
+			return nil, fmt.Errorf("%w: key %q must be lowercase letters, digits and underscores", ErrInvalidMetadataSchema, f.Key)
+		}
+		if seen[f.Key] {
+			return nil, fmt.Errorf("%w: key %q is defined twice", ErrInvalidMetadataSchema, f.Key)
+		}
+		seen[f.Key] = true
+
+		switch f.Type {
+		case model.ExamMetadataString, model.ExamMetadataNumber, model.ExamMetadataList:
+		case model.ExamMetadataEnum:
+			if len(f.Options) == 0 {
+				return nil, fmt.Errorf("%w: enum %q has no options", ErrInvalidMetadataSchema, f.Key)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %q has unknown type %q", ErrInvalidMetadataSchema, f.Key, f.Type)
+		}
+	}
+	return fields, nil
+}
+
+// metadataSchema reads the exam metadata schema setting. Without one, exam metadata
+// is free-form and nil is returned.
+func (s *ExamService) metadataSchema(ctx context.Context) ([]model.ExamMetadataField, error) {
+	setting, err := s.settingRepo.GetByKey(ctx, model.SettingExamMetadataSchema)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ParseExamMetadataSchema(setting.Value)
+}
+
+// checkMetadata validates an exam's metadata against the schema and returns it
+// normalized: text trimmed and empty values dropped. With a schema, unknown keys are
+// refused and required fields enforced; without one any keys are accepted, holding
+// text, numbers, booleans or lists of text.
+func (s *ExamService) checkMetadata(ctx context.Context, metadata map[string]any) (map[string]any, error) {
+	schema, err := s.metadataSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	normalized := make(map[string]any, len(metadata))
+	if len(metadata) > maxMetadataFields {
+		problems = append(problems, fmt.Sprintf("at most %d fields", maxMetadataFields))
+	}
+
+	if schema == nil {
+		for key, value := range metadata {
+			if !metadataKeyPattern.MatchString(key) {
+				problems = append(problems, fmt.Sprintf("key %q must be lowercase letters, digits and underscores", key))
+				continue
+			}
+			if b, ok := value.(bool); ok {
+				normalized[key] = b
+				continue
+			}
+			v, problem := normalizeMetadataValue(key, value, "")
+			if problem != "" {
+				problems = append(problems, problem)
+			} else if v != nil {
+				normalized[key] = v
+			}
+		}
+	} else {
+		known := make(map[string]bool, len(schema))
+		for _, f := range schema {
+			known[f.Key] = true
+			v, problem := normalizeMetadataValue(f.Key, metadata[f.Key], f.Type)
+			if problem == "" && v != nil && f.Type == model.ExamMetadataEnum && !slices.Contains(f.Options, v.(string)) {
+				problem = fmt.Sprintf("%s must be one of %s", f.Key, strings.Join(f.Options, ", "))
+			}
+			switch {
+			case problem != "":
+				problems = append(problems, problem)
+			case v != nil:
+				normalized[f.Key] = v
+			case f.Required:
+				problems = append(problems, fmt.Sprintf("%s is required", f.Key))
+			}
+		}
+		for key := range metadata {
+			if !known[key] {
+				problems = append(problems, fmt.Sprintf("%s is not a metadata field", key))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidExamMetadata, strings.Join(problems, "; "))
+	}
+	return normalized, nil
+}
+
+// normalizeMetadataValue checks value against a field type, or against any of text,
+// number and list when typ is empty. It returns nil for an absent or empty value and
+// a problem when the value does not fit.
+func normalizeMetadataValue(key string, value any, typ model.ExamMetadataType) (any, string) {
+	switch v := value.(type) {
+	case nil:
+		return nil, ""
+	case string:
+		if typ != "" && typ != model.ExamMetadataString && typ != model.ExamMetadataEnum {
+			break
+		}
+		v = strings.TrimSpace(v)
+		if len([]rune(v)) > maxMetadataText {
+			return nil, fmt.Sprintf("%s must be at most %d characters", key, maxMetadataText)
+		}
+		if v == "" {
+			return nil, ""
+		}
+		return v, ""
+	case float64:
+		if typ != "" && typ != model.ExamMetadataNumber {
+			break
+		}
+		return v, ""
+	case []any:
+		if typ != "" && typ != model.ExamMetadataList {
+			break
+		}
+		if len(v) > maxMetadataListItems {
+			return nil, fmt.Sprintf("%s must have at most %d items", key, maxMetadataListItems)
+		}
+		items := make([]any, 0, len(v))
+		for _, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, fmt.Sprintf("%s must be a list of text", key)
+			}
+			text = strings.TrimSpace(text)
+			if len([]rune(text)) > maxMetadataText {
+				return nil, fmt.Sprintf("%s items must be at most %d characters", key, maxMetadataText)
+			}
+			if text != "" {
+				items = append(items, text)
+			}
+		}
+		if len(items) == 0 {
+			return nil, ""
+		}
+		return items, ""
+	}
+
+	switch typ {
+	case model.ExamMetadataNumber:
+		return nil, fmt.Sprintf("%s must be a number", key)
+	case model.ExamMetadataList:
+		return nil, fmt.Sprintf("%s must be a list of text", key)
+	case "":
+		return nil, fmt.Sprintf("%s must be text, a number, a boolean or a list of text", key)
+	default:
+		return nil, fmt.Sprintf("%s must be text", key)
+	}
+}
+
+// metadataFilter turns list filters, metadata key to the value asked for, into the
+// JSON object exams' metadata must contain. Values are typed after the schema: a
+// list field matches exams whose list holds the value. Without a schema, values
+// match text fields.
+func (s *ExamService) metadataFilter(ctx context.Context, filters map[string]string) (map[string]any, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	schema, err := s.metadataSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]model.ExamMetadataType, len(schema))
+	for _, f := range schema {
+		types[f.Key] = f.Type
+	}
+
+	contains := make(map[string]any, len(filters))
+	for key, value := range filters {
+		typ, known := types[key]
+		if schema != nil && !known {
+			return nil, fmt.Errorf("%w: %s is not a metadata field", ErrInvalidExamMetadata, key)
+		}
+		switch typ {
+		case model.ExamMetadataNumber:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidExamMetadata, key)
+			}
+			contains[key] = n
+		case model.ExamMetadataList:
+			contains[key] = []any{value}
+		default:
+			contains[key] = value
+		}
+	}
+	return contains, nil
+}
+
+// FormatMetadata renders an exam's metadata for exports, labelled and ordered as in
+// the schema. Keys the schema does not know, as all are without one, follow in key
+// order, labelled by their key.
+func (s *ExamService) FormatMetadata(ctx context.Context, metadata map[string]any) ([]model.ExamMetadataValue, error) {
+	schema, err := s.metadataSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return formatMetadata(schema, metadata), nil
+}
+
+func formatMetadata(schema []model.ExamMetadataField, metadata map[string]any) []model.ExamMetadataValue {
+	values := make([]model.ExamMetadataValue, 0, len(metadata))
+	known := make(map[string]bool, len(schema))
+	for _, f := range schema {
+		known[f.Key] = true
+		if v, ok := metadata[f.Key]; ok {
+			label := f.Label
+			if label == "" {
+				label = f.Key
+			}
+			values = append(values, model.ExamMetadataValue{Key: f.Key, Label: label, Value: formatMetadataValue(v)})
+		}
+	}
+
+	var rest []string
+	for key := range metadata {
+		if !known[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	for _, key := range rest {
+		values = append(values, model.ExamMetadataValue{Key: key, Label: key, Value: formatMetadataValue(metadata[key])})
+	}
+	return values
+}
+
+func formatMetadataValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
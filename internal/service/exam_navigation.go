@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// The server tracks the position in their question order each student is on, so the
+// live monitor can show it. Restricted navigation also tracks the furthest position a
+// student has reached: questions before the start of that position's lock block (the
+// position itself for LINEAR, its section for SECTION_LOCKED) can no longer be answered.
+
+// ErrQuestionLocked is returned when a student answers a question the navigation policy has locked.
+var ErrQuestionLocked = errors.New("question is locked by the navigation policy")
+
+// advanceNavPosition checks ARGV[1] against the lock block of the stored furthest
+// position KEYS[1] (block size ARGV[2]). When allowed it moves the furthest position
+// forward and records ARGV[1] as field ARGV[3] of the current-question hash KEYS[2].
+// It returns {allowed, unlocked_from}.
+var advanceNavPosition = redis.NewScript(`
+local furthest = tonumber(redis.call("GET", KEYS[1]) or "0")
+local pos = tonumber(ARGV[1])
+local size = tonumber(ARGV[2])
+if pos < furthest - furthest % size then
+	return {0, furthest - furthest % size}
+end
+if pos > furthest then
+	redis.call("SET", KEYS[1], pos)
+	furthest = pos
+end
+redis.call("HSET", KEYS[2], ARGV[3], pos)
+return {1, furthest - furthest % size}
+`)
+
+// GetNavigationRules returns an exam's cached navigation policy. Exams cached before
+// the policy existed navigate freely.
+func (s *ExamSessionService) GetNavigationRules(ctx context.Context, examID uuid.UUID) (model.NavigationRules, error) {
+	rules := model.NavigationRules{Policy: model.NavigationFree}
+	raw, err := s.rdb.Get(ctx, config.CacheKey.ExamNavigationKey(examID.String())).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return rules, nil
+	}
+	if err != nil {
+		return rules, fmt.Errorf("get navigation rules: %w", err)
+	}
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return rules, fmt.Errorf("unmarshal navigation rules: %w", err)
+	}
+	return rules, nil
+}
+
+// MoveToQuestion records that the student is on position in their question order and
+// returns the first position still unlocked. ErrQuestionLocked is returned, and the
+// move is not recorded, when position lies before it.
+func (s *ExamSessionService) MoveToQuestion(ctx context.Context, examID uuid.UUID, studentID int, rules model.NavigationRules, position int) (int, error) {
+	currentKey := config.CacheKey.ExamCurrentQuestionsKey(examID.String())
+	size := rules.LockSize()
+	if size == 0 {
+		if err := s.rdb.HSet(ctx, currentKey, strconv.Itoa(studentID), position).Err(); err != nil {
+			return 0, fmt.Errorf("set current question: %w", err)
+		}
+		return 0, nil
+	}
+
+	keys := []string{config.CacheKey.StudentNavPositionKey(examID.String(), studentID), currentKey}
+	res, err := advanceNavPosition.Run(ctx, s.rdb, keys, position, size, studentID).Int64Slice()
+	if err != nil {
+		return 0, fmt.Errorf("advance navigation: %w", err)
+	}
+	if res[0] == 0 {
+		return int(res[1]), ErrQuestionLocked
+	}
+	return int(res[1]), nil
+}
+
+// navigationState returns an exam's navigation rules, the position the student is on
+// and the first position they may still answer.
+func (s *ExamSessionService) navigationState(ctx context.Context, examID uuid.UUID, studentID int) (model.NavigationRules, int, int, error) {
+	rules, err := s.GetNavigationRules(ctx, examID)
+	if err != nil {
+		return rules, 0, 0, err
+	}
+
+	current, err := s.rdb.HGet(ctx, config.CacheKey.ExamCurrentQuestionsKey(examID.String()), strconv.Itoa(studentID)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return rules, 0, 0, fmt.Errorf("get current question: %w", err)
+	}
+	if rules.LockSize() == 0 {
+		return rules, current, 0, nil
+	}
+
+	furthest, err := s.rdb.Get(ctx, config.CacheKey.StudentNavPositionKey(examID.String(), studentID)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return rules, 0, 0, fmt.Errorf("get navigation position: %w", err)
+	}
+	return rules, current, rules.UnlockedFrom(furthest), nil
+}
+
+// CurrentQuestions returns the position each student of an exam is on, keyed by student ID.
+func (s *ExamSessionService) CurrentQuestions(ctx context.Context, examID uuid.UUID) (map[int]int, error) {
+	raw, err := s.rdb.HGetAll(ctx, config.CacheKey.ExamCurrentQuestionsKey(examID.String())).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get current questions: %w", err)
+	}
+	current := make(map[int]int, len(raw))
+	for sid, v := range raw {
+		id, err1 := strconv.Atoi(sid)
+		pos, err2 := strconv.Atoi(v)
+		if err1 == nil && err2 == nil {
+			current[id] = pos
+		}
+	}
+	return current, nil
+}
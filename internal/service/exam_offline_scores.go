@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// MaxOfflineScoresBytes caps the size of an uploaded offline scores CSV.
+const MaxOfflineScoresBytes = 2 << 20
+
+// maxOfflineScoreRows caps the rows of an offline scores upload, as for the JSON payload.
+const maxOfflineScoreRows = 5000
+
+// ErrOfflineScoresFormat is returned when an offline scores CSV lacks its columns.
+var ErrOfflineScoresFormat = errors.New("offline scores CSV needs nisn and score columns")
+
+// offlineScoreColumns are the accepted header names of the CSV columns.
+var offlineScoreColumns = map[string][]string{
+	"nisn":  {"nisn"},
+	"score": {"score", "nilai"},
+}
+
+// RecordOfflineScores enters the scores of a paper exam, held when the online exam
+// could not run. Each student gets a COMPLETED session flagged as an offline entry,
+// which results, stats and exports count like any other; entering a student again
+// updates their score. Students must be participants of the exam. A session started
+// online is completed with the entered score, while one submitted online is skipped. Nothing is written unless confirm is set, so a dry
+// run reports exactly what would happen.
+func (s *ExamSessionService) RecordOfflineScores(ctx context.Context, examID uuid.UUID, entries []model.OfflineScoreEntry, confirm bool) (*model.OfflineScoreReport, error) {
+	rows := make([]model.OfflineScoreRow, len(entries))
+	for i, e := range entries {
+		rows[i] = model.OfflineScoreRow{Row: i + 1, NISN: strings.TrimSpace(e.NISN), Score: e.Score}
+	}
+	return s.recordOfflineScores(ctx, examID, rows, confirm)
+}
+
+// RecordOfflineScoresCSV is RecordOfflineScores for a CSV upload with a header row
+// naming its nisn and score (or nilai) columns, separated by commas or semicolons.
+// Rows with an unreadable score are reported as skipped.
+func (s *ExamSessionService) RecordOfflineScoresCSV(ctx context.Context, examID uuid.UUID, filename string, r io.Reader, confirm bool) (*model.OfflineScoreReport, error) {
+	if ext := strings.ToLower(filepath.Ext(filename)); ext != ".csv" {
+		return nil, fmt.Errorf("%w: %s (allowed: .csv)", ErrUnsupportedFileType, ext)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, MaxOfflineScoresBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	if len(data) > MaxOfflineScoresBytes {
+		return nil, fmt.Errorf("%w: max %d bytes", ErrFileTooLarge, MaxOfflineScoresBytes)
+	}
+
+	rows, err := parseOfflineScores(string(data))
+	if err != nil {
+		return nil, err
+	}
+	return s.recordOfflineScores(ctx, examID, rows, confirm)
+}
+
+// parseOfflineScores reads the rows of an offline scores CSV. Row numbers count the
+// header as row 1, as a spreadsheet shows them.
+func parseOfflineScores(data string) ([]model.OfflineScoreRow, error) {
+	data = strings.TrimPrefix(data, "\ufeff")
+	header, _, _ := strings.Cut(data, "\n")
+	reader := csv.NewReader(strings.NewReader(data))
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOfflineScoresFormat, err)
+	}
+	if len(records) == 0 {
+		return nil, ErrOfflineScoresFormat
+	}
+
+	cols := map[string]int{}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for col, names := range offlineScoreColumns {
+			for _, n := range names {
+				if name == n {
+					cols[col] = i
+				}
+			}
+		}
+	}
+	nisnCol, okNISN := cols["nisn"]
+	scoreCol, okScore := cols["score"]
+	if !okNISN || !okScore {
+		return nil, ErrOfflineScoresFormat
+	}
+	if len(records)-1 > maxOfflineScoreRows {
+		return nil, fmt.Errorf("%w: max %d rows", ErrFileTooLarge, maxOfflineScoreRows)
+	}
+
+	rows := make([]model.OfflineScoreRow, 0, len(records)-1)
+	for i, rec := range records[1:] {
+		field := func(col int) string {
+			if col < len(rec) {
+				return strings.TrimSpace(rec[col])
+			}
+			return ""
+		}
+		row := model.OfflineScoreRow{Row: i + 2, NISN: field(nisnCol)}
+		raw := field(scoreCol)
+		if row.NISN == "" && raw == "" {
+			continue
+		}
+		// Spreadsheets in Indonesian locales write decimal commas.
+		score, err := strconv.ParseFloat(strings.Replace(raw, ",", ".", 1), 64)
+		if err != nil {
+			row.Action, row.Error = model.ImportActionSkip, fmt.Sprintf("invalid score %q", raw)
+		}
+		row.Score = score
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// recordOfflineScores checks each row against the exam's participants and, when
+// confirm is set, saves the rows that pass. Rows already marked skipped are kept as is.
+func (s *ExamSessionService) recordOfflineScores(ctx context.Context, examID uuid.UUID, rows []model.OfflineScoreRow, confirm bool) (*model.OfflineScoreReport, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	if exam.Status == model.ExamStatusDraft {
+		return nil, ErrExamNotPublished
+	}
+
+	participants, err := s.targetRepo.ListAttendance(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("list participants: %w", err)
+	}
+	byNISN := make(map[string]model.AttendanceRow, len(participants))
+	for _, p := range participants {
+		byNISN[p.NISN] = p
+	}
+
+	report := &model.OfflineScoreReport{Rows: rows}
+	seen := make(map[string]int, len(rows))
+	studentIDs := make([]int, 0, len(rows))
+	scores := make([]float64, 0, len(rows))
+
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		if row.Action != model.ImportActionSkip {
+			p, ok := byNISN[row.NISN]
+			switch {
+			case row.NISN == "":
+				row.Error = "missing NISN"
+			case row.Score < 0 || row.Score > 100:
+				row.Error = "score must be between 0 and 100"
+			case !ok:
+				row.Error = "student is not a participant of this exam"
+			case seen[row.NISN] > 0:
+				row.Error = fmt.Sprintf("duplicate of row %d", seen[row.NISN])
+			case p.SessionStatus != nil && *p.SessionStatus == model.SessionStatusCompleted && !p.OfflineEntry:
+				row.Error = "student already submitted the exam online"
+			}
+			if ok {
+				row.StudentID, row.Name = p.StudentID, p.Name
+			}
+			if row.Error == "" {
+				seen[row.NISN] = row.Row
+				row.Action = model.ImportActionCreate
+				if p.SessionStatus != nil {
+					row.Action = model.ImportActionUpdate
+				}
+			} else {
+				row.Action = model.ImportActionSkip
+			}
+		}
+
+		switch row.Action {
+		case model.ImportActionCreate:
+			report.Created++
+		case model.ImportActionUpdate:
+			report.Updated++
+		default:
+			report.Skipped++
+			continue
+		}
+		studentIDs = append(studentIDs, row.StudentID)
+		scores = append(scores, row.Score)
+	}
+
+	if !confirm || len(studentIDs) == 0 {
+		return report, nil
+	}
+	if err := s.sessionRepo.SaveOfflineScores(ctx, examID, studentIDs, scores); err != nil {
+		return nil, fmt.Errorf("save offline scores: %w", err)
+	}
+	report.Applied = true
+
+	// The stats sweep catches these sessions too; the job only refreshes them sooner.
+	payload, _ := json.Marshal(map[string]string{"exam_id": examID.String()})
+	_ = s.queue.Push(ctx, config.WorkerKey.RefreshExamStatsQueue, payload)
+	return report, nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/logger"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// examPaperTTL is how long an export job and its PDF are kept for download.
+const examPaperTTL = 24 * time.Hour
+
+// Sentinel errors for question paper exports.
+var (
+	ErrExamPaperNotFound = errors.New("exam paper export not found")
+	ErrExamPaperNotReady = errors.New("exam paper export is not ready")
+)
+
+// ExamPaperPayload is the queue message of a question paper export.
+type ExamPaperPayload struct {
+	JobID     string `json:"job_id"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// StartPaperExport queues the rendering of an exam's printable question paper and
+// returns the job to poll. The seed draws the questions and their order like a
+// preview's. The exam must have questions; drafts can be exported too.
+func (s *ExamService) StartPaperExport(ctx context.Context, examID uuid.UUID, includeAnswerKey bool, seed int64, adminID int) (*model.ExamPaperJob, error) {
+	if _, err := s.examRepo.GetByID(ctx, examID); err != nil {
+		return nil, err
+	}
+	questions, err := s.questionRepo.ListByExam(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("list questions: %w", err)
+	}
+	if len(questions) == 0 {
+		return nil, ErrNoQuestions
+	}
+
+	now := time.Now()
+	job := &model.ExamPaperJob{
+		ID:               uuid.New(),
+		ExamID:           examID,
+		IncludeAnswerKey: includeAnswerKey,
+		Seed:             seed,
+		Status:           model.ExamPaperPending,
+		RequestedBy:      adminID,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(examPaperTTL),
+	}
+	if err := s.savePaperJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	raw, _ := json.Marshal(ExamPaperPayload{JobID: job.ID.String(), RequestID: logger.RequestID(ctx)})
+	if err := s.queue.Push(ctx, config.WorkerKey.ExportExamPaperQueue, raw); err != nil {
+		return nil, fmt.Errorf("queue paper export: %w", err)
+	}
+	return job, nil
+}
+
+// PaperJob returns an export job of the exam.
+func (s *ExamService) PaperJob(ctx context.Context, examID, jobID uuid.UUID) (*model.ExamPaperJob, error) {
+	job, err := s.loadPaperJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.ExamID != examID {
+		return nil, ErrExamPaperNotFound
+	}
+	return job, nil
+}
+
+// PaperPDF returns the rendered PDF of a finished export job of the exam.
+func (s *ExamService) PaperPDF(ctx context.Context, examID, jobID uuid.UUID) (*model.ExamPaperJob, []byte, error) {
+	job, err := s.PaperJob(ctx, examID, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != model.ExamPaperReady {
+		return nil, nil, ErrExamPaperNotReady
+	}
+	pdfBytes, err := s.rdb.Get(ctx, config.CacheKey.ExamPaperFileKey(jobID.String())).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil, ErrExamPaperNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("get exam paper: %w", err)
+	}
+	return job, pdfBytes, nil
+}
+
+// RunPaperExport renders the question paper of a queued job and stores it for
+// download. A job that fails to render is marked FAILED with the reason; the error
+// is returned only when the job could not be read or updated.
+func (s *ExamService) RunPaperExport(ctx context.Context, jobID uuid.UUID) error {
+	job, err := s.loadPaperJob(ctx, jobID)
+	if errors.Is(err, ErrExamPaperNotFound) {
+		// Expired before a worker got to it.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if job.Status != model.ExamPaperPending {
+		return nil
+	}
+
+	pdfBytes, renderErr := s.renderPaper(ctx, job)
+	now := time.Now()
+	job.FinishedAt = &now
+	if renderErr != nil {
+		s.log.Warn().Err(renderErr).Str("job_id", job.ID.String()).Str("exam_id", job.ExamID.String()).
+			Msg("Exam paper export failed")
+		job.Status = model.ExamPaperFailed
+		job.Error = renderErr.Error()
+		return s.savePaperJob(ctx, job)
+	}
+
+	ttl := time.Until(job.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	if err := s.rdb.Set(ctx, config.CacheKey.ExamPaperFileKey(job.ID.String()), pdfBytes, ttl).Err(); err != nil {
+		return fmt.Errorf("store exam paper: %w", err)
+	}
+	job.Status = model.ExamPaperReady
+	return s.savePaperJob(ctx, job)
+}
+
+// renderPaper draws the job's exam as a preview with its seed and renders the paper.
+func (s *ExamService) renderPaper(ctx context.Context, job *model.ExamPaperJob) ([]byte, error) {
+	preview, err := s.Preview(ctx, job.ExamID, job.Seed, job.IncludeAnswerKey)
+	if err != nil {
+		return nil, err
+	}
+	exam, err := s.examRepo.GetByID(ctx, job.ExamID)
+	if err != nil {
+		return nil, err
+	}
+
+	paper := ExamPaper{Preview: preview, ScheduledStart: exam.ScheduledStart, Instructions: exam.Instructions}
+	if exam.QBankID != nil {
+		if qbank, err := s.questionRepo.GetQBanks(ctx, *exam.QBankID); err == nil && qbank.SubjectID != nil {
+			if subject, err := s.subjectRepo.GetByID(ctx, *qbank.SubjectID); err == nil {
+				paper.Subject = subject.Name
+			}
+		}
+	}
+	if setting, err := s.settingRepo.GetByKey(ctx, "school_name"); err == nil {
+		paper.School.Name = setting.Value
+	}
+	if setting, err := s.settingRepo.GetByKey(ctx, "school_logo_url"); err == nil {
+		paper.School.LogoURL = setting.Value
+	}
+	return GenerateExamPaperPDF(paper)
+}
+
+func (s *ExamService) savePaperJob(ctx context.Context, job *model.ExamPaperJob) error {
+	ttl := time.Until(job.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	raw, _ := json.Marshal(job)
+	if err := s.rdb.Set(ctx, config.CacheKey.ExamPaperJobKey(job.ID.String()), raw, ttl).Err(); err != nil {
+		return fmt.Errorf("save exam paper job: %w", err)
+	}
+	return nil
+}
+
+func (s *ExamService) loadPaperJob(ctx context.Context, jobID uuid.UUID) (*model.ExamPaperJob, error) {
+	raw, err := s.rdb.Get(ctx, config.CacheKey.ExamPaperJobKey(jobID.String())).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrExamPaperNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get exam paper job: %w", err)
+	}
+	var job model.ExamPaperJob
+	if err := json.Unmarshal(raw, &job); err != nil {
+		return nil, fmt.Errorf("decode exam paper job: %w", err)
+	}
+	return &job, nil
+}
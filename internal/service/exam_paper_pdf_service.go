@@ -0,0 +1,449 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/signintech/gopdf"
+	"github.com/stemsi/exstem-backend/internal/assets/fonts"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// ExamPaper is what a printed question paper shows: one drawn version of the exam,
+// and the details printed in its header.
+type ExamPaper struct {
+	Preview        *model.ExamPreview
+	School         SchoolInfo
+	Subject        string
+	ScheduledStart *model.LocalTime
+	Instructions   string
+}
+
+// ---------------------------------------------------------------------------
+// Layout constants — millimeters unless noted otherwise. The page, margin and
+// font settings are shared with the student card PDF.
+// ---------------------------------------------------------------------------
+
+const (
+	paperLogoSizeMM   = 16.0 // logo square side length in the header
+	paperLineHMM      = 5.0  // height of a line of body text
+	paperIndentMM     = 8.0  // indent of question text after its number
+	paperOptionIndent = 6.0  // further indent of options after their letter
+	paperQuestionGap  = 3.0  // space between questions
+	paperEssayLines   = 4    // dotted answer lines after an essay question
+	paperFooterMM     = 8.0  // space above the bottom margin kept for the page number
+	paperBodyFontPt   = 10.0
+
+	paperSheetCols    = 4   // question columns on the answer sheet
+	paperSheetRowHMM  = 7.0 // height of an answer sheet row
+	paperBubbleRMM    = 2.2 // radius of an answer bubble
+	paperBubbleStepMM = 6.0 // distance between bubble centres
+)
+
+// paperTagRe matches an HTML tag of question and passage text.
+var paperTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// paperBreakRe matches the tags that end a line of question and passage text.
+var paperBreakRe = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>|</h[1-6]>`)
+
+// paperText turns the HTML of question and passage text into the plain lines it reads
+// as, without empty lines.
+func paperText(s string) []string {
+	s = paperBreakRe.ReplaceAllString(s, "\n")
+	s = strings.ReplaceAll(s, "<li>", "• ")
+	s = html.UnescapeString(paperTagRe.ReplaceAllString(s, ""))
+
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// paperOption is one answer choice as printed: its letter and text.
+type paperOption struct {
+	letter string
+	text   string
+}
+
+// paperOptions lists the printed choices of a question: its options lettered by key
+// or position, Benar/Salah for true/false, and none for essays. Images are shown by
+// their alt text.
+func paperOptions(q model.QuestionForStudent) []paperOption {
+	switch q.QuestionType {
+	case model.QuestionTypeTrueFalse:
+		return []paperOption{{"B", "Benar"}, {"S", "Salah"}}
+	case model.QuestionTypeMultipleChoice, "":
+		var keyed []model.QuestionOption
+		if err := json.Unmarshal(q.Options, &keyed); err == nil && len(keyed) > 0 && keyed[0].Key != "" {
+			out := make([]paperOption, len(keyed))
+			for i, o := range keyed {
+				text := strings.Join(paperText(o.Text), " ")
+				if o.MediaID != nil {
+					text = strings.TrimSpace(text + " [Gambar: " + o.MediaAlt + "]")
+				}
+				out[i] = paperOption{letter: strings.ToUpper(o.Key), text: text}
+			}
+			return out
+		}
+		var plain []string
+		if err := json.Unmarshal(q.Options, &plain); err == nil {
+			out := make([]paperOption, 0, len(plain))
+			for i, text := range plain[:min(len(plain), 26)] {
+				out = append(out, paperOption{letter: string(rune('A' + i)), text: strings.Join(paperText(text), " ")})
+			}
+			return out
+		}
+	}
+	return nil
+}
+
+// paperKey is how a question's correct answer is printed in the answer key.
+func paperKey(q model.QuestionForStudent, correct string) string {
+	switch q.QuestionType {
+	case model.QuestionTypeEssay:
+		return "Esai"
+	case model.QuestionTypeTrueFalse:
+		if strings.EqualFold(correct, "true") {
+			return "B"
+		}
+		return "S"
+	}
+	return gradingKey(correct)
+}
+
+// ---------------------------------------------------------------------------
+// Public API
+// ---------------------------------------------------------------------------
+
+// GenerateExamPaperPDF builds an A4 question paper: a header with space for the
+// student's details, the questions with their passages and options, an answer sheet
+// with a bubble per choice and, when the preview carries it, an answer key on its own
+// page. Returns the raw PDF bytes.
+func GenerateExamPaperPDF(paper ExamPaper) ([]byte, error) {
+	if paper.Preview == nil || len(paper.Preview.Questions) == 0 {
+		return nil, fmt.Errorf("no questions on the exam paper")
+	}
+
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+
+	for name, file := range map[string]string{
+		fontRegular: "Roboto-Regular.ttf",
+		fontBold:    "Roboto-Bold.ttf",
+	} {
+		fontBytes, err := fonts.FS.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read embedded font %s: %w", file, err)
+		}
+		if err := pdf.AddTTFFontData(name, fontBytes); err != nil {
+			return nil, fmt.Errorf("load font %s: %w", name, err)
+		}
+	}
+
+	logoBytes, _ := loadLogoAsJPEG(resolveLogoPath(paper.School.LogoURL))
+	w := &paperWriter{pdf: pdf}
+
+	w.newPage()
+	drawPaperHeader(w, paper, "NASKAH SOAL UJIAN", logoBytes, true)
+	drawPaperQuestions(w, paper)
+
+	w.newPage()
+	drawPaperHeader(w, paper, "LEMBAR JAWABAN", logoBytes, true)
+	drawPaperAnswerSheet(w, paper.Preview.Questions)
+
+	if paper.Preview.AnswerKey != nil {
+		w.newPage()
+		drawPaperHeader(w, paper, "KUNCI JAWABAN — RAHASIA", logoBytes, false)
+		drawPaperAnswerKey(w, paper.Preview)
+	}
+
+	drawPaperPageNumbers(pdf, paper.Preview.Title)
+
+	var buf bytes.Buffer
+	if _, err := pdf.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("write pdf: %w", err)
+	}
+	pdf.Close()
+
+	return buf.Bytes(), nil
+}
+
+// ---------------------------------------------------------------------------
+// Paper drawing
+// ---------------------------------------------------------------------------
+
+// paperWriter lays text out top to bottom, starting a new page when it runs out of room.
+type paperWriter struct {
+	pdf *gopdf.GoPdf
+	y   float64 // mm
+}
+
+func (w *paperWriter) newPage() {
+	w.pdf.AddPage()
+	w.y = pdfPageMarginMM
+}
+
+// ensure starts a new page unless hMM more fits on this one.
+func (w *paperWriter) ensure(hMM float64) {
+	if w.y+hMM > pdfPageHeightMM-pdfPageMarginMM-paperFooterMM {
+		w.newPage()
+	}
+}
+
+// paragraph writes text wrapped to the width between xMM and the right margin.
+func (w *paperWriter) paragraph(text, font string, xMM float64) {
+	_ = w.pdf.SetFont(font, "", paperBodyFontPt)
+	w.pdf.SetTextColor(30, 40, 50)
+	width := mmToPt(pdfPageWidthMM - pdfPageMarginMM - xMM)
+	lines, err := w.pdf.SplitTextWithWordWrap(text, width)
+	if err != nil {
+		lines = []string{text}
+	}
+	for _, line := range lines {
+		w.ensure(paperLineHMM)
+		w.pdf.SetXY(mmToPt(xMM), mmToPt(w.y))
+		w.pdf.Text(line)
+		w.y += paperLineHMM
+	}
+}
+
+// label writes text at xMM on the current line without advancing.
+func (w *paperWriter) label(text, font string, size, xMM float64) {
+	_ = w.pdf.SetFont(font, "", size)
+	w.pdf.SetTextColor(30, 40, 50)
+	w.pdf.SetXY(mmToPt(xMM), mmToPt(w.y))
+	w.pdf.Text(text)
+}
+
+// drawPaperHeader renders the school, title and exam details at the top of a section,
+// followed by lines for the student's details when forStudent is set.
+func drawPaperHeader(w *paperWriter, paper ExamPaper, title string, logoBytes []byte, forStudent bool) {
+	pdf := w.pdf
+	x, y := pdfPageMarginMM, w.y
+	textX := x
+	if len(logoBytes) > 0 {
+		if holder, err := gopdf.ImageHolderByBytes(logoBytes); err == nil {
+			size := mmToPt(paperLogoSizeMM)
+			if err := pdf.ImageByHolder(holder, mmToPt(x), mmToPt(y), &gopdf.Rect{W: size, H: size}); err == nil {
+				textX += paperLogoSizeMM + 4
+			}
+		}
+	}
+
+	line := func(text, font string, size, atMM float64) {
+		w.y = atMM
+		w.label(text, font, size, textX)
+	}
+	if paper.School.Name != "" {
+		line(strings.ToUpper(paper.School.Name), fontBold, 10, y+3)
+	}
+	line(title, fontBold, 13, y+9)
+	line(paper.Preview.Title, fontRegular, 10, y+15)
+
+	details := []string{}
+	if paper.Subject != "" {
+		details = append(details, "Mapel: "+paper.Subject)
+	}
+	if paper.ScheduledStart != nil {
+		details = append(details, "Jadwal: "+paper.ScheduledStart.Time().Format("02/01/2006 15:04"))
+	}
+	details = append(details, fmt.Sprintf("Waktu: %d menit", paper.Preview.Duration))
+	details = append(details, fmt.Sprintf("Jumlah soal: %d", len(paper.Preview.Questions)))
+	line(strings.Join(details, "    "), fontRegular, 9, y+21)
+
+	// Rule under the header.
+	pdf.SetStrokeColor(60, 70, 80)
+	pdf.SetLineWidth(0.8)
+	pdf.Line(mmToPt(x), mmToPt(y+26), mmToPt(pdfPageWidthMM-pdfPageMarginMM), mmToPt(y+26))
+
+	w.y = y + 33
+	if !forStudent {
+		return
+	}
+	// Student's details, filled in by hand.
+	w.label("Nama  : ..................................................", fontRegular, 9, x)
+	w.label("No. Peserta : ..............................", fontRegular, 9, x+105)
+	w.y += 6
+	w.label("Kelas : ..................................................", fontRegular, 9, x)
+	w.label("Ruang / Kursi : ...........................", fontRegular, 9, x+105)
+	w.y += 10
+}
+
+// drawPaperQuestions renders the exam's instructions and then its questions in order,
+// each passage before the first question that refers to it.
+func drawPaperQuestions(w *paperWriter, paper ExamPaper) {
+	x := pdfPageMarginMM
+	preview := paper.Preview
+	for _, line := range paperText(paper.Instructions) {
+		w.paragraph(line, fontRegular, x)
+	}
+
+	passages := make(map[string]model.PassageForStudent, len(preview.Passages))
+	for _, p := range preview.Passages {
+		passages[p.ID.String()] = p
+	}
+	printed := make(map[string]bool)
+
+	for _, q := range preview.Questions {
+		if q.PassageID != nil && !printed[q.PassageID.String()] {
+			printed[q.PassageID.String()] = true
+			if p, ok := passages[q.PassageID.String()]; ok {
+				w.y += paperQuestionGap
+				w.ensure(3 * paperLineHMM)
+				if p.Title != "" {
+					w.paragraph(p.Title, fontBold, x)
+				}
+				for _, line := range paperText(p.Content) {
+					w.paragraph(line, fontRegular, x)
+				}
+			}
+		}
+
+		w.y += paperQuestionGap
+		w.ensure(2 * paperLineHMM)
+		w.label(fmt.Sprintf("%d.", q.OrderNum), fontBold, paperBodyFontPt, x)
+		lines := paperText(q.QuestionText)
+		if q.MathLatex != "" {
+			lines = append(lines, "Rumus: "+q.MathLatex)
+		}
+		for _, m := range q.Media {
+			lines = append(lines, fmt.Sprintf("[%s: %s]", paperMediaLabel(m.Type), m.Alt))
+		}
+		if len(lines) == 0 {
+			w.y += paperLineHMM
+		}
+		for _, line := range lines {
+			w.paragraph(line, fontRegular, x+paperIndentMM)
+		}
+
+		for _, o := range paperOptions(q) {
+			w.ensure(paperLineHMM)
+			w.label(o.letter+".", fontRegular, paperBodyFontPt, x+paperIndentMM)
+			w.paragraph(o.text, fontRegular, x+paperIndentMM+paperOptionIndent)
+		}
+		if q.QuestionType == model.QuestionTypeEssay {
+			for range paperEssayLines {
+				w.ensure(paperLineHMM + 2)
+				w.y += 2
+				w.label(strings.Repeat(".", 140), fontRegular, 8, x+paperIndentMM)
+				w.y += paperLineHMM
+			}
+		}
+	}
+}
+
+// paperMediaLabel names an embedded media type in the printed text.
+func paperMediaLabel(mediaType string) string {
+	switch mediaType {
+	case "audio":
+		return "Audio"
+	case "video":
+		return "Video"
+	}
+	return "Gambar"
+}
+
+// drawPaperAnswerSheet renders a grid of the question numbers, each with a bubble per
+// choice. Essays are answered on separate paper.
+func drawPaperAnswerSheet(w *paperWriter, questions []model.QuestionForStudent) {
+	colW := (pdfPageWidthMM - 2*pdfPageMarginMM) / paperSheetCols
+	pdf := w.pdf
+
+	w.label("Hitamkan bulatan huruf jawaban yang benar. Jawaban esai ditulis pada lembar terpisah.", fontRegular, 8, pdfPageMarginMM)
+	w.y += 8
+
+	for start := 0; start < len(questions); {
+		top := w.y
+		rows := int((pdfPageHeightMM - pdfPageMarginMM - paperFooterMM - top) / paperSheetRowHMM)
+		if rows < 1 {
+			w.newPage()
+			continue
+		}
+
+		for i := start; i < len(questions) && i < start+rows*paperSheetCols; i++ {
+			q := questions[i]
+			col, row := (i-start)/rows, (i-start)%rows
+			x := pdfPageMarginMM + float64(col)*colW
+			w.y = top + float64(row)*paperSheetRowHMM
+			w.label(fmt.Sprintf("%d.", q.OrderNum), fontBold, 8, x)
+
+			options := paperOptions(q)
+			if len(options) == 0 {
+				w.label("Esai", fontRegular, 8, x+8)
+				continue
+			}
+			for j, o := range options {
+				cx := x + 8 + paperBubbleRMM + float64(j)*paperBubbleStepMM
+				if cx+paperBubbleRMM > x+colW {
+					break
+				}
+				cy := w.y + paperBubbleRMM - 0.6
+				pdf.SetStrokeColor(60, 70, 80)
+				pdf.SetLineWidth(0.5)
+				pdf.Oval(mmToPt(cx-paperBubbleRMM), mmToPt(cy-paperBubbleRMM), mmToPt(cx+paperBubbleRMM), mmToPt(cy+paperBubbleRMM))
+				_ = pdf.SetFont(fontRegular, "", 6)
+				pdf.SetTextColor(90, 100, 110)
+				pdf.SetXY(mmToPt(cx-paperBubbleRMM), mmToPt(cy-paperBubbleRMM))
+				_ = pdf.CellWithOption(&gopdf.Rect{W: mmToPt(2 * paperBubbleRMM), H: mmToPt(2 * paperBubbleRMM)}, o.letter,
+					gopdf.CellOption{Align: gopdf.Center | gopdf.Middle})
+			}
+		}
+
+		start += rows * paperSheetCols
+		if start < len(questions) {
+			w.newPage()
+		}
+	}
+}
+
+// drawPaperAnswerKey renders each question's correct answer in a grid like the answer sheet's.
+func drawPaperAnswerKey(w *paperWriter, preview *model.ExamPreview) {
+	colW := (pdfPageWidthMM - 2*pdfPageMarginMM) / paperSheetCols
+	questions := preview.Questions
+
+	for start := 0; start < len(questions); {
+		top := w.y
+		rows := int((pdfPageHeightMM - pdfPageMarginMM - paperFooterMM - top) / paperSheetRowHMM)
+		if rows < 1 {
+			w.newPage()
+			continue
+		}
+		for i := start; i < len(questions) && i < start+rows*paperSheetCols; i++ {
+			q := questions[i]
+			col, row := (i-start)/rows, (i-start)%rows
+			x := pdfPageMarginMM + float64(col)*colW
+			w.y = top + float64(row)*paperSheetRowHMM
+			w.label(fmt.Sprintf("%d.", q.OrderNum), fontBold, 9, x)
+			w.label(paperKey(q, preview.AnswerKey[q.ID.String()]), fontRegular, 9, x+10)
+		}
+		start += rows * paperSheetCols
+		if start < len(questions) {
+			w.newPage()
+		}
+	}
+}
+
+// drawPaperPageNumbers writes the exam title and "page n of N" at the foot of every page.
+func drawPaperPageNumbers(pdf *gopdf.GoPdf, title string) {
+	total := pdf.GetNumberOfPages()
+	for n := 1; n <= total; n++ {
+		if err := pdf.SetPage(n); err != nil {
+			return
+		}
+		_ = pdf.SetFont(fontRegular, "", 7.5)
+		pdf.SetTextColor(120, 130, 140)
+		y := mmToPt(pdfPageHeightMM - pdfPageMarginMM - 2)
+		pdf.SetXY(mmToPt(pdfPageMarginMM), y)
+		pdf.Text(title)
+		pdf.SetXY(mmToPt(pdfPageMarginMM), y-mmToPt(3))
+		_ = pdf.CellWithOption(&gopdf.Rect{W: mmToPt(pdfPageWidthMM - 2*pdfPageMarginMM), H: mmToPt(4)},
+			fmt.Sprintf("Halaman %d dari %d", n, total), gopdf.CellOption{Align: gopdf.Right | gopdf.Middle})
+	}
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stemsi/exstem-backend/internal/helper"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+var (
+	// ErrExamPayloadTooLarge is returned when publishing an exam whose payload is above
+	// the exam_payload_max_mb setting.
+	ErrExamPayloadTooLarge = errors.New("exam payload is too large")
+	// ErrInvalidPayloadLimit is returned when an exam payload limit setting is not a
+	// non-negative number of megabytes.
+	ErrInvalidPayloadLimit = errors.New("exam payload limits must be a non-negative number of megabytes")
+)
+
+// Exam payload limits, in megabytes, used while the settings are unset.
+const (
+	defaultExamPayloadWarnMB = 10
+	defaultExamPayloadMaxMB  = 25
+)
+
+// PayloadSize measures what each student downloads when the exam starts, against the
+// configured limits, and estimates the transfer for all eligible students.
+func (s *ExamService) PayloadSize(ctx context.Context, examID uuid.UUID) (*model.ExamPayloadSize, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := s.questionRepo.ListByExam(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("list questions: %w", err)
+	}
+	size, err := s.payloadSize(ctx, exam, questions)
+	if err != nil {
+		return nil, err
+	}
+
+	eligible, err := s.targetRepo.CountEligibleStudents(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("count eligible students: %w", err)
+	}
+	size.EligibleStudents = eligible
+	size.StartTransferBytes = int64(eligible) * size.TotalBytes
+	return size, nil
+}
+
+// payloadSize sizes the student payload built from questions and the library media
+// they and their passages reference. Media outside the library are listed as untracked;
+// inline data URIs are already part of the text.
+func (s *ExamService) payloadSize(ctx context.Context, exam *model.Exam, questions []model.Question) (*model.ExamPayloadSize, error) {
+	if err := s.resolveOptionMedia(ctx, questions); err != nil {
+		return nil, err
+	}
+	passages, err := s.questionRepo.ListPassagesByExam(ctx, exam.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list passages: %w", err)
+	}
+	raw, err := json.Marshal(studentPayload(exam, questions, passages))
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	size := &model.ExamPayloadSize{
+		ExamID:         exam.ID,
+		TextBytes:      int64(len(raw)),
+		Media:          []model.ExamPayloadMedia{},
+		UntrackedMedia: []string{},
+	}
+	size.WarnBytes, size.MaxBytes = s.payloadLimits(ctx)
+
+	// Question order numbers using each referenced source.
+	uses := make(map[string][]int)
+	use := func(src string, orderNum int) {
+		if src == "" || strings.HasPrefix(src, "data:") {
+			return
+		}
+		uses[src] = append(uses[src], orderNum)
+	}
+	passageQuestions := make(map[uuid.UUID][]int)
+	for _, q := range questions {
+		for _, r := range questionMedia(q) {
+			use(r.Src, q.OrderNum)
+		}
+		for _, t := range q.Translations {
+			for _, r := range helper.FindMedia(t.QuestionText) {
+				use(r.Src, q.OrderNum)
+			}
+		}
+		if q.PassageID != nil {
+			passageQuestions[*q.PassageID] = append(passageQuestions[*q.PassageID], q.OrderNum)
+		}
+	}
+	for _, p := range passages {
+		for _, r := range helper.FindMedia(p.Content) {
+			for _, n := range passageQuestions[p.ID] {
+				use(r.Src, n)
+			}
+		}
+	}
+	if len(uses) == 0 {
+		size.TotalBytes = size.TextBytes
+		return size, nil
+	}
+
+	// The same file may be referenced by relative and absolute URLs; its filename is
+	// what the library knows it by.
+	filenames := make([]string, 0, len(uses))
+	for src := range uses {
+		filenames = append(filenames, mediaFilename(src))
+	}
+	files, err := s.mediaRepo.GetByFilenames(ctx, filenames)
+	if err != nil {
+		return nil, fmt.Errorf("get exam media: %w", err)
+	}
+
+	byFile := make(map[string]*model.ExamPayloadMedia)
+	for src, orderNums := range uses {
+		f, ok := files[mediaFilename(src)]
+		if !ok {
+			size.UntrackedMedia = append(size.UntrackedMedia, src)
+			continue
+		}
+		m, ok := byFile[f.Filename]
+		if !ok {
+			m = &model.ExamPayloadMedia{
+				MediaID:      f.ID,
+				URL:          f.URL,
+				OriginalName: f.OriginalName,
+				MimeType:     f.MimeType,
+				SizeBytes:    f.SizeBytes,
+			}
+			byFile[f.Filename] = m
+			size.MediaBytes += f.SizeBytes
+		}
+		m.OrderNums = append(m.OrderNums, orderNums...)
+	}
+	for _, m := range byFile {
+		slices.Sort(m.OrderNums)
+		m.OrderNums = slices.Compact(m.OrderNums)
+		size.Media = append(size.Media, *m)
+	}
+	sort.Slice(size.Media, func(i, j int) bool {
+		if size.Media[i].SizeBytes != size.Media[j].SizeBytes {
+			return size.Media[i].SizeBytes > size.Media[j].SizeBytes
+		}
+		return size.Media[i].URL < size.Media[j].URL
+	})
+	sort.Strings(size.UntrackedMedia)
+
+	size.TotalBytes = size.TextBytes + size.MediaBytes
+	return size, nil
+}
+
+// checkPayloadSize refuses an exam whose payload is above the maximum, naming its
+// largest media so the author knows what to shrink.
+func checkPayloadSize(size *model.ExamPayloadSize) error {
+	if size.MaxBytes <= 0 || size.TotalBytes <= size.MaxBytes {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is above the %s limit%s", ErrExamPayloadTooLarge,
+		formatMegabytes(size.TotalBytes), formatMegabytes(size.MaxBytes), largestMediaNote(size))
+}
+
+// largestMediaNote describes the largest media of a payload, or "" when it has none.
+func largestMediaNote(size *model.ExamPayloadSize) string {
+	if len(size.Media) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, m := range size.Media[:min(3, len(size.Media))] {
+		parts = append(parts, fmt.Sprintf("%s (%s)", m.OriginalName, formatMegabytes(m.SizeBytes)))
+	}
+	return "; largest media: " + strings.Join(parts, ", ")
+}
+
+// payloadLimits reads the exam payload limits in bytes, falling back to the defaults
+// when a setting is unset or unreadable.
+func (s *ExamService) payloadLimits(ctx context.Context) (warnBytes, maxBytes int64) {
+	return s.payloadLimit(ctx, model.SettingExamPayloadWarnMB, defaultExamPayloadWarnMB),
+		s.payloadLimit(ctx, model.SettingExamPayloadMaxMB, defaultExamPayloadMaxMB)
+}
+
+func (s *ExamService) payloadLimit(ctx context.Context, key string, defaultMB float64) int64 {
+	mb := defaultMB
+	setting, err := s.settingRepo.GetByKey(ctx, key)
+	switch {
+	case err == nil:
+		if v, err := parsePayloadLimit(setting.Value); err == nil {
+			mb = v
+		} else {
+			s.log.Warn().Str("key", key).Str("value", setting.Value).Msg("Invalid exam payload limit, using the default")
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		s.log.Warn().Err(err).Str("key", key).Msg("Failed to read exam payload limit, using the default")
+	}
+	return int64(mb * 1024 * 1024)
+}
+
+// parsePayloadLimit parses an exam payload limit setting in megabytes.
+func parsePayloadLimit(value string) (float64, error) {
+	mb, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || mb < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPayloadLimit, value)
+	}
+	return mb, nil
+}
+
+// mediaFilename returns the file name a media URL points at, without query or fragment.
+func mediaFilename(src string) string {
+	if u, err := url.Parse(src); err == nil {
+		src = u.Path
+	}
+	return path.Base(src)
+}
+
+// formatMegabytes formats a byte count in megabytes, e.g. "12.4 MB".
+func formatMegabytes(n int64) string {
+	return strconv.FormatFloat(float64(n)/(1024*1024), 'f', 1, 64) + " MB"
+}
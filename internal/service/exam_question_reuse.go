@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// academicTerm returns the school semester containing t: July–December (ganjil)
+// or January–June (genap). The end is exclusive.
+func academicTerm(t time.Time) (time.Time, time.Time) {
+	year, month := t.Year(), t.Month()
+	if month >= time.July {
+		return time.Date(year, time.July, 1, 0, 0, 0, 0, t.Location()),
+			time.Date(year+1, time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location()),
+		time.Date(year, time.July, 1, 0, 0, 0, 0, t.Location())
+}
+
+// QuestionReuse finds questions of an exam whose text also appears in other exams
+// scheduled in the same term that target at least one of the same classes. Students
+// who sat the earlier exam can pass the questions on, inflating later scores.
+// The term is taken from the exam's scheduled start, or today for unscheduled exams.
+func (s *ExamService) QuestionReuse(ctx context.Context, examID uuid.UUID) (*model.QuestionReuseReport, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := time.Now()
+	if exam.ScheduledStart != nil {
+		ref = exam.ScheduledStart.Time()
+	}
+	termStart, termEnd := academicTerm(ref)
+
+	report := &model.QuestionReuseReport{
+		ExamID:    examID,
+		TermStart: termStart.Format("2006-01-02"),
+		TermEnd:   termEnd.Format("2006-01-02"),
+		Reused:    []model.QuestionReuse{},
+	}
+	if exam.QBankID == nil {
+		return report, nil
+	}
+
+	candidates, err := s.examRepo.ListScheduledBetween(ctx, model.LocalTime(termStart), model.LocalTime(termEnd))
+	if err != nil {
+		return nil, fmt.Errorf("list exams in term: %w", err)
+	}
+	ids := []uuid.UUID{examID}
+	for _, e := range candidates {
+		if e.ID != examID {
+			ids = append(ids, e.ID)
+		}
+	}
+	if len(ids) == 1 {
+		return report, nil
+	}
+
+	classes, err := s.targetRepo.ListTargetedClasses(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("list targeted classes: %w", err)
+	}
+	own := classes[examID]
+	if len(own) == 0 {
+		return report, nil
+	}
+
+	var others []uuid.UUID
+	for _, id := range ids[1:] {
+		if len(intersectInts(own, classes[id])) > 0 {
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		return report, nil
+	}
+
+	reuses, err := s.questionRepo.FindSharedText(ctx, examID, others)
+	if err != nil {
+		return nil, fmt.Errorf("find shared questions: %w", err)
+	}
+	for i := range reuses {
+		reuses[i].ClassIDs = intersectInts(own, classes[reuses[i].OtherExamID])
+	}
+	report.Reused = append(report.Reused, reuses...)
+	return report, nil
+}
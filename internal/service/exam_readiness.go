@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// Readiness thresholds. Exceeding them produces warnings, not a no-go.
+const (
+	readinessMaxQueueBacklog = 1000
+	readinessMaxLatency      = 100 * time.Millisecond
+)
+
+// Readiness checks that an exam can take its expected load: the Redis cache is warm,
+// students are targeted, worker queues are drained and DB/Redis respond quickly.
+func (s *ExamService) Readiness(ctx context.Context, examID uuid.UUID) (*model.ExamReadiness, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ExamReadiness{ExamID: examID, Ready: true, QueueBacklog: map[string]int64{}}
+	add := func(key string, passed bool, severity model.ValidationSeverity, message string) {
+		result.Checks = append(result.Checks, model.ExamValidationCheck{
+			Key: key, Passed: passed, Severity: severity, Message: message,
+		})
+		if !passed && severity == model.ValidationError {
+			result.Ready = false
+		}
+	}
+
+	// Latency
+	start := time.Now()
+	dbErr := s.examRepo.Ping(ctx)
+	dbLatency := time.Since(start)
+	result.DBLatencyMs = float64(dbLatency.Microseconds()) / 1000
+	switch {
+	case dbErr != nil:
+		add("db_latency", false, model.ValidationError, "Database is unreachable: "+dbErr.Error())
+	case dbLatency > readinessMaxLatency:
+		add("db_latency", false, model.ValidationWarning, fmt.Sprintf("Database responded in %s", dbLatency.Round(time.Millisecond)))
+	default:
+		add("db_latency", true, model.ValidationWarning, fmt.Sprintf("Database responded in %s", dbLatency.Round(time.Millisecond)))
+	}
+
+	start = time.Now()
+	redisErr := s.rdb.Ping(ctx).Err()
+	redisLatency := time.Since(start)
+	result.RedisLatencyMs = float64(redisLatency.Microseconds()) / 1000
+	switch {
+	case redisErr != nil:
+		add("redis_latency", false, model.ValidationError, "Redis is unreachable: "+redisErr.Error())
+	case redisLatency > readinessMaxLatency:
+		add("redis_latency", false, model.ValidationWarning, fmt.Sprintf("Redis responded in %s", redisLatency.Round(time.Millisecond)))
+	default:
+		add("redis_latency", true, model.ValidationWarning, fmt.Sprintf("Redis responded in %s", redisLatency.Round(time.Millisecond)))
+	}
+
+	// Status and cache
+	if !exam.Status.IsLive() {
+		add("status", false, model.ValidationError, fmt.Sprintf("Exam is %s; publish it before it starts", exam.Status))
+	} else {
+		add("status", true, model.ValidationError, fmt.Sprintf("Exam is %s", exam.Status))
+	}
+
+	if redisErr == nil {
+		id := examID.String()
+		pipe := s.rdb.Pipeline()
+		payloadCmd := pipe.Exists(ctx, config.CacheKey.ExamPayloadKey(id))
+		keyCmd := pipe.HLen(ctx, config.CacheKey.ExamAnswerKey(id))
+		durationCmd := pipe.Exists(ctx, config.CacheKey.ExamDurationKey(id))
+		queues := map[string]string{
+			"answers":        config.WorkerKey.PersistAnswersQueue,
+			"cheats":         config.WorkerKey.PersistCheatsQueue,
+			"scores":         config.WorkerKey.PersistScoresQueue,
+			"question_order": config.WorkerKey.PersistQuestionOrderQueue,
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("inspect redis: %w", err)
+		}
+
+		warm := payloadCmd.Val() > 0 && keyCmd.Val() > 0 && durationCmd.Val() > 0
+		if warm {
+			add("cache_warm", true, model.ValidationError, fmt.Sprintf("Exam cache is warm (%d answer keys)", keyCmd.Val()))
+		} else {
+			add("cache_warm", false, model.ValidationError, "Exam payload or answer key is missing from Redis; publish or refresh the cache")
+		}
+
+		var backlog int64
+		for name, key := range queues {
+			n, err := s.queue.Len(ctx, key)
+			if err != nil {
+				return nil, fmt.Errorf("inspect queue %s: %w", name, err)
+			}
+			result.QueueBacklog[name] = n
+			backlog += n
+		}
+		if backlog > readinessMaxQueueBacklog {
+			add("queue_backlog", false, model.ValidationWarning, fmt.Sprintf("%d jobs are waiting in worker queues", backlog))
+		} else {
+			add("queue_backlog", true, model.ValidationWarning, fmt.Sprintf("%d jobs are waiting in worker queues", backlog))
+		}
+	}
+
+	// Load estimate
+	if dbErr == nil {
+		eligible, err := s.targetRepo.CountEligibleStudents(ctx, examID)
+		if err != nil {
+			return nil, fmt.Errorf("count eligible students: %w", err)
+		}
+		started, completed, err := s.examRepo.CountSessions(ctx, examID)
+		if err != nil {
+			return nil, fmt.Errorf("count sessions: %w", err)
+		}
+		result.EligibleStudents = eligible
+		result.StartedSessions = started
+		result.CompletedSessions = completed
+		// Every student who has not finished holds one WebSocket during the exam.
+		if peak := eligible - completed; peak > 0 {
+			result.ExpectedPeakConnections = peak
+		}
+
+		if eligible == 0 {
+			add("eligible_students", false, model.ValidationError, "No students match the exam's target rules")
+		} else {
+			add("eligible_students", true, model.ValidationError, fmt.Sprintf("%d students are eligible", eligible))
+		}
+	}
+
+	return result, nil
+}
@@ -5,14 +5,22 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/helper"
 	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/queue"
 	"github.com/stemsi/exstem-backend/internal/repository"
 	"github.com/stemsi/exstem-backend/internal/response"
+	"golang.org/x/sync/singleflight"
 )
 
 // Domain Errors
@@ -21,6 +29,27 @@ var (
 	ErrExamNotDraft     = errors.New("exam is not in draft status")
 	ErrDuplicateTarget  = errors.New("duplicate target rule")
 	ErrExamNotPublished = errors.New("exam status is not PUBLISHED")
+	ErrExamNotCompleted = errors.New("exam status is not COMPLETED")
+	ErrExamHasSessions  = errors.New("exam already has student sessions")
+	ErrScheduleConflict = errors.New("exam schedule conflicts with another exam")
+	ErrMediaAltMissing  = errors.New("question media is missing alt text")
+
+	ErrQuestionCountTooHigh = errors.New("question_count exceeds the questions available")
+	ErrNoGradableQuestions  = errors.New("question bank has no automatically graded questions")
+	// ErrExamCacheBusy is returned when another instance kept rebuilding the exam's
+	// cache for longer than a caller waits.
+	ErrExamCacheBusy = errors.New("exam cache is being rebuilt, try again")
+)
+
+// cacheRebuildTimeout bounds rebuilding a live exam's lost cache from PostgreSQL.
+const cacheRebuildTimeout = 10 * time.Second
+
+// Publish, RefreshCache and the rebuild of a lost cache hold a per-exam lock while
+// they write an exam's cache, so their pipelines never interleave. A holder that dies
+// frees it after examCacheLockTTL; others wait up to examCacheLockWait for it.
+const (
+	examCacheLockTTL  = 30 * time.Second
+	examCacheLockWait = 10 * time.Second
 )
 
 // ExamService handles exam business logic and Redis caching.
@@ -28,8 +57,15 @@ type ExamService struct {
 	examRepo     *repository.ExamRepository
 	questionRepo *repository.QuestionRepository
 	targetRepo   *repository.ExamTargetRuleRepository
+	settingRepo  *repository.SettingRepository
+	subjectRepo  *repository.SubjectRepository
+	mediaRepo    *repository.MediaRepository
 	rdb          *redis.Client
+	queue        queue.Queue
 	log          zerolog.Logger
+
+	// rebuilds collapses concurrent rebuilds of one exam's lost cache into one.
+	rebuilds singleflight.Group
 }
 
 // NewExamService creates a new ExamService.
@@ -37,14 +73,22 @@ func NewExamService(
 	examRepo *repository.ExamRepository,
 	questionRepo *repository.QuestionRepository,
 	targetRepo *repository.ExamTargetRuleRepository,
+	settingRepo *repository.SettingRepository,
+	subjectRepo *repository.SubjectRepository,
+	mediaRepo *repository.MediaRepository,
 	rdb *redis.Client,
+	q queue.Queue,
 	log zerolog.Logger,
 ) *ExamService {
 	return &ExamService{
 		examRepo:     examRepo,
 		questionRepo: questionRepo,
 		targetRepo:   targetRepo,
+		settingRepo:  settingRepo,
+		subjectRepo:  subjectRepo,
+		mediaRepo:    mediaRepo,
 		rdb:          rdb,
+		queue:        q,
 		log:          log.With().Str("component", "exam_service").Logger(),
 	}
 }
@@ -54,8 +98,14 @@ func (s *ExamService) GetByID(ctx context.Context, id uuid.UUID) (*model.Exam, e
 	return s.examRepo.GetByID(ctx, id)
 }
 
-// ListByAuthor retrieves exams, filtered by author if not superadmin.
-func (s *ExamService) ListByAuthor(ctx context.Context, page, perPage int) ([]model.Exam, *response.Pagination, error) {
+// ListPublicResults lists the exams whose results are published on the public lookup page.
+func (s *ExamService) ListPublicResults(ctx context.Context) ([]model.PublicExam, error) {
+	return s.examRepo.ListPublicResults(ctx)
+}
+
+// ListByAuthor retrieves exams, filtered by author if not superadmin, and by metadata:
+// each key of metadata to the value the exam must have.
+func (s *ExamService) ListByAuthor(ctx context.Context, page, perPage int, metadata map[string]string) ([]model.Exam, *response.Pagination, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -69,71 +119,264 @@ func (s *ExamService) ListByAuthor(ctx context.Context, page, perPage int) ([]mo
 	limit := perPage
 	offset := (page - 1) * perPage
 
-	exams, total, err := s.examRepo.ListByAuthorPaginated(ctx, limit, offset)
+	contains, err := s.metadataFilter(ctx, metadata)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	if exams == nil {
-		exams = []model.Exam{}
-	}
-
-	totalPages := (total + perPage - 1) / perPage
-
-	pagination := &response.Pagination{
-		Page:       page,
-		PerPage:    perPage,
-		TotalItems: total,
-		TotalPages: totalPages,
+	exams, total, err := s.examRepo.ListByAuthorPaginated(ctx, contains, limit, offset)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return exams, pagination, nil
+	return exams, response.NewPagination(page, perPage, total), nil
 }
 
 // Create inserts a new exam as DRAFT.
 func (s *ExamService) Create(ctx context.Context, exam *model.Exam) error {
+	if err := s.checkExamScope(ctx, exam.QBankID, model.PermissionExamsWrite); err != nil {
+		return err
+	}
+	metadata, err := s.checkMetadata(ctx, exam.Metadata)
+	if err != nil {
+		return err
+	}
+	exam.Metadata = metadata
+	exam.Language, exam.Languages = model.DefaultLanguage, []string{}
 	exam.Status = model.ExamStatusDraft
 	return s.examRepo.Create(ctx, exam)
 }
 
+// SubjectTeacherWarning returns a warning when adminID is not assigned to teach the
+// subject of the exam's question bank. Exams without a subject and subjects without
+// assigned teachers draw no warning.
+func (s *ExamService) SubjectTeacherWarning(ctx context.Context, exam *model.Exam, adminID int) (*model.SubjectTeacherWarning, error) {
+	if exam.QBankID == nil {
+		return nil, nil
+	}
+	qbank, err := s.questionRepo.GetQBanks(ctx, *exam.QBankID)
+	if err != nil {
+		return nil, err
+	}
+	if qbank.SubjectID == nil {
+		return nil, nil
+	}
+	hasTeachers, assigned, err := s.subjectRepo.TeacherStatus(ctx, *qbank.SubjectID, adminID)
+	if err != nil || !hasTeachers || assigned {
+		return nil, err
+	}
+
+	warning := &model.SubjectTeacherWarning{SubjectID: *qbank.SubjectID}
+	if qbank.SubjectName != nil {
+		warning.SubjectName = *qbank.SubjectName
+	}
+	warning.Message = fmt.Sprintf("you are not assigned to teach %s", warning.SubjectName)
+	return warning, nil
+}
+
 // Publish changes exam status to PUBLISHED and caches the payload + answer key in Redis.
 // This is the critical path that populates the "Fast Lane".
-func (s *ExamService) Publish(ctx context.Context, examID uuid.UUID) error {
+// Schedule conflicts with other live exams are returned as warnings.
+func (s *ExamService) Publish(ctx context.Context, examID uuid.UUID) ([]model.ExamConflict, error) {
+	unlock, err := s.lockExamCache(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	exam, err := s.examRepo.GetByID(ctx, examID)
 	if err != nil {
-		return fmt.Errorf("get exam: %w", err)
+		return nil, fmt.Errorf("get exam: %w", err)
+	}
+	if err := s.checkExamScope(ctx, exam.QBankID, model.PermissionExamsPublish); err != nil {
+		return nil, err
 	}
 
 	if exam.Status != model.ExamStatusDraft {
-		return ErrExamNotDraft
+		return nil, ErrExamNotDraft
+	}
+
+	conflicts, err := s.checkScheduleConflicts(ctx, exam, nil)
+	if err != nil {
+		return conflicts, err
+	}
+
+	questions, err := s.questionRepo.ListByExam(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("list questions: %w", err)
+	}
+	if len(questionsMissingAltText(questions)) > 0 {
+		return nil, ErrMediaAltMissing
+	}
+	if err := checkQuestionSelection(exam, questions); err != nil {
+		return nil, err
+	}
+	if missing := missingTranslations(exam, questions); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTranslationMissing, strings.Join(missing, "; "))
+	}
+	size, err := s.payloadSize(ctx, exam, questions)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPayloadSize(size); err != nil {
+		return nil, err
 	}
 
 	// Prewarm cache for this exam.
 	if err := s.WarmExamCache(ctx, exam); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Update status in PostgreSQL.
 	if err := s.examRepo.UpdateStatus(ctx, examID, model.ExamStatusPublished); err != nil {
-		return fmt.Errorf("update status: %w", err)
+		return nil, fmt.Errorf("update status: %w", err)
 	}
 
 	s.log.Info().Str("exam_id", examID.String()).Msg("Exam published")
+	return conflicts, nil
+}
+
+// Unpublish reverts a published exam to DRAFT and clears its Redis caches.
+// Only allowed while no student has started a session.
+func (s *ExamService) Unpublish(ctx context.Context, examID uuid.UUID) error {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return fmt.Errorf("get exam: %w", err)
+	}
+	if err := s.checkExamScope(ctx, exam.QBankID, model.PermissionExamsPublish); err != nil {
+		return err
+	}
+
+	if exam.Status != model.ExamStatusPublished {
+		return ErrExamNotPublished
+	}
+
+	// The status flip and the session check happen in one statement so a
+	// student joining concurrently cannot slip in between.
+	reverted, err := s.examRepo.RevertToDraft(ctx, examID)
+	if err != nil {
+		return fmt.Errorf("revert status: %w", err)
+	}
+	if !reverted {
+		return ErrExamHasSessions
+	}
+
+	s.ClearExamCache(ctx, examID)
+
+	s.log.Info().Str("exam_id", examID.String()).Msg("Exam unpublished")
+	return nil
+}
+
+// Archive moves a completed exam to ARCHIVED, taking it off the calendar and the
+// dashboards' current lists, and clears whatever is left of its Redis caches.
+func (s *ExamService) Archive(ctx context.Context, examID uuid.UUID) error {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return fmt.Errorf("get exam: %w", err)
+	}
+	if err := s.checkExamScope(ctx, exam.QBankID, model.PermissionExamsPublish); err != nil {
+		return err
+	}
+	if exam.Status != model.ExamStatusCompleted {
+		return ErrExamNotCompleted
+	}
+
+	if err := s.examRepo.UpdateStatus(ctx, examID, model.ExamStatusArchived); err != nil {
+		return fmt.Errorf("update status: %w", err)
+	}
+	s.ClearExamCache(ctx, examID)
+
+	s.log.Info().Str("exam_id", examID.String()).Msg("Exam archived")
 	return nil
 }
 
+// AdvanceStatuses applies the automatic exam lifecycle transitions:
+// PUBLISHED → IN_PROGRESS once a student joins, and PUBLISHED/IN_PROGRESS → COMPLETED
+// once the scheduled window has ended and no session is still running, or sessions
+// are still open DefaultStaleGraceMinutes after the last student's time ran out.
+// Each transition is announced on the exam's monitor channel. Returns the IDs of
+// the exams that started and of those that completed.
+func (s *ExamService) AdvanceStatuses(ctx context.Context) (startedIDs, completedIDs []uuid.UUID, err error) {
+	startedIDs, err = s.examRepo.MarkStartedInProgress(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mark in progress: %w", err)
+	}
+	for _, id := range startedIDs {
+		s.publishStatusEvent(ctx, id, model.ExamStatusInProgress)
+	}
+
+	completedIDs, err = s.examRepo.MarkFinishedCompleted(ctx, model.LocalTime(time.Now()), DefaultStaleGraceMinutes*time.Minute)
+	if err != nil {
+		return startedIDs, nil, fmt.Errorf("mark completed: %w", err)
+	}
+	for _, id := range completedIDs {
+		s.publishStatusEvent(ctx, id, model.ExamStatusCompleted)
+	}
+
+	return startedIDs, completedIDs, nil
+}
+
+func (s *ExamService) publishStatusEvent(ctx context.Context, examID uuid.UUID, status model.ExamStatus) {
+	s.log.Info().Str("exam_id", examID.String()).Str("status", string(status)).Msg("Exam status changed")
+
+	event := map[string]interface{}{
+		"type":    "exam_status",
+		"exam_id": examID.String(),
+		"status":  status,
+	}
+	if err := publishMonitorEvent(ctx, s.rdb, s.queue, examID, event); err != nil {
+		s.log.Warn().Err(err).Str("exam_id", examID.String()).Msg("Failed to publish status event")
+	}
+}
+
+// ClearExamCache removes an exam's cached payload, answer key and settings from Redis.
+func (s *ExamService) ClearExamCache(ctx context.Context, examID uuid.UUID) {
+	id := examID.String()
+	err := s.rdb.Del(ctx,
+		config.CacheKey.ExamPayloadKey(id),
+		config.CacheKey.ExamAnswerKey(id),
+		config.CacheKey.ExamCheatRulesKey(id),
+		config.CacheKey.ExamDurationKey(id),
+		config.CacheKey.ExamRandomOrderKey(id),
+		config.CacheKey.ExamPlayLimitsKey(id),
+		config.CacheKey.ExamNavigationKey(id),
+		config.CacheKey.ExamCurrentQuestionsKey(id),
+		config.CacheKey.ExamBreakPolicyKey(id),
+		config.CacheKey.ExamDisconnectPauseKey(id),
+		config.CacheKey.ExamLastSeenKey(id),
+		config.CacheKey.ExamDisconnectPausedKey(id),
+	).Err()
+	if err != nil {
+		s.log.Warn().Err(err).Str("exam_id", id).Msg("Failed to clear exam cache")
+	}
+}
+
 // RefreshCache re-caches the payload + answer key for a published exam.
-// Called when questions are updated after publish.
+// Called when questions are updated after publish. Like Publish it refuses questions
+// that no longer fit the exam, keeping the cached payload.
 func (s *ExamService) RefreshCache(ctx context.Context, examID uuid.UUID) error {
+	unlock, err := s.lockExamCache(ctx, examID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	exam, err := s.examRepo.GetByID(ctx, examID)
 	if err != nil {
 		return fmt.Errorf("get exam: %w", err)
 	}
 
-	if exam.Status != model.ExamStatusPublished {
+	if !exam.Status.IsLive() {
 		return ErrExamNotPublished
 	}
 
+	questions, err := s.questionRepo.ListByExam(ctx, examID)
+	if err != nil {
+		return fmt.Errorf("list questions: %w", err)
+	}
+	if err := checkQuestionSelection(exam, questions); err != nil {
+		return err
+	}
+
 	if err := s.WarmExamCache(ctx, exam); err != nil {
 		return err
 	}
@@ -144,6 +387,8 @@ func (s *ExamService) RefreshCache(ctx context.Context, examID uuid.UUID) error
 
 // WarmExamCache loads an exam's payload and answer key from PostgreSQL into Redis.
 // This is the core cache-warming logic used by Publish, RefreshCache, and PrewarmAllCaches.
+// Question selection is checked by its callers, so that prewarming never drops an
+// exam that was published before the checks existed.
 func (s *ExamService) WarmExamCache(ctx context.Context, exam *model.Exam) error {
 	questions, err := s.questionRepo.ListByExam(ctx, exam.ID)
 	if err != nil {
@@ -152,34 +397,37 @@ func (s *ExamService) WarmExamCache(ctx context.Context, exam *model.Exam) error
 	if len(questions) == 0 {
 		return ErrNoQuestions
 	}
-
-	// Build student-facing payload (without correct answers).
-	studentQuestions := make([]model.QuestionForStudent, len(questions))
-	for i, q := range questions {
-		studentQuestions[i] = model.QuestionForStudent{
-			ID:           q.ID,
-			QuestionText: q.QuestionText,
-			Options:      q.Options,
-			OrderNum:     q.OrderNum,
-		}
+	if err := s.resolveOptionMedia(ctx, questions); err != nil {
+		return err
 	}
 
-	payload := model.ExamPayload{
-		ExamID:    exam.ID,
-		Title:     exam.Title,
-		Duration:  exam.DurationMinutes,
-		Questions: studentQuestions,
+	passages, err := s.questionRepo.ListPassagesByExam(ctx, exam.ID)
+	if err != nil {
+		return fmt.Errorf("list passages: %w", err)
 	}
 
-	payloadJSON, err := json.Marshal(payload)
+	// Build student-facing payload (without correct answers).
+	payloadJSON, err := json.Marshal(studentPayload(exam, questions, passages))
 	if err != nil {
 		return fmt.Errorf("marshal payload: %w", err)
 	}
+	navJSON, err := json.Marshal(model.NavigationRules{Policy: exam.NavigationPolicy, SectionSize: exam.SectionSize})
+	if err != nil {
+		return fmt.Errorf("marshal navigation rules: %w", err)
+	}
+	breakJSON, err := json.Marshal(model.BreakPolicy{MaxBreaks: exam.MaxBreaks, BreakMinutes: exam.BreakMinutes})
+	if err != nil {
+		return fmt.Errorf("marshal break policy: %w", err)
+	}
 
-	// Build answer key map for RAM grading.
+	// Build answer key map for RAM grading, plus play limits for media questions.
 	answerKey := make(map[string]interface{}, len(questions))
+	playLimits := make(map[string]interface{})
 	for _, q := range questions {
 		answerKey[q.ID.String()] = q.CorrectOption
+		if q.MaxPlays > 0 {
+			playLimits[q.ID.String()] = q.MaxPlays
+		}
 	}
 
 	// Cache both atomically via pipeline.
@@ -190,6 +438,13 @@ func (s *ExamService) WarmExamCache(ctx context.Context, exam *model.Exam) error
 	pipe.Set(ctx, config.CacheKey.ExamCheatRulesKey(exam.ID.String()), []byte(exam.CheatRules), 0)
 	pipe.Set(ctx, config.CacheKey.ExamDurationKey(exam.ID.String()), exam.DurationMinutes, 0)
 	pipe.Set(ctx, config.CacheKey.ExamRandomOrderKey(exam.ID.String()), exam.RandomizeQuestions, 0)
+	pipe.Set(ctx, config.CacheKey.ExamNavigationKey(exam.ID.String()), navJSON, 0)
+	pipe.Set(ctx, config.CacheKey.ExamBreakPolicyKey(exam.ID.String()), breakJSON, 0)
+	pipe.Set(ctx, config.CacheKey.ExamDisconnectPauseKey(exam.ID.String()), exam.DisconnectPauseMinutes, 0)
+	pipe.Del(ctx, config.CacheKey.ExamPlayLimitsKey(exam.ID.String()))
+	if len(playLimits) > 0 {
+		pipe.HSet(ctx, config.CacheKey.ExamPlayLimitsKey(exam.ID.String()), playLimits)
+	}
 
 	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("cache to redis: %w", err)
@@ -202,6 +457,220 @@ func (s *ExamService) WarmExamCache(ctx context.Context, exam *model.Exam) error
 	return nil
 }
 
+// toStudentQuestions strips correct answers from questions and lists their media with
+// its alt text, so the exam UI can adapt them for assistive technology.
+func toStudentQuestions(questions []model.Question) []model.QuestionForStudent {
+	studentQuestions := make([]model.QuestionForStudent, len(questions))
+	for i, q := range questions {
+		studentQuestions[i] = model.QuestionForStudent{
+			ID:           q.ID,
+			QuestionType: q.QuestionType,
+			QuestionText: q.QuestionText,
+			Options:      q.Options,
+			OrderNum:     q.OrderNum,
+			MaxPlays:     q.MaxPlays,
+			MathLatex:    q.MathLatex,
+			Media:        mediaHints(q),
+			PassageID:    q.PassageID,
+			Translations: studentTranslations(q.Translations),
+		}
+	}
+	return studentQuestions
+}
+
+// toStudentPassages converts passages to their student-facing form.
+func toStudentPassages(passages []model.Passage) []model.PassageForStudent {
+	if len(passages) == 0 {
+		return nil
+	}
+	out := make([]model.PassageForStudent, len(passages))
+	for i, p := range passages {
+		out[i] = model.PassageForStudent{ID: p.ID, Title: p.Title, Content: p.Content}
+	}
+	return out
+}
+
+// questionMedia lists the media embedded in a question's text and options, followed
+// by the media attached to its options.
+func questionMedia(q model.Question) []helper.MediaRef {
+	refs := append(helper.FindMedia(q.QuestionText), helper.FindMediaInJSON(q.Options)...)
+	for _, o := range keyedOptions(q) {
+		if o.MediaID != nil {
+			refs = append(refs, helper.MediaRef{Kind: o.MediaType, Src: o.MediaURL, Label: strings.TrimSpace(o.MediaAlt)})
+		}
+	}
+	return refs
+}
+
+// questionsMissingAltText returns the order numbers of questions with an image lacking
+// alt text, or audio/video lacking an aria-label.
+func questionsMissingAltText(questions []model.Question) []string {
+	var missing []string
+	for _, q := range questions {
+		for _, m := range questionMedia(q) {
+			if m.Label == "" {
+				missing = append(missing, strconv.Itoa(q.OrderNum))
+				break
+			}
+		}
+	}
+	return missing
+}
+
+// checkQuestionSelection verifies that an exam's questions can serve it: question_count
+// must not exceed them, and at least one must be graded automatically. Errors carry
+// the numbers involved.
+func checkQuestionSelection(exam *model.Exam, questions []model.Question) error {
+	if len(questions) == 0 {
+		return ErrNoQuestions
+	}
+	if exam.QuestionCount > len(questions) {
+		return fmt.Errorf("%w: question_count is %d but only %d questions exist", ErrQuestionCountTooHigh, exam.QuestionCount, len(questions))
+	}
+	if countGradable(questions) == 0 {
+		return fmt.Errorf("%w: all %d questions are essays or lack a valid correct option", ErrNoGradableQuestions, len(questions))
+	}
+	return nil
+}
+
+// countGradable counts the multiple-choice and true/false questions with a valid
+// correct option, the ones graded on submit.
+func countGradable(questions []model.Question) int {
+	n := 0
+	for _, q := range questions {
+		if q.QuestionType != model.QuestionTypeEssay && hasValidCorrectOption(q) {
+			n++
+		}
+	}
+	return n
+}
+
+// mediaHints converts a question's media into accessibility hints.
+func mediaHints(q model.Question) []model.MediaHint {
+	refs := questionMedia(q)
+	if len(refs) == 0 {
+		return nil
+	}
+	hints := make([]model.MediaHint, len(refs))
+	for i, r := range refs {
+		hints[i] = model.MediaHint{Type: r.Kind, Src: r.Src, Alt: r.Label}
+	}
+	return hints
+}
+
+// selectQuestionOrder applies an exam's randomization and question_count to its
+// questions and returns the selected IDs. Shared by session creation and author preview
+// so both agree. Questions sharing a passage stay together, in their original order,
+// and are shuffled as one block.
+func selectQuestionOrder(exam *model.Exam, questions []model.QuestionForStudent, r *rand.Rand) []string {
+	var blocks [][]string
+	passageBlock := make(map[uuid.UUID]int)
+	for _, q := range questions {
+		if q.PassageID != nil {
+			if i, ok := passageBlock[*q.PassageID]; ok {
+				blocks[i] = append(blocks[i], q.ID.String())
+				continue
+			}
+			passageBlock[*q.PassageID] = len(blocks)
+		}
+		blocks = append(blocks, []string{q.ID.String()})
+	}
+
+	if exam.RandomizeQuestions {
+		r.Shuffle(len(blocks), func(i, j int) {
+			blocks[i], blocks[j] = blocks[j], blocks[i]
+		})
+	}
+
+	qIDs := make([]string, 0, len(questions))
+	for _, b := range blocks {
+		qIDs = append(qIDs, b...)
+	}
+
+	if exam.QuestionCount > 0 && exam.QuestionCount < len(qIDs) {
+		qIDs = qIDs[:exam.QuestionCount]
+	}
+	return qIDs
+}
+
+// Preview builds the exam as a student would see it, straight from PostgreSQL,
+// without creating a session or touching the cache. Works for drafts too.
+// The seed drives the simulated shuffle; withAnswers adds the answer-key overlay.
+func (s *ExamService) Preview(ctx context.Context, examID uuid.UUID, seed int64, withAnswers bool) (*model.ExamPreview, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.questionRepo.ListByExam(ctx, exam.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list questions: %w", err)
+	}
+	if len(questions) == 0 {
+		return nil, ErrNoQuestions
+	}
+	if err := s.resolveOptionMedia(ctx, questions); err != nil {
+		return nil, err
+	}
+
+	passages, err := s.questionRepo.ListPassagesByExam(ctx, exam.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list passages: %w", err)
+	}
+
+	studentQuestions := toStudentQuestions(questions)
+	byID := make(map[string]model.QuestionForStudent, len(studentQuestions))
+	for _, q := range studentQuestions {
+		byID[q.ID.String()] = q
+	}
+
+	ordered := selectQuestionOrder(exam, studentQuestions, rand.New(rand.NewSource(seed)))
+	previewQuestions := make([]model.QuestionForStudent, len(ordered))
+	for i, id := range ordered {
+		q := byID[id]
+		q.OrderNum = i + 1
+		previewQuestions[i] = q
+	}
+
+	preview := &model.ExamPreview{
+		ExamPayload: model.ExamPayload{
+			ExamID:    exam.ID,
+			Title:     exam.Title,
+			Duration:  exam.DurationMinutes,
+			Questions: previewQuestions,
+			Passages:  model.PassagesFor(previewQuestions, toStudentPassages(passages)),
+		},
+		Seed:       seed,
+		CheatRules: exam.CheatRules,
+	}
+
+	if withAnswers {
+		correct := make(map[string]string, len(questions))
+		for _, q := range questions {
+			correct[q.ID.String()] = q.CorrectOption
+		}
+		preview.AnswerKey = make(map[string]string, len(ordered))
+		for _, id := range ordered {
+			preview.AnswerKey[id] = correct[id]
+		}
+	}
+
+	return preview, nil
+}
+
+// reviewQuestions lists the questions of an exam with their answers, explanations and
+// option media, straight from PostgreSQL, for students reviewing the exam after it.
+func (s *ExamService) reviewQuestions(ctx context.Context, examID uuid.UUID) ([]model.Question, error) {
+	questions, err := s.questionRepo.ListByExam(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("list questions: %w", err)
+	}
+	if err := s.resolveOptionMedia(ctx, questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
 // PrewarmAllCaches loads all published exams into Redis on application startup.
 // This prevents any lazy-loading race conditions under thundering herd traffic.
 func (s *ExamService) PrewarmAllCaches(ctx context.Context) error {
@@ -240,11 +709,20 @@ func (s *ExamService) PrewarmAllCaches(ctx context.Context) error {
 func (s *ExamService) GetExamPayload(ctx context.Context, examID uuid.UUID) (*model.ExamPayload, error) {
 	key := config.CacheKey.ExamPayloadKey(examID.String())
 	data, err := s.rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The payload of a live exam was lost (e.g. Redis restarted without persistence).
+		if err := s.rebuildCache(ctx, examID); err != nil {
+			return nil, err
+		}
+		data, err = s.rdb.Get(ctx, key).Bytes()
+	}
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
 			return nil, errors.New("exam not published or payload not cached")
 		}
-		return nil, fmt.Errorf("get payload: %w", err)
+		// Redis is unreachable; serve the payload from PostgreSQL instead.
+		s.log.Warn().Err(err).Str("exam_id", examID.String()).Msg("Payload read from database, Redis unavailable")
+		return s.examPayloadFromDB(ctx, examID)
 	}
 
 	var payload model.ExamPayload
@@ -254,10 +732,63 @@ func (s *ExamService) GetExamPayload(ctx context.Context, examID uuid.UUID) (*mo
 	return &payload, nil
 }
 
+// examPayloadFromDB builds the student-facing payload of a live exam from PostgreSQL.
+func (s *ExamService) examPayloadFromDB(ctx context.Context, examID uuid.UUID) (*model.ExamPayload, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("get exam: %w", err)
+	}
+	if !exam.Status.IsLive() {
+		return nil, errors.New("exam not published or payload not cached")
+	}
+	questions, err := s.questionRepo.ListByExam(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("list questions: %w", err)
+	}
+	if err := s.resolveOptionMedia(ctx, questions); err != nil {
+		return nil, err
+	}
+	passages, err := s.questionRepo.ListPassagesByExam(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("list passages: %w", err)
+	}
+	payload := studentPayload(exam, questions, passages)
+	return &payload, nil
+}
+
+// studentPayload builds the payload students download when they join an exam, without
+// correct answers. Option media must already be resolved.
+func studentPayload(exam *model.Exam, questions []model.Question, passages []model.Passage) model.ExamPayload {
+	payload := model.ExamPayload{
+		ExamID:    exam.ID,
+		Title:     exam.Title,
+		Duration:  exam.DurationMinutes,
+		Questions: toStudentQuestions(questions),
+		Passages:  toStudentPassages(passages),
+	}
+	setPayloadLanguages(&payload, exam)
+	return payload
+}
+
+// setPayloadLanguages records in a payload the languages it may be served in.
+func setPayloadLanguages(payload *model.ExamPayload, exam *model.Exam) {
+	payload.Language = exam.Language
+	payload.ForcedLanguage = exam.ForcedLanguage
+	if len(exam.Languages) > 0 {
+		payload.Languages = append([]string{exam.Language}, exam.Languages...)
+	}
+}
+
 // GetAnswerKey retrieves the answer key from Redis for instant grading.
 func (s *ExamService) GetAnswerKey(ctx context.Context, examID uuid.UUID) (map[string]string, error) {
 	key := config.CacheKey.ExamAnswerKey(examID.String())
 	result, err := s.rdb.HGetAll(ctx, key).Result()
+	if err == nil && len(result) == 0 {
+		if err := s.rebuildCache(ctx, examID); err != nil {
+			return nil, err
+		}
+		result, err = s.rdb.HGetAll(ctx, key).Result()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("get answer key: %w", err)
 	}
@@ -267,20 +798,108 @@ func (s *ExamService) GetAnswerKey(ctx context.Context, examID uuid.UUID) (map[s
 	return result, nil
 }
 
-// AddTargetRule adds a target rule to an exam.
-func (s *ExamService) AddTargetRule(ctx context.Context, rule *model.ExamTargetRule) error {
+// rebuildCache re-warms the cache of a live exam whose cached payload or answer key
+// is missing. Concurrent callers for the same exam share one rebuild, so a lost key
+// does not send every student of the exam to PostgreSQL at once. The rebuild outlives
+// the request that started it, as other requests wait on it.
+func (s *ExamService) rebuildCache(ctx context.Context, examID uuid.UUID) error {
+	_, err, _ := s.rebuilds.Do(examID.String(), func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cacheRebuildTimeout)
+		defer cancel()
+
+		unlock, err := s.lockExamCache(ctx, examID)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		// A publish or refresh that held the lock may have written the cache already.
+		id := examID.String()
+		if n, err := s.rdb.Exists(ctx, config.CacheKey.ExamPayloadKey(id), config.CacheKey.ExamAnswerKey(id)).Result(); err == nil && n == 2 {
+			return nil, nil
+		}
+
+		exam, err := s.examRepo.GetByID(ctx, examID)
+		if err != nil {
+			return nil, fmt.Errorf("get exam: %w", err)
+		}
+		if !exam.Status.IsLive() {
+			return nil, ErrExamNotPublished
+		}
+		if err := s.WarmExamCache(ctx, exam); err != nil {
+			s.log.Error().Err(err).Str("exam_id", examID.String()).Msg("Failed to rebuild lost exam cache")
+			return nil, err
+		}
+		s.log.Warn().Str("exam_id", examID.String()).Msg("Exam cache was missing, rebuilt from database")
+		return nil, nil
+	})
+	return err
+}
+
+// lockExamCache takes the lock serializing writes of an exam's cache across instances.
+func (s *ExamService) lockExamCache(ctx context.Context, examID uuid.UUID) (func(), error) {
+	unlock, err := acquireLock(ctx, s.rdb, config.CacheKey.ExamCacheLockKey(examID.String()), examCacheLockTTL, examCacheLockWait)
+	if errors.Is(err, errLockHeld) {
+		return nil, ErrExamCacheBusy
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lock exam cache: %w", err)
+	}
+	return unlock, nil
+}
+
+// GetAnswerKeyDirect builds the answer key from PostgreSQL, for grading while Redis is down.
+func (s *ExamService) GetAnswerKeyDirect(ctx context.Context, examID uuid.UUID) (map[string]string, error) {
+	questions, err := s.questionRepo.ListByExam(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("list questions: %w", err)
+	}
+	if len(questions) == 0 {
+		return nil, ErrNoQuestions
+	}
+	answerKey := make(map[string]string, len(questions))
+	for _, q := range questions {
+		answerKey[q.ID.String()] = q.CorrectOption
+	}
+	return answerKey, nil
+}
+
+// AddTargetRule adds a target rule to an exam and reports schedule conflicts it introduces.
+func (s *ExamService) AddTargetRule(ctx context.Context, rule *model.ExamTargetRule) ([]model.ExamConflict, error) {
+	if err := s.checkExamIDScope(ctx, rule.ExamID, model.PermissionExamsWrite); err != nil {
+		return nil, err
+	}
 	if err := s.checkDuplicateTargetRule(ctx, rule); err != nil {
-		return err
+		return nil, err
 	}
-	return s.targetRepo.Create(ctx, rule)
+	conflicts, err := s.checkRuleConflicts(ctx, rule)
+	if err != nil {
+		return conflicts, err
+	}
+	return conflicts, s.targetRepo.Create(ctx, rule)
 }
 
-// UpdateTargetRule modifies an existing target rule for an exam.
-func (s *ExamService) UpdateTargetRule(ctx context.Context, rule *model.ExamTargetRule) error {
+// UpdateTargetRule modifies an existing target rule for an exam and reports schedule conflicts.
+func (s *ExamService) UpdateTargetRule(ctx context.Context, rule *model.ExamTargetRule) ([]model.ExamConflict, error) {
+	if err := s.checkExamIDScope(ctx, rule.ExamID, model.PermissionExamsWrite); err != nil {
+		return nil, err
+	}
 	if err := s.checkDuplicateTargetRule(ctx, rule); err != nil {
-		return err
+		return nil, err
+	}
+	conflicts, err := s.checkRuleConflicts(ctx, rule)
+	if err != nil {
+		return conflicts, err
+	}
+	return conflicts, s.targetRepo.Update(ctx, rule)
+}
+
+func (s *ExamService) checkRuleConflicts(ctx context.Context, rule *model.ExamTargetRule) ([]model.ExamConflict, error) {
+	exam, err := s.examRepo.GetByID(ctx, rule.ExamID)
+	if err != nil {
+		return nil, err
 	}
-	return s.targetRepo.Update(ctx, rule)
+	return s.checkScheduleConflicts(ctx, exam, rule)
 }
 
 func (s *ExamService) checkDuplicateTargetRule(ctx context.Context, rule *model.ExamTargetRule) error {
@@ -310,6 +929,9 @@ func (s *ExamService) checkDuplicateTargetRule(ctx context.Context, rule *model.
 
 // DeleteTargetRule removes a target rule by ID for a specific exam.
 func (s *ExamService) DeleteTargetRule(ctx context.Context, ruleID int, examID uuid.UUID) error {
+	if err := s.checkExamIDScope(ctx, examID, model.PermissionExamsWrite); err != nil {
+		return err
+	}
 	return s.targetRepo.Delete(ctx, ruleID, examID)
 }
 
@@ -318,25 +940,48 @@ func (s *ExamService) GetTargetRules(ctx context.Context, examID uuid.UUID) ([]m
 	return s.targetRepo.ListByExam(ctx, examID)
 }
 
-// Update modifies an existing draft exam.
-func (s *ExamService) Update(ctx context.Context, exam *model.Exam) error {
-	_, err := s.examRepo.GetByID(ctx, exam.ID)
+// Update modifies an existing draft exam and reports schedule conflicts with other live exams.
+func (s *ExamService) Update(ctx context.Context, exam *model.Exam) ([]model.ExamConflict, error) {
+	existing, err := s.examRepo.GetByID(ctx, exam.ID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// Both the current and the new question bank must be within scope.
+	if err := s.checkExamScope(ctx, existing.QBankID, model.PermissionExamsWrite); err != nil {
+		return nil, err
+	}
+	if err := s.checkExamScope(ctx, exam.QBankID, model.PermissionExamsWrite); err != nil {
+		return nil, err
+	}
+	// Unchanged metadata is kept as it is, even if the schema changed since.
+	if !reflect.DeepEqual(exam.Metadata, existing.Metadata) {
+		metadata, err := s.checkMetadata(ctx, exam.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		exam.Metadata = metadata
+	}
+	if err := checkExamLanguages(exam); err != nil {
+		return nil, err
+	}
+
+	conflicts, err := s.checkScheduleConflicts(ctx, exam, nil)
+	if err != nil {
+		return conflicts, err
 	}
 	// Always allow change
 	// if existing.Status != model.ExamStatusDraft {
 	// 	return ErrExamNotDraft
 	// }
 
-	// rewarm cache if exam is published
-	if exam.Status == model.ExamStatusPublished {
+	// rewarm cache if exam is live
+	if exam.Status.IsLive() {
 		if err := s.WarmExamCache(ctx, exam); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	return s.examRepo.Update(ctx, exam)
+	return conflicts, s.examRepo.Update(ctx, exam)
 }
 
 // Delete removes a draft exam.
@@ -345,6 +990,9 @@ func (s *ExamService) Delete(ctx context.Context, id uuid.UUID) error {
 	if err != nil {
 		return err
 	}
+	if err := s.checkExamScope(ctx, existing.QBankID, model.PermissionExamsWrite); err != nil {
+		return err
+	}
 	if existing.Status != model.ExamStatusDraft {
 		return ErrExamNotDraft
 	}
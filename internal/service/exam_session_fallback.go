@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// While Redis is degraded, autosave and submit go straight to PostgreSQL. Every such
+// write is also journaled in memory so it can be replayed into Redis on recovery;
+// otherwise the stale Redis answer hash would win over the newer database rows.
+
+type sessionRef struct {
+	examID    uuid.UUID
+	studentID int
+}
+
+type directWrites struct {
+	answers   map[string]string // latest answer per question; "" means removed
+	completed bool
+}
+
+// RedisDegraded reports whether session reads and writes currently bypass Redis.
+func (s *ExamSessionService) RedisDegraded() bool {
+	return s.health.Degraded()
+}
+
+// SaveAnswerDirect persists an answer straight to PostgreSQL. An empty answer removes it.
+func (s *ExamSessionService) SaveAnswerDirect(ctx context.Context, examID uuid.UUID, studentID int, qid, answer string) error {
+	questionID, err := uuid.Parse(qid)
+	if err != nil {
+		return fmt.Errorf("invalid question id: %w", err)
+	}
+	if err := s.sessionRepo.SaveAnswer(ctx, examID, studentID, questionID, answer); err != nil {
+		return fmt.Errorf("save answer: %w", err)
+	}
+
+	s.journalMu.Lock()
+	w := s.journalEntry(examID, studentID)
+	w.answers[qid] = answer
+	s.journalMu.Unlock()
+	return nil
+}
+
+// ListAnswersDirect returns a student's answers from PostgreSQL.
+func (s *ExamSessionService) ListAnswersDirect(ctx context.Context, examID uuid.UUID, studentID int) (map[string]string, error) {
+	return s.sessionRepo.ListAnswers(ctx, examID, studentID)
+}
+
+// CompleteDirect marks a session completed with its score in PostgreSQL,
+// bypassing the scoring queue. It returns the session's final score, which is the
+// earlier score when the session was already completed.
+func (s *ExamSessionService) CompleteDirect(ctx context.Context, examID uuid.UUID, studentID int, score float64) (float64, error) {
+	completed, err := s.sessionRepo.Complete(ctx, examID, studentID, score)
+	if err != nil {
+		return 0, fmt.Errorf("complete session: %w", err)
+	}
+	if !completed {
+		if prev, done, err := s.completedScoreFromDB(ctx, examID, studentID); err == nil && done {
+			score = prev
+		}
+	}
+
+	s.journalMu.Lock()
+	s.journalEntry(examID, studentID).completed = true
+	s.journalMu.Unlock()
+	return score, nil
+}
+
+// journalEntry returns the journal entry for a session. Callers must hold journalMu.
+func (s *ExamSessionService) journalEntry(examID uuid.UUID, studentID int) *directWrites {
+	ref := sessionRef{examID: examID, studentID: studentID}
+	w, ok := s.journal[ref]
+	if !ok {
+		w = &directWrites{answers: make(map[string]string)}
+		s.journal[ref] = w
+	}
+	return w
+}
+
+// ResyncRedis replays writes made directly to PostgreSQL during an outage into Redis:
+// answers are applied to the answer hashes, and completed sessions have their
+// autosave, media play and active exam keys cleared like the scoring worker does.
+// Entries that fail to apply are kept for the next recovery.
+func (s *ExamSessionService) ResyncRedis(ctx context.Context) {
+	s.journalMu.Lock()
+	journal := s.journal
+	s.journal = make(map[sessionRef]*directWrites)
+	s.journalMu.Unlock()
+
+	if len(journal) == 0 {
+		return
+	}
+
+	failed := 0
+	for ref, w := range journal {
+		examID := ref.examID.String()
+		_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if w.completed {
+				pipe.Del(ctx, config.CacheKey.StudentAnswersKey(examID, ref.studentID))
+				pipe.Del(ctx, config.CacheKey.StudentMediaPlaysKey(examID, ref.studentID))
+				pipe.Del(ctx, config.CacheKey.StudentNavPositionKey(examID, ref.studentID))
+				pipe.Del(ctx, config.CacheKey.StudentBreaksKey(examID, ref.studentID))
+				pipe.HDel(ctx, config.CacheKey.ExamCurrentQuestionsKey(examID), strconv.Itoa(ref.studentID))
+				pipe.Del(ctx, config.CacheKey.StudentActiveExamKey(ref.studentID))
+				return nil
+			}
+			answersKey := config.CacheKey.StudentAnswersKey(examID, ref.studentID)
+			for qid, answer := range w.answers {
+				if answer == "" {
+					pipe.HDel(ctx, answersKey, qid)
+				} else {
+					pipe.HSet(ctx, answersKey, qid, answer)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			failed++
+			s.journalMu.Lock()
+			s.mergeJournal(ref, w)
+			s.journalMu.Unlock()
+		}
+	}
+
+	if failed > 0 {
+		s.log.Warn().Int("failed", failed).Int("total", len(journal)).Msg("Redis resync left sessions pending")
+	}
+}
+
+// mergeJournal puts an unapplied entry back without overriding newer writes.
+// Callers must hold journalMu.
+func (s *ExamSessionService) mergeJournal(ref sessionRef, old *directWrites) {
+	cur := s.journalEntry(ref.examID, ref.studentID)
+	for qid, answer := range old.answers {
+		if _, newer := cur.answers[qid]; !newer {
+			cur.answers[qid] = answer
+		}
+	}
+	cur.completed = cur.completed || old.completed
+}
+
+// examStateFromDB rebuilds the exam session state from PostgreSQL while Redis is down.
+// Media play counters and the navigation position live only in Redis and are
+// reported as empty.
+func (s *ExamSessionService) examStateFromDB(ctx context.Context, examID uuid.UUID, studentID int) (*model.ExamSessionState, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("get exam: %w", err)
+	}
+	sess, err := s.sessionRepo.GetByExamAndStudent(ctx, examID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	answers, err := s.sessionRepo.ListAnswers(ctx, examID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("list answers: %w", err)
+	}
+
+	var cheatRules map[string]bool
+	if len(exam.CheatRules) > 0 {
+		if err := json.Unmarshal(exam.CheatRules, &cheatRules); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cheat rules: %w", err)
+		}
+	}
+
+	breaks, err := s.breakStatusFromDB(ctx, exam, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	disconnectPaused, err := s.sessionRepo.GetDisconnectPause(ctx, examID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("get disconnect pause: %w", err)
+	}
+
+	endTime := timerEnd(sess.StartedAt, exam.DurationMinutes, breaks, time.Now()).Add(time.Duration(disconnectPaused) * time.Second)
+	remaining := time.Until(endTime)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return &model.ExamSessionState{
+		ExamID:           examID,
+		StudentID:        studentID,
+		IsRandomOrder:    exam.RandomizeQuestions,
+		CheatRules:       cheatRules,
+		AutosavedAnswers: answers,
+		MediaPlays:       map[string]int{},
+		NavigationPolicy: exam.NavigationPolicy,
+		SectionSize:      exam.SectionSize,
+		Breaks:           breaks,
+		RemainingTime:    remaining.Seconds(),
+
+		DisconnectPausedSeconds: disconnectPaused,
+	}, nil
+}
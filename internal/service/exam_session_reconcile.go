@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// reconcileBatchSize caps the sessions one reconciliation pipeline covers.
+const reconcileBatchSize = 500
+
+// ReconcileRedis restores the Redis state of open sessions that Redis lost, e.g. in a
+// crash without persistence: the start time, active exam and question order from
+// exam_sessions, and the answer hash from student_answers. Only missing keys and
+// answers are written, so state newer than PostgreSQL is never overridden.
+func (s *ExamSessionService) ReconcileRedis(ctx context.Context) (*model.SessionReconcileReport, error) {
+	sessions, err := s.sessionRepo.ListOpenSessions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list open sessions: %w", err)
+	}
+
+	report := &model.SessionReconcileReport{Sessions: len(sessions)}
+	for start := 0; start < len(sessions); start += reconcileBatchSize {
+		batch := sessions[start:min(start+reconcileBatchSize, len(sessions))]
+		if err := s.reconcileBatch(ctx, batch, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func (s *ExamSessionService) reconcileBatch(ctx context.Context, sessions []model.ExamSession, report *model.SessionReconcileReport) error {
+	type checks struct {
+		start, active, order *redis.BoolCmd
+		answers              *redis.IntCmd
+	}
+	results := make([]checks, len(sessions))
+
+	pipe := s.rdb.Pipeline()
+	for i, sess := range sessions {
+		examID := sess.ExamID.String()
+		results[i].start = pipe.SetNX(ctx, config.CacheKey.StudentExamSessionStartKey(examID, sess.StudentID), sess.StartedAt.Unix(), 0)
+		results[i].active = pipe.SetNX(ctx, config.CacheKey.StudentActiveExamKey(sess.StudentID), examID, 0)
+		if len(sess.QuestionOrder) > 0 {
+			orderJSON, err := json.Marshal(sess.QuestionOrder)
+			if err != nil {
+				return err
+			}
+			results[i].order = pipe.SetNX(ctx, config.CacheKey.StudentShuffledQuestionKey(examID, sess.StudentID), orderJSON, 0)
+		}
+		results[i].answers = pipe.Exists(ctx, config.CacheKey.StudentAnswersKey(examID, sess.StudentID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("restore session keys: %w", err)
+	}
+
+	for i, sess := range sessions {
+		r := results[i]
+		if r.start.Val() {
+			report.StartTimes++
+		}
+		if r.active.Val() {
+			report.ActiveExams++
+		}
+		if r.order != nil && r.order.Val() {
+			report.QuestionOrders++
+		}
+		if r.answers.Val() > 0 {
+			continue
+		}
+
+		answers, err := s.sessionRepo.ListAnswers(ctx, sess.ExamID, sess.StudentID)
+		if err != nil {
+			return fmt.Errorf("list answers: %w", err)
+		}
+		if len(answers) == 0 {
+			continue
+		}
+		// HSETNX keeps answers the student saved since the hash was checked.
+		key := config.CacheKey.StudentAnswersKey(sess.ExamID.String(), sess.StudentID)
+		pipe := s.rdb.Pipeline()
+		for qid, answer := range answers {
+			pipe.HSetNX(ctx, key, qid, answer)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("restore answers: %w", err)
+		}
+		report.AnswerSets++
+		report.Answers += len(answers)
+	}
+	return nil
+}
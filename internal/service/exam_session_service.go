@@ -2,19 +2,28 @@ package service
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
 	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/logger"
 	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/queue"
 	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/stemsi/exstem-backend/internal/resilience"
 )
 
 // ExamSessionService handles exam session business logic.
@@ -22,7 +31,15 @@ type ExamSessionService struct {
 	sessionRepo *repository.ExamSessionRepository
 	examRepo    *repository.ExamRepository
 	targetRepo  *repository.ExamTargetRuleRepository
+	examService *ExamService
 	rdb         *redis.Client
+	queue       queue.Queue
+	health      *resilience.HealthMonitor
+	log         zerolog.Logger
+
+	// journal records writes made directly to PostgreSQL while Redis is degraded.
+	journalMu sync.Mutex
+	journal   map[sessionRef]*directWrites
 }
 
 // NewExamSessionService creates a new ExamSessionService.
@@ -30,13 +47,22 @@ func NewExamSessionService(
 	sessionRepo *repository.ExamSessionRepository,
 	examRepo *repository.ExamRepository,
 	targetRepo *repository.ExamTargetRuleRepository,
+	examService *ExamService,
 	rdb *redis.Client,
+	q queue.Queue,
+	health *resilience.HealthMonitor,
+	log zerolog.Logger,
 ) *ExamSessionService {
 	return &ExamSessionService{
 		sessionRepo: sessionRepo,
 		examRepo:    examRepo,
 		targetRepo:  targetRepo,
+		examService: examService,
 		rdb:         rdb,
+		queue:       q,
+		health:      health,
+		log:         log.With().Str("component", "exam_session_service").Logger(),
+		journal:     make(map[sessionRef]*directWrites),
 	}
 }
 
@@ -58,7 +84,10 @@ type LobbyExam struct {
 	ScheduledStart  *model.LocalTime     `json:"scheduled_start,omitempty"`
 	ScheduledEnd    *model.LocalTime     `json:"scheduled_end,omitempty"`
 	DurationMinutes int                  `json:"duration_minutes"`
+	Instructions    string               `json:"instructions"`
+	HonorCode       string               `json:"honor_code"`
 	Status          model.ExamStatus     `json:"status"`
+	AssessmentID    *uuid.UUID           `json:"assessment_id,omitempty"`
 	CreatedAt       time.Time            `json:"created_at"`
 	UpdatedAt       time.Time            `json:"updated_at"`
 	LobbyStatus     LobbyStatus          `json:"lobby_status"`
@@ -69,7 +98,7 @@ type LobbyExam struct {
 // GetLobby returns the list of exams available to a student based on their class.
 func (s *ExamSessionService) GetLobby(ctx context.Context, studentID, classID int) ([]LobbyExam, error) {
 	// Find all exam IDs targeting this student's class/grade/major.
-	examIDs, err := s.targetRepo.FindExamsForStudent(ctx, classID)
+	examIDs, err := s.targetRepo.FindExamsForStudent(ctx, studentID, classID)
 	if err != nil {
 		return nil, fmt.Errorf("find exams for student: %w", err)
 	}
@@ -95,47 +124,15 @@ func (s *ExamSessionService) GetLobby(ctx context.Context, studentID, classID in
 		}
 
 		// Only show PUBLISHED or IN_PROGRESS exams in the lobby.
-		if exam.Status != model.ExamStatusPublished && exam.Status != model.ExamStatusInProgress {
+		if !exam.Status.IsLive() {
 			continue
 		}
 
-		entry := LobbyExam{
-			ID:              exam.ID,
-			Title:           exam.Title,
-			ScheduledStart:  exam.ScheduledStart,
-			ScheduledEnd:    exam.ScheduledEnd,
-			DurationMinutes: exam.DurationMinutes,
-			Status:          exam.Status,
-			CreatedAt:       exam.CreatedAt,
-			UpdatedAt:       exam.UpdatedAt,
-		}
-
-		// Determine LobbyStatus
-		if sess, ok := sessionMap[eid]; ok {
-			entry.SessionStatus = &sess.Status
-			entry.FinalScore = sess.FinalScore
-			if sess.Status == model.SessionStatusCompleted {
-				entry.LobbyStatus = LobbyStatusCompleted
-			} else {
-				entry.LobbyStatus = LobbyStatusInProgress
-			}
-		} else {
-			// No session yet. Check schedule.
-			if exam.ScheduledEnd != nil && now.After(exam.ScheduledEnd.Time()) {
-				entry.LobbyStatus = LobbyStatusClosed // Time's up
-			} else if exam.ScheduledStart != nil && exam.ScheduledStart.Time().After(now) {
-				// Only show upcoming if it's scheduled for today
-				y1, m1, d1 := exam.ScheduledStart.Time().Date()
-				y2, m2, d2 := now.Date()
-				if y1 == y2 && m1 == m2 && d1 == d2 {
-					entry.LobbyStatus = LobbyStatusUpcoming
-				} else {
-					// Don't show exams for future days
-					continue
-				}
-			} else {
-				entry.LobbyStatus = LobbyStatusAvailable
-			}
+		entry := newLobbyExam(exam, sessionMap[eid], now)
+
+		// Only show upcoming exams if they're scheduled for today
+		if entry.LobbyStatus == LobbyStatusUpcoming && !sameDay(exam.ScheduledStart.Time(), now) {
+			continue
 		}
 
 		lobby = append(lobby, entry)
@@ -144,9 +141,134 @@ func (s *ExamSessionService) GetLobby(ctx context.Context, studentID, classID in
 	return lobby, nil
 }
 
+// newLobbyExam builds a lobby entry and derives its LobbyStatus from the
+// student's session (if any) and the exam schedule.
+func newLobbyExam(exam *model.Exam, sess *model.ExamSession, now time.Time) LobbyExam {
+	entry := LobbyExam{
+		ID:              exam.ID,
+		Title:           exam.Title,
+		ScheduledStart:  exam.ScheduledStart,
+		ScheduledEnd:    exam.ScheduledEnd,
+		DurationMinutes: exam.DurationMinutes,
+		Instructions:    exam.Instructions,
+		HonorCode:       exam.HonorCode,
+		Status:          exam.Status,
+		AssessmentID:    exam.AssessmentID,
+		CreatedAt:       exam.CreatedAt,
+		UpdatedAt:       exam.UpdatedAt,
+	}
+
+	if sess != nil {
+		entry.SessionStatus = &sess.Status
+		entry.FinalScore = sess.FinalScore
+		if sess.Status == model.SessionStatusCompleted {
+			entry.LobbyStatus = LobbyStatusCompleted
+		} else {
+			entry.LobbyStatus = LobbyStatusInProgress
+		}
+		return entry
+	}
+
+	// No session yet. Check schedule.
+	switch {
+	case exam.ScheduledEnd != nil && now.After(exam.ScheduledEnd.Time()):
+		entry.LobbyStatus = LobbyStatusClosed // Time's up
+	case exam.ScheduledStart != nil && exam.ScheduledStart.Time().After(now):
+		entry.LobbyStatus = LobbyStatusUpcoming
+	default:
+		entry.LobbyStatus = LobbyStatusAvailable
+	}
+	return entry
+}
+
+// sortLobbyBySchedule orders lobby entries by scheduled start, unscheduled ones last.
+func sortLobbyBySchedule(entries []LobbyExam) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].ScheduledStart, entries[j].ScheduledStart
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Time().Before(b.Time())
+	})
+}
+
+func sameDay(a, b time.Time) bool {
+	y1, m1, d1 := a.Date()
+	y2, m2, d2 := b.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+// ScheduleDay groups a student's scheduled exams by calendar date (YYYY-MM-DD).
+type ScheduleDay struct {
+	Date  string      `json:"date"`
+	Exams []LobbyExam `json:"exams"`
+}
+
+// GetSchedule returns the live exams targeted at a student that start within the
+// next `days` days (starting today), grouped by day. Unscheduled exams are omitted.
+func (s *ExamSessionService) GetSchedule(ctx context.Context, studentID, classID, days int) ([]ScheduleDay, error) {
+	examIDs, err := s.targetRepo.FindExamsForStudent(ctx, studentID, classID)
+	if err != nil {
+		return nil, fmt.Errorf("find exams for student: %w", err)
+	}
+
+	sessions, err := s.sessionRepo.ListByStudent(ctx, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	sessionMap := make(map[uuid.UUID]*model.ExamSession, len(sessions))
+	for i := range sessions {
+		sessionMap[sessions[i].ExamID] = &sessions[i]
+	}
+
+	now := time.Now()
+	y, m, d := now.Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 0, days)
+
+	var entries []LobbyExam
+	for _, eid := range examIDs {
+		exam, err := s.examRepo.GetByID(ctx, eid)
+		if err != nil {
+			continue // Skip if exam was deleted
+		}
+		if !exam.Status.IsLive() || exam.ScheduledStart == nil {
+			continue
+		}
+		start := exam.ScheduledStart.Time()
+		if start.Before(from) || !start.Before(to) {
+			continue
+		}
+		entries = append(entries, newLobbyExam(exam, sessionMap[eid], now))
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ScheduledStart.Time().Before(entries[j].ScheduledStart.Time())
+	})
+
+	schedule := []ScheduleDay{}
+	for _, e := range entries {
+		date := e.ScheduledStart.Time().Format("2006-01-02")
+		if n := len(schedule); n == 0 || schedule[n-1].Date != date {
+			schedule = append(schedule, ScheduleDay{Date: date})
+		}
+		last := &schedule[len(schedule)-1]
+		last.Exams = append(last.Exams, e)
+	}
+	return schedule, nil
+}
+
 // GetActiveExam returns the exam ID of the student's currently active session.
 // It checks Redis first, falls back to PostgreSQL, and self-heals the cache.
 func (s *ExamSessionService) GetActiveExam(ctx context.Context, studentID int) (*uuid.UUID, error) {
+	if s.health.Degraded() {
+		examID, err := s.sessionRepo.GetActiveExamID(ctx, studentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return examID, err
+	}
+
 	key := config.CacheKey.StudentActiveExamKey(studentID)
 	val, err := s.rdb.Get(ctx, key).Result()
 
@@ -174,34 +296,46 @@ func (s *ExamSessionService) GetActiveExam(ctx context.Context, studentID int) (
 	return &parsed, nil
 }
 
+// Join errors.
+var (
+	// ErrExamNotAvailable is returned when the exam is not open for joining or not
+	// targeted at the student's class.
+	ErrExamNotAvailable  = errors.New("exam is not available for joining")
+	ErrInvalidEntryToken = errors.New("invalid entry token")
+	// ErrConsentRequired is returned when a student joins an exam with an honor code
+	// without acknowledging it.
+	ErrConsentRequired = errors.New("honor code must be acknowledged")
+)
+
 // JoinExam validates the entry token and creates a session for the student.
 // classID is required to verify the student's class is eligible for this exam.
-func (s *ExamSessionService) JoinExam(ctx context.Context, examID uuid.UUID, studentID, classID int, entryToken string) (*model.ExamSession, error) {
+// consent records the student's acknowledgment of the exam's honor code, if any.
+func (s *ExamSessionService) JoinExam(ctx context.Context, examID uuid.UUID, studentID, classID int, entryToken string, consent bool) (*model.ExamSession, error) {
 	exam, err := s.examRepo.GetByID(ctx, examID)
 	if err != nil {
 		return nil, fmt.Errorf("get exam: %w", err)
 	}
 
 	if exam.Status != model.ExamStatusPublished && exam.Status != model.ExamStatusInProgress {
-		return nil, errors.New("exam is not available for joining")
+		return nil, ErrExamNotAvailable
 	}
 
 	now := time.Now()
 	if exam.ScheduledStart != nil && now.Before(exam.ScheduledStart.Time()) {
-		return nil, errors.New("exam is not available for joining")
+		return nil, ErrExamNotAvailable
 	}
 	if exam.ScheduledEnd != nil && now.After(exam.ScheduledEnd.Time()) {
-		return nil, errors.New("exam is not available for joining")
+		return nil, ErrExamNotAvailable
 	}
 
 	if exam.EntryToken != entryToken {
-		return nil, errors.New("invalid entry token")
+		return nil, ErrInvalidEntryToken
 	}
 
 	// SECURITY: Verify the student's class is an eligible target for this exam.
 	// This prevents a student from joining an exam that was not targeted at
 	// their class/grade/major, even if they somehow obtained the entry token.
-	allowedExamIDs, err := s.targetRepo.FindExamsForStudent(ctx, classID)
+	allowedExamIDs, err := s.targetRepo.FindExamsForStudent(ctx, studentID, classID)
 	if err != nil {
 		return nil, fmt.Errorf("check eligibility: %w", err)
 	}
@@ -213,7 +347,7 @@ func (s *ExamSessionService) JoinExam(ctx context.Context, examID uuid.UUID, stu
 		}
 	}
 	if !eligible {
-		return nil, errors.New("exam is not available for joining")
+		return nil, ErrExamNotAvailable
 	}
 
 	// Check if student already has a session.
@@ -222,29 +356,29 @@ func (s *ExamSessionService) JoinExam(ctx context.Context, examID uuid.UUID, stu
 		return nil, fmt.Errorf("check existing session: %w", err)
 	}
 
-	// IDEMPOTENCY CHECK: If they already joined, ensure Redis has the start time
-	// This handles cases where they joined on a different device or refreshed immediately.
+	// IDEMPOTENCY CHECK: If they already joined, make sure Redis holds their session.
+	// This handles joins from a different device, refreshes, and joins whose
+	// initialization failed halfway.
 	if existing != nil {
-		_ = s.rdb.Set(ctx, config.CacheKey.StudentExamSessionStartKey(examID.String(), studentID), existing.StartedAt.Unix(), 0)
-		// Ensure active_exam key is set (idempotent)
-		_ = s.rdb.Set(ctx, config.CacheKey.StudentActiveExamKey(studentID), examID.String(), 0)
-
-		// Ensure Shuffled Questions are in Redis
-		key := config.CacheKey.StudentShuffledQuestionKey(examID.String(), studentID)
-		if s.rdb.Exists(ctx, key).Val() == 0 && len(existing.QuestionOrder) > 0 {
-			orderJSON, _ := json.Marshal(existing.QuestionOrder)
-			s.rdb.Set(ctx, key, orderJSON, 0)
+		if err := s.initSessionCache(ctx, exam, existing); err != nil {
+			return nil, err
 		}
-
 		return existing, nil
 	}
 
+	if exam.HonorCode != "" && !consent {
+		return nil, ErrConsentRequired
+	}
+
 	session := &model.ExamSession{
 		ExamID:    examID,
 		StudentID: studentID,
 		// StartedAt will be set by the DB default NOW(), but we need it for Redis
 		StartedAt: time.Now(),
 	}
+	if exam.HonorCode != "" {
+		session.ConsentAcknowledgedAt = &session.StartedAt
+	}
 
 	// Try to create the session.
 	if err := s.sessionRepo.Create(ctx, session); err != nil {
@@ -259,20 +393,8 @@ func (s *ExamSessionService) JoinExam(ctx context.Context, examID uuid.UUID, stu
 		return nil, fmt.Errorf("create session: %w", err)
 	}
 
-	// REDIS OPTIMIZATION: Store the Unix timestamp
-	// Use session.StartedAt.Unix() to ensure DB and Redis are perfectly synced
-	startKey := config.CacheKey.StudentExamSessionStartKey(session.ExamID.String(), session.StudentID)
-	if err := s.rdb.Set(ctx, startKey, session.StartedAt.Unix(), 0).Err(); err != nil {
-		// Log this error but don't fail the request. The Fallback in GetExamState will handle it.
-		fmt.Printf("Warning: Failed to cache start time: %v\n", err)
-	}
-
-	// REDIS: Mark the active exam for this student
-	_ = s.rdb.Set(ctx, config.CacheKey.StudentActiveExamKey(studentID), examID.String(), 0)
-
-	// Initialize Shuffled Questions
-	if err := s.initShuffledQuestions(ctx, exam, studentID); err != nil {
-		fmt.Printf("Warning: Failed to init shuffled questions: %v\n", err)
+	if err := s.initSessionCache(ctx, exam, session); err != nil {
+		return nil, err
 	}
 
 	return session, nil
@@ -281,9 +403,13 @@ func (s *ExamSessionService) JoinExam(ctx context.Context, examID uuid.UUID, stu
 // VerifyActiveSession checks that a student has an active (IN_PROGRESS) session
 // for the given exam. Uses Redis first, falls back to PostgreSQL.
 func (s *ExamSessionService) VerifyActiveSession(ctx context.Context, examID uuid.UUID, studentID int) error {
-	// Fast path: check Redis active_exam key
+	// Fast path: check Redis active_exam key (skipped while Redis is degraded)
 	key := config.CacheKey.StudentActiveExamKey(studentID)
-	val, err := s.rdb.Get(ctx, key).Result()
+	var val string
+	var err error = redis.Nil
+	if !s.health.Degraded() {
+		val, err = s.rdb.Get(ctx, key).Result()
+	}
 	if err == nil {
 		// Cache hit — verify it matches the requested exam
 		if val == examID.String() {
@@ -303,63 +429,101 @@ func (s *ExamSessionService) VerifyActiveSession(ctx context.Context, examID uui
 	}
 
 	// Self-heal: write back to Redis
-	_ = s.rdb.Set(ctx, key, examID.String(), 0)
+	if !s.health.Degraded() {
+		_ = s.rdb.Set(ctx, key, examID.String(), 0)
+	}
 	return nil
 }
 
-// initShuffledQuestions generates, caches, and queues the persistence of shuffled questions
-func (s *ExamSessionService) initShuffledQuestions(ctx context.Context, exam *model.Exam, studentID int) error {
-	payloadKey := config.CacheKey.ExamPayloadKey(exam.ID.String())
-	data, err := s.rdb.Get(ctx, payloadKey).Bytes()
-	if err != nil {
-		return fmt.Errorf("failed to get exam payload from redis: %w", err)
-	}
-
-	var payload model.ExamPayload
-	if err := json.Unmarshal(data, &payload); err != nil {
-		return fmt.Errorf("failed to parse exam payload: %w", err)
+// initSessionScript writes the Redis state of a joined session in one step: its start
+// time (KEYS[1]), the student's active exam (KEYS[2]) and, unless one is already
+// stored, its question order (KEYS[3]). ARGV holds the start time, the exam ID and the
+// order, which may be empty. It returns how many of the three keys exist afterwards
+// and the stored order.
+var initSessionScript = redis.NewScript(`
+redis.call("SET", KEYS[1], ARGV[1])
+redis.call("SET", KEYS[2], ARGV[2])
+if ARGV[3] ~= "" then
+	redis.call("SET", KEYS[3], ARGV[3], "NX")
+end
+return {redis.call("EXISTS", KEYS[1], KEYS[2], KEYS[3]), redis.call("GET", KEYS[3])}
+`)
+
+// initSessionCache initializes the Redis state of a joined session atomically, so a
+// session is never left with a start time but no question order. The order comes from
+// PostgreSQL or, for a new session, is drawn from the exam payload; an order drawn
+// here is queued for persistence. It is idempotent: a join that fails here succeeds
+// on retry. While Redis is degraded the session is served from PostgreSQL and
+// nothing is written.
+func (s *ExamSessionService) initSessionCache(ctx context.Context, exam *model.Exam, session *model.ExamSession) error {
+	if s.health.Degraded() {
+		return nil
+	}
+
+	examID := exam.ID.String()
+	shuffledKey := config.CacheKey.StudentShuffledQuestionKey(examID, session.StudentID)
+
+	order := session.QuestionOrder
+	if len(order) == 0 && s.rdb.Exists(ctx, shuffledKey).Val() == 0 {
+		payload, err := s.examService.GetExamPayload(ctx, exam.ID)
+		if err != nil {
+			return fmt.Errorf("get exam payload: %w", err)
+		}
+		order = selectQuestionOrder(exam, payload.Questions, rand.New(rand.NewSource(time.Now().UnixNano())))
 	}
-
-	var qIDs []string
-	for _, q := range payload.Questions {
-		qIDs = append(qIDs, q.ID.String())
+	var orderJSON []byte
+	if len(order) > 0 {
+		var err error
+		if orderJSON, err = json.Marshal(order); err != nil {
+			return err
+		}
 	}
 
-	if exam.RandomizeQuestions {
-		r := rand.New(rand.NewSource(time.Now().UnixNano()))
-		r.Shuffle(len(qIDs), func(i, j int) {
-			qIDs[i], qIDs[j] = qIDs[j], qIDs[i]
-		})
+	res, err := initSessionScript.Run(ctx, s.rdb, []string{
+		config.CacheKey.StudentExamSessionStartKey(examID, session.StudentID),
+		config.CacheKey.StudentActiveExamKey(session.StudentID),
+		shuffledKey,
+	}, session.StartedAt.Unix(), examID, orderJSON).Slice()
+	if err != nil {
+		return fmt.Errorf("init session cache: %w", err)
 	}
-
-	if exam.QuestionCount > 0 && exam.QuestionCount < len(qIDs) {
-		qIDs = qIDs[:exam.QuestionCount]
+	if len(res) != 2 {
+		return fmt.Errorf("init session cache: unexpected reply %v", res)
 	}
-
-	shuffledKey := config.CacheKey.StudentShuffledQuestionKey(exam.ID.String(), studentID)
-	orderJSON, err := json.Marshal(qIDs)
-	if err != nil {
-		return err
+	if n, _ := res[0].(int64); n != 3 {
+		return fmt.Errorf("init session cache: only %d of 3 keys written", n)
 	}
 
-	pipe := s.rdb.Pipeline()
-	pipe.Set(ctx, shuffledKey, orderJSON, 0)
-
-	// Queue for async persistence
-	workerPayload := map[string]interface{}{
-		"exam_id":    exam.ID.String(),
-		"student_id": studentID,
-		"order":      qIDs,
+	// The stored order may be one a failed earlier join drew but never persisted.
+	if len(session.QuestionOrder) == 0 {
+		stored, _ := res[1].(string)
+		var storedOrder []string
+		if err := json.Unmarshal([]byte(stored), &storedOrder); err != nil {
+			return fmt.Errorf("parse stored question order: %w", err)
+		}
+		workerPayload, _ := json.Marshal(map[string]interface{}{
+			"exam_id":    examID,
+			"student_id": session.StudentID,
+			"order":      storedOrder,
+			"request_id": logger.RequestID(ctx),
+		})
+		if err := s.queue.Push(ctx, config.WorkerKey.PersistQuestionOrderQueue, workerPayload); err != nil {
+			return fmt.Errorf("queue question order: %w", err)
+		}
 	}
-	workerPayloadJSON, _ := json.Marshal(workerPayload)
-	pipe.RPush(ctx, config.WorkerKey.PersistQuestionOrderQueue, workerPayloadJSON)
-
-	_, err = pipe.Exec(ctx)
-	return err
+	return nil
 }
 
 // GetShuffledQuestionIDs retrieves the ordered question IDs for a student's exam session
 func (s *ExamSessionService) GetShuffledQuestionIDs(ctx context.Context, examID uuid.UUID, studentID int) ([]string, error) {
+	if s.health.Degraded() {
+		sess, err := s.sessionRepo.GetByExamAndStudent(ctx, examID, studentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch session from DB: %w", err)
+		}
+		return sess.QuestionOrder, nil
+	}
+
 	key := config.CacheKey.StudentShuffledQuestionKey(examID.String(), studentID)
 	val, err := s.rdb.Get(ctx, key).Bytes()
 
@@ -388,6 +552,10 @@ func (s *ExamSessionService) GetShuffledQuestionIDs(ctx context.Context, examID
 
 // GetExamState retrieves the current state of the exam for the student.
 func (s *ExamSessionService) GetExamState(ctx context.Context, examID uuid.UUID, studentID int) (*model.ExamSessionState, error) {
+	if s.health.Degraded() {
+		return s.examStateFromDB(ctx, examID, studentID)
+	}
+
 	// 1. Get all related student answered question-answer from redis
 	sessionKey := config.CacheKey.StudentAnswersKey(examID.String(), studentID)
 	questionAnswers, err := s.rdb.HGetAll(ctx, sessionKey).Result()
@@ -437,11 +605,31 @@ func (s *ExamSessionService) GetExamState(ctx context.Context, examID uuid.UUID,
 		}
 	}
 
-	// 4. Calculate Remaining Time
+	// 4. Calculate Remaining Time, extended by the student's breaks and prolonged disconnects
 	// Convert Unix Timestamp (int64) back to Time object
 	startTime := time.Unix(startTimeUnix, 0)
 
-	endTime := startTime.Add(time.Duration(durationMinutes) * time.Minute)
+	var breaks *model.BreakStatus
+	breakPolicy, err := s.GetBreakPolicy(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	if breakPolicy.Enabled() {
+		if breaks, err = s.GetBreakStatus(ctx, examID, studentID, breakPolicy); err != nil {
+			return nil, err
+		}
+	}
+
+	// A failure to record the pause in PostgreSQL leaves it in effect through Redis.
+	if _, err := s.SettleDisconnect(ctx, examID, studentID); err != nil && !errors.Is(err, ErrDisconnectPauseNotRecorded) {
+		return nil, err
+	}
+	disconnectPaused, err := s.DisconnectPaused(ctx, examID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	endTime := timerEnd(startTime, durationMinutes, breaks, time.Now()).Add(time.Duration(disconnectPaused) * time.Second)
 	remaining := time.Until(endTime)
 
 	if remaining < 0 {
@@ -464,17 +652,268 @@ func (s *ExamSessionService) GetExamState(ctx context.Context, examID uuid.UUID,
 		isRandom = true
 	}
 
+	// 7. Get Media Play Counters
+	playsRaw, err := s.rdb.HGetAll(ctx, config.CacheKey.StudentMediaPlaysKey(examID.String(), studentID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get media plays: %w", err)
+	}
+	mediaPlays := make(map[string]int, len(playsRaw))
+	for qid, v := range playsRaw {
+		if n, err := strconv.Atoi(v); err == nil {
+			mediaPlays[qid] = n
+		}
+	}
+
+	// 8. Get Navigation Policy and Position
+	nav, current, unlockedFrom, err := s.navigationState(ctx, examID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &model.ExamSessionState{
 		ExamID:           examID,
 		StudentID:        studentID,
 		IsRandomOrder:    isRandom,
 		CheatRules:       cheatRules,
 		AutosavedAnswers: questionAnswers,
+		MediaPlays:       mediaPlays,
 		RemainingTime:    remaining.Seconds(),
+		NavigationPolicy: nav.Policy,
+		SectionSize:      nav.SectionSize,
+		CurrentPosition:  current,
+		UnlockedFrom:     unlockedFrom,
+		Breaks:           breaks,
+
+		DisconnectPausedSeconds: disconnectPaused,
 	}, nil
 }
 
 // GetExamResults retrieves paginated exam results with optional filters.
-func (s *ExamSessionService) GetExamResults(ctx context.Context, examID uuid.UUID, page, perPage int, classID *int, gradeLevel *string, majorCode *string, groupNumber *int, religion *string) ([]repository.ExamResult, int64, error) {
+func (s *ExamSessionService) GetExamResults(ctx context.Context, examID uuid.UUID, page, perPage int, classID *int, gradeLevel *string, majorCode *string, groupNumber *int, religion *model.Religion) ([]repository.ExamResult, int64, error) {
 	return s.sessionRepo.ListByExam(ctx, examID, page, perPage, classID, gradeLevel, majorCode, groupNumber, religion)
 }
+
+// GetExamStats retrieves an exam's session aggregates as maintained by the stats worker.
+func (s *ExamSessionService) GetExamStats(ctx context.Context, examID uuid.UUID) (*model.ExamStats, error) {
+	return s.sessionRepo.GetStats(ctx, examID)
+}
+
+// ErrResultNotAvailable is returned when a student asks for a result before the exam window closes.
+var ErrResultNotAvailable = errors.New("exam result is not available yet")
+
+// resultAvailable reports whether a student may see their result: the session must be
+// completed and the exam either finished or past its scheduled end. Exams without a
+// schedule release results as soon as the session is completed.
+func resultAvailable(sessionStatus model.SessionStatus, examStatus model.ExamStatus, scheduledEnd *model.LocalTime) bool {
+	if sessionStatus != model.SessionStatusCompleted {
+		return false
+	}
+	switch {
+	case examStatus == model.ExamStatusCompleted || examStatus == model.ExamStatusArchived:
+		return true
+	case scheduledEnd == nil:
+		return true
+	default:
+		return scheduledEnd.Time().Before(time.Now())
+	}
+}
+
+// GetStudentResult returns a student's own result for an exam once the exam window has closed.
+func (s *ExamSessionService) GetStudentResult(ctx context.Context, examID uuid.UUID, studentID int) (*model.StudentExamResult, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.sessionRepo.GetByExamAndStudent(ctx, examID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !resultAvailable(sess.Status, exam.Status, exam.ScheduledEnd) {
+		return nil, ErrResultNotAvailable
+	}
+
+	correct, answered, err := s.sessionRepo.GetAnswerStats(ctx, examID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("get answer stats: %w", err)
+	}
+
+	total := len(sess.QuestionOrder)
+	if total < answered {
+		total = answered
+	}
+
+	result := &model.StudentExamResult{
+		ExamID:          exam.ID,
+		Title:           exam.Title,
+		FinalScore:      sess.FinalScore,
+		TotalQuestions:  total,
+		CorrectCount:    correct,
+		IncorrectCount:  answered - correct,
+		UnansweredCount: total - answered,
+		StartedAt:       sess.StartedAt,
+		FinishedAt:      sess.FinishedAt,
+	}
+	if sess.FinishedAt != nil {
+		result.TimeTakenSeconds = int(sess.FinishedAt.Sub(sess.StartedAt).Seconds())
+	}
+
+	if exam.ShowClassAverage {
+		avg, err := s.sessionRepo.GetClassAverage(ctx, examID, studentID)
+		if err != nil {
+			return nil, fmt.Errorf("get class average: %w", err)
+		}
+		result.ClassAverage = avg
+	}
+
+	return result, nil
+}
+
+// GetStudentReview returns a student's review of an exam: each question of their
+// session with their answer, the correct answer and its explanation, in lang when the
+// exam offers it. It is available once the result is, and only for exams that allow
+// review.
+func (s *ExamSessionService) GetStudentReview(ctx context.Context, examID uuid.UUID, studentID int, lang string) (*model.StudentExamReview, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.sessionRepo.GetByExamAndStudent(ctx, examID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exam.AllowReview || !resultAvailable(sess.Status, exam.Status, exam.ScheduledEnd) {
+		return nil, ErrResultNotAvailable
+	}
+
+	questions, err := s.examService.reviewQuestions(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := s.sessionRepo.ListAnswers(ctx, examID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("list answers: %w", err)
+	}
+
+	byID := make(map[string]*model.Question, len(questions))
+	for i := range questions {
+		byID[questions[i].ID.String()] = &questions[i]
+	}
+	order := sess.QuestionOrder
+	if len(order) == 0 {
+		order = make([]string, len(questions))
+		for i, q := range questions {
+			order[i] = q.ID.String()
+		}
+	}
+
+	lang = normalizeLanguage(lang)
+	if exam.ForcedLanguage != "" {
+		lang = exam.ForcedLanguage
+	} else if !slices.Contains(exam.Languages, lang) {
+		lang = exam.Language
+	}
+
+	review := &model.StudentExamReview{
+		ExamID:    exam.ID,
+		Title:     exam.Title,
+		Language:  lang,
+		Questions: make([]model.ReviewQuestion, 0, len(order)),
+	}
+	for _, id := range order {
+		q, ok := byID[id]
+		if !ok {
+			// Deleted from the bank after the exam.
+			continue
+		}
+		student := toStudentQuestions([]model.Question{*q})[0]
+		student.OrderNum = len(review.Questions) + 1
+		student.Translations = nil
+		explanation := q.Explanation
+		if t, ok := q.Translations[lang]; ok && lang != exam.Language {
+			applyTranslation(t, &student.QuestionText, &student.Options, &student.MathLatex)
+			if strings.TrimSpace(t.Explanation) != "" {
+				explanation = t.Explanation
+			}
+		}
+		answer, answered := answers[id]
+		review.Questions = append(review.Questions, model.ReviewQuestion{
+			QuestionForStudent: student,
+			Answer:             answer,
+			CorrectOption:      q.CorrectOption,
+			IsCorrect:          answered && AnswerMatches(answer, q.CorrectOption),
+			Explanation:        explanation,
+		})
+	}
+	return review, nil
+}
+
+// ErrPublicResultNotFound is returned by the public lookup for any mismatch of exam,
+// access code or NISN, so callers cannot tell which one was wrong.
+var ErrPublicResultNotFound = errors.New("public result not found")
+
+// LookupPublicResult returns the score and grade of the student with the given NISN,
+// provided the exam publishes its results publicly and the access code matches.
+func (s *ExamSessionService) LookupPublicResult(ctx context.Context, examID uuid.UUID, nisn, accessCode string) (*model.PublicExamResult, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPublicResultNotFound
+		}
+		return nil, err
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(accessCode))
+	if !exam.PublicResults || exam.ResultsAccessCode == "" ||
+		subtle.ConstantTimeCompare([]byte(code), []byte(strings.ToUpper(exam.ResultsAccessCode))) != 1 {
+		return nil, ErrPublicResultNotFound
+	}
+
+	sess, err := s.sessionRepo.GetByExamAndNISN(ctx, examID, strings.TrimSpace(nisn))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPublicResultNotFound
+		}
+		return nil, err
+	}
+
+	if !resultAvailable(sess.Status, exam.Status, exam.ScheduledEnd) || sess.FinalScore == nil {
+		return nil, ErrResultNotAvailable
+	}
+
+	return &model.PublicExamResult{
+		ExamID:     exam.ID,
+		Title:      exam.Title,
+		FinalScore: sess.FinalScore,
+		Grade:      model.ScoreGrade(*sess.FinalScore),
+	}, nil
+}
+
+// GetStudentHistory lists a student's exam sessions. Scores are only included
+// once the corresponding result is available.
+func (s *ExamSessionService) GetStudentHistory(ctx context.Context, studentID int) ([]model.StudentExamHistoryItem, error) {
+	rows, err := s.sessionRepo.ListHistoryByStudent(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]model.StudentExamHistoryItem, len(rows))
+	for i, r := range rows {
+		available := resultAvailable(r.Status, r.ExamStatus, r.ScheduledEnd)
+		history[i] = model.StudentExamHistoryItem{
+			ExamID:          r.ExamID,
+			Title:           r.Title,
+			Status:          r.Status,
+			StartedAt:       r.StartedAt,
+			FinishedAt:      r.FinishedAt,
+			ResultAvailable: available,
+		}
+		if available {
+			history[i].FinalScore = r.FinalScore
+		}
+	}
+	return history, nil
+}
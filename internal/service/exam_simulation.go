@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// simulationBucketWidth is the width of a score bucket in a simulation's distribution.
+const simulationBucketWidth = 10
+
+// Simulate dry-runs an exam: students virtual students each get their questions
+// through the same selection as a real session, answer at random and are graded
+// like a submit. Nothing is stored. Alongside the score distribution it reports the
+// failed preflight checks and the problems only a run reveals.
+func (s *ExamService) Simulate(ctx context.Context, examID uuid.UUID, students int, seed int64) (*model.ExamSimulation, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := s.questionRepo.ListByExam(ctx, exam.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list questions: %w", err)
+	}
+	if len(questions) == 0 {
+		return nil, ErrNoQuestions
+	}
+
+	validation, err := s.Validate(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+
+	studentQuestions := toStudentQuestions(questions)
+	byID := make(map[string]model.QuestionForStudent, len(studentQuestions))
+	answerKey := make(map[string]string, len(questions))
+	for i, q := range studentQuestions {
+		byID[q.ID.String()] = q
+		answerKey[q.ID.String()] = questions[i].CorrectOption
+	}
+
+	sim := &model.ExamSimulation{
+		ExamID:             exam.ID,
+		Seed:               seed,
+		Students:           students,
+		QuestionsAvailable: len(questions),
+		Distribution:       make([]model.ScoreBucket, 100/simulationBucketWidth),
+		Checks:             []model.ExamValidationCheck{},
+	}
+	for i := range sim.Distribution {
+		sim.Distribution[i] = model.ScoreBucket{From: i * simulationBucketWidth, To: (i + 1) * simulationBucketWidth}
+	}
+
+	// Each student gets their own source, so a run is reproducible from its seed.
+	seen := make(map[string]bool, len(questions))
+	scores := make([]float64, students)
+	for i := range scores {
+		r := rand.New(rand.NewSource(seed + int64(i)))
+		ordered := selectQuestionOrder(exam, studentQuestions, r)
+		sim.QuestionsPerStudent = len(ordered)
+
+		correct := 0
+		for _, id := range ordered {
+			seen[id] = true
+			if AnswerMatches(simulatedAnswer(byID[id], r), answerKey[id]) {
+				correct++
+			}
+		}
+		if len(ordered) > 0 {
+			scores[i] = float64(correct) / float64(len(ordered)) * 100
+		}
+
+		bucket := int(scores[i]) / simulationBucketWidth
+		if bucket >= len(sim.Distribution) {
+			bucket = len(sim.Distribution) - 1
+		}
+		sim.Distribution[bucket].Count++
+	}
+	sim.QuestionsNeverSeen = len(questions) - len(seen)
+
+	sort.Float64s(scores)
+	var sum float64
+	for _, sc := range scores {
+		sum += sc
+	}
+	sim.AverageScore = sum / float64(students)
+	sim.MinScore = scores[0]
+	sim.MaxScore = scores[len(scores)-1]
+	if n := len(scores); n%2 == 1 {
+		sim.MedianScore = scores[n/2]
+	} else {
+		sim.MedianScore = (scores[n/2-1] + scores[n/2]) / 2
+	}
+
+	// The draft-status check does not apply to a dry run.
+	for _, c := range validation.Checks {
+		if !c.Passed && c.Key != "status" {
+			sim.Checks = append(sim.Checks, c)
+		}
+	}
+	essays := 0
+	for _, q := range questions {
+		if q.QuestionType == model.QuestionTypeEssay {
+			essays++
+		}
+	}
+	if essays > 0 {
+		sim.Checks = append(sim.Checks, model.ExamValidationCheck{
+			Key: "essay_questions", Severity: model.ValidationWarning,
+			Message: fmt.Sprintf("%d essay questions are not graded automatically and count as wrong in the submitted score", essays),
+		})
+	}
+
+	return sim, nil
+}
+
+// simulatedAnswer picks a random answer a student could give to q: one of its
+// options, true or false, and nothing for questions that are not graded automatically.
+func simulatedAnswer(q model.QuestionForStudent, r *rand.Rand) string {
+	switch q.QuestionType {
+	case model.QuestionTypeTrueFalse:
+		if r.Intn(2) == 0 {
+			return "true"
+		}
+		return "false"
+	case model.QuestionTypeMultipleChoice:
+		var keyed []model.QuestionOption
+		if err := json.Unmarshal(q.Options, &keyed); err == nil && len(keyed) > 0 && keyed[0].Key != "" {
+			return keyed[r.Intn(len(keyed))].Key
+		}
+		var plain []string
+		if err := json.Unmarshal(q.Options, &plain); err == nil && len(plain) > 0 {
+			return string(rune('A' + r.Intn(min(len(plain), 26))))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/logger"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// DefaultStaleGraceMinutes is how long past its deadline an unsubmitted session is
+// reported stale unless asked otherwise, leaving room for a late submit in flight.
+const DefaultStaleGraceMinutes = 15
+
+// StaleSessions lists an exam's IN_PROGRESS sessions whose deadline passed more than
+// grace ago: students whose time ran out without their exam being submitted, for
+// instance because their device died before the final submit.
+func (s *ExamSessionService) StaleSessions(ctx context.Context, examID uuid.UUID, grace time.Duration) ([]model.StaleSession, error) {
+	if _, err := s.examRepo.GetByID(ctx, examID); err != nil {
+		return nil, err
+	}
+	sessions, err := s.sessionRepo.ListStale(ctx, examID, grace)
+	if err != nil {
+		return nil, fmt.Errorf("list stale sessions: %w", err)
+	}
+	if sessions == nil {
+		sessions = []model.StaleSession{}
+	}
+	return sessions, nil
+}
+
+// SubmitStaleSessions submits an exam's stale sessions, or those of studentIDs among
+// them, as the student's own submit would: their saved answers are graded against
+// their question subset and the score goes through the scoring queue. Sessions that
+// fail are reported and left open.
+func (s *ExamSessionService) SubmitStaleSessions(ctx context.Context, examID uuid.UUID, grace time.Duration, studentIDs []int) (*model.StaleSubmitResult, error) {
+	if err := s.examService.checkExamIDScope(ctx, examID, model.PermissionExamsWrite); err != nil {
+		return nil, err
+	}
+	sessions, err := s.StaleSessions(ctx, examID, grace)
+	if err != nil {
+		return nil, err
+	}
+	if len(studentIDs) > 0 {
+		wanted := make(map[int]bool, len(studentIDs))
+		for _, id := range studentIDs {
+			wanted[id] = true
+		}
+		kept := sessions[:0]
+		for _, ss := range sessions {
+			if wanted[ss.StudentID] {
+				kept = append(kept, ss)
+			}
+		}
+		sessions = kept
+	}
+
+	result := &model.StaleSubmitResult{Items: make([]model.StaleSubmitItem, 0, len(sessions))}
+	if len(sessions) == 0 {
+		return result, nil
+	}
+	answerKey, err := s.examService.GetAnswerKeyDirect(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("get answer key: %w", err)
+	}
+
+	for _, ss := range sessions {
+		item := model.StaleSubmitItem{StudentID: ss.StudentID, Name: ss.Name}
+		score, err := s.submitStale(ctx, examID, ss, answerKey)
+		if err != nil {
+			item.Error = err.Error()
+			result.Failed++
+		} else {
+			item.Score = &score
+			result.Submitted++
+			_ = s.PublishMonitorEvent(ctx, examID, map[string]interface{}{
+				"type":         "submit",
+				"student_id":   ss.StudentID,
+				"student_name": ss.Name,
+				"score":        score,
+				"message":      fmt.Sprintf("%s was submitted by an admin after their time ran out", ss.Name),
+			})
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+// submitStale grades and submits one stale session, under the same submit lock as a
+// student's own submit. Answers still in Redis take precedence over those the
+// autosave worker persisted.
+func (s *ExamSessionService) submitStale(ctx context.Context, examID uuid.UUID, ss model.StaleSession, answerKey map[string]string) (float64, error) {
+	degraded := s.RedisDegraded()
+	if !degraded {
+		release, err := s.LockSubmit(ctx, examID, ss.StudentID)
+		if err != nil {
+			return 0, err
+		}
+		defer release()
+	}
+	if score, done, err := s.CompletedScore(ctx, examID, ss.StudentID); err != nil {
+		return 0, err
+	} else if done {
+		return score, nil
+	}
+
+	answers, err := s.sessionRepo.ListAnswers(ctx, examID, ss.StudentID)
+	if err != nil {
+		return 0, fmt.Errorf("list answers: %w", err)
+	}
+	if !degraded {
+		cached, err := s.rdb.HGetAll(ctx, config.CacheKey.StudentAnswersKey(examID.String(), ss.StudentID)).Result()
+		if err != nil {
+			return 0, fmt.Errorf("get cached answers: %w", err)
+		}
+		for qid, answer := range cached {
+			answers[qid] = answer
+		}
+	}
+
+	order := ss.QuestionOrder
+	if len(order) == 0 {
+		for qid := range answerKey {
+			order = append(order, qid)
+		}
+	}
+	score := GradeAnswers(answerKey, answers, order)
+
+	if degraded {
+		return s.CompleteDirect(ctx, examID, ss.StudentID, score)
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"student_id": ss.StudentID,
+		"exam_id":    examID.String(),
+		"score":      score,
+		"request_id": logger.RequestID(ctx),
+	})
+	return score, s.QueueScore(ctx, examID, ss.StudentID, payload, score)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// A submit is graded at most once per session: a short-lived lock serializes
+// concurrent submits, and the computed score is kept in a marker key so that a
+// double click or a retried frame gets the same score back instead of queueing another.
+const (
+	submitLockTTL = 30 * time.Second
+	submittedTTL  = 24 * time.Hour
+)
+
+// ErrSubmitInProgress is returned when another submit for the same session holds the lock.
+var ErrSubmitInProgress = errors.New("submit already in progress")
+
+// LockSubmit takes the per-session submit lock. The returned function releases it.
+func (s *ExamSessionService) LockSubmit(ctx context.Context, examID uuid.UUID, studentID int) (func(), error) {
+	key := config.CacheKey.StudentSubmitLockKey(examID.String(), studentID)
+	release, err := acquireLock(ctx, s.rdb, key, submitLockTTL, 0)
+	if errors.Is(err, errLockHeld) {
+		return nil, ErrSubmitInProgress
+	}
+	if err != nil {
+		return nil, fmt.Errorf("acquire submit lock: %w", err)
+	}
+	return release, nil
+}
+
+// CompletedScore returns the score of a session that was already submitted.
+// The Redis marker is checked first; PostgreSQL covers markers lost to expiry or outages.
+func (s *ExamSessionService) CompletedScore(ctx context.Context, examID uuid.UUID, studentID int) (float64, bool, error) {
+	if !s.health.Degraded() {
+		raw, err := s.rdb.Get(ctx, config.CacheKey.StudentSubmittedKey(examID.String(), studentID)).Result()
+		switch {
+		case err == nil:
+			score, err := strconv.ParseFloat(raw, 64)
+			if err == nil {
+				return score, true, nil
+			}
+		case !errors.Is(err, redis.Nil):
+			return 0, false, fmt.Errorf("get submitted marker: %w", err)
+		}
+	}
+	return s.completedScoreFromDB(ctx, examID, studentID)
+}
+
+// QueueScore records the submitted marker and queues the score for persistence
+// in one transaction, so a retried submit can never queue a second score.
+func (s *ExamSessionService) QueueScore(ctx context.Context, examID uuid.UUID, studentID int, payload []byte, score float64) error {
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, config.CacheKey.StudentSubmittedKey(examID.String(), studentID),
+			strconv.FormatFloat(score, 'f', -1, 64), submittedTTL)
+		s.queue.PushPipe(ctx, pipe, config.WorkerKey.PersistScoresQueue, payload)
+		return nil
+	})
+	return err
+}
+
+func (s *ExamSessionService) completedScoreFromDB(ctx context.Context, examID uuid.UUID, studentID int) (float64, bool, error) {
+	sess, err := s.sessionRepo.GetByExamAndStudent(ctx, examID, studentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("get session: %w", err)
+	}
+	if sess.Status != model.SessionStatusCompleted || sess.FinalScore == nil {
+		return 0, false, nil
+	}
+	return *sess.FinalScore, true, nil
+}
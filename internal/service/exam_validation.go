@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// Validate runs the publish preflight checklist for an exam so the UI can
+// block publishing with actionable messages instead of failing after the fact.
+func (s *ExamService) Validate(ctx context.Context, examID uuid.UUID) (*model.ExamValidationResult, error) {
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return nil, err
+	}
+
+	var questions []model.Question
+	if exam.QBankID != nil {
+		questions, err = s.questionRepo.ListByExam(ctx, examID)
+		if err != nil {
+			return nil, fmt.Errorf("list questions: %w", err)
+		}
+	}
+
+	rules, err := s.targetRepo.ListByExam(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("list target rules: %w", err)
+	}
+
+	result := &model.ExamValidationResult{ExamID: examID, Publishable: true}
+	add := func(key string, passed bool, severity model.ValidationSeverity, message string) {
+		result.Checks = append(result.Checks, model.ExamValidationCheck{
+			Key: key, Passed: passed, Severity: severity, Message: message,
+		})
+		if !passed && severity == model.ValidationError {
+			result.Publishable = false
+		}
+	}
+
+	// Status
+	if exam.Status == model.ExamStatusDraft {
+		add("status", true, model.ValidationError, "Exam is a draft")
+	} else {
+		add("status", false, model.ValidationError, fmt.Sprintf("Exam is %s; only drafts can be published", exam.Status))
+	}
+
+	// Question bank and questions
+	switch {
+	case exam.QBankID == nil:
+		add("has_questions", false, model.ValidationError, "No question bank is attached to this exam")
+	case len(questions) == 0:
+		add("has_questions", false, model.ValidationError, "The attached question bank has no questions")
+	default:
+		add("has_questions", true, model.ValidationError, fmt.Sprintf("%d questions available", len(questions)))
+	}
+
+	// Correct options
+	if len(questions) > 0 {
+		var bad []string
+		for _, q := range questions {
+			if !hasValidCorrectOption(q) {
+				bad = append(bad, strconv.Itoa(q.OrderNum))
+			}
+		}
+		if len(bad) == 0 {
+			add("correct_options", true, model.ValidationError, "All questions have a valid correct option")
+		} else {
+			add("correct_options", false, model.ValidationError,
+				"Questions with a missing or unknown correct option (order_num): "+strings.Join(bad, ", "))
+		}
+	}
+
+	// Alt text on question media
+	if len(questions) > 0 {
+		if bad := questionsMissingAltText(questions); len(bad) == 0 {
+			add("media_alt_text", true, model.ValidationError, "All question media have alt text")
+		} else {
+			add("media_alt_text", false, model.ValidationError,
+				"Questions with images missing alt, or audio/video missing aria-label (order_num): "+strings.Join(bad, ", "))
+		}
+	}
+
+	// Translations
+	if len(questions) > 0 && len(exam.Languages) > 0 {
+		if missing := missingTranslations(exam, questions); len(missing) == 0 {
+			add("translations", true, model.ValidationError,
+				"All questions are translated into "+strings.Join(exam.Languages, ", "))
+		} else {
+			add("translations", false, model.ValidationError,
+				"Questions missing a translation: "+strings.Join(missing, "; "))
+		}
+	}
+
+	// Question count
+	if exam.QuestionCount > len(questions) && len(questions) > 0 {
+		add("question_count", false, model.ValidationError,
+			fmt.Sprintf("question_count is %d but only %d questions exist", exam.QuestionCount, len(questions)))
+	}
+	if !exam.RandomizeQuestions && exam.QuestionCount > 0 && exam.QuestionCount < len(questions) {
+		add("question_subset", false, model.ValidationWarning,
+			fmt.Sprintf("Questions are not randomized, so every student gets the same first %d questions and %d are never used",
+				exam.QuestionCount, len(questions)-exam.QuestionCount))
+	}
+
+	// Automatically graded questions
+	if len(questions) > 0 {
+		if n := countGradable(questions); n == 0 {
+			add("gradable_questions", false, model.ValidationError,
+				"No question is graded automatically; all are essays or lack a valid correct option")
+		} else {
+			add("gradable_questions", true, model.ValidationError, fmt.Sprintf("%d questions are graded automatically", n))
+		}
+	}
+
+	// Payload size
+	if len(questions) > 0 {
+		size, err := s.payloadSize(ctx, exam, questions)
+		if err != nil {
+			return nil, fmt.Errorf("measure payload: %w", err)
+		}
+		total := formatMegabytes(size.TotalBytes)
+		untracked := ""
+		if n := len(size.UntrackedMedia); n > 0 {
+			untracked = fmt.Sprintf("; %d media outside the library are not counted", n)
+		}
+		switch {
+		case size.MaxBytes > 0 && size.TotalBytes > size.MaxBytes:
+			add("payload_size", false, model.ValidationError, fmt.Sprintf("Exam payload is %s, above the %s limit%s%s",
+				total, formatMegabytes(size.MaxBytes), largestMediaNote(size), untracked))
+		case size.WarnBytes > 0 && size.TotalBytes > size.WarnBytes:
+			add("payload_size", false, model.ValidationWarning, fmt.Sprintf("Exam payload is %s, above the %s warning size%s%s",
+				total, formatMegabytes(size.WarnBytes), largestMediaNote(size), untracked))
+		default:
+			add("payload_size", true, model.ValidationError, fmt.Sprintf("Exam payload is %s%s", total, untracked))
+		}
+	}
+
+	// Question reuse within the term
+	if len(questions) > 0 && len(rules) > 0 {
+		reuse, err := s.QuestionReuse(ctx, examID)
+		if err != nil {
+			return nil, fmt.Errorf("check question reuse: %w", err)
+		}
+		if n := countReusedQuestions(reuse.Reused); n > 0 {
+			add("question_reuse", false, model.ValidationWarning,
+				fmt.Sprintf("%d questions also appear in other exams for the same classes this term", n))
+		} else {
+			add("question_reuse", true, model.ValidationWarning, "No questions are reused in other exams for the same classes this term")
+		}
+	}
+
+	// Target rules
+	if len(rules) == 0 {
+		add("target_rules", false, model.ValidationError, "No target rules; no student will see this exam")
+	} else {
+		add("target_rules", true, model.ValidationError, fmt.Sprintf("%d target rules", len(rules)))
+	}
+
+	// Schedule
+	switch {
+	case exam.ScheduledStart == nil || exam.ScheduledEnd == nil:
+		add("schedule", false, model.ValidationWarning, "Schedule is open-ended; the exam is available as soon as it is published")
+	case !exam.ScheduledEnd.Time().After(exam.ScheduledStart.Time()):
+		add("schedule", false, model.ValidationError, "Scheduled end must be after scheduled start")
+	case exam.ScheduledEnd.Time().Before(time.Now()):
+		add("schedule", false, model.ValidationError, "Scheduled end is in the past")
+	default:
+		add("schedule", true, model.ValidationError, "Schedule is valid")
+
+		window := exam.ScheduledEnd.Time().Sub(exam.ScheduledStart.Time())
+		duration := time.Duration(exam.DurationMinutes) * time.Minute
+		if duration > window {
+			add("duration_fits_window", false, model.ValidationError,
+				fmt.Sprintf("Duration (%d min) is longer than the schedule window (%d min)", exam.DurationMinutes, int(window.Minutes())))
+		} else {
+			add("duration_fits_window", true, model.ValidationError, "Duration fits within the schedule window")
+		}
+	}
+
+	return result, nil
+}
+
+// hasValidCorrectOption reports whether a multiple-choice question's correct_option
+// refers to one of its options. Options may be an array of {key, text} objects or
+// an array of strings, in which case a 0-based index or a letter (A, B, …) is accepted.
+// True/false questions need "true" or "false". Essay questions are not graded
+// automatically and always pass.
+func hasValidCorrectOption(q model.Question) bool {
+	if q.QuestionType == model.QuestionTypeTrueFalse {
+		return isTrueFalseAnswer(q.CorrectOption)
+	}
+	if q.QuestionType != model.QuestionTypeMultipleChoice {
+		return true
+	}
+	answer := strings.TrimSpace(q.CorrectOption)
+	if answer == "" {
+		return false
+	}
+
+	var keyed []model.QuestionOption
+	if err := json.Unmarshal(q.Options, &keyed); err == nil && len(keyed) > 0 && keyed[0].Key != "" {
+		for _, o := range keyed {
+			if strings.EqualFold(o.Key, answer) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var plain []string
+	if err := json.Unmarshal(q.Options, &plain); err == nil {
+		if idx, err := strconv.Atoi(answer); err == nil {
+			return idx >= 0 && idx < len(plain)
+		}
+		if len(answer) == 1 {
+			idx := int(strings.ToUpper(answer)[0] - 'A')
+			return idx >= 0 && idx < len(plain)
+		}
+		return false
+	}
+
+	// Unknown option shape: accept any non-empty answer rather than block publishing.
+	return true
+}
+
+// countReusedQuestions counts distinct questions in a reuse list.
+func countReusedQuestions(reuses []model.QuestionReuse) int {
+	seen := make(map[uuid.UUID]bool, len(reuses))
+	for _, r := range reuses {
+		seen[r.QuestionID] = true
+	}
+	return len(seen)
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// BenchmarkGradeAnswers grades one submit of a 100-question exam, as handleSubmit
+// does: a mix of right, wrong, unanswered and index-style answers.
+func BenchmarkGradeAnswers(b *testing.B) {
+	const questions = 100
+
+	answerKey := make(map[string]string, questions)
+	studentAnswers := make(map[string]string, questions)
+	orderedIDs := make([]string, 0, questions)
+	for i := 0; i < questions; i++ {
+		id := uuid.NewString()
+		orderedIDs = append(orderedIDs, id)
+		answerKey[id] = string(rune('A' + i%5))
+		switch i % 4 {
+		case 0:
+			studentAnswers[id] = answerKey[id]
+		case 1:
+			studentAnswers[id] = string(rune('a' + (i+1)%5))
+		case 2:
+			studentAnswers[id] = strconv.Itoa(i % 5)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		GradeAnswers(answerKey, studentAnswers, orderedIDs)
+	}
+}
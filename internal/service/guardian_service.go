@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/stemsi/exstem-backend/internal/response"
+)
+
+var (
+	// ErrGuardianContactRequired is returned when a guardian has neither email nor phone to log in with.
+	ErrGuardianContactRequired = errors.New("guardian needs an email or phone number")
+	// ErrGuardianPhoneRequired is returned when WhatsApp notifications are chosen without a phone number.
+	ErrGuardianPhoneRequired = errors.New("whatsapp notifications need a phone number")
+)
+
+// GuardianService handles guardian accounts and their links to students.
+type GuardianService struct {
+	repo        *repository.GuardianRepository
+	authService *AuthService
+}
+
+// NewGuardianService creates a new GuardianService.
+func NewGuardianService(repo *repository.GuardianRepository, authService *AuthService) *GuardianService {
+	return &GuardianService{repo: repo, authService: authService}
+}
+
+// List retrieves guardians with pagination, optionally filtered by a search term.
+func (s *GuardianService) List(ctx context.Context, search *string, page, perPage int) ([]model.Guardian, *response.Pagination, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 10
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+
+	guardians, total, err := s.repo.ListPaginated(ctx, search, perPage, (page-1)*perPage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return guardians, response.NewPagination(page, perPage, total), nil
+}
+
+// GetByID retrieves a guardian by ID.
+func (s *GuardianService) GetByID(ctx context.Context, id int) (*model.Guardian, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// GetByIdentifier retrieves a guardian by email or phone number.
+func (s *GuardianService) GetByIdentifier(ctx context.Context, identifier string) (*model.Guardian, error) {
+	return s.repo.GetByIdentifier(ctx, strings.TrimSpace(identifier))
+}
+
+// Create creates a guardian account.
+func (s *GuardianService) Create(ctx context.Context, req model.CreateGuardianRequest) (*model.Guardian, error) {
+	g := &model.Guardian{
+		Name:  req.Name,
+		Email: normalizeContact(req.Email),
+		Phone: normalizeContact(req.Phone),
+	}
+	if g.Email == nil && g.Phone == nil {
+		return nil, ErrGuardianContactRequired
+	}
+
+	hash, err := s.authService.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("hash password: %w", err)
+	}
+	g.PasswordHash = hash
+
+	if err := s.repo.Create(ctx, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Update modifies a guardian account. Changing the password signs the guardian out everywhere.
+func (s *GuardianService) Update(ctx context.Context, id int, req model.UpdateGuardianRequest) (*model.Guardian, error) {
+	g := &model.Guardian{
+		ID:    id,
+		Name:  req.Name,
+		Email: normalizeContact(req.Email),
+		Phone: normalizeContact(req.Phone),
+	}
+	if g.Email == nil && g.Phone == nil {
+		return nil, ErrGuardianContactRequired
+	}
+
+	if req.Password != "" {
+		hash, err := s.authService.HashPassword(req.Password)
+		if err != nil {
+			return nil, fmt.Errorf("hash password: %w", err)
+		}
+		g.PasswordHash = hash
+	}
+
+	if err := s.repo.Update(ctx, g); err != nil {
+		return nil, err
+	}
+	if req.Password != "" {
+		if err := s.authService.RevokeGuardianSessions(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// Delete removes a guardian account and ends their sessions.
+func (s *GuardianService) Delete(ctx context.Context, id int) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.authService.RevokeGuardianSessions(ctx, id)
+}
+
+// UpdatePreferences saves a guardian's notification preferences.
+func (s *GuardianService) UpdatePreferences(ctx context.Context, id int, req model.UpdateGuardianPreferencesRequest) (*model.Guardian, error) {
+	g, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.NotifyChannel == model.NotifyChannelWhatsApp && g.Phone == nil {
+		return nil, ErrGuardianPhoneRequired
+	}
+	g.NotifyChannel = req.NotifyChannel
+	g.TelegramChatID = strings.TrimSpace(req.TelegramChatID)
+	g.NotifyResults = req.NotifyResults
+
+	if err := s.repo.UpdatePreferences(ctx, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// LinkStudent links a student to a guardian.
+func (s *GuardianService) LinkStudent(ctx context.Context, guardianID int, req model.LinkGuardianStudentRequest) error {
+	return s.repo.LinkStudent(ctx, guardianID, req.StudentID, strings.TrimSpace(req.Relationship))
+}
+
+// UnlinkStudent removes a student from a guardian.
+func (s *GuardianService) UnlinkStudent(ctx context.Context, guardianID, studentID int) error {
+	return s.repo.UnlinkStudent(ctx, guardianID, studentID)
+}
+
+// ListChildren returns the students linked to a guardian.
+func (s *GuardianService) ListChildren(ctx context.Context, guardianID int) ([]model.GuardianChild, error) {
+	children, err := s.repo.ListChildren(ctx, guardianID)
+	if err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// GetChild returns one of a guardian's children, or pgx.ErrNoRows when the
+// student is not theirs.
+func (s *GuardianService) GetChild(ctx context.Context, guardianID, studentID int) (*model.GuardianChild, error) {
+	return s.repo.GetChild(ctx, guardianID, studentID)
+}
+
+// normalizeContact trims an optional email/phone and maps empty values to nil.
+func normalizeContact(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*v)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/xuri/excelize/v2"
+)
+
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// SendHomeroomSummaries emails each homeroom teacher a summary of their class's
+// results in a completed exam, with the per-student results as a workbook. It only
+// runs for exams that opted in and when email is configured.
+func (s *NotificationService) SendHomeroomSummaries(ctx context.Context, examID uuid.UUID) {
+	if s.cfg.SMTPHost == "" {
+		return
+	}
+	settings, err := s.repo.GetSettings(ctx, examID)
+	if err != nil || !settings.NotifyHomeroomSummary {
+		return
+	}
+
+	summaries, err := s.repo.ListHomeroomSummaries(ctx, examID)
+	if err != nil {
+		s.log.Error().Err(err).Str("exam_id", examID.String()).Msg("Failed to list homeroom summaries")
+		return
+	}
+
+	for _, hs := range summaries {
+		workbook, err := homeroomSummaryXLSX(settings.ExamTitle, hs)
+		if err != nil {
+			s.log.Error().Err(err).Int("class_id", hs.ClassID).Msg("Failed to build homeroom summary")
+			continue
+		}
+		subject := fmt.Sprintf("Hasil ujian \"%s\" kelas %s", settings.ExamTitle, hs.ClassName)
+		attachment := &emailAttachment{
+			Filename:    fmt.Sprintf("Hasil_%s.xlsx", strings.ReplaceAll(hs.ClassName, " ", "_")),
+			ContentType: xlsxContentType,
+			Data:        workbook,
+		}
+		if err := s.sendEmail(hs.TeacherEmail, subject, homeroomSummaryText(settings.ExamTitle, hs), attachment); err != nil {
+			s.log.Warn().Err(err).Int("class_id", hs.ClassID).Msg("Failed to email homeroom summary")
+		}
+	}
+}
+
+// homeroomSummaryText is the body of a homeroom summary email.
+func homeroomSummaryText(examTitle string, hs model.HomeroomSummary) string {
+	var completed, scored int
+	var sum float64
+	var highest, lowest *float64
+	for _, r := range hs.Results {
+		if r.Status == model.SessionStatusCompleted {
+			completed++
+		}
+		if r.FinalScore == nil {
+			continue
+		}
+		score := *r.FinalScore
+		scored++
+		sum += score
+		if highest == nil || score > *highest {
+			highest = &score
+		}
+		if lowest == nil || score < *lowest {
+			lowest = &score
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Yth. %s,\n\n", hs.TeacherName)
+	fmt.Fprintf(&b, "Ujian \"%s\" telah selesai. Ringkasan hasil kelas %s:\n", examTitle, hs.ClassName)
+	fmt.Fprintf(&b, "- Peserta: %d\n", len(hs.Results))
+	fmt.Fprintf(&b, "- Selesai: %d\n", completed)
+	if scored > 0 {
+		fmt.Fprintf(&b, "- Rata-rata: %.2f\n", sum/float64(scored))
+		fmt.Fprintf(&b, "- Tertinggi: %.2f\n", *highest)
+		fmt.Fprintf(&b, "- Terendah: %.2f\n", *lowest)
+	}
+	b.WriteString("\nNilai setiap siswa terlampir.\n")
+	return b.String()
+}
+
+// homeroomSummaryXLSX builds the workbook of a class's results, one row per student.
+func homeroomSummaryXLSX(examTitle string, hs model.HomeroomSummary) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const sheet = "Hasil"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+	})
+
+	_ = f.SetCellValue(sheet, "A1", fmt.Sprintf("%s - %s", examTitle, hs.ClassName))
+	header := []interface{}{"No", "Nama", "NISN", "Status", "Nilai"}
+	if err := f.SetSheetRow(sheet, "A3", &header); err != nil {
+		return nil, err
+	}
+	_ = f.SetCellStyle(sheet, "A3", "E3", headerStyle)
+
+	for i, r := range hs.Results {
+		row := []interface{}{i + 1, r.Name, r.NISN, string(r.Status), ""}
+		if r.FinalScore != nil {
+			row[4] = *r.FinalScore
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+4)
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			return nil, err
+		}
+	}
+	_ = f.SetColWidth(sheet, "B", "B", 32)
+	_ = f.SetColWidth(sheet, "C", "D", 16)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
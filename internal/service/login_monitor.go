@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/metrics"
+)
+
+// loginWindow is the fixed window login attempts are counted in. loginRecordTimeout
+// bounds the Redis work of one login, which runs before the login is answered.
+const (
+	loginWindow        = 10 * time.Minute
+	loginRecordTimeout = 500 * time.Millisecond
+)
+
+// loginKindLabels name the account kinds in alerts.
+var loginKindLabels = map[TokenType]string{
+	TokenTypeStudent:  "siswa",
+	TokenTypeAdmin:    "admin",
+	TokenTypeGuardian: "wali",
+}
+
+// LoginMonitor counts login attempts per IP and per account in Redis and raises an
+// anomaly when an IP fails logins to many accounts, the mark of credential stuffing,
+// or an account fails many logins, the mark of password guessing. Anomalies are
+// logged, counted in the metrics and sent to proctors who opted in, once per subject
+// and window.
+type LoginMonitor struct {
+	rdb      *redis.Client
+	notifier *NotificationService
+	cfg      *config.Config
+	log      zerolog.Logger
+}
+
+// NewLoginMonitor creates a new LoginMonitor.
+func NewLoginMonitor(rdb *redis.Client, notifier *NotificationService, cfg *config.Config, log zerolog.Logger) *LoginMonitor {
+	return &LoginMonitor{
+		rdb:      rdb,
+		notifier: notifier,
+		cfg:      cfg,
+		log:      log.With().Str("component", "login_monitor").Logger(),
+	}
+}
+
+// Record counts a login attempt from ip to an account of kind, named by the
+// identifier the client sent. It is best-effort: Redis failures are logged, never
+// returned, so logins do not depend on it, and a slow Redis delays a login by at most
+// loginRecordTimeout.
+func (m *LoginMonitor) Record(ctx context.Context, kind TokenType, ip, account string, success bool) {
+	metrics.Logins.Observe(string(kind), success)
+
+	ctx, cancel := context.WithTimeout(ctx, loginRecordTimeout)
+	defer cancel()
+
+	window := time.Now().Unix() / int64(loginWindow/time.Second)
+	account = strings.ToLower(strings.TrimSpace(account))
+	ttl := 2 * loginWindow
+
+	field := "failure"
+	if success {
+		field = "success"
+	}
+	ipKey := config.CacheKey.LoginIPKey(ip, window)
+	pipe := m.rdb.Pipeline()
+	pipe.HIncrBy(ctx, ipKey, field, 1)
+	pipe.Expire(ctx, ipKey, ttl)
+	if success {
+		if _, err := pipe.Exec(ctx); err != nil {
+			m.log.Warn().Err(err).Msg("Failed to record login")
+		}
+		return
+	}
+
+	// Only failures count towards anomalies: a school behind one NAT address logs in
+	// hundreds of students from a single IP.
+	accountsKey := config.CacheKey.LoginIPAccountsKey(ip, window)
+	pipe.PFAdd(ctx, accountsKey, string(kind)+":"+account)
+	pipe.Expire(ctx, accountsKey, ttl)
+	accounts := pipe.PFCount(ctx, accountsKey)
+	ipCounts := pipe.HGetAll(ctx, ipKey)
+	accountKey := config.CacheKey.LoginAccountKey(string(kind), account, window)
+	failures := pipe.Incr(ctx, accountKey)
+	pipe.Expire(ctx, accountKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		m.log.Warn().Err(err).Msg("Failed to record login")
+		return
+	}
+
+	minutes := int(loginWindow / time.Minute)
+	if limit := m.cfg.LoginAnomalyAccounts; limit > 0 && accounts.Val() >= limit {
+		counts := ipCounts.Val()
+		m.raise(ctx, "ip:"+ip, window,
+			fmt.Sprintf("🚨 Dugaan credential stuffing: IP %s gagal masuk ke %d akun berbeda dalam %d menit (%s gagal, %s berhasil).",
+				ip, accounts.Val(), minutes, countOrZero(counts["failure"]), countOrZero(counts["success"])))
+	}
+	if limit := m.cfg.LoginAnomalyFailures; limit > 0 && failures.Val() >= limit {
+		// The identifier is whatever the client sent, so the alert names it by a short
+		// hash; the log line of the anomaly has it in full.
+		m.raise(ctx, "account:"+string(kind)+":"+account, window,
+			fmt.Sprintf("🚨 Akun %s #%s gagal masuk %d kali dalam %d menit, terakhir dari IP %s.",
+				loginKindLabels[kind], accountHash(account), failures.Val(), minutes, ip))
+	}
+}
+
+// raise reports an anomaly of subject, an IP or an account, unless it was already
+// reported in the window.
+func (m *LoginMonitor) raise(ctx context.Context, subject string, window int64, text string) {
+	first, err := m.rdb.SetNX(ctx, config.CacheKey.LoginAnomalyAlertKey(subject, window), 1, 2*loginWindow).Result()
+	if err != nil || !first {
+		return
+	}
+	metrics.Logins.ObserveAnomaly()
+	m.log.Warn().Str("subject", subject).Str("alert", text).Msg("Login anomaly")
+
+	// Delivery can take seconds; the login answers without waiting for it.
+	go m.notifier.NotifyLoginAnomaly(context.WithoutCancel(ctx), text)
+}
+
+// accountHash is the short hash an account is named by in alerts.
+func accountHash(account string) string {
+	sum := sha256.Sum256([]byte(account))
+	return hex.EncodeToString(sum[:4])
+}
+
+func countOrZero(v string) string {
+	if v == "" {
+		return "0"
+	}
+	return v
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -8,15 +11,22 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/stemsi/exstem-backend/internal/response"
 )
 
 // Sentinel errors for media uploads.
 var (
 	ErrUnsupportedFileType = errors.New("unsupported file type")
 	ErrFileTooLarge        = errors.New("file too large")
+	ErrMediaInUse          = errors.New("media file is referenced by questions")
+	ErrInvalidDuration     = errors.New("invalid media duration")
 )
 
 // Allowed image MIME types.
@@ -27,59 +37,216 @@ var allowedMIMETypes = map[string]string{
 	"image/webp": ".webp",
 }
 
-// MediaService handles file upload operations.
+// Allowed audio/video MIME types. These are streamed with range requests
+// and are subject to the larger MaxMediaUploadBytes limit.
+var allowedStreamMIMETypes = map[string]string{
+	"audio/mpeg": ".mp3",
+	"audio/ogg":  ".ogg",
+	"audio/wav":  ".wav",
+	"audio/mp4":  ".m4a",
+	"audio/webm": ".weba",
+	"video/mp4":  ".mp4",
+	"video/webm": ".webm",
+}
+
+// mediaHashLength is how many hex digits of the content's SHA-256 name an upload.
+const mediaHashLength = 32
+
+// MediaService handles file upload operations and the media library.
 type MediaService struct {
-	cfg *config.Config
+	cfg       *config.Config
+	mediaRepo *repository.MediaRepository
 }
 
 // NewMediaService creates a new MediaService.
-func NewMediaService(cfg *config.Config) *MediaService {
-	return &MediaService{cfg: cfg}
+func NewMediaService(cfg *config.Config, mediaRepo *repository.MediaRepository) *MediaService {
+	return &MediaService{cfg: cfg, mediaRepo: mediaRepo}
 }
 
-// SaveUpload saves an uploaded file to local storage with a UUID filename.
-// Returns the relative URL path to the saved file.
-func (s *MediaService) SaveUpload(file multipart.File, header *multipart.FileHeader) (string, error) {
+// SaveUpload saves an uploaded file to local storage and records it in the media
+// library. durationSeconds is only kept for audio/video.
+//
+// The file is named after a hash of its content, so a URL never serves different
+// content and uploads are safe to cache for a year: replacing an image yields a new
+// URL. Uploading content already in the library returns the existing file.
+func (s *MediaService) SaveUpload(ctx context.Context, file multipart.File, header *multipart.FileHeader, uploaderID int, durationSeconds *int) (*model.MediaFile, error) {
 	// Validate MIME type.
 	contentType := header.Header.Get("Content-Type")
+	maxBytes := s.cfg.MaxUploadBytes
 	ext, ok := allowedMIMETypes[contentType]
 	if !ok {
-		return "", fmt.Errorf("%w: %s (allowed: %s)",
-			ErrUnsupportedFileType, contentType, strings.Join(allowedTypes(), ", "))
+		ext, ok = allowedStreamMIMETypes[contentType]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s (allowed: %s)",
+				ErrUnsupportedFileType, contentType, strings.Join(allowedTypes(), ", "))
+		}
+		maxBytes = s.cfg.MaxMediaUploadBytes
+	} else {
+		durationSeconds = nil
+	}
+
+	if durationSeconds != nil && *durationSeconds < 0 {
+		return nil, ErrInvalidDuration
 	}
 
 	// Validate file size.
-	if header.Size > s.cfg.MaxUploadBytes {
-		return "", fmt.Errorf("%w: %d bytes (max: %d)", ErrFileTooLarge, header.Size, s.cfg.MaxUploadBytes)
+	if header.Size > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes (max: %d)", ErrFileTooLarge, header.Size, maxBytes)
 	}
 
 	// Ensure upload directory exists.
 	if err := os.MkdirAll(s.cfg.UploadDir, 0o755); err != nil {
-		return "", fmt.Errorf("create upload dir: %w", err)
+		return nil, fmt.Errorf("create upload dir: %w", err)
+	}
+
+	// Write to a temporary file while hashing, then move it to its content-hash name.
+	tmp, err := os.CreateTemp(s.cfg.UploadDir, ".upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), file)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("write file: %w", err)
+	}
+	filename := hex.EncodeToString(hash.Sum(nil))[:mediaHashLength] + ext
+
+	if existing, err := s.mediaRepo.GetByFilename(ctx, filename); err == nil {
+		return s.reuse(ctx, existing, uploaderID)
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("check media: %w", err)
 	}
 
-	// Generate UUID filename.
-	filename := uuid.New().String() + ext
 	destPath := filepath.Join(s.cfg.UploadDir, filename)
+	if err := os.Rename(tmp.Name(), destPath); err != nil {
+		return nil, fmt.Errorf("store file: %w", err)
+	}
+
+	media := &model.MediaFile{
+		Filename:     filename,
+		OriginalName: filepath.Base(header.Filename),
+		URL:          "/uploads/" + filename,
+		MimeType:     contentType,
+		SizeBytes:    written,
+		Duration:     durationSeconds,
+		UploadedBy:   &uploaderID,
+	}
+	if err := s.mediaRepo.Create(ctx, media); err != nil {
+		// A concurrent upload of the same content may have recorded it first.
+		if existing, getErr := s.mediaRepo.GetByFilename(ctx, filename); getErr == nil {
+			return s.reuse(ctx, existing, uploaderID)
+		}
+		_ = os.Remove(destPath)
+		return nil, fmt.Errorf("record media: %w", err)
+	}
+
+	return media, nil
+}
+
+// reuse returns a media file uploaded again by uploaderID. Its grace period starts
+// over, so the cleanup does not remove it as an orphan before the question using it
+// is saved.
+func (s *MediaService) reuse(ctx context.Context, media *model.MediaFile, uploaderID int) (*model.MediaFile, error) {
+	if err := s.mediaRepo.MarkReused(ctx, media, uploaderID); err != nil {
+		return nil, fmt.Errorf("mark media reused: %w", err)
+	}
+	return media, nil
+}
+
+// List retrieves media files with pagination and usage counts.
+func (s *MediaService) List(ctx context.Context, page, perPage int, search string) ([]model.MediaFile, *response.Pagination, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 20
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+
+	files, total, err := s.mediaRepo.ListPaginated(ctx, perPage, (page-1)*perPage, search)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return files, response.NewPagination(page, perPage, total), nil
+}
+
+// GetUsage returns the questions, passages and settings that reference a media file.
+func (s *MediaService) GetUsage(ctx context.Context, id uuid.UUID) ([]model.MediaUsage, error) {
+	if _, err := s.mediaRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	usages, err := s.mediaRepo.ListUsage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return usages, nil
+}
+
+// Delete removes a media file from storage and the library.
+// Files still referenced by questions, passages or settings are rejected unless force
+// is set.
+func (s *MediaService) Delete(ctx context.Context, id uuid.UUID, force bool) error {
+	media, err := s.mediaRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		usages, err := s.mediaRepo.ListUsage(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(usages) > 0 {
+			return ErrMediaInUse
+		}
+	}
 
-	dst, err := os.Create(destPath)
+	return s.remove(ctx, media)
+}
+
+// CleanupOrphans deletes media files older than gracePeriod that no question, passage
+// or setting references.
+// Returns the number of files removed.
+func (s *MediaService) CleanupOrphans(ctx context.Context, gracePeriod time.Duration) (int, error) {
+	orphans, err := s.mediaRepo.ListOrphans(ctx, time.Now().Add(-gracePeriod))
 	if err != nil {
-		return "", fmt.Errorf("create file: %w", err)
+		return 0, fmt.Errorf("list orphans: %w", err)
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, file); err != nil {
-		return "", fmt.Errorf("write file: %w", err)
+	removed := 0
+	for i := range orphans {
+		if err := s.remove(ctx, &orphans[i]); err != nil {
+			return removed, fmt.Errorf("remove %s: %w", orphans[i].Filename, err)
+		}
+		removed++
 	}
+	return removed, nil
+}
 
-	// Return relative URL path.
-	return "/uploads/" + filename, nil
+// remove deletes the file on disk first, then its library record.
+func (s *MediaService) remove(ctx context.Context, media *model.MediaFile) error {
+	path := filepath.Join(s.cfg.UploadDir, filepath.Base(media.Filename))
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove file: %w", err)
+	}
+	return s.mediaRepo.Delete(ctx, media.ID)
 }
 
 func allowedTypes() []string {
-	types := make([]string, 0, len(allowedMIMETypes))
+	types := make([]string, 0, len(allowedMIMETypes)+len(allowedStreamMIMETypes))
 	for t := range allowedMIMETypes {
 		types = append(types, t)
 	}
+	for t := range allowedStreamMIMETypes {
+		types = append(types, t)
+	}
 	return types
 }
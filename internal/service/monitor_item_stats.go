@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// ItemStatsEnabled reads the monitor_item_stats setting; item stats are off unless
+// it is "true".
+func (s *MonitorService) ItemStatsEnabled(ctx context.Context) bool {
+	setting, err := s.settingRepo.GetByKey(ctx, model.SettingMonitorItemStats)
+	return err == nil && setting.Value == "true"
+}
+
+// GetItemStats counts how the multiple-choice and true/false questions of a running
+// exam have been answered so far: students in progress from their Redis answer
+// hashes, everyone else from their persisted answers. Index answers count as the
+// option letter, as in grading.
+func (s *MonitorService) GetItemStats(ctx context.Context, examID uuid.UUID, questions []model.QuestionForStudent, answerKey map[string]string) ([]model.LiveItemStat, error) {
+	studentIDs, err := s.monitorRepo.GetInProgressStudentIDs(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("get students in progress: %w", err)
+	}
+	live, err := s.monitorRepo.GetLiveAnswers(ctx, examID, studentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get live answers: %w", err)
+	}
+	submitted, err := s.monitorRepo.GetSubmittedAnswerCounts(ctx, examID)
+	if err != nil {
+		return nil, fmt.Errorf("get submitted answers: %w", err)
+	}
+
+	stats := make([]model.LiveItemStat, 0, len(questions))
+	byID := make(map[string]int, len(questions))
+	for _, q := range questions {
+		choices := itemChoices(q)
+		if choices == nil {
+			continue
+		}
+		byID[q.ID.String()] = len(stats)
+		stats = append(stats, model.LiveItemStat{
+			QuestionID:    q.ID,
+			OrderNum:      q.OrderNum,
+			Choices:       choices,
+			CorrectOption: answerKey[q.ID.String()],
+		})
+	}
+
+	count := func(qid, answer string, n int64) {
+		i, ok := byID[qid]
+		if !ok || answer == "" {
+			return
+		}
+		st := &stats[i]
+		for choice := range st.Choices {
+			if AnswerMatches(answer, choice) {
+				st.Choices[choice] += n
+				st.Answered += n
+				return
+			}
+		}
+	}
+	for _, answers := range live {
+		for qid, answer := range answers {
+			count(qid, answer, 1)
+		}
+	}
+	for qid, answers := range submitted {
+		for answer, n := range answers {
+			count(qid, answer, n)
+		}
+	}
+
+	for i := range stats {
+		st := &stats[i]
+		if st.Answered == 0 {
+			continue
+		}
+		for choice, n := range st.Choices {
+			if AnswerMatches(choice, st.CorrectOption) {
+				st.CorrectRate = float64(n) / float64(st.Answered)
+			}
+		}
+	}
+	return stats, nil
+}
+
+// itemChoices lists the answers a question's distribution is counted over, all at
+// zero: its option keys, true and false, or nil for questions graded by hand.
+func itemChoices(q model.QuestionForStudent) map[string]int64 {
+	switch q.QuestionType {
+	case model.QuestionTypeTrueFalse:
+		return map[string]int64{"true": 0, "false": 0}
+	case model.QuestionTypeMultipleChoice, "":
+		var keyed []model.QuestionOption
+		if err := json.Unmarshal(q.Options, &keyed); err == nil && len(keyed) > 0 && keyed[0].Key != "" {
+			choices := make(map[string]int64, len(keyed))
+			for _, o := range keyed {
+				choices[o.Key] = 0
+			}
+			return choices
+		}
+		var plain []string
+		if err := json.Unmarshal(q.Options, &plain); err == nil && len(plain) > 0 {
+			choices := make(map[string]int64, len(plain))
+			for i := range plain[:min(len(plain), 26)] {
+				choices[string(rune('A'+i))] = 0
+			}
+			return choices
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/queue"
+)
+
+const (
+	// overviewRateWindow is how many whole minutes the overview's rates are averaged over.
+	overviewRateWindow = 5
+	// overviewConnectedWithin is how recently a student's socket must have been seen to
+	// count as connected, matching when the monitor reports them idle.
+	overviewConnectedWithin = 60 * time.Second
+)
+
+// GetOverview aggregates every running exam: sessions, connected students and submit
+// rates from PostgreSQL and Redis, plus the API error rate and the worker queues.
+// While Redis is degraded only the PostgreSQL figures are filled in.
+func (s *MonitorService) GetOverview(ctx context.Context) (*model.MonitorOverview, error) {
+	now := time.Now()
+	exams, err := s.monitorRepo.GetActiveExamOverviews(ctx, now.Add(-overviewRateWindow*time.Minute))
+	if err != nil {
+		return nil, fmt.Errorf("get active exams: %w", err)
+	}
+
+	overview := &model.MonitorOverview{
+		Timestamp:     now.Unix(),
+		ActiveExams:   len(exams),
+		Queues:        map[string]int64{},
+		RedisDegraded: s.health.Degraded(),
+		Exams:         make([]model.ExamOverview, 0, len(exams)),
+	}
+
+	var connected map[uuid.UUID]int
+	if !overview.RedisDegraded {
+		ids := make([]uuid.UUID, len(exams))
+		for i, e := range exams {
+			ids[i] = e.ExamID
+		}
+		if connected, err = s.monitorRepo.CountConnected(ctx, ids, now.Add(-overviewConnectedWithin)); err != nil {
+			return nil, fmt.Errorf("count connected students: %w", err)
+		}
+
+		// The current minute is still being counted, so the window ends at the last whole one.
+		minute := now.Unix() / 60
+		total, failed, err := s.monitorRepo.GetRequestStats(ctx, minute-overviewRateWindow, minute-1)
+		if err != nil {
+			return nil, fmt.Errorf("get request stats: %w", err)
+		}
+		overview.RequestsPerMinute = float64(total) / overviewRateWindow
+		if total > 0 {
+			overview.ErrorRate = float64(failed) / float64(total)
+		}
+
+		overview.Queues = workerQueueLengths(ctx, s.queue)
+	}
+
+	for _, e := range exams {
+		e.SubmitsPerMinute /= overviewRateWindow
+		e.Connected = connected[e.ExamID]
+		overview.InProgress += e.InProgress
+		overview.Completed += e.Completed
+		overview.Connected += e.Connected
+		overview.Disconnected += max(e.InProgress-e.Connected, 0)
+		overview.SubmitsPerMinute += e.SubmitsPerMinute
+		overview.Exams = append(overview.Exams, e)
+	}
+	return overview, nil
+}
+
+// workerQueueLengths reads the length of every worker queue. A queue that cannot be
+// read is left out.
+func workerQueueLengths(ctx context.Context, q queue.Queue) map[string]int64 {
+	names := []string{
+		config.WorkerKey.PersistAnswersQueue,
+		config.WorkerKey.PersistCheatsQueue,
+		config.WorkerKey.PersistScoresQueue,
+		config.WorkerKey.PersistQuestionOrderQueue,
+		config.WorkerKey.RefreshExamStatsQueue,
+		config.WorkerKey.RecordMonitorEventsQueue,
+	}
+	lengths := make(map[string]int64, len(names))
+	for _, name := range names {
+		if n, err := q.Len(ctx, name); err == nil {
+			lengths[name] = n
+		}
+	}
+	return lengths
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/queue"
+)
+
+// Every event sent to an exam's live monitor is also queued for the monitor recording
+// worker, which stores it in exam_monitor_events for replay.
+
+const (
+	// DefaultMonitorReplayLimit and MaxMonitorReplayLimit bound a replay page.
+	DefaultMonitorReplayLimit = 1000
+	MaxMonitorReplayLimit     = 5000
+)
+
+// MonitorEventJob is a monitor event queued for recording.
+type MonitorEventJob struct {
+	ExamID     string          `json:"exam_id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	RecordedAt int64           `json:"recorded_at"` // unix milliseconds
+}
+
+// publishMonitorEvent sends event to the exam's live monitors and queues it for the
+// exam's monitor recording.
+func publishMonitorEvent(ctx context.Context, rdb *redis.Client, q queue.Queue, examID uuid.UUID, event map[string]interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal monitor event: %w", err)
+	}
+	eventType, _ := event["type"].(string)
+	job, _ := json.Marshal(MonitorEventJob{
+		ExamID:     examID.String(),
+		Type:       eventType,
+		Payload:    data,
+		RecordedAt: time.Now().UnixMilli(),
+	})
+
+	var errs []error
+	if err := rdb.Publish(ctx, config.CacheKey.ExamMonitorChannel(examID.String()), data).Err(); err != nil {
+		errs = append(errs, fmt.Errorf("publish monitor event: %w", err))
+	}
+	if err := q.Push(ctx, config.WorkerKey.RecordMonitorEventsQueue, job); err != nil {
+		errs = append(errs, fmt.Errorf("queue monitor event: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// PublishMonitorEvent sends event to the exam's live monitors and records it.
+func (s *ExamSessionService) PublishMonitorEvent(ctx context.Context, examID uuid.UUID, event map[string]interface{}) error {
+	return publishMonitorEvent(ctx, s.rdb, s.queue, examID, event)
+}
+
+// GetReplay returns the exam's monitor events recorded within [from, to], oldest
+// first, starting after the event with ID after (0 for the first page).
+func (s *MonitorService) GetReplay(ctx context.Context, examID uuid.UUID, from, to time.Time, after int64, limit int) (*model.MonitorReplay, error) {
+	if limit <= 0 || limit > MaxMonitorReplayLimit {
+		limit = DefaultMonitorReplayLimit
+	}
+	// One extra row tells whether another page follows.
+	events, err := s.monitorRepo.ListEvents(ctx, examID, from, to, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("list monitor events: %w", err)
+	}
+
+	replay := &model.MonitorReplay{Events: events}
+	if len(events) > limit {
+		replay.Events = events[:limit]
+		next := events[limit-1].ID
+		replay.NextAfter = &next
+	}
+	return replay, nil
+}
@@ -5,17 +5,23 @@ import (
 	"sync"
 
 	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/queue"
 	"github.com/stemsi/exstem-backend/internal/repository"
+	"github.com/stemsi/exstem-backend/internal/resilience"
 )
 
 // MonitorService orchestrates live exam monitoring business logic.
 type MonitorService struct {
-	monitorRepo *repository.MonitorRepository
+	monitorRepo    *repository.MonitorRepository
+	settingRepo    *repository.SettingRepository
+	metricsService *SystemMetricsService
+	queue          queue.Queue
+	health         *resilience.HealthMonitor
 }
 
 // NewMonitorService creates a new MonitorService.
-func NewMonitorService(monitorRepo *repository.MonitorRepository) *MonitorService {
-	return &MonitorService{monitorRepo: monitorRepo}
+func NewMonitorService(monitorRepo *repository.MonitorRepository, settingRepo *repository.SettingRepository, metricsService *SystemMetricsService, q queue.Queue, health *resilience.HealthMonitor) *MonitorService {
+	return &MonitorService{monitorRepo: monitorRepo, settingRepo: settingRepo, metricsService: metricsService, queue: q, health: health}
 }
 
 // StudentProgressSnapshot holds the answered count and cheat count for every in-progress student.
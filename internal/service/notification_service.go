@@ -0,0 +1,360 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+)
+
+const (
+	telegramAPIURL = "https://api.telegram.org"
+	// cheatAlertTTL keeps a student from being reported twice for the same exam.
+	cheatAlertTTL = 24 * time.Hour
+	// queueBacklogCooldown spaces out repeated backlog alerts while a queue stays long.
+	queueBacklogCooldown = 10 * time.Minute
+)
+
+// Notification errors.
+var (
+	ErrNotificationNotConfigured = errors.New("no notification channel is configured")
+	ErrNotificationNoRecipients  = errors.New("exam has no notification recipients")
+)
+
+// NotificationService messages proctors over Telegram and WhatsApp about exam
+// events. Delivery is best-effort: failures are logged, never surfaced to students.
+type NotificationService struct {
+	repo   *repository.NotificationRepository
+	rdb    *redis.Client
+	cfg    *config.Config
+	client *http.Client
+	log    zerolog.Logger
+}
+
+// NewNotificationService creates a new NotificationService.
+func NewNotificationService(repo *repository.NotificationRepository, rdb *redis.Client, cfg *config.Config, log zerolog.Logger) *NotificationService {
+	return &NotificationService{
+		repo:   repo,
+		rdb:    rdb,
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		log:    log.With().Str("component", "notification").Logger(),
+	}
+}
+
+// Enabled reports whether at least one notification channel is configured.
+func (s *NotificationService) Enabled() bool {
+	return s.cfg.TelegramBotToken != "" || s.cfg.WhatsAppGatewayURL != ""
+}
+
+// GetSettings retrieves an exam's notification settings.
+func (s *NotificationService) GetSettings(ctx context.Context, examID uuid.UUID) (*model.ExamNotificationSettings, error) {
+	return s.repo.GetSettings(ctx, examID)
+}
+
+// SetSettings configures an exam's notification recipients and triggers.
+func (s *NotificationService) SetSettings(ctx context.Context, examID uuid.UUID, req model.UpsertExamNotificationSettingsRequest) (*model.ExamNotificationSettings, error) {
+	settings := &model.ExamNotificationSettings{
+		ExamID:             examID,
+		TelegramChatIDs:    trimAll(req.TelegramChatIDs),
+		WhatsAppNumbers:    trimAll(req.WhatsAppNumbers),
+		NotifyExamStart:    req.NotifyExamStart,
+		CheatThreshold:     req.CheatThreshold,
+		NotifyQueueBacklog: req.NotifyQueueBacklog,
+
+		NotifyHomeroomSummary: req.NotifyHomeroomSummary,
+		NotifyLoginAnomaly:    req.NotifyLoginAnomaly,
+	}
+	if err := s.repo.UpsertSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// DeleteSettings turns off all notifications for an exam.
+func (s *NotificationService) DeleteSettings(ctx context.Context, examID uuid.UUID) error {
+	return s.repo.DeleteSettings(ctx, examID)
+}
+
+// SendTest sends a test message to an exam's recipients so proctors can check their setup.
+// Unlike the event notifications it reports delivery failures.
+func (s *NotificationService) SendTest(ctx context.Context, examID uuid.UUID) error {
+	if !s.Enabled() {
+		return ErrNotificationNotConfigured
+	}
+	settings, err := s.repo.GetSettings(ctx, examID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotificationNoRecipients
+	}
+	if err != nil {
+		return err
+	}
+	if !settings.HasRecipients() {
+		return ErrNotificationNoRecipients
+	}
+	return s.send(ctx, settings, fmt.Sprintf("✅ Tes notifikasi untuk ujian \"%s\".", settings.ExamTitle))
+}
+
+// NotifyExamStarted tells an exam's proctors that the exam has started.
+func (s *NotificationService) NotifyExamStarted(ctx context.Context, examID uuid.UUID) {
+	settings := s.settingsFor(ctx, examID)
+	if settings == nil || !settings.NotifyExamStart {
+		return
+	}
+	s.deliver(ctx, settings, fmt.Sprintf("▶️ Ujian \"%s\" telah dimulai.", settings.ExamTitle))
+}
+
+// CheckCheatThresholds alerts proctors about students among studentIDs whose cheat
+// events in the exam reached its threshold. Each student is reported once per exam.
+func (s *NotificationService) CheckCheatThresholds(ctx context.Context, examID uuid.UUID, studentIDs []int) {
+	settings := s.settingsFor(ctx, examID)
+	if settings == nil || settings.CheatThreshold <= 0 {
+		return
+	}
+
+	counts, err := s.repo.CountCheats(ctx, examID, studentIDs)
+	if err != nil {
+		s.log.Error().Err(err).Str("exam_id", examID.String()).Msg("Failed to count cheat events")
+		return
+	}
+
+	for _, cc := range counts {
+		if cc.Count < settings.CheatThreshold {
+			continue
+		}
+		first, err := s.rdb.SetNX(ctx, config.CacheKey.CheatAlertKey(examID.String(), cc.StudentID), 1, cheatAlertTTL).Result()
+		if err != nil || !first {
+			continue
+		}
+		s.deliver(ctx, settings, fmt.Sprintf("⚠️ %s mencatat %d pelanggaran pada ujian \"%s\".", cc.Name, cc.Count, settings.ExamTitle))
+	}
+}
+
+// NotifyQueueBacklog alerts proctors of running exams that worker queues are backing
+// up. lengths maps queue name to its pending job count. Alerts are rate limited.
+func (s *NotificationService) NotifyQueueBacklog(ctx context.Context, lengths map[string]int64) {
+	if !s.Enabled() {
+		return
+	}
+
+	first, err := s.rdb.SetNX(ctx, config.CacheKey.QueueBacklogAlertKey(), 1, queueBacklogCooldown).Result()
+	if err != nil || !first {
+		return
+	}
+
+	subscribers, err := s.repo.ListBacklogSubscribers(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to list queue backlog subscribers")
+		return
+	}
+
+	names := make([]string, 0, len(lengths))
+	for name := range lengths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString("🚨 Antrean worker menumpuk:")
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n- %s: %d", name, lengths[name])
+	}
+	b.WriteString("\nJawaban dan skor siswa mungkin tertunda.")
+	s.broadcast(ctx, subscribers, b.String())
+}
+
+// NotifyLoginAnomaly alerts proctors of upcoming and running exams who opted in to a
+// login anomaly, described by text. The caller limits how often it is sent.
+func (s *NotificationService) NotifyLoginAnomaly(ctx context.Context, text string) {
+	if !s.Enabled() {
+		return
+	}
+
+	subscribers, err := s.repo.ListLoginAnomalySubscribers(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to list login anomaly subscribers")
+		return
+	}
+	s.broadcast(ctx, subscribers, text)
+}
+
+// broadcast delivers text to the recipients of several exams. Proctors watching
+// several exams get it once.
+func (s *NotificationService) broadcast(ctx context.Context, subscribers []model.ExamNotificationSettings, text string) {
+	sent := make(map[string]bool)
+	for i := range subscribers {
+		settings := subscribers[i]
+		settings.TelegramChatIDs = unsent(settings.TelegramChatIDs, "tg:", sent)
+		settings.WhatsAppNumbers = unsent(settings.WhatsAppNumbers, "wa:", sent)
+		s.deliver(ctx, &settings, text)
+	}
+}
+
+// NotifyGuardiansOfResults tells guardians who opted in that their child's result for
+// a completed exam is available, on each guardian's chosen channel.
+func (s *NotificationService) NotifyGuardiansOfResults(ctx context.Context, examID uuid.UUID) {
+	if !s.Enabled() {
+		return
+	}
+
+	recipients, err := s.repo.ListGuardianResultRecipients(ctx, examID)
+	if err != nil {
+		s.log.Error().Err(err).Str("exam_id", examID.String()).Msg("Failed to list guardian recipients")
+		return
+	}
+
+	for _, r := range recipients {
+		text := fmt.Sprintf("📄 Hasil ujian \"%s\" untuk %s sudah tersedia di portal orang tua.", r.ExamTitle, r.StudentName)
+		var err error
+		switch {
+		case r.Channel == model.NotifyChannelTelegram && s.cfg.TelegramBotToken != "" && r.TelegramChatID != "":
+			err = s.sendTelegram(ctx, r.TelegramChatID, text)
+		case r.Channel == model.NotifyChannelWhatsApp && s.cfg.WhatsAppGatewayURL != "" && r.Phone != nil:
+			err = s.sendWhatsApp(ctx, *r.Phone, text)
+		default:
+			continue
+		}
+		if err != nil {
+			s.log.Warn().Err(err).Int("guardian_id", r.GuardianID).Msg("Failed to notify guardian")
+		}
+	}
+}
+
+// settingsFor returns an exam's settings when it has something to deliver, otherwise nil.
+func (s *NotificationService) settingsFor(ctx context.Context, examID uuid.UUID) *model.ExamNotificationSettings {
+	if !s.Enabled() {
+		return nil
+	}
+	settings, err := s.repo.GetSettings(ctx, examID)
+	if err != nil || !settings.HasRecipients() {
+		return nil
+	}
+	return settings
+}
+
+// deliver sends text and logs, rather than returns, any failure.
+func (s *NotificationService) deliver(ctx context.Context, settings *model.ExamNotificationSettings, text string) {
+	if err := s.send(ctx, settings, text); err != nil {
+		s.log.Warn().Err(err).Str("exam_id", settings.ExamID.String()).Msg("Failed to deliver notification")
+	}
+}
+
+// send delivers text to every recipient on the configured channels, returning the
+// first error after trying them all.
+func (s *NotificationService) send(ctx context.Context, settings *model.ExamNotificationSettings, text string) error {
+	var firstErr error
+	if s.cfg.TelegramBotToken != "" {
+		for _, chatID := range settings.TelegramChatIDs {
+			if err := s.sendTelegram(ctx, chatID, text); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if s.cfg.WhatsAppGatewayURL != "" {
+		for _, phone := range settings.WhatsAppNumbers {
+			if err := s.sendWhatsApp(ctx, phone, text); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (s *NotificationService) sendTelegram(ctx context.Context, chatID, text string) error {
+	url := fmt.Sprintf("%s/bot%s/sendMessage", telegramAPIURL, s.cfg.TelegramBotToken)
+	if err := s.post(ctx, url, "", map[string]string{"chat_id": chatID, "text": text}); err != nil {
+		return fmt.Errorf("telegram: %w", err)
+	}
+	return nil
+}
+
+func (s *NotificationService) sendWhatsApp(ctx context.Context, phone, text string) error {
+	if err := s.post(ctx, s.cfg.WhatsAppGatewayURL, s.cfg.WhatsAppGatewayToken, map[string]string{"phone": phone, "message": text}); err != nil {
+		return fmt.Errorf("whatsapp: %w", err)
+	}
+	return nil
+}
+
+// sendEmail emails text to one recipient, with an optional attachment.
+func (s *NotificationService) sendEmail(to, subject, text string, attachment *emailAttachment) error {
+	msg, err := buildEmail(s.cfg.SMTPFrom, to, subject, text, attachment)
+	if err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+
+	var auth smtp.Auth
+	if s.cfg.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", s.cfg.SMTPUsername, s.cfg.SMTPPassword, s.cfg.SMTPHost)
+	}
+	addr := net.JoinHostPort(s.cfg.SMTPHost, strconv.Itoa(s.cfg.SMTPPort))
+	if err := smtp.SendMail(addr, auth, s.cfg.SMTPFrom, []string{to}, msg); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return nil
+}
+
+func (s *NotificationService) post(ctx context.Context, url, token string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// The Telegram URL embeds the bot token; keep it out of logs.
+		return errors.New("request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// trimAll trims each value and drops empty ones.
+func trimAll(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// unsent returns the recipients not yet in sent and marks them as sent.
+func unsent(recipients []string, prefix string, sent map[string]bool) []string {
+	out := make([]string, 0, len(recipients))
+	for _, r := range recipients {
+		if !sent[prefix+r] {
+			sent[prefix+r] = true
+			out = append(out, r)
+		}
+	}
+	return out
+}
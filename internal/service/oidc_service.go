@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+)
+
+// OIDC login errors.
+var (
+	ErrOIDCNotConfigured = errors.New("oidc login is not configured")
+	ErrOIDCStateInvalid  = errors.New("oidc state is invalid or expired")
+	ErrOIDCTokenInvalid  = errors.New("oidc id token is invalid")
+	ErrOIDCUnverified    = errors.New("oidc email is not verified")
+	ErrOIDCNotLinked     = errors.New("oidc account is not linked to an admin")
+)
+
+// OIDCStateTTL bounds how long a user may take at the identity provider.
+const OIDCStateTTL = 10 * time.Minute
+
+// oidcLogin is what a pending login keeps under its state: the nonce the ID token must
+// carry and the PKCE code verifier the token request proves the login with.
+type oidcLogin struct {
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// oidcDiscovery holds the provider endpoints used by the authorization code flow.
+type oidcDiscovery struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// OIDCService implements admin single sign-on via OpenID Connect (e.g. Google Workspace).
+// Accounts are linked by email; groups from the configured claim map to roles.
+type OIDCService struct {
+	cfg         *config.Config
+	rdb         *redis.Client
+	adminRepo   *repository.AdminRepository
+	roleRepo    *repository.RoleRepository
+	authService *AuthService
+	client      *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+}
+
+// NewOIDCService creates a new OIDCService.
+func NewOIDCService(cfg *config.Config, rdb *redis.Client, adminRepo *repository.AdminRepository, roleRepo *repository.RoleRepository, authService *AuthService) *OIDCService {
+	return &OIDCService{
+		cfg:         cfg,
+		rdb:         rdb,
+		adminRepo:   adminRepo,
+		roleRepo:    roleRepo,
+		authService: authService,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled reports whether OIDC login is configured.
+func (s *OIDCService) Enabled() bool {
+	return s.cfg.OIDCClientID != "" && s.cfg.OIDCRedirectURL != ""
+}
+
+// AuthCodeURL registers a fresh login and returns the provider URL to send the admin
+// to, with its state. The caller keeps the state in the browser, so the callback can
+// only complete a login that browser started.
+func (s *OIDCService) AuthCodeURL(ctx context.Context) (authURL, state string, err error) {
+	if !s.Enabled() {
+		return "", "", ErrOIDCNotConfigured
+	}
+
+	disc, err := s.discover(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err = randomToken()
+	if err != nil {
+		return "", "", fmt.Errorf("generate state: %w", err)
+	}
+	login := oidcLogin{}
+	if login.Nonce, err = randomToken(); err != nil {
+		return "", "", fmt.Errorf("generate nonce: %w", err)
+	}
+	if login.Verifier, err = randomToken(); err != nil {
+		return "", "", fmt.Errorf("generate code verifier: %w", err)
+	}
+	data, err := json.Marshal(login)
+	if err != nil {
+		return "", "", fmt.Errorf("marshal login: %w", err)
+	}
+	if err := s.rdb.Set(ctx, config.CacheKey.OIDCStateKey(state), data, OIDCStateTTL).Err(); err != nil {
+		return "", "", fmt.Errorf("store state: %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(login.Verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {s.cfg.OIDCClientID},
+		"redirect_uri":          {s.cfg.OIDCRedirectURL},
+		"scope":                 {"openid email profile"},
+		"state":                 {state},
+		"nonce":                 {login.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	return disc.AuthorizationEndpoint + "?" + params.Encode(), state, nil
+}
+
+// Authenticate completes the authorization code flow and returns the linked admin.
+// browserState is the state the browser kept when the login started; it must match
+// state. An admin whose groups map to a role gets that role; an unknown email is
+// provisioned only when one of its groups is mapped.
+func (s *OIDCService) Authenticate(ctx context.Context, code, state, browserState string) (*model.Admin, error) {
+	if !s.Enabled() {
+		return nil, ErrOIDCNotConfigured
+	}
+	if subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	// A state is single-use, so a replayed callback is rejected.
+	data, err := s.rdb.GetDel(ctx, config.CacheKey.OIDCStateKey(state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrOIDCStateInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("check state: %w", err)
+	}
+	var login oidcLogin
+	if err := json.Unmarshal(data, &login); err != nil || login.Nonce == "" || login.Verifier == "" {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	disc, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, idToken, err := s.exchangeCode(ctx, disc.TokenEndpoint, code, login.Verifier)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkIDToken(idToken, login.Nonce); err != nil {
+		return nil, err
+	}
+
+	info, err := s.fetchUserinfo(ctx, disc.UserinfoEndpoint, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	email, _ := info["email"].(string)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if verified, _ := info["email_verified"].(bool); email == "" || !verified {
+		return nil, ErrOIDCUnverified
+	}
+
+	roleID, mapped, err := s.mappedRoleID(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := s.adminRepo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	if admin == nil {
+		if !mapped {
+			return nil, ErrOIDCNotLinked
+		}
+		name, _ := info["name"].(string)
+		// SSO-provisioned admins get an unguessable password until one is set for them.
+		hash, err := s.authService.HashPassword(uuid.New().String())
+		if err != nil {
+			return nil, fmt.Errorf("hash password: %w", err)
+		}
+		created := &model.Admin{Username: email, Email: email, Name: name, PasswordHash: hash, RoleID: roleID}
+		if err := s.adminRepo.Create(ctx, created); err != nil {
+			return nil, fmt.Errorf("create admin: %w", err)
+		}
+		return s.adminRepo.GetByID(ctx, created.ID)
+	}
+
+	if mapped && admin.RoleID != roleID {
+		if err := s.adminRepo.UpdateRole(ctx, admin.ID, roleID); err != nil {
+			return nil, fmt.Errorf("update role: %w", err)
+		}
+		return s.adminRepo.GetByID(ctx, admin.ID)
+	}
+
+	return admin, nil
+}
+
+// mappedRoleID resolves the first of the user's groups that has a configured role.
+func (s *OIDCService) mappedRoleID(ctx context.Context, info map[string]interface{}) (int, bool, error) {
+	groups, _ := info[s.cfg.OIDCGroupsClaim].([]interface{})
+	for _, g := range groups {
+		group, _ := g.(string)
+		roleName, ok := s.cfg.OIDCRoleMapping[group]
+		if !ok {
+			continue
+		}
+		roleID, err := s.roleRepo.GetRoleIDByName(ctx, roleName)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return 0, false, fmt.Errorf("resolve role %q: %w", roleName, err)
+		}
+		return roleID, true, nil
+	}
+	return 0, false, nil
+}
+
+// discover fetches and caches the provider's OpenID configuration.
+func (s *OIDCService) discover(ctx context.Context) (*oidcDiscovery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.discovery != nil {
+		return s.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.OIDCIssuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create discovery request: %w", err)
+	}
+
+	var disc oidcDiscovery
+	if err := s.doJSON(req, &disc); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if disc.AuthorizationEndpoint == "" || disc.TokenEndpoint == "" || disc.UserinfoEndpoint == "" {
+		return nil, errors.New("oidc discovery: missing endpoints")
+	}
+
+	s.discovery = &disc
+	return s.discovery, nil
+}
+
+// exchangeCode trades an authorization code for an access token and an ID token,
+// proving with verifier that this server started the login.
+func (s *OIDCService) exchangeCode(ctx context.Context, endpoint, code, verifier string) (accessToken, idToken string, err error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {s.cfg.OIDCRedirectURL},
+		"client_id":     {s.cfg.OIDCClientID},
+		"client_secret": {s.cfg.OIDCClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", "", fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		IDToken     string `json:"id_token"`
+	}
+	if err := s.doJSON(req, &token); err != nil {
+		return "", "", fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if token.AccessToken == "" || token.IDToken == "" {
+		return "", "", errors.New("oidc token exchange: missing access or id token")
+	}
+	return token.AccessToken, token.IDToken, nil
+}
+
+// checkIDToken verifies the ID token was issued for this login: by the configured
+// issuer, to this client, unexpired and carrying the login's nonce. The token comes
+// straight from the token endpoint over TLS, which OpenID Connect accepts in place of
+// checking its signature.
+func (s *OIDCService) checkIDToken(idToken, nonce string) error {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(idToken, claims); err != nil {
+		return fmt.Errorf("%w: %v", ErrOIDCTokenInvalid, err)
+	}
+
+	if iss, _ := claims.GetIssuer(); strings.TrimSuffix(iss, "/") != s.cfg.OIDCIssuerURL {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrOIDCTokenInvalid, iss)
+	}
+	if aud, _ := claims.GetAudience(); !slices.Contains(aud, s.cfg.OIDCClientID) {
+		return fmt.Errorf("%w: issued to another client", ErrOIDCTokenInvalid)
+	}
+	if exp, _ := claims.GetExpirationTime(); exp == nil || time.Now().After(exp.Time) {
+		return fmt.Errorf("%w: expired", ErrOIDCTokenInvalid)
+	}
+	got, _ := claims["nonce"].(string)
+	if subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+		return fmt.Errorf("%w: nonce mismatch", ErrOIDCTokenInvalid)
+	}
+	return nil
+}
+
+// fetchUserinfo reads the authenticated user's claims from the userinfo endpoint.
+func (s *OIDCService) fetchUserinfo(ctx context.Context, endpoint, accessToken string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create userinfo request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	info := make(map[string]interface{})
+	if err := s.doJSON(req, &info); err != nil {
+		return nil, fmt.Errorf("oidc userinfo: %w", err)
+	}
+	return info, nil
+}
+
+func (s *OIDCService) doJSON(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("provider returned status: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// randomToken returns 32 random bytes, URL-safe encoded.
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrWeakPassword is returned when a password set by an admin or a student does not
+// meet the password policy. The wrapping error lists what is missing.
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// commonPasswords are rejected whatever the policy's length and character classes,
+// compared case-insensitively. They are the passwords tried first when guessing,
+// including the ones common at Indonesian schools.
+var commonPasswords = map[string]bool{
+	"123456": true, "1234567": true, "12345678": true, "123456789": true, "1234567890": true,
+	"654321": true, "111111": true, "000000": true, "123123": true, "112233": true,
+	"121212": true, "123321": true, "666666": true, "888888": true, "999999": true,
+	"password": true, "password1": true, "password123": true, "passw0rd": true, "p@ssw0rd": true,
+	"qwerty": true, "qwerty123": true, "qwertyuiop": true, "asdfgh": true, "zxcvbn": true,
+	"abc123": true, "abcdef": true, "iloveyou": true, "welcome": true, "letmein": true,
+	"admin": true, "admin123": true, "administrator": true, "root": true, "secret": true,
+	"bismillah": true, "indonesia": true, "sayang": true, "sayangku": true, "rahasia": true,
+	"sekolah": true, "siswa": true, "guru": true, "ujian": true, "merdeka": true,
+	"exstem": true, "exstem123": true, "stemsi": true, "stemsi123": true,
+}
+
+// CheckPasswordPolicy checks a new password against the configured policy: its
+// minimum length, the number of character classes (lowercase, uppercase, digits,
+// symbols) it must mix, the common passwords list, and the account's identifiers,
+// such as a student's NIS and NISN, which others know. It returns ErrWeakPassword
+// wrapped with every rule the password breaks.
+func (s *AuthService) CheckPasswordPolicy(password string, identifiers ...string) error {
+	var problems []string
+
+	if n := len([]rune(password)); n < s.cfg.PasswordMinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters", s.cfg.PasswordMinLength))
+	}
+	if s.cfg.PasswordMinCharClasses > 1 && passwordCharClasses(password) < s.cfg.PasswordMinCharClasses {
+		problems = append(problems, fmt.Sprintf("must mix at least %d of lowercase letters, uppercase letters, digits and symbols", s.cfg.PasswordMinCharClasses))
+	}
+	if commonPasswords[strings.ToLower(password)] {
+		problems = append(problems, "must not be a common password")
+	}
+	for _, id := range identifiers {
+		if id != "" && strings.EqualFold(password, strings.TrimSpace(id)) {
+			problems = append(problems, "must not be the account's username, NIS or NISN")
+			break
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrWeakPassword, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// passwordCharClasses counts the character classes a password mixes.
+func passwordCharClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	n := 0
+	for _, ok := range []bool{lower, upper, digit, symbol} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// Bulk question errors.
+var (
+	// ErrQuestionsNotInQBank is returned when a bulk operation names a question
+	// outside the qbank, or names one twice.
+	ErrQuestionsNotInQBank = errors.New("questions do not belong to the question bank")
+	// ErrIncompleteOrder is returned when a reorder does not list every question once.
+	ErrIncompleteOrder = errors.New("order must list every question of the question bank once")
+	// ErrSameQBank is returned when questions are transferred to their own qbank.
+	ErrSameQBank = errors.New("target is the same question bank")
+)
+
+// Reorder sets the order of a qbank's questions to that of ids.
+func (s *QuestionService) Reorder(ctx context.Context, qbankID uuid.UUID, ids []uuid.UUID) error {
+	if err := s.checkQBankScope(ctx, qbankID, qbankWritePerms...); err != nil {
+		return err
+	}
+	ok, err := s.questionRepo.Reorder(ctx, qbankID, ids)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrIncompleteOrder
+	}
+	return nil
+}
+
+// DeleteMany removes questions from a qbank, all or none.
+func (s *QuestionService) DeleteMany(ctx context.Context, qbankID uuid.UUID, ids []uuid.UUID) error {
+	if err := s.checkQBankScope(ctx, qbankID, qbankWritePerms...); err != nil {
+		return err
+	}
+	ok, err := s.questionRepo.DeleteMany(ctx, qbankID, ids)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrQuestionsNotInQBank
+	}
+	return nil
+}
+
+// Transfer moves or copies questions to the end of another qbank, all or none.
+func (s *QuestionService) Transfer(ctx context.Context, qbankID uuid.UUID, req model.TransferQuestionsRequest) error {
+	if req.TargetQBankID == qbankID {
+		return ErrSameQBank
+	}
+	// Copying only reads the source; moving changes both banks.
+	if req.Mode == model.TransferMove {
+		if err := s.checkQBankScope(ctx, qbankID, qbankWritePerms...); err != nil {
+			return err
+		}
+	}
+	if err := s.checkQBankScope(ctx, req.TargetQBankID, qbankWritePerms...); err != nil {
+		return err
+	}
+
+	ok, err := s.questionRepo.Transfer(ctx, qbankID, req.TargetQBankID, req.QuestionIDs, req.Mode == model.TransferCopy)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrQuestionsNotInQBank
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// duplicateSimilarity is the trigram similarity from which two question texts count
+// as near-duplicates.
+const duplicateSimilarity = 0.8
+
+// ErrDuplicateQuestion is returned when new questions are near-duplicates of questions
+// already in the qbank and duplicates were not allowed.
+var ErrDuplicateQuestion = errors.New("question bank already holds a similar question")
+
+// findDuplicates returns the questions of the qbank that the new questions duplicate.
+func (s *QuestionService) findDuplicates(ctx context.Context, qbankID uuid.UUID, questions []model.Question) ([]model.QuestionDuplicate, error) {
+	if len(questions) == 0 {
+		return nil, nil
+	}
+	texts := make([]string, len(questions))
+	for i, q := range questions {
+		texts[i] = q.QuestionText
+	}
+	dups, err := s.questionRepo.FindDuplicates(ctx, qbankID, texts, duplicateSimilarity)
+	if err != nil {
+		return nil, fmt.Errorf("find duplicates: %w", err)
+	}
+	return dups, nil
+}
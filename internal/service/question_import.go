@@ -0,0 +1,285 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// MaxImportDocBytes caps the size of an uploaded question document.
+const MaxImportDocBytes = 10 << 20
+
+// maxDocxXMLBytes caps the decompressed word/document.xml of a .docx, as the upload
+// limit only bounds its compressed size.
+const maxDocxXMLBytes = 50 << 20
+
+// ErrImportHasIssues is returned when a confirmed import still contains parse issues.
+var ErrImportHasIssues = errors.New("import document has issues")
+
+// Document convention (Markdown, plain text or .docx paragraphs):
+//
+//	Latihan Matematika
+//
+//	1. Berapakah 2 + 2?
+//	A. 3
+//	B. 4
+//	C. 5
+//	D. 6
+//	Jawaban: B
+//
+// A question starts with a number followed by "." or ")". Lines up to the
+// first option continue the question text. Options are lettered A–E. The answer
+// is given by a "Jawaban:", "Kunci:" or "Answer:" line, or by ending an option with "*".
+// Text before the first question (titles, instructions) is ignored.
+var (
+	importQuestionRe = regexp.MustCompile(`^\s*\d+\s*[.)]\s+(.*)$`)
+	importOptionRe   = regexp.MustCompile(`^\s*([A-Ea-e])\s*[.)]\s+(.*)$`)
+	importAnswerRe   = regexp.MustCompile(`(?i)^\s*(?:jawaban|kunci|answer)\s*:\s*([A-E])\s*$`)
+)
+
+// ImportDocument parses a question document and, when confirm is set,
+// appends the parsed questions to the qbank. Without confirm it only returns a preview.
+// Questions resembling ones already in the qbank are listed as duplicates and block a
+// confirmed import unless allowDuplicates is set.
+func (s *QuestionService) ImportDocument(ctx context.Context, qbankID uuid.UUID, filename string, r io.Reader, confirm, allowDuplicates bool) (*model.ImportQuestionsResult, error) {
+	if err := s.checkQBankScope(ctx, qbankID, qbankWritePerms...); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, MaxImportDocBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read document: %w", err)
+	}
+	if len(data) > MaxImportDocBytes {
+		return nil, fmt.Errorf("%w: max %d bytes", ErrFileTooLarge, MaxImportDocBytes)
+	}
+
+	var text string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".docx":
+		text, err = docxToText(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedFileType, err)
+		}
+	case ".md", ".txt":
+		text = string(data)
+	default:
+		return nil, fmt.Errorf("%w: %s (allowed: .docx, .md, .txt)", ErrUnsupportedFileType, filepath.Ext(filename))
+	}
+
+	questions, issues := parseQuestionDocument(text)
+	for i := range questions {
+		questions[i].QBankID = qbankID
+		if err := sanitizeQuestion(&questions[i]); err != nil {
+			issues = append(issues, model.ImportIssue{Question: questions[i].OrderNum, Message: err.Error()})
+		}
+	}
+
+	dups, err := s.findDuplicates(ctx, qbankID, questions)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ImportQuestionsResult{Questions: questions, Issues: issues, Duplicates: dups}
+	if !confirm {
+		return result, nil
+	}
+	if len(issues) > 0 || len(questions) == 0 {
+		return result, ErrImportHasIssues
+	}
+	if len(dups) > 0 && !allowDuplicates {
+		return result, ErrDuplicateQuestion
+	}
+
+	if err := s.questionRepo.AppendBatch(ctx, qbankID, questions); err != nil {
+		return nil, err
+	}
+	result.Imported = true
+	return result, nil
+}
+
+// parseQuestionDocument turns document text into multiple-choice questions.
+// Issues are collected rather than aborting so the preview can show them all at once.
+func parseQuestionDocument(text string) ([]model.Question, []model.ImportIssue) {
+	type draft struct {
+		line    int
+		text    []string
+		options []model.QuestionOption
+		answer  string
+	}
+
+	var (
+		drafts  []*draft
+		issues  []model.ImportIssue
+		current *draft
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if m := importQuestionRe.FindStringSubmatch(line); m != nil {
+			current = &draft{line: lineNum, text: []string{m[1]}}
+			drafts = append(drafts, current)
+			continue
+		}
+
+		if current == nil {
+			continue // titles and instructions before the first question
+		}
+
+		if m := importAnswerRe.FindStringSubmatch(line); m != nil {
+			current.answer = strings.ToUpper(m[1])
+			continue
+		}
+
+		if m := importOptionRe.FindStringSubmatch(line); m != nil {
+			key := strings.ToUpper(m[1])
+			optText := strings.TrimSpace(m[2])
+			if strings.HasSuffix(optText, "*") {
+				optText = strings.TrimSpace(strings.TrimSuffix(optText, "*"))
+				current.answer = key
+			}
+			current.options = append(current.options, model.QuestionOption{Key: key, Text: html.EscapeString(optText)})
+			continue
+		}
+
+		if len(current.options) > 0 {
+			// Continuation of the previous option.
+			last := &current.options[len(current.options)-1]
+			last.Text += "<br>" + html.EscapeString(line)
+			continue
+		}
+		current.text = append(current.text, line)
+	}
+
+	questions := make([]model.Question, 0, len(drafts))
+	for i, d := range drafts {
+		num := i + 1
+		if len(d.options) < 2 {
+			issues = append(issues, model.ImportIssue{Question: num, Line: d.line, Message: "at least two options (A–E) are required"})
+			continue
+		}
+		if d.answer == "" {
+			issues = append(issues, model.ImportIssue{Question: num, Line: d.line, Message: "answer marker is missing"})
+			continue
+		}
+
+		seen := make(map[string]bool, len(d.options))
+		valid := true
+		for _, o := range d.options {
+			if seen[o.Key] {
+				issues = append(issues, model.ImportIssue{Question: num, Line: d.line, Message: "duplicate option " + o.Key})
+				valid = false
+				break
+			}
+			seen[o.Key] = true
+		}
+		if !valid {
+			continue
+		}
+		if !seen[d.answer] {
+			issues = append(issues, model.ImportIssue{Question: num, Line: d.line, Message: "answer " + d.answer + " does not match any option"})
+			continue
+		}
+
+		paragraphs := make([]string, len(d.text))
+		for j, t := range d.text {
+			paragraphs[j] = html.EscapeString(t)
+		}
+		options, _ := json.Marshal(d.options)
+
+		questions = append(questions, model.Question{
+			QuestionText:  "<p>" + strings.Join(paragraphs, "<br>") + "</p>",
+			QuestionType:  model.QuestionTypeMultipleChoice,
+			Options:       options,
+			CorrectOption: d.answer,
+			OrderNum:      num,
+		})
+	}
+
+	return questions, issues
+}
+
+// docxToText extracts paragraph text from a .docx (word/document.xml), one paragraph per line.
+func docxToText(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("open docx: %w", err)
+	}
+
+	var doc io.ReadCloser
+	for _, f := range zr.File {
+		if f.Name == "word/document.xml" {
+			if f.UncompressedSize64 > maxDocxXMLBytes {
+				return "", fmt.Errorf("document.xml is larger than %d bytes", maxDocxXMLBytes)
+			}
+			doc, err = f.Open()
+			if err != nil {
+				return "", fmt.Errorf("open document.xml: %w", err)
+			}
+			break
+		}
+	}
+	if doc == nil {
+		return "", errors.New("word/document.xml not found")
+	}
+	defer doc.Close()
+
+	// The declared size can lie; a document cut off at the limit fails to parse.
+	var b strings.Builder
+	dec := xml.NewDecoder(io.LimitReader(doc, maxDocxXMLBytes))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("parse document.xml: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+
+	return b.String(), nil
+}
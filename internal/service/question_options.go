@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// maxOptionKeyLength matches the length allowed for correct_option.
+const maxOptionKeyLength = 10
+
+// Option errors.
+var (
+	ErrInvalidOption       = errors.New("invalid option")
+	ErrOptionMediaNotFound = errors.New("option media does not exist")
+)
+
+// normalizeOptions rewrites a multiple-choice question's options as keyed option
+// objects so answers are graded by option key rather than position. Plain string
+// options become options keyed A, B, …, and a 0-based index correct_option becomes
+// the matching letter. Keys are upper-cased and must not be numbers, which would read
+// as positions; every option needs a unique key and text or media. Other question types are left as they are.
+func normalizeOptions(q *model.Question) error {
+	if q.QuestionType != model.QuestionTypeMultipleChoice {
+		return nil
+	}
+
+	var options []model.QuestionOption
+	var plain []string
+	if err := json.Unmarshal(q.Options, &plain); err == nil {
+		if len(plain) > 26 {
+			return fmt.Errorf("%w: at most 26 options without keys", ErrInvalidOption)
+		}
+		options = make([]model.QuestionOption, len(plain))
+		for i, text := range plain {
+			options[i] = model.QuestionOption{Key: string(rune('A' + i)), Text: text}
+		}
+		if idx, err := strconv.Atoi(strings.TrimSpace(q.CorrectOption)); err == nil && idx >= 0 && idx < len(plain) {
+			q.CorrectOption = options[idx].Key
+		}
+	} else if err := json.Unmarshal(q.Options, &options); err != nil {
+		return fmt.Errorf("%w: options must be a list of strings or of {key, text, media_id} objects", ErrInvalidOption)
+	}
+
+	seen := make(map[string]bool, len(options))
+	for i := range options {
+		o := &options[i]
+		o.Key = strings.ToUpper(strings.TrimSpace(o.Key))
+		switch {
+		case o.Key == "":
+			return fmt.Errorf("%w: option %d has no key", ErrInvalidOption, i+1)
+		case len(o.Key) > maxOptionKeyLength:
+			return fmt.Errorf("%w: option key %q is longer than %d characters", ErrInvalidOption, o.Key, maxOptionKeyLength)
+		case isDigits(o.Key):
+			return fmt.Errorf("%w: option key %q must not be a number, which reads as a position", ErrInvalidOption, o.Key)
+		case seen[o.Key]:
+			return fmt.Errorf("%w: option key %q is used twice", ErrInvalidOption, o.Key)
+		case strings.TrimSpace(o.Text) == "" && o.MediaID == nil:
+			return fmt.Errorf("%w: option %s needs text or media", ErrInvalidOption, o.Key)
+		}
+		seen[o.Key] = true
+		// Derived from MediaID when the exam is published; never stored.
+		o.MediaURL, o.MediaType = "", ""
+	}
+
+	raw, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	q.Options = raw
+	q.CorrectOption = strings.ToUpper(strings.TrimSpace(q.CorrectOption))
+	return nil
+}
+
+// isDigits reports whether s consists only of digits.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// optionMediaIDs lists the media attached to the options of questions.
+func optionMediaIDs(questions []model.Question) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, q := range questions {
+		for _, o := range keyedOptions(q) {
+			if o.MediaID != nil {
+				ids = append(ids, *o.MediaID)
+			}
+		}
+	}
+	return ids
+}
+
+// keyedOptions returns a question's options when they are keyed option objects.
+func keyedOptions(q model.Question) []model.QuestionOption {
+	var options []model.QuestionOption
+	if err := json.Unmarshal(q.Options, &options); err != nil || len(options) == 0 || options[0].Key == "" {
+		return nil
+	}
+	return options
+}
+
+// checkOptionMedia verifies that the media attached to options exists.
+func (s *QuestionService) checkOptionMedia(ctx context.Context, questions []model.Question) error {
+	ids := optionMediaIDs(questions)
+	if len(ids) == 0 {
+		return nil
+	}
+	files, err := s.mediaRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, ok := files[id]; !ok {
+			return fmt.Errorf("%w: %s", ErrOptionMediaNotFound, id)
+		}
+	}
+	return nil
+}
+
+// resolveOptionMedia fills in the URL and type of the media attached to options, for
+// the student payload.
+func (s *ExamService) resolveOptionMedia(ctx context.Context, questions []model.Question) error {
+	ids := optionMediaIDs(questions)
+	if len(ids) == 0 {
+		return nil
+	}
+	files, err := s.mediaRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("get option media: %w", err)
+	}
+
+	for i := range questions {
+		options := keyedOptions(questions[i])
+		changed := false
+		for j := range options {
+			o := &options[j]
+			if o.MediaID == nil {
+				continue
+			}
+			if f, ok := files[*o.MediaID]; ok {
+				o.MediaURL = f.URL
+				o.MediaType, _, _ = strings.Cut(f.MimeType, "/")
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		raw, err := json.Marshal(options)
+		if err != nil {
+			return err
+		}
+		questions[i].Options = raw
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stemsi/exstem-backend/internal/helper"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// Passage errors.
+var (
+	ErrPassageNotFound = errors.New("passage not found")
+	// ErrPassageNotInQBank is returned when a question refers to a passage of another qbank.
+	ErrPassageNotInQBank = errors.New("passage does not belong to the question bank")
+)
+
+// ListPassages retrieves the passages of a qbank.
+func (s *QuestionService) ListPassages(ctx context.Context, qbankID uuid.UUID) ([]model.Passage, error) {
+	passages, err := s.questionRepo.ListPassages(ctx, qbankID)
+	if err != nil {
+		return nil, err
+	}
+	return passages, nil
+}
+
+// CreatePassage adds a passage to a qbank.
+func (s *QuestionService) CreatePassage(ctx context.Context, p *model.Passage) error {
+	if err := s.checkQBankScope(ctx, p.QBankID, qbankWritePerms...); err != nil {
+		return err
+	}
+	p.Content = helper.SanitizeHTML(p.Content)
+	return s.questionRepo.CreatePassage(ctx, p)
+}
+
+// UpdatePassage changes a passage's title and content.
+func (s *QuestionService) UpdatePassage(ctx context.Context, p *model.Passage) error {
+	if err := s.checkQBankScope(ctx, p.QBankID, qbankWritePerms...); err != nil {
+		return err
+	}
+	p.Content = helper.SanitizeHTML(p.Content)
+	if err := s.questionRepo.UpdatePassage(ctx, p); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPassageNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// DeletePassage removes a passage. Questions that referred to it stay in the qbank.
+func (s *QuestionService) DeletePassage(ctx context.Context, qbankID, passageID uuid.UUID) error {
+	if err := s.checkQBankScope(ctx, qbankID, qbankWritePerms...); err != nil {
+		return err
+	}
+	deleted, err := s.questionRepo.DeletePassage(ctx, qbankID, passageID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPassageNotFound
+	}
+	return nil
+}
+
+// checkPassages verifies that every passage the questions refer to belongs to qbankID.
+func (s *QuestionService) checkPassages(ctx context.Context, qbankID uuid.UUID, questions []model.Question) error {
+	checked := make(map[uuid.UUID]bool)
+	for i, q := range questions {
+		if q.PassageID == nil || checked[*q.PassageID] {
+			continue
+		}
+		p, err := s.questionRepo.GetPassage(ctx, *q.PassageID)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && p.QBankID != qbankID) {
+			return fmt.Errorf("question %d: %w", i, ErrPassageNotInQBank)
+		}
+		if err != nil {
+			return err
+		}
+		checked[*q.PassageID] = true
+	}
+	return nil
+}
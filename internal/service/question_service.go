@@ -2,21 +2,28 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/helper"
 	"github.com/stemsi/exstem-backend/internal/model"
 	"github.com/stemsi/exstem-backend/internal/repository"
 	"github.com/stemsi/exstem-backend/internal/response"
 )
 
+// ErrInvalidOptions is returned when question options are not valid JSON.
+var ErrInvalidOptions = errors.New("invalid question options")
+
 // QuestionService handles question business logic.
 type QuestionService struct {
 	questionRepo *repository.QuestionRepository
+	mediaRepo    *repository.MediaRepository
 }
 
 // NewQuestionService creates a new QuestionService.
-func NewQuestionService(questionRepo *repository.QuestionRepository) *QuestionService {
-	return &QuestionService{questionRepo: questionRepo}
+func NewQuestionService(questionRepo *repository.QuestionRepository, mediaRepo *repository.MediaRepository) *QuestionService {
+	return &QuestionService{questionRepo: questionRepo, mediaRepo: mediaRepo}
 }
 
 // ListQBanks retrieves question banks with pagination.
@@ -48,20 +55,7 @@ func (s *QuestionService) ListQBanks(ctx context.Context, authorID *int, page, p
 		return nil, nil, err
 	}
 
-	if qbanks == nil {
-		qbanks = []model.QuestionBank{}
-	}
-
-	totalPages := (total + perPage - 1) / perPage
-
-	pagination := &response.Pagination{
-		Page:       page,
-		PerPage:    perPage,
-		TotalItems: total,
-		TotalPages: totalPages,
-	}
-
-	return qbanks, pagination, nil
+	return qbanks, response.NewPagination(page, perPage, total), nil
 }
 
 // GetQBanks retrieves a specific question bank.
@@ -71,16 +65,29 @@ func (s *QuestionService) GetQBanks(ctx context.Context, qbankID uuid.UUID) (*mo
 
 // CreateQBanks creates a new question bank.
 func (s *QuestionService) CreateQBanks(ctx context.Context, qbank *model.QuestionBank) error {
+	if err := checkSubjectScope(ctx, qbank.SubjectID, qbankWritePerms...); err != nil {
+		return err
+	}
 	return s.questionRepo.CreateQBanks(ctx, qbank)
 }
 
 // UpdateQBanks updates a specific question bank.
 func (s *QuestionService) UpdateQBanks(ctx context.Context, qbank *model.QuestionBank) error {
+	// Moving a bank requires scope on both its current and its new subject.
+	if err := s.checkQBankScope(ctx, qbank.ID, qbankWritePerms...); err != nil {
+		return err
+	}
+	if err := checkSubjectScope(ctx, qbank.SubjectID, qbankWritePerms...); err != nil {
+		return err
+	}
 	return s.questionRepo.UpdateQBanks(ctx, qbank)
 }
 
 // DeleteQBanks deletes a specific question bank.
 func (s *QuestionService) DeleteQBanks(ctx context.Context, qbankID uuid.UUID) error {
+	if err := s.checkQBankScope(ctx, qbankID, qbankWritePerms...); err != nil {
+		return err
+	}
 	return s.questionRepo.DeleteQBanks(ctx, qbankID)
 }
 
@@ -89,15 +96,70 @@ func (s *QuestionService) ListByQBank(ctx context.Context, qbankID uuid.UUID) ([
 	return s.questionRepo.ListByQBank(ctx, qbankID)
 }
 
-// Create adds a question to an qbank.
-func (s *QuestionService) Create(ctx context.Context, question *model.Question) error {
-	return s.questionRepo.Create(ctx, question)
+// Create adds a question to an qbank. A question resembling one already in the qbank
+// is rejected with ErrDuplicateQuestion unless allowDuplicate is set; the returned
+// duplicates list the resemblance either way.
+func (s *QuestionService) Create(ctx context.Context, question *model.Question, allowDuplicate bool) ([]model.QuestionDuplicate, error) {
+	if err := s.checkQBankScope(ctx, question.QBankID, qbankWritePerms...); err != nil {
+		return nil, err
+	}
+	if err := sanitizeQuestion(question); err != nil {
+		return nil, err
+	}
+	if err := s.checkPassages(ctx, question.QBankID, []model.Question{*question}); err != nil {
+		return nil, err
+	}
+	if err := s.checkOptionMedia(ctx, []model.Question{*question}); err != nil {
+		return nil, err
+	}
+	dups, err := s.findDuplicates(ctx, question.QBankID, []model.Question{*question})
+	if err != nil {
+		return nil, err
+	}
+	if len(dups) > 0 && !allowDuplicate {
+		return dups, ErrDuplicateQuestion
+	}
+	return dups, s.questionRepo.Create(ctx, question)
 }
 
 // ReplaceAll replaces all questions for an qbank
 func (s *QuestionService) ReplaceAll(ctx context.Context, qBankID uuid.UUID, questions []model.Question) error {
+	if err := s.checkQBankScope(ctx, qBankID, qbankWritePerms...); err != nil {
+		return err
+	}
 	for i := range questions {
 		questions[i].QBankID = qBankID
+		if err := sanitizeQuestion(&questions[i]); err != nil {
+			return fmt.Errorf("question %d: %w", i, err)
+		}
+	}
+	if err := s.checkPassages(ctx, qBankID, questions); err != nil {
+		return err
+	}
+	if err := s.checkOptionMedia(ctx, questions); err != nil {
+		return err
 	}
 	return s.questionRepo.ReplaceAll(ctx, qBankID, questions)
 }
+
+// sanitizeQuestion strips disallowed HTML from the question text, the explanation and
+// option strings, normalizes multiple-choice options to keyed options, validates the
+// LaTeX math source and checks the translations before anything reaches the database.
+func sanitizeQuestion(q *model.Question) error {
+	if err := helper.ValidateLatex(q.MathLatex); err != nil {
+		return err
+	}
+
+	options, err := helper.SanitizeJSONStrings(q.Options)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+	}
+
+	q.QuestionText = helper.SanitizeHTML(q.QuestionText)
+	q.Explanation = helper.SanitizeHTML(q.Explanation)
+	q.Options = options
+	if err := normalizeOptions(q); err != nil {
+		return err
+	}
+	return sanitizeTranslations(q)
+}
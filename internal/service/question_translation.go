@@ -0,0 +1,256 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stemsi/exstem-backend/internal/helper"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+var (
+	// ErrInvalidTranslation is returned when a question's translations are malformed.
+	ErrInvalidTranslation = errors.New("invalid question translation")
+	// ErrInvalidLanguage is returned when an exam's languages are malformed.
+	ErrInvalidLanguage = errors.New("invalid exam language")
+	// ErrTranslationMissing is returned when publishing an exam whose questions lack
+	// a translation into one of its languages. The wrapping error lists them.
+	ErrTranslationMissing = errors.New("question translations are missing")
+)
+
+// languagePattern is the form of language codes: ISO 639-1, optionally with a region
+// (en, id, ar, zh-cn).
+var languagePattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]{2})?$`)
+
+// normalizeLanguage lower-cases and trims a language code.
+func normalizeLanguage(lang string) string {
+	return strings.ToLower(strings.TrimSpace(lang))
+}
+
+// sanitizeTranslations normalizes the language codes of a question's translations,
+// strips disallowed HTML from them like from the question itself and checks their
+// options against the question's: a translated option must exist.
+func sanitizeTranslations(q *model.Question) error {
+	if len(q.Translations) == 0 {
+		q.Translations = map[string]model.QuestionTranslation{}
+		return nil
+	}
+
+	keys := optionTranslationKeys(*q)
+	translations := make(map[string]model.QuestionTranslation, len(q.Translations))
+	for lang, t := range q.Translations {
+		lang = normalizeLanguage(lang)
+		if !languagePattern.MatchString(lang) {
+			return fmt.Errorf("%w: %q is not a language code", ErrInvalidTranslation, lang)
+		}
+		if _, ok := translations[lang]; ok {
+			return fmt.Errorf("%w: %s is given twice", ErrInvalidTranslation, lang)
+		}
+		if err := helper.ValidateLatex(t.MathLatex); err != nil {
+			return err
+		}
+
+		t.QuestionText = helper.SanitizeHTML(t.QuestionText)
+		t.Explanation = helper.SanitizeHTML(t.Explanation)
+		if strings.TrimSpace(t.QuestionText) == "" {
+			return fmt.Errorf("%w: %s has no question_text", ErrInvalidTranslation, lang)
+		}
+		options := make(map[string]string, len(t.Options))
+		for key, text := range t.Options {
+			key = strings.ToUpper(strings.TrimSpace(key))
+			if !slices.Contains(keys, key) {
+				return fmt.Errorf("%w: %s translates option %s, which the question does not have", ErrInvalidTranslation, lang, key)
+			}
+			options[key] = helper.SanitizeHTML(text)
+		}
+		t.Options = options
+		translations[lang] = t
+	}
+	q.Translations = translations
+	return nil
+}
+
+// optionTranslationKeys lists the keys translations refer to a question's options by:
+// their keys, or the letters A, B, … of options without keys.
+func optionTranslationKeys(q model.Question) []string {
+	if options := keyedOptions(q); options != nil {
+		keys := make([]string, len(options))
+		for i, o := range options {
+			keys[i] = o.Key
+		}
+		return keys
+	}
+	var plain []string
+	if err := json.Unmarshal(q.Options, &plain); err != nil {
+		return nil
+	}
+	keys := make([]string, len(plain))
+	for i := range plain {
+		keys[i] = string(rune('A' + i))
+	}
+	return keys
+}
+
+// checkExamLanguages normalizes an exam's languages: the language questions are
+// written in defaults to DefaultLanguage, translations must be distinct from it and
+// from each other, and a forced language must be one the exam offers.
+func checkExamLanguages(exam *model.Exam) error {
+	exam.Language = normalizeLanguage(exam.Language)
+	if exam.Language == "" {
+		exam.Language = model.DefaultLanguage
+	}
+	if !languagePattern.MatchString(exam.Language) {
+		return fmt.Errorf("%w: %q is not a language code", ErrInvalidLanguage, exam.Language)
+	}
+
+	languages := make([]string, 0, len(exam.Languages))
+	for _, lang := range exam.Languages {
+		lang = normalizeLanguage(lang)
+		switch {
+		case !languagePattern.MatchString(lang):
+			return fmt.Errorf("%w: %q is not a language code", ErrInvalidLanguage, lang)
+		case lang == exam.Language || slices.Contains(languages, lang):
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidLanguage, lang)
+		}
+		languages = append(languages, lang)
+	}
+	exam.Languages = languages
+
+	exam.ForcedLanguage = normalizeLanguage(exam.ForcedLanguage)
+	if exam.ForcedLanguage != "" && exam.ForcedLanguage != exam.Language && !slices.Contains(languages, exam.ForcedLanguage) {
+		return fmt.Errorf("%w: forced language %s is not one of the exam's languages", ErrInvalidLanguage, exam.ForcedLanguage)
+	}
+	return nil
+}
+
+// missingTranslations lists, per language of an exam, the order numbers of questions
+// lacking a translation into it or a translation of one of their options' text.
+func missingTranslations(exam *model.Exam, questions []model.Question) []string {
+	var missing []string
+	for _, lang := range exam.Languages {
+		var bad []string
+		for _, q := range questions {
+			if !translationComplete(q, lang) {
+				bad = append(bad, strconv.Itoa(q.OrderNum))
+			}
+		}
+		if len(bad) > 0 {
+			missing = append(missing, fmt.Sprintf("%s (order_num %s)", lang, strings.Join(bad, ", ")))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// translationComplete reports whether a question is translated into lang, options
+// with text included.
+func translationComplete(q model.Question, lang string) bool {
+	t, ok := q.Translations[lang]
+	if !ok || strings.TrimSpace(t.QuestionText) == "" {
+		return false
+	}
+	if options := keyedOptions(q); options != nil {
+		for _, o := range options {
+			if strings.TrimSpace(o.Text) != "" && strings.TrimSpace(t.Options[o.Key]) == "" {
+				return false
+			}
+		}
+		return true
+	}
+	var plain []string
+	if err := json.Unmarshal(q.Options, &plain); err == nil {
+		for i, text := range plain {
+			if strings.TrimSpace(text) != "" && strings.TrimSpace(t.Options[string(rune('A'+i))]) == "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// studentTranslations drops explanations from translations for the live payload.
+func studentTranslations(translations map[string]model.QuestionTranslation) map[string]model.QuestionTranslation {
+	if len(translations) == 0 {
+		return nil
+	}
+	out := make(map[string]model.QuestionTranslation, len(translations))
+	for lang, t := range translations {
+		t.Explanation = ""
+		out[lang] = t
+	}
+	return out
+}
+
+// ServedLanguage picks the language a student is served an exam in: the forced one,
+// else the one asked for when the exam offers it, else the exam's own.
+func ServedLanguage(payload *model.ExamPayload, requested string) string {
+	if payload.ForcedLanguage != "" {
+		return payload.ForcedLanguage
+	}
+	requested = normalizeLanguage(requested)
+	if requested != "" && slices.Contains(payload.Languages, requested) {
+		return requested
+	}
+	return payload.Language
+}
+
+// LocalizeExamPayload serves a payload in lang. Questions take their translation into
+// lang, falling back to their own text where it or a part of it is missing, and the
+// translations are dropped.
+func LocalizeExamPayload(payload *model.ExamPayload, lang string) {
+	for i := range payload.Questions {
+		q := &payload.Questions[i]
+		if t, ok := q.Translations[lang]; ok && lang != payload.Language {
+			applyTranslation(t, &q.QuestionText, &q.Options, &q.MathLatex)
+		}
+		q.Translations = nil
+	}
+	if payload.ForcedLanguage != "" {
+		payload.Languages = nil
+	}
+	payload.Language = lang
+}
+
+// applyTranslation replaces a question's text, option text and math with those of t
+// that are not empty.
+func applyTranslation(t model.QuestionTranslation, text *string, options *json.RawMessage, latex *string) {
+	if strings.TrimSpace(t.QuestionText) != "" {
+		*text = t.QuestionText
+	}
+	if t.MathLatex != "" {
+		*latex = t.MathLatex
+	}
+	if len(t.Options) == 0 {
+		return
+	}
+
+	var keyed []model.QuestionOption
+	if err := json.Unmarshal(*options, &keyed); err == nil && len(keyed) > 0 && keyed[0].Key != "" {
+		for i := range keyed {
+			if text := t.Options[keyed[i].Key]; strings.TrimSpace(text) != "" {
+				keyed[i].Text = text
+			}
+		}
+		if raw, err := json.Marshal(keyed); err == nil {
+			*options = raw
+		}
+		return
+	}
+	var plain []string
+	if err := json.Unmarshal(*options, &plain); err == nil {
+		for i := range plain {
+			if text := t.Options[string(rune('A'+i))]; strings.TrimSpace(text) != "" {
+				plain[i] = text
+			}
+		}
+		if raw, err := json.Marshal(plain); err == nil {
+			*options = raw
+		}
+	}
+}
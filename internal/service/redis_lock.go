@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// lockRetryInterval is how often a waiting acquireLock retries a held lock.
+const lockRetryInterval = 50 * time.Millisecond
+
+// errLockHeld is returned by acquireLock when the lock stayed held for the whole wait.
+var errLockHeld = errors.New("lock is held")
+
+// releaseLock deletes a lock only if it still holds its owner's token, so an expired
+// lock taken over by another owner is left alone.
+var releaseLock = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// acquireLock takes a lock shared by all instances (SET NX PX on key), waiting up to
+// wait for a holder to release it. The lock expires after ttl should its holder die.
+// The returned function releases it.
+func acquireLock(ctx context.Context, rdb *redis.Client, key string, ttl, wait time.Duration) (func(), error) {
+	token := uuid.NewString()
+	deadline := time.Now().Add(wait)
+	for {
+		ok, err := rdb.SetNX(ctx, key, token, ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return func() {
+				_ = releaseLock.Run(context.Background(), rdb, []string{key}, token).Err()
+			}, nil
+		}
+		if !time.Now().Before(deadline) {
+			return nil, errLockHeld
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
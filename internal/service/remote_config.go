@@ -0,0 +1,260 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// ErrInvalidRemoteConfig is returned when a remote config setting is saved with a
+// value that does not fit its type.
+var ErrInvalidRemoteConfig = errors.New("invalid remote config setting")
+
+// Remote config bounds.
+const (
+	maxAnnouncementLength = 500
+	maxAllowedBrowsers    = 20
+)
+
+var (
+	hexColorPattern    = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	browserNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+	themeModes         = []string{"light", "dark", "system"}
+	announcementLevels = []string{model.AnnouncementInfo, model.AnnouncementWarning, model.AnnouncementCritical}
+)
+
+// RemoteConfig returns the student app's remote config, loading it on first use and
+// after every change.
+func (s *SettingService) RemoteConfig(ctx context.Context) (*model.RemoteConfig, error) {
+	s.mu.Lock()
+	remote, gen := s.remote, s.remoteGen
+	s.mu.Unlock()
+	if remote != nil {
+		return remote, nil
+	}
+
+	settings, err := s.settingRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remote = &model.RemoteConfig{AllowedBrowsers: []model.UIAllowedBrowser{}}
+	for _, setting := range settings {
+		if !slices.Contains(model.RemoteConfigKeys, setting.Key) {
+			continue
+		}
+		if err := applyRemoteSetting(remote, setting.Key, setting.Value); err != nil {
+			s.log.Warn().Err(err).Str("key", setting.Key).Msg("Ignoring invalid remote config setting")
+		}
+	}
+	raw, _ := json.Marshal(remote)
+	sum := sha256.Sum256(raw)
+	remote.Version = hex.EncodeToString(sum[:8])
+
+	// A change during the load makes it stale; keep it out of the cache.
+	s.mu.Lock()
+	if s.remoteGen == gen {
+		s.remote = remote
+	}
+	s.mu.Unlock()
+	return remote, nil
+}
+
+// WatchRemoteConfig returns a channel receiving the remote config each time it
+// changes, and a function to stop watching. A slow reader only gets the latest.
+func (s *SettingService) WatchRemoteConfig() (<-chan *model.RemoteConfig, func()) {
+	ch := make(chan *model.RemoteConfig, 1)
+	s.mu.Lock()
+	s.watchers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.watchers, ch)
+		s.mu.Unlock()
+	}
+}
+
+// ListenRemoteConfig reloads the remote config and passes it to the watchers whenever
+// an instance announces a change, until ctx is done.
+func (s *SettingService) ListenRemoteConfig(ctx context.Context) {
+	sub := s.rdb.Subscribe(ctx, config.CacheKey.RemoteConfigChannel())
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-sub.Channel():
+			if !ok {
+				return
+			}
+			s.reloadRemoteConfig(ctx)
+		}
+	}
+}
+
+// announceRemoteConfig tells every instance, this one included, that the remote config
+// changed. Without Redis only this instance's watchers hear of it.
+func (s *SettingService) announceRemoteConfig(ctx context.Context) {
+	if err := s.rdb.Publish(ctx, config.CacheKey.RemoteConfigChannel(), "").Err(); err != nil {
+		s.log.Warn().Err(err).Msg("Failed to announce remote config change")
+		s.reloadRemoteConfig(ctx)
+	}
+}
+
+// reloadRemoteConfig drops the cached remote config and sends the reloaded one to the
+// watchers.
+func (s *SettingService) reloadRemoteConfig(ctx context.Context) {
+	s.mu.Lock()
+	s.remote = nil
+	s.remoteGen++
+	s.mu.Unlock()
+
+	remote, err := s.RemoteConfig(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to reload remote config")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.watchers {
+		// Replace an update the watcher has not read yet.
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- remote
+	}
+}
+
+// applyRemoteSetting decodes a remote config setting into remote after checking it
+// fits its type. An empty or invalid value leaves that part unset.
+func applyRemoteSetting(remote *model.RemoteConfig, key, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	var err error
+	switch key {
+	case model.SettingUITheme:
+		var theme model.UITheme
+		if err = decodeRemoteSetting(value, &theme); err == nil {
+			if err = validateTheme(&theme); err == nil {
+				remote.Theme = &theme
+			}
+		}
+	case model.SettingUIAnnouncement:
+		var announcement model.UIAnnouncement
+		if err = decodeRemoteSetting(value, &announcement); err == nil {
+			if err = validateAnnouncement(&announcement); err == nil {
+				remote.Announcement = &announcement
+			}
+		}
+	case model.SettingUISupportContact:
+		var contact model.UISupportContact
+		if err = decodeRemoteSetting(value, &contact); err == nil {
+			if err = validateSupportContact(&contact); err == nil {
+				remote.SupportContact = &contact
+			}
+		}
+	case model.SettingUIAllowedBrowsers:
+		var browsers []model.UIAllowedBrowser
+		if err = decodeRemoteSetting(value, &browsers); err == nil {
+			if err = validateAllowedBrowsers(browsers); err == nil {
+				remote.AllowedBrowsers = append([]model.UIAllowedBrowser{}, browsers...)
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidRemoteConfig, key, err)
+	}
+	return nil
+}
+
+// decodeRemoteSetting decodes a JSON setting value, refusing unknown fields.
+func decodeRemoteSetting(value string, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader([]byte(value)))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func validateTheme(t *model.UITheme) error {
+	for _, color := range []string{t.PrimaryColor, t.AccentColor} {
+		if color != "" && !hexColorPattern.MatchString(color) {
+			return fmt.Errorf("color %q must be #rrggbb", color)
+		}
+	}
+	if t.Mode != "" && !slices.Contains(themeModes, t.Mode) {
+		return fmt.Errorf("mode must be one of %s", strings.Join(themeModes, ", "))
+	}
+	if t.LogoURL != "" && !validLogoURL(t.LogoURL) {
+		return fmt.Errorf("logo_url %q must be an https:// URL or an /uploads/ path", t.LogoURL)
+	}
+	return nil
+}
+
+// validLogoURL reports whether the logo is served over https or is one of our uploads,
+// so the theme cannot point the exam UI at a javascript: or plain-http address.
+func validLogoURL(raw string) bool {
+	if strings.HasPrefix(raw, "/uploads/") {
+		return !strings.Contains(raw, "..") && !strings.ContainsAny(raw, "\\?#") && !strings.Contains(raw, "//")
+	}
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme == "https" && u.Host != "" && u.User == nil
+}
+
+func validateAnnouncement(a *model.UIAnnouncement) error {
+	a.Message = strings.TrimSpace(a.Message)
+	switch {
+	case a.Message == "":
+		return errors.New("message is required")
+	case len([]rune(a.Message)) > maxAnnouncementLength:
+		return fmt.Errorf("message must be at most %d characters", maxAnnouncementLength)
+	case !slices.Contains(announcementLevels, a.Level):
+		return fmt.Errorf("level must be one of %s", strings.Join(announcementLevels, ", "))
+	case a.StartsAt != nil && a.EndsAt != nil && !a.EndsAt.After(*a.StartsAt):
+		return errors.New("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+func validateSupportContact(c *model.UISupportContact) error {
+	if c.Email != "" {
+		if _, err := mail.ParseAddress(c.Email); err != nil {
+			return fmt.Errorf("email %q is not a valid address", c.Email)
+		}
+	}
+	return nil
+}
+
+func validateAllowedBrowsers(browsers []model.UIAllowedBrowser) error {
+	if len(browsers) > maxAllowedBrowsers {
+		return fmt.Errorf("at most %d browsers", maxAllowedBrowsers)
+	}
+	seen := make(map[string]bool, len(browsers))
+	for _, b := range browsers {
+		if !browserNamePattern.MatchString(b.Name) {
+			return fmt.Errorf("browser name %q must be lowercase, e.g. chrome", b.Name)
+		}
+		if seen[b.Name] {
+			return fmt.Errorf("browser %q is listed twice", b.Name)
+		}
+		seen[b.Name] = true
+		if b.MinVersion < 0 {
+			return fmt.Errorf("min_version of %s must not be negative", b.Name)
+		}
+	}
+	return nil
+}
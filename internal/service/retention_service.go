@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+)
+
+// retentionBatchSize caps the rows one retention statement changes.
+const retentionBatchSize = 5000
+
+// ErrRetentionUnsupported is returned for a rule on an unknown target or with an
+// action its target does not support.
+var ErrRetentionUnsupported = errors.New("unsupported retention target or action")
+
+// RetentionService manages the data retention rules and applies them.
+type RetentionService struct {
+	repo  *repository.RetentionRepository
+	audit *AuditService
+	log   zerolog.Logger
+}
+
+// NewRetentionService creates a new RetentionService.
+func NewRetentionService(repo *repository.RetentionRepository, audit *AuditService, log zerolog.Logger) *RetentionService {
+	return &RetentionService{
+		repo:  repo,
+		audit: audit,
+		log:   log.With().Str("component", "retention").Logger(),
+	}
+}
+
+// ListTargets returns the catalog of retention targets.
+func (s *RetentionService) ListTargets() []model.RetentionTarget {
+	return model.RetentionTargets
+}
+
+// ListRules returns all retention rules.
+func (s *RetentionService) ListRules(ctx context.Context) ([]model.RetentionRule, error) {
+	return s.repo.ListRules(ctx)
+}
+
+// CreateRule creates a retention rule. Rules are enabled unless the request says otherwise.
+func (s *RetentionService) CreateRule(ctx context.Context, req model.CreateRetentionRuleRequest) (*model.RetentionRule, error) {
+	if !model.RetentionTargetSupports(req.Target, req.Action) {
+		return nil, fmt.Errorf("%w: %s cannot be %sd", ErrRetentionUnsupported, req.Target, req.Action)
+	}
+	rule := &model.RetentionRule{
+		Target:    req.Target,
+		Action:    req.Action,
+		AfterDays: req.AfterDays,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+	}
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateRule changes a retention rule's age and whether it is enabled.
+func (s *RetentionService) UpdateRule(ctx context.Context, id int, req model.UpdateRetentionRuleRequest) (*model.RetentionRule, error) {
+	rule := &model.RetentionRule{ID: id, AfterDays: req.AfterDays, Enabled: req.Enabled}
+	if err := s.repo.UpdateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteRule removes a retention rule.
+func (s *RetentionService) DeleteRule(ctx context.Context, id int) error {
+	return s.repo.DeleteRule(ctx, id)
+}
+
+// Report is a dry run of the retention rules: how many rows each would delete or
+// anonymize if it ran now. Disabled rules are included so they can be tried out
+// before they are enabled.
+func (s *RetentionService) Report(ctx context.Context) (*model.RetentionReport, error) {
+	rules, err := s.repo.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &model.RetentionReport{GeneratedAt: now, Results: make([]model.RetentionResult, 0, len(rules))}
+	for _, rule := range rules {
+		cutoff := retentionCutoff(now, rule)
+		rows, err := s.repo.CountExpired(ctx, rule, cutoff)
+		if err != nil {
+			return nil, fmt.Errorf("count %s to %s: %w", rule.Target, rule.Action, err)
+		}
+		report.Results = append(report.Results, model.RetentionResult{Rule: rule, Cutoff: cutoff, Rows: rows})
+	}
+	return report, nil
+}
+
+// Run applies every enabled retention rule in batches and records what each purged
+// in the audit log. A failing rule does not stop the others.
+func (s *RetentionService) Run(ctx context.Context) ([]model.RetentionResult, error) {
+	rules, err := s.repo.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var results []model.RetentionResult
+	var errs []error
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		result := model.RetentionResult{Rule: rule, Cutoff: retentionCutoff(now, rule)}
+		for {
+			n, err := s.repo.ApplyExpired(ctx, rule, result.Cutoff, retentionBatchSize)
+			result.Rows += n
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", rule.Action, rule.Target, err))
+				break
+			}
+			if n < retentionBatchSize {
+				break
+			}
+		}
+		results = append(results, result)
+
+		if result.Rows == 0 {
+			continue
+		}
+		s.log.Info().Str("target", rule.Target).Str("action", rule.Action).Int64("rows", result.Rows).Msg("Retention rule applied")
+		// The purge already happened; a failed entry is logged by the audit service.
+		_ = s.audit.RecordSystem(ctx, model.AuditActionRetentionPurge, "retention_rule", strconv.Itoa(rule.ID), map[string]interface{}{
+			"target":     rule.Target,
+			"action":     rule.Action,
+			"after_days": rule.AfterDays,
+			"cutoff":     result.Cutoff,
+			"rows":       result.Rows,
+		})
+	}
+	return results, errors.Join(errs...)
+}
+
+// retentionCutoff is the time before which a rule's data has expired.
+func retentionCutoff(now time.Time, rule model.RetentionRule) time.Time {
+	return now.AddDate(0, 0, -rule.AfterDays)
+}
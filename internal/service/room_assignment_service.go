@@ -19,6 +19,9 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
+// ErrNoDistribution is returned when exporting before any student has been distributed.
+var ErrNoDistribution = errors.New("no distribution data available to export")
+
 // RoomAssignmentService handles standalone student-to-room distribution.
 type RoomAssignmentService struct {
 	assignmentRepo *repository.RoomAssignmentRepository
@@ -176,7 +179,7 @@ func (s *RoomAssignmentService) ExportPresenceXLSX(ctx context.Context, sessionO
 	}
 
 	if len(dist.Sessions) == 0 {
-		return nil, errors.New("no distribution data available to export")
+		return nil, ErrNoDistribution
 	}
 
 	// Fetch letterhead setting.
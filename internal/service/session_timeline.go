@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// timelineAutosaveWindow is the span autosaves are sampled over on a session timeline.
+const timelineAutosaveWindow = time.Minute
+
+// SessionTimeline consolidates what was recorded of a student's session into one
+// timeline: the join and submit from the session itself, cheat events as persisted,
+// and the student's recorded monitor events (connects, disconnects, breaks, idles,
+// navigation), with autosaves sampled to one entry per minute. Monitor events are
+// not recorded while Redis is degraded, so the persisted answers are returned as
+// well.
+func (s *MonitorService) SessionTimeline(ctx context.Context, examID uuid.UUID, studentID int) (*model.SessionTimeline, error) {
+	timeline, err := s.monitorRepo.GetSessionTimeline(ctx, examID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := s.monitorRepo.ListStudentEvents(ctx, examID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("list monitor events: %w", err)
+	}
+	cheats, err := s.monitorRepo.ListStudentCheats(ctx, examID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("list cheats: %w", err)
+	}
+	if timeline.Answers, err = s.monitorRepo.ListStudentAnswers(ctx, examID, studentID); err != nil {
+		return nil, fmt.Errorf("list answers: %w", err)
+	}
+
+	events := []model.SessionTimelineEvent{{At: timeline.StartedAt, Type: model.TimelineJoin, Message: "Session started"}}
+	for _, c := range cheats {
+		events = append(events, model.SessionTimelineEvent{At: c.RecordedAt, Type: model.TimelineCheat, Message: c.Type, Data: c.Payload})
+	}
+
+	var autosave *model.SessionTimelineEvent
+	var autosaveQuestions []string
+	flushAutosave := func() {
+		if autosave == nil {
+			return
+		}
+		autosave.Data, _ = json.Marshal(map[string][]string{"q_ids": autosaveQuestions})
+		events = append(events, *autosave)
+		autosave, autosaveQuestions = nil, nil
+	}
+	for _, e := range recorded {
+		var payload struct {
+			Message string `json:"message"`
+			QID     string `json:"q_id"`
+		}
+		_ = json.Unmarshal(e.Payload, &payload)
+
+		switch e.Type {
+		case model.TimelineJoin, model.TimelineSubmit, model.TimelineCheat:
+			// Taken from the session and the persisted cheats instead.
+			continue
+		case model.TimelineAutosave:
+			if autosave != nil && e.RecordedAt.Sub(autosave.At) >= timelineAutosaveWindow {
+				flushAutosave()
+			}
+			if autosave == nil {
+				autosave = &model.SessionTimelineEvent{At: e.RecordedAt, Type: model.TimelineAutosave}
+			}
+			autosave.Count++
+			autosave.Message = fmt.Sprintf("%d autosaves", autosave.Count)
+			if payload.QID != "" {
+				autosaveQuestions = append(autosaveQuestions, payload.QID)
+			}
+			continue
+		}
+		events = append(events, model.SessionTimelineEvent{At: e.RecordedAt, Type: e.Type, Message: payload.Message})
+	}
+	flushAutosave()
+
+	if timeline.FinishedAt != nil {
+		submit := model.SessionTimelineEvent{At: *timeline.FinishedAt, Type: model.TimelineSubmit, Message: "Session submitted"}
+		if timeline.FinalScore != nil {
+			submit.Data, _ = json.Marshal(map[string]float64{"score": *timeline.FinalScore})
+		}
+		events = append(events, submit)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+	timeline.Events = events
+	return timeline, nil
+}
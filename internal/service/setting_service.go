@@ -2,20 +2,34 @@ package service
 
 import (
 	"context"
+	"sync"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/model"
 	"github.com/stemsi/exstem-backend/internal/repository"
 )
 
 type SettingService struct {
 	settingRepo *repository.SettingRepository
+	rdb         *redis.Client
 	log         zerolog.Logger
+
+	// remote caches the student app's remote config; nil until loaded or after a change,
+	// which bumps remoteGen. watchers are the channels of the lobby streams waiting for
+	// changes.
+	mu        sync.Mutex
+	remote    *model.RemoteConfig
+	remoteGen uint64
+	watchers  map[chan *model.RemoteConfig]struct{}
 }
 
-func NewSettingService(settingRepo *repository.SettingRepository, log zerolog.Logger) *SettingService {
+func NewSettingService(settingRepo *repository.SettingRepository, rdb *redis.Client, log zerolog.Logger) *SettingService {
 	return &SettingService{
 		settingRepo: settingRepo,
+		rdb:         rdb,
 		log:         log.With().Str("component", "setting_service").Logger(),
+		watchers:    make(map[chan *model.RemoteConfig]struct{}),
 	}
 }
 
@@ -34,6 +48,28 @@ func (s *SettingService) GetAllSettings(ctx context.Context) (map[string]string,
 }
 
 func (s *SettingService) UpdateSettings(ctx context.Context, settingsMap map[string]string) error {
+	if schema, ok := settingsMap[model.SettingExamMetadataSchema]; ok {
+		if _, err := ParseExamMetadataSchema(schema); err != nil {
+			return err
+		}
+	}
+	for _, key := range []string{model.SettingExamPayloadWarnMB, model.SettingExamPayloadMaxMB} {
+		if value, ok := settingsMap[key]; ok {
+			if _, err := parsePayloadLimit(value); err != nil {
+				return err
+			}
+		}
+	}
+	remoteChanged := false
+	for _, key := range model.RemoteConfigKeys {
+		if value, ok := settingsMap[key]; ok {
+			if err := applyRemoteSetting(&model.RemoteConfig{}, key, value); err != nil {
+				return err
+			}
+			remoteChanged = true
+		}
+	}
+
 	// Simple iterative upsert since settings are low volume. Can be optimized into a single tx if needed.
 	for key, value := range settingsMap {
 		if err := s.settingRepo.Upsert(ctx, key, value); err != nil {
@@ -41,6 +77,9 @@ func (s *SettingService) UpdateSettings(ctx context.Context, settingsMap map[str
 			return err
 		}
 	}
+	if remoteChanged {
+		s.announceRemoteConfig(ctx)
+	}
 	return nil
 }
 
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stemsi/exstem-backend/internal/helper"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// ErrPasswordChangeRequired is returned when a student who was issued credentials
+// tries to take an exam before choosing their own password.
+var ErrPasswordChangeRequired = errors.New("password must be changed before continuing")
+
+// IssueCredentials gives every student of a class a fresh random password and makes
+// them change it at their next login. Only bcrypt hashes are stored: the returned
+// cards carry the plaintext passwords, which cannot be retrieved again.
+func (s *StudentService) IssueCredentials(ctx context.Context, classID int) ([]model.StudentCardInfo, error) {
+	rows, err := s.studentRepo.ListStudentCards(ctx, &classID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// A student with several room assignments is listed once per assignment.
+	cards := make([]model.StudentCardInfo, 0, len(rows))
+	hashes := make(map[int]string, len(rows))
+	for _, card := range rows {
+		if _, ok := hashes[card.ID]; ok {
+			continue
+		}
+		password, err := helper.GenerateStudentPassword()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := s.authService.HashPassword(password)
+		if err != nil {
+			return nil, fmt.Errorf("hash password: %w", err)
+		}
+		hashes[card.ID] = hash
+		card.Password = password
+		cards = append(cards, card)
+	}
+	if len(cards) == 0 {
+		return cards, nil
+	}
+
+	if err := s.studentRepo.IssuePasswords(ctx, hashes); err != nil {
+		return nil, fmt.Errorf("store passwords: %w", err)
+	}
+	return cards, nil
+}
+
+// ChangeOwnPassword replaces a student's password after checking the current one.
+// The new password must meet the password policy and is stored as a bcrypt hash.
+func (s *StudentService) ChangeOwnPassword(ctx context.Context, studentID int, current, next string) error {
+	student, err := s.studentRepo.GetByID(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	if err := s.authService.CheckStudentPassword(student.Password, current); err != nil {
+		return err
+	}
+	if err := s.authService.CheckPasswordPolicy(next, student.NIS, student.NISN); err != nil {
+		return err
+	}
+	hash, err := s.authService.HashPassword(next)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	return s.studentRepo.ChangeOwnPassword(ctx, studentID, hash)
+}
+
+// visiblePassword hides a stored password that is a hash, as only plaintext
+// passwords can be shown to admins or printed on cards.
+func visiblePassword(stored string) string {
+	if isPasswordHash(stored) {
+		return ""
+	}
+	return stored
+}
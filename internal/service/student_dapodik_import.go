@@ -0,0 +1,418 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stemsi/exstem-backend/internal/helper"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/xuri/excelize/v2"
+)
+
+// MaxDapodikImportBytes caps the size of an uploaded Dapodik export.
+const MaxDapodikImportBytes = 20 << 20
+
+// ErrDapodikFormat is returned when the upload does not look like a Dapodik student export.
+var ErrDapodikFormat = errors.New("not a dapodik student export")
+
+// Dapodik's "Daftar Peserta Didik" export starts with a few title rows; the header
+// row is the first one naming the columns below. Columns are found by header name
+// so the differently ordered exports of each Dapodik version all work.
+const dapodikHeaderScanRows = 15
+
+var dapodikColumns = map[string][]string{
+	"name":     {"nama", "nama peserta didik"},
+	"nis":      {"nipd", "nis"},
+	"nisn":     {"nisn"},
+	"gender":   {"jk", "jenis kelamin"},
+	"religion": {"agama"},
+	"class":    {"rombel saat ini", "rombel", "kelas"},
+}
+
+// dapodikReligions maps Dapodik's religion spellings onto model.Religion.
+var dapodikReligions = map[string]model.Religion{
+	"islam":             model.ReligionIslam,
+	"kristen":           model.ReligionKristen,
+	"kristen/protestan": model.ReligionKristen,
+	"protestan":         model.ReligionKristen,
+	"katholik":          model.ReligionKatolik,
+	"katolik":           model.ReligionKatolik,
+	"hindu":             model.ReligionHindu,
+	"budha":             model.ReligionBuddha,
+	"buddha":            model.ReligionBuddha,
+	"khonghucu":         model.ReligionKonghucu,
+	"konghucu":          model.ReligionKonghucu,
+	"kong hu chu":       model.ReligionKonghucu,
+}
+
+// DapodikImportService reconciles Dapodik student exports against existing students.
+type DapodikImportService struct {
+	pool *pgxpool.Pool
+}
+
+// NewDapodikImportService creates a new DapodikImportService.
+func NewDapodikImportService(pool *pgxpool.Pool) *DapodikImportService {
+	return &DapodikImportService{pool: pool}
+}
+
+// dapodikStudent is one parsed row of the export.
+type dapodikStudent struct {
+	row      int
+	nis      string
+	nisn     string
+	name     string
+	gender   model.Gender
+	religion model.Religion
+	class    string
+}
+
+// Import reconciles a Dapodik .xlsx export against the students table. Students are
+// matched by NISN, then NIS. The whole import runs in one transaction that is only
+// committed when confirm is set, so a dry run reports exactly what would happen.
+func (s *DapodikImportService) Import(ctx context.Context, filename string, r io.Reader, confirm bool) (*model.DapodikImportReport, error) {
+	if ext := strings.ToLower(filepath.Ext(filename)); ext != ".xlsx" {
+		return nil, fmt.Errorf("%w: %s (allowed: .xlsx)", ErrUnsupportedFileType, ext)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, MaxDapodikImportBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	if len(data) > MaxDapodikImportBytes {
+		return nil, fmt.Errorf("%w: max %d bytes", ErrFileTooLarge, MaxDapodikImportBytes)
+	}
+
+	rows, err := parseDapodikExport(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	report := &model.DapodikImportReport{
+		Rows:       make([]model.DapodikImportRow, 0, len(rows)),
+		NewClasses: []string{},
+		Missing:    []model.Student{},
+	}
+	classIDs := make(map[string]int)
+	seenIDs := []int{}
+
+	for _, st := range rows {
+		out := model.DapodikImportRow{Row: st.row, NIS: st.nis, NISN: st.nisn, Name: st.name, Class: st.class}
+
+		if st.nisn == "" && st.nis == "" {
+			out.Action, out.Error = model.ImportActionSkip, "missing NISN and NIPD"
+			report.Rows = append(report.Rows, out)
+			report.Skipped++
+			continue
+		}
+
+		classID, ok := classIDs[st.class]
+		if !ok {
+			classID, err = s.resolveClass(ctx, tx, st.class, report)
+			if err != nil {
+				out.Action, out.Error = model.ImportActionSkip, err.Error()
+				report.Rows = append(report.Rows, out)
+				report.Skipped++
+				continue
+			}
+			classIDs[st.class] = classID
+		}
+
+		existing, err := s.findStudent(ctx, tx, st.nisn, st.nis)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing == nil {
+			if err := s.createStudent(ctx, tx, st, classID); err != nil {
+				out.Action, out.Error = model.ImportActionSkip, err.Error()
+				report.Rows = append(report.Rows, out)
+				report.Skipped++
+				continue
+			}
+			out.Action = model.ImportActionCreate
+			report.Created++
+			report.Rows = append(report.Rows, out)
+			continue
+		}
+
+		seenIDs = append(seenIDs, existing.ID)
+		out.Changes = diffDapodikStudent(existing, st, classID)
+		if len(out.Changes) == 0 {
+			out.Action = model.ImportActionUnchanged
+			report.Unchanged++
+			report.Rows = append(report.Rows, out)
+			continue
+		}
+
+		if err := s.updateStudent(ctx, tx, existing.ID, st, classID); err != nil {
+			out.Action, out.Error = model.ImportActionSkip, err.Error()
+			out.Changes = nil
+			report.Rows = append(report.Rows, out)
+			report.Skipped++
+			continue
+		}
+		out.Action = model.ImportActionUpdate
+		report.Updated++
+		report.Rows = append(report.Rows, out)
+	}
+
+	if err := s.collectMissing(ctx, tx, classIDs, seenIDs, report); err != nil {
+		return nil, err
+	}
+
+	if !confirm {
+		return report, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	report.Applied = true
+	return report, nil
+}
+
+// resolveClass finds the class for a Dapodik rombel, creating it when only the class is new.
+func (s *DapodikImportService) resolveClass(ctx context.Context, tx pgx.Tx, name string, report *model.DapodikImportReport) (int, error) {
+	grade, majorCode, group, ok := parseClassName(name)
+	if !ok {
+		return 0, fmt.Errorf("%q is not a class", name)
+	}
+
+	var classID int
+	err := tx.QueryRow(ctx,
+		"SELECT id FROM classes WHERE grade_level = $1 AND major_code = $2 AND group_number = $3",
+		grade, majorCode, group,
+	).Scan(&classID)
+	if err == nil {
+		return classID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	classID, err = ensureClass(ctx, tx, name)
+	if err != nil {
+		return 0, err
+	}
+	report.NewClasses = append(report.NewClasses, fmt.Sprintf("%s %s %d", grade, majorCode, group))
+	return classID, nil
+}
+
+// findStudent matches an existing student by NISN, falling back to NIS.
+func (s *DapodikImportService) findStudent(ctx context.Context, tx pgx.Tx, nisn, nis string) (*model.Student, error) {
+	st := &model.Student{}
+	err := tx.QueryRow(ctx,
+		`SELECT id, nis, nisn, name, gender, religion, class_id, is_active
+		 FROM students
+		 WHERE ($1 <> '' AND nisn = $1) OR ($2 <> '' AND nis = $2)
+		 ORDER BY (nisn = $1) DESC
+		 LIMIT 1`, nisn, nis,
+	).Scan(&st.ID, &st.NIS, &st.NISN, &st.Name, &st.Gender, &st.Religion, &st.ClassID, &st.IsActive)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find student: %w", err)
+	}
+	return st, nil
+}
+
+func (s *DapodikImportService) createStudent(ctx context.Context, tx pgx.Tx, st dapodikStudent, classID int) error {
+	password, err := helper.GenerateStudentPassword()
+	if err != nil {
+		return fmt.Errorf("generate password: %w", err)
+	}
+	nis, nisn := st.nis, st.nisn
+	if nis == "" {
+		nis = nisn
+	}
+	if nisn == "" {
+		nisn = nis
+	}
+
+	if _, err := tx.Exec(ctx, "SAVEPOINT dapodik_row"); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO students (nis, nisn, name, gender, religion, password, class_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		nis, nisn, st.name, st.gender, st.religion, password, classID,
+	)
+	return releaseDapodikSavepoint(ctx, tx, err)
+}
+
+func (s *DapodikImportService) updateStudent(ctx context.Context, tx pgx.Tx, id int, st dapodikStudent, classID int) error {
+	if _, err := tx.Exec(ctx, "SAVEPOINT dapodik_row"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx,
+		`UPDATE students SET
+			nis = COALESCE(NULLIF($1, ''), nis),
+			nisn = COALESCE(NULLIF($2, ''), nisn),
+			name = $3, gender = $4, religion = $5, class_id = $6, updated_at = NOW()
+		 WHERE id = $7`,
+		st.nis, st.nisn, st.name, st.gender, st.religion, classID, id,
+	)
+	return releaseDapodikSavepoint(ctx, tx, err)
+}
+
+// releaseDapodikSavepoint keeps a failed row (e.g. a NIS clash) from aborting the whole import.
+func releaseDapodikSavepoint(ctx context.Context, tx pgx.Tx, err error) error {
+	if err != nil {
+		_, _ = tx.Exec(ctx, "ROLLBACK TO SAVEPOINT dapodik_row")
+		return err
+	}
+	_, err = tx.Exec(ctx, "RELEASE SAVEPOINT dapodik_row")
+	return err
+}
+
+// collectMissing lists active students of the exported classes that the export left out.
+func (s *DapodikImportService) collectMissing(ctx context.Context, tx pgx.Tx, classIDs map[string]int, seenIDs []int, report *model.DapodikImportReport) error {
+	if len(classIDs) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(classIDs))
+	for _, id := range classIDs {
+		ids = append(ids, id)
+	}
+
+	// Students created by this import are not yet in seenIDs, so exclude them by timestamp too.
+	rows, err := tx.Query(ctx,
+		`SELECT id, nis, nisn, name, gender, religion, class_id, is_active, created_at, updated_at
+		 FROM students
+		 WHERE class_id = ANY($1) AND is_active AND NOT (id = ANY($2)) AND created_at < transaction_timestamp()
+		 ORDER BY class_id, name`, ids, seenIDs,
+	)
+	if err != nil {
+		return fmt.Errorf("list missing students: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st model.Student
+		if err := rows.Scan(&st.ID, &st.NIS, &st.NISN, &st.Name, &st.Gender, &st.Religion, &st.ClassID, &st.IsActive, &st.CreatedAt, &st.UpdatedAt); err != nil {
+			return err
+		}
+		report.Missing = append(report.Missing, st)
+	}
+	return rows.Err()
+}
+
+// diffDapodikStudent lists the fields the export would change on an existing student.
+func diffDapodikStudent(existing *model.Student, st dapodikStudent, classID int) []model.FieldChange {
+	var changes []model.FieldChange
+	add := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, model.FieldChange{Field: field, From: from, To: to})
+		}
+	}
+	if st.nis != "" {
+		add("nis", existing.NIS, st.nis)
+	}
+	if st.nisn != "" {
+		add("nisn", existing.NISN, st.nisn)
+	}
+	add("name", existing.Name, st.name)
+	add("gender", string(existing.Gender), string(st.gender))
+	add("religion", string(existing.Religion), string(st.religion))
+	if existing.ClassID != classID {
+		add("class_id", fmt.Sprint(existing.ClassID), fmt.Sprint(classID))
+	}
+	return changes
+}
+
+// parseDapodikExport reads the student rows of the first sheet of a Dapodik export.
+func parseDapodikExport(data []byte) ([]dapodikStudent, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFileType, err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, ErrDapodikFormat
+	}
+	rows, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDapodikFormat, err)
+	}
+
+	headerRow, cols := -1, map[string]int{}
+	for i := 0; i < len(rows) && i < dapodikHeaderScanRows; i++ {
+		if found := matchDapodikHeader(rows[i]); found != nil {
+			headerRow, cols = i, found
+			break
+		}
+	}
+	if headerRow < 0 {
+		return nil, ErrDapodikFormat
+	}
+
+	cell := func(row []string, key string) string {
+		idx, ok := cols[key]
+		if !ok || idx >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[idx])
+	}
+
+	var students []dapodikStudent
+	for i := headerRow + 1; i < len(rows); i++ {
+		row := rows[i]
+		name := cell(row, "name")
+		if name == "" {
+			continue // blank and sub-header rows
+		}
+		religion, ok := dapodikReligions[strings.ToLower(cell(row, "religion"))]
+		if !ok {
+			religion = model.Religion(cell(row, "religion"))
+		}
+		students = append(students, dapodikStudent{
+			row:      i + 1,
+			nis:      cell(row, "nis"),
+			nisn:     cell(row, "nisn"),
+			name:     name,
+			gender:   directoryGender(cell(row, "gender")),
+			religion: religion,
+			class:    cell(row, "class"),
+		})
+	}
+	return students, nil
+}
+
+// matchDapodikHeader maps column keys to indexes if the row is the export's header row.
+func matchDapodikHeader(row []string) map[string]int {
+	cols := make(map[string]int)
+	for idx, raw := range row {
+		h := strings.ToLower(strings.TrimSpace(raw))
+		for key, names := range dapodikColumns {
+			if _, done := cols[key]; done {
+				continue
+			}
+			for _, name := range names {
+				if h == name {
+					cols[key] = idx
+					break
+				}
+			}
+		}
+	}
+	for _, required := range []string{"name", "nisn", "class"} {
+		if _, ok := cols[required]; !ok {
+			return nil
+		}
+	}
+	return cols
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/helper"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// directorySource tags students owned by the directory sync. Only those are ever
+// deactivated by it; manually created students are left alone.
+const directorySource = "directory"
+
+var (
+	// ErrDirectoryNotConfigured is returned when no directory URL is configured.
+	ErrDirectoryNotConfigured = errors.New("student directory is not configured")
+	// ErrDirectoryEmpty guards against deactivating every student on a broken export.
+	ErrDirectoryEmpty = errors.New("student directory returned no entries")
+)
+
+// classNameRe matches class names such as "XII RPL 1", "XI-TKJ-2" or "Kelas 10 AKL 3".
+var classNameRe = regexp.MustCompile(`(?i)^(?:kelas\s+)?(XII|XI|X|10|11|12)[\s\-_]+([A-Za-z0-9]+)[\s\-_]+(\d+)$`)
+
+// parseClassName splits a class name into grade level, major code and group number.
+func parseClassName(name string) (grade, majorCode string, group int, ok bool) {
+	m := classNameRe.FindStringSubmatch(strings.TrimSpace(name))
+	if m == nil {
+		return "", "", 0, false
+	}
+
+	grade = strings.ToUpper(m[1])
+	switch grade {
+	case "10":
+		grade = "X"
+	case "11":
+		grade = "XI"
+	case "12":
+		grade = "XII"
+	}
+	group, _ = strconv.Atoi(m[3])
+	return grade, strings.ToUpper(m[2]), group, true
+}
+
+// DirectoryEntry is one student record in the directory export. Class placement
+// comes from the entry's OU, given directly or as part of its LDAP DN.
+type DirectoryEntry struct {
+	NIS      string `json:"nis"`
+	NISN     string `json:"nisn"`
+	Name     string `json:"name"`
+	Gender   string `json:"gender"`
+	Religion string `json:"religion"`
+	OU       string `json:"ou"`
+	DN       string `json:"dn"`
+}
+
+// DirectorySyncService keeps student accounts in line with the school directory
+// (an LDAP/Active Directory export served over REST).
+type DirectorySyncService struct {
+	pool   *pgxpool.Pool
+	cfg    *config.Config
+	client *http.Client
+	log    zerolog.Logger
+}
+
+// NewDirectorySyncService creates a new DirectorySyncService.
+func NewDirectorySyncService(pool *pgxpool.Pool, cfg *config.Config, log zerolog.Logger) *DirectorySyncService {
+	return &DirectorySyncService{
+		pool:   pool,
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+		log:    log.With().Str("component", "directory_sync").Logger(),
+	}
+}
+
+// Enabled reports whether a directory source is configured.
+func (s *DirectorySyncService) Enabled() bool {
+	return s.cfg.StudentDirectoryURL != ""
+}
+
+// Sync creates, updates and deactivates students to match the directory. It is
+// idempotent: running it twice against the same export changes nothing the second time.
+func (s *DirectorySyncService) Sync(ctx context.Context) (*model.DirectorySyncResult, error) {
+	if !s.Enabled() {
+		return nil, ErrDirectoryNotConfigured
+	}
+
+	entries, err := s.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrDirectoryEmpty
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result := &model.DirectorySyncResult{Skipped: []string{}}
+	seen := make([]string, 0, len(entries))
+	classIDs := make(map[string]int)
+
+	for _, e := range entries {
+		nis := strings.TrimSpace(e.NIS)
+		if nis == "" {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%q: missing NIS", e.Name))
+			continue
+		}
+		// Still listed in the directory, so never deactivated even if skipped below.
+		seen = append(seen, nis)
+		nisn := strings.TrimSpace(e.NISN)
+		if nisn == "" {
+			nisn = nis
+		}
+
+		ou := strings.TrimSpace(e.OU)
+		if ou == "" {
+			ou = classOUFromDN(e.DN)
+		}
+		classID, ok := classIDs[ou]
+		if !ok {
+			classID, err = ensureClass(ctx, tx, ou)
+			if err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", nis, err))
+				continue
+			}
+			classIDs[ou] = classID
+		}
+
+		religion := model.Religion(strings.TrimSpace(e.Religion))
+		if religion == "" {
+			religion = "-"
+		}
+
+		// New accounts get a fresh password; existing ones keep theirs.
+		password, err := helper.GenerateStudentPassword()
+		if err != nil {
+			return nil, fmt.Errorf("generate password: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, "SAVEPOINT directory_student"); err != nil {
+			return nil, fmt.Errorf("savepoint: %w", err)
+		}
+
+		var inserted bool
+		err = tx.QueryRow(ctx, `
+			INSERT INTO students (nis, nisn, name, gender, religion, password, class_id, directory_source)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (nis) DO UPDATE SET
+				nisn = EXCLUDED.nisn,
+				name = EXCLUDED.name,
+				gender = EXCLUDED.gender,
+				religion = EXCLUDED.religion,
+				class_id = EXCLUDED.class_id,
+				directory_source = EXCLUDED.directory_source,
+				status = CASE WHEN students.status = 'transferred' THEN 'active' ELSE students.status END,
+				status_changed_at = CASE WHEN students.status = 'transferred' THEN NOW() ELSE students.status_changed_at END,
+				updated_at = NOW()
+			WHERE (students.nisn, students.name, students.gender, students.religion, students.class_id, students.status = 'transferred', students.directory_source)
+				IS DISTINCT FROM (EXCLUDED.nisn, EXCLUDED.name, EXCLUDED.gender, EXCLUDED.religion, EXCLUDED.class_id, FALSE, EXCLUDED.directory_source)
+			RETURNING (xmax = 0)
+		`, nis, nisn, strings.TrimSpace(e.Name), directoryGender(e.Gender), religion, password, classID, directorySource).Scan(&inserted)
+		// No row back means the student was already up to date.
+		unchanged := errors.Is(err, pgx.ErrNoRows)
+		if err != nil && !unchanged {
+			_, _ = tx.Exec(ctx, "ROLLBACK TO SAVEPOINT directory_student")
+			s.log.Error().Err(err).Str("nis", nis).Msg("Failed to upsert directory student")
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", nis, err))
+			continue
+		}
+		_, _ = tx.Exec(ctx, "RELEASE SAVEPOINT directory_student")
+
+		switch {
+		case unchanged:
+		case inserted:
+			result.Created++
+		default:
+			result.Updated++
+		}
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE students SET status = 'transferred', status_changed_at = NOW(), updated_at = NOW()
+		WHERE directory_source = $1 AND status = 'active' AND NOT (nis = ANY($2))
+	`, directorySource, seen)
+	if err != nil {
+		return nil, fmt.Errorf("deactivate students: %w", err)
+	}
+	result.Deactivated = int(tag.RowsAffected())
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	s.log.Info().
+		Int("created", result.Created).
+		Int("updated", result.Updated).
+		Int("deactivated", result.Deactivated).
+		Int("skipped", len(result.Skipped)).
+		Msg("Student directory sync completed")
+	return result, nil
+}
+
+// fetch downloads the directory export, accepting either a bare array or {"data": [...]}.
+func (s *DirectorySyncService) fetch(ctx context.Context) ([]DirectoryEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.StudentDirectoryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.cfg.StudentDirectoryToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.StudentDirectoryToken)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch directory: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("directory returned status: %d", resp.StatusCode)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode directory: %w", err)
+	}
+
+	var entries []DirectoryEntry
+	if err := json.Unmarshal(raw, &entries); err == nil {
+		return entries, nil
+	}
+	var wrapped struct {
+		Data []DirectoryEntry `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return nil, fmt.Errorf("decode directory: %w", err)
+	}
+	return wrapped.Data, nil
+}
+
+// ensureClass maps a class name to its class, creating the class when its major exists.
+func ensureClass(ctx context.Context, tx pgx.Tx, name string) (int, error) {
+	grade, majorCode, group, ok := parseClassName(name)
+	if !ok {
+		return 0, fmt.Errorf("%q is not a class", name)
+	}
+
+	var majorExists bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM majors WHERE code = $1)", majorCode).Scan(&majorExists); err != nil {
+		return 0, err
+	}
+	if !majorExists {
+		return 0, fmt.Errorf("unknown major %q", majorCode)
+	}
+
+	var classID int
+	err := tx.QueryRow(ctx, `
+		INSERT INTO classes (grade_level, major_code, group_number)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (grade_level, major_code, group_number) DO UPDATE SET grade_level = EXCLUDED.grade_level
+		RETURNING id
+	`, grade, majorCode, group).Scan(&classID)
+	return classID, err
+}
+
+// classOUFromDN returns the innermost OU of an LDAP DN,
+// e.g. "CN=Budi,OU=XII RPL 1,OU=Siswa,DC=sekolah,DC=sch,DC=id" -> "XII RPL 1".
+func classOUFromDN(dn string) string {
+	for _, rdn := range strings.Split(dn, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(rdn), "=")
+		if ok && strings.EqualFold(key, "OU") {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// directoryGender maps the directory's gender notation onto model.Gender.
+func directoryGender(raw string) model.Gender {
+	switch strings.ToUpper(strings.TrimSpace(raw)) {
+	case "P", "F", "PEREMPUAN", "FEMALE":
+		return model.GenderFemale
+	default:
+		return model.GenderMale
+	}
+}
@@ -12,11 +12,12 @@ import (
 // StudentService handles student business logic.
 type StudentService struct {
 	studentRepo *repository.StudentRepository
+	authService *AuthService
 }
 
 // NewStudentService creates a new StudentService.
-func NewStudentService(studentRepo *repository.StudentRepository) *StudentService {
-	return &StudentService{studentRepo: studentRepo}
+func NewStudentService(studentRepo *repository.StudentRepository, authService *AuthService) *StudentService {
+	return &StudentService{studentRepo: studentRepo, authService: authService}
 }
 
 // GetByNISN retrieves a student by their NISN.
@@ -48,21 +49,11 @@ func (s *StudentService) ListStudents(ctx context.Context, filter model.StudentF
 	if err != nil {
 		return nil, nil, err
 	}
-
-	if students == nil {
-		students = []model.Student{}
+	for i := range students {
+		students[i].Password = visiblePassword(students[i].Password)
 	}
 
-	totalPages := (total + perPage - 1) / perPage
-
-	pagination := &response.Pagination{
-		Page:       page,
-		PerPage:    perPage,
-		TotalItems: total,
-		TotalPages: totalPages,
-	}
-
-	return students, pagination, nil
+	return students, response.NewPagination(page, perPage, total), nil
 }
 
 // ListStudentCards retrieves students data optimized for ID cards.
@@ -71,15 +62,20 @@ func (s *StudentService) ListStudentCards(ctx context.Context, classID *int, gra
 	if err != nil {
 		return nil, err
 	}
-	if cards == nil {
-		cards = []model.StudentCardInfo{}
+	for i := range cards {
+		cards[i].Password = visiblePassword(cards[i].Password)
 	}
 	return cards, nil
 }
 
-// Create inserts a new student with a raw password.
+// Create inserts a new student with a raw password. A password given by the admin
+// must meet the password policy; a generated one is used otherwise.
 func (s *StudentService) Create(ctx context.Context, student *model.Student) error {
-	if student.Password == "" {
+	if student.Password != "" {
+		if err := s.authService.CheckPasswordPolicy(student.Password, student.NIS, student.NISN); err != nil {
+			return err
+		}
+	} else {
 		pass, err := helper.GenerateStudentPassword()
 		if err != nil {
 			return err
@@ -91,6 +87,12 @@ func (s *StudentService) Create(ctx context.Context, student *model.Student) err
 
 // Update modifies a student's details. Updates password if provided.
 func (s *StudentService) Update(ctx context.Context, student *model.Student, updatePassword bool) error {
+	if updatePassword && student.Password != "" {
+		if err := s.authService.CheckPasswordPolicy(student.Password, student.NIS, student.NISN); err != nil {
+			return err
+		}
+	}
+
 	// 1. Update basic info
 	if err := s.studentRepo.Update(ctx, student); err != nil {
 		return err
@@ -104,7 +106,32 @@ func (s *StudentService) Update(ctx context.Context, student *model.Student, upd
 	return nil
 }
 
+// UpdateStatus moves students to status and returns the IDs whose status changed.
+// Results and sessions are kept whatever the status, so alumni keep their history.
+func (s *StudentService) UpdateStatus(ctx context.Context, ids []int, status model.StudentStatus) ([]int, error) {
+	return s.studentRepo.UpdateStatus(ctx, ids, status)
+}
+
 // Delete removes a student by ID.
 func (s *StudentService) Delete(ctx context.Context, id int) error {
 	return s.studentRepo.Delete(ctx, id)
 }
+
+// GetAccessibilitySettings retrieves a student's accessibility preferences.
+func (s *StudentService) GetAccessibilitySettings(ctx context.Context, studentID int) (*model.AccessibilitySettings, error) {
+	return s.studentRepo.GetAccessibilitySettings(ctx, studentID)
+}
+
+// UpdateAccessibilitySettings saves a student's accessibility preferences.
+func (s *StudentService) UpdateAccessibilitySettings(ctx context.Context, studentID int, req model.UpdateAccessibilitySettingsRequest) (*model.AccessibilitySettings, error) {
+	a := &model.AccessibilitySettings{
+		StudentID:    studentID,
+		FontSize:     req.FontSize,
+		HighContrast: req.HighContrast,
+		ScreenReader: req.ScreenReader,
+	}
+	if err := s.studentRepo.UpsertAccessibilitySettings(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
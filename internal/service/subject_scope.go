@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/stemsi/exstem-backend/internal/model"
+)
+
+// ErrSubjectOutOfScope is returned when an admin's role limits a permission to
+// subjects that do not include the subject being changed.
+var ErrSubjectOutOfScope = errors.New("subject is outside the permission scope")
+
+type subjectScopesKey struct{}
+
+// WithSubjectScopes returns a context carrying the admin's subject scopes
+// (permission code -> allowed subject IDs), as embedded in the admin JWT.
+func WithSubjectScopes(ctx context.Context, scopes map[string][]int) context.Context {
+	return context.WithValue(ctx, subjectScopesKey{}, scopes)
+}
+
+func subjectScopes(ctx context.Context) map[string][]int {
+	scopes, _ := ctx.Value(subjectScopesKey{}).(map[string][]int)
+	return scopes
+}
+
+// isSubjectScoped reports whether any of perms is limited to subjects in ctx.
+func isSubjectScoped(ctx context.Context, perms ...model.Permission) bool {
+	scopes := subjectScopes(ctx)
+	for _, p := range perms {
+		if _, ok := scopes[string(p)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSubjectScope verifies subjectID against the scopes of perms. Unscoped
+// permissions allow every subject; when several perms are scoped the subject may be
+// in any of their lists. Content without a subject is denied once a scope applies.
+func checkSubjectScope(ctx context.Context, subjectID *int, perms ...model.Permission) error {
+	if !isSubjectScoped(ctx, perms...) {
+		return nil
+	}
+	if subjectID == nil {
+		return ErrSubjectOutOfScope
+	}
+	scopes := subjectScopes(ctx)
+	for _, p := range perms {
+		for _, id := range scopes[string(p)] {
+			if id == *subjectID {
+				return nil
+			}
+		}
+	}
+	return ErrSubjectOutOfScope
+}
+
+// checkQBankScope verifies the subject of a question bank against the scopes of perms.
+// The bank is only loaded when one of perms is scoped.
+func (s *QuestionService) checkQBankScope(ctx context.Context, qbankID uuid.UUID, perms ...model.Permission) error {
+	if !isSubjectScoped(ctx, perms...) {
+		return nil
+	}
+	qbank, err := s.questionRepo.GetQBanks(ctx, qbankID)
+	if err != nil {
+		return err
+	}
+	return checkSubjectScope(ctx, qbank.SubjectID, perms...)
+}
+
+// checkExamScope verifies the subject of an exam's question bank against the scopes of perms.
+// An exam without a question bank has no subject yet and is allowed.
+func (s *ExamService) checkExamScope(ctx context.Context, qbankID *uuid.UUID, perms ...model.Permission) error {
+	if qbankID == nil || !isSubjectScoped(ctx, perms...) {
+		return nil
+	}
+	qbank, err := s.questionRepo.GetQBanks(ctx, *qbankID)
+	if err != nil {
+		return err
+	}
+	return checkSubjectScope(ctx, qbank.SubjectID, perms...)
+}
+
+// checkExamIDScope loads an exam and verifies its subject against the scopes of perms.
+func (s *ExamService) checkExamIDScope(ctx context.Context, examID uuid.UUID, perms ...model.Permission) error {
+	if !isSubjectScoped(ctx, perms...) {
+		return nil
+	}
+	exam, err := s.examRepo.GetByID(ctx, examID)
+	if err != nil {
+		return err
+	}
+	return s.checkExamScope(ctx, exam.QBankID, perms...)
+}
+
+// qbankWritePerms are the permissions that allow editing question banks.
+var qbankWritePerms = []model.Permission{model.PermissionQBanksWriteOwn, model.PermissionQBanksWriteAll}
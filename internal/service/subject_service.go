@@ -21,7 +21,21 @@ func NewSubjectService(subjectRepo *repository.SubjectRepository, log zerolog.Lo
 }
 
 func (s *SubjectService) GetAll(ctx context.Context) ([]model.Subject, error) {
-	return s.subjectRepo.GetAll(ctx)
+	subjects, err := s.subjectRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	teachers, err := s.subjectRepo.ListAllTeachers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subjects {
+		subjects[i].Teachers = teachers[subjects[i].ID]
+		if subjects[i].Teachers == nil {
+			subjects[i].Teachers = []model.SubjectTeacher{}
+		}
+	}
+	return subjects, nil
 }
 
 func (s *SubjectService) Create(ctx context.Context, sub *model.Subject) error {
@@ -35,3 +49,24 @@ func (s *SubjectService) Update(ctx context.Context, sub *model.Subject) error {
 func (s *SubjectService) Delete(ctx context.Context, id int) error {
 	return s.subjectRepo.Delete(ctx, id)
 }
+
+// ListTeachers returns the teachers assigned to a subject.
+func (s *SubjectService) ListTeachers(ctx context.Context, subjectID int) ([]model.SubjectTeacher, error) {
+	if _, err := s.subjectRepo.GetByID(ctx, subjectID); err != nil {
+		return nil, err
+	}
+	return s.subjectRepo.ListTeachers(ctx, subjectID)
+}
+
+// AssignTeachers assigns admins to a subject and returns its teachers.
+func (s *SubjectService) AssignTeachers(ctx context.Context, subjectID int, adminIDs []int) ([]model.SubjectTeacher, error) {
+	if err := s.subjectRepo.AssignTeachers(ctx, subjectID, adminIDs); err != nil {
+		return nil, err
+	}
+	return s.subjectRepo.ListTeachers(ctx, subjectID)
+}
+
+// UnassignTeacher removes an admin from a subject's teachers.
+func (s *SubjectService) UnassignTeacher(ctx context.Context, subjectID, adminID int) error {
+	return s.subjectRepo.UnassignTeacher(ctx, subjectID, adminID)
+}
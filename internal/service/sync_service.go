@@ -13,6 +13,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 	"github.com/stemsi/exstem-backend/internal/helper"
+	"github.com/stemsi/exstem-backend/internal/model"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -116,7 +117,7 @@ func (s *SyncService) SyncStudents(ctx context.Context) (int, error) {
 		if strings.ToUpper(strings.TrimSpace(data.Kelamin)) == "P" {
 			gender = "P"
 		}
-		religion := strings.TrimSpace(data.Agama)
+		religion := model.Religion(strings.TrimSpace(data.Agama))
 		if religion == "" {
 			religion = "-"
 		}
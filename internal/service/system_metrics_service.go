@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/repository"
+)
+
+// ErrInvalidMetricsRange is returned for a metrics history range that is not offered.
+var ErrInvalidMetricsRange = errors.New("range must be one of 1h, 6h, 24h, 7d or 30d")
+
+// DefaultMetricsHistoryRange is the range of the metrics history when none is asked for.
+const DefaultMetricsHistoryRange = "24h"
+
+// metricsHistoryRanges are the ranges the metrics history is offered over, each with
+// the step it is downsampled to, keeping a chart to a few hundred points per instance.
+var metricsHistoryRanges = map[string]struct{ span, step time.Duration }{
+	"1h":  {time.Hour, time.Minute},
+	"6h":  {6 * time.Hour, 5 * time.Minute},
+	"24h": {24 * time.Hour, 10 * time.Minute},
+	"7d":  {7 * 24 * time.Hour, time.Hour},
+	"30d": {30 * 24 * time.Hour, 4 * time.Hour},
+}
+
+// SystemMetricsService keeps the sampled system metrics history.
+type SystemMetricsService struct {
+	metricsRepo *repository.SystemMetricsRepository
+	interval    time.Duration
+}
+
+// NewSystemMetricsService creates a new SystemMetricsService. interval is how often
+// each instance samples its metrics; zero means the history is not recorded.
+func NewSystemMetricsService(metricsRepo *repository.SystemMetricsRepository, interval time.Duration) *SystemMetricsService {
+	return &SystemMetricsService{metricsRepo: metricsRepo, interval: interval}
+}
+
+// Record stores a sample.
+func (s *SystemMetricsService) Record(ctx context.Context, sample model.MetricsSample) error {
+	return s.metricsRepo.Insert(ctx, sample)
+}
+
+// Prune deletes the samples older than retention and returns how many.
+func (s *SystemMetricsService) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.metricsRepo.Prune(ctx, time.Now().Add(-retention))
+}
+
+// History returns the samples of the last rangeName, downsampled to its step.
+func (s *SystemMetricsService) History(ctx context.Context, rangeName string) (*model.MetricsHistory, error) {
+	if rangeName == "" {
+		rangeName = DefaultMetricsHistoryRange
+	}
+	r, ok := metricsHistoryRanges[rangeName]
+	if !ok {
+		return nil, ErrInvalidMetricsRange
+	}
+
+	to := time.Now()
+	from := to.Add(-r.span)
+	samples, err := s.metricsRepo.List(ctx, from, to, r.step)
+	if err != nil {
+		return nil, fmt.Errorf("list metrics samples: %w", err)
+	}
+	return &model.MetricsHistory{Range: rangeName, Step: r.step.String(), From: from, To: to, Samples: samples}, nil
+}
+
+// Uptime returns the percentage of sampling intervals within [from, to) in which any
+// instance recorded a sample, counted from the first sample of the period since the
+// history may not reach back to its start. It is nil without samples in the period.
+func (s *SystemMetricsService) Uptime(ctx context.Context, from, to time.Time) (*float64, error) {
+	if s.interval <= 0 {
+		return nil, nil
+	}
+	if now := time.Now(); to.After(now) {
+		to = now
+	}
+	first, sampled, err := s.metricsRepo.Coverage(ctx, from, to, s.interval)
+	if err != nil || first == nil {
+		return nil, err
+	}
+
+	expected := math.Ceil(float64(to.Sub(first.Truncate(s.interval))) / float64(s.interval))
+	uptime := 100.0
+	if expected > 0 && float64(sampled) < expected {
+		uptime = math.Round(float64(sampled)/expected*10000) / 100
+	}
+	return &uptime, nil
+}
@@ -0,0 +1,46 @@
+// Package storage keeps database backups outside the database.
+//
+// Two backends are available:
+//   - local (default): a directory on the server's disk. Only as safe as that disk.
+//   - s3: a bucket on any S3-compatible object storage (AWS S3, MinIO, R2, ...),
+//     addressed path-style and signed with AWS Signature Version 4.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/stemsi/exstem-backend/internal/config"
+)
+
+// ErrNotFound is returned by Get when no object has the name.
+var ErrNotFound = errors.New("object not found")
+
+// Object describes a stored object.
+type Object struct {
+	Name         string
+	Size         int64
+	LastModified time.Time
+}
+
+// Store is a flat namespace of objects.
+type Store interface {
+	// Put stores size bytes read from r under name, replacing any object of that name.
+	Put(ctx context.Context, name string, r io.Reader, size int64) error
+	// Get opens the named object and returns ErrNotFound when there is none.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns every object, in no particular order.
+	List(ctx context.Context) ([]Object, error)
+}
+
+// New returns the backup Store: the S3 bucket when one is configured, the local
+// backup directory otherwise.
+func New(cfg *config.Config) Store {
+	if cfg.BackupS3Bucket != "" {
+		return NewS3Store(cfg.BackupS3Endpoint, cfg.BackupS3Region, cfg.BackupS3Bucket, cfg.BackupS3Prefix,
+			cfg.BackupS3AccessKey, cfg.BackupS3SecretKey)
+	}
+	return NewLocalStore(cfg.BackupDir)
+}
@@ -1,14 +1,23 @@
 package websocket
 
+import "time"
+
 // ─── Actions (Client → Server) ──────────────────────────────────────
 
 type Action string
 
 const (
-	ActionAutosave Action = "autosave"
-	ActionSubmit   Action = "submit"
-	ActionPing     Action = "ping"
-	ActionCheat    Action = "cheat"
+	ActionAutosave   Action = "autosave"
+	ActionSubmit     Action = "submit"
+	ActionPing       Action = "ping"
+	ActionCheat      Action = "cheat"
+	ActionMedia      Action = "media_play"
+	ActionNavigate   Action = "navigate"
+	ActionBreakStart Action = "break_start"
+	ActionBreakEnd   Action = "break_end"
+
+	// ActionBatchAutosave saves several answers at once, e.g. changes queued offline.
+	ActionBatchAutosave Action = "batch_autosave"
 )
 
 // RequestEnvelope is used to peek at the action before full parsing.
@@ -23,12 +32,39 @@ type AutosaveRequest struct {
 	Answer string `json:"ans"`
 }
 
+// AutosaveItem is one answer of a BatchAutosaveRequest.
+type AutosaveItem struct {
+	QID    string `json:"q_id"`
+	Answer string `json:"ans"`
+}
+
+// BatchAutosaveRequest is sent by the client to save several answers in one frame,
+// e.g. the changes it queued while disconnected. Later items win over earlier ones
+// for the same question.
+type BatchAutosaveRequest struct {
+	Action  Action         `json:"action"`
+	Answers []AutosaveItem `json:"answers"`
+}
+
 // CheatRequest is sent by the client to report a cheat event.
 type CheatRequest struct {
 	Action  Action `json:"action"`
 	Payload string `json:"payload"` // Receives the JSON string directly
 }
 
+// MediaPlayRequest is sent by the client before playing a question's audio/video.
+type MediaPlayRequest struct {
+	Action Action `json:"action"`
+	QID    string `json:"q_id"`
+}
+
+// NavigateRequest is sent by the client when the student moves to a question, so
+// restricted navigation locks the questions left behind even if they stay unanswered.
+type NavigateRequest struct {
+	Action Action `json:"action"`
+	QID    string `json:"q_id"`
+}
+
 // SubmitRequest is sent by the client to finish and grade the exam.
 type SubmitRequest struct {
 	Action Action `json:"action"`
@@ -43,6 +79,16 @@ const (
 	EventSuccess Event = "success"
 	EventGraded  Event = "graded"
 	EventPong    Event = "pong"
+
+	EventPlayGranted Event = "play_granted"
+	EventPlayDenied  Event = "play_denied"
+
+	EventNavigated Event = "navigated"
+
+	EventBreakStarted Event = "break_started"
+	EventBreakEnded   Event = "break_ended"
+
+	EventBackpressure Event = "backpressure"
 )
 
 type AutosaveResponse struct {
@@ -50,15 +96,66 @@ type AutosaveResponse struct {
 	Status string `json:"status"`
 }
 
+// BackpressureResponse asks the client to debounce autosaves by DebounceMs while the
+// server is overloaded; Active false (DebounceMs 0) restores the client's default.
+type BackpressureResponse struct {
+	Event      Event `json:"event"`
+	Active     bool  `json:"active"`
+	DebounceMs int64 `json:"debounce_ms"`
+}
+
+// BatchAutosaveResponse reports how many answers of a batch were saved. Answers that
+// were refused are listed in Errors and were not saved.
+type BatchAutosaveResponse struct {
+	Event  Event           `json:"event"`
+	Status string          `json:"status"`
+	Saved  int             `json:"saved"`
+	Errors []ErrorResponse `json:"errors"`
+}
+
+type NavigateResponse struct {
+	Event        Event  `json:"event"`
+	QID          string `json:"q_id"`
+	UnlockedFrom int    `json:"unlocked_from"`
+}
+
+type BreakResponse struct {
+	Event         Event      `json:"event"`
+	BreaksUsed    int        `json:"breaks_used"`
+	BreaksAllowed int        `json:"breaks_allowed"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+}
+
 type GradedResponse struct {
 	Event  Event   `json:"event"`
 	Status string  `json:"status"`
 	Score  float64 `json:"score"`
 }
 
+type MediaPlayResponse struct {
+	Event     Event  `json:"event"`
+	QID       string `json:"q_id"`
+	PlaysUsed int    `json:"plays_used"`
+	MaxPlays  int    `json:"max_plays"` // 0 = unlimited
+}
+
+// ErrorCode identifies errors the client can react to without parsing the message.
+type ErrorCode string
+
+const (
+	ErrCodeInvalidAnswer      ErrorCode = "INVALID_ANSWER"
+	ErrCodeQuestionOutOfScope ErrorCode = "QUESTION_OUT_OF_SCOPE"
+	ErrCodeQuestionLocked     ErrorCode = "QUESTION_LOCKED"
+	ErrCodeOnBreak            ErrorCode = "ON_BREAK"
+	ErrCodeBreakNotAllowed    ErrorCode = "BREAK_NOT_ALLOWED"
+	ErrCodeAlreadyConnected   ErrorCode = "ALREADY_CONNECTED"
+)
+
 type ErrorResponse struct {
-	Event Event  `json:"event"`
-	Error string `json:"error"`
+	Event Event     `json:"event"`
+	Code  ErrorCode `json:"code,omitempty"`
+	QID   string    `json:"q_id,omitempty"`
+	Error string    `json:"error"`
 }
 
 type PongResponse struct {
@@ -1,15 +1,30 @@
 package websocket
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/stemsi/exstem-backend/internal/chaos"
 )
 
-// WriteTyped sends a strongly-typed response payload over the WebSocket.
+// compressMinBytes is the smallest message compressed when the connection negotiated
+// permessage-deflate; deflating short acks costs more than it saves.
+const compressMinBytes = 512
+
+// WriteTyped sends a strongly-typed response payload over the WebSocket. In chaos
+// mode the frame may be dropped as if lost on the way.
 func WriteTyped(conn *websocket.Conn, v interface{}) error {
+	if chaos.DropFrame() {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
 	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-	return conn.WriteJSON(v)
+	conn.EnableWriteCompression(len(data) >= compressMinBytes)
+	return conn.WriteMessage(websocket.TextMessage, data)
 }
 
 // WriteError sends a typed ErrorResponse over the WebSocket.
@@ -20,6 +35,16 @@ func WriteError(conn *websocket.Conn, errMsg string) error {
 	})
 }
 
+// WriteAnswerError sends a coded ErrorResponse about the answer to a question.
+func WriteAnswerError(conn *websocket.Conn, code ErrorCode, qid, errMsg string) error {
+	return WriteTyped(conn, ErrorResponse{
+		Event: EventError,
+		Code:  code,
+		QID:   qid,
+		Error: errMsg,
+	})
+}
+
 // ReadJSON reads and decodes a message into the provided structure.
 // It sets a read deadline.
 func ReadJSON(conn *websocket.Conn, v interface{}) error {
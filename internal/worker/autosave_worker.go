@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,6 +11,8 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/metrics"
+	"github.com/stemsi/exstem-backend/internal/queue"
 )
 
 const (
@@ -19,16 +22,21 @@ const (
 )
 
 type AutosaveWorker struct {
-	pool *pgxpool.Pool
-	rdb  *redis.Client
-	log  zerolog.Logger
+	pool  *pgxpool.Pool
+	rdb   *redis.Client
+	queue queue.Queue
+	// slo is the p95 flush lag above which the worker flags autosaves as falling behind.
+	slo time.Duration
+	log zerolog.Logger
 }
 
-func NewAutosaveWorker(pool *pgxpool.Pool, rdb *redis.Client, log zerolog.Logger) *AutosaveWorker {
+func NewAutosaveWorker(pool *pgxpool.Pool, rdb *redis.Client, q queue.Queue, slo time.Duration, log zerolog.Logger) *AutosaveWorker {
 	return &AutosaveWorker{
-		pool: pool,
-		rdb:  rdb,
-		log:  log.With().Str("component", "autosave_worker").Logger(),
+		pool:  pool,
+		rdb:   rdb,
+		queue: q,
+		slo:   slo,
+		log:   log.With().Str("component", "autosave_worker").Logger(),
 	}
 }
 
@@ -37,12 +45,28 @@ type answerPayload struct {
 	ExamID    string `json:"exam_id"`
 	QID       string `json:"q_id"`
 	Answer    string `json:"answer"`
+	// SavedAt is when the student saved the answer (unix ms). Writes only apply
+	// if they are not older than the stored row, so a queue drained after a Redis
+	// outage cannot overwrite answers written directly to Postgres in the meantime.
+	SavedAt int64 `json:"ts,omitempty"`
+	// RequestID is the ID of the WebSocket connection's upgrade request, tracing
+	// the answer back to the client frame that saved it.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// savedAt returns the time the answer was saved, or now for payloads without a timestamp.
+func (p *answerPayload) savedAt(now time.Time) time.Time {
+	if p.SavedAt == 0 {
+		return now
+	}
+	return time.UnixMilli(p.SavedAt)
 }
 
 func (w *AutosaveWorker) Start(ctx context.Context) {
-	w.log.Info().Msg("AutosaveWorker started")
+	w.log.Info().Dur("flush_slo", w.slo).Msg("AutosaveWorker started")
 
 	buffer := make([]*answerPayload, 0, AutosaveBatchSize)
+	msgs := make([]*queue.Message, 0, AutosaveBatchSize)
 	lastFlush := time.Now()
 
 	for {
@@ -51,47 +75,57 @@ func (w *AutosaveWorker) Start(ctx context.Context) {
 			(time.Since(lastFlush) >= AutosaveBatchTimeout || len(buffer) >= AutosaveBatchSize) {
 
 			w.flushSafe(ctx, buffer)
+			w.ack(ctx, msgs)
+			w.checkSLO()
 			buffer = buffer[:0]
+			msgs = msgs[:0]
 			lastFlush = time.Now()
 		}
 
 		// 2. Shutdown?
 		select {
 		case <-ctx.Done():
-			w.shutdown(buffer)
+			w.shutdown(buffer, msgs)
 			return
 		default:
 		}
 
-		// 3. Block & pop from Redis
-		result, err := w.rdb.BLPop(ctx, AutosavePollTimeout, config.WorkerKey.PersistAnswersQueue).Result()
+		// 3. Block & pop from the queue
+		msg, err := w.queue.Pop(ctx, config.WorkerKey.PersistAnswersQueue, AutosavePollTimeout)
 		if err != nil {
-			if err == redis.Nil {
+			if errors.Is(err, queue.ErrEmpty) {
 				continue
 			}
 			if ctx.Err() != nil {
-				return
+				continue // flush on the shutdown path
 			}
 			w.log.Error().Err(err).Msg("Redis error, backing off")
 			time.Sleep(time.Second)
 			continue
 		}
 
-		if len(result) < 2 {
-			continue
-		}
-
 		var p answerPayload
-		if err := json.Unmarshal([]byte(result[1]), &p); err != nil {
+		if err := json.Unmarshal(msg.Body, &p); err != nil {
 			w.log.Error().Err(err).Msg("Skipping malformed JSON")
+			w.ack(ctx, []*queue.Message{msg})
 			continue
 		}
 
 		buffer = append(buffer, &p)
+		msgs = append(msgs, msg)
+	}
+}
+
+// ack acknowledges processed messages. Failed rows were already requeued as new jobs.
+func (w *AutosaveWorker) ack(ctx context.Context, msgs []*queue.Message) {
+	if err := w.queue.Ack(ctx, config.WorkerKey.PersistAnswersQueue, queue.IDs(msgs)...); err != nil {
+		w.log.Error().Err(err).Int("count", len(msgs)).Msg("Failed to ack answers, they will be redelivered")
 	}
 }
 
 func (w *AutosaveWorker) flushSafe(ctx context.Context, batch []*answerPayload) {
+	batch = w.dropSuperseded(ctx, latestPerQuestion(batch))
+
 	toUpsert := make([]*answerPayload, 0, len(batch))
 	toDelete := make([]*answerPayload, 0, len(batch))
 
@@ -108,6 +142,8 @@ func (w *AutosaveWorker) flushSafe(ctx context.Context, batch []*answerPayload)
 		if err := w.bulkUpsert(ctx, toUpsert); err != nil {
 			w.log.Warn().Err(err).Msg("Bulk upsert failed, using fallback")
 			w.fallbackProcess(ctx, toUpsert)
+		} else {
+			w.traceFlushed(toUpsert...)
 		}
 	}
 
@@ -116,10 +152,114 @@ func (w *AutosaveWorker) flushSafe(ctx context.Context, batch []*answerPayload)
 		if err := w.bulkDelete(ctx, toDelete); err != nil {
 			w.log.Warn().Err(err).Msg("Bulk delete failed, using fallback")
 			w.fallbackProcess(ctx, toDelete)
+		} else {
+			w.traceFlushed(toDelete...)
+		}
+	}
+}
+
+// traceFlushed records the flush lag of answers written to PostgreSQL and, at debug
+// level, logs each one with the request ID it was saved under.
+func (w *AutosaveWorker) traceFlushed(batch ...*answerPayload) {
+	observeFlushed(batch...)
+	for _, p := range batch {
+		w.log.Debug().
+			Int("student_id", p.StudentID).
+			Str("exam_id", p.ExamID).
+			Str("q_id", p.QID).
+			Str("request_id", p.RequestID).
+			Msg("Answer persisted")
+	}
+}
+
+// observeFlushed samples the lag between saving answers and writing them to PostgreSQL.
+func observeFlushed(batch ...*answerPayload) {
+	now := time.Now()
+	for _, p := range batch {
+		if p.SavedAt != 0 {
+			metrics.AutosaveFlush.Observe(now.Sub(time.UnixMilli(p.SavedAt)))
 		}
 	}
 }
 
+// checkSLO flags the autosave flush lag when its p95 goes above the SLO, and clears
+// the flag once it is back under.
+func (w *AutosaveWorker) checkSLO() {
+	if w.slo <= 0 {
+		return
+	}
+	lag := metrics.AutosaveFlush.Percentiles()
+	breached := lag.P95 > w.slo
+	if !metrics.SetAutosaveSLOBreached(breached) {
+		return
+	}
+	if breached {
+		w.log.Warn().Dur("p95", lag.P95).Dur("p99", lag.P99).Dur("slo", w.slo).
+			Msg("Autosave flush lag above SLO")
+	} else {
+		w.log.Info().Dur("p95", lag.P95).Dur("slo", w.slo).Msg("Autosave flush lag back within SLO")
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////
+// DEDUPLICATION
+///////////////////////////////////////////////////////////////////////////
+
+type answerKey struct {
+	examID    string
+	studentID int
+	qid       string
+}
+
+// latestPerQuestion keeps the most recently saved answer of each exam, student and
+// question in the batch. A bulk upsert cannot touch the same row twice anyway.
+func latestPerQuestion(batch []*answerPayload) []*answerPayload {
+	latest := make(map[answerKey]int, len(batch))
+	out := make([]*answerPayload, 0, len(batch))
+	for _, p := range batch {
+		k := answerKey{p.ExamID, p.StudentID, p.QID}
+		if i, ok := latest[k]; ok {
+			if p.SavedAt >= out[i].SavedAt {
+				out[i] = p
+			}
+			continue
+		}
+		latest[k] = len(out)
+		out = append(out, p)
+	}
+	return out
+}
+
+// dropSuperseded skips answers for which a newer answer was queued since, as recorded
+// in the student's answers_queued hash; that job writes the question instead. If Redis
+// cannot be read the batch is written whole.
+func (w *AutosaveWorker) dropSuperseded(ctx context.Context, batch []*answerPayload) []*answerPayload {
+	cmds := make([]*redis.StringCmd, len(batch))
+	_, err := w.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, p := range batch {
+			cmds[i] = pipe.HGet(ctx, config.CacheKey.StudentAnswersQueuedKey(p.ExamID, p.StudentID), p.QID)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		w.log.Warn().Err(err).Msg("Failed to read queued answer markers, writing whole batch")
+		return batch
+	}
+
+	out := batch[:0]
+	for i, p := range batch {
+		queuedAt, err := cmds[i].Int64()
+		if err == nil && p.SavedAt != 0 && queuedAt > p.SavedAt {
+			continue
+		}
+		out = append(out, p)
+	}
+	if skipped := len(batch) - len(out); skipped > 0 {
+		w.log.Debug().Int("skipped", skipped).Msg("Skipped superseded answers")
+	}
+	return out
+}
+
 ///////////////////////////////////////////////////////////////////////////
 // BULK UPSERT (optimized UNNEST + column aliases)
 ///////////////////////////////////////////////////////////////////////////
@@ -143,7 +283,7 @@ func (w *AutosaveWorker) bulkUpsert(ctx context.Context, batch []*answerPayload)
 		students = append(students, p.StudentID)
 		questionIDs = append(questionIDs, qID)
 		answers = append(answers, p.Answer)
-		timestamps[i] = now
+		timestamps[i] = p.savedAt(now)
 	}
 
 	query := `
@@ -167,6 +307,7 @@ func (w *AutosaveWorker) bulkUpsert(ctx context.Context, batch []*answerPayload)
 		DO UPDATE SET 
 			answer = EXCLUDED.answer,
 			updated_at = EXCLUDED.updated_at
+		WHERE student_answers.updated_at <= EXCLUDED.updated_at
 	`
 
 	_, err := w.pool.Exec(ctx, query, examIDs, students, questionIDs, answers, timestamps)
@@ -182,8 +323,10 @@ func (w *AutosaveWorker) bulkDelete(ctx context.Context, batch []*answerPayload)
 	examIDs := make([]uuid.UUID, 0, n)
 	students := make([]int, 0, n)
 	questionIDs := make([]uuid.UUID, 0, n)
+	timestamps := make([]time.Time, n)
 
-	for _, p := range batch {
+	now := time.Now()
+	for i, p := range batch {
 		eID, err1 := uuid.Parse(p.ExamID)
 		qID, err2 := uuid.Parse(p.QID)
 		if err1 != nil || err2 != nil {
@@ -192,6 +335,7 @@ func (w *AutosaveWorker) bulkDelete(ctx context.Context, batch []*answerPayload)
 		examIDs = append(examIDs, eID)
 		students = append(students, p.StudentID)
 		questionIDs = append(questionIDs, qID)
+		timestamps[i] = p.savedAt(now)
 	}
 
 	query := `
@@ -200,19 +344,22 @@ func (w *AutosaveWorker) bulkDelete(ctx context.Context, batch []*answerPayload)
 			SELECT 
 				u.exam_id,
 				u.student_id,
-				u.question_id
+				u.question_id,
+				u.saved_at
 			FROM UNNEST(
 				$1::uuid[],
 				$2::int[],
-				$3::uuid[]
-			) AS u (exam_id, student_id, question_id)
+				$3::uuid[],
+				$4::timestamptz[]
+			) AS u (exam_id, student_id, question_id, saved_at)
 		) AS u
 		WHERE s.exam_id = u.exam_id
 		  AND s.student_id = u.student_id
 		  AND s.question_id = u.question_id
+		  AND s.updated_at <= u.saved_at
 	`
 
-	_, err := w.pool.Exec(ctx, query, examIDs, students, questionIDs)
+	_, err := w.pool.Exec(ctx, query, examIDs, students, questionIDs, timestamps)
 	return err
 }
 
@@ -227,9 +374,13 @@ func (w *AutosaveWorker) fallbackProcess(ctx context.Context, batch []*answerPay
 		if err := w.persistSingle(ctx, p); err != nil {
 			w.log.Error().Err(err).
 				Int("student_id", p.StudentID).
+				Str("q_id", p.QID).
+				Str("request_id", p.RequestID).
 				Msg("Single persist failed, requeueing")
 			requeue = append(requeue, p)
+			continue
 		}
+		w.traceFlushed(p)
 	}
 
 	if len(requeue) > 0 {
@@ -247,23 +398,26 @@ func (w *AutosaveWorker) persistSingle(ctx context.Context, p *answerPayload) er
 		return nil
 	}
 
+	savedAt := p.savedAt(time.Now())
+
 	if p.Answer == "" {
 		_, err = w.pool.Exec(ctx,
 			`DELETE FROM student_answers 
-			 WHERE exam_id=$1 AND student_id=$2 AND question_id=$3`,
-			eID, p.StudentID, qID,
+			 WHERE exam_id=$1 AND student_id=$2 AND question_id=$3 AND updated_at <= $4`,
+			eID, p.StudentID, qID, savedAt,
 		)
 		return err
 	}
 
 	_, err = w.pool.Exec(ctx,
 		`INSERT INTO student_answers (exam_id, student_id, question_id, answer, updated_at)
-		 VALUES ($1, $2, $3, $4, NOW())
+		 VALUES ($1, $2, $3, $4, $5)
 		 ON CONFLICT (exam_id, student_id, question_id)
 		 DO UPDATE SET 
 			answer = EXCLUDED.answer,
-			updated_at = NOW()`,
-		eID, p.StudentID, qID, p.Answer,
+			updated_at = EXCLUDED.updated_at
+		 WHERE student_answers.updated_at <= EXCLUDED.updated_at`,
+		eID, p.StudentID, qID, p.Answer, savedAt,
 	)
 	return err
 }
@@ -273,12 +427,12 @@ func (w *AutosaveWorker) persistSingle(ctx context.Context, p *answerPayload) er
 ///////////////////////////////////////////////////////////////////////////
 
 func (w *AutosaveWorker) requeue(ctx context.Context, items []*answerPayload) {
-	pipe := w.rdb.Pipeline()
+	bodies := make([][]byte, 0, len(items))
 	for _, p := range items {
 		data, _ := json.Marshal(p)
-		pipe.RPush(ctx, config.WorkerKey.PersistAnswersQueue, data)
+		bodies = append(bodies, data)
 	}
-	_, _ = pipe.Exec(ctx)
+	_ = w.queue.Push(ctx, config.WorkerKey.PersistAnswersQueue, bodies...)
 	time.Sleep(time.Second)
 }
 
@@ -286,7 +440,7 @@ func (w *AutosaveWorker) requeue(ctx context.Context, items []*answerPayload) {
 // SHUTDOWN
 ///////////////////////////////////////////////////////////////////////////
 
-func (w *AutosaveWorker) shutdown(batch []*answerPayload) {
+func (w *AutosaveWorker) shutdown(batch []*answerPayload, msgs []*queue.Message) {
 	w.log.Info().Msg("Worker stopping, flushing remaining buffer")
 	if len(batch) == 0 {
 		return
@@ -296,4 +450,5 @@ func (w *AutosaveWorker) shutdown(batch []*answerPayload) {
 	defer cancel()
 
 	w.flushSafe(ctx, batch)
+	w.ack(ctx, msgs)
 }
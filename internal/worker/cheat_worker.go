@@ -3,14 +3,16 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5" // useful if you need specific error checking
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/queue"
+	"github.com/stemsi/exstem-backend/internal/service"
 )
 
 const (
@@ -20,16 +22,18 @@ const (
 )
 
 type CheatWorker struct {
-	pool *pgxpool.Pool
-	rdb  *redis.Client
-	log  zerolog.Logger
+	pool     *pgxpool.Pool
+	queue    queue.Queue
+	notifier *service.NotificationService
+	log      zerolog.Logger
 }
 
-func NewCheatWorker(pool *pgxpool.Pool, rdb *redis.Client, log zerolog.Logger) *CheatWorker {
+func NewCheatWorker(pool *pgxpool.Pool, q queue.Queue, notifier *service.NotificationService, log zerolog.Logger) *CheatWorker {
 	return &CheatWorker{
-		pool: pool,
-		rdb:  rdb,
-		log:  log.With().Str("component", "cheat_worker").Logger(),
+		pool:     pool,
+		queue:    q,
+		notifier: notifier,
+		log:      log.With().Str("component", "cheat_worker").Logger(),
 	}
 }
 
@@ -38,12 +42,14 @@ type cheatPayload struct {
 	ExamID    string `json:"exam_id"`
 	Timestamp int64  `json:"timestamp"`
 	Payload   string `json:"payload"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 func (w *CheatWorker) Start(ctx context.Context) {
 	w.log.Info().Msg("CheatWorker started (Production Mode)")
 
 	buffer := make([]*cheatPayload, 0, BatchSize)
+	msgs := make([]*queue.Message, 0, BatchSize)
 	lastFlushTime := time.Now()
 
 	for {
@@ -51,7 +57,10 @@ func (w *CheatWorker) Start(ctx context.Context) {
 		if len(buffer) > 0 {
 			if len(buffer) >= BatchSize || time.Since(lastFlushTime) >= BatchTimeout {
 				w.flushSafe(ctx, buffer)
+				w.ack(ctx, msgs)
+				w.checkThresholds(buffer)
 				buffer = buffer[:0] // Clear buffer, keep capacity
+				msgs = msgs[:0]
 				lastFlushTime = time.Now()
 			}
 		}
@@ -59,22 +68,22 @@ func (w *CheatWorker) Start(ctx context.Context) {
 		// 2. Check Context (Graceful Shutdown)
 		select {
 		case <-ctx.Done():
-			w.shutdown(buffer)
+			w.shutdown(buffer, msgs)
 			return
 		default:
 			// Continue
 		}
 
-		// 3. Fetch from Redis
-		// BLPop blocks for 1 second. Returns immediately if data exists.
-		result, err := w.rdb.BLPop(ctx, PollTimeout, config.WorkerKey.PersistCheatsQueue).Result()
+		// 3. Fetch from the queue
+		// Pop blocks for 1 second. Returns immediately if data exists.
+		msg, err := w.queue.Pop(ctx, config.WorkerKey.PersistCheatsQueue, PollTimeout)
 
 		if err != nil {
-			if err == redis.Nil {
+			if errors.Is(err, queue.ErrEmpty) {
 				continue // Timeout (Queue empty), loop back to check flush timer
 			}
 			if ctx.Err() != nil {
-				return // Context cancelled
+				continue // Context cancelled, flush on the shutdown path
 			}
 			// Real Redis error (e.g., connection lost)
 			w.log.Error().Err(err).Msg("Redis connection error, sleeping 3s")
@@ -83,18 +92,49 @@ func (w *CheatWorker) Start(ctx context.Context) {
 		}
 
 		// 4. Process Data
-		if len(result) < 2 {
-			continue
-		}
-
 		var payload cheatPayload
-		if err := json.Unmarshal([]byte(result[1]), &payload); err != nil {
+		if err := json.Unmarshal(msg.Body, &payload); err != nil {
 			// If JSON is malformed, we CANNOT retry it. Log and discard.
-			w.log.Error().Err(err).Str("data", result[1]).Msg("Discarding malformed JSON")
+			w.log.Error().Err(err).Str("data", string(msg.Body)).Msg("Discarding malformed JSON")
+			w.ack(ctx, []*queue.Message{msg})
 			continue
 		}
 
 		buffer = append(buffer, &payload)
+		msgs = append(msgs, msg)
+	}
+}
+
+// checkThresholds hands the students in a flushed batch to the notifier, which alerts
+// proctors about any that reached their exam's cheat threshold. It runs in the
+// background so slow notification channels never hold up persistence.
+func (w *CheatWorker) checkThresholds(batch []*cheatPayload) {
+	if !w.notifier.Enabled() {
+		return
+	}
+
+	students := make(map[uuid.UUID][]int)
+	for _, p := range batch {
+		examID, err := uuid.Parse(p.ExamID)
+		if err != nil {
+			continue
+		}
+		students[examID] = append(students[examID], p.StudentID)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		for examID, ids := range students {
+			w.notifier.CheckCheatThresholds(ctx, examID, ids)
+		}
+	}()
+}
+
+// ack acknowledges processed messages. Failed inserts were already requeued as new jobs.
+func (w *CheatWorker) ack(ctx context.Context, msgs []*queue.Message) {
+	if err := w.queue.Ack(ctx, config.WorkerKey.PersistCheatsQueue, queue.IDs(msgs)...); err != nil {
+		w.log.Error().Err(err).Int("count", len(msgs)).Msg("Failed to ack cheat events, they will be redelivered")
 	}
 }
 
@@ -153,7 +193,7 @@ func (w *CheatWorker) fallbackInsert(ctx context.Context, batch []*cheatPayload)
 			// But for safety, we requeue everything that fails SQL insert
 			// (except obvious constraint violations if you want to be specific).
 
-			w.log.Error().Err(err).Int("student_id", p.StudentID).Msg("Insert failed, requeueing")
+			w.log.Error().Err(err).Int("student_id", p.StudentID).Str("request_id", p.RequestID).Msg("Insert failed, requeueing")
 			requeueList = append(requeueList, p)
 		}
 	}
@@ -165,13 +205,13 @@ func (w *CheatWorker) fallbackInsert(ctx context.Context, batch []*cheatPayload)
 }
 
 func (w *CheatWorker) requeue(ctx context.Context, items []*cheatPayload) {
-	// Use a pipeline to push everything back quickly
-	pipe := w.rdb.Pipeline()
+	// Push everything back in one round trip
+	bodies := make([][]byte, 0, len(items))
 	for _, p := range items {
 		data, _ := json.Marshal(p)
-		pipe.RPush(ctx, config.WorkerKey.PersistCheatsQueue, data)
+		bodies = append(bodies, data)
 	}
-	_, err := pipe.Exec(ctx)
+	err := w.queue.Push(ctx, config.WorkerKey.PersistCheatsQueue, bodies...)
 	if err != nil {
 		w.log.Error().Err(err).Msg("CRITICAL: Failed to requeue items to Redis. Data loss occurred.")
 	} else {
@@ -181,7 +221,7 @@ func (w *CheatWorker) requeue(ctx context.Context, items []*cheatPayload) {
 	}
 }
 
-func (w *CheatWorker) shutdown(buffer []*cheatPayload) {
+func (w *CheatWorker) shutdown(buffer []*cheatPayload, msgs []*queue.Message) {
 	w.log.Info().Msg("Worker stopping, flushing remaining buffer...")
 
 	// Give it 5 seconds to flush to DB
@@ -190,5 +230,6 @@ func (w *CheatWorker) shutdown(buffer []*cheatPayload) {
 
 	if len(buffer) > 0 {
 		w.flushSafe(shutdownCtx, buffer)
+		w.ack(shutdownCtx, msgs)
 	}
 }
@@ -0,0 +1,43 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+// DirectorySyncWorker periodically syncs student accounts from the school directory.
+type DirectorySyncWorker struct {
+	syncService *service.DirectorySyncService
+	interval    time.Duration
+	log         zerolog.Logger
+}
+
+func NewDirectorySyncWorker(syncService *service.DirectorySyncService, interval time.Duration, log zerolog.Logger) *DirectorySyncWorker {
+	return &DirectorySyncWorker{
+		syncService: syncService,
+		interval:    interval,
+		log:         log.With().Str("component", "directory_sync_worker").Logger(),
+	}
+}
+
+func (w *DirectorySyncWorker) Start(ctx context.Context) {
+	w.log.Info().Dur("interval", w.interval).Msg("DirectorySyncWorker started")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info().Msg("DirectorySyncWorker stopped")
+			return
+		case <-ticker.C:
+			if _, err := w.syncService.Sync(ctx); err != nil {
+				w.log.Error().Err(err).Msg("Student directory sync failed")
+			}
+		}
+	}
+}
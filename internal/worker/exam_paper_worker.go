@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/queue"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+const ExamPaperPollTimeout = 1 * time.Second
+
+// ExamPaperWorker renders the question papers admins export, one job at a time, so
+// that a large exam's PDF is not drawn inside a request.
+type ExamPaperWorker struct {
+	examService *service.ExamService
+	queue       queue.Queue
+	log         zerolog.Logger
+}
+
+func NewExamPaperWorker(examService *service.ExamService, q queue.Queue, log zerolog.Logger) *ExamPaperWorker {
+	return &ExamPaperWorker{
+		examService: examService,
+		queue:       q,
+		log:         log.With().Str("component", "exam_paper_worker").Logger(),
+	}
+}
+
+func (w *ExamPaperWorker) Start(ctx context.Context) {
+	w.log.Info().Msg("ExamPaperWorker started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info().Msg("ExamPaperWorker stopped")
+			return
+
+		default:
+			msg, err := w.queue.Pop(ctx, config.WorkerKey.ExportExamPaperQueue, ExamPaperPollTimeout)
+			if err != nil {
+				if !errors.Is(err, queue.ErrEmpty) && ctx.Err() == nil {
+					w.log.Error().Err(err).Msg("Queue pop error")
+				}
+				continue
+			}
+
+			var p service.ExamPaperPayload
+			if err := json.Unmarshal(msg.Body, &p); err != nil {
+				w.log.Error().Err(err).Msg("Invalid JSON payload")
+				w.ack(ctx, msg)
+				continue
+			}
+			jobID, err := uuid.Parse(p.JobID)
+			if err != nil {
+				w.log.Error().Err(err).Msg("Invalid exam paper payload")
+				w.ack(ctx, msg)
+				continue
+			}
+
+			// A job whose state could not be saved is left unacknowledged, so the stream
+			// backend redelivers it.
+			if err := w.examService.RunPaperExport(ctx, jobID); err != nil {
+				w.log.Error().Err(err).Str("job_id", p.JobID).Str("request_id", p.RequestID).Msg("Exam paper export failed")
+				continue
+			}
+			w.ack(ctx, msg)
+		}
+	}
+}
+
+func (w *ExamPaperWorker) ack(ctx context.Context, msg *queue.Message) {
+	if err := w.queue.Ack(ctx, config.WorkerKey.ExportExamPaperQueue, queue.IDs([]*queue.Message{msg})...); err != nil {
+		w.log.Error().Err(err).Msg("Failed to ack exam paper job, it will be redelivered")
+	}
+}
@@ -0,0 +1,206 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/queue"
+)
+
+const (
+	ExamStatsBatchSize    = 50
+	ExamStatsBatchTimeout = 5 * time.Second
+	ExamStatsPollTimeout  = 1 * time.Second
+	// ExamStatsSweepInterval spaces out sweeps for stats that went stale without a
+	// refresh job, e.g. sessions completed while Redis was down or students joining.
+	ExamStatsSweepInterval = 5 * time.Minute
+)
+
+// ExamStatsWorker keeps the exam_stats aggregates current. The scoring worker queues
+// an exam's ID whenever sessions of it complete; a periodic sweep catches the rest.
+type ExamStatsWorker struct {
+	pool  *pgxpool.Pool
+	queue queue.Queue
+	log   zerolog.Logger
+}
+
+func NewExamStatsWorker(pool *pgxpool.Pool, q queue.Queue, log zerolog.Logger) *ExamStatsWorker {
+	return &ExamStatsWorker{
+		pool:  pool,
+		queue: q,
+		log:   log.With().Str("component", "exam_stats_worker").Logger(),
+	}
+}
+
+type examStatsPayload struct {
+	ExamID string `json:"exam_id"`
+}
+
+// queueExamStats queues a stats refresh for each distinct exam ID.
+func queueExamStats(ctx context.Context, q queue.Queue, examIDs []string) error {
+	seen := make(map[string]bool, len(examIDs))
+	bodies := make([][]byte, 0, len(examIDs))
+	for _, id := range examIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		raw, _ := json.Marshal(examStatsPayload{ExamID: id})
+		bodies = append(bodies, raw)
+	}
+	if len(bodies) == 0 {
+		return nil
+	}
+	return q.Push(ctx, config.WorkerKey.RefreshExamStatsQueue, bodies...)
+}
+
+func (w *ExamStatsWorker) Start(ctx context.Context) {
+	w.log.Info().Msg("ExamStatsWorker started")
+
+	batch := make(map[uuid.UUID]bool, ExamStatsBatchSize)
+	msgs := make([]*queue.Message, 0, ExamStatsBatchSize)
+	lastFlush := time.Now()
+	lastSweep := time.Now()
+
+	for {
+		if len(msgs) > 0 &&
+			(len(msgs) >= ExamStatsBatchSize || time.Since(lastFlush) >= ExamStatsBatchTimeout) {
+
+			w.flush(ctx, batch)
+			w.ack(ctx, msgs)
+			batch = make(map[uuid.UUID]bool, ExamStatsBatchSize)
+			msgs = msgs[:0]
+			lastFlush = time.Now()
+		}
+
+		if time.Since(lastSweep) >= ExamStatsSweepInterval {
+			w.sweep(ctx)
+			lastSweep = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			w.log.Info().Msg("Shutdown requested. Flushing remaining batch...")
+			w.flush(context.Background(), batch)
+			w.ack(context.Background(), msgs)
+			return
+
+		default:
+			msg, err := w.queue.Pop(ctx, config.WorkerKey.RefreshExamStatsQueue, ExamStatsPollTimeout)
+			if err != nil {
+				if !errors.Is(err, queue.ErrEmpty) && ctx.Err() == nil {
+					w.log.Error().Err(err).Msg("Queue pop error")
+				}
+				continue
+			}
+
+			var p examStatsPayload
+			if err := json.Unmarshal(msg.Body, &p); err != nil {
+				w.log.Error().Err(err).Msg("Invalid JSON payload")
+				w.ack(ctx, []*queue.Message{msg})
+				continue
+			}
+			examID, err := uuid.Parse(p.ExamID)
+			if err != nil {
+				w.log.Error().Err(err).Msg("Invalid stats payload")
+				w.ack(ctx, []*queue.Message{msg})
+				continue
+			}
+
+			batch[examID] = true
+			msgs = append(msgs, msg)
+		}
+	}
+}
+
+// ack acknowledges processed messages. Failed refreshes are left to the sweep.
+func (w *ExamStatsWorker) ack(ctx context.Context, msgs []*queue.Message) {
+	if err := w.queue.Ack(ctx, config.WorkerKey.RefreshExamStatsQueue, queue.IDs(msgs)...); err != nil {
+		w.log.Error().Err(err).Int("count", len(msgs)).Msg("Failed to ack stats jobs, they will be redelivered")
+	}
+}
+
+func (w *ExamStatsWorker) flush(ctx context.Context, batch map[uuid.UUID]bool) {
+	if len(batch) == 0 {
+		return
+	}
+	examIDs := make([]uuid.UUID, 0, len(batch))
+	for id := range batch {
+		examIDs = append(examIDs, id)
+	}
+	if err := w.refresh(ctx, examIDs); err != nil {
+		w.log.Error().Err(err).Int("exams", len(examIDs)).Msg("Failed to refresh exam stats")
+	}
+}
+
+// sweep refreshes the stats of exams with sessions started or finished since their
+// stats were last computed.
+func (w *ExamStatsWorker) sweep(ctx context.Context) {
+	rows, err := w.pool.Query(ctx, `
+		SELECT s.exam_id
+		FROM exam_sessions s
+		LEFT JOIN exam_stats st ON st.exam_id = s.exam_id
+		GROUP BY s.exam_id, st.updated_at
+		HAVING st.updated_at IS NULL
+		    OR MAX(GREATEST(s.started_at, COALESCE(s.finished_at, s.started_at))) > st.updated_at
+	`)
+	if err != nil {
+		w.log.Error().Err(err).Msg("Failed to find stale exam stats")
+		return
+	}
+	var examIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			w.log.Error().Err(err).Msg("Failed to find stale exam stats")
+			return
+		}
+		examIDs = append(examIDs, id)
+	}
+	rows.Close()
+	if len(examIDs) == 0 {
+		return
+	}
+
+	if err := w.refresh(ctx, examIDs); err != nil {
+		w.log.Error().Err(err).Int("exams", len(examIDs)).Msg("Failed to refresh stale exam stats")
+		return
+	}
+	w.log.Debug().Int("exams", len(examIDs)).Msg("Stale exam stats refreshed")
+}
+
+// refresh recomputes the aggregates of the given exams from exam_sessions.
+func (w *ExamStatsWorker) refresh(ctx context.Context, examIDs []uuid.UUID) error {
+	_, err := w.pool.Exec(ctx, `
+		INSERT INTO exam_stats
+			(exam_id, participants, completed, average_score, median_score, stddev_score, completion_rate, updated_at)
+		SELECT e.id,
+		       COUNT(s.id),
+		       COUNT(s.id) FILTER (WHERE s.status = 'COMPLETED'),
+		       AVG(s.final_score) FILTER (WHERE s.status = 'COMPLETED'),
+		       percentile_cont(0.5) WITHIN GROUP (ORDER BY s.final_score) FILTER (WHERE s.status = 'COMPLETED'),
+		       stddev_pop(s.final_score) FILTER (WHERE s.status = 'COMPLETED'),
+		       COALESCE(COUNT(s.id) FILTER (WHERE s.status = 'COMPLETED')::float8 / NULLIF(COUNT(s.id), 0), 0),
+		       NOW()
+		FROM exams e
+		LEFT JOIN exam_sessions s ON s.exam_id = e.id
+		WHERE e.id = ANY($1)
+		GROUP BY e.id
+		ON CONFLICT (exam_id) DO UPDATE SET
+			participants    = EXCLUDED.participants,
+			completed       = EXCLUDED.completed,
+			average_score   = EXCLUDED.average_score,
+			median_score    = EXCLUDED.median_score,
+			stddev_score    = EXCLUDED.stddev_score,
+			completion_rate = EXCLUDED.completion_rate,
+			updated_at      = EXCLUDED.updated_at
+	`, examIDs)
+	return err
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+const ExamStatusInterval = 30 * time.Second
+
+// ExamStatusWorker is the exam scheduler: it periodically advances exams through
+// PUBLISHED → IN_PROGRESS → COMPLETED based on their sessions and schedule.
+type ExamStatusWorker struct {
+	examService *service.ExamService
+	notifier    *service.NotificationService
+	log         zerolog.Logger
+}
+
+func NewExamStatusWorker(examService *service.ExamService, notifier *service.NotificationService, log zerolog.Logger) *ExamStatusWorker {
+	return &ExamStatusWorker{
+		examService: examService,
+		notifier:    notifier,
+		log:         log.With().Str("component", "exam_status_worker").Logger(),
+	}
+}
+
+func (w *ExamStatusWorker) Start(ctx context.Context) {
+	w.log.Info().Msg("ExamStatusWorker started")
+
+	ticker := time.NewTicker(ExamStatusInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info().Msg("ExamStatusWorker stopped")
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+func (w *ExamStatusWorker) runOnce(ctx context.Context) {
+	started, completed, err := w.examService.AdvanceStatuses(ctx)
+	if err != nil {
+		w.log.Error().Err(err).Msg("Exam status transition failed")
+		return
+	}
+	if len(started) > 0 || len(completed) > 0 {
+		w.log.Info().Int("in_progress", len(started)).Int("completed", len(completed)).Msg("Exam statuses advanced")
+	}
+	for _, examID := range started {
+		w.notifier.NotifyExamStarted(ctx, examID)
+	}
+	for _, examID := range completed {
+		w.notifier.NotifyGuardiansOfResults(ctx, examID)
+		w.notifier.SendHomeroomSummaries(ctx, examID)
+	}
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+const (
+	MediaCleanupInterval    = 1 * time.Hour
+	MediaCleanupGracePeriod = 24 * time.Hour // Leave fresh uploads alone while questions are being drafted
+)
+
+// MediaCleanupWorker periodically removes media files no question references.
+type MediaCleanupWorker struct {
+	mediaService *service.MediaService
+	log          zerolog.Logger
+}
+
+func NewMediaCleanupWorker(mediaService *service.MediaService, log zerolog.Logger) *MediaCleanupWorker {
+	return &MediaCleanupWorker{
+		mediaService: mediaService,
+		log:          log.With().Str("component", "media_cleanup_worker").Logger(),
+	}
+}
+
+func (w *MediaCleanupWorker) Start(ctx context.Context) {
+	w.log.Info().Msg("MediaCleanupWorker started")
+
+	ticker := time.NewTicker(MediaCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info().Msg("MediaCleanupWorker stopped")
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+func (w *MediaCleanupWorker) runOnce(ctx context.Context) {
+	removed, err := w.mediaService.CleanupOrphans(ctx, MediaCleanupGracePeriod)
+	if err != nil {
+		w.log.Error().Err(err).Int("removed", removed).Msg("Orphaned media cleanup failed")
+		return
+	}
+	if removed > 0 {
+		w.log.Info().Int("removed", removed).Msg("Orphaned media cleaned up")
+	}
+}
@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/model"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+// MetricsHistoryPruneInterval is how often samples past the retention are deleted.
+const MetricsHistoryPruneInterval = time.Hour
+
+// MetricsHistoryWorker records this instance's system metrics into the metrics
+// history every interval and prunes samples older than the retention.
+type MetricsHistoryWorker struct {
+	metricsService *service.SystemMetricsService
+	sample         func() model.MetricsSample
+	instance       string
+	interval       time.Duration
+	retention      time.Duration
+	log            zerolog.Logger
+}
+
+// NewMetricsHistoryWorker creates a new MetricsHistoryWorker. sample collects the
+// instance's current metrics.
+func NewMetricsHistoryWorker(metricsService *service.SystemMetricsService, sample func() model.MetricsSample, instance string, interval, retention time.Duration, log zerolog.Logger) *MetricsHistoryWorker {
+	return &MetricsHistoryWorker{
+		metricsService: metricsService,
+		sample:         sample,
+		instance:       instance,
+		interval:       interval,
+		retention:      retention,
+		log:            log.With().Str("component", "metrics_history_worker").Logger(),
+	}
+}
+
+func (w *MetricsHistoryWorker) Start(ctx context.Context) {
+	w.log.Info().Dur("interval", w.interval).Dur("retention", w.retention).Msg("MetricsHistoryWorker started")
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	pruneTicker := time.NewTicker(MetricsHistoryPruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info().Msg("MetricsHistoryWorker stopped")
+			return
+		case now := <-ticker.C:
+			s := w.sample()
+			s.Instance = w.instance
+			s.RecordedAt = now
+			if err := w.metricsService.Record(ctx, s); err != nil {
+				w.log.Warn().Err(err).Msg("Metrics sample not recorded")
+			}
+		case <-pruneTicker.C:
+			// Every instance prunes; the deletes are idempotent.
+			n, err := w.metricsService.Prune(ctx, w.retention)
+			if err != nil {
+				w.log.Warn().Err(err).Msg("Metrics history not pruned")
+			} else if n > 0 {
+				w.log.Debug().Int64("samples", n).Msg("Metrics history pruned")
+			}
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/queue"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+const (
+	MonitorRecordBatchSize    = 200
+	MonitorRecordBatchTimeout = 2 * time.Second
+	MonitorRecordPollTimeout  = 1 * time.Second
+	// MonitorSnapshotInterval spaces out the progress snapshots recorded for running exams.
+	MonitorSnapshotInterval = time.Minute
+)
+
+// MonitorRecordWorker stores the events sent to live monitors in exam_monitor_events
+// and, every MonitorSnapshotInterval, a progress snapshot of each running exam, so
+// an exam can be replayed afterwards.
+type MonitorRecordWorker struct {
+	pool    *pgxpool.Pool
+	rdb     *redis.Client
+	queue   queue.Queue
+	monitor *service.MonitorService
+	log     zerolog.Logger
+}
+
+func NewMonitorRecordWorker(pool *pgxpool.Pool, rdb *redis.Client, q queue.Queue, monitor *service.MonitorService, log zerolog.Logger) *MonitorRecordWorker {
+	return &MonitorRecordWorker{
+		pool:    pool,
+		rdb:     rdb,
+		queue:   q,
+		monitor: monitor,
+		log:     log.With().Str("component", "monitor_record_worker").Logger(),
+	}
+}
+
+func (w *MonitorRecordWorker) Start(ctx context.Context) {
+	w.log.Info().Msg("MonitorRecordWorker started")
+
+	batch := make([]*service.MonitorEventJob, 0, MonitorRecordBatchSize)
+	msgs := make([]*queue.Message, 0, MonitorRecordBatchSize)
+	lastFlush := time.Now()
+	lastSnapshot := time.Now()
+
+	for {
+		if len(batch) > 0 &&
+			(len(batch) >= MonitorRecordBatchSize || time.Since(lastFlush) >= MonitorRecordBatchTimeout) {
+
+			w.flush(ctx, batch)
+			w.ack(ctx, msgs)
+			batch = batch[:0]
+			msgs = msgs[:0]
+			lastFlush = time.Now()
+		}
+
+		if time.Since(lastSnapshot) >= MonitorSnapshotInterval {
+			w.snapshot(ctx)
+			lastSnapshot = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			w.log.Info().Msg("Shutdown requested. Flushing remaining batch...")
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			w.flush(shutdownCtx, batch)
+			w.ack(shutdownCtx, msgs)
+			cancel()
+			return
+
+		default:
+			msg, err := w.queue.Pop(ctx, config.WorkerKey.RecordMonitorEventsQueue, MonitorRecordPollTimeout)
+			if err != nil {
+				if !errors.Is(err, queue.ErrEmpty) && ctx.Err() == nil {
+					w.log.Error().Err(err).Msg("Queue pop error, sleeping 3s")
+					time.Sleep(3 * time.Second)
+				}
+				continue
+			}
+
+			var job service.MonitorEventJob
+			if err := json.Unmarshal(msg.Body, &job); err != nil {
+				w.log.Error().Err(err).Str("data", string(msg.Body)).Msg("Discarding malformed monitor event")
+				w.ack(ctx, []*queue.Message{msg})
+				continue
+			}
+			if _, err := uuid.Parse(job.ExamID); err != nil {
+				w.log.Error().Str("exam_id", job.ExamID).Msg("Discarding monitor event with invalid exam ID")
+				w.ack(ctx, []*queue.Message{msg})
+				continue
+			}
+
+			batch = append(batch, &job)
+			msgs = append(msgs, msg)
+		}
+	}
+}
+
+// ack acknowledges processed messages. Failed inserts were already requeued as new jobs.
+func (w *MonitorRecordWorker) ack(ctx context.Context, msgs []*queue.Message) {
+	if len(msgs) == 0 {
+		return
+	}
+	if err := w.queue.Ack(ctx, config.WorkerKey.RecordMonitorEventsQueue, queue.IDs(msgs)...); err != nil {
+		w.log.Error().Err(err).Int("count", len(msgs)).Msg("Failed to ack monitor events, they will be redelivered")
+	}
+}
+
+// flush stores a batch of events, requeueing it when PostgreSQL refuses it.
+func (w *MonitorRecordWorker) flush(ctx context.Context, batch []*service.MonitorEventJob) {
+	if len(batch) == 0 {
+		return
+	}
+	err := w.insert(ctx, batch)
+	if err == nil {
+		return
+	}
+	w.log.Warn().Err(err).Int("count", len(batch)).Msg("Monitor event insert failed, requeueing")
+
+	bodies := make([][]byte, 0, len(batch))
+	for _, job := range batch {
+		data, _ := json.Marshal(job)
+		bodies = append(bodies, data)
+	}
+	if err := w.queue.Push(ctx, config.WorkerKey.RecordMonitorEventsQueue, bodies...); err != nil {
+		w.log.Error().Err(err).Int("count", len(batch)).Msg("Failed to requeue monitor events, recording lost")
+		return
+	}
+	// Avoid thrashing while the database is down.
+	time.Sleep(2 * time.Second)
+}
+
+func (w *MonitorRecordWorker) insert(ctx context.Context, batch []*service.MonitorEventJob) error {
+	rows := make([][]interface{}, 0, len(batch))
+	for _, job := range batch {
+		examID, err := uuid.Parse(job.ExamID)
+		if err != nil {
+			continue
+		}
+		rows = append(rows, []interface{}{
+			examID, job.Type, string(job.Payload), time.UnixMilli(job.RecordedAt),
+		})
+	}
+
+	_, err := w.pool.CopyFrom(
+		ctx,
+		pgx.Identifier{"exam_monitor_events"},
+		[]string{"exam_id", "event_type", "payload", "recorded_at"},
+		pgx.CopyFromRows(rows),
+	)
+	return err
+}
+
+// snapshot records the progress of every running exam: the answered and cheat counts
+// of each student, as the monitor's refresh events carry them. Only one instance
+// records each interval.
+func (w *MonitorRecordWorker) snapshot(ctx context.Context) {
+	ok, err := w.rdb.SetNX(ctx, config.CacheKey.MonitorProgressSnapshotLockKey(), 1, MonitorSnapshotInterval-5*time.Second).Result()
+	if err != nil || !ok {
+		return
+	}
+
+	rows, err := w.pool.Query(ctx, `SELECT id FROM exams WHERE status = 'IN_PROGRESS'`)
+	if err != nil {
+		w.log.Error().Err(err).Msg("Failed to list running exams for progress snapshots")
+		return
+	}
+	examIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		w.log.Error().Err(err).Msg("Failed to list running exams for progress snapshots")
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	batch := make([]*service.MonitorEventJob, 0, len(examIDs))
+	for _, examID := range examIDs {
+		progress, err := w.monitor.GetStudentProgress(ctx, examID)
+		if err != nil {
+			w.log.Warn().Err(err).Str("exam_id", examID.String()).Msg("Failed to fetch progress for snapshot")
+			continue
+		}
+
+		students := make([]map[string]interface{}, 0, len(progress.AnsweredCounts))
+		for sid, answered := range progress.AnsweredCounts {
+			students = append(students, map[string]interface{}{
+				"student_id":     sid,
+				"answered_count": answered,
+				"cheat_count":    progress.CheatCounts[sid],
+			})
+		}
+		payload, _ := json.Marshal(map[string]interface{}{
+			"type":         "progress",
+			"total_cheats": progress.TotalCheats,
+			"students":     students,
+		})
+		batch = append(batch, &service.MonitorEventJob{
+			ExamID:     examID.String(),
+			Type:       "progress",
+			Payload:    payload,
+			RecordedAt: now,
+		})
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+	if err := w.insert(ctx, batch); err != nil {
+		w.log.Error().Err(err).Int("exams", len(batch)).Msg("Failed to record progress snapshots")
+	}
+}
@@ -3,13 +3,14 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/queue"
 )
 
 const (
@@ -19,16 +20,16 @@ const (
 )
 
 type QuestionOrderWorker struct {
-	pool *pgxpool.Pool
-	rdb  *redis.Client
-	log  zerolog.Logger
+	pool  *pgxpool.Pool
+	queue queue.Queue
+	log   zerolog.Logger
 }
 
-func NewQuestionOrderWorker(pool *pgxpool.Pool, rdb *redis.Client, log zerolog.Logger) *QuestionOrderWorker {
+func NewQuestionOrderWorker(pool *pgxpool.Pool, q queue.Queue, log zerolog.Logger) *QuestionOrderWorker {
 	return &QuestionOrderWorker{
-		pool: pool,
-		rdb:  rdb,
-		log:  log.With().Str("component", "question_order_worker").Logger(),
+		pool:  pool,
+		queue: q,
+		log:   log.With().Str("component", "question_order_worker").Logger(),
 	}
 }
 
@@ -36,12 +37,14 @@ type questionOrderPayload struct {
 	ExamID    string   `json:"exam_id"`
 	StudentID int      `json:"student_id"`
 	Order     []string `json:"order"`
+	RequestID string   `json:"request_id,omitempty"`
 }
 
 func (w *QuestionOrderWorker) Start(ctx context.Context) {
 	w.log.Info().Msg("QuestionOrderWorker started")
 
 	batch := make([]*questionOrderPayload, 0, QuestionOrderBatchSize)
+	msgs := make([]*queue.Message, 0, QuestionOrderBatchSize)
 	lastFlush := time.Now()
 
 	for {
@@ -49,7 +52,9 @@ func (w *QuestionOrderWorker) Start(ctx context.Context) {
 			(len(batch) >= QuestionOrderBatchSize || time.Since(lastFlush) >= QuestionOrderBatchTimeout) {
 
 			w.flushSafe(ctx, batch)
+			w.ack(ctx, msgs)
 			batch = batch[:0]
+			msgs = msgs[:0]
 			lastFlush = time.Now()
 		}
 
@@ -57,32 +62,38 @@ func (w *QuestionOrderWorker) Start(ctx context.Context) {
 		case <-ctx.Done():
 			w.log.Info().Msg("Shutdown requested. Flushing remaining batch...")
 			w.flushSafe(context.Background(), batch)
+			w.ack(context.Background(), msgs)
 			return
 
 		default:
-			item, err := w.rdb.BLPop(ctx, QuestionOrderPollTimeout, config.WorkerKey.PersistQuestionOrderQueue).Result()
+			msg, err := w.queue.Pop(ctx, config.WorkerKey.PersistQuestionOrderQueue, QuestionOrderPollTimeout)
 			if err != nil {
-				if err != redis.Nil && ctx.Err() == nil {
-					w.log.Error().Err(err).Msg("BLPop error")
+				if !errors.Is(err, queue.ErrEmpty) && ctx.Err() == nil {
+					w.log.Error().Err(err).Msg("Queue pop error")
 				}
 				continue
 			}
 
-			if len(item) < 2 {
-				continue
-			}
-
 			var p questionOrderPayload
-			if err := json.Unmarshal([]byte(item[1]), &p); err != nil {
+			if err := json.Unmarshal(msg.Body, &p); err != nil {
 				w.log.Error().Err(err).Msg("Invalid JSON payload")
+				w.ack(ctx, []*queue.Message{msg})
 				continue
 			}
 
 			batch = append(batch, &p)
+			msgs = append(msgs, msg)
 		}
 	}
 }
 
+// ack acknowledges processed messages. Failed rows were already requeued as new jobs.
+func (w *QuestionOrderWorker) ack(ctx context.Context, msgs []*queue.Message) {
+	if err := w.queue.Ack(ctx, config.WorkerKey.PersistQuestionOrderQueue, queue.IDs(msgs)...); err != nil {
+		w.log.Error().Err(err).Int("count", len(msgs)).Msg("Failed to ack question orders, they will be redelivered")
+	}
+}
+
 func (w *QuestionOrderWorker) flushSafe(ctx context.Context, batch []*questionOrderPayload) {
 	if len(batch) == 0 {
 		return
@@ -93,9 +104,9 @@ func (w *QuestionOrderWorker) flushSafe(ctx context.Context, batch []*questionOr
 
 		for _, p := range batch {
 			if err := w.persistSingle(ctx, p); err != nil {
-				w.log.Error().Err(err).Msg("persistSingle failed — requeueing")
+				w.log.Error().Err(err).Int("student_id", p.StudentID).Str("request_id", p.RequestID).Msg("persistSingle failed — requeueing")
 				raw, _ := json.Marshal(p)
-				w.rdb.RPush(ctx, config.WorkerKey.PersistQuestionOrderQueue, raw)
+				_ = w.queue.Push(ctx, config.WorkerKey.PersistQuestionOrderQueue, raw)
 			}
 		}
 	}
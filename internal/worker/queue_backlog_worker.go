@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/queue"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+const QueueBacklogInterval = time.Minute
+
+// QueueBacklogWorker watches the worker queues and alerts proctors of running
+// exams when any of them grows past the configured threshold.
+type QueueBacklogWorker struct {
+	queue     queue.Queue
+	notifier  *service.NotificationService
+	threshold int64
+	log       zerolog.Logger
+}
+
+func NewQueueBacklogWorker(q queue.Queue, notifier *service.NotificationService, threshold int64, log zerolog.Logger) *QueueBacklogWorker {
+	return &QueueBacklogWorker{
+		queue:     q,
+		notifier:  notifier,
+		threshold: threshold,
+		log:       log.With().Str("component", "queue_backlog_worker").Logger(),
+	}
+}
+
+func (w *QueueBacklogWorker) Start(ctx context.Context) {
+	w.log.Info().Int64("threshold", w.threshold).Msg("QueueBacklogWorker started")
+
+	ticker := time.NewTicker(QueueBacklogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info().Msg("QueueBacklogWorker stopped")
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+func (w *QueueBacklogWorker) runOnce(ctx context.Context) {
+	names := []string{
+		config.WorkerKey.PersistAnswersQueue,
+		config.WorkerKey.PersistCheatsQueue,
+		config.WorkerKey.PersistScoresQueue,
+		config.WorkerKey.PersistQuestionOrderQueue,
+	}
+
+	backlog := make(map[string]int64)
+	for _, name := range names {
+		n, err := w.queue.Len(ctx, name)
+		if err != nil {
+			w.log.Warn().Err(err).Str("queue", name).Msg("Failed to read queue length")
+			continue
+		}
+		if n >= w.threshold {
+			backlog[name] = n
+		}
+	}
+
+	if len(backlog) > 0 {
+		w.log.Warn().Interface("backlog", backlog).Msg("Worker queues backing up")
+		w.notifier.NotifyQueueBacklog(ctx, backlog)
+	}
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/metrics"
+)
+
+const (
+	// RequestStatsFlushInterval is how often the request counts are added to Redis.
+	RequestStatsFlushInterval = 10 * time.Second
+	// RequestStatsTTL keeps each minute's counts long enough for the monitor overview.
+	RequestStatsTTL = 15 * time.Minute
+)
+
+// RequestStatsWorker adds this instance's request and server error counts to the
+// per-minute counters in Redis, so the monitor overview reports them for all instances.
+type RequestStatsWorker struct {
+	rdb *redis.Client
+	log zerolog.Logger
+}
+
+func NewRequestStatsWorker(rdb *redis.Client, log zerolog.Logger) *RequestStatsWorker {
+	return &RequestStatsWorker{
+		rdb: rdb,
+		log: log.With().Str("component", "request_stats_worker").Logger(),
+	}
+}
+
+func (w *RequestStatsWorker) Start(ctx context.Context) {
+	w.log.Info().Msg("RequestStatsWorker started")
+
+	ticker := time.NewTicker(RequestStatsFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info().Msg("RequestStatsWorker stopped")
+			return
+		case now := <-ticker.C:
+			w.flush(ctx, now)
+		}
+	}
+}
+
+// flush reports the counts under the current minute. Counts that cannot be reported
+// are kept for the next flush.
+func (w *RequestStatsWorker) flush(ctx context.Context, now time.Time) {
+	total, failed := metrics.Requests.Take()
+	if total == 0 {
+		return
+	}
+
+	key := config.CacheKey.RequestStatsKey(now.Unix() / 60)
+	pipe := w.rdb.Pipeline()
+	pipe.HIncrBy(ctx, key, "total", total)
+	pipe.HIncrBy(ctx, key, "failed", failed)
+	pipe.Expire(ctx, key, RequestStatsTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		metrics.Requests.Restore(total, failed)
+		w.log.Debug().Err(err).Msg("Request stats not flushed")
+	}
+}
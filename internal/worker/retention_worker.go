@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/service"
+)
+
+// RetentionCheckInterval is how often the worker checks whether the nightly run is due.
+const RetentionCheckInterval = 10 * time.Minute
+
+// RetentionWorker applies the data retention rules once a night, during runHour.
+type RetentionWorker struct {
+	retentionService *service.RetentionService
+	rdb              *redis.Client
+	runHour          int
+	log              zerolog.Logger
+}
+
+func NewRetentionWorker(retentionService *service.RetentionService, rdb *redis.Client, runHour int, log zerolog.Logger) *RetentionWorker {
+	return &RetentionWorker{
+		retentionService: retentionService,
+		rdb:              rdb,
+		runHour:          runHour,
+		log:              log.With().Str("component", "retention_worker").Logger(),
+	}
+}
+
+func (w *RetentionWorker) Start(ctx context.Context) {
+	w.log.Info().Int("run_hour", w.runHour).Msg("RetentionWorker started")
+
+	ticker := time.NewTicker(RetentionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info().Msg("RetentionWorker stopped")
+			return
+		case now := <-ticker.C:
+			if now.Hour() == w.runHour {
+				w.runOnce(ctx, now)
+			}
+		}
+	}
+}
+
+// runOnce applies the rules unless this or another instance already did today.
+func (w *RetentionWorker) runOnce(ctx context.Context, now time.Time) {
+	key := config.CacheKey.RetentionRunLockKey(now.Format("2006-01-02"))
+	ok, err := w.rdb.SetNX(ctx, key, 1, 24*time.Hour).Result()
+	if err != nil || !ok {
+		return
+	}
+
+	results, err := w.retentionService.Run(ctx)
+	var rows int64
+	for _, r := range results {
+		rows += r.Rows
+	}
+	if err != nil {
+		w.log.Error().Err(err).Int64("rows", rows).Msg("Retention run failed")
+		return
+	}
+	w.log.Info().Int("rules", len(results)).Int64("rows", rows).Msg("Retention run finished")
+}
@@ -3,6 +3,8 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,6 +12,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/stemsi/exstem-backend/internal/config"
+	"github.com/stemsi/exstem-backend/internal/queue"
 )
 
 const (
@@ -19,16 +22,18 @@ const (
 )
 
 type ScoringWorker struct {
-	pool *pgxpool.Pool
-	rdb  *redis.Client
-	log  zerolog.Logger
+	pool  *pgxpool.Pool
+	rdb   *redis.Client
+	queue queue.Queue
+	log   zerolog.Logger
 }
 
-func NewScoringWorker(pool *pgxpool.Pool, rdb *redis.Client, log zerolog.Logger) *ScoringWorker {
+func NewScoringWorker(pool *pgxpool.Pool, rdb *redis.Client, q queue.Queue, log zerolog.Logger) *ScoringWorker {
 	return &ScoringWorker{
-		pool: pool,
-		rdb:  rdb,
-		log:  log.With().Str("component", "scoring_worker").Logger(),
+		pool:  pool,
+		rdb:   rdb,
+		queue: q,
+		log:   log.With().Str("component", "scoring_worker").Logger(),
 	}
 }
 
@@ -36,6 +41,7 @@ type scorePayload struct {
 	StudentID int     `json:"student_id"`
 	ExamID    string  `json:"exam_id"`
 	Score     float64 `json:"score"`
+	RequestID string  `json:"request_id,omitempty"`
 }
 
 // ----------------------------------------------------------------
@@ -46,15 +52,17 @@ func (w *ScoringWorker) Start(ctx context.Context) {
 	w.log.Info().Msg("ScoringWorker started")
 
 	batch := make([]*scorePayload, 0, ScoreBatchSize)
+	msgs := make([]*queue.Message, 0, ScoreBatchSize)
 	lastFlush := time.Now()
 
 	for {
-		// Should flush?
 		if len(batch) > 0 &&
 			(len(batch) >= ScoreBatchSize || time.Since(lastFlush) >= ScoreBatchTimeout) {
 
 			w.flushSafe(ctx, batch)
+			w.ack(ctx, msgs)
 			batch = batch[:0]
+			msgs = msgs[:0]
 			lastFlush = time.Now()
 		}
 
@@ -62,35 +70,37 @@ func (w *ScoringWorker) Start(ctx context.Context) {
 		case <-ctx.Done():
 			w.log.Info().Msg("Shutdown requested. Flushing remaining batch...")
 			w.flushSafe(context.Background(), batch)
+			w.ack(context.Background(), msgs)
 			return
 
 		default:
-			item, err := w.rdb.BLPop(ctx, ScorePollTimeout, config.WorkerKey.PersistScoresQueue).Result()
+			msg, err := w.queue.Pop(ctx, config.WorkerKey.PersistScoresQueue, ScorePollTimeout)
 			if err != nil {
-				if err != redis.Nil && ctx.Err() == nil {
-					w.log.Error().Err(err).Msg("BLPop error")
+				if !errors.Is(err, queue.ErrEmpty) && ctx.Err() == nil {
+					w.log.Error().Err(err).Msg("Queue pop error")
 				}
 				continue
 			}
 
-			if len(item) < 2 {
-				continue
-			}
-
 			var p scorePayload
-			if err := json.Unmarshal([]byte(item[1]), &p); err != nil {
+			if err := json.Unmarshal(msg.Body, &p); err != nil {
 				w.log.Error().Err(err).Msg("Invalid JSON payload")
+				w.ack(ctx, []*queue.Message{msg})
 				continue
 			}
 
 			batch = append(batch, &p)
+			msgs = append(msgs, msg)
 		}
 	}
 }
 
-// ----------------------------------------------------------------
-// Batch Upsert/Update Wrapper
-// ----------------------------------------------------------------
+// ack acknowledges processed messages. Failed rows were already requeued as new jobs.
+func (w *ScoringWorker) ack(ctx context.Context, msgs []*queue.Message) {
+	if err := w.queue.Ack(ctx, config.WorkerKey.PersistScoresQueue, queue.IDs(msgs)...); err != nil {
+		w.log.Error().Err(err).Int("count", len(msgs)).Msg("Failed to ack scores, they will be redelivered")
+	}
+}
 
 func (w *ScoringWorker) flushSafe(ctx context.Context, batch []*scorePayload) {
 	if len(batch) == 0 {
@@ -100,18 +110,35 @@ func (w *ScoringWorker) flushSafe(ctx context.Context, batch []*scorePayload) {
 	if err := w.bulkUpdateScores(ctx, batch); err != nil {
 		w.log.Warn().Err(err).Msg("bulk score update failed, using fallback")
 
+		var persisted []string
 		for _, p := range batch {
 			if err := w.persistSingle(ctx, p); err != nil {
-				w.log.Error().Err(err).Msg("persistSingle failed — requeueing")
+				w.log.Error().Err(err).Int("student_id", p.StudentID).Str("request_id", p.RequestID).Msg("persistSingle failed — requeueing")
 				raw, _ := json.Marshal(p)
-				w.rdb.RPush(ctx, config.WorkerKey.PersistScoresQueue, raw)
+				_ = w.queue.Push(ctx, config.WorkerKey.PersistScoresQueue, raw)
+				continue
 			}
+			persisted = append(persisted, p.ExamID)
 		}
+		w.queueStats(ctx, persisted)
 		return
 	}
 
 	// After successful score updates → delete autosave buffers in Redis
 	w.bulkClearAutosavedAnswers(ctx, batch)
+
+	examIDs := make([]string, len(batch))
+	for i, p := range batch {
+		examIDs[i] = p.ExamID
+	}
+	w.queueStats(ctx, examIDs)
+}
+
+// queueStats asks the stats worker to refresh the aggregates of exams whose sessions completed.
+func (w *ScoringWorker) queueStats(ctx context.Context, examIDs []string) {
+	if err := queueExamStats(ctx, w.queue, examIDs); err != nil {
+		w.log.Warn().Err(err).Msg("Failed to queue exam stats refresh")
+	}
 }
 
 // ----------------------------------------------------------------
@@ -158,6 +185,7 @@ func (w *ScoringWorker) bulkUpdateScores(ctx context.Context, batch []*scorePayl
 		) AS t
 		WHERE s.exam_id = t.exam_id
 		  AND s.student_id = t.student_id
+		  AND s.status <> 'COMPLETED'
 	`
 
 	_, err := w.pool.Exec(ctx, query, examIDs, students, scores, finishedAts)
@@ -174,6 +202,10 @@ func (w *ScoringWorker) bulkClearAutosavedAnswers(ctx context.Context, batch []*
 	for _, p := range batch {
 		key := config.CacheKey.StudentAnswersKey(p.ExamID, p.StudentID)
 		pipe.Del(ctx, key)
+		pipe.Del(ctx, config.CacheKey.StudentMediaPlaysKey(p.ExamID, p.StudentID))
+		pipe.Del(ctx, config.CacheKey.StudentNavPositionKey(p.ExamID, p.StudentID))
+		pipe.Del(ctx, config.CacheKey.StudentBreaksKey(p.ExamID, p.StudentID))
+		pipe.HDel(ctx, config.CacheKey.ExamCurrentQuestionsKey(p.ExamID), strconv.Itoa(p.StudentID))
 		// Clear active_exam so student is no longer session-locked
 		activeKey := config.CacheKey.StudentActiveExamKey(p.StudentID)
 		pipe.Del(ctx, activeKey)
@@ -197,7 +229,7 @@ func (w *ScoringWorker) persistSingle(ctx context.Context, p *scorePayload) erro
 		 SET status = 'COMPLETED',
 		     final_score = $1,
 		     finished_at = NOW()
-		 WHERE exam_id = $2 AND student_id = $3`,
+		 WHERE exam_id = $2 AND student_id = $3 AND status <> 'COMPLETED'`,
 		p.Score, eID, p.StudentID,
 	)
 
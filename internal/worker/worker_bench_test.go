@@ -0,0 +1,262 @@
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+// benchRows is the batch size of the flush benchmarks.
+const benchRows = 1000
+
+// benchPool connects to BENCH_DATABASE_URL, a migrated database, and shadows the
+// tables the workers write with empty temporary copies, so benchmarks neither need
+// seed data nor touch real rows. The pool holds a single connection, the one the
+// temporary tables live in.
+//
+// Without BENCH_DATABASE_URL the pool talks to an in-process server that acknowledges
+// every statement, so the benchmarks measure the worker's side of a flush: building
+// the batch, encoding it and the round trip. The committed baseline is recorded that way.
+func benchPool(b *testing.B, tables ...string) *pgxpool.Pool {
+	b.Helper()
+
+	url := os.Getenv("BENCH_DATABASE_URL")
+	if url == "" {
+		return fakePool(b)
+	}
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		b.Fatalf("parse BENCH_DATABASE_URL: %v", err)
+	}
+	cfg.MaxConns = 1
+
+	ctx := context.Background()
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		b.Fatalf("connect: %v", err)
+	}
+	b.Cleanup(pool.Close)
+
+	// Copies keep the columns, defaults and unique constraints but not the foreign keys.
+	for _, table := range tables {
+		if _, err := pool.Exec(ctx, `CREATE TEMP TABLE `+table+` (LIKE public.`+table+` INCLUDING ALL)`); err != nil {
+			b.Fatalf("shadow %s: %v", table, err)
+		}
+	}
+	return pool
+}
+
+// BenchmarkAutosaveBulkUpsert flushes a batch of answers, first as new rows, then
+// over the rows already stored.
+func BenchmarkAutosaveBulkUpsert(b *testing.B) {
+	pool := benchPool(b, "student_answers")
+	w := NewAutosaveWorker(pool, nil, nil, 0, zerolog.Nop())
+	ctx := context.Background()
+
+	examID := uuid.NewString()
+	batch := make([]*answerPayload, 0, benchRows)
+	for i := 0; i < benchRows; i++ {
+		batch = append(batch, &answerPayload{
+			StudentID: i/40 + 1,
+			ExamID:    examID,
+			QID:       uuid.NewString(),
+			Answer:    string(rune('A' + i%5)),
+		})
+	}
+
+	b.Run("insert", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			if _, err := pool.Exec(ctx, `TRUNCATE student_answers`); err != nil {
+				b.Fatalf("truncate: %v", err)
+			}
+			b.StartTimer()
+			if err := w.bulkUpsert(ctx, batch); err != nil {
+				b.Fatalf("bulkUpsert: %v", err)
+			}
+		}
+	})
+
+	b.Run("update", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := w.bulkUpsert(ctx, batch); err != nil {
+				b.Fatalf("bulkUpsert: %v", err)
+			}
+		}
+	})
+}
+
+// BenchmarkScoringBulkUpdate completes a batch of in-progress sessions with their scores.
+func BenchmarkScoringBulkUpdate(b *testing.B) {
+	pool := benchPool(b, "exam_sessions")
+	w := NewScoringWorker(pool, nil, nil, zerolog.Nop())
+	ctx := context.Background()
+
+	examID := uuid.NewString()
+	batch := make([]*scorePayload, 0, benchRows)
+	for i := 0; i < benchRows; i++ {
+		batch = append(batch, &scorePayload{StudentID: i + 1, ExamID: examID, Score: float64(i % 101)})
+	}
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO exam_sessions (exam_id, student_id)
+		SELECT $1::uuid, s FROM generate_series(1, $2::int) AS s`, examID, benchRows); err != nil {
+		b.Fatalf("seed sessions: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		if _, err := pool.Exec(ctx, `UPDATE exam_sessions SET status = 'IN_PROGRESS', final_score = NULL, finished_at = NULL`); err != nil {
+			b.Fatalf("reset sessions: %v", err)
+		}
+		b.StartTimer()
+		if err := w.bulkUpdateScores(ctx, batch); err != nil {
+			b.Fatalf("bulkUpdateScores: %v", err)
+		}
+	}
+}
+
+// fakePool returns a pool over net.Pipe to serveAckPostgres.
+func fakePool(b *testing.B) *pgxpool.Pool {
+	b.Helper()
+
+	cfg, err := pgxpool.ParseConfig("postgres://bench@fake/bench?sslmode=disable")
+	if err != nil {
+		b.Fatalf("parse pool config: %v", err)
+	}
+	cfg.MaxConns = 1
+	cfg.ConnConfig.LookupFunc = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	}
+	cfg.ConnConfig.DialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go serveAckPostgres(server)
+		return client, nil
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
+	if err != nil {
+		b.Fatalf("create pool: %v", err)
+	}
+	b.Cleanup(pool.Close)
+	return pool
+}
+
+// paramCast matches a cast parameter such as $1::uuid[].
+var paramCast = regexp.MustCompile(`\$(\d+)::(\w+(?:\[\])?)`)
+
+// paramOIDs are the types of the casts the benchmarked statements use.
+var paramOIDs = map[string]uint32{
+	"uuid": 2950, "uuid[]": 2951, "int": 23, "int[]": 1007,
+	"text[]": 1009, "float8[]": 1022, "timestamptz[]": 1185,
+}
+
+// serveAckPostgres speaks enough of the extended protocol for pgx to prepare and run
+// statements, and answers every statement as completed without running it. Parameter
+// types come from the casts in the statement, as the real server would infer them.
+func serveAckPostgres(conn net.Conn) {
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+	// The startup message has no type byte.
+	var size int32
+	if err := binary.Read(rd, binary.BigEndian, &size); err != nil {
+		return
+	}
+	if _, err := io.CopyN(io.Discard, rd, int64(size)-4); err != nil {
+		return
+	}
+	var out bytes.Buffer
+	writeMessage(&out, 'R', 0, 0, 0, 0)
+	writeMessage(&out, 'S', []byte("standard_conforming_strings\x00on\x00")...)
+	writeMessage(&out, 'S', []byte("client_encoding\x00UTF8\x00")...)
+	writeMessage(&out, 'K', 0, 0, 0, 1, 0, 0, 0, 1)
+	writeMessage(&out, 'Z', 'I')
+	if _, err := conn.Write(out.Bytes()); err != nil {
+		return
+	}
+	out.Reset()
+
+	statements := make(map[string]string)
+	for {
+		kind, err := rd.ReadByte()
+		if err != nil {
+			return
+		}
+		if err := binary.Read(rd, binary.BigEndian, &size); err != nil {
+			return
+		}
+		body := make([]byte, size-4)
+		if _, err := io.ReadFull(rd, body); err != nil {
+			return
+		}
+
+		// Replies are held until Sync: the client writes a whole exchange before reading.
+		switch kind {
+		case 'X':
+			return
+		case 'P':
+			fields := bytes.SplitN(body, []byte{0}, 3)
+			statements[string(fields[0])] = string(fields[1])
+			writeMessage(&out, '1')
+		case 'D':
+			if body[0] == 'S' {
+				writeMessage(&out, 't', paramDescription(statements[string(bytes.TrimRight(body[1:], "\x00"))])...)
+			}
+			writeMessage(&out, 'n')
+		case 'B':
+			writeMessage(&out, '2')
+		case 'E':
+			writeMessage(&out, 'C', []byte("OK\x00")...)
+		case 'C':
+			writeMessage(&out, '3')
+		case 'Q':
+			writeMessage(&out, 'C', []byte("OK\x00")...)
+			fallthrough
+		case 'S':
+			writeMessage(&out, 'Z', 'I')
+			if _, err := conn.Write(out.Bytes()); err != nil {
+				return
+			}
+			out.Reset()
+		}
+	}
+}
+
+// paramDescription describes the parameters of query, typing uncast ones as text.
+func paramDescription(query string) []byte {
+	var oids []uint32
+	for _, m := range paramCast.FindAllStringSubmatch(query, -1) {
+		n, _ := strconv.Atoi(m[1])
+		for len(oids) < n {
+			oids = append(oids, 25)
+		}
+		if oid, ok := paramOIDs[m[2]]; ok {
+			oids[n-1] = oid
+		}
+	}
+	desc := binary.BigEndian.AppendUint16(nil, uint16(len(oids)))
+	for _, oid := range oids {
+		desc = binary.BigEndian.AppendUint32(desc, oid)
+	}
+	return desc
+}
+
+func writeMessage(out *bytes.Buffer, kind byte, body ...byte) {
+	out.WriteByte(kind)
+	out.Write(binary.BigEndian.AppendUint32(nil, uint32(len(body)+4)))
+	out.Write(body)
+}
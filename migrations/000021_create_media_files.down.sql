@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE code IN ('media:read', 'media:delete');
+DROP INDEX IF EXISTS idx_media_files_created_at;
+DROP TABLE IF EXISTS media_files;
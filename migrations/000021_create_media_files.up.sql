@@ -0,0 +1,26 @@
+-- Create media_files table to track uploaded media
+CREATE TABLE IF NOT EXISTS media_files (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    filename VARCHAR(255) NOT NULL UNIQUE,
+    original_name VARCHAR(255) NOT NULL DEFAULT '',
+    url VARCHAR(500) NOT NULL UNIQUE,
+    mime_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    uploaded_by INT REFERENCES admins(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_files_created_at ON media_files(created_at);
+
+-- Seed media management permissions
+INSERT INTO permissions (code, description) VALUES
+    ('media:read', 'View the media library'),
+    ('media:delete', 'Delete media files')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Superadmin'
+  AND p.code IN ('media:read', 'media:delete')
+ON CONFLICT DO NOTHING;
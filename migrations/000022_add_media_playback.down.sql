@@ -0,0 +1,2 @@
+ALTER TABLE questions DROP COLUMN IF EXISTS max_plays;
+ALTER TABLE media_files DROP COLUMN IF EXISTS duration_seconds;
@@ -0,0 +1,5 @@
+-- Track duration for audio/video uploads
+ALTER TABLE media_files ADD COLUMN IF NOT EXISTS duration_seconds INT;
+
+-- Per-question playback limit for attached audio/video (0 = unlimited)
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS max_plays INT NOT NULL DEFAULT 0;
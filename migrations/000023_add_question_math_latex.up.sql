@@ -0,0 +1,2 @@
+-- LaTeX math source rendered alongside question_text (validated server-side)
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS math_latex TEXT NOT NULL DEFAULT '';
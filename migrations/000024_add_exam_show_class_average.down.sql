@@ -0,0 +1 @@
+ALTER TABLE exams DROP COLUMN IF EXISTS show_class_average;
@@ -0,0 +1,2 @@
+-- Whether students may see their class average on the result page
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS show_class_average BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+DELETE FROM app_settings WHERE key = 'exam_conflict_policy';
@@ -0,0 +1,4 @@
+-- How schedule conflicts between exams sharing a class are handled: 'warn' or 'block'
+INSERT INTO app_settings (key, value) VALUES
+    ('exam_conflict_policy', 'warn')
+ON CONFLICT (key) DO NOTHING;
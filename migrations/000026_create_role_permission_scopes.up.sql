@@ -0,0 +1,9 @@
+-- Limit a role's permission to certain subjects. A permission without rows here
+-- applies to every subject.
+CREATE TABLE IF NOT EXISTS role_permission_scopes (
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_code VARCHAR(100) NOT NULL REFERENCES permissions(code) ON DELETE CASCADE,
+    -- No cascade: deleting the last scoped subject must not widen the permission.
+    subject_id INT NOT NULL REFERENCES subjects(id),
+    PRIMARY KEY (role_id, permission_code, subject_id)
+);
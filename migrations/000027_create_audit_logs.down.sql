@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE code = 'students:impersonate';
+DROP INDEX IF EXISTS idx_audit_logs_admin_id;
+DROP INDEX IF EXISTS idx_audit_logs_created_at;
+DROP TABLE IF EXISTS audit_logs;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_students_directory_source;
+ALTER TABLE students
+    DROP COLUMN IF EXISTS directory_source,
+    DROP COLUMN IF EXISTS is_active;
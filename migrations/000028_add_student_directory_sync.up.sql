@@ -0,0 +1,7 @@
+-- Students pulled from the school directory are tagged with their source, and are
+-- deactivated rather than deleted once they disappear from it.
+ALTER TABLE students
+    ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN IF NOT EXISTS directory_source VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_students_directory_source ON students(directory_source);
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE code = 'erapor:export';
+DROP INDEX IF EXISTS idx_exam_erapor_mappings_subject;
+DROP TABLE IF EXISTS exam_erapor_mappings;
@@ -0,0 +1,22 @@
+-- Maps an exam onto the e-Rapor subject (mata pelajaran) and competency (KD) its score reports.
+CREATE TABLE IF NOT EXISTS exam_erapor_mappings (
+    exam_id UUID PRIMARY KEY REFERENCES exams(id) ON DELETE CASCADE,
+    subject_code VARCHAR(50) NOT NULL,
+    competency_code VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_exam_erapor_mappings_subject ON exam_erapor_mappings(subject_code);
+
+-- Seed e-Rapor export permission
+INSERT INTO permissions (code, description) VALUES
+    ('erapor:export', 'Export and push exam results to e-Rapor')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Superadmin'
+  AND p.code = 'erapor:export'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,12 @@
+-- Per-exam proctor notifications over Telegram and/or WhatsApp.
+CREATE TABLE IF NOT EXISTS exam_notification_settings (
+    exam_id UUID PRIMARY KEY REFERENCES exams(id) ON DELETE CASCADE,
+    telegram_chat_ids TEXT[] NOT NULL DEFAULT '{}',
+    whatsapp_numbers TEXT[] NOT NULL DEFAULT '{}',
+    notify_exam_start BOOLEAN NOT NULL DEFAULT TRUE,
+    -- Cheat events per student that trigger an alert; 0 disables cheat alerts.
+    cheat_threshold INT NOT NULL DEFAULT 0 CHECK (cheat_threshold >= 0),
+    notify_queue_backlog BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
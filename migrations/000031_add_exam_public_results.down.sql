@@ -0,0 +1,2 @@
+ALTER TABLE exams DROP COLUMN IF EXISTS results_access_code;
+ALTER TABLE exams DROP COLUMN IF EXISTS public_results;
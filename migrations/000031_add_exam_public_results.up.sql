@@ -0,0 +1,4 @@
+-- Whether students/parents may look up this exam's results on the public page,
+-- and the per-exam code they must present alongside the student's NISN.
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS public_results BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS results_access_code VARCHAR(20) NOT NULL DEFAULT '';
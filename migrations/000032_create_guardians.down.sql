@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE code IN ('guardians:read', 'guardians:write');
+DROP INDEX IF EXISTS idx_guardian_students_student_id;
+DROP TABLE IF EXISTS guardian_students;
+DROP TABLE IF EXISTS guardians;
@@ -0,0 +1,38 @@
+-- Parents/guardians: a third principal type with read-only access to their children.
+CREATE TABLE IF NOT EXISTS guardians (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) UNIQUE,
+    phone VARCHAR(20) UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    -- Notification preferences. WhatsApp messages go to phone.
+    notify_channel VARCHAR(10) NOT NULL DEFAULT 'none' CHECK (notify_channel IN ('none', 'telegram', 'whatsapp')),
+    telegram_chat_id VARCHAR(64) NOT NULL DEFAULT '',
+    notify_results BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (email IS NOT NULL OR phone IS NOT NULL)
+);
+
+CREATE TABLE IF NOT EXISTS guardian_students (
+    guardian_id INT NOT NULL REFERENCES guardians(id) ON DELETE CASCADE,
+    student_id INT NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    relationship VARCHAR(30) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (guardian_id, student_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_guardian_students_student_id ON guardian_students(student_id);
+
+-- Seed guardian management permissions
+INSERT INTO permissions (code, description) VALUES
+    ('guardians:read', 'View guardian accounts'),
+    ('guardians:write', 'Create, update and delete guardian accounts and their student links')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Superadmin'
+  AND p.code IN ('guardians:read', 'guardians:write')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+ALTER TABLE exams DROP COLUMN IF EXISTS section_size;
+ALTER TABLE exams DROP COLUMN IF EXISTS navigation_policy;
@@ -0,0 +1,6 @@
+-- How students may move between questions: FREE (any order), LINEAR (never back)
+-- or SECTION_LOCKED (freely within a block of section_size questions, never back
+-- into an earlier block).
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS navigation_policy VARCHAR(20) NOT NULL DEFAULT 'FREE'
+    CHECK (navigation_policy IN ('FREE', 'LINEAR', 'SECTION_LOCKED'));
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS section_size INT NOT NULL DEFAULT 0 CHECK (section_size >= 0);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS exam_breaks;
+ALTER TABLE exams DROP COLUMN IF EXISTS break_minutes;
+ALTER TABLE exams DROP COLUMN IF EXISTS max_breaks;
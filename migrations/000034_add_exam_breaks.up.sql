@@ -0,0 +1,16 @@
+-- Student-initiated breaks: up to max_breaks per session, each pausing the student's
+-- timer for at most break_minutes. max_breaks = 0 disables breaks.
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS max_breaks INT NOT NULL DEFAULT 0 CHECK (max_breaks >= 0);
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS break_minutes INT NOT NULL DEFAULT 0 CHECK (break_minutes >= 0);
+
+-- One row per break taken. ended_at stays NULL while the break runs, and also when a
+-- break ran out without the student resuming.
+CREATE TABLE IF NOT EXISTS exam_breaks (
+    id SERIAL PRIMARY KEY,
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    student_id INT NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_exam_breaks_exam_student ON exam_breaks(exam_id, student_id);
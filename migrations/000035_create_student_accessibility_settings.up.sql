@@ -0,0 +1,9 @@
+-- Per-student accessibility preferences for the exam UI. Students without a row use
+-- the defaults.
+CREATE TABLE IF NOT EXISTS student_accessibility_settings (
+    student_id INT PRIMARY KEY REFERENCES students(id) ON DELETE CASCADE,
+    font_size VARCHAR(10) NOT NULL DEFAULT 'NORMAL' CHECK (font_size IN ('NORMAL', 'LARGE', 'XLARGE')),
+    high_contrast BOOLEAN NOT NULL DEFAULT FALSE,
+    screen_reader BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
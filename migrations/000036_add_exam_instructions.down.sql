@@ -0,0 +1,3 @@
+ALTER TABLE exam_sessions DROP COLUMN IF EXISTS consent_acknowledged_at;
+ALTER TABLE exams DROP COLUMN IF EXISTS honor_code;
+ALTER TABLE exams DROP COLUMN IF EXISTS instructions;
@@ -0,0 +1,6 @@
+-- Per-exam instructions (Markdown) shown before joining, and an optional honor code
+-- students must acknowledge to join. An empty honor_code requires no consent.
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS instructions TEXT NOT NULL DEFAULT '';
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS honor_code TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE exam_sessions ADD COLUMN IF NOT EXISTS consent_acknowledged_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,2 @@
+ALTER TABLE questions DROP COLUMN IF EXISTS passage_id;
+DROP TABLE IF EXISTS question_passages;
@@ -0,0 +1,13 @@
+-- Reading passages (stimuli) shared by several questions of a question bank.
+CREATE TABLE IF NOT EXISTS question_passages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    qbank_id UUID NOT NULL REFERENCES question_banks(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL DEFAULT '',
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_question_passages_qbank_id ON question_passages(qbank_id);
+
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS passage_id UUID REFERENCES question_passages(id) ON DELETE SET NULL;
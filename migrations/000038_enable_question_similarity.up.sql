@@ -0,0 +1,2 @@
+-- Trigram similarity for near-duplicate question detection within a question bank.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
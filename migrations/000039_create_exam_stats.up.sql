@@ -0,0 +1,24 @@
+-- Per-exam aggregates of exam_sessions, kept up to date by the stats worker so
+-- dashboards do not recompute them on every request.
+CREATE TABLE IF NOT EXISTS exam_stats (
+    exam_id UUID PRIMARY KEY REFERENCES exams(id) ON DELETE CASCADE,
+    participants INT NOT NULL DEFAULT 0,
+    completed INT NOT NULL DEFAULT 0,
+    average_score DOUBLE PRECISION,
+    median_score DOUBLE PRECISION,
+    stddev_score DOUBLE PRECISION,
+    completion_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO exam_stats (exam_id, participants, completed, average_score, median_score, stddev_score, completion_rate)
+SELECT s.exam_id,
+       COUNT(*),
+       COUNT(*) FILTER (WHERE s.status = 'COMPLETED'),
+       AVG(s.final_score) FILTER (WHERE s.status = 'COMPLETED'),
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY s.final_score) FILTER (WHERE s.status = 'COMPLETED'),
+       stddev_pop(s.final_score) FILTER (WHERE s.status = 'COMPLETED'),
+       COUNT(*) FILTER (WHERE s.status = 'COMPLETED')::float8 / COUNT(*)
+FROM exam_sessions s
+GROUP BY s.exam_id
+ON CONFLICT (exam_id) DO NOTHING;
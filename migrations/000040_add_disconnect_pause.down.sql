@@ -0,0 +1,2 @@
+ALTER TABLE exam_sessions DROP COLUMN IF EXISTS disconnect_paused_seconds;
+ALTER TABLE exams DROP COLUMN IF EXISTS disconnect_pause_minutes;
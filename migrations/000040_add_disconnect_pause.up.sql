@@ -0,0 +1,6 @@
+-- A student disconnected for longer than disconnect_pause_minutes has their timer
+-- paused from then until they reconnect. 0 disables the pause.
+ALTER TABLE exams ADD COLUMN IF NOT EXISTS disconnect_pause_minutes INT NOT NULL DEFAULT 0 CHECK (disconnect_pause_minutes >= 0);
+
+-- Seconds the session's timer was paused for prolonged disconnects.
+ALTER TABLE exam_sessions ADD COLUMN IF NOT EXISTS disconnect_paused_seconds INT NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- Recording of an exam's live monitor stream (joins, submits, cheat events, progress
+-- snapshots, ...) so coordinators can replay how the exam unfolded.
+CREATE TABLE IF NOT EXISTS exam_monitor_events (
+    id BIGSERIAL PRIMARY KEY,
+    exam_id UUID NOT NULL REFERENCES exams(id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_exam_monitor_events_exam_time ON exam_monitor_events (exam_id, recorded_at, id);
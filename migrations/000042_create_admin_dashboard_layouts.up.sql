@@ -0,0 +1,6 @@
+-- Per-admin dashboard layouts. Admins without a row get the default layout.
+CREATE TABLE IF NOT EXISTS admin_dashboard_layouts (
+    admin_id INT PRIMARY KEY REFERENCES admins(id) ON DELETE CASCADE,
+    widgets JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
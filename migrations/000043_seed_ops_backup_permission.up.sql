@@ -0,0 +1,11 @@
+-- Seed the database backup permission
+INSERT INTO permissions (code, description) VALUES
+    ('ops:backup', 'Create, list and restore database backups')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Superadmin'
+  AND p.code = 'ops:backup'
+ON CONFLICT DO NOTHING;
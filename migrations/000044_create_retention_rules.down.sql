@@ -0,0 +1,4 @@
+DELETE FROM student_answers WHERE student_id IS NULL;
+ALTER TABLE student_answers ALTER COLUMN student_id SET NOT NULL;
+
+DROP TABLE IF EXISTS retention_rules;
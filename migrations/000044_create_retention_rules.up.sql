@@ -0,0 +1,14 @@
+-- Data retention rules, applied nightly by the retention worker.
+CREATE TABLE IF NOT EXISTS retention_rules (
+    id SERIAL PRIMARY KEY,
+    target VARCHAR(50) NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('delete', 'anonymize')),
+    after_days INT NOT NULL CHECK (after_days > 0),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (target, action)
+);
+
+-- Anonymized answers are detached from their student but kept for question statistics.
+ALTER TABLE student_answers ALTER COLUMN student_id DROP NOT NULL;
@@ -0,0 +1,9 @@
+-- Teachers assigned to a subject. Exams created outside them draw a warning.
+CREATE TABLE IF NOT EXISTS subject_teachers (
+    subject_id INT NOT NULL REFERENCES subjects(id) ON DELETE CASCADE,
+    admin_id INT NOT NULL REFERENCES admins(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (subject_id, admin_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subject_teachers_admin ON subject_teachers (admin_id);
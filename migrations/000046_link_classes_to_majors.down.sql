@@ -0,0 +1 @@
+ALTER TABLE classes DROP CONSTRAINT IF EXISTS fk_classes_major_code;
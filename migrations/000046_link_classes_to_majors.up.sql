@@ -0,0 +1,9 @@
+-- Backfill a major for every code classes already use, named after the code
+-- until an admin renames it, then tie classes to the majors table.
+INSERT INTO majors (code, long_name)
+SELECT DISTINCT major_code, major_code FROM classes
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE classes
+    ADD CONSTRAINT fk_classes_major_code FOREIGN KEY (major_code)
+    REFERENCES majors(code) ON UPDATE CASCADE ON DELETE RESTRICT;
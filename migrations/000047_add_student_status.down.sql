@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_students_status;
+ALTER TABLE students DROP COLUMN IF EXISTS is_active;
+ALTER TABLE students ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE;
+UPDATE students SET is_active = (status = 'active');
+ALTER TABLE students
+    DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,14 @@
+-- Students move through a lifecycle instead of only being active or not. Students
+-- deactivated by the directory sync left the school, so they count as transferred.
+-- is_active now follows the status.
+ALTER TABLE students
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'transferred', 'graduated', 'suspended')),
+    ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMPTZ;
+
+UPDATE students SET status = 'transferred', status_changed_at = updated_at WHERE NOT is_active;
+
+ALTER TABLE students DROP COLUMN is_active;
+ALTER TABLE students ADD COLUMN is_active BOOLEAN GENERATED ALWAYS AS (status = 'active') STORED;
+
+CREATE INDEX IF NOT EXISTS idx_students_status ON students(status);
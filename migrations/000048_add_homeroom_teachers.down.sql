@@ -0,0 +1,2 @@
+ALTER TABLE exam_notification_settings DROP COLUMN IF EXISTS notify_homeroom_summary;
+ALTER TABLE classes DROP COLUMN IF EXISTS homeroom_admin_id;
@@ -0,0 +1,7 @@
+-- Each class may have a homeroom teacher, who can be emailed a summary of the
+-- class's results once an exam completes.
+ALTER TABLE classes
+    ADD COLUMN IF NOT EXISTS homeroom_admin_id INT REFERENCES admins(id) ON DELETE SET NULL;
+
+ALTER TABLE exam_notification_settings
+    ADD COLUMN IF NOT EXISTS notify_homeroom_summary BOOLEAN NOT NULL DEFAULT FALSE;
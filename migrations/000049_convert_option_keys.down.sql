@@ -0,0 +1,14 @@
+-- Keyed options go back to plain strings in their stored order. Correct options and
+-- answers keep their letters, which plain options accept as well. Option media is lost.
+UPDATE questions q
+SET options = (
+        SELECT jsonb_agg(COALESCE(e.value -> 'text', '""') ORDER BY e.ord)
+        FROM jsonb_array_elements(q.options) WITH ORDINALITY AS e(value, ord)
+    )
+WHERE q.question_type = 'MULTIPLE_CHOICE'
+  AND jsonb_typeof(q.options) = 'array'
+  AND jsonb_array_length(q.options) > 0
+  AND jsonb_typeof(q.options -> 0) = 'object';
+
+UPDATE questions SET correct_option = left(correct_option, 5) WHERE length(correct_option) > 5;
+ALTER TABLE questions ALTER COLUMN correct_option TYPE VARCHAR(5);
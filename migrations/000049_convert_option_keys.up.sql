@@ -0,0 +1,33 @@
+-- Multiple-choice options become keyed objects so answers no longer depend on an
+-- option's position. Plain string options are keyed A, B, C… in their current order;
+-- index-style correct options and saved answers ("0", "1", …) become the matching
+-- letter. Everything else is upper-cased, as the service stores keys. Answers are
+-- converted first, while their questions still have plain options.
+ALTER TABLE questions ALTER COLUMN correct_option TYPE VARCHAR(10);
+
+UPDATE student_answers sa
+SET answer = CASE
+        WHEN sa.answer ~ '^[0-9]+$' AND sa.answer::int < jsonb_array_length(q.options) THEN chr(65 + sa.answer::int)
+        ELSE upper(sa.answer)
+    END
+FROM questions q
+WHERE sa.question_id = q.id
+  AND q.question_type = 'MULTIPLE_CHOICE'
+  AND jsonb_typeof(q.options) = 'array'
+  AND jsonb_array_length(q.options) BETWEEN 1 AND 26
+  AND NOT EXISTS (SELECT 1 FROM jsonb_array_elements(q.options) e WHERE jsonb_typeof(e) <> 'string');
+
+UPDATE questions q
+SET options = (
+        SELECT jsonb_agg(jsonb_build_object('key', chr(64 + e.ord::int), 'text', e.value) ORDER BY e.ord)
+        FROM jsonb_array_elements_text(q.options) WITH ORDINALITY AS e(value, ord)
+    ),
+    correct_option = CASE
+        WHEN trim(q.correct_option) ~ '^[0-9]+$' AND trim(q.correct_option)::int < jsonb_array_length(q.options)
+            THEN chr(65 + trim(q.correct_option)::int)
+        ELSE upper(trim(q.correct_option))
+    END
+WHERE q.question_type = 'MULTIPLE_CHOICE'
+  AND jsonb_typeof(q.options) = 'array'
+  AND jsonb_array_length(q.options) BETWEEN 1 AND 26
+  AND NOT EXISTS (SELECT 1 FROM jsonb_array_elements(q.options) e WHERE jsonb_typeof(e) <> 'string');
@@ -0,0 +1 @@
+DELETE FROM app_settings WHERE key = 'monitor_item_stats';
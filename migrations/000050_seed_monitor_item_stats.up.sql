@@ -0,0 +1,4 @@
+-- Whether the exam monitor streams live per-question answer distributions: 'true' or 'false'
+INSERT INTO app_settings (key, value) VALUES
+    ('monitor_item_stats', 'false')
+ON CONFLICT (key) DO NOTHING;
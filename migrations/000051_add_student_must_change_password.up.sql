@@ -0,0 +1,4 @@
+-- Students given issued credentials must pick their own password at first login.
+-- Issued and self-chosen passwords are stored as bcrypt hashes.
+ALTER TABLE students
+    ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE exam_sessions DROP COLUMN IF EXISTS offline_entry;
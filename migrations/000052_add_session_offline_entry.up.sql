@@ -0,0 +1,4 @@
+-- Sessions entered by hand from a paper exam held when the online exam could not
+-- run. They are COMPLETED with a score but have no answers.
+ALTER TABLE exam_sessions
+    ADD COLUMN IF NOT EXISTS offline_entry BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+-- Fails while encrypted values remain; run encrypt-pii -decrypt first.
+ALTER TABLE exam_target_rules ALTER COLUMN religion TYPE VARCHAR(50);
+ALTER TABLE students ALTER COLUMN religion TYPE VARCHAR(50);
@@ -0,0 +1,4 @@
+-- Religion is stored encrypted when PII_ENCRYPTION_KEY is set; ciphertexts outgrow
+-- VARCHAR(50).
+ALTER TABLE students ALTER COLUMN religion TYPE TEXT;
+ALTER TABLE exam_target_rules ALTER COLUMN religion TYPE TEXT;
@@ -0,0 +1 @@
+ALTER TABLE exam_notification_settings DROP COLUMN IF EXISTS notify_login_anomaly;
@@ -0,0 +1,4 @@
+-- Proctors of upcoming and running exams can be alerted to login anomalies, such
+-- as one IP failing logins to many accounts.
+ALTER TABLE exam_notification_settings
+    ADD COLUMN IF NOT EXISTS notify_login_anomaly BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,20 @@
+-- Seed the read-only live monitor permission. Roles that could monitor exams through
+-- exams:write keep doing so.
+INSERT INTO permissions (code, description) VALUES
+    ('monitor:read', 'Watch the live exam monitor and room assignments without editing exams')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT rp.role_id, p.id
+FROM role_permissions rp
+JOIN permissions w ON w.id = rp.permission_id AND w.code = 'exams:write'
+CROSS JOIN permissions p
+WHERE p.code = 'monitor:read'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'Superadmin'
+  AND p.code = 'monitor:read'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_exams_metadata;
+ALTER TABLE exams DROP COLUMN IF EXISTS metadata;
@@ -0,0 +1,7 @@
+-- Exams carry school-defined metadata, such as curriculum codes, semester and KD
+-- identifiers, as a JSON object. Its schema is the exam_metadata_schema app setting.
+ALTER TABLE exams
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+-- Exam lists are filtered by containment (metadata @> '{"semester": "2"}').
+CREATE INDEX IF NOT EXISTS idx_exams_metadata ON exams USING GIN (metadata jsonb_path_ops);
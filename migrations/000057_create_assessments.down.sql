@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_exams_assessment_id;
+ALTER TABLE exams DROP COLUMN IF EXISTS assessment_id;
+DROP TABLE IF EXISTS assessments;
@@ -0,0 +1,17 @@
+-- An assessment groups exams given together, such as one exam per subject in final
+-- exams week, for combined reporting, shared target rules and a lobby section.
+CREATE TABLE IF NOT EXISTS assessments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    -- The target rules applied to every exam of the assessment, as a JSON list.
+    target_rules JSONB NOT NULL DEFAULT '[]',
+    author_id INT REFERENCES admins(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE exams
+    ADD COLUMN IF NOT EXISTS assessment_id UUID REFERENCES assessments(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_exams_assessment_id ON exams(assessment_id);
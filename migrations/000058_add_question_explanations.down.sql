@@ -0,0 +1,2 @@
+ALTER TABLE exams DROP COLUMN IF EXISTS allow_review;
+ALTER TABLE questions DROP COLUMN IF EXISTS explanation;
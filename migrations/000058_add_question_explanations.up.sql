@@ -0,0 +1,8 @@
+-- The explanation of a question's answer, shown to students when they review the
+-- exam after it, never during it.
+ALTER TABLE questions
+    ADD COLUMN IF NOT EXISTS explanation TEXT NOT NULL DEFAULT '';
+
+-- Whether students may review their answers against the answer key after the exam.
+ALTER TABLE exams
+    ADD COLUMN IF NOT EXISTS allow_review BOOLEAN NOT NULL DEFAULT FALSE;
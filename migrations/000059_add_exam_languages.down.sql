@@ -0,0 +1,5 @@
+ALTER TABLE exams
+    DROP COLUMN IF EXISTS forced_language,
+    DROP COLUMN IF EXISTS languages,
+    DROP COLUMN IF EXISTS language;
+ALTER TABLE questions DROP COLUMN IF EXISTS translations;
@@ -0,0 +1,11 @@
+-- Questions may carry their text and options in other languages, as a JSON object
+-- keyed by language code: {"en": {"question_text": "...", "options": {"A": "..."}}}.
+ALTER TABLE questions
+    ADD COLUMN IF NOT EXISTS translations JSONB NOT NULL DEFAULT '{}';
+
+-- language is the language questions are written in; languages are the translations
+-- students may choose from; forced_language, when set, is served to every student.
+ALTER TABLE exams
+    ADD COLUMN IF NOT EXISTS language VARCHAR(8) NOT NULL DEFAULT 'id',
+    ADD COLUMN IF NOT EXISTS languages TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS forced_language VARCHAR(8) NOT NULL DEFAULT '';
@@ -0,0 +1,24 @@
+-- Sampled system metrics, one row per instance per sampling interval, so admins can
+-- look back at how the servers held up during past exams. Samples older than the
+-- retention are pruned by the sampler, keeping the table a ring buffer.
+CREATE TABLE IF NOT EXISTS system_metrics_samples (
+    id BIGSERIAL PRIMARY KEY,
+    instance TEXT NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL,
+    cpu_percent DOUBLE PRECISION NOT NULL,
+    mem_percent DOUBLE PRECISION NOT NULL,
+    mem_used_bytes BIGINT NOT NULL,
+    disk_percent DOUBLE PRECISION NOT NULL,
+    load_avg_1 DOUBLE PRECISION NOT NULL,
+    goroutines INT NOT NULL,
+    heap_alloc BIGINT NOT NULL,
+    db_acquired_conns INT NOT NULL,
+    ws_connections INT NOT NULL,
+    queue_answers BIGINT NOT NULL,
+    queue_cheats BIGINT NOT NULL,
+    queue_scores BIGINT NOT NULL,
+    queue_monitor_events BIGINT NOT NULL,
+    redis_degraded BOOLEAN NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_system_metrics_samples_time ON system_metrics_samples (recorded_at);
@@ -0,0 +1,3 @@
+ALTER TABLE media_files
+    DROP COLUMN IF EXISTS reused_by,
+    DROP COLUMN IF EXISTS reused_at;
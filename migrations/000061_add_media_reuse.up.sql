@@ -0,0 +1,5 @@
+-- An upload of content already in the library reuses its file. reused_at restarts the
+-- orphan grace period without touching created_at; reused_by is who uploaded it again.
+ALTER TABLE media_files
+    ADD COLUMN IF NOT EXISTS reused_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS reused_by INT REFERENCES admins(id) ON DELETE SET NULL;
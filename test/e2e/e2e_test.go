@@ -14,8 +14,7 @@ import (
 	"testing"
 	"time"
 
-	"strconv"
-
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/joho/godotenv"
 	"github.com/stemsi/exstem-backend/internal/model"
@@ -28,7 +27,7 @@ const (
 	adminEmail     = "e2e_admin@example.com"
 	adminPass      = "password123"
 	studentNISN    = "e2e_student"
-	studentPass    = "password123"
+	studentPass    = "e2e-Student1"
 	studentName    = "E2E Student"
 )
 
@@ -38,6 +37,7 @@ var (
 	initialClassID int
 	adminToken     string
 	studentToken   string
+	qbankID        uuid.UUID
 	examID         string
 )
 
@@ -77,7 +77,7 @@ func setupInitialAdmin() error {
 	defer conn.Close(ctx)
 
 	// Cleanup previous test data (order matters due to FK)
-	tables := []string{"student_answers", "exam_sessions", "questions", "exam_target_rules", "exams", "students", "classes", "admins"}
+	tables := []string{"student_answers", "exam_sessions", "questions", "exam_target_rules", "exams", "question_banks", "students", "classes", "admins"}
 	for _, table := range tables {
 		if _, err := conn.Exec(ctx, fmt.Sprintf("DELETE FROM %s", table)); err != nil {
 			return fmt.Errorf("cleanup %s: %w", table, err)
@@ -238,16 +238,58 @@ func TestE2EFlow(t *testing.T) {
 		t.Logf("Student Token received")
 	})
 
-	// Step 4: Create Exam (Admin)
+	// Step 4: Create Question Bank with a question (Admin)
+	t.Run("CreateQuestionBank", func(t *testing.T) {
+		resp, err := post("/admin/qbanks", model.CreateQuestionBankRequest{Name: "E2E Question Bank"}, adminToken)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("status %d: %s", resp.StatusCode, readBody(resp))
+		}
+
+		var body struct {
+			Data struct {
+				ID uuid.UUID `json:"id"`
+			} `json:"data"`
+		}
+		decodeJSON(t, resp, &body)
+		qbankID = body.Data.ID
+
+		optionsJSON, _ := json.Marshal([]string{"3", "4", "5", "6"})
+		reqBody := model.AddQuestionRequest{
+			QuestionText:  "What is 2+2?",
+			QuestionType:  "MULTIPLE_CHOICE",
+			Options:       json.RawMessage(optionsJSON),
+			CorrectOption: "1", // Index 1 -> "4"
+			OrderNum:      1,
+		}
+		respQ, err := post(fmt.Sprintf("/admin/qbanks/%s/questions", qbankID), reqBody, adminToken)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer respQ.Body.Close()
+
+		if respQ.StatusCode != http.StatusCreated {
+			t.Fatalf("status %d: %s", respQ.StatusCode, readBody(respQ))
+		}
+		t.Logf("Question Bank Created: %s", qbankID)
+	})
+
+	// Step 5: Create Exam (Admin)
 	t.Run("CreateExam", func(t *testing.T) {
-		start := time.Now().Add(1 * time.Hour)
-		end := start.Add(2 * time.Hour)
+		// Already started, so the student can join and open the exam socket.
+		start := model.LocalTime(time.Now().Add(-5 * time.Minute))
+		end := model.LocalTime(time.Time(start).Add(2 * time.Hour))
 		reqBody := model.CreateExamRequest{
 			Title:           "E2E Test Exam",
 			ScheduledStart:  &start,
 			ScheduledEnd:    &end,
 			DurationMinutes: 60,
 			EntryToken:      "TOKEN123",
+			QBankID:         &qbankID,
 		}
 		resp, err := post("/admin/exams", reqBody, adminToken)
 		if err != nil {
@@ -272,11 +314,10 @@ func TestE2EFlow(t *testing.T) {
 		t.Logf("Exam Created: %s", examID)
 	})
 
-	// Step 5: Add Target Rule (Admin)
+	// Step 6: Add Target Rule (Admin)
 	t.Run("AddTargetRule", func(t *testing.T) {
 		reqBody := model.AddTargetRuleRequest{
-			TargetType:  "CLASS",
-			TargetValue: strconv.Itoa(initialClassID),
+			ClassID: &initialClassID,
 		}
 		resp, err := post(fmt.Sprintf("/admin/exams/%s/target-rules", examID), reqBody, adminToken)
 		if err != nil {
@@ -290,35 +331,6 @@ func TestE2EFlow(t *testing.T) {
 		t.Logf("Target Rule Added")
 	})
 
-	// Step 6: Add Question (Admin)
-	t.Run("AddQuestion", func(t *testing.T) {
-		optionsJSON, _ := json.Marshal([]string{"3", "4", "5", "6"})
-		reqBody := model.AddQuestionRequest{
-			QuestionText:  "What is 2+2?",
-			QuestionType:  "MULTIPLE_CHOICE",
-			Options:       json.RawMessage(optionsJSON),
-			CorrectOption: "1", // Index 1 -> "4"
-			OrderNum:      1,
-			ScoreValue:    10,
-		}
-		// Note: Question model options structure depends on implementation.
-		// model.Question has Options json.RawMessage.
-		// AddQuestionRequest defines Options as json.RawMessage too?
-		// Let's check model.Question.
-		// Assuming simple array works if validated.
-
-		resp, err := post(fmt.Sprintf("/admin/exams/%s/questions", examID), reqBody, adminToken)
-		if err != nil {
-			t.Fatalf("request failed: %v", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusCreated {
-			t.Fatalf("status %d: %s", resp.StatusCode, readBody(resp))
-		}
-		t.Logf("Question Added")
-	})
-
 	// Step 7: Publish Exam (Admin)
 	t.Run("PublishExam", func(t *testing.T) {
 		resp, err := post(fmt.Sprintf("/admin/exams/%s/publish", examID), nil, adminToken)